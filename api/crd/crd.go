@@ -0,0 +1,11 @@
+// Package crd embeds the CustomResourceDefinition manifests of this
+// directory, for the binaries to install them (see k8s.Client.EnsureCRD)
+package crd
+
+import _ "embed"
+
+// UAVMetrics is the UAVMetrics CustomResourceDefinition, with its OpenAPI
+// schema
+//
+//go:embed uav-metrics-crd.yaml
+var UAVMetrics []byte
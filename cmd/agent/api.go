@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/recorder"
+)
+
+// serveAPI serves the latest collected metrics to on-board applications
+// until ctx is cancelled:
+//
+//	GET /api/v1/metrics            metrics of the vehicle (node=<name> selects
+//	                               one when the agent proxies several)
+//	GET /api/v1/history            recent samples, oldest first (since=<RFC
+//	                               3339 time or duration like 10m>, limit=<n>
+//	                               keeps the most recent n)
+//	GET /api/v1/recording          samples recorded on disk, oldest first
+//	                               (since and limit as for history,
+//	                               until=<RFC 3339 time>)
+//
+// Metrics are served as collected, before sensitive fields are sealed, so
+// the API should only listen on a local address.
+func serveAPI(ctx context.Context, addr string, agents []*vehicleAgent) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		handleMetrics(w, r, agents)
+	})
+	mux.HandleFunc("/api/v1/history", func(w http.ResponseWriter, r *http.Request) {
+		handleHistory(w, r, agents)
+	})
+	mux.HandleFunc("/api/v1/recording", func(w http.ResponseWriter, r *http.Request) {
+		handleRecording(w, r, agents)
+	})
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request, agents []*vehicleAgent) {
+	agent := selectAgent(w, r, agents)
+	if agent == nil {
+		return
+	}
+
+	metrics := agent.latest.Load()
+	if metrics == nil {
+		http.Error(w, "no metrics collected yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJSON(w, metrics)
+}
+
+func handleHistory(w http.ResponseWriter, r *http.Request, agents []*vehicleAgent) {
+	agent := selectAgent(w, r, agents)
+	if agent == nil {
+		return
+	}
+	if agent.history == nil {
+		http.Error(w, "history disabled (HISTORY_SIZE=0)", http.StatusNotFound)
+		return
+	}
+
+	since, limit, ok := rangeParams(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, agent.history.since(since, limit))
+}
+
+func handleRecording(w http.ResponseWriter, r *http.Request, agents []*vehicleAgent) {
+	agent := selectAgent(w, r, agents)
+	if agent == nil {
+		return
+	}
+	if agent.recorder == nil {
+		http.Error(w, "recording disabled (RECORDING_PATH is empty)", http.StatusNotFound)
+		return
+	}
+
+	since, limit, ok := rangeParams(w, r)
+	if !ok {
+		return
+	}
+	var until time.Time
+	if value := r.URL.Query().Get("until"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid until %q, expected an RFC 3339 time", value), http.StatusBadRequest)
+			return
+		}
+		until = t
+	}
+
+	samples, err := agent.recorder.Query(agent.cfg.Agent.NodeName, since, until, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if samples == nil {
+		samples = []recorder.Sample{}
+	}
+	writeJSON(w, samples)
+}
+
+// rangeParams parses the since and limit parameters, or writes an error and
+// returns false
+func rangeParams(w http.ResponseWriter, r *http.Request) (time.Time, int, bool) {
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, value); err == nil {
+			since = t
+		} else {
+			http.Error(w, fmt.Sprintf("invalid since %q, expected an RFC 3339 time or a duration", value), http.StatusBadRequest)
+			return time.Time{}, 0, false
+		}
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", value), http.StatusBadRequest)
+			return time.Time{}, 0, false
+		}
+		limit = n
+	}
+	return since, limit, true
+}
+
+// selectAgent returns the vehicle selected by the node parameter, or writes
+// an error and returns nil
+func selectAgent(w http.ResponseWriter, r *http.Request, agents []*vehicleAgent) *vehicleAgent {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil
+	}
+
+	node := r.URL.Query().Get("node")
+	switch {
+	case node != "":
+		for _, a := range agents {
+			if a.cfg.Agent.NodeName == node {
+				return a
+			}
+		}
+		http.Error(w, fmt.Sprintf("unknown vehicle %q", node), http.StatusNotFound)
+		return nil
+	case len(agents) == 1:
+		return agents[0]
+	default:
+		names := make([]string, 0, len(agents))
+		for _, a := range agents {
+			names = append(names, a.cfg.Agent.NodeName)
+		}
+		sort.Strings(names)
+		http.Error(w, fmt.Sprintf("node parameter required, one of %v", names), http.StatusBadRequest)
+		return nil
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(v)
+}
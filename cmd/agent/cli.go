@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/collector"
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// cliOptions holds the flags shared by all subcommands
+type cliOptions struct {
+	configPath string
+	overrides  []string
+	logLevel   string
+	dryRun     bool
+}
+
+// load loads the configuration: --set, --log-level and --dry-run flags >
+// environment > config file > defaults
+func (o *cliOptions) load() (*config.Config, error) {
+	cfg, err := config.Load(o.configPath, o.allOverrides())
+	return cfg, models.Categorize(models.ErrorCategoryConfig, err)
+}
+
+// allOverrides returns the --set flags, followed by --log-level and
+// --dry-run
+func (o *cliOptions) allOverrides() []string {
+	overrides := slices.Clone(o.overrides)
+	if o.logLevel != "" {
+		overrides = append(overrides, "agent.logLevel="+o.logLevel)
+	}
+	if o.dryRun {
+		overrides = append(overrides, "agent.dryRun=true")
+	}
+	return overrides
+}
+
+// newRootCommand creates the agent CLI. Without a subcommand it runs the
+// agent, as the DaemonSet does.
+func newRootCommand() *cobra.Command {
+	opts := &cliOptions{}
+	var printConfig, exportSamples bool
+
+	root := &cobra.Command{
+		Use:           "uav-agent",
+		Short:         "Collect UAV telemetry and publish it as UAVMetrics",
+		Version:       version,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case printConfig:
+				return runPrintConfig(opts, cmd.OutOrStdout())
+			case exportSamples:
+				return runExportRecording(opts, cmd.OutOrStdout())
+			}
+			return runAgent(opts)
+		},
+	}
+	root.SetVersionTemplate("{{.Version}}\n")
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.configPath, "config", "", "YAML or JSON configuration file")
+	flags.StringArrayVar(&opts.overrides, "set", nil, "Override a configuration value, e.g. --set collection.interval=5s (repeatable, value is YAML)")
+	flags.StringVar(&opts.logLevel, "log-level", "", "Override agent.logLevel (debug, info, warn or error)")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "Collect, log and serve metrics locally but never write to the Kubernetes API")
+
+	// Kept for scripts written before the subcommands
+	root.Flags().BoolVar(&printConfig, "print-config", false, "Print the effective configuration as YAML and exit")
+	root.Flags().BoolVar(&exportSamples, "export-recording", false, "Print the samples recorded on this node as JSON lines and exit")
+	root.Flags().MarkDeprecated("print-config", "use \"uav-agent print-config\" instead")
+	root.Flags().MarkDeprecated("export-recording", "use \"uav-agent export-recording\" instead")
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "run",
+			Short: "Run the agent (default)",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runAgent(opts)
+			},
+		},
+		&cobra.Command{
+			Use:   "version",
+			Short: "Print the version",
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				fmt.Fprintln(cmd.OutOrStdout(), version)
+			},
+		},
+		&cobra.Command{
+			Use:   "validate-config",
+			Short: "Load and validate the configuration, then exit",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cfg, err := opts.load()
+				if err != nil {
+					return err
+				}
+				if err := cfg.Validate(); err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), "Configuration is valid")
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "print-config",
+			Short: "Print the effective configuration as YAML",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runPrintConfig(opts, cmd.OutOrStdout())
+			},
+		},
+		&cobra.Command{
+			Use:   "export-recording",
+			Short: "Print the samples recorded on this node as JSON lines (the agent must be stopped)",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runExportRecording(opts, cmd.OutOrStdout())
+			},
+		},
+		&cobra.Command{
+			Use:   "export-file-sink [file]",
+			Short: "Print the samples of the file sink (SINK_FILE_PATH or file) as JSON lines, decrypted",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runExportFileSink(opts, args, cmd.OutOrStdout())
+			},
+		},
+		newCollectOnceCommand(opts),
+	)
+
+	return root
+}
+
+func newCollectOnceCommand(opts *cliOptions) *cobra.Command {
+	var vehicle string
+	var wait time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "collect-once",
+		Short: "Collect one sample, print it as JSON and exit",
+		Long: `Collect one sample of every vehicle (or of --vehicle) and print it as JSON,
+without contacting the Kubernetes API server. Backends that stream
+telemetry, such as MAVLink, are given up to --wait to deliver a complete
+sample; after that the sample is printed with its collection errors.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := opts.load()
+			if err != nil {
+				return err
+			}
+			if err := cfg.Validate(); err != nil {
+				return err
+			}
+			initLogger(cfg.Agent.LogLevel)
+			// Keep stdout for the samples
+			log.SetOutput(os.Stderr)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			return collectOnce(ctx, cfg, vehicle, wait, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&vehicle, "vehicle", "", "Only collect the vehicle with this name (when the agent proxies several vehicles)")
+	cmd.Flags().DurationVar(&wait, "wait", 10*time.Second, "How long to wait for a sample without collection errors")
+	return cmd
+}
+
+func runPrintConfig(opts *cliOptions, w io.Writer) error {
+	cfg, err := opts.load()
+	if err != nil {
+		return err
+	}
+	data, err := cfg.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to render configuration: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func runExportRecording(opts *cliOptions, w io.Writer) error {
+	cfg, err := opts.load()
+	if err != nil {
+		return err
+	}
+	if cfg.Storage.RecordingPath == "" {
+		return fmt.Errorf("recording is disabled (RECORDING_PATH is empty)")
+	}
+	if err := exportRecording(cfg, w); err != nil {
+		return fmt.Errorf("failed to export recording: %w", err)
+	}
+	return nil
+}
+
+func runExportFileSink(opts *cliOptions, args []string, w io.Writer) error {
+	cfg, err := opts.load()
+	if err != nil {
+		return err
+	}
+	path := cfg.Sinks.FilePath
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if err := exportFileSink(cfg, path, w); err != nil {
+		return fmt.Errorf("failed to export %s: %w", path, err)
+	}
+	return nil
+}
+
+// collectOnce collects one sample per vehicle and writes it to w as JSON
+func collectOnce(ctx context.Context, cfg *config.Config, vehicle string, wait time.Duration, w io.Writer) error {
+	vehicleConfigs := []*config.Config{cfg}
+	if len(cfg.Vehicles) > 0 {
+		vehicleConfigs = nil
+		for _, v := range cfg.Vehicles {
+			if vehicle == "" || v.Name == vehicle {
+				vehicleConfigs = append(vehicleConfigs, cfg.ForVehicle(v))
+			}
+		}
+	} else if vehicle != "" && vehicle != cfg.Agent.NodeName {
+		vehicleConfigs = nil
+	}
+	if len(vehicleConfigs) == 0 {
+		return fmt.Errorf("unknown vehicle %q", vehicle)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	for _, vehicleCfg := range vehicleConfigs {
+		metrics, err := collectSample(ctx, vehicleCfg, wait)
+		if err != nil {
+			return fmt.Errorf("failed to collect metrics of %s: %w", vehicleCfg.Agent.NodeName, err)
+		}
+		if err := encoder.Encode(metrics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectSample collects samples until one has no collection errors or wait
+// has elapsed, and returns the last one
+func collectSample(ctx context.Context, cfg *config.Config, wait time.Duration) (*models.UAVMetrics, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	dataCollector := collector.NewCollector(cfg)
+	dataCollector.Start(ctx)
+
+	deadline := time.Now().Add(wait)
+	for {
+		metrics, err := dataCollector.CollectMetrics(ctx)
+		if err == nil && len(metrics.CollectionErrors) == 0 {
+			return metrics, nil
+		}
+		if !time.Now().Before(deadline) {
+			return metrics, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
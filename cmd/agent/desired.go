@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// setBaseConfig hands a configuration loaded from the config file and
+// environment to the collection loop, with the vehicle's desired state
+// applied on top
+func (a *vehicleAgent) setBaseConfig(base *config.Config) {
+	a.desiredMu.Lock()
+	defer a.desiredMu.Unlock()
+
+	a.base = base
+	next, err := applyDesiredState(base, a.desired)
+	if err != nil {
+		log.WithError(err).WithField("nodeName", base.Agent.NodeName).Warn("Desired state no longer valid, applying configuration without it")
+		next = base
+	}
+	a.reload(next)
+}
+
+// setDesiredState applies the desired state of the vehicle's UAVAgentConfig
+// (nil when it was deleted) on top of the configuration. An invalid desired
+// state is rejected and the current configuration kept.
+func (a *vehicleAgent) setDesiredState(desired *models.UAVAgentConfigSpec) error {
+	a.desiredMu.Lock()
+	defer a.desiredMu.Unlock()
+
+	next, err := applyDesiredState(a.base, desired)
+	if err != nil {
+		return err
+	}
+	current, err := applyDesiredState(a.base, a.desired)
+	if err != nil {
+		current = a.base
+	}
+	if next.Collection.Simulate && !current.Collection.Simulate {
+		if err := a.checkGrounded(); err != nil {
+			return fmt.Errorf("simulation not enabled: %w", err)
+		}
+	}
+	a.desired = desired
+	a.reload(next)
+	return nil
+}
+
+// checkGrounded returns an error unless the latest sample shows the vehicle
+// disarmed and on the ground. Switching a flying vehicle to simulated
+// telemetry would hide its real position from the fleet.
+func (a *vehicleAgent) checkGrounded() error {
+	latest := a.latest.Load()
+	switch {
+	case latest == nil:
+		return fmt.Errorf("flight state not known yet")
+	case latest.CollectionFailed(models.SectionFlight):
+		return fmt.Errorf("flight state could not be collected")
+	case latest.Flight != nil && (latest.Flight.Armed || latest.Flight.IsFlying):
+		return fmt.Errorf("vehicle is armed or flying")
+	}
+	return nil
+}
+
+// reload hands cfg to the collection loop, replacing a reload the loop has
+// not picked up yet
+func (a *vehicleAgent) reload(cfg *config.Config) {
+	select {
+	case <-a.reloads:
+	default:
+	}
+	a.reloads <- cfg
+}
+
+// applyDesiredState returns base with the fields set in desired replaced
+func applyDesiredState(base *config.Config, desired *models.UAVAgentConfigSpec) (*config.Config, error) {
+	if desired == nil {
+		return base, nil
+	}
+	if desired.NodeName != "" && desired.NodeName != base.Agent.NodeName {
+		return nil, fmt.Errorf("spec.nodeName %s does not match the vehicle", desired.NodeName)
+	}
+
+	next := *base
+	if desired.CollectionInterval != "" {
+		interval, err := time.ParseDuration(desired.CollectionInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec.collectionInterval %q", desired.CollectionInterval)
+		}
+		next.Collection.Interval = interval
+	}
+	if c := desired.Collectors; c != nil {
+		setBool(&next.Collection.EnableGPS, c.GPS)
+		setBool(&next.Collection.EnableBattery, c.Battery)
+		setBool(&next.Collection.EnableFlight, c.Flight)
+		setBool(&next.Collection.EnableNetwork, c.Network)
+		setBool(&next.Collection.EnablePerformance, c.Performance)
+		setBool(&next.Collection.EnableHealthCheck, c.HealthCheck)
+	}
+	setBool(&next.Collection.Simulate, desired.Simulation)
+	setBool(&next.PowerSave.Enabled, desired.PowerSave)
+
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+func setBool(dst *bool, value *bool) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+// watchDesiredState watches the vehicles' UAVAgentConfigs and applies each
+// spec whenever it changes, reporting in the status whether it was applied.
+// Deleting the UAVAgentConfig reverts the agent to its own configuration.
+// A rejected spec is retried on every resync, so e.g. a simulation switch
+// refused in flight is applied once the vehicle has landed.
+func watchDesiredState(ctx context.Context, k8sClient *k8s.Client, agents []*vehicleAgent, resync time.Duration, dryRun bool) {
+	changes := make(chan struct{}, 1)
+	watch, err := k8sClient.WatchAgentConfigs(ctx, resync, func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to watch UAVAgentConfigs, desired state disabled")
+		return
+	}
+
+	// Generation of each vehicle's UAVAgentConfig last applied, 0 for none
+	observed := make(map[*vehicleAgent]int64, len(agents))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changes:
+		}
+		if !watch.HasSynced() {
+			continue
+		}
+
+		for _, agent := range agents {
+			nodeName := agent.cfg.Agent.NodeName
+			desired, err := watch.Get(nodeName)
+			if apierrors.IsNotFound(err) {
+				if observed[agent] != 0 {
+					observed[agent] = 0
+					if err := agent.setDesiredState(nil); err == nil {
+						log.WithField("nodeName", nodeName).Info("UAVAgentConfig deleted, reverting to the agent configuration")
+					}
+				}
+				continue
+			}
+			if err != nil {
+				log.WithError(err).WithField("nodeName", nodeName).Debug("Failed to read desired state")
+				continue
+			}
+			if desired.Generation == observed[agent] {
+				continue
+			}
+
+			status := models.UAVAgentConfigStatus{
+				ObservedGeneration: desired.Generation,
+				Phase:              models.AgentConfigApplied,
+			}
+			if err := agent.setDesiredState(&desired.Spec); err != nil {
+				status.Phase = models.AgentConfigRejected
+				status.Message = err.Error()
+				log.WithError(err).WithField("nodeName", nodeName).Warn("Rejected desired state")
+			} else {
+				observed[agent] = desired.Generation
+				log.WithFields(logrus.Fields{
+					"nodeName":   nodeName,
+					"generation": desired.Generation,
+				}).Info("Applied desired state")
+			}
+
+			if dryRun || desired.Status.ObservedGeneration == status.ObservedGeneration &&
+				desired.Status.Phase == status.Phase && desired.Status.Message == status.Message {
+				continue
+			}
+			status.UpdatedAt = time.Now().UTC()
+			desired.Status = status
+			if err := k8sClient.UpdateAgentConfigStatus(ctx, desired); err != nil {
+				log.WithError(err).WithField("nodeName", nodeName).Warn("Failed to update UAVAgentConfig status")
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Exit codes by error category, so a restarting agent can be triaged from
+// the container's last termination state
+const (
+	exitCodeFailure    = 1 // uncategorized
+	exitCodeConfig     = 2
+	exitCodeHardware   = 3
+	exitCodeK8sAPI     = 4
+	exitCodeValidation = 5
+)
+
+// exitCode returns the exit code of an error terminating the agent
+func exitCode(err error) int {
+	switch models.ErrorCategory(err) {
+	case models.ErrorCategoryConfig:
+		return exitCodeConfig
+	case models.ErrorCategoryHardware:
+		return exitCodeHardware
+	case models.ErrorCategoryK8sAPI:
+		return exitCodeK8sAPI
+	case models.ErrorCategoryValidation:
+		return exitCodeValidation
+	}
+	return exitCodeFailure
+}
+
+// terminalStatus records the error terminating the agent in the status of
+// its vehicles' UAVMetrics, once the Kubernetes client is initialized
+var terminalStatus struct {
+	mu     sync.Mutex
+	client *k8s.Client // nil before initialization and in dry runs
+	nodes  []string
+}
+
+// setTerminalStatus sets where fatal records the error terminating the agent
+func setTerminalStatus(client *k8s.Client, nodes []string) {
+	terminalStatus.mu.Lock()
+	defer terminalStatus.mu.Unlock()
+	terminalStatus.client, terminalStatus.nodes = client, nodes
+}
+
+// fatal logs err with its category, records it in the status of every
+// vehicle's UAVMetrics and exits with the category's exit code. Like
+// logrus' Fatal, deferred functions don't run.
+func fatal(err error, msg string, fields logrus.Fields) {
+	code := exitCode(err)
+	category := models.ErrorCategory(err)
+	log.WithFields(fields).WithError(err).WithFields(logrus.Fields{
+		"category": category,
+		"exitCode": code,
+	}).Error(msg)
+
+	terminalStatus.mu.Lock()
+	client, nodes := terminalStatus.client, terminalStatus.nodes
+	terminalStatus.mu.Unlock()
+	if client != nil {
+		if category == "" {
+			category = "unknown"
+		}
+		failure := &models.AgentFailure{
+			Category: category,
+			Message:  msg + ": " + err.Error(),
+			ExitCode: code,
+			Time:     time.Now(),
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		for _, node := range nodes {
+			if err := client.MarkFailed(ctx, node, failure); err != nil {
+				log.WithError(err).WithField("nodeName", node).Warn("Failed to record the failure in status")
+			}
+		}
+		cancel()
+	}
+	os.Exit(code)
+}
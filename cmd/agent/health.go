@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+)
+
+// healthState backs the /healthz and /readyz endpoints. The agent is live
+// while no collection cycle has been running for longer than stallTimeout,
+// and ready once the Kubernetes client is initialized and a collection has
+// been published.
+type healthState struct {
+	stallTimeout time.Duration
+
+	mu        sync.Mutex
+	k8sClient *k8s.Client
+	agents    []*vehicleAgent
+}
+
+// vehicleHealth is the per-vehicle detail reported by both endpoints
+type vehicleHealth struct {
+	LastSuccess    *time.Time `json:"lastSuccess,omitempty"`
+	CycleRunningMs int64      `json:"cycleRunningMs,omitempty"` // duration of the cycle in progress
+	Stalled        bool       `json:"stalled,omitempty"`
+}
+
+func (h *healthState) setClient(client *k8s.Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.k8sClient = client
+}
+
+func (h *healthState) setAgents(agents []*vehicleAgent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.agents = agents
+}
+
+// vehicles returns the per-vehicle health and whether any collection loop
+// is stalled or has published at least once
+func (h *healthState) vehicles(now time.Time) (map[string]vehicleHealth, bool, bool) {
+	h.mu.Lock()
+	agents := h.agents
+	h.mu.Unlock()
+
+	vehicles := make(map[string]vehicleHealth, len(agents))
+	stalled, collected := false, false
+	for _, agent := range agents {
+		var vh vehicleHealth
+		if ns := agent.lastSuccess.Load(); ns != 0 {
+			t := time.Unix(0, ns)
+			vh.LastSuccess = &t
+			collected = true
+		}
+		if ns := agent.cycleStart.Load(); ns != 0 {
+			running := now.Sub(time.Unix(0, ns))
+			vh.CycleRunningMs = running.Milliseconds()
+			vh.Stalled = running > h.stallTimeout
+			stalled = stalled || vh.Stalled
+		}
+		vehicles[agent.cfg.Agent.NodeName] = vh
+	}
+	return vehicles, stalled, collected
+}
+
+func (h *healthState) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	vehicles, stalled, _ := h.vehicles(time.Now())
+	status, code := "ok", http.StatusOK
+	if stalled {
+		status, code = "collection stalled", http.StatusServiceUnavailable
+	}
+	writeHealth(w, code, status, vehicles)
+}
+
+func (h *healthState) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	clientReady := h.k8sClient != nil
+	h.mu.Unlock()
+	vehicles, _, collected := h.vehicles(time.Now())
+
+	status, code := "ok", http.StatusOK
+	switch {
+	case !clientReady:
+		status, code = "kubernetes client not initialized", http.StatusServiceUnavailable
+	case !collected:
+		status, code = "no successful collection yet", http.StatusServiceUnavailable
+	}
+	writeHealth(w, code, status, vehicles)
+}
+
+func writeHealth(w http.ResponseWriter, code int, status string, vehicles map[string]vehicleHealth) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   status,
+		"vehicles": vehicles,
+	})
+}
+
+// serveHealth serves /healthz and /readyz on addr until ctx is cancelled
+func serveHealth(ctx context.Context, addr string, h *healthState) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.handleHealthz)
+	mux.HandleFunc("/readyz", h.handleReadyz)
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
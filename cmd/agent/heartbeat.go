@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+)
+
+// runHeartbeats renews the heartbeat Lease of every vehicle each interval
+// until ctx is done, so consumers can tell a dead agent (k8s.IsAlive) from
+// stale telemetry. Standby instances of a rollout don't renew it.
+func runHeartbeats(ctx context.Context, k8sClient *k8s.Client, agents []*vehicleAgent, identity string, interval time.Duration) {
+	if identity == "" {
+		identity, _ = os.Hostname()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, agent := range agents {
+			if agent.leader != nil && !agent.leader.IsLeader() {
+				continue
+			}
+			nodeName := agent.cfg.Agent.NodeName
+			if err := k8sClient.RenewHeartbeat(ctx, nodeName, identity); err != nil && ctx.Err() == nil {
+				log.WithError(err).WithField("nodeName", nodeName).Debug("Failed to renew heartbeat")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
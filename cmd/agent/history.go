@@ -0,0 +1,76 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// historySample is one collected sample kept for the local API
+type historySample struct {
+	CollectedAt time.Time          `json:"collectedAt"`
+	Metrics     *models.UAVMetrics `json:"metrics"`
+}
+
+// history keeps the most recent samples of a vehicle in a ring buffer, so
+// trends can be inspected on the vehicle without a TSDB. Samples older than
+// maxAge (the history retention policy, 0 for none) are dropped.
+type history struct {
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	samples []historySample
+	start   int // index of the oldest sample
+	count   int
+}
+
+func newHistory(size int, maxAge time.Duration) *history {
+	return &history{samples: make([]historySample, size), maxAge: maxAge}
+}
+
+// add records a sample, overwriting the oldest one when the buffer is full
+func (h *history) add(at time.Time, metrics *models.UAVMetrics) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expireLocked(at)
+	if h.count == len(h.samples) {
+		h.start = (h.start + 1) % len(h.samples)
+		h.count--
+	}
+	h.samples[(h.start+h.count)%len(h.samples)] = historySample{CollectedAt: at, Metrics: metrics}
+	h.count++
+}
+
+// expireLocked drops the samples past maxAge
+func (h *history) expireLocked(now time.Time) {
+	if h.maxAge <= 0 {
+		return
+	}
+	for h.count > 0 && now.Sub(h.samples[h.start].CollectedAt) > h.maxAge {
+		h.samples[h.start] = historySample{}
+		h.start = (h.start + 1) % len(h.samples)
+		h.count--
+	}
+}
+
+// since returns the samples collected after since, oldest first. When limit
+// is positive, only the most recent limit samples are returned.
+func (h *history) since(since time.Time, limit int) []historySample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expireLocked(time.Now())
+
+	n := 0
+	for n < h.count && h.samples[(h.start+h.count-1-n)%len(h.samples)].CollectedAt.After(since) {
+		n++
+	}
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	samples := make([]historySample, n)
+	for i := range samples {
+		samples[i] = h.samples[(h.start+h.count-n+i)%len(h.samples)]
+	}
+	return samples
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/securestore"
+)
+
+// homeStore keeps each vehicle's home point in storage.homeStateDir,
+// encrypted at rest when storage.encryptAtRest is set. With field encryption
+// the published home is sealed, so the cluster can't give it back after a
+// restart.
+type homeStore struct {
+	dir   string
+	store *securestore.Store
+
+	mu    sync.Mutex
+	saved map[string]models.HomeData // key: node name
+}
+
+func openHomeStore(cfg *config.Config) (*homeStore, error) {
+	if err := os.MkdirAll(cfg.Storage.HomeStateDir, 0700); err != nil {
+		return nil, err
+	}
+	store, err := openStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &homeStore{
+		dir:   cfg.Storage.HomeStateDir,
+		store: store,
+		saved: make(map[string]models.HomeData),
+	}, nil
+}
+
+func (s *homeStore) path(nodeName string) string {
+	return filepath.Join(s.dir, nodeName+".json")
+}
+
+// Load returns the vehicle's saved home point, or nil if none was saved
+func (s *homeStore) Load(nodeName string) (*models.HomeData, error) {
+	data, err := os.ReadFile(s.path(nodeName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.store != nil {
+		plaintext, err := s.store.Decrypt(data)
+		if err == nil {
+			data = plaintext
+		} else if !errors.Is(err, securestore.ErrNotEncrypted) {
+			return nil, err
+		}
+	}
+
+	var home models.HomeData
+	if err := json.Unmarshal(data, &home); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.saved[nodeName] = home
+	s.mu.Unlock()
+	return &home, nil
+}
+
+// Save writes the vehicle's home point when it changed since the last save
+func (s *homeStore) Save(nodeName string, home *models.HomeData) error {
+	if home == nil {
+		return nil
+	}
+	point := models.HomeData{
+		Latitude:  home.Latitude,
+		Longitude: home.Longitude,
+		Source:    home.Source,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if saved, ok := s.saved[nodeName]; ok && saved == point {
+		return nil
+	}
+
+	data, err := json.Marshal(point)
+	if err != nil {
+		return err
+	}
+	if s.store != nil {
+		err = s.store.WriteFile(s.path(nodeName), data, 0600)
+	} else {
+		err = writeFile(s.path(nodeName), data, 0600)
+	}
+	if err != nil {
+		return err
+	}
+	s.saved[nodeName] = point
+	return nil
+}
+
+// writeFile atomically replaces path
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
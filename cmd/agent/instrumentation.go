@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/k3suav/uav-monitor/cmd/agent"
+
+// Collection pipeline instrumentation. The global providers are no-ops
+// unless OTLP export is configured (OTEL_EXPORTER_OTLP_ENDPOINT).
+var (
+	tracer = otel.Tracer(instrumentationName)
+	meter  = otel.Meter(instrumentationName)
+
+	cycleDuration, _ = meter.Float64Histogram("uav.agent.cycle.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of a collection cycle, from collection to the CRD update"))
+	cycleCount, _ = meter.Int64Counter("uav.agent.cycles",
+		metric.WithDescription("Collection cycles by result"))
+	stageDuration, _ = meter.Float64Histogram("uav.agent.stage.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of each step of a collection cycle"))
+	collectionErrors, _ = meter.Int64Counter("uav.agent.collection.errors",
+		metric.WithDescription("Telemetry sections that failed to collect"))
+)
+
+// startCycle starts the root span of a collection cycle. The returned
+// function ends it and records the cycle's duration and result.
+func startCycle(ctx context.Context, nodeName string) (context.Context, func(error)) {
+	start := time.Now()
+	node := attribute.String("uav.node", nodeName)
+	ctx, span := tracer.Start(ctx, "collection cycle", trace.WithAttributes(node))
+	return ctx, func(err error) {
+		result := "success"
+		if err != nil {
+			result = "failure"
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		attrs := metric.WithAttributes(node, attribute.String("result", result))
+		cycleDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+		cycleCount.Add(ctx, 1, attrs)
+	}
+}
+
+// startStage starts a span for one step of the cycle in ctx. The returned
+// function ends it and records the step's duration.
+func startStage(ctx context.Context, stage string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, stage)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		stageDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attribute.String("stage", stage)))
+	}
+}
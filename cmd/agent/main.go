@@ -2,16 +2,34 @@ package main
 
 import (
 	"context"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/k3suav/uav-monitor/pkg/alert"
 	"github.com/k3suav/uav-monitor/pkg/collector"
 	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/enrollment"
+	"github.com/k3suav/uav-monitor/pkg/envelope"
 	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/logfile"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/observability"
+	"github.com/k3suav/uav-monitor/pkg/recorder"
+	"github.com/k3suav/uav-monitor/pkg/remoteid"
+	"github.com/k3suav/uav-monitor/pkg/sink"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
 const (
@@ -23,15 +41,34 @@ var (
 )
 
 func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// runAgent runs the agent until it receives SIGINT or SIGTERM
+func runAgent(opts *cliOptions) error {
+	cfg, err := opts.load()
+	if err != nil {
+		fatal(err, "Failed to load configuration", nil)
+	}
+
 	// Initialize logger
-	initLogger()
+	initLogger(cfg.Agent.LogLevel)
 
 	log.WithField("version", version).Info("Starting UAV Agent")
 
-	// Load configuration
-	cfg := config.DefaultConfig()
 	if err := cfg.Validate(); err != nil {
-		log.WithError(err).Fatal("Invalid configuration")
+		fatal(models.Categorize(models.ErrorCategoryValidation, err), "Invalid configuration", nil)
+	}
+
+	if cfg.Agent.LogFile != "" {
+		logFile, err := openLogFile(cfg.Agent)
+		if err != nil {
+			fatal(models.Categorize(models.ErrorCategoryConfig, err), "Failed to open log file", logrus.Fields{"path": cfg.Agent.LogFile})
+		}
+		defer logFile.Close()
 	}
 
 	log.WithFields(logrus.Fields{
@@ -39,33 +76,283 @@ func main() {
 		"namespace":          cfg.Kubernetes.Namespace,
 		"collectionInterval": cfg.Collection.Interval,
 	}).Info("Configuration loaded")
+	if cfg.Agent.DryRun {
+		log.Warn("Dry run: metrics are logged and served locally but never written to the Kubernetes API")
+	}
+
+	// Setup context with cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Export collection pipeline metrics and spans over OTLP (optional)
+	if cfg.OpenTelemetry.Endpoint != "" {
+		shutdownOTel, err := observability.Setup(ctx, cfg.OpenTelemetry, version, attribute.String("k8s.node.name", cfg.Agent.NodeName))
+		if err != nil {
+			fatal(models.Categorize(models.ErrorCategoryConfig, err), "Failed to initialize OpenTelemetry export", nil)
+		}
+		defer func() {
+			flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer flushCancel()
+			if err := shutdownOTel(flushCtx); err != nil {
+				log.WithError(err).Warn("Failed to flush OpenTelemetry data")
+			}
+		}()
+		log.WithFields(logrus.Fields{
+			"endpoint": cfg.OpenTelemetry.Endpoint,
+			"protocol": cfg.OpenTelemetry.Protocol,
+		}).Info("OpenTelemetry export enabled")
+	}
+
+	// Serve liveness and readiness probes (optional)
+	health := &healthState{stallTimeout: cfg.Agent.HealthStallTimeout}
+	if cfg.Agent.HealthListen != "" {
+		go func() {
+			if err := serveHealth(ctx, cfg.Agent.HealthListen, health); err != nil {
+				log.WithError(err).Error("Health server stopped")
+			}
+		}()
+		log.WithField("address", cfg.Agent.HealthListen).Info("Health server started")
+	}
 
 	// Create Kubernetes client
 	k8sClient, err := k8s.NewClient(cfg)
 	if err != nil {
-		log.WithError(err).Fatal("Failed to create Kubernetes client")
+		fatal(models.Categorize(models.ErrorCategoryK8sAPI, err), "Failed to create Kubernetes client", nil)
 	}
+	health.setClient(k8sClient)
 	log.Info("Kubernetes client initialized")
 
-	// Create data collector
-	dataCollector := collector.NewCollector(cfg)
+	// Every node runs an agent, so during a rollout agents of both versions
+	// would apply their own schema; the CRD is installed by the scheduler or
+	// the aggregator instead
+	if cfg.Kubernetes.InstallCRD {
+		log.Warn("kubernetes.installCRD is ignored by the agent, install the UAVMetrics CRD with the scheduler or aggregator")
+	}
+
+	// A ground node may proxy several vehicles, each published as its own UAVMetrics
+	vehicleConfigs := []*config.Config{cfg}
+	if len(cfg.Vehicles) > 0 {
+		vehicleConfigs = make([]*config.Config, 0, len(cfg.Vehicles))
+		for _, v := range cfg.Vehicles {
+			vehicleConfigs = append(vehicleConfigs, cfg.ForVehicle(v))
+		}
+		log.WithField("vehicles", len(vehicleConfigs)).Info("Proxying telemetry for multiple vehicles")
+	}
+
+	// From here on, fatal errors are recorded in each vehicle's UAVMetrics status
+	if !cfg.Agent.DryRun {
+		nodes := make([]string, 0, len(vehicleConfigs))
+		for _, vehicleCfg := range vehicleConfigs {
+			nodes = append(nodes, vehicleCfg.Agent.NodeName)
+		}
+		setTerminalStatus(k8sClient, nodes)
+	}
+
+	// Enroll every vehicle in the fleet before publishing its telemetry (optional)
+	if cfg.Enrollment.Enabled && cfg.Agent.DryRun {
+		log.Warn("Dry run: skipping enrollment")
+	} else if cfg.Enrollment.Enabled {
+		errs := make(chan error, len(vehicleConfigs))
+		for _, vehicleCfg := range vehicleConfigs {
+			go func(vehicleCfg *config.Config) {
+				errs <- enrollment.Enroll(ctx, k8sClient, vehicleCfg, log)
+			}(vehicleCfg)
+		}
+		for range vehicleConfigs {
+			if err := <-errs; err != nil {
+				fatal(models.Categorize(models.ErrorCategoryK8sAPI, err), "Enrollment failed", nil)
+			}
+		}
+		// Certificates expire long before a vehicle's agent is restarted
+		for _, vehicleCfg := range vehicleConfigs {
+			go enrollment.Renew(ctx, k8sClient, vehicleCfg, log)
+		}
+	}
+
+	// Keep home points on the node's disk; a sealed home can't be restored from the cluster
+	var homes *homeStore
+	if cfg.Storage.HomeStateDir != "" {
+		if homes, err = openHomeStore(cfg); err != nil {
+			log.WithError(err).WithField("dir", cfg.Storage.HomeStateDir).Warn("Failed to open home state, home points are restored from the cluster only")
+		}
+	}
+
+	agents := make([]*vehicleAgent, 0, len(vehicleConfigs))
+	for _, vehicleCfg := range vehicleConfigs {
+		agent, err := newVehicleAgent(vehicleCfg, k8sClient, homes)
+		if err != nil {
+			fatal(models.Categorize(models.ErrorCategoryConfig, err), "Invalid Remote ID configuration", logrus.Fields{"nodeName": vehicleCfg.Agent.NodeName})
+		}
+		agents = append(agents, agent)
+	}
 	log.Info("Data collector initialized")
 
-	// Setup context with cancellation
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Create field sealer (optional)
+	var sealer *envelope.Sealer
+	if cfg.FieldEncryption.Enabled {
+		sealer, err = envelope.NewSealer(cfg.FieldEncryption.PublicKeyPath, cfg.FieldEncryption.Fields)
+		if err != nil {
+			fatal(models.Categorize(models.ErrorCategoryConfig, err), "Failed to initialize field encryption", nil)
+		}
+		log.WithField("fields", cfg.FieldEncryption.Fields).Info("Sensitive field encryption enabled")
+	}
+
+	// Record every sample on the node's disk (optional)
+	if cfg.Storage.RecordingPath != "" {
+		rec, err := openRecorder(cfg)
+		if err != nil {
+			fatal(models.Categorize(models.ErrorCategoryHardware, err), "Failed to open recording database", nil)
+		}
+		defer rec.Close()
+		for _, agent := range agents {
+			agent.recorder = rec
+		}
+		log.WithFields(logrus.Fields{
+			"path":       cfg.Storage.RecordingPath,
+			"maxSamples": cfg.Storage.RecordingMaxSamples,
+			"encrypted":  cfg.Storage.EncryptAtRest,
+		}).Info("Recording samples on disk")
+	}
+
+	for _, agent := range agents {
+		agent.collector.Start(ctx)
+	}
+	health.setAgents(agents)
+
+	// Serve the latest metrics to on-board applications (optional)
+	if cfg.Agent.APIListen != "" {
+		go func() {
+			if err := serveAPI(ctx, cfg.Agent.APIListen, agents); err != nil {
+				log.WithError(err).Error("Local API server stopped")
+			}
+		}()
+		log.WithField("address", cfg.Agent.APIListen).Info("Local API server started")
+	}
+
+	// Fan every published sample out to the enabled sinks
+	store, err := openStore(cfg)
+	if err != nil {
+		fatal(models.Categorize(models.ErrorCategoryConfig, err), "Failed to load the storage encryption key", nil)
+	}
+	sinks, err := sink.Build(cfg, k8sClient, store, log)
+	if err != nil {
+		fatal(models.Categorize(models.ErrorCategoryConfig, err), "Failed to initialize sinks", nil)
+	}
+	defer sinks.Close()
+	sinks.Trace = startStage
+	for _, agent := range agents {
+		agent.sinks = sinks
+	}
+	log.WithField("sinks", strings.Join(sinks.Names(), ",")).Info("Sinks initialized")
+
+	// Only publish while holding the node's Lease. The Lease outlives ctx so
+	// it's released after the shutdown status update, not before.
+	var leader *k8s.Leader
+	leaderCtx, releaseLease := context.WithCancel(context.Background())
+	defer releaseLease()
+	if cfg.Kubernetes.LeaderElection && !cfg.Agent.DryRun {
+		leader, err = k8sClient.NewLeader(cfg.Agent.NodeName, cfg.Kubernetes.LeaderElectionIdentity, log)
+		if err != nil {
+			fatal(models.Categorize(models.ErrorCategoryConfig, err), "Failed to initialize leader election", nil)
+		}
+		for _, agent := range agents {
+			agent.leader = leader
+		}
+		go leader.Run(leaderCtx)
+		log.WithFields(logrus.Fields{
+			"lease":    k8sClient.LeaseName(cfg.Agent.NodeName),
+			"identity": leader.Identity(),
+		}).Info("Leader election enabled")
+	}
+
+	// Notify webhooks of critical health (optional)
+	if cfg.Alert.Enabled() {
+		notifier := alert.NewNotifier(cfg, log)
+		for _, agent := range agents {
+			agent.alerts = notifier
+		}
+		go notifier.Run(ctx)
+		log.WithFields(logrus.Fields{
+			"webhooks":      len(cfg.Alert.WebhookURLs),
+			"slackWebhooks": len(cfg.Alert.SlackWebhookURLs),
+			"errorPatterns": len(cfg.Alert.ErrorPatterns),
+			"cooldown":      cfg.Alert.Cooldown,
+		}).Info("Alert webhooks initialized")
+	}
+
+	// Stream every collected sample to gRPC subscribers (optional)
+	if cfg.Agent.GRPCListen != "" {
+		hub := newTelemetryHub()
+		for _, agent := range agents {
+			agent.telemetry = hub
+		}
+		go func() {
+			if err := serveTelemetry(ctx, cfg.Agent.GRPCListen, hub, agents); err != nil {
+				log.WithError(err).Error("Telemetry gRPC server stopped")
+			}
+		}()
+		log.WithField("address", cfg.Agent.GRPCListen).Info("Telemetry gRPC server started")
+	}
+
+	// Serve the Kubernetes API call metrics
+	go func() {
+		if err := k8s.ServeClientMetrics(ctx, cfg.Agent.MetricsListen); err != nil {
+			log.WithError(err).Error("Client metrics server stopped")
+		}
+	}()
+	log.WithField("address", cfg.Agent.MetricsListen).Info("Client metrics server started")
+
+	// Serve bandwidth and latency probes for other nodes (optional)
+	if cfg.BandwidthProbe.Listen != "" {
+		go func() {
+			if err := collector.ServeBandwidthProbe(ctx, cfg.BandwidthProbe); err != nil {
+				log.WithError(err).Error("Bandwidth probe server stopped")
+			}
+		}()
+		log.WithField("address", cfg.BandwidthProbe.Listen).Info("Bandwidth probe server started")
+	}
+	if cfg.LatencyProbe.Listen != "" {
+		go func() {
+			if err := collector.ServeLatencyProbe(ctx, cfg.LatencyProbe.Listen); err != nil {
+				log.WithError(err).Error("Latency probe server stopped")
+			}
+		}()
+		log.WithField("address", cfg.LatencyProbe.Listen).Info("Latency probe server started")
+	}
 
 	// Setup signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// Create error channel for goroutines
-	errChan := make(chan error, 1)
+	errChan := make(chan error, len(agents))
 
-	// Start collection loop in goroutine
-	go func() {
-		errChan <- runCollectionLoop(ctx, cfg, k8sClient, dataCollector)
-	}()
+	// Start one collection loop per vehicle
+	for _, agent := range agents {
+		go func(agent *vehicleAgent) {
+			errChan <- runCollectionLoop(ctx, agent, sealer)
+		}(agent)
+	}
+
+	// Reinitialize data sources that block collection (optional)
+	if cfg.Agent.WatchdogIntervals > 0 {
+		go runWatchdog(ctx, agents, cfg.Agent.WatchdogIntervals)
+	}
+
+	// Apply the desired state operators set in each vehicle's UAVAgentConfig
+	if cfg.Agent.DesiredStateInterval > 0 {
+		go watchDesiredState(ctx, k8sClient, agents, cfg.Agent.DesiredStateInterval, cfg.Agent.DryRun)
+	}
+
+	// Renew each vehicle's heartbeat Lease, telling consumers the agent is alive
+	if cfg.Kubernetes.HeartbeatInterval > 0 && !cfg.Agent.DryRun {
+		go runHeartbeats(ctx, k8sClient, agents, cfg.Kubernetes.LeaderElectionIdentity, cfg.Kubernetes.HeartbeatInterval)
+	}
+
+	// Reload the configuration on SIGHUP or when the config file changes
+	go watchConfig(ctx, opts.configPath, opts.allOverrides(), cfg.Agent.ConfigReloadInterval, func(next *config.Config) {
+		distributeConfig(cfg.Vehicles, next, agents)
+	})
 
 	// Wait for shutdown signal or error
 	select {
@@ -86,21 +373,152 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
 
-	if err := k8sClient.UpdateStatus(shutdownCtx, cfg.Agent.NodeName, "Inactive"); err != nil {
-		log.WithError(err).Warn("Failed to update status on shutdown")
+	for _, agent := range agents {
+		if cfg.Agent.DryRun || (leader != nil && !leader.IsLeader()) {
+			break
+		}
+		if err := k8sClient.UpdateStatus(shutdownCtx, agent.cfg.Agent.NodeName, "Inactive"); err != nil {
+			log.WithError(err).WithField("nodeName", agent.cfg.Agent.NodeName).Warn("Failed to update status on shutdown")
+		}
+	}
+
+	// Hand the Lease over to the other instance, if any
+	if leader != nil {
+		releaseLease()
+		select {
+		case <-leader.Released():
+		case <-shutdownCtx.Done():
+			log.Warn("Timed out releasing the agent lease")
+		}
 	}
 
+	k8sClient.ShutdownEvents()
+
 	log.Info("UAV Agent stopped")
+	return nil
 }
 
-func runCollectionLoop(ctx context.Context, cfg *config.Config, k8sClient *k8s.Client, dataCollector *collector.Collector) error {
-	ticker := time.NewTicker(cfg.Collection.Interval)
+// vehicleAgent collects and publishes the metrics of one vehicle
+type vehicleAgent struct {
+	cfg          *config.Config
+	collector    *collector.Collector
+	ridPublisher *remoteid.Publisher
+	reloads      chan *config.Config // applied by the collection loop between cycles
+
+	// For the health endpoints: start of the cycle in progress (0 when idle)
+	// and end of the last successful one, in Unix nanoseconds
+	cycleStart  atomic.Int64
+	lastSuccess atomic.Int64
+
+	// Current collection interval in nanoseconds, for the watchdog
+	interval atomic.Int64
+
+	// Most recently collected metrics, unsealed, for the local API
+	latest atomic.Pointer[models.UAVMetrics]
+
+	// Recent samples, unsealed, for the local API (nil when disabled)
+	history *history
+
+	// Persists every sample on the node's disk (nil when disabled)
+	recorder *recorder.Recorder
+
+	// Keeps the home point on the node's disk (nil when disabled)
+	homes *homeStore
+
+	// Streams each sample to gRPC subscribers (nil when disabled)
+	telemetry *telemetryHub
+
+	// Destinations each sealed sample is published to
+	sinks *sink.Pipeline
+
+	// Holds the node's agent Lease; samples are only published by the
+	// leader (nil when leader election is disabled)
+	leader *k8s.Leader
+
+	// Notifies webhooks of critical health (nil when disabled)
+	alerts *alert.Notifier
+
+	// Configuration from the config file and environment, and the desired
+	// state of the vehicle's UAVAgentConfig applied on top of it
+	desiredMu sync.Mutex
+	base      *config.Config
+	desired   *models.UAVAgentConfigSpec
+}
+
+func newVehicleAgent(cfg *config.Config, k8sClient *k8s.Client, homes *homeStore) (*vehicleAgent, error) {
+	base := *cfg
+	agent := &vehicleAgent{
+		cfg:       cfg,
+		collector: collector.NewCollector(cfg),
+		reloads:   make(chan *config.Config, 1),
+		base:      &base,
+		homes:     homes,
+	}
+	if cfg.Agent.HistorySize > 0 {
+		policy, _ := cfg.Retention.PolicyFor(cfg.Kubernetes.Namespace, config.RetentionHistory)
+		agent.history = newHistory(cfg.Agent.HistorySize, policy.MaxAge)
+	}
+
+	// The local home point wins over the published one, which may be sealed
+	if homes != nil {
+		home, err := homes.Load(cfg.Agent.NodeName)
+		if err != nil {
+			log.WithError(err).WithField("nodeName", cfg.Agent.NodeName).Warn("Failed to load saved home point")
+		} else if home != nil {
+			agent.collector.RestoreHome(home)
+		}
+	}
+
+	// Restore airtime, flight stats and home position from the existing CRD so restarts don't reset them
+	restoreCtx, restoreCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if previous, err := k8sClient.GetUAVMetrics(restoreCtx, cfg.Agent.NodeName); err == nil {
+		agent.collector.RestoreState(previous)
+	}
+	restoreCancel()
+
+	// Create Remote ID publisher (optional)
+	if cfg.RemoteID.Enabled {
+		ridPublisher, err := remoteid.NewPublisher(cfg)
+		if err != nil {
+			return nil, err
+		}
+		agent.ridPublisher = ridPublisher
+		log.WithFields(logrus.Fields{
+			"nodeName": cfg.Agent.NodeName,
+			"format":   cfg.RemoteID.Format,
+			"endpoint": cfg.RemoteID.Endpoint,
+		}).Info("Remote ID publisher initialized")
+	}
+
+	return agent, nil
+}
+
+func runCollectionLoop(ctx context.Context, agent *vehicleAgent, sealer *envelope.Sealer) error {
+	cfg, dataCollector := agent.cfg, agent.collector
+	interval := cfg.Collection.Interval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
+	agent.interval.Store(int64(interval))
+
+	// Follow the collector's interval, which grows while saving power
+	adjustInterval := func() {
+		if next := dataCollector.Interval(); next != interval {
+			interval = next
+			ticker.Reset(interval)
+			agent.interval.Store(int64(interval))
+			log.WithFields(logrus.Fields{
+				"nodeName":    cfg.Agent.NodeName,
+				"interval":    interval,
+				"powerSaving": dataCollector.PowerSaving(),
+			}).Info("Collection interval changed")
+		}
+	}
 
 	// Initial collection
-	if err := collectAndUpdate(ctx, cfg, k8sClient, dataCollector); err != nil {
-		log.WithError(err).Error("Initial collection failed")
+	if err := agent.runCycle(ctx, sealer); err != nil {
+		log.WithError(err).WithField("category", models.ErrorCategory(err)).Error("Initial collection failed")
 	}
+	adjustInterval()
 
 	for {
 		select {
@@ -108,74 +526,245 @@ func runCollectionLoop(ctx context.Context, cfg *config.Config, k8sClient *k8s.C
 			log.Info("Collection loop stopped")
 			return ctx.Err()
 		case <-ticker.C:
-			if err := collectAndUpdate(ctx, cfg, k8sClient, dataCollector); err != nil {
-				log.WithError(err).Error("Collection failed")
+			if err := agent.runCycle(ctx, sealer); err != nil {
+				log.WithError(err).WithField("category", models.ErrorCategory(err)).Error("Collection failed")
 				// Continue despite errors - don't stop the loop
 			}
+			adjustInterval()
+		case next := <-agent.reloads:
+			restart, err := dataCollector.Reload(next)
+			if err != nil {
+				log.WithError(err).WithField("nodeName", cfg.Agent.NodeName).Error("Failed to apply configuration")
+				continue
+			}
+			interval = dataCollector.Interval()
+			ticker.Reset(interval)
+			agent.interval.Store(int64(interval))
+			fields := logrus.Fields{
+				"nodeName": cfg.Agent.NodeName,
+				"interval": interval,
+			}
+			if len(restart) > 0 {
+				fields["restartRequired"] = strings.Join(restart, ",")
+			}
+			log.WithFields(fields).Info("Configuration reloaded")
+		}
+	}
+}
+
+// runCycle collects and publishes metrics once, recording the cycle for the
+// health endpoints
+func (a *vehicleAgent) runCycle(ctx context.Context, sealer *envelope.Sealer) error {
+	a.cycleStart.Store(time.Now().UnixNano())
+	defer a.cycleStart.Store(0)
+
+	ctx, endCycle := startCycle(ctx, a.cfg.Agent.NodeName)
+	err := collectAndUpdate(ctx, a, sealer)
+	endCycle(err)
+	if err != nil {
+		return err
+	}
+	a.lastSuccess.Store(time.Now().UnixNano())
+	return nil
+}
+
+// watchConfig reloads the configuration on SIGHUP and, when a config file is
+// used, whenever its content changes (ConfigMap updates replace the mounted
+// file). Invalid configurations are logged and ignored.
+func watchConfig(ctx context.Context, path string, overrides []string, interval time.Duration, apply func(*config.Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var poll <-chan time.Time
+	var last []byte
+	if path != "" && interval > 0 {
+		last, _ = os.ReadFile(path)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	reload := func(reason string) {
+		next, err := config.Load(path, overrides)
+		if err == nil {
+			err = next.Validate()
+		}
+		if err != nil {
+			log.WithError(err).WithField("reason", reason).Error("Ignoring invalid configuration")
+			return
+		}
+		log.WithField("reason", reason).Info("Reloading configuration")
+		setLogLevel(next.Agent.LogLevel)
+		apply(next)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reload("SIGHUP")
+		case <-poll:
+			data, err := os.ReadFile(path)
+			if err != nil || bytes.Equal(data, last) {
+				continue
+			}
+			last = data
+			reload("config file changed")
+		}
+	}
+}
+
+// distributeConfig hands a reloaded configuration to each vehicle's
+// collection loop. vehicles is the vehicle list the agent started with;
+// adding or removing vehicles needs a restart.
+func distributeConfig(vehicles []config.VehicleConfig, next *config.Config, agents []*vehicleAgent) {
+	if !reflect.DeepEqual(vehicles, next.Vehicles) {
+		log.Warn("Vehicle list changed, restart the agent to apply it")
+	}
+	for _, agent := range agents {
+		vehicleCfg := next
+		if len(vehicles) > 0 {
+			vehicleCfg = nil
+			for _, v := range next.Vehicles {
+				if v.Name == agent.cfg.Agent.NodeName {
+					vehicleCfg = next.ForVehicle(v)
+				}
+			}
+			if vehicleCfg == nil {
+				continue
+			}
 		}
+		agent.setBaseConfig(vehicleCfg)
 	}
 }
 
-func collectAndUpdate(ctx context.Context, cfg *config.Config, k8sClient *k8s.Client, dataCollector *collector.Collector) error {
+func collectAndUpdate(ctx context.Context, agent *vehicleAgent, sealer *envelope.Sealer) error {
+	ridPublisher := agent.ridPublisher
 	startTime := time.Now()
 
 	// Collect metrics
-	metrics, err := dataCollector.CollectMetrics(ctx)
+	collectCtx, endCollect := startStage(ctx, "collect")
+	metrics, err := agent.collector.CollectMetrics(collectCtx)
+	endCollect(err)
 	if err != nil {
-		return fmt.Errorf("failed to collect metrics: %w", err)
+		return models.Categorize(models.ErrorCategoryHardware, fmt.Errorf("failed to collect metrics: %w", err))
+	}
+
+	// Serve the latest metrics locally even if the API server is unreachable
+	agent.latest.Store(metrics)
+	if agent.history != nil {
+		agent.history.add(startTime, metrics)
+	}
+	if agent.recorder != nil {
+		if err := agent.recorder.Record(startTime, metrics); err != nil {
+			log.WithError(err).WithField("nodeName", agent.cfg.Agent.NodeName).Warn("Failed to record sample")
+		}
+	}
+	if agent.homes != nil {
+		if err := agent.homes.Save(agent.cfg.Agent.NodeName, metrics.Home); err != nil {
+			log.WithError(err).WithField("nodeName", agent.cfg.Agent.NodeName).Warn("Failed to save home point")
+		}
+	}
+	if agent.telemetry != nil {
+		agent.telemetry.publish(metrics)
 	}
 
 	collectionDuration := time.Since(startTime)
 
+	// Partial metrics are still published; the failed sections are annotated
+	for _, e := range metrics.CollectionErrors {
+		log.WithField("section", e.Section).Warnf("Failed to collect %s data: %s", e.Section, e.Error)
+		collectionErrors.Add(ctx, 1, metric.WithAttributes(attribute.String("section", e.Section)))
+	}
+
 	log.WithFields(logrus.Fields{
 		"nodeName":     metrics.NodeName,
 		"battery":      fmt.Sprintf("%.1f%%", metrics.Battery.RemainingPercent),
 		"gps_lat":      fmt.Sprintf("%.6f", metrics.GPS.Latitude),
 		"gps_lon":      fmt.Sprintf("%.6f", metrics.GPS.Longitude),
 		"gps_sats":     metrics.GPS.Satellites,
-		"health":       metrics.Health.Status,
+		"health":       healthStatus(metrics),
 		"duration_ms":  collectionDuration.Milliseconds(),
 	}).Debug("Metrics collected")
 
-	// Update CRD with retry
-	updateStart := time.Now()
-	if err := k8sClient.CreateOrUpdateWithRetry(ctx, metrics); err != nil {
-		return fmt.Errorf("failed to update CRD: %w", err)
+	// Another instance on this node (e.g. the other pod of a rollout) publishes
+	if agent.leader != nil && !agent.leader.IsLeader() {
+		log.WithField("nodeName", metrics.NodeName).Debug("Standing by, another agent instance holds the lease")
+		return nil
 	}
-	updateDuration := time.Since(updateStart)
 
-	// Determine phase based on health
-	phase := "Active"
-	if metrics.Health != nil {
-		switch metrics.Health.Status {
-		case "Critical":
-			phase = "Error"
-		case "Warning", "Healthy":
-			phase = "Active"
-		default:
-			phase = "Unknown"
+	// Publish Remote ID before the CRD update so the broadcast is never delayed by the API server.
+	// Simulated positions are never broadcast.
+	if ridPublisher != nil && !metrics.Simulated {
+		ridCtx, endRID := startStage(ctx, "remote-id publish")
+		err := ridPublisher.Publish(ridCtx, metrics)
+		endRID(err)
+		if err != nil {
+			log.WithError(err).Warn("Failed to publish Remote ID")
+		}
+	}
+
+	// Seal sensitive fields before they leave the node
+	published := metrics
+	if sealer != nil {
+		_, endSeal := startStage(ctx, "seal")
+		published, err = sealer.Seal(metrics)
+		endSeal(err)
+		if err != nil {
+			return fmt.Errorf("failed to seal sensitive fields: %w", err)
 		}
 	}
 
-	// Update status
-	if err := k8sClient.UpdateStatus(ctx, metrics.NodeName, phase); err != nil {
-		log.WithError(err).Warn("Failed to update status")
-		// Don't return error for status update failures
+	// Alerts don't depend on the API server being reachable. They leave the
+	// node like the other destinations, so they carry the sealed sample.
+	if agent.alerts != nil {
+		agent.alerts.Observe(published)
+	}
+
+	// Publish to the enabled sinks: the CRD (or the aggregator), MQTT, Prometheus, a file
+	updateStart := time.Now()
+	if err := agent.sinks.Publish(ctx, published); err != nil {
+		return err
+	}
+	updateDuration := time.Since(updateStart)
+
+	// The CRD sink is left out of dry runs
+	if agent.cfg.Agent.DryRun {
+		logDryRun(published, collectionDuration)
+		return nil
 	}
 
 	totalDuration := time.Since(startTime)
 
+	var healthErrors, healthWarnings int
+	if metrics.Health != nil {
+		healthErrors, healthWarnings = len(metrics.Health.Errors), len(metrics.Health.Warnings)
+	}
 	log.WithFields(logrus.Fields{
 		"nodeName":          metrics.NodeName,
 		"battery":           fmt.Sprintf("%.1f%%", metrics.Battery.RemainingPercent),
-		"health":            metrics.Health.Status,
-		"errors":            len(metrics.Health.Errors),
-		"warnings":          len(metrics.Health.Warnings),
+		"health":            healthStatus(metrics),
+		"errors":            healthErrors,
+		"warnings":          healthWarnings,
 		"collection_ms":     collectionDuration.Milliseconds(),
 		"update_ms":         updateDuration.Milliseconds(),
 		"total_ms":          totalDuration.Milliseconds(),
 	}).Info("Metrics updated successfully")
 
+	// Security alert for positions that fail GNSS integrity checks
+	if metrics.GPS.PositionDegraded() {
+		log.WithFields(logrus.Fields{
+			"audit":                true,
+			"action":               "gnss-integrity-alert",
+			"nodeName":             metrics.NodeName,
+			"horizontalDivergence": metrics.GPS.Integrity.HorizontalDivergence,
+			"verticalDivergence":   metrics.GPS.Integrity.VerticalDivergence,
+			"reasons":              metrics.GPS.Integrity.Reasons,
+		}).Error("Security alert: suspected GNSS spoofing or jamming")
+	}
+
 	// Log warnings and errors
 	if metrics.Health != nil {
 		for _, warning := range metrics.Health.Warnings {
@@ -189,15 +778,61 @@ func collectAndUpdate(ctx context.Context, cfg *config.Config, k8sClient *k8s.Cl
 	return nil
 }
 
-func initLogger() {
+// logDryRun logs the metrics a dry run would have written to the CRD
+func logDryRun(metrics *models.UAVMetrics, collectionDuration time.Duration) {
+	entry := log.WithFields(logrus.Fields{
+		"nodeName":      metrics.NodeName,
+		"battery":       fmt.Sprintf("%.1f%%", metrics.Battery.RemainingPercent),
+		"health":        healthStatus(metrics),
+		"collection_ms": collectionDuration.Milliseconds(),
+	})
+	if data, err := json.Marshal(metrics); err == nil {
+		entry = entry.WithField("metrics", string(data))
+	}
+	entry.Info("Dry run: metrics not written")
+}
+
+// healthStatus returns the health status of metrics, unknown when health
+// checks are disabled
+func healthStatus(metrics *models.UAVMetrics) string {
+	if metrics.Health == nil {
+		return models.HealthStatusUnknown
+	}
+	return metrics.Health.Status
+}
+
+func initLogger(logLevel string) {
 	// Set log format
 	log.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp:   true,
 		TimestampFormat: "2006-01-02 15:04:05",
 	})
 
-	// Set log level from environment or default to Info
-	logLevel := os.Getenv("LOG_LEVEL")
+	setLogLevel(logLevel)
+
+	// Use JSON format if structured logging is enabled
+	if os.Getenv("STRUCTURED_LOGGING") == "true" {
+		log.SetFormatter(&logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339,
+		})
+	}
+
+	log.SetOutput(os.Stdout)
+}
+
+// openLogFile additionally writes logs to the rotating log file configured
+// for the agent
+func openLogFile(cfg config.AgentConfig) (*logfile.Writer, error) {
+	w, err := logfile.Open(cfg.LogFile, int64(cfg.LogFileMaxSize)<<20, cfg.LogFileMaxAge, 0, cfg.LogFileMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	log.SetOutput(io.MultiWriter(os.Stdout, w))
+	return w, nil
+}
+
+// setLogLevel sets the log level, defaulting to Info
+func setLogLevel(logLevel string) {
 	switch logLevel {
 	case "debug":
 		log.SetLevel(logrus.DebugLevel)
@@ -210,13 +845,4 @@ func initLogger() {
 	default:
 		log.SetLevel(logrus.InfoLevel)
 	}
-
-	// Use JSON format if structured logging is enabled
-	if os.Getenv("STRUCTURED_LOGGING") == "true" {
-		log.SetFormatter(&logrus.JSONFormatter{
-			TimestampFormat: time.RFC3339,
-		})
-	}
-
-	log.SetOutput(os.Stdout)
 }
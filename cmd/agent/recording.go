@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/recorder"
+	"github.com/k3suav/uav-monitor/pkg/securestore"
+	"github.com/k3suav/uav-monitor/pkg/sink"
+)
+
+// openStore returns the store encrypting local state at rest, or nil when
+// storage.encryptAtRest is not set
+func openStore(cfg *config.Config) (*securestore.Store, error) {
+	if !cfg.Storage.EncryptAtRest {
+		return nil, nil
+	}
+	key, err := securestore.LoadKey(cfg.Storage.EncryptionKeyPath, cfg.Storage.GenerateKey)
+	if err != nil {
+		return nil, err
+	}
+	return securestore.New(key)
+}
+
+// openRecorder opens the recording database of storage.recordingPath,
+// encrypted at rest when storage.encryptAtRest is set
+func openRecorder(cfg *config.Config) (*recorder.Recorder, error) {
+	store, err := openStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	policy, _ := cfg.Retention.PolicyFor(cfg.Kubernetes.Namespace, config.RetentionRecording)
+	return recorder.Open(cfg.Storage.RecordingPath, cfg.Storage.RecordingMaxSamples, policy.MaxAge, store)
+}
+
+// exportRecording writes every recorded sample to w as JSON lines, vehicle
+// by vehicle and oldest first, for post-flight analysis
+func exportRecording(cfg *config.Config, w io.Writer) error {
+	rec, err := openRecorder(cfg)
+	if err != nil {
+		return err
+	}
+	defer rec.Close()
+
+	vehicles, err := rec.Vehicles()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	for _, node := range vehicles {
+		samples, err := rec.Query(node, time.Time{}, time.Time{}, 0)
+		if err != nil {
+			return err
+		}
+		for _, sample := range samples {
+			if err := encoder.Encode(sample); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportFileSink writes the samples of a file written by the file sink to w
+// as JSON lines, decrypted with the storage.encryptAtRest key
+func exportFileSink(cfg *config.Config, path string, w io.Writer) error {
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return sink.ReadFileSink(file, store, w)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// telemetryBuffer is the number of samples queued per subscriber before
+// further samples are dropped
+const telemetryBuffer = 16
+
+// telemetryHub fans collected samples out to gRPC subscribers. Publishing
+// never blocks the collection loop: a subscriber that falls behind loses
+// samples instead.
+type telemetryHub struct {
+	mu   sync.Mutex
+	subs map[*telemetrySub]struct{}
+}
+
+type telemetrySub struct {
+	node    string // empty for all vehicles
+	samples chan []byte
+	dropped atomic.Uint64
+}
+
+func newTelemetryHub() *telemetryHub {
+	return &telemetryHub{subs: make(map[*telemetrySub]struct{})}
+}
+
+func (h *telemetryHub) subscribe(node string) *telemetrySub {
+	sub := &telemetrySub{node: node, samples: make(chan []byte, telemetryBuffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+func (h *telemetryHub) unsubscribe(sub *telemetrySub) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, sub)
+}
+
+// publish sends a sample to every matching subscriber, encoding it once
+func (h *telemetryHub) publish(metrics *models.UAVMetrics) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.subs) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		log.WithError(err).Warn("Failed to encode telemetry sample")
+		return
+	}
+	for sub := range h.subs {
+		if sub.node != "" && sub.node != metrics.NodeName {
+			continue
+		}
+		select {
+		case sub.samples <- data:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// telemetryService is the hand-written descriptor of uav.telemetry.v1.Telemetry
+// (api/proto/telemetry.proto)
+var telemetryService = grpc.ServiceDesc{
+	ServiceName: "uav.telemetry.v1.Telemetry",
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       handleSubscribe,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "telemetry.proto",
+}
+
+type telemetryServer struct {
+	hub    *telemetryHub
+	agents []*vehicleAgent
+}
+
+func handleSubscribe(srv interface{}, stream grpc.ServerStream) error {
+	s := srv.(*telemetryServer)
+	req := &wrapperspb.StringValue{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	node := req.GetValue()
+
+	var selected []*vehicleAgent
+	for _, agent := range s.agents {
+		if node == "" || agent.cfg.Agent.NodeName == node {
+			selected = append(selected, agent)
+		}
+	}
+	if len(selected) == 0 {
+		return status.Errorf(codes.NotFound, "unknown vehicle %q", node)
+	}
+
+	sub := s.hub.subscribe(node)
+	defer s.hub.unsubscribe(sub)
+
+	entry := log.WithField("node", node)
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		entry = entry.WithField("peer", p.Addr.String())
+	}
+	entry.Debug("Telemetry subscriber connected")
+	defer func() {
+		entry.WithField("dropped", sub.dropped.Load()).Debug("Telemetry subscriber disconnected")
+	}()
+
+	// Start with the latest sample so subscribers don't wait a full interval
+	for _, agent := range selected {
+		metrics := agent.latest.Load()
+		if metrics == nil {
+			continue
+		}
+		data, err := json.Marshal(metrics)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to encode metrics: %v", err)
+		}
+		if err := stream.SendMsg(wrapperspb.String(string(data))); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case data := <-sub.samples:
+			if err := stream.SendMsg(wrapperspb.String(string(data))); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// serveTelemetry serves the telemetry gRPC service on addr until ctx is
+// cancelled. Like the local REST API, samples are unsealed.
+func serveTelemetry(ctx context.Context, addr string, hub *telemetryHub, agents []*vehicleAgent) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer()
+	server.RegisterService(&telemetryService, &telemetryServer{hub: hub, agents: agents})
+
+	go func() {
+		<-ctx.Done()
+		// Subscriptions never end on their own, so don't wait for them
+		server.Stop()
+	}()
+
+	return server.Serve(lis)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// watchdogCheckInterval is how often the watchdog checks the collection loops
+const watchdogCheckInterval = time.Second
+
+// runWatchdog watches every vehicle's collection loop until ctx is
+// cancelled. A data source blocking collection for intervals collection
+// intervals is reinitialized; when collection is still blocked after as many
+// intervals more, the agent exits with the hardware exit code so Kubernetes
+// restarts it.
+func runWatchdog(ctx context.Context, agents []*vehicleAgent, intervals int) {
+	ticker := time.NewTicker(watchdogCheckInterval)
+	defer ticker.Stop()
+
+	// Start of the blocked step each vehicle's data source was reinitialized for
+	interrupted := make(map[*vehicleAgent]time.Time, len(agents))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, agent := range agents {
+				checkCollection(agent, intervals, interrupted, now)
+			}
+		}
+	}
+}
+
+func checkCollection(agent *vehicleAgent, intervals int, interrupted map[*vehicleAgent]time.Time, now time.Time) {
+	section, since := agent.collector.Busy()
+	if section == "" {
+		return
+	}
+	limit := time.Duration(intervals) * time.Duration(agent.interval.Load())
+	blocked := now.Sub(since)
+	fields := logrus.Fields{
+		"nodeName": agent.cfg.Agent.NodeName,
+		"section":  section,
+		"blocked":  blocked.Round(time.Second),
+	}
+
+	switch {
+	case limit <= 0 || blocked <= limit:
+	case interrupted[agent].Equal(since):
+		if blocked > 2*limit {
+			err := fmt.Errorf("collection blocked in %s for %s", section, blocked.Round(time.Second))
+			fatal(models.Categorize(models.ErrorCategoryHardware, err), "Collection still blocked after reinitializing the data source", fields)
+		}
+	default:
+		log.WithFields(fields).Warn("Collection blocked, reinitializing the data source")
+		agent.collector.Interrupt(section)
+		interrupted[agent] = since
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/aggregator"
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/sirupsen/logrus"
+)
+
+const version = "v0.1.0"
+
+// statsInterval is how often the counters are logged
+const statsInterval = time.Minute
+
+func main() {
+	log := logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	if level, err := logrus.ParseLevel(os.Getenv("LOG_LEVEL")); err == nil {
+		log.SetLevel(level)
+	}
+
+	log.WithField("version", version).Info("Starting UAV Aggregator")
+
+	// Same environment variables as the agent for the API server connection
+	cfg := config.DefaultConfig()
+	flag.BoolVar(&cfg.Kubernetes.InstallCRD, "install-crd", cfg.Kubernetes.InstallCRD, "Install or upgrade the UAVMetrics CRD at startup")
+	flag.Parse()
+	if err := cfg.ValidateAggregator(); err != nil {
+		log.WithError(err).Fatal("Invalid configuration")
+	}
+
+	client, err := k8s.NewClient(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Kubernetes client")
+	}
+	if cfg.Kubernetes.InstallCRD {
+		if err := client.EnsureCRD(context.Background()); err != nil {
+			log.WithError(err).Fatal("Failed to install the UAVMetrics CRD")
+		}
+		log.Info("UAVMetrics CRD established")
+	}
+
+	log.WithFields(logrus.Fields{
+		"listen":        cfg.Aggregator.Listen,
+		"namespace":     cfg.Kubernetes.Namespace,
+		"flushInterval": cfg.Aggregator.FlushInterval,
+		"concurrency":   cfg.Aggregator.Concurrency,
+		"fieldManager":  cfg.Aggregator.FieldManager,
+	}).Info("Configuration loaded")
+
+	// Agents present their enrollment certificate
+	tlsConfig, err := aggregator.ServerTLSConfig(cfg.Aggregator.TLSCertFile, cfg.Aggregator.TLSKeyFile, cfg.Enrollment.CACertPath)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid TLS configuration")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	agg := aggregator.New(client, cfg.Aggregator, log)
+	flushed := make(chan struct{})
+	go func() {
+		agg.Run(ctx)
+		close(flushed)
+	}()
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- agg.Serve(ctx, cfg.Aggregator.Listen, tlsConfig, cfg.Aggregator.HealthListen)
+	}()
+
+	go func() {
+		ticker := time.NewTicker(statsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				stats := agg.Stats()
+				log.WithFields(logrus.Fields{
+					"pending":   stats.Pending,
+					"received":  stats.Received,
+					"coalesced": stats.Coalesced,
+					"stale":     stats.Stale,
+					"written":   stats.Written,
+					"failed":    stats.Failed,
+				}).Info("Aggregator stats")
+			}
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case sig := <-sigChan:
+		log.WithField("signal", sig).Info("Received shutdown signal")
+	case err := <-errChan:
+		log.WithError(err).Error("gRPC server stopped")
+	}
+	cancel()
+
+	// Write the samples still pending before exiting
+	<-flushed
+	log.Info("UAV Aggregator stopped")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/conversion"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/sirupsen/logrus"
+)
+
+const version = "v0.1.0"
+
+func main() {
+	log := logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	if level, err := logrus.ParseLevel(os.Getenv("LOG_LEVEL")); err == nil {
+		log.SetLevel(level)
+	}
+
+	log.WithField("version", version).Info("Starting UAV Conversion Webhook")
+
+	cfg := config.DefaultConfig()
+	if err := cfg.ValidateConversionWebhook(); err != nil {
+		log.WithError(err).Fatal("Invalid configuration")
+	}
+
+	webhook, err := conversion.NewWebhook(log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create conversion webhook")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/convert", webhook)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := &http.Server{
+		Addr:              cfg.ConversionWebhook.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.ListenAndServeTLS(cfg.ConversionWebhook.CertFile, cfg.ConversionWebhook.KeyFile)
+	}()
+	log.WithField("listen", cfg.ConversionWebhook.Listen).Info("Serving UAVMetrics conversions")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Point the CRD's conversion at this webhook (optional)
+	if cfg.ConversionWebhook.Service != "" {
+		client, err := k8s.NewClient(cfg)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create Kubernetes client")
+		}
+		go conversion.Install(ctx, client, cfg.ConversionWebhook, log)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case sig := <-sigChan:
+		log.WithField("signal", sig).Info("Received shutdown signal")
+	case err := <-errChan:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Error("HTTPS server stopped")
+		}
+	}
+
+	cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	_ = server.Shutdown(shutdownCtx)
+
+	log.Info("UAV Conversion Webhook stopped")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/decommission"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/migration"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+const version = "v0.1.0"
+
+func main() {
+	log := logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	if level, err := logrus.ParseLevel(os.Getenv("LOG_LEVEL")); err == nil {
+		log.SetLevel(level)
+	}
+
+	log.WithField("version", version).Info("Starting UAV Decommission Controller")
+
+	// Same environment variables as the agent for the API server connection
+	cfg := config.DefaultConfig()
+	if err := cfg.ValidateDecommissionController(); err != nil {
+		log.WithError(err).Fatal("Invalid configuration")
+	}
+
+	client, err := k8s.NewClient(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Kubernetes client")
+	}
+
+	var checkpointer *migration.Checkpointer
+	if cfg.Decommission.CheckpointRegistry != "" {
+		clientset, err := kubernetes.NewForConfig(client.RestConfig())
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create Kubernetes clientset")
+		}
+		checkpointer = migration.NewCheckpointer(clientset, cfg.Decommission, cfg.Kubernetes.Namespace, log)
+	}
+
+	controller := decommission.NewController(client, checkpointer, cfg, log)
+
+	log.WithFields(logrus.Fields{
+		"namespace":          cfg.Kubernetes.Namespace,
+		"archiveDir":         cfg.Decommission.ArchiveDir,
+		"agentAPIPort":       cfg.Decommission.AgentAPIPort,
+		"checkpointRegistry": cfg.Decommission.CheckpointRegistry,
+	}).Info("Configuration loaded")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go controller.Run(ctx)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigChan
+	log.WithField("signal", sig).Info("Received shutdown signal")
+	cancel()
+	client.ShutdownEvents()
+
+	log.Info("UAV Decommission Controller stopped")
+}
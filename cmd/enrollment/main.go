@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/enrollment"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/sirupsen/logrus"
+)
+
+const version = "v0.1.0"
+
+func main() {
+	log := logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	if level, err := logrus.ParseLevel(os.Getenv("LOG_LEVEL")); err == nil {
+		log.SetLevel(level)
+	}
+
+	log.WithField("version", version).Info("Starting UAV Enrollment Controller")
+
+	// Same environment variables as the agent for the API server connection
+	cfg := config.DefaultConfig()
+	if err := cfg.ValidateEnrollmentController(); err != nil {
+		log.WithError(err).Fatal("Invalid configuration")
+	}
+
+	client, err := k8s.NewClient(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Kubernetes client")
+	}
+
+	controller, err := enrollment.NewController(client, cfg, log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load fleet CA")
+	}
+
+	log.WithFields(logrus.Fields{
+		"namespace":          cfg.Kubernetes.Namespace,
+		"certValidity":       cfg.Enrollment.CertValidity,
+		"autoApproveSerials": cfg.Enrollment.AutoApproveSerials,
+		"autoApproveModels":  cfg.Enrollment.AutoApproveModels,
+	}).Info("Configuration loaded")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go controller.Run(ctx)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigChan
+	log.WithField("signal", sig).Info("Received shutdown signal")
+	cancel()
+
+	log.Info("UAV Enrollment Controller stopped")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/export"
+	"github.com/k3suav/uav-monitor/pkg/fleetcache"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/sirupsen/logrus"
+)
+
+const version = "v0.1.0"
+
+func main() {
+	log := logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	if level, err := logrus.ParseLevel(os.Getenv("LOG_LEVEL")); err == nil {
+		log.SetLevel(level)
+	}
+
+	log.WithField("version", version).Info("Starting UAV Fleet Exporter")
+
+	// Same environment variables as the agent for the API server connection
+	cfg := config.DefaultConfig()
+	if err := cfg.ValidateExporter(); err != nil {
+		log.WithError(err).Fatal("Invalid configuration")
+	}
+
+	client, err := k8s.NewClient(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Kubernetes client")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Federated deployments read the fleet from the snapshot cache
+	if cfg.FleetCache.Address != "" {
+		fleetCache, err := fleetcache.NewClient(cfg.FleetCache.Address, cfg.FleetCache.CAFile, log)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create fleet cache client")
+		}
+		client.SetFleetSource(fleetCache)
+		go fleetCache.Run(ctx)
+		log.WithField("address", cfg.FleetCache.Address).Info("Reading fleet from cache")
+	}
+
+	file, err := os.Create(cfg.Export.Output)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create output file")
+	}
+	writer, err := export.NewWriter(cfg.Export.ExportFormat(), file)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create export writer")
+	}
+
+	log.WithFields(logrus.Fields{
+		"output":        cfg.Export.Output,
+		"format":        cfg.Export.ExportFormat(),
+		"interval":      cfg.Export.Interval,
+		"duration":      cfg.Export.Duration,
+		"schedulerName": cfg.Export.SchedulerName,
+	}).Info("Configuration loaded")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigChan
+		log.WithField("signal", sig).Info("Received shutdown signal")
+		cancel()
+	}()
+
+	exportErr := export.NewExporter(client, cfg, log).Run(ctx, writer)
+
+	// Parquet files are only readable once their footer is written
+	if err := writer.Close(); err != nil {
+		log.WithError(err).Error("Failed to complete export file")
+	}
+	if err := file.Close(); err != nil {
+		log.WithError(err).Error("Failed to close export file")
+	}
+	if exportErr != nil {
+		log.WithError(exportErr).Fatal("Export failed")
+	}
+
+	log.Info("UAV Fleet Exporter stopped")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/fleetcache"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/sirupsen/logrus"
+)
+
+const version = "v0.1.0"
+
+func main() {
+	log := logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	if level, err := logrus.ParseLevel(os.Getenv("LOG_LEVEL")); err == nil {
+		log.SetLevel(level)
+	}
+
+	log.WithField("version", version).Info("Starting UAV Fleet Cache")
+
+	// Same environment variables as the agent for the API server connection
+	cfg := config.DefaultConfig()
+	if err := cfg.ValidateFleetCache(); err != nil {
+		log.WithError(err).Fatal("Invalid configuration")
+	}
+
+	client, err := k8s.NewClient(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Kubernetes client")
+	}
+
+	log.WithFields(logrus.Fields{
+		"listen":          cfg.FleetCache.Listen,
+		"healthListen":    cfg.FleetCache.HealthListen,
+		"namespace":       cfg.Kubernetes.Namespace,
+		"refreshInterval": cfg.FleetCache.RefreshInterval,
+		"history":         cfg.FleetCache.History,
+	}).Info("Configuration loaded")
+
+	tlsConfig, err := fleetcache.ServerTLSConfig(cfg.FleetCache.TLSCertFile, cfg.FleetCache.TLSKeyFile)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load TLS configuration")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache := fleetcache.New(client, cfg.FleetCache, log)
+	go cache.Run(ctx)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- cache.Serve(ctx, cfg.FleetCache.Listen, tlsConfig, cfg.FleetCache.HealthListen)
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case sig := <-sigChan:
+		log.WithField("signal", sig).Info("Received shutdown signal")
+	case err := <-errChan:
+		log.WithError(err).Error("gRPC server stopped")
+	}
+	cancel()
+
+	log.Info("UAV Fleet Cache stopped")
+}
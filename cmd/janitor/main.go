@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/retention"
+	"github.com/sirupsen/logrus"
+)
+
+const version = "v0.1.0"
+
+func main() {
+	log := logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	if level, err := logrus.ParseLevel(os.Getenv("LOG_LEVEL")); err == nil {
+		log.SetLevel(level)
+	}
+
+	log.WithField("version", version).Info("Starting UAV Retention Janitor")
+
+	// Same environment variables as the agent, so policies are shared
+	cfg := config.DefaultConfig()
+	if err := cfg.ValidateJanitor(); err != nil {
+		log.WithError(err).Fatal("Invalid configuration")
+	}
+
+	client, err := k8s.NewClient(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Kubernetes client")
+	}
+
+	for _, policy := range cfg.Retention.Policies {
+		log.WithFields(logrus.Fields{
+			"fleet":    policy.Fleet,
+			"dataType": policy.DataType,
+			"maxAge":   policy.MaxAge,
+			"minAge":   policy.MinAge,
+		}).Info("Retention policy loaded")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go retention.NewJanitor(client, cfg, log).Run(ctx)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigChan
+	log.WithField("signal", sig).Info("Received shutdown signal")
+	cancel()
+
+	log.Info("UAV Retention Janitor stopped")
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s/fake"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/router"
+	"github.com/k3suav/uav-monitor/pkg/router/algorithm"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// 演示服务（endpoint 分布在各模拟 UAV 上）和作为路由源节点的 UAV
+const (
+	demoNamespace = "demo"
+	demoService   = "web"
+	demoSource    = "demo-uav-1"
+)
+
+// demoVehicle 演示机队中的一架 UAV 及其上运行的服务 Pod
+type demoVehicle struct {
+	node        string
+	lat, lon    float64
+	battery     float64
+	podIPs      []string // 第一个地址写入 Endpoints，其余为双栈 Pod 的另一地址族
+	hostNetwork bool
+}
+
+var demoFleet = []demoVehicle{
+	{node: demoSource, lat: 22.5431, lon: 114.0579, battery: 90, podIPs: []string{"10.42.0.10"}},
+	{node: "demo-uav-2", lat: 22.5480, lon: 114.0650, battery: 75, podIPs: []string{"10.42.1.10", "fd00:42:1::10"}},
+	{node: "demo-uav-3", lat: 22.5602, lon: 114.0811, battery: 15, podIPs: []string{"10.42.2.10"}},
+	{node: "demo-uav-4", lat: 22.5305, lon: 114.0402, battery: 60, podIPs: []string{"192.168.10.4", "fd00:10::4"}, hostNetwork: true},
+}
+
+// runDemo 在进程内运行完整的路由链路（fake clientset + 内存 UAV 客户端 + HTTP handler），
+// 无需集群：加载模拟机队和服务后依次请求 /route 和 /route/compare 并输出响应
+func runDemo(log *logrus.Logger) {
+	algorithmName := os.Getenv("ALGORITHM")
+	if algorithmName == "" {
+		algorithmName = "distance-based"
+	}
+
+	now := time.Now()
+	uavClient := fake.NewUAVClient()
+	objects := make([]runtime.Object, 0, len(demoFleet)+1)
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: demoNamespace, Name: demoService},
+		Subsets:    []v1.EndpointSubset{{Ports: []v1.EndpointPort{{Name: "http", Port: 8080}}}},
+	}
+	for i, v := range demoFleet {
+		uavClient.SetUAVMetrics(&models.UAVMetrics{
+			NodeName: v.node,
+			GPS:      models.GPSData{Latitude: v.lat, Longitude: v.lon, Altitude: 120, Satellites: 12, LastUpdate: now},
+			Battery:  models.BatteryData{RemainingPercent: v.battery, Voltage: 22.2},
+			Health:   &models.HealthData{Status: models.HealthStatusHealthy, LastHealthCheck: now},
+		})
+
+		podName := fmt.Sprintf("%s-%d", demoService, i+1)
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: demoNamespace, Name: podName},
+			Spec:       v1.PodSpec{NodeName: v.node, HostNetwork: v.hostNetwork},
+		}
+		for _, ip := range v.podIPs {
+			pod.Status.PodIPs = append(pod.Status.PodIPs, v1.PodIP{IP: ip})
+			if v.hostNetwork {
+				pod.Status.HostIPs = append(pod.Status.HostIPs, v1.HostIP{IP: ip})
+			}
+		}
+		objects = append(objects, pod)
+
+		nodeName := v.node
+		endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, v1.EndpointAddress{
+			IP:        v.podIPs[0],
+			NodeName:  &nodeName,
+			TargetRef: &v1.ObjectReference{Kind: "Pod", Namespace: demoNamespace, Name: podName},
+		})
+	}
+	objects = append(objects, endpoints)
+
+	// 演示只输出结果，Router 自身的日志降为警告
+	quiet := logrus.New()
+	quiet.SetLevel(logrus.WarnLevel)
+
+	routerAgent := router.NewRouterAgent(
+		demoSource,
+		k8sfake.NewClientset(objects...),
+		uavClient,
+		createRoutingAlgorithm(algorithmName, log),
+		0, // 不检测失联
+		nil,
+		nil,
+		nil,
+		0,
+		quiet,
+	)
+	var candidates []algorithm.RoutingAlgorithm
+	for _, name := range []string{"distance-based", "battery-aware", "composite"} {
+		if name != algorithmName {
+			candidates = append(candidates, createRoutingAlgorithm(name, discardLogger()))
+		}
+	}
+	routerAgent.SetComparisonAlgorithms(candidates)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	log.WithFields(logrus.Fields{
+		"source":    demoSource,
+		"vehicles":  len(demoFleet),
+		"service":   demoNamespace + "/" + demoService,
+		"algorithm": algorithmName,
+	}).Info("Starting router demo with an in-memory fleet")
+	if err := routerAgent.Start(ctx); err != nil {
+		log.WithError(err).Fatal("Failed to start router agent")
+	}
+
+	// Endpoints 由 informer 异步加载
+	for routerAgent.GetCacheStats()["services_cached"] == 0 {
+		select {
+		case <-ctx.Done():
+			log.Fatal("Timed out waiting for the endpoints cache")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	handler := router.NewServer(routerAgent, 0, "", quiet).Handler()
+	service := demoNamespace + "/" + demoService
+	for _, path := range []string{
+		"/route?service=" + service,
+		"/route?service=" + service + "&family=ipv4",
+		"/route?service=" + service + "&family=ipv6",
+		"/route/compare?service=" + service + "&iterations=10",
+		"/stats",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		body := rec.Body.Bytes()
+		var indented bytes.Buffer
+		if json.Indent(&indented, body, "", "  ") == nil {
+			body = indented.Bytes()
+		}
+		fmt.Printf("GET %s -> %d\n%s\n", path, rec.Code, bytes.TrimSpace(body))
+	}
+}
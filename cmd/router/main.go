@@ -2,18 +2,24 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/fleetcache"
 	"github.com/k3suav/uav-monitor/pkg/k8s"
 	"github.com/k3suav/uav-monitor/pkg/router"
 	"github.com/k3suav/uav-monitor/pkg/router/algorithm"
+	"github.com/k3suav/uav-monitor/pkg/terrain"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 func main() {
@@ -24,6 +30,12 @@ func main() {
 		FullTimestamp: true,
 	})
 
+	// router demo：无需集群，在进程内以模拟机队演示完整路由链路
+	if len(os.Args) > 1 && os.Args[1] == "demo" {
+		runDemo(log)
+		return
+	}
+
 	// 获取节点名称
 	nodeName := os.Getenv("NODE_NAME")
 	if nodeName == "" {
@@ -42,29 +54,88 @@ func main() {
 		// 可以添加端口解析逻辑
 	}
 
-	log.WithFields(logrus.Fields{
-		"node":      nodeName,
-		"algorithm": algorithmName,
-		"port":      apiPort,
-	}).Info("Starting UAV Router Agent")
+	// 失联判定超时（超过此时间未上报的 UAV 视为 Lost，0 表示不检测）
+	lostTimeout := 60 * time.Second
+	if value := os.Getenv("LOST_TIMEOUT"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid LOST_TIMEOUT")
+		}
+		lostTimeout = d
+	}
 
-	// 创建 Kubernetes 客户端
-	k8sConfig, err := getK8sConfig()
-	if err != nil {
-		log.WithError(err).Fatal("Failed to get Kubernetes config")
+	// 失联 UAV 搜索区域估算参数：风速（m/s）、风向（来向，度）、失联点最小搜索半径（米）
+	windSpeed := getEnvFloat("SEARCH_WIND_SPEED", 0, log)
+	windDirection := getEnvFloat("SEARCH_WIND_DIRECTION", 0, log)
+	searchRadius := getEnvFloat("SEARCH_BASE_RADIUS", 100, log)
+
+	// 多跳中继：地面站列表（name:lat:lon[:alt]，逗号分隔），未配置时不提供 /relay
+	var relayPlanner *router.RelayPlanner
+	if value := os.Getenv("RELAY_GROUND_STATIONS"); value != "" {
+		stations, err := router.ParseGroundStations(strings.Split(value, ","))
+		if err != nil {
+			log.WithError(err).Fatal("Invalid RELAY_GROUND_STATIONS")
+		}
+		relayPlanner = router.NewRelayPlanner(
+			newRadioModel(log),
+			stations,
+			getEnvFloat("MIN_LINK_BANDWIDTH", 1, log),
+			getEnvFloat("RELAY_HOP_PENALTY", 5, log),
+		)
 	}
 
-	k8sClientset, err := kubernetes.NewForConfig(k8sConfig)
-	if err != nil {
-		log.WithError(err).Fatal("Failed to create Kubernetes clientset")
+	// gossip 节点存活检测：GOSSIP_PORT 非 0 时启用，种子地址逗号分隔
+	// GOSSIP_KEY 为所有节点共享的报文签名密钥，启用时必须设置
+	var gossip *router.Gossip
+	if port := getEnvInt("GOSSIP_PORT", 0, log); port > 0 {
+		advertise := os.Getenv("POD_IP")
+		if advertise == "" {
+			log.Fatal("POD_IP is required for gossip peer liveness")
+		}
+		key := os.Getenv("GOSSIP_KEY")
+		if len(key) < 16 {
+			log.Fatal("GOSSIP_KEY of at least 16 bytes is required for gossip peer liveness")
+		}
+		var seeds []string
+		if value := os.Getenv("GOSSIP_SEEDS"); value != "" {
+			seeds = strings.Split(value, ",")
+		}
+		gossip = router.NewGossip(
+			nodeName,
+			[]byte(key),
+			fmt.Sprintf(":%d", port),
+			net.JoinHostPort(advertise, strconv.Itoa(port)),
+			seeds,
+			getEnvDuration("GOSSIP_INTERVAL", 250*time.Millisecond, log),
+			getEnvDuration("GOSSIP_FAIL_TIMEOUT", 2*time.Second, log),
+			log,
+		)
 	}
 
+	log.WithFields(logrus.Fields{
+		"node":        nodeName,
+		"algorithm":   algorithmName,
+		"port":        apiPort,
+		"lostTimeout": lostTimeout,
+	}).Info("Starting UAV Router Agent")
+
 	// 创建 UAV Metrics 客户端
 	uavConfig := config.DefaultConfig()
+	flag.Parse()
 	uavClient, err := k8s.NewClient(uavConfig)
 	if err != nil {
 		log.WithError(err).Fatal("Failed to create UAV metrics client")
 	}
+	// 每个节点都运行 Router，滚动更新期间新旧版本会来回覆盖 CRD，由 Scheduler 或聚合代理安装
+	if uavConfig.Kubernetes.InstallCRD {
+		log.Warn("INSTALL_CRD is ignored by the router, install the UAVMetrics CRD with the scheduler or aggregator")
+	}
+
+	// 创建 Kubernetes 客户端，与 UAV Metrics 客户端共用连接配置（含 DNS 缓存和请求对冲）
+	k8sClientset, err := kubernetes.NewForConfig(uavClient.RestConfig())
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Kubernetes clientset")
+	}
 
 	// 创建路由算法
 	routingAlgorithm := createRoutingAlgorithm(algorithmName, log)
@@ -75,20 +146,56 @@ func main() {
 		k8sClientset,
 		uavClient,
 		routingAlgorithm,
+		lostTimeout,
+		router.NewSearchAreaEstimator(windSpeed, windDirection, searchRadius),
+		relayPlanner,
+		gossip,
+		getEnvDuration("ROUTING_STATS_INTERVAL", 0, log),
 		log,
 	)
 
+	// /route/compare 对比的候选算法：全部内置算法，line-of-sight 仅在配置了地形数据时参与
+	var candidates []algorithm.RoutingAlgorithm
+	for _, name := range []string{"distance-based", "battery-aware", "line-of-sight", "bandwidth", "composite"} {
+		if name == algorithmName || (name == "line-of-sight" && os.Getenv("TERRAIN_DEM_DIR") == "") {
+			continue
+		}
+		candidates = append(candidates, createRoutingAlgorithm(name, discardLogger()))
+	}
+	routerAgent.SetComparisonAlgorithms(candidates)
+	routerAgent.SetEventRecorder(uavClient.EventRecorder("uav-router", nodeName))
+
 	// 创建上下文
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// 联邦部署：从机队快照缓存读取 UAVMetrics，不直接 List API Server
+	if uavConfig.FleetCache.Address != "" {
+		fleetCache, err := fleetcache.NewClient(uavConfig.FleetCache.Address, uavConfig.FleetCache.CAFile, log)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create fleet cache client")
+		}
+		uavClient.SetFleetSource(fleetCache)
+		go fleetCache.Run(ctx)
+		log.WithField("address", uavConfig.FleetCache.Address).Info("Reading fleet from cache")
+	}
+
+	// 先启动 gossip，Router Agent 启动时即可感知节点故障
+	if gossip != nil {
+		go func() {
+			if err := gossip.Run(ctx); err != nil {
+				log.WithError(err).Fatal("Gossip peer liveness stopped")
+			}
+		}()
+	}
+
 	// 启动 Router Agent
 	if err := routerAgent.Start(ctx); err != nil {
 		log.WithError(err).Fatal("Failed to start router agent")
 	}
 
-	// 启动 HTTP API 服务器
-	server := router.NewServer(routerAgent, apiPort, log)
+	// 启动 HTTP API 服务器（ROUTER_ADMIN_TOKEN 为空时不提供管理接口）
+	server := router.NewServer(routerAgent, apiPort, os.Getenv("ROUTER_ADMIN_TOKEN"), log)
 	go func() {
 		if err := server.Start(ctx); err != nil {
 			log.WithError(err).Error("HTTP server stopped")
@@ -102,23 +209,67 @@ func main() {
 
 	log.Info("Shutting down router agent")
 	cancel()
+	uavClient.ShutdownEvents()
+}
+
+// discardLogger 返回只输出错误的 logger，创建候选算法时不重复输出 "Using ... algorithm"
+func discardLogger() *logrus.Logger {
+	quiet := logrus.New()
+	quiet.SetLevel(logrus.ErrorLevel)
+	return quiet
 }
 
-// getK8sConfig 获取 Kubernetes 配置
-func getK8sConfig() (*rest.Config, error) {
-	// 优先使用 in-cluster 配置
-	config, err := rest.InClusterConfig()
-	if err == nil {
-		return config, nil
+// getEnvFloat 读取浮点型环境变量，格式错误时退出
+func getEnvFloat(key string, defaultValue float64, log *logrus.Logger) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
 	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.WithError(err).Fatalf("Invalid %s", key)
+	}
+	return f
+}
 
-	// 否则使用 kubeconfig
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		kubeconfig = os.Getenv("HOME") + "/.kube/config"
+// getEnvInt 读取整型环境变量，格式错误时退出
+func getEnvInt(key string, defaultValue int, log *logrus.Logger) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
 	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.WithError(err).Fatalf("Invalid %s", key)
+	}
+	return i
+}
 
-	return clientcmd.BuildConfigFromFlags("", kubeconfig)
+// getEnvDuration 读取时长型环境变量，格式错误时退出
+func getEnvDuration(key string, defaultValue time.Duration, log *logrus.Logger) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.WithError(err).Fatalf("Invalid %s", key)
+	}
+	return d
+}
+
+// newRadioModel 根据环境变量创建无线链路传播模型
+// 参数：频率（MHz）、发射功率（dBm）、收发天线增益（dBi）、信道带宽（MHz）
+func newRadioModel(log *logrus.Logger) *algorithm.RadioModel {
+	model := algorithm.NewRadioModel(
+		getEnvFloat("RADIO_FREQUENCY_MHZ", 5800, log),
+		getEnvFloat("RADIO_TX_POWER_DBM", 20, log),
+		getEnvFloat("RADIO_TX_GAIN_DBI", 5, log),
+		getEnvFloat("RADIO_RX_GAIN_DBI", 5, log),
+		getEnvFloat("RADIO_CHANNEL_MHZ", 20, log),
+	)
+	model.MaxRateMbps = getEnvFloat("RADIO_MAX_RATE_MBPS", 0, log)
+	return model
 }
 
 // createRoutingAlgorithm 创建路由算法实例
@@ -132,6 +283,31 @@ func createRoutingAlgorithm(name string, log *logrus.Logger) algorithm.RoutingAl
 		log.Info("Using battery-aware routing algorithm")
 		return algorithm.NewBatteryAwareRouter(20.0) // 最低 20% 电量
 
+	case "line-of-sight":
+		// 离线地形高程瓦片目录（SRTM .hgt）
+		demDir := os.Getenv("TERRAIN_DEM_DIR")
+		if demDir == "" {
+			log.Fatal("TERRAIN_DEM_DIR is required for the line-of-sight algorithm")
+		}
+		dem, err := terrain.NewDEM(demDir)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to load terrain elevation data")
+		}
+		clearance := getEnvFloat("LOS_CLEARANCE", 10, log)
+		log.WithFields(logrus.Fields{
+			"demDir":    demDir,
+			"clearance": clearance,
+		}).Info("Using line-of-sight routing algorithm")
+		return algorithm.NewLineOfSightRouter(dem, getEnvFloat("LOS_MAX_DISTANCE", 50, log), clearance)
+
+	case "bandwidth":
+		model := newRadioModel(log)
+		log.WithFields(logrus.Fields{
+			"frequencyMHz": model.FrequencyMHz,
+			"txPowerDBm":   model.TxPowerDBm,
+		}).Info("Using bandwidth routing algorithm")
+		return algorithm.NewBandwidthRouter(model, getEnvFloat("MIN_LINK_BANDWIDTH", 1, log))
+
 	case "composite":
 		log.Info("Using composite routing algorithm")
 		distanceAlgo := algorithm.NewDistanceBasedRouter(500.0)
@@ -2,12 +2,15 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/fleetcache"
 	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
 	"github.com/k3suav/uav-monitor/pkg/scheduler"
 	"github.com/k3suav/uav-monitor/pkg/scheduler/algorithm"
 	schedulerConfig "github.com/k3suav/uav-monitor/pkg/scheduler/config"
@@ -52,12 +55,22 @@ func main() {
 	uavConfig := config.DefaultConfig()
 	uavConfig.Kubernetes.KubeconfigPath = cfg.KubeconfigPath
 	uavConfig.Kubernetes.Namespace = cfg.Namespace
+	flag.BoolVar(&uavConfig.Kubernetes.InstallCRD, "install-crd", uavConfig.Kubernetes.InstallCRD, "启动时安装或升级 UAVMetrics CRD")
+	flag.Parse()
 
 	uavClient, err := k8s.NewClient(uavConfig)
 	if err != nil {
 		log.WithError(err).Fatal("Failed to create UAV client")
 	}
 
+	// 安装 CRD 并等待其可用（可选）
+	if uavConfig.Kubernetes.InstallCRD {
+		if err := uavClient.EnsureCRD(context.Background()); err != nil {
+			log.WithError(err).Fatal("Failed to install the UAVMetrics CRD")
+		}
+		log.Info("UAVMetrics CRD established")
+	}
+
 	log.Info("UAV client initialized")
 
 	// 5. 创建调度器
@@ -72,6 +85,31 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// 联邦部署：从机队快照缓存读取 UAVMetrics，不直接 List API Server
+	if uavConfig.FleetCache.Address != "" {
+		fleetCache, err := fleetcache.NewClient(uavConfig.FleetCache.Address, uavConfig.FleetCache.CAFile, log)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create fleet cache client")
+		}
+		uavClient.SetFleetSource(fleetCache)
+		go fleetCache.Run(ctx)
+		log.WithField("address", uavConfig.FleetCache.Address).Info("Reading fleet from cache")
+	} else {
+		// 以 informer 缓存 UAVMetrics，调度时从缓存读取，不再逐次 List API Server
+		err := uavClient.WatchUAVMetrics(ctx, k8s.UAVMetricsHandler{
+			AddFunc: func(m *models.UAVMetrics) {
+				log.WithField("node", m.NodeName).Debug("UAV node added")
+			},
+			DeleteFunc: func(m *models.UAVMetrics) {
+				log.WithField("node", m.NodeName).Debug("UAV node removed")
+			},
+		})
+		if err != nil {
+			log.WithError(err).Fatal("Failed to watch UAVMetrics")
+		}
+		log.Info("UAVMetrics cache synced")
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -92,6 +130,7 @@ func main() {
 		}
 		cancel()
 	}
+	uavClient.ShutdownEvents()
 
 	log.Info("Scheduler stopped")
 }
@@ -116,7 +155,12 @@ func registerBuiltinAlgorithms(cfg *schedulerConfig.SchedulerConfig) {
 	registry.Register(networkAlgo)
 	log.Debugf("Registered algorithm: %s", networkAlgo.Name())
 
-	// 4. Composite 算法（示例：组合 distance + battery）
+	// 4. Airtime-budget 算法
+	airtimeAlgo := algorithm.NewAirtimeBudgetAlgorithm(cfg.AlgorithmParams.AirtimeBudget)
+	registry.Register(airtimeAlgo)
+	log.Debugf("Registered algorithm: %s", airtimeAlgo.Name())
+
+	// 5. Composite 算法（示例：组合 distance + battery）
 	compositeAlgo := algorithm.NewCompositeAlgorithm(
 		[]algorithm.SchedulingAlgorithm{distanceAlgo, batteryAlgo},
 		[]float64{0.6, 0.4}, // 60% 距离权重，40% 电池权重
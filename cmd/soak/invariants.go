@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/router"
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// 检查的不变量
+const (
+	invariantCriticalPlacement = "no-pod-on-critical-node"
+	invariantRoutingWeights    = "routing-weights"
+	invariantCacheFreshness    = "router-cache-freshness"
+)
+
+// violation 一次不变量违反
+type violation struct {
+	Time      time.Time `json:"time"`
+	Invariant string    `json:"invariant"`
+	Message   string    `json:"message"`
+}
+
+// invariantStats 单个不变量的检查次数和违反次数
+type invariantStats struct {
+	Checks     int `json:"checks"`
+	Violations int `json:"violations"`
+}
+
+// checker 周期性检查调度器、路由和模拟器之间的不变量：
+//   - 不把 Pod 绑定到持续 Critical 超过 grace 的节点
+//   - 路由权重在 0-100 之间、总和大于 0，且只分配给服务自身的 endpoint
+//   - 路由缓存落后于已发布遥测的时间不超过 staleThreshold
+type checker struct {
+	vehicles       map[string]*vehicle
+	router         *router.RouterAgent
+	workload       *workload
+	grace          time.Duration
+	staleThreshold time.Duration
+
+	verified map[types.UID]bool // 已检查过绑定的 Pod
+
+	mu         sync.Mutex
+	stats      map[string]*invariantStats
+	violations []violation
+}
+
+func newChecker(vehicles map[string]*vehicle, routerAgent *router.RouterAgent, w *workload, grace, staleThreshold time.Duration) *checker {
+	return &checker{
+		vehicles:       vehicles,
+		router:         routerAgent,
+		workload:       w,
+		grace:          grace,
+		staleThreshold: staleThreshold,
+		verified:       make(map[types.UID]bool),
+		stats: map[string]*invariantStats{
+			invariantCriticalPlacement: {},
+			invariantRoutingWeights:    {},
+			invariantCacheFreshness:    {},
+		},
+	}
+}
+
+// check 执行一轮检查，pods 为本轮 soak 测试的 Pod
+func (c *checker) check(ctx context.Context, pods []v1.Pod, now time.Time) {
+	c.checkPlacement(pods, now)
+	c.checkWeights(ctx)
+	c.checkCache(now)
+}
+
+// checkPlacement 检查新绑定的 Pod 在绑定时刻所在节点是否已持续 Critical 超过 grace
+func (c *checker) checkPlacement(pods []v1.Pod, now time.Time) {
+	seen := make(map[types.UID]bool, len(pods))
+	for _, pod := range pods {
+		seen[pod.UID] = true
+		if pod.Spec.NodeName == "" || c.verified[pod.UID] {
+			continue
+		}
+		c.verified[pod.UID] = true
+
+		v := c.vehicles[pod.Spec.NodeName]
+		if v == nil {
+			continue
+		}
+		boundAt := bindTime(&pod, now)
+		c.record(invariantCriticalPlacement, !v.criticalThroughout(boundAt.Add(-c.grace), boundAt),
+			"pod %s bound to %s at %s while the node was Critical", pod.Name, pod.Spec.NodeName, boundAt.Format(time.RFC3339))
+	}
+	for uid := range c.verified {
+		if !seen[uid] {
+			delete(c.verified, uid)
+		}
+	}
+}
+
+// bindTime 返回 PodScheduled 条件的变化时间，缺失时使用观察到绑定的时间
+func bindTime(pod *v1.Pod, observed time.Time) time.Time {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodScheduled && cond.Status == v1.ConditionTrue && !cond.LastTransitionTime.IsZero() {
+			return cond.LastTransitionTime.Time
+		}
+	}
+	return observed
+}
+
+// checkWeights 检查路由权重；没有可用 endpoint 时（如所有节点都在故障中）跳过
+func (c *checker) checkWeights(ctx context.Context) {
+	service := c.workload.namespace + "/" + c.workload.service
+	weights, _, err := c.router.ComputeRouting(ctx, service, "")
+	if err != nil {
+		log.WithError(err).Debug("Routing not computed, weights check skipped")
+		return
+	}
+
+	total := 0
+	keys := make(map[string]bool, len(weights))
+	problem := ""
+	for _, w := range weights {
+		key := fmt.Sprintf("%s:%d", w.Endpoint.PodIP, w.Endpoint.Port)
+		switch {
+		case w.Weight < 0 || w.Weight > 100:
+			problem = fmt.Sprintf("endpoint %s has weight %d outside 0-100", w.Endpoint.PodName, w.Weight)
+		case keys[key]:
+			problem = fmt.Sprintf("endpoint %s weighted more than once", key)
+		case w.Endpoint.Namespace != c.workload.namespace || w.Endpoint.Service != c.workload.service:
+			problem = fmt.Sprintf("weight assigned to %s/%s which is not an endpoint of %s", w.Endpoint.Namespace, w.Endpoint.PodName, service)
+		}
+		keys[key] = true
+		total += w.Weight
+	}
+	if problem == "" && len(weights) > 0 && total <= 0 {
+		problem = fmt.Sprintf("weights of %d endpoints sum to %d", len(weights), total)
+	}
+	c.record(invariantRoutingWeights, problem == "", "%s", problem)
+}
+
+// checkCache 检查路由缓存是否落后于已发布的遥测超过 staleThreshold
+func (c *checker) checkCache(now time.Time) {
+	for name, v := range c.vehicles {
+		var cachedSeen time.Time
+		if cached := c.router.CachedMetrics(name); cached != nil {
+			cachedSeen = cached.LastSeen()
+		}
+		unseen, ok := v.firstUnseen(cachedSeen)
+		stale := ok && now.Sub(unseen.at) > c.staleThreshold
+		c.record(invariantCacheFreshness, !stale,
+			"router cache for %s is %s behind telemetry published at %s", name, now.Sub(unseen.at).Round(time.Second), unseen.at.Format(time.RFC3339))
+	}
+}
+
+// record 记录一次检查结果，ok 为 false 时记录违反
+func (c *checker) record(invariant string, ok bool, format string, args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats[invariant]
+	stats.Checks++
+	if ok {
+		return
+	}
+	stats.Violations++
+	v := violation{Time: time.Now(), Invariant: invariant, Message: fmt.Sprintf(format, args...)}
+	c.violations = append(c.violations, v)
+	log.WithField("invariant", invariant).Error("Invariant violated: " + v.Message)
+}
+
+// report soak 测试报告
+type report struct {
+	Started     time.Time                 `json:"started"`
+	Finished    time.Time                 `json:"finished"`
+	PodsCreated int                       `json:"podsCreated"`
+	Invariants  map[string]invariantStats `json:"invariants"`
+	Violations  []violation               `json:"violations"`
+}
+
+// summary 返回各不变量的统计和全部违反记录
+func (c *checker) summary() (map[string]invariantStats, []violation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make(map[string]invariantStats, len(c.stats))
+	for name, s := range c.stats {
+		stats[name] = *s
+	}
+	return stats, append([]violation{}, c.violations...)
+}
+
+// logSummary 输出检查统计
+func (c *checker) logSummary(elapsed time.Duration, podsCreated int) {
+	stats, violations := c.summary()
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := logrus.Fields{
+		"elapsed":     elapsed.Round(time.Second),
+		"podsCreated": podsCreated,
+		"violations":  len(violations),
+	}
+	for _, name := range names {
+		fields[name] = fmt.Sprintf("%d/%d", stats[name].Violations, stats[name].Checks)
+	}
+	log.WithFields(fields).Info("Soak test progress (violations/checks)")
+}
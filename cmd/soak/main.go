@@ -0,0 +1,314 @@
+// soak 长时间运行模拟器、调度器和路由，持续检查控制回路的不变量。
+//
+// 在测试集群（如单节点 k3s）上运行，需要已部署 UAVMetrics CRD。
+// 模拟的 UAV 以虚构节点名发布遥测，被测调度器和路由在进程内运行，
+// 与生产部署使用相同的代码路径；测试结束后输出统计，有违反时以状态码 1 退出。
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/router"
+	routeralgorithm "github.com/k3suav/uav-monitor/pkg/router/algorithm"
+	"github.com/k3suav/uav-monitor/pkg/scheduler"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/algorithm"
+	schedulerConfig "github.com/k3suav/uav-monitor/pkg/scheduler/config"
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var log = logrus.New()
+
+func main() {
+	kubeconfig := flag.String("kubeconfig", os.Getenv("KUBECONFIG"), "kubeconfig 路径（为空时使用 in-cluster 配置）")
+	namespace := flag.String("namespace", "uav-soak", "测试使用的命名空间（不存在时创建），UAVMetrics 和 Pod 都写入此命名空间")
+	duration := flag.Duration("duration", 4*time.Hour, "测试时长")
+	vehicleCount := flag.Int("vehicles", 5, "模拟的 UAV 数量")
+	nodes := flag.String("nodes", "", "模拟 UAV 使用的节点名（逗号分隔），为空时生成 soak-uav-N")
+	sampleInterval := flag.Duration("sample-interval", 2*time.Second, "模拟 UAV 的遥测发布间隔")
+	checkInterval := flag.Duration("check-interval", 10*time.Second, "不变量检查间隔")
+	podInterval := flag.Duration("pod-interval", 15*time.Second, "创建测试 Pod 的间隔")
+	maxPods := flag.Int("max-pods", 20, "同时存在的测试 Pod 上限，超出时删除最早的 Pod")
+	faultRate := flag.Float64("fault-rate", 0.01, "每次采样注入电量耗尽故障（Critical）的概率")
+	faultDuration := flag.Duration("fault-duration", time.Minute, "注入故障的持续时间")
+	grace := flag.Duration("grace", 5*time.Second, "节点变为 Critical 后调度器仍可能绑定的时间窗口")
+	staleThreshold := flag.Duration("stale-threshold", 15*time.Second, "路由缓存允许落后于已发布遥测的时间")
+	schedulerAlgorithm := flag.String("scheduler-algorithm", "battery-aware", "被测调度算法（distance-based/battery-aware/network-latency/airtime-budget/composite）")
+	routerAlgorithm := flag.String("router-algorithm", "battery-aware", "被测路由算法（distance-based/battery-aware/composite）")
+	reportInterval := flag.Duration("report-interval", 5*time.Minute, "输出检查统计的间隔")
+	reportPath := flag.String("report", "", "测试结束时写入 JSON 报告的文件路径")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "故障注入的随机种子，用于复现")
+	verbose := flag.Bool("v", false, "输出调度器和路由的调试日志")
+	installCRD := flag.Bool("install-crd", false, "测试开始前安装或升级 UAVMetrics CRD，用于全新集群")
+	flag.Parse()
+
+	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+
+	names := vehicleNames(*nodes, *vehicleCount)
+	if len(names) == 0 {
+		log.Fatal("At least one vehicle is required")
+	}
+	if *maxPods < 1 {
+		log.Fatal("-max-pods must be >= 1")
+	}
+
+	// UAV 客户端（模拟器发布、调度器和路由读取共用）
+	uavConfig := config.DefaultConfig()
+	uavConfig.Kubernetes.KubeconfigPath = *kubeconfig
+	uavConfig.Kubernetes.Namespace = *namespace
+	uavConfig.Kubernetes.HealthEvents = false
+	uavConfig.Collection.Interval = *sampleInterval
+	uavClient, err := k8s.NewClient(uavConfig)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create UAV client")
+	}
+	clientset, err := kubernetes.NewForConfig(uavClient.RestConfig())
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Kubernetes clientset")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigChan
+		log.WithField("signal", sig).Info("Received shutdown signal, finishing soak test")
+		cancel()
+	}()
+
+	if err := ensureNamespace(ctx, clientset, *namespace); err != nil {
+		log.WithError(err).Fatal("Failed to prepare namespace")
+	}
+	if *installCRD {
+		if err := uavClient.EnsureCRD(ctx); err != nil {
+			log.WithError(err).Fatal("Failed to install the UAVMetrics CRD")
+		}
+	}
+
+	// 1. 模拟器
+	retention := *grace + *staleThreshold + 2**checkInterval
+	vehicles := make(map[string]*vehicle, len(names))
+	for i, name := range names {
+		v := newVehicle(name, uavConfig, uavClient, *faultRate, *faultDuration, *seed+int64(i))
+		vehicles[name] = v
+		go v.run(ctx, *sampleInterval, retention)
+	}
+
+	// 2. 被测调度器
+	schedCfg := schedulerConfig.DefaultConfig()
+	schedCfg.SchedulerName = "uav-soak-scheduler"
+	schedCfg.AlgorithmName = *schedulerAlgorithm
+	schedCfg.Namespace = *namespace
+	schedCfg.KubeconfigPath = *kubeconfig
+	schedCfg.AdminPort = 0
+	schedCfg.Chargeback.Enabled = false
+	schedCfg.LogLevel = "warn"
+	if *verbose {
+		schedCfg.LogLevel = "debug"
+	}
+	if err := schedCfg.Validate(); err != nil {
+		log.WithError(err).Fatal("Invalid scheduler configuration")
+	}
+	schedAlgo, err := schedulingAlgorithm(*schedulerAlgorithm, schedCfg)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid scheduler algorithm")
+	}
+	sched, err := scheduler.NewScheduler(schedCfg, schedAlgo, uavClient)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create scheduler")
+	}
+	go func() {
+		if err := sched.Run(ctx); err != nil && ctx.Err() == nil {
+			log.WithError(err).Error("Scheduler stopped")
+		}
+	}()
+
+	// 3. 被测路由（以第一架 UAV 作为源节点，不检测失联）
+	routerLog := logrus.New()
+	routerLog.SetLevel(logrus.WarnLevel)
+	if *verbose {
+		routerLog.SetLevel(logrus.DebugLevel)
+	}
+	routeAlgo, err := routingAlgorithm(*routerAlgorithm)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid router algorithm")
+	}
+	routerAgent := router.NewRouterAgent(names[0], clientset, uavClient, routeAlgo, 0, nil, nil, nil, 0, routerLog)
+	if err := routerAgent.Start(ctx); err != nil {
+		log.WithError(err).Fatal("Failed to start router agent")
+	}
+
+	// 4. 负载和检查
+	w := &workload{
+		clientset:     clientset,
+		namespace:     *namespace,
+		service:       "soak",
+		schedulerName: schedCfg.SchedulerName,
+		maxPods:       *maxPods,
+	}
+	if err := w.ensureService(ctx); err != nil {
+		log.WithError(err).Fatal("Failed to prepare workload")
+	}
+	c := newChecker(vehicles, routerAgent, w, *grace, *staleThreshold)
+
+	log.WithFields(logrus.Fields{
+		"namespace":          *namespace,
+		"duration":           *duration,
+		"vehicles":           len(names),
+		"schedulerAlgorithm": schedAlgo.Name(),
+		"routerAlgorithm":    routeAlgo.Name(),
+		"seed":               *seed,
+	}).Info("Soak test started")
+
+	started := time.Now()
+	run(ctx, w, c, *podInterval, *checkInterval, *reportInterval, started)
+
+	// 清理（测试上下文已结束，使用新的上下文）
+	cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cleanupCancel()
+	w.cleanup(cleanupCtx)
+	for _, name := range names {
+		if err := uavClient.DeleteUAVMetrics(cleanupCtx, name); err != nil {
+			log.WithError(err).WithField("vehicle", name).Warn("Failed to delete simulated UAVMetrics")
+		}
+	}
+
+	c.logSummary(time.Since(started), w.created)
+	stats, violations := c.summary()
+	if *reportPath != "" {
+		data, _ := json.MarshalIndent(report{
+			Started:     started,
+			Finished:    time.Now(),
+			PodsCreated: w.created,
+			Invariants:  stats,
+			Violations:  violations,
+		}, "", "  ")
+		if err := os.WriteFile(*reportPath, data, 0o644); err != nil {
+			log.WithError(err).Error("Failed to write report")
+		}
+	}
+	if len(violations) > 0 {
+		log.WithField("violations", len(violations)).Error("Soak test failed")
+		os.Exit(1)
+	}
+	log.Info("Soak test passed")
+}
+
+// run 驱动负载和检查，直到 ctx 结束
+func run(ctx context.Context, w *workload, c *checker, podInterval, checkInterval, reportInterval time.Duration, started time.Time) {
+	podTicker := time.NewTicker(podInterval)
+	defer podTicker.Stop()
+	checkTicker := time.NewTicker(checkInterval)
+	defer checkTicker.Stop()
+	reportTicker := time.NewTicker(reportInterval)
+	defer reportTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-podTicker.C:
+			if err := w.churn(ctx); err != nil && ctx.Err() == nil {
+				log.WithError(err).Warn("Failed to churn soak pods")
+			}
+		case now := <-checkTicker.C:
+			pods, err := w.pods(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.WithError(err).Warn("Failed to list soak pods, check skipped")
+				}
+				continue
+			}
+			if err := w.syncEndpoints(ctx, pods); err != nil && ctx.Err() == nil {
+				log.WithError(err).Warn("Failed to sync soak endpoints")
+			}
+			c.check(ctx, pods, now)
+		case <-reportTicker.C:
+			c.logSummary(time.Since(started), w.created)
+		}
+	}
+}
+
+// vehicleNames 返回模拟 UAV 的节点名
+func vehicleNames(nodes string, count int) []string {
+	if nodes != "" {
+		names := []string{}
+		for _, name := range strings.Split(nodes, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	names := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		names = append(names, fmt.Sprintf("soak-uav-%d", i))
+	}
+	return names
+}
+
+// ensureNamespace 创建测试命名空间（已存在时忽略）
+func ensureNamespace(ctx context.Context, clientset kubernetes.Interface, name string) error {
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	_, err := clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// schedulingAlgorithm 创建被测调度算法，参数与 uav-scheduler 的内置算法一致
+func schedulingAlgorithm(name string, cfg *schedulerConfig.SchedulerConfig) (algorithm.SchedulingAlgorithm, error) {
+	distance := algorithm.NewDistanceBasedAlgorithm(cfg.AlgorithmParams.TargetLatitude, cfg.AlgorithmParams.TargetLongitude)
+	battery := algorithm.NewBatteryAwareAlgorithm(cfg.AlgorithmParams.MinBattery)
+	switch name {
+	case "distance-based":
+		return distance, nil
+	case "battery-aware":
+		return battery, nil
+	case "network-latency":
+		return algorithm.NewNetworkLatencyAlgorithm(cfg.AlgorithmParams.MaxLatency), nil
+	case "airtime-budget":
+		return algorithm.NewAirtimeBudgetAlgorithm(cfg.AlgorithmParams.AirtimeBudget), nil
+	case "composite":
+		return algorithm.NewCompositeAlgorithm(
+			[]algorithm.SchedulingAlgorithm{distance, battery},
+			[]float64{0.6, 0.4},
+		), nil
+	}
+	return nil, fmt.Errorf("unknown scheduling algorithm %q", name)
+}
+
+// routingAlgorithm 创建被测路由算法，参数与 uav-router 一致
+func routingAlgorithm(name string) (routeralgorithm.RoutingAlgorithm, error) {
+	switch name {
+	case "distance-based":
+		return routeralgorithm.NewDistanceBasedRouter(500.0), nil
+	case "battery-aware":
+		return routeralgorithm.NewBatteryAwareRouter(20.0), nil
+	case "composite":
+		return routeralgorithm.NewCompositeRouter(
+			[]routeralgorithm.RoutingAlgorithm{
+				routeralgorithm.NewDistanceBasedRouter(500.0),
+				routeralgorithm.NewBatteryAwareRouter(20.0),
+			},
+			[]float64{0.7, 0.3},
+		)
+	}
+	return nil, fmt.Errorf("unknown routing algorithm %q", name)
+}
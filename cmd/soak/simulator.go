@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/collector"
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// publication 一次成功发布的样本
+type publication struct {
+	at       time.Time // 写入 API Server 完成的时间
+	lastSeen time.Time // 样本自身的时间戳（UAVMetrics.LastSeen）
+	health   string
+}
+
+// vehicle 模拟的 UAV：使用 Agent 的采集器生成遥测，按概率注入电量耗尽故障，
+// 并记录发布历史供一致性检查使用
+type vehicle struct {
+	name      string
+	collector *collector.Collector
+	client    *k8s.Client
+	rand      *rand.Rand
+
+	faultRate     float64       // 每次采样进入故障的概率
+	faultDuration time.Duration // 故障持续时间
+	faultUntil    time.Time
+
+	mu      sync.Mutex
+	history []publication // 按时间排序，只保留 retention 内的记录
+}
+
+func newVehicle(name string, base *config.Config, client *k8s.Client, faultRate float64, faultDuration time.Duration, seed int64) *vehicle {
+	cfg := *base
+	cfg.Agent.NodeName = name
+	return &vehicle{
+		name:          name,
+		collector:     collector.NewCollector(&cfg),
+		client:        client,
+		rand:          rand.New(rand.NewSource(seed)),
+		faultRate:     faultRate,
+		faultDuration: faultDuration,
+	}
+}
+
+// run 按 interval 采集并发布，直到 ctx 取消
+func (v *vehicle) run(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := v.publish(ctx, retention); err != nil && ctx.Err() == nil {
+			log.WithError(err).WithField("vehicle", v.name).Warn("Failed to publish simulated metrics")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (v *vehicle) publish(ctx context.Context, retention time.Duration) error {
+	metrics, err := v.collector.CollectMetrics(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if now.After(v.faultUntil) && v.rand.Float64() < v.faultRate {
+		v.faultUntil = now.Add(v.faultDuration)
+		log.WithFields(logrus.Fields{
+			"vehicle": v.name,
+			"until":   v.faultUntil.Format(time.RFC3339),
+		}).Info("Injecting critical battery fault")
+	}
+	if now.Before(v.faultUntil) {
+		injectBatteryFault(metrics)
+	}
+
+	if err := v.client.CreateOrUpdateWithRetry(ctx, metrics); err != nil {
+		return err
+	}
+
+	health := models.HealthStatusUnknown
+	if metrics.Health != nil {
+		health = metrics.Health.Status
+	}
+	v.record(publication{at: time.Now(), lastSeen: metrics.LastSeen(), health: health}, retention)
+	return nil
+}
+
+// injectBatteryFault 模拟电量耗尽：电量降至 5%，健康状态置为 Critical
+func injectBatteryFault(metrics *models.UAVMetrics) {
+	metrics.Battery.RemainingPercent = 5
+	if metrics.Health == nil {
+		metrics.Health = &models.HealthData{LastHealthCheck: time.Now()}
+	}
+	metrics.Health.Status = models.HealthStatusCritical
+	metrics.Health.Errors = append(metrics.Health.Errors, fmt.Sprintf("Critical battery: %.1f%% (injected)", metrics.Battery.RemainingPercent))
+}
+
+func (v *vehicle) record(p publication, retention time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.history = append(v.history, p)
+
+	// 至少保留一条早于保留期的记录，以便回答保留期起点的状态
+	cutoff := p.at.Add(-retention)
+	i := 0
+	for i+1 < len(v.history) && v.history[i+1].at.Before(cutoff) {
+		i++
+	}
+	v.history = v.history[i:]
+}
+
+// criticalThroughout 判断 [from, to] 期间已发布的健康状态是否始终为 Critical
+func (v *vehicle) criticalThroughout(from, to time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	known := false
+	for _, p := range v.history {
+		if p.at.After(to) {
+			break
+		}
+		if !p.at.After(from) {
+			// from 时刻生效的状态
+			known = p.health == models.HealthStatusCritical
+			continue
+		}
+		if p.health != models.HealthStatusCritical {
+			return false
+		}
+	}
+	return known
+}
+
+// firstUnseen 返回 lastSeen 晚于 cached 的最早一次发布，全部已被缓存时返回 false
+func (v *vehicle) firstUnseen(cached time.Time) (publication, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, p := range v.history {
+		if p.lastSeen.After(cached) {
+			return p, true
+		}
+	}
+	return publication{}, false
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// soakLabel 标记 soak 测试创建的对象，清理时按此删除
+const soakLabel = "uav.k3s.io/soak"
+
+// workload 持续创建和删除由被测调度器调度的 Pod，
+// 并为已绑定的 Pod 维护一个无 selector 的 Service 及其 Endpoints，供被测路由计算权重。
+// 模拟节点没有 kubelet，Pod 不会真正运行，Endpoints 中使用虚构的 Pod IP
+type workload struct {
+	clientset     kubernetes.Interface
+	namespace     string
+	service       string
+	schedulerName string
+	maxPods       int
+
+	created int
+}
+
+// ensureService 创建 soak 测试使用的 Service
+func (w *workload) ensureService(ctx context.Context) error {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      w.service,
+			Namespace: w.namespace,
+			Labels:    map[string]string{soakLabel: "true"},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Name: "http", Port: 80}},
+		},
+	}
+	_, err := w.clientset.CoreV1().Services(w.namespace).Create(ctx, svc, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	return nil
+}
+
+// churn 创建一个新 Pod，超过 maxPods 时强制删除最早的 Pod
+func (w *workload) churn(ctx context.Context) error {
+	pods, err := w.pods(ctx)
+	if err != nil {
+		return err
+	}
+	for len(pods) >= w.maxPods {
+		zero := int64(0)
+		err := w.clientset.CoreV1().Pods(w.namespace).Delete(ctx, pods[0].Name, metav1.DeleteOptions{GracePeriodSeconds: &zero})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete pod %s: %w", pods[0].Name, err)
+		}
+		pods = pods[1:]
+	}
+
+	w.created++
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: w.service + "-",
+			Namespace:    w.namespace,
+			Labels:       map[string]string{soakLabel: "true", "app": w.service},
+		},
+		Spec: v1.PodSpec{
+			SchedulerName: w.schedulerName,
+			Containers: []v1.Container{{
+				Name:  "pause",
+				Image: "registry.k8s.io/pause:3.9",
+			}},
+			// 模拟节点没有 kubelet，容忍其 NotReady/unreachable 污点
+			Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+		},
+	}
+	if _, err := w.clientset.CoreV1().Pods(w.namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create pod: %w", err)
+	}
+	return nil
+}
+
+// pods 返回 soak 测试创建的 Pod，按创建时间排序
+func (w *workload) pods(ctx context.Context) ([]v1.Pod, error) {
+	list, err := w.clientset.CoreV1().Pods(w.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: soakLabel + "=true",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	pods := list.Items
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+	})
+	return pods, nil
+}
+
+// syncEndpoints 将已绑定的 Pod 写入 Service 的 Endpoints
+func (w *workload) syncEndpoints(ctx context.Context, pods []v1.Pod) error {
+	addresses := []v1.EndpointAddress{}
+	for i, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		nodeName := pod.Spec.NodeName
+		addresses = append(addresses, v1.EndpointAddress{
+			IP:       fmt.Sprintf("10.254.%d.%d", i/250, i%250+1),
+			NodeName: &nodeName,
+			TargetRef: &v1.ObjectReference{
+				Kind:      "Pod",
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+				UID:       pod.UID,
+			},
+		})
+	}
+
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      w.service,
+			Namespace: w.namespace,
+			Labels:    map[string]string{soakLabel: "true"},
+		},
+	}
+	if len(addresses) > 0 {
+		endpoints.Subsets = []v1.EndpointSubset{{
+			Addresses: addresses,
+			Ports:     []v1.EndpointPort{{Name: "http", Port: 80}},
+		}}
+	}
+
+	client := w.clientset.CoreV1().Endpoints(w.namespace)
+	existing, err := client.Get(ctx, w.service, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, endpoints, metav1.CreateOptions{})
+	} else if err == nil {
+		endpoints.ResourceVersion = existing.ResourceVersion
+		_, err = client.Update(ctx, endpoints, metav1.UpdateOptions{})
+	}
+	if err != nil && !apierrors.IsConflict(err) {
+		return fmt.Errorf("failed to update endpoints: %w", err)
+	}
+	return nil
+}
+
+// cleanup 删除 soak 测试创建的 Pod、Service 和 Endpoints
+func (w *workload) cleanup(ctx context.Context) {
+	zero := int64(0)
+	selector := metav1.ListOptions{LabelSelector: soakLabel + "=true"}
+	if err := w.clientset.CoreV1().Pods(w.namespace).DeleteCollection(ctx, metav1.DeleteOptions{GracePeriodSeconds: &zero}, selector); err != nil {
+		log.WithError(err).Warn("Failed to delete soak pods")
+	}
+	if err := w.clientset.CoreV1().Services(w.namespace).Delete(ctx, w.service, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		log.WithError(err).Warn("Failed to delete soak service")
+	}
+	if err := w.clientset.CoreV1().Endpoints(w.namespace).Delete(ctx, w.service, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		log.WithError(err).Warn("Failed to delete soak endpoints")
+	}
+}
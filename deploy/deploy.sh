@@ -28,6 +28,8 @@ else
     kubectl apply -f api/crd/uav-metrics-crd.yaml
     echo "  ✅ CRD 部署完成"
 fi
+# Agent 期望配置 CRD（可选功能，重复 apply 无副作用）
+kubectl apply -f api/crd/uav-agentconfig-crd.yaml >/dev/null
 echo ""
 
 # 步骤 2: 构建 Docker 镜像
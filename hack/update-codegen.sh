@@ -0,0 +1,36 @@
+#!/usr/bin/env bash
+# 根据 pkg/apis 下的类型重新生成 deepcopy、typed clientset、lister 和 informer
+# （pkg/generated），修改 UAVMetrics 类型或 pkg/models 后运行
+set -o errexit
+set -o nounset
+set -o pipefail
+
+SCRIPT_ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+MODULE=github.com/k3suav/uav-monitor
+CODEGEN_VERSION=${CODEGEN_VERSION:-v0.34.1}
+CODEGEN_PKG=${CODEGEN_PKG:-$(go env GOMODCACHE)/k8s.io/code-generator@${CODEGEN_VERSION}}
+
+if [ ! -d "${CODEGEN_PKG}" ]; then
+    go mod download "k8s.io/code-generator@${CODEGEN_VERSION}"
+fi
+source "${CODEGEN_PKG}/kube_codegen.sh"
+
+# 生成的文件不带许可证头
+BOILERPLATE=$(mktemp)
+trap 'rm -f "${BOILERPLATE}"' EXIT
+
+# pkg/models 中的遥测类型作为 UAVMetrics 的 spec 和 status，同样需要 deepcopy
+kube::codegen::gen_helpers \
+    --boilerplate "${BOILERPLATE}" \
+    "${SCRIPT_ROOT}/pkg/models"
+
+kube::codegen::gen_helpers \
+    --boilerplate "${BOILERPLATE}" \
+    "${SCRIPT_ROOT}/pkg/apis"
+
+kube::codegen::gen_client \
+    --with-watch \
+    --output-dir "${SCRIPT_ROOT}/pkg/generated" \
+    --output-pkg "${MODULE}/pkg/generated" \
+    --boilerplate "${BOILERPLATE}" \
+    "${SCRIPT_ROOT}/pkg/apis"
@@ -0,0 +1,25 @@
+#!/usr/bin/env bash
+# 根据 pkg/apis 和 pkg/models 中的 kubebuilder 标记（取值范围、枚举、打印列等）
+# 重新生成 UAVMetrics CRD 及其 OpenAPI structural schema
+# （api/crd/uav-metrics-crd.yaml），修改遥测字段或校验规则后运行
+set -o errexit
+set -o nounset
+set -o pipefail
+
+SCRIPT_ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+CONTROLLER_GEN_VERSION=${CONTROLLER_GEN_VERSION:-v0.19.0}
+CONTROLLER_GEN=${CONTROLLER_GEN:-go run sigs.k8s.io/controller-tools/cmd/controller-gen@${CONTROLLER_GEN_VERSION}}
+
+OUTPUT_DIR=$(mktemp -d)
+trap 'rm -rf "${OUTPUT_DIR}"' EXIT
+
+cd "${SCRIPT_ROOT}"
+
+# 遥测数据以 float64 表示（经纬度、电量等），需要 allowDangerousTypes
+${CONTROLLER_GEN} \
+    crd:allowDangerousTypes=true \
+    paths=./pkg/apis/... \
+    output:crd:dir="${OUTPUT_DIR}"
+
+cp "${OUTPUT_DIR}/uav.k3s.io_uavmetrics.yaml" "${SCRIPT_ROOT}/api/crd/uav-metrics-crd.yaml"
+echo "已生成 api/crd/uav-metrics-crd.yaml"
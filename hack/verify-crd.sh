@@ -0,0 +1,26 @@
+#!/usr/bin/env bash
+# 检查 api/crd/uav-metrics-crd.yaml 是否与 kubebuilder 标记生成的结果一致，
+# 防止手工修改 CRD 或修改标记后忘记运行 make manifests
+set -o errexit
+set -o nounset
+set -o pipefail
+
+SCRIPT_ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+CONTROLLER_GEN_VERSION=${CONTROLLER_GEN_VERSION:-v0.19.0}
+CONTROLLER_GEN=${CONTROLLER_GEN:-go run sigs.k8s.io/controller-tools/cmd/controller-gen@${CONTROLLER_GEN_VERSION}}
+
+OUTPUT_DIR=$(mktemp -d)
+trap 'rm -rf "${OUTPUT_DIR}"' EXIT
+
+cd "${SCRIPT_ROOT}"
+
+${CONTROLLER_GEN} \
+    crd:allowDangerousTypes=true \
+    paths=./pkg/apis/... \
+    output:crd:dir="${OUTPUT_DIR}"
+
+if ! diff -u "${SCRIPT_ROOT}/api/crd/uav-metrics-crd.yaml" "${OUTPUT_DIR}/uav.k3s.io_uavmetrics.yaml"; then
+    echo "api/crd/uav-metrics-crd.yaml 与 kubebuilder 标记不一致，请运行 make manifests" >&2
+    exit 1
+fi
+echo "api/crd/uav-metrics-crd.yaml 已是最新"
@@ -0,0 +1,193 @@
+// Package aggregator implements the regional aggregation proxy for large
+// fleets. Agents report samples over gRPC instead of writing the CRD
+// themselves; the aggregator keeps only the latest sample of each vehicle and
+// writes them periodically with server-side apply, so the API server sees a
+// few long-lived connections instead of one per vehicle.
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// shutdownFlushTimeout bounds the final write of pending samples on shutdown
+const shutdownFlushTimeout = 10 * time.Second
+
+// Stats counts the samples handled since the aggregator started
+type Stats struct {
+	// Samples waiting for the next flush
+	Pending int `json:"pending"`
+
+	Received uint64 `json:"received"`
+
+	// Replaced by a newer sample of the same vehicle before being written
+	Coalesced uint64 `json:"coalesced"`
+
+	// Older than a sample already pending or written, and dropped
+	Stale uint64 `json:"stale"`
+
+	Written uint64 `json:"written"`
+
+	// Failed writes; the sample is retried on the next flush unless a newer
+	// one has arrived
+	Failed uint64 `json:"failed"`
+}
+
+// Aggregator coalesces reported samples and writes them to the API server
+type Aggregator struct {
+	client *k8s.Client
+	cfg    config.AggregatorConfig
+	log    *logrus.Logger
+
+	mu      sync.Mutex
+	pending map[string]*models.UAVMetrics
+	written map[string]time.Time // LastSeen of the last sample written per vehicle
+	stats   Stats
+}
+
+// New creates an aggregator writing through client
+func New(client *k8s.Client, cfg config.AggregatorConfig, log *logrus.Logger) *Aggregator {
+	return &Aggregator{
+		client:  client,
+		cfg:     cfg,
+		log:     log,
+		pending: make(map[string]*models.UAVMetrics),
+		written: make(map[string]time.Time),
+	}
+}
+
+// Offer queues metrics for the next flush, replacing any sample of the same
+// vehicle still pending. Samples older than the pending or last written one
+// (e.g. delivered late after a reconnect) are dropped. Timestamps ahead of
+// the aggregator's clock are clamped to now, so one sample from a vehicle
+// whose clock runs ahead can't hold back the following ones.
+func (a *Aggregator) Offer(metrics *models.UAVMetrics) error {
+	if metrics.NodeName == "" {
+		return errors.New("nodeName is required")
+	}
+	now := time.Now()
+	if metrics.Health != nil && metrics.Health.LastHealthCheck.After(now) {
+		metrics.Health.LastHealthCheck = now
+	}
+	if metrics.GPS.LastUpdate.After(now) {
+		metrics.GPS.LastUpdate = now
+	}
+	seen := metrics.LastSeen()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stats.Received++
+	if current, ok := a.pending[metrics.NodeName]; ok {
+		if current.LastSeen().After(seen) {
+			a.stats.Stale++
+			return nil
+		}
+		a.stats.Coalesced++
+	} else if last, ok := a.written[metrics.NodeName]; ok && last.After(seen) {
+		a.stats.Stale++
+		return nil
+	}
+	a.pending[metrics.NodeName] = metrics
+	return nil
+}
+
+// Stats returns a snapshot of the counters
+func (a *Aggregator) Stats() Stats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stats := a.stats
+	stats.Pending = len(a.pending)
+	return stats
+}
+
+// Run flushes pending samples every FlushInterval until ctx is cancelled,
+// then writes what is still pending
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+			a.flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			a.flush(ctx)
+		}
+	}
+}
+
+// flush writes the pending samples with at most Concurrency requests in
+// flight. Samples reported meanwhile wait for the next flush.
+func (a *Aggregator) flush(ctx context.Context) {
+	a.mu.Lock()
+	batch := a.pending
+	a.pending = make(map[string]*models.UAVMetrics)
+	a.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	start := time.Now()
+	sem := make(chan struct{}, a.cfg.Concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed int
+	var firstErr error
+	for _, metrics := range batch {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(metrics *models.UAVMetrics) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := a.client.ApplyUAVMetrics(ctx, metrics, a.cfg.FieldManager)
+			a.done(metrics, err)
+			if err != nil {
+				mu.Lock()
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(metrics)
+	}
+	wg.Wait()
+
+	entry := a.log.WithFields(logrus.Fields{
+		"vehicles":    len(batch),
+		"failed":      failed,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+	if failed > 0 {
+		// One line per flush rather than per vehicle while the API server is down
+		entry.WithError(firstErr).Warn("Failed to write some UAVMetrics, retrying on the next flush")
+		return
+	}
+	entry.Debug("Flushed UAVMetrics")
+}
+
+// done records the outcome of writing metrics, requeueing it on failure
+func (a *Aggregator) done(metrics *models.UAVMetrics, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		a.stats.Failed++
+		if _, ok := a.pending[metrics.NodeName]; !ok {
+			a.pending[metrics.NodeName] = metrics
+		}
+		return
+	}
+	a.stats.Written++
+	a.written[metrics.NodeName] = metrics.LastSeen()
+}
@@ -0,0 +1,233 @@
+package aggregator
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	serviceName  = "uav.aggregator.v1.Aggregator"
+	reportMethod = "/" + serviceName + "/Report"
+)
+
+// serviceDesc is the hand-written descriptor of uav.aggregator.v1.Aggregator
+// (api/proto/aggregator.proto)
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Report",
+			Handler:    handleReport,
+		},
+	},
+	Metadata: "aggregator.proto",
+}
+
+func handleReport(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := &wrapperspb.BytesValue{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	report := func(ctx context.Context, req interface{}) (interface{}, error) {
+		var metrics models.UAVMetrics
+		if err := json.Unmarshal(req.(*wrapperspb.BytesValue).GetValue(), &metrics); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid UAVMetrics: %v", err)
+		}
+		node, ok := peerNode(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "a fleet client certificate is required")
+		}
+		if node != metrics.NodeName {
+			return nil, status.Errorf(codes.PermissionDenied, "certificate of %q can't report %q", node, metrics.NodeName)
+		}
+		if err := srv.(*Aggregator).Offer(&metrics); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return &emptypb.Empty{}, nil
+	}
+	if interceptor == nil {
+		return report(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: reportMethod}
+	return interceptor(ctx, req, info, report)
+}
+
+// peerNode returns the node name of the verified client certificate of the
+// caller, false on plaintext connections
+func peerNode(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName, true
+}
+
+// ServerTLSConfig returns the TLS configuration of an aggregator serving
+// certFile, requiring client certificates issued by the fleet CA in
+// clientCAFile (see package enrollment)
+func ServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("invalid client CA certificate %s", clientCAFile)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// Serve serves the aggregator and the standard gRPC health service on addr
+// until ctx is cancelled. Reports need a fleet client certificate
+// (tlsConfig, see ServerTLSConfig), which is only allowed to report the
+// vehicle it was issued for. The health service is also served in
+// plaintext on healthAddr for probes.
+func (a *Aggregator) Serve(ctx context.Context, addr string, tlsConfig *tls.Config, healthAddr string) error {
+	if tlsConfig == nil || tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		return errors.New("the aggregator requires client certificates")
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	probeLis, err := net.Listen("tcp", healthAddr)
+	if err != nil {
+		lis.Close()
+		return err
+	}
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	server.RegisterService(&serviceDesc, a)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(serviceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	probeServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(probeServer, healthServer)
+	go probeServer.Serve(probeLis)
+
+	go func() {
+		<-ctx.Done()
+		healthServer.Shutdown()
+		// Reports are short unary calls, so let those in flight finish
+		server.GracefulStop()
+		probeServer.Stop()
+	}()
+
+	return server.Serve(lis)
+}
+
+// Client reports samples to an aggregator as gzip compressed JSON. Each
+// vehicle reports over its own connection, authenticated by its enrollment
+// certificate.
+type Client struct {
+	address     string
+	timeout     time.Duration
+	credentials func(nodeName string) (*tls.Config, error)
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn // by node name
+}
+
+// NewClient creates a client for the aggregator at address. credentials
+// returns the TLS configuration of a vehicle. Connections are established
+// on the first report.
+func NewClient(address string, timeout time.Duration, credentials func(nodeName string) (*tls.Config, error)) (*Client, error) {
+	if credentials == nil {
+		return nil, errors.New("the aggregator requires client certificates")
+	}
+	return &Client{
+		address:     address,
+		timeout:     timeout,
+		credentials: credentials,
+		conns:       make(map[string]*grpc.ClientConn),
+	}, nil
+}
+
+// conn returns the connection reporting for nodeName, creating it on first
+// use
+func (c *Client) conn(nodeName string) (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if conn, ok := c.conns[nodeName]; ok {
+		return conn, nil
+	}
+
+	tlsConfig, err := c.credentials(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(c.address,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.conns[nodeName] = conn
+	return conn, nil
+}
+
+// Report hands metrics to the aggregator. A nil error means the sample was
+// queued, not that it was written: the aggregator retries failed writes
+// itself.
+func (c *Client) Report(ctx context.Context, metrics *models.UAVMetrics) error {
+	conn, err := c.conn(metrics.NodeName)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return conn.Invoke(ctx, reportMethod, wrapperspb.Bytes(data), &emptypb.Empty{})
+}
+
+// Close closes the connections
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var firstErr error
+	for _, conn := range c.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,187 @@
+// Package alert notifies webhooks when a vehicle's health becomes Critical
+// or specific health errors appear, so operators learn about failing
+// vehicles without watching the cluster.
+package alert
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Alert rules
+const (
+	// RuleHealthCritical fires while health is Critical
+	RuleHealthCritical = "health-critical"
+	// RuleHealthError fires while a health error matches a configured pattern
+	RuleHealthError = "health-error"
+)
+
+// Alert states
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// Alert is the payload posted to generic webhooks
+type Alert struct {
+	NodeName string `json:"nodeName"`
+	Rule     string `json:"rule"`
+	// Error pattern of a health-error alert
+	Pattern string    `json:"pattern,omitempty"`
+	State   string    `json:"state"`
+	Summary string    `json:"summary"`
+	Time    time.Time `json:"time"`
+
+	Health   string   `json:"health"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+
+	BatteryPercent float64 `json:"batteryPercent"`
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	Altitude       float64 `json:"altitude"`
+	FlightMode     string  `json:"flightMode,omitempty"`
+	Flying         bool    `json:"flying"`
+}
+
+// alertState tracks one alert of one vehicle
+type alertState struct {
+	active bool
+	// A firing notification was sent for the current activation
+	notified bool
+	lastSent time.Time
+}
+
+// Notifier turns samples into alerts and sends them to the configured
+// webhooks. An alert is sent once when it starts firing and once when it
+// resolves; a firing alert is not sent again within the cooldown of the
+// previous one, but is sent once the cooldown has passed if still firing.
+// It is safe for concurrent use, so one notifier serves every vehicle of a
+// ground node.
+type Notifier struct {
+	config config.AlertConfig
+	sender *sender
+
+	mu     sync.Mutex
+	alerts map[string]*alertState
+}
+
+// NewNotifier creates a notifier from the agent configuration. Run must be
+// called to send the alerts.
+func NewNotifier(cfg *config.Config, log *logrus.Logger) *Notifier {
+	return &Notifier{
+		config: cfg.Alert,
+		sender: newSender(cfg.Alert, log),
+		alerts: make(map[string]*alertState),
+	}
+}
+
+// Observe evaluates the alert rules against a sample and queues the
+// resulting notifications. It never blocks the collection loop;
+// notifications are dropped if the webhooks fall far behind.
+func (n *Notifier) Observe(metrics *models.UAVMetrics) {
+	now := time.Now()
+	firing := n.evaluate(metrics, now)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for key, alert := range firing {
+		state := n.alerts[key]
+		if state == nil {
+			state = &alertState{}
+			n.alerts[key] = state
+		}
+		state.active = true
+		if state.notified || (!state.lastSent.IsZero() && now.Sub(state.lastSent) < n.config.Cooldown) {
+			continue
+		}
+		alert.State = StateFiring
+		if n.sender.enqueue(alert) {
+			state.notified = true
+			state.lastSent = now
+		}
+	}
+
+	prefix := metrics.NodeName + "/"
+	for key, state := range n.alerts {
+		if _, ok := firing[key]; ok || !state.active || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		state.active = false
+		if state.notified {
+			state.notified = false
+			alert := newAlert(metrics, now)
+			alert.Rule, alert.Pattern = ruleOf(strings.TrimPrefix(key, prefix))
+			alert.State = StateResolved
+			alert.Summary = resolvedSummary(alert)
+			n.sender.enqueue(alert)
+		}
+	}
+}
+
+// evaluate returns the alerts firing for a sample, by key
+func (n *Notifier) evaluate(metrics *models.UAVMetrics, now time.Time) map[string]*Alert {
+	firing := make(map[string]*Alert)
+	if metrics.Health == nil {
+		return firing
+	}
+
+	if metrics.Health.Status == models.HealthStatusCritical {
+		alert := newAlert(metrics, now)
+		alert.Rule = RuleHealthCritical
+		alert.Summary = firingSummary(alert)
+		firing[metrics.NodeName+"/"+RuleHealthCritical] = alert
+	}
+
+	// Keyed by pattern rather than message, messages usually carry values
+	// that change with every sample
+	for _, pattern := range n.config.ErrorPatterns {
+		for _, message := range metrics.Health.Errors {
+			if ok, _ := path.Match(pattern, message); ok {
+				alert := newAlert(metrics, now)
+				alert.Rule = RuleHealthError
+				alert.Pattern = pattern
+				alert.Summary = firingSummary(alert)
+				firing[metrics.NodeName+"/"+RuleHealthError+":"+pattern] = alert
+				break
+			}
+		}
+	}
+	return firing
+}
+
+// ruleOf splits an alert key without its node prefix into rule and pattern
+func ruleOf(key string) (string, string) {
+	if pattern, ok := strings.CutPrefix(key, RuleHealthError+":"); ok {
+		return RuleHealthError, pattern
+	}
+	return key, ""
+}
+
+func newAlert(metrics *models.UAVMetrics, now time.Time) *Alert {
+	alert := &Alert{
+		NodeName:       metrics.NodeName,
+		Time:           now.UTC(),
+		BatteryPercent: metrics.Battery.RemainingPercent,
+		Latitude:       metrics.GPS.Latitude,
+		Longitude:      metrics.GPS.Longitude,
+		Altitude:       metrics.GPS.Altitude,
+	}
+	if metrics.Health != nil {
+		alert.Health = metrics.Health.Status
+		alert.Errors = metrics.Health.Errors
+		alert.Warnings = metrics.Health.Warnings
+	}
+	if metrics.Flight != nil {
+		alert.FlightMode = metrics.Flight.Mode
+		alert.Flying = metrics.Flight.IsFlying
+	}
+	return alert
+}
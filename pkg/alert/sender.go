@@ -0,0 +1,209 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/resilience"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// queueSize is the number of notifications waiting for the webhooks
+	// before new ones are dropped
+	queueSize = 64
+
+	// sendAttempts is the number of times a notification is posted to a
+	// webhook that is unreachable or returns a 5xx status
+	sendAttempts = 3
+)
+
+// webhook is one notification receiver
+type webhook struct {
+	url   string
+	slack bool
+}
+
+// sender posts queued notifications to the webhooks one at a time
+type sender struct {
+	webhooks   []webhook
+	httpClient *http.Client
+	queue      chan *Alert
+	log        *logrus.Logger
+}
+
+func newSender(cfg config.AlertConfig, log *logrus.Logger) *sender {
+	s := &sender{
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		queue:      make(chan *Alert, queueSize),
+		log:        log,
+	}
+	for _, u := range cfg.WebhookURLs {
+		s.webhooks = append(s.webhooks, webhook{url: u})
+	}
+	for _, u := range cfg.SlackWebhookURLs {
+		s.webhooks = append(s.webhooks, webhook{url: u, slack: true})
+	}
+	return s
+}
+
+// enqueue queues a notification, reporting false if the queue is full
+func (s *sender) enqueue(alert *Alert) bool {
+	select {
+	case s.queue <- alert:
+		return true
+	default:
+		s.log.WithFields(logrus.Fields{
+			"nodeName": alert.NodeName,
+			"rule":     alert.Rule,
+			"state":    alert.State,
+		}).Warn("Alert queue full, dropping notification")
+		return false
+	}
+}
+
+// Run sends queued notifications until ctx is cancelled
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case alert := <-n.sender.queue:
+			n.sender.send(ctx, alert)
+		}
+	}
+}
+
+// statusError is a webhook response with an unsuccessful status
+type statusError struct {
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return "webhook returned " + e.status
+}
+
+// retryable reports whether posting again may succeed: the webhook was
+// unreachable or failed on its side. A 4xx response (e.g. a revoked Slack
+// webhook) fails the same way on every attempt.
+func retryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500
+	}
+	return true
+}
+
+// send posts a notification to every webhook, retrying network errors and
+// 5xx responses with backoff
+func (s *sender) send(ctx context.Context, alert *Alert) {
+	for _, w := range s.webhooks {
+		backoff := resilience.NewBackoff(time.Second, 10*time.Second)
+		for attempt := 1; ; attempt++ {
+			err := s.post(ctx, w, alert)
+			if err == nil {
+				s.log.WithFields(logrus.Fields{
+					"nodeName": alert.NodeName,
+					"rule":     alert.Rule,
+					"state":    alert.State,
+				}).Info("Alert sent")
+				break
+			}
+			if attempt == sendAttempts || !retryable(err) || ctx.Err() != nil {
+				// The URL is not logged, Slack webhook URLs are secrets
+				s.log.WithError(err).WithFields(logrus.Fields{
+					"nodeName": alert.NodeName,
+					"rule":     alert.Rule,
+					"state":    alert.State,
+				}).Error("Failed to send alert")
+				break
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff.Next()):
+			}
+		}
+	}
+}
+
+func (s *sender) post(ctx context.Context, w webhook, alert *Alert) error {
+	var payload interface{} = alert
+	if w.slack {
+		payload = map[string]string{"text": slackText(alert)}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return &statusError{status: resp.Status, code: resp.StatusCode}
+	}
+	return nil
+}
+
+// unwrapURLError returns the cause of a request error without the URL
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+func firingSummary(alert *Alert) string {
+	if alert.Rule == RuleHealthError {
+		return fmt.Sprintf("%s reports a health error matching %q", alert.NodeName, alert.Pattern)
+	}
+	return fmt.Sprintf("%s health is Critical", alert.NodeName)
+}
+
+func resolvedSummary(alert *Alert) string {
+	if alert.Rule == RuleHealthError {
+		return fmt.Sprintf("%s no longer reports a health error matching %q", alert.NodeName, alert.Pattern)
+	}
+	return fmt.Sprintf("%s health is no longer Critical (now %s)", alert.NodeName, alert.Health)
+}
+
+// slackText formats an alert as a Slack message
+func slackText(alert *Alert) string {
+	var b strings.Builder
+	if alert.State == StateResolved {
+		fmt.Fprintf(&b, ":white_check_mark: *Resolved:* %s", alert.Summary)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, ":rotating_light: *%s*", alert.Summary)
+	for _, e := range alert.Errors {
+		fmt.Fprintf(&b, "\n• %s", e)
+	}
+	fmt.Fprintf(&b, "\nBattery %.1f%%, position %.6f, %.6f at %.0f m", alert.BatteryPercent, alert.Latitude, alert.Longitude, alert.Altitude)
+	if alert.FlightMode != "" {
+		fmt.Fprintf(&b, ", mode %s", alert.FlightMode)
+	}
+	if alert.Flying {
+		b.WriteString(", in flight")
+	}
+	return b.String()
+}
@@ -0,0 +1,9 @@
+// Package v1alpha1 contains the v1alpha1 API of the uav.k3s.io group: the
+// UAVMetrics custom resource published by the agents. The clientset,
+// listers and informers under pkg/generated are generated from these types,
+// and the CRD's OpenAPI schema under api/crd from their kubebuilder markers
+// and those of the pkg/models types they embed.
+//
+// +k8s:deepcopy-gen=package
+// +groupName=uav.k3s.io
+package v1alpha1
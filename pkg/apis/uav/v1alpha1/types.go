@@ -0,0 +1,73 @@
+package v1alpha1
+
+import (
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=uav;uavs,singular=uavmetric
+// +kubebuilder:metadata:annotations="description=UAV Metrics CRD for collecting and managing drone telemetry data"
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.nodeName`
+// +kubebuilder:printcolumn:name="Battery",type=number,JSONPath=`.spec.battery.remainingPercent`
+// +kubebuilder:printcolumn:name="GPS-Lat",type=number,JSONPath=`.spec.gps.latitude`,priority=1
+// +kubebuilder:printcolumn:name="GPS-Lon",type=number,JSONPath=`.spec.gps.longitude`,priority=1
+// +kubebuilder:printcolumn:name="Altitude",type=number,JSONPath=`.spec.gps.altitude`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.spec.health.status`
+// +kubebuilder:printcolumn:name="Score",type=number,JSONPath=`.spec.health.score`,priority=1
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="PowerSave",type=string,JSONPath=`.spec.powerSave.reason`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// UAVMetrics is the telemetry an agent publishes for a vehicle. The spec is
+// the latest sample; the status is written by the agent, the node's router
+// and the lost-UAV detection.
+type UAVMetrics struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   models.UAVMetrics `json:"spec"`
+	Status UAVMetricsStatus  `json:"status,omitempty"`
+}
+
+// UAVMetricsStatus is the status subresource of a UAVMetrics
+type UAVMetricsStatus struct {
+	models.UAVMetricsStatus `json:",inline"`
+
+	// Last known state of the vehicle, set while it's Lost
+	LastKnownPosition *models.LostBeacon `json:"lastKnownPosition,omitempty"`
+
+	// Ready, BatteryHealthy, GPSLocked and LinkHealthy, maintained by the agent
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// UAVMetrics condition types
+const (
+	// The agent is publishing and the vehicle's health isn't critical
+	ConditionReady = "Ready"
+	// Battery is above the low battery threshold
+	ConditionBatteryHealthy = "BatteryHealthy"
+	// The GNSS receiver has a trustworthy 3D fix
+	ConditionGPSLocked = "GPSLocked"
+	// Telemetry and the vehicle's internal links are fresh, with little packet loss
+	ConditionLinkHealthy = "LinkHealthy"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// UAVMetricsList is a list of UAVMetrics
+type UAVMetricsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []UAVMetrics `json:"items"`
+}
@@ -0,0 +1,88 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/k3suav/uav-monitor/pkg/apis/uav/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SensorsAnnotation holds the JSON encoded spec.sensors of a UAVMetrics
+// converted to v1alpha1, which has no field for them, so that they survive
+// a round trip through the storage version
+const SensorsAnnotation = GroupName + "/sensors"
+
+// addConversionFuncs registers the conversions between v1alpha1 and
+// v1beta1 UAVMetrics
+func addConversionFuncs(scheme *runtime.Scheme) error {
+	if err := scheme.AddConversionFunc((*v1alpha1.UAVMetrics)(nil), (*UAVMetrics)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_UAVMetrics_To_v1beta1_UAVMetrics(a.(*v1alpha1.UAVMetrics), b.(*UAVMetrics), scope)
+	}); err != nil {
+		return err
+	}
+	return scheme.AddConversionFunc((*UAVMetrics)(nil), (*v1alpha1.UAVMetrics)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_UAVMetrics_To_v1alpha1_UAVMetrics(a.(*UAVMetrics), b.(*v1alpha1.UAVMetrics), scope)
+	})
+}
+
+// Convert_v1alpha1_UAVMetrics_To_v1beta1_UAVMetrics converts a v1alpha1
+// UAVMetrics, restoring the sensors kept in SensorsAnnotation. An
+// annotation that doesn't decode is left in place, so nothing is lost.
+func Convert_v1alpha1_UAVMetrics_To_v1beta1_UAVMetrics(in *v1alpha1.UAVMetrics, out *UAVMetrics, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = UAVMetricsSpec{UAVMetrics: *in.Spec.DeepCopy()}
+	out.Status = UAVMetricsStatus{
+		UAVMetricsStatus:  *in.Status.UAVMetricsStatus.DeepCopy(),
+		LastKnownPosition: in.Status.LastKnownPosition.DeepCopy(),
+		Conditions:        in.Status.DeepCopy().Conditions,
+	}
+
+	data, ok := out.Annotations[SensorsAnnotation]
+	if !ok {
+		return nil
+	}
+	var sensors []SensorReading
+	if err := json.Unmarshal([]byte(data), &sensors); err != nil {
+		return nil
+	}
+	out.Spec.Sensors = sensors
+	delete(out.Annotations, SensorsAnnotation)
+	if len(out.Annotations) == 0 {
+		out.Annotations = nil
+	}
+	return nil
+}
+
+// Convert_v1beta1_UAVMetrics_To_v1alpha1_UAVMetrics converts a v1beta1
+// UAVMetrics, keeping its sensors in SensorsAnnotation
+func Convert_v1beta1_UAVMetrics_To_v1alpha1_UAVMetrics(in *UAVMetrics, out *v1alpha1.UAVMetrics, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = *in.Spec.UAVMetrics.DeepCopy()
+	out.Status = v1alpha1.UAVMetricsStatus{
+		UAVMetricsStatus:  *in.Status.UAVMetricsStatus.DeepCopy(),
+		LastKnownPosition: in.Status.LastKnownPosition.DeepCopy(),
+		Conditions:        in.Status.DeepCopy().Conditions,
+	}
+
+	// Sensors removed in v1beta1 must not come back from a stale annotation
+	delete(out.Annotations, SensorsAnnotation)
+	if len(in.Spec.Sensors) > 0 {
+		data, err := json.Marshal(in.Spec.Sensors)
+		if err != nil {
+			return fmt.Errorf("failed to encode sensors: %w", err)
+		}
+		if out.Annotations == nil {
+			out.Annotations = map[string]string{}
+		}
+		out.Annotations[SensorsAnnotation] = string(data)
+	}
+	if len(out.Annotations) == 0 {
+		out.Annotations = nil
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package v1beta1_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/apis/uav/v1alpha1"
+	"github.com/k3suav/uav-monitor/pkg/apis/uav/v1beta1"
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newUAVMetrics(sensors []v1beta1.SensorReading, annotations map[string]string) *v1beta1.UAVMetrics {
+	return &v1beta1.UAVMetrics{
+		TypeMeta: metav1.TypeMeta{APIVersion: v1beta1.SchemeGroupVersion.String(), Kind: "UAVMetrics"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "uav-node-1",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+		Spec: v1beta1.UAVMetricsSpec{
+			UAVMetrics: models.UAVMetrics{
+				NodeName: "node-1",
+				GPS:      models.GPSData{Latitude: 34.0522, Longitude: -118.2437, Satellites: 12},
+				Battery:  models.BatteryData{RemainingPercent: 80, Voltage: 22.2},
+			},
+			Sensors: sensors,
+		},
+		Status: v1beta1.UAVMetricsStatus{
+			UAVMetricsStatus: models.UAVMetricsStatus{Phase: "Active"},
+			Conditions: []metav1.Condition{{
+				Type:               "Ready",
+				Status:             metav1.ConditionTrue,
+				Reason:             "Publishing",
+				LastTransitionTime: metav1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+			}},
+		},
+	}
+}
+
+// jsonEqual compares objects as the API server stores them (the models hold
+// time.Time values, which reflect.DeepEqual compares by location)
+func jsonEqual(t *testing.T, a, b interface{}) bool {
+	t.Helper()
+	dataA, err := json.Marshal(a)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	dataB, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	return string(dataA) == string(dataB)
+}
+
+func TestConversionRoundTrip(t *testing.T) {
+	sensors := []v1beta1.SensorReading{
+		{Name: "payload-temp", Type: "temperature", Value: 21.5, Unit: "Cel"},
+		{Name: "wind", Type: "windSpeed", Value: 4.2, Unit: "m/s"},
+	}
+	tests := []struct {
+		name        string
+		in          *v1beta1.UAVMetrics
+		annotations map[string]string // expected on the v1alpha1 object
+	}{
+		{
+			name:        "without sensors",
+			in:          newUAVMetrics(nil, nil),
+			annotations: nil,
+		},
+		{
+			name: "with sensors",
+			in:   newUAVMetrics(sensors, map[string]string{"team": "survey"}),
+			annotations: map[string]string{
+				"team":                    "survey",
+				v1beta1.SensorsAnnotation: `[{"name":"payload-temp","type":"temperature","value":21.5,"unit":"Cel"},{"name":"wind","type":"windSpeed","value":4.2,"unit":"m/s"}]`,
+			},
+		},
+		{
+			name:        "stale sensors annotation",
+			in:          newUAVMetrics(nil, map[string]string{v1beta1.SensorsAnnotation: `[{"name":"removed","value":1}]`}),
+			annotations: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := &v1alpha1.UAVMetrics{}
+			if err := v1beta1.Convert_v1beta1_UAVMetrics_To_v1alpha1_UAVMetrics(tt.in.DeepCopy(), stored, nil); err != nil {
+				t.Fatalf("v1beta1 -> v1alpha1: %v", err)
+			}
+			if !jsonEqual(t, stored.Annotations, tt.annotations) {
+				t.Fatalf("v1alpha1 annotations = %v, want %v", stored.Annotations, tt.annotations)
+			}
+			if !jsonEqual(t, stored.Spec, tt.in.Spec.UAVMetrics) {
+				t.Fatalf("v1alpha1 spec = %+v, want %+v", stored.Spec, tt.in.Spec.UAVMetrics)
+			}
+
+			// Through the JSON the API server stores
+			data, err := json.Marshal(stored)
+			if err != nil {
+				t.Fatalf("failed to encode v1alpha1: %v", err)
+			}
+			decoded := &v1alpha1.UAVMetrics{}
+			if err := json.Unmarshal(data, decoded); err != nil {
+				t.Fatalf("failed to decode v1alpha1: %v", err)
+			}
+
+			out := &v1beta1.UAVMetrics{}
+			if err := v1beta1.Convert_v1alpha1_UAVMetrics_To_v1beta1_UAVMetrics(decoded, out, nil); err != nil {
+				t.Fatalf("v1alpha1 -> v1beta1: %v", err)
+			}
+			want := tt.in.DeepCopy()
+			if _, ok := want.Annotations[v1beta1.SensorsAnnotation]; ok {
+				// Only spec.sensors is authoritative in v1beta1
+				delete(want.Annotations, v1beta1.SensorsAnnotation)
+				if len(want.Annotations) == 0 {
+					want.Annotations = nil
+				}
+			}
+			if !jsonEqual(t, out, want) {
+				t.Fatalf("round trip = %+v, want %+v", out, want)
+			}
+		})
+	}
+}
+
+func TestConvertUndecodableSensorsAnnotation(t *testing.T) {
+	in := &v1alpha1.UAVMetrics{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "uav-node-1",
+			Annotations: map[string]string{v1beta1.SensorsAnnotation: "not json"},
+		},
+	}
+	out := &v1beta1.UAVMetrics{}
+	if err := v1beta1.Convert_v1alpha1_UAVMetrics_To_v1beta1_UAVMetrics(in, out, nil); err != nil {
+		t.Fatalf("v1alpha1 -> v1beta1: %v", err)
+	}
+	if out.Spec.Sensors != nil {
+		t.Fatalf("sensors = %v, want none", out.Spec.Sensors)
+	}
+	if out.Annotations[v1beta1.SensorsAnnotation] != "not json" {
+		t.Fatalf("undecodable annotation was dropped: %v", out.Annotations)
+	}
+}
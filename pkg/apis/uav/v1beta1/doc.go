@@ -0,0 +1,10 @@
+// Package v1beta1 contains the v1beta1 API of the uav.k3s.io group. It is
+// served alongside v1alpha1, which stays the storage version the agents
+// write, and adds fields v1alpha1 has no room for, such as readings of
+// sensors the fixed telemetry sections don't model. The conversion between
+// the two versions keeps those fields in an annotation of the stored
+// object, see conversion.go and pkg/conversion.
+//
+// +k8s:deepcopy-gen=package
+// +groupName=uav.k3s.io
+package v1beta1
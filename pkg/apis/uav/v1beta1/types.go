@@ -0,0 +1,84 @@
+package v1beta1
+
+import (
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=uav;uavs,singular=uavmetric
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.nodeName`
+// +kubebuilder:printcolumn:name="Battery",type=number,JSONPath=`.spec.battery.remainingPercent`
+// +kubebuilder:printcolumn:name="GPS-Lat",type=number,JSONPath=`.spec.gps.latitude`,priority=1
+// +kubebuilder:printcolumn:name="GPS-Lon",type=number,JSONPath=`.spec.gps.longitude`,priority=1
+// +kubebuilder:printcolumn:name="Altitude",type=number,JSONPath=`.spec.gps.altitude`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.spec.health.status`
+// +kubebuilder:printcolumn:name="Score",type=number,JSONPath=`.spec.health.score`,priority=1
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="PowerSave",type=string,JSONPath=`.spec.powerSave.reason`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// UAVMetrics is the telemetry an agent publishes for a vehicle, as served
+// in v1beta1
+type UAVMetrics struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UAVMetricsSpec   `json:"spec"`
+	Status UAVMetricsStatus `json:"status,omitempty"`
+}
+
+// UAVMetricsSpec is the latest telemetry sample: the v1alpha1 spec and the
+// fields added in v1beta1
+type UAVMetricsSpec struct {
+	models.UAVMetrics `json:",inline"`
+
+	// Readings of sensors the fixed sections don't model, such as payload
+	// or environmental sensors
+	// +listType=map
+	// +listMapKey=name
+	Sensors []SensorReading `json:"sensors,omitempty"`
+}
+
+// SensorReading is the latest reading of one sensor
+type SensorReading struct {
+	// Sensor name, unique within the spec
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Measured quantity, e.g. temperature, humidity or windSpeed
+	Type string `json:"type,omitempty"`
+
+	Value float64 `json:"value"`
+
+	// Unit of Value, e.g. Cel, % or m/s
+	Unit string `json:"unit,omitempty"`
+}
+
+// UAVMetricsStatus is the status subresource of a UAVMetrics
+type UAVMetricsStatus struct {
+	models.UAVMetricsStatus `json:",inline"`
+
+	// Last known state of the vehicle, set while it's Lost
+	LastKnownPosition *models.LostBeacon `json:"lastKnownPosition,omitempty"`
+
+	// Ready, BatteryHealthy, GPSLocked and LinkHealthy, maintained by the agent
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// UAVMetricsList is a list of UAVMetrics
+type UAVMetricsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []UAVMetrics `json:"items"`
+}
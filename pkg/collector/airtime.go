@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+const airtimeDateFormat = models.AirtimeDateFormat
+
+// airtimeTracker accumulates airborne time per calendar day (UTC)
+type airtimeTracker struct {
+	date       string
+	airborne   time.Duration
+	lastSample time.Time
+	maxGap     time.Duration // gaps longer than this (agent down) are not counted
+}
+
+func newAirtimeTracker(interval time.Duration) *airtimeTracker {
+	return &airtimeTracker{
+		maxGap: 2 * interval,
+	}
+}
+
+// Update records a sample and adds the time since the previous sample if flying
+func (t *airtimeTracker) Update(isFlying bool, now time.Time) {
+	today := now.UTC().Format(airtimeDateFormat)
+	if today != t.date {
+		t.date = today
+		t.airborne = 0
+		t.lastSample = time.Time{}
+	}
+
+	if isFlying && !t.lastSample.IsZero() {
+		gap := now.Sub(t.lastSample)
+		if gap > 0 && gap <= t.maxGap {
+			t.airborne += gap
+		}
+	}
+	t.lastSample = now
+}
+
+// Restore seeds the tracker from previously published data (e.g. after a restart)
+func (t *airtimeTracker) Restore(data *models.AirtimeData, now time.Time) {
+	if data == nil || data.Date != now.UTC().Format(airtimeDateFormat) {
+		return
+	}
+	t.date = data.Date
+	t.airborne = time.Duration(data.AirborneMinutes * float64(time.Minute))
+}
+
+// Snapshot returns the current airtime data
+func (t *airtimeTracker) Snapshot(budgetMinutes float64) *models.AirtimeData {
+	return &models.AirtimeData{
+		Date:            t.date,
+		AirborneMinutes: t.airborne.Minutes(),
+		BudgetMinutes:   budgetMinutes,
+	}
+}
@@ -0,0 +1,144 @@
+package collector
+
+import (
+	"math"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+const (
+	// ICAO standard atmosphere sea level pressure (hPa)
+	standardPressure = 1013.25
+
+	// Exponent of the standard atmosphere pressure-altitude relation
+	pressureExponent = 0.190263
+
+	// Altitude scale of the standard atmosphere pressure-altitude relation (m)
+	pressureScale = 44330.77
+)
+
+// baroSource is implemented by backends that report static pressure
+type baroSource interface {
+	// Pressure returns the latest static pressure (hPa); ok is false when
+	// there is no fresh reading
+	Pressure() (pressure float64, ok bool)
+}
+
+// pressureAltitude converts static pressure (hPa) to altitude in the ICAO
+// standard atmosphere (m)
+func pressureAltitude(pressure float64) float64 {
+	return pressureScale * (1 - math.Pow(pressure/standardPressure, pressureExponent))
+}
+
+// standardPressureAt is the inverse of pressureAltitude
+func standardPressureAt(altitude float64) float64 {
+	return standardPressure * math.Pow(1-altitude/pressureScale, 1/pressureExponent)
+}
+
+// altitudeFuser combines barometric and GNSS altitude. Pressure altitude is
+// offset from MSL by the local weather, so the offset is learned from GNSS
+// altitude with a slow low-pass filter whenever GNSS altitude is trustworthy;
+// the filter averages out GNSS vertical noise while following the slow
+// weather drift. When GNSS altitude is unusable, e.g. without a 3D fix near
+// the ground, the barometer keeps the last learned offset; before any offset
+// has been learned GNSS altitude is reported instead.
+type altitudeFuser struct {
+	timeConstant time.Duration
+	maxVDOP      float64
+
+	offset     float64 // MSL minus pressure altitude (m)
+	calibrated bool
+	lastUpdate time.Time
+}
+
+func newAltitudeFuser(timeConstant time.Duration, maxVDOP float64) *altitudeFuser {
+	return &altitudeFuser{
+		timeConstant: timeConstant,
+		maxVDOP:      maxVDOP,
+	}
+}
+
+// gnssUsable reports whether the GNSS altitude can be trusted
+func (f *altitudeFuser) gnssUsable(gps *models.GPSData) bool {
+	if gps == nil || gps.PositionDegraded() {
+		return false
+	}
+	if gps.FixType != "" {
+		if !gps.Has3DFix() {
+			return false
+		}
+	} else if gps.Satellites < 4 {
+		return false // no fix type reported, 4 satellites are needed for altitude
+	}
+	return gps.VDOP == nil || *gps.VDOP <= f.maxVDOP
+}
+
+// Update fuses the latest readings. pressure is ignored unless hasBaro is
+// set; gps may be nil. Returns nil when there is no altitude to report:
+// without a barometer when GNSS altitude is unusable, and with an
+// uncalibrated barometer when there is no GNSS reading at all.
+func (f *altitudeFuser) Update(pressure float64, hasBaro bool, gps *models.GPSData, now time.Time) *models.AltitudeData {
+	usable := f.gnssUsable(gps)
+
+	if !hasBaro {
+		if !usable {
+			return nil
+		}
+		gnss := gps.Altitude
+		return &models.AltitudeData{
+			Fused:  gnss,
+			Source: models.AltitudeSourceGNSS,
+			GNSS:   &gnss,
+		}
+	}
+
+	baro := pressureAltitude(pressure)
+	data := &models.AltitudeData{
+		Fused:      baro,
+		Source:     models.AltitudeSourceBaro,
+		Pressure:   &pressure,
+		Barometric: &baro,
+	}
+
+	if usable {
+		gnss := gps.Altitude
+		data.GNSS = &gnss
+		f.calibrate(gnss-baro, now)
+		data.Source = models.AltitudeSourceFused
+	}
+	if f.calibrated {
+		offset := f.offset
+		data.BaroOffset = &offset
+		data.Fused = baro + offset
+		return data
+	}
+
+	// Pressure altitude is not MSL until the offset has been learned, so
+	// report GNSS altitude even if it is not trusted for calibration
+	if gps == nil {
+		return nil
+	}
+	gnss := gps.Altitude
+	data.GNSS = &gnss
+	data.Fused = gnss
+	data.Source = models.AltitudeSourceGNSS
+	return data
+}
+
+// calibrate moves the barometric offset towards a new GNSS sample
+func (f *altitudeFuser) calibrate(sample float64, now time.Time) {
+	if !f.calibrated {
+		f.offset = sample
+		f.calibrated = true
+		f.lastUpdate = now
+		return
+	}
+	dt := now.Sub(f.lastUpdate)
+	if dt <= 0 {
+		return
+	}
+	f.lastUpdate = now
+	alpha := dt.Seconds() / (f.timeConstant.Seconds() + dt.Seconds())
+	f.offset += alpha * (sample - f.offset)
+}
@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// sensorChannel describes the plausibility limits of a single sensor reading
+type sensorChannel struct {
+	sensor   string
+	field    string
+	min, max float64 // valid range
+	maxRate  float64 // max plausible change per second, 0 disables
+	stuck    bool    // reading normally jitters, so repeated identical values are suspicious
+	unit     string
+}
+
+// sensorChannels lists the readings checked by the anomaly detector
+var sensorChannels = []sensorChannel{
+	{sensor: "gps", field: "latitude", min: -90, max: 90, stuck: true},
+	{sensor: "gps", field: "longitude", min: -180, max: 180, stuck: true},
+	{sensor: "gps", field: "altitude", min: -500, max: 10000, maxRate: 50, unit: "m"},
+	{sensor: "gps", field: "speed", min: 0, max: 100, maxRate: 20, unit: "m/s"},
+	{sensor: "battery", field: "remainingPercent", min: 0, max: 100, maxRate: 5, unit: "%"},
+	{sensor: "battery", field: "voltage", min: 0, max: 60, maxRate: 2, stuck: true, unit: "V"},
+	{sensor: "battery", field: "current", min: -200, max: 200, stuck: true, unit: "A"},
+	{sensor: "battery", field: "temperature", min: -40, max: 90, maxRate: 5, unit: "°C"},
+	{sensor: "imu", field: "rollAngle", min: -180, max: 180, maxRate: 720, stuck: true, unit: "°"},
+	{sensor: "imu", field: "pitchAngle", min: -90, max: 90, maxRate: 720, stuck: true, unit: "°"},
+	{sensor: "imu", field: "yawAngle", min: -180, max: 360, unit: "°"}, // ±180 or 0..360 depending on the autopilot
+	{sensor: "imu", field: "verticalSpeed", min: -50, max: 50, maxRate: 20, unit: "m/s"},
+}
+
+type channelState struct {
+	value   float64
+	at      time.Time
+	repeats int
+}
+
+// anomalyDetector flags stuck, implausibly fast-changing and out-of-range sensor readings
+type anomalyDetector struct {
+	stuckSamples int
+	state        map[string]*channelState
+}
+
+func newAnomalyDetector(stuckSamples int) *anomalyDetector {
+	return &anomalyDetector{
+		stuckSamples: stuckSamples,
+		state:        make(map[string]*channelState),
+	}
+}
+
+// Check inspects the sensors present in metrics and returns any anomalies found
+func (d *anomalyDetector) Check(metrics *models.UAVMetrics, now time.Time) []models.SensorAnomaly {
+	readings := d.readings(metrics)
+	// On the ground readings legitimately hold still, so only flag stuck
+	// sensors in flight
+	flying := metrics.Flight != nil && metrics.Flight.IsFlying
+	anomalies := []models.SensorAnomaly{}
+
+	for _, ch := range sensorChannels {
+		key := ch.sensor + "." + ch.field
+		value, ok := readings[key]
+		if !ok {
+			continue
+		}
+
+		if math.IsNaN(value) || value < ch.min || value > ch.max {
+			anomalies = append(anomalies, models.SensorAnomaly{
+				Sensor:  ch.sensor,
+				Field:   ch.field,
+				Type:    models.AnomalyOutOfRange,
+				Value:   value,
+				Message: fmt.Sprintf("%s out of range: %.2f%s not in [%.0f, %.0f]", key, value, ch.unit, ch.min, ch.max),
+			})
+		}
+
+		prev := d.state[key]
+		if prev == nil {
+			d.state[key] = &channelState{value: value, at: now}
+			continue
+		}
+
+		if dt := now.Sub(prev.at).Seconds(); ch.maxRate > 0 && dt > 0 {
+			rate := math.Abs(value-prev.value) / dt
+			if rate > ch.maxRate {
+				anomalies = append(anomalies, models.SensorAnomaly{
+					Sensor:  ch.sensor,
+					Field:   ch.field,
+					Type:    models.AnomalyRateOfChange,
+					Value:   value,
+					Message: fmt.Sprintf("%s changing too fast: %.2f%s/s exceeds %.0f%s/s", key, rate, ch.unit, ch.maxRate, ch.unit),
+				})
+			}
+		}
+
+		if value == prev.value && flying {
+			prev.repeats++
+		} else {
+			prev.repeats = 0
+		}
+		if ch.stuck && d.stuckSamples > 0 && prev.repeats >= d.stuckSamples {
+			anomalies = append(anomalies, models.SensorAnomaly{
+				Sensor:  ch.sensor,
+				Field:   ch.field,
+				Type:    models.AnomalyStuck,
+				Value:   value,
+				Message: fmt.Sprintf("%s stuck at %.6g%s for %d samples", key, value, ch.unit, prev.repeats+1),
+			})
+		}
+
+		prev.value = value
+		prev.at = now
+	}
+
+	return anomalies
+}
+
+// readings flattens the collected sensor values keyed by sensor.field
+func (d *anomalyDetector) readings(metrics *models.UAVMetrics) map[string]float64 {
+	readings := make(map[string]float64)
+
+	// Stale values carried over from a failed collection would look stuck
+	if !metrics.GPS.LastUpdate.IsZero() && !metrics.CollectionFailed(models.SectionGPS) {
+		readings["gps.latitude"] = metrics.GPS.Latitude
+		readings["gps.longitude"] = metrics.GPS.Longitude
+		readings["gps.altitude"] = metrics.GPS.Altitude
+		readings["gps.speed"] = metrics.GPS.Speed
+	}
+
+	if (metrics.Battery.Voltage != 0 || metrics.Battery.RemainingPercent != 0) && !metrics.CollectionFailed(models.SectionBattery) {
+		readings["battery.remainingPercent"] = metrics.Battery.RemainingPercent
+		readings["battery.voltage"] = metrics.Battery.Voltage
+		readings["battery.current"] = metrics.Battery.Current
+		readings["battery.temperature"] = metrics.Battery.Temperature
+	}
+
+	if metrics.Flight != nil {
+		readings["imu.rollAngle"] = metrics.Flight.RollAngle
+		readings["imu.pitchAngle"] = metrics.Flight.PitchAngle
+		readings["imu.yawAngle"] = metrics.Flight.YawAngle
+		readings["imu.verticalSpeed"] = metrics.Flight.VerticalSpeed
+	}
+
+	return readings
+}
@@ -0,0 +1,247 @@
+package collector
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"golang.org/x/time/rate"
+)
+
+// maxBandwidthProbeSize caps the transfer a probe server will send per request
+const maxBandwidthProbeSize = 64 << 20
+
+// bandwidthProbe periodically measures download throughput from a ground
+// endpoint: an HTTP download, or the built-in probe server of another agent
+// (see ServeBandwidthProbe). Collection reads the latest result so a slow
+// link never delays the collection loop.
+type bandwidthProbe struct {
+	cfg    config.BandwidthProbeConfig
+	paused atomic.Bool // no probes while saving power
+
+	mu   sync.Mutex
+	mbps float64 // latest measurement (0 until the first probe succeeds)
+	err  error   // latest probe error
+}
+
+func newBandwidthProbe(cfg config.BandwidthProbeConfig) *bandwidthProbe {
+	if cfg.Target == "" {
+		return nil
+	}
+	return &bandwidthProbe{cfg: cfg}
+}
+
+// Run probes immediately and then every interval until ctx is cancelled
+func (p *bandwidthProbe) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if !p.paused.Load() {
+			probeCtx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+			mbps, err := p.measure(probeCtx)
+			cancel()
+
+			p.mu.Lock()
+			p.err = err
+			if err == nil {
+				p.mbps = mbps
+			}
+			p.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SetPaused pauses or resumes probing. Result keeps returning the last
+// measurement while paused.
+func (p *bandwidthProbe) SetPaused(paused bool) {
+	p.paused.Store(paused)
+}
+
+// Result returns the latest measured bandwidth in Mbps and the error of the
+// latest probe. A failed probe keeps the previous measurement.
+func (p *bandwidthProbe) Result() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.mbps, p.err
+}
+
+func (p *bandwidthProbe) measure(ctx context.Context) (float64, error) {
+	if strings.HasPrefix(p.cfg.Target, "http://") || strings.HasPrefix(p.cfg.Target, "https://") {
+		return p.measureHTTP(ctx)
+	}
+	return p.measureTCP(ctx)
+}
+
+// measureHTTP downloads up to Size bytes from the target URL
+func (p *bandwidthProbe) measureHTTP(ctx context.Context) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", p.cfg.Size-1))
+	req.Header.Set("Cache-Control", "no-cache")
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("bandwidth probe %s returned %s", p.cfg.Target, resp.Status)
+	}
+
+	n, err := io.Copy(io.Discard, io.LimitReader(resp.Body, int64(p.cfg.Size)))
+	if err != nil {
+		return 0, err
+	}
+	return throughputMbps(n, time.Since(start))
+}
+
+// measureTCP requests Size bytes from another agent's probe server
+func (p *bandwidthProbe) measureTCP(ctx context.Context) (float64, error) {
+	// Read on every probe so a rotated Secret takes effect
+	token, err := readProbeToken(p.cfg.TokenFile)
+	if err != nil {
+		return 0, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.cfg.Target)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	start := time.Now()
+	request := binary.BigEndian.AppendUint32(nil, uint32(p.cfg.Size))
+	request = append(request, byte(len(token)))
+	request = append(request, token...)
+	if _, err := conn.Write(request); err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(io.Discard, io.LimitReader(conn, int64(p.cfg.Size)))
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("bandwidth probe %s refused the probe (wrong token or rate limited)", p.cfg.Target)
+	}
+	if n < int64(p.cfg.Size) {
+		return 0, fmt.Errorf("bandwidth probe %s sent %d of %d bytes", p.cfg.Target, n, p.cfg.Size)
+	}
+	return throughputMbps(n, time.Since(start))
+}
+
+func throughputMbps(bytes int64, elapsed time.Duration) (float64, error) {
+	if bytes == 0 || elapsed <= 0 {
+		return 0, fmt.Errorf("bandwidth probe transferred no data")
+	}
+	return float64(bytes) * 8 / elapsed.Seconds() / 1e6, nil
+}
+
+// readProbeToken reads the shared probe token, which must be 1 to 255 bytes
+func readProbeToken(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bandwidth probe token: %w", err)
+	}
+	token := []byte(strings.TrimSpace(string(data)))
+	if len(token) == 0 || len(token) > 255 {
+		return nil, fmt.Errorf("bandwidth probe token in %s must be 1 to 255 bytes", path)
+	}
+	return token, nil
+}
+
+// ServeBandwidthProbe answers bandwidth probes from other agents on
+// cfg.Listen until ctx is cancelled. Each connection sends a 4-byte
+// big-endian byte count, a 1-byte token length and the token, and receives
+// that many bytes. Probes with the wrong token, or beyond cfg.ServeRate per
+// minute, are closed without data.
+func ServeBandwidthProbe(ctx context.Context, cfg config.BandwidthProbeConfig) error {
+	token, err := readProbeToken(cfg.TokenFile)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen for bandwidth probes on %s: %w", cfg.Listen, err)
+	}
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	payload := make([]byte, 32*1024)
+	for i := range payload {
+		payload[i] = byte(i) // not all zeros, so link compression can't inflate the result
+	}
+	limiter := rate.NewLimiter(rate.Limit(float64(cfg.ServeRate)/60), 1)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("bandwidth probe server: %w", err)
+		}
+		go serveBandwidthProbe(conn, payload, token, limiter, cfg.Timeout)
+	}
+}
+
+func serveBandwidthProbe(conn net.Conn, payload, token []byte, limiter *rate.Limiter, timeout time.Duration) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	var request [5]byte
+	if _, err := io.ReadFull(conn, request[:]); err != nil {
+		return
+	}
+	presented := make([]byte, request[4])
+	if _, err := io.ReadFull(conn, presented); err != nil {
+		return
+	}
+	if subtle.ConstantTimeCompare(presented, token) != 1 {
+		return
+	}
+	// Only authenticated probes use up the rate
+	if !limiter.Allow() {
+		return
+	}
+
+	remaining := int(binary.BigEndian.Uint32(request[:4]))
+	if remaining > maxBandwidthProbeSize {
+		remaining = maxBandwidthProbeSize
+	}
+	for remaining > 0 {
+		chunk := payload
+		if remaining < len(chunk) {
+			chunk = chunk[:remaining]
+		}
+		if _, err := conn.Write(chunk); err != nil {
+			return
+		}
+		remaining -= len(chunk)
+	}
+}
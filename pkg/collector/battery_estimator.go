@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"math"
+)
+
+// enduranceEstimator estimates battery time remaining from a rolling window
+// of measured current draw and the remaining capacity
+type enduranceEstimator struct {
+	capacityMah float64
+	window      []float64 // absolute current draw samples (A)
+	size        int
+	next        int
+	filled      bool
+}
+
+func newEnduranceEstimator(capacityMah float64, size int) *enduranceEstimator {
+	return &enduranceEstimator{
+		capacityMah: capacityMah,
+		window:      make([]float64, size),
+		size:        size,
+	}
+}
+
+// Estimate records a current sample and returns the estimated seconds
+// remaining and a confidence in [0, 1]
+func (e *enduranceEstimator) Estimate(remainingPercent, current float64) (int, float64) {
+	draw := math.Abs(current)
+	if draw > 0 {
+		e.window[e.next] = draw
+		e.next = (e.next + 1) % e.size
+		if e.next == 0 {
+			e.filled = true
+		}
+	}
+
+	samples := e.samples()
+	if len(samples) == 0 {
+		// No current measurement yet: naive linear guess with low confidence
+		return int((remainingPercent / 100) * 1800), 0.1
+	}
+
+	mean, stddev := meanStddev(samples)
+	if mean <= 0 {
+		return 0, 0
+	}
+
+	remainingAh := e.capacityMah / 1000 * remainingPercent / 100
+	seconds := remainingAh / mean * 3600
+
+	// Confidence grows with window fill and shrinks with draw variability
+	fill := float64(len(samples)) / float64(e.size)
+	variability := stddev / mean
+	confidence := fill * (1 - math.Min(variability, 1))
+
+	return int(seconds), confidence
+}
+
+func (e *enduranceEstimator) samples() []float64 {
+	if e.filled {
+		return e.window
+	}
+	return e.window[:e.next]
+}
+
+func meanStddev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
@@ -0,0 +1,173 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// Battery chemistries
+const (
+	ChemistryLiPo       = "lipo"
+	ChemistryLiIon      = "li-ion"
+	ChemistrySolidState = "solid-state"
+)
+
+// maxCellVoltage is above the full voltage of every chemistry; a reading
+// above it is a pack voltage, not a cell's
+const maxCellVoltage = 5.0
+
+// batteryProfile describes the cell voltages of a battery chemistry
+type batteryProfile struct {
+	name string
+
+	// Nominal cell voltage, used to convert capacity to energy
+	nominal float64
+
+	// Cell voltage below which the pack is critically low whatever the
+	// reported charge, as cells are damaged or sag out under load
+	critical float64
+
+	// Resting cell voltage at a state of charge, by ascending voltage
+	curve []socPoint
+}
+
+type socPoint struct {
+	voltage float64
+	percent float64
+}
+
+// batteryProfiles lists the supported chemistries. Curves are typical
+// resting (open-circuit) voltages; under load the estimate reads low.
+var batteryProfiles = map[string]batteryProfile{
+	ChemistryLiPo: {
+		name:     "LiPo",
+		nominal:  3.7,
+		critical: 3.5,
+		curve: []socPoint{
+			{3.27, 0}, {3.61, 5}, {3.69, 10}, {3.71, 15}, {3.73, 20}, {3.75, 25},
+			{3.77, 30}, {3.79, 35}, {3.80, 40}, {3.82, 45}, {3.84, 50}, {3.85, 55},
+			{3.87, 60}, {3.91, 65}, {3.95, 70}, {3.98, 75}, {4.02, 80}, {4.08, 85},
+			{4.11, 90}, {4.15, 95}, {4.20, 100},
+		},
+	},
+	ChemistryLiIon: {
+		name:     "Li-ion",
+		nominal:  3.6,
+		critical: 3.2,
+		curve: []socPoint{
+			{3.00, 0}, {3.45, 5}, {3.55, 10}, {3.68, 20}, {3.74, 30}, {3.79, 40},
+			{3.82, 50}, {3.87, 60}, {3.92, 70}, {3.98, 80}, {4.06, 90}, {4.20, 100},
+		},
+	},
+	ChemistrySolidState: {
+		name:     "solid-state",
+		nominal:  3.85,
+		critical: 3.3,
+		curve: []socPoint{
+			{3.00, 0}, {3.45, 5}, {3.60, 10}, {3.70, 20}, {3.77, 30}, {3.84, 40},
+			{3.91, 50}, {3.99, 60}, {4.08, 70}, {4.18, 80}, {4.30, 90}, {4.45, 100},
+		},
+	},
+}
+
+// batteryPack is the configured battery: a chemistry and a number of cells
+// in series
+type batteryPack struct {
+	chemistry string
+	profile   batteryProfile
+	cells     int
+}
+
+func newBatteryPack(chemistry string, cells int) *batteryPack {
+	profile, ok := batteryProfiles[chemistry]
+	if !ok {
+		chemistry, profile = ChemistryLiPo, batteryProfiles[ChemistryLiPo]
+	}
+	return &batteryPack{chemistry: chemistry, profile: profile, cells: cells}
+}
+
+// NominalVoltage returns the nominal pack voltage
+func (p *batteryPack) NominalVoltage() float64 {
+	return p.profile.nominal * float64(p.cells)
+}
+
+// Voltage returns the resting pack voltage at a state of charge
+func (p *batteryPack) Voltage(percent float64) float64 {
+	curve := p.profile.curve
+	i := sort.Search(len(curve), func(i int) bool { return curve[i].percent >= percent })
+	var cell float64
+	switch {
+	case i == 0:
+		cell = curve[0].voltage
+	case i == len(curve):
+		cell = curve[len(curve)-1].voltage
+	default:
+		lo, hi := curve[i-1], curve[i]
+		cell = lo.voltage + (percent-lo.percent)/(hi.percent-lo.percent)*(hi.voltage-lo.voltage)
+	}
+	return math.Round(cell*float64(p.cells)*1000) / 1000
+}
+
+// Percent estimates the state of charge from the pack voltage
+func (p *batteryPack) Percent(voltage float64) float64 {
+	cell := voltage / float64(p.cells)
+	curve := p.profile.curve
+	i := sort.Search(len(curve), func(i int) bool { return curve[i].voltage >= cell })
+	switch {
+	case i == 0:
+		return 0
+	case i == len(curve):
+		return 100
+	}
+	lo, hi := curve[i-1], curve[i]
+	percent := lo.percent + (cell-lo.voltage)/(hi.voltage-lo.voltage)*(hi.percent-lo.percent)
+	return math.Round(percent*10) / 10
+}
+
+// Annotate adds the pack's chemistry, cell voltage and cell imbalance to
+// battery and, when the source reports no state of charge (NaN), estimates
+// it from the voltage
+func (p *batteryPack) Annotate(battery *models.BatteryData) error {
+	battery.Chemistry = p.chemistry
+	battery.Cells = p.cells
+	if battery.Voltage > 0 {
+		battery.CellVoltage = math.Round(battery.Voltage/float64(p.cells)*1000) / 1000
+	}
+	if len(battery.CellVoltages) > 1 {
+		lowest, highest := battery.CellVoltages[0], battery.CellVoltages[0]
+		for _, cell := range battery.CellVoltages[1:] {
+			lowest, highest = math.Min(lowest, cell), math.Max(highest, cell)
+		}
+		battery.CellImbalance = math.Round((highest-lowest)*1000) / 1000
+	}
+	if !math.IsNaN(battery.RemainingPercent) {
+		return nil
+	}
+	if battery.Voltage <= 0 {
+		return fmt.Errorf("battery reports neither remaining charge nor voltage")
+	}
+	battery.RemainingPercent = p.Percent(battery.Voltage)
+	battery.RemainingEstimated = true
+	return nil
+}
+
+// CriticalVoltage returns why the cell voltage is critically low for the
+// chemistry, empty when it isn't
+func (p *batteryPack) CriticalVoltage(battery *models.BatteryData) string {
+	if battery.CellVoltage <= 0 || battery.CellVoltage >= p.profile.critical {
+		return ""
+	}
+	return fmt.Sprintf("Critical cell voltage: %.2fV (%s minimum %.2fV)", battery.CellVoltage, p.profile.name, p.profile.critical)
+}
+
+// cellImbalanceWarning returns why the spread of the cell voltages exceeds
+// threshold, empty when it doesn't or the cells aren't reported
+func cellImbalanceWarning(battery *models.BatteryData, threshold float64) string {
+	if threshold <= 0 || battery.CellImbalance <= threshold {
+		return ""
+	}
+	return fmt.Sprintf("Battery cell imbalance: %.3fV (threshold %.3fV)", battery.CellImbalance, threshold)
+}
@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// gpsEpoch is the start of GPS time
+var gpsEpoch = time.Date(1980, time.January, 6, 0, 0, 0, 0, time.UTC)
+
+// gpsLeapSeconds is GPS time minus UTC, used when the receiver doesn't
+// report it
+const gpsLeapSeconds = 18
+
+// gnssTimeSource is implemented by backends that report the receiver's
+// time
+type gnssTimeSource interface {
+	// GNSSTime returns the latest GNSS time and the system time at which it
+	// was received, false when no fresh time is available
+	GNSSTime() (gnss, received time.Time, ok bool)
+}
+
+// clockSkew returns the system clock minus GNSS time in seconds, nil when
+// the backend reports no GNSS time
+func clockSkew(backend telemetryBackend) *float64 {
+	source, ok := backend.(gnssTimeSource)
+	if !ok {
+		return nil
+	}
+	gnss, received, ok := source.GNSSTime()
+	if !ok {
+		return nil
+	}
+	skew := math.Round(received.Sub(gnss).Seconds()*1000) / 1000
+	return &skew
+}
+
+// clockSkewWarning returns a health warning when the skew exceeds
+// threshold (0 disables the check), empty otherwise
+func clockSkewWarning(gps *models.GPSData, threshold time.Duration) string {
+	if gps.ClockSkew == nil || threshold <= 0 || math.Abs(*gps.ClockSkew) <= threshold.Seconds() {
+		return ""
+	}
+	direction := "ahead of"
+	if *gps.ClockSkew < 0 {
+		direction = "behind"
+	}
+	return fmt.Sprintf("System clock is %.1fs %s GNSS time", math.Abs(*gps.ClockSkew), direction)
+}
@@ -4,8 +4,10 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"math"
 	"math/rand"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -16,9 +18,42 @@ import (
 
 // Collector collects UAV telemetry data
 type Collector struct {
-	config     *config.Config
-	rand       *rand.Rand
-	hostPrefix string // 主机路径前缀（容器中为 /host，宿主机为空）
+	config       *config.Config
+	rand         *rand.Rand
+	hostPrefix   string // 主机路径前缀（容器中为 /host，宿主机为空）
+	gpsFilter    *gpsFilter
+	gpsWarning   string          // outlier rejection note from the latest GPS fix
+	modem        modemReader     // nil for simulated signal
+	modemWarning string          // why the latest modem read failed
+	bandwidth    *bandwidthProbe // nil for simulated bandwidth
+	probeWarning string          // why the latest bandwidth probe failed
+	latency      *latencyProbe   // nil for simulated latency and packet loss
+	airtime      *airtimeTracker
+	stats        *flightStatsTracker
+	endurance    *enduranceEstimator
+	battery      *batteryPack // configured chemistry and cell count
+	home         *homeTracker
+	anomalies    *anomalyDetector
+	integrity    *gnssIntegrityMonitor
+	altitude     *altitudeFuser
+	geofences    *geofenceChecker    // nil when no geofences are configured
+	healthRules  *healthRuleEngine   // threshold rules of the health check
+	powerSave    *powerSaveTracker   // low-power collection mode
+	backend      telemetryBackend    // nil for simulated telemetry
+	configured   telemetryBackend    // configured backend, running even while simulating
+	runCtx       context.Context     // context the backend runs with, nil before Start
+	watchdog     sourceWatchdog      // collection step in progress, for the agent's watchdog
+	lastGPS      *models.GPSData     // last good filtered fix, published while GPS fails
+	lastBattery  *models.BatteryData // last good battery reading, published while battery fails
+}
+
+// telemetryBackend supplies GPS, battery and flight data from a vehicle bus
+type telemetryBackend interface {
+	Run(ctx context.Context)
+	GPS() (*models.GPSData, error)
+	Battery() (*models.BatteryData, error)
+	Flight() (*models.FlightData, error)
+	Diagnostics() *models.DiagnosticsData
 }
 
 // NewCollector creates a new data collector
@@ -29,64 +64,283 @@ func NewCollector(cfg *config.Config) *Collector {
 		hostPrefix = "/host"
 	}
 
-	return &Collector{
+	battery := newBatteryPack(cfg.Collection.BatteryChemistry, cfg.Collection.BatteryCells)
+	c := &Collector{
 		config:     cfg,
 		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
 		hostPrefix: hostPrefix,
+		gpsFilter: newGPSFilter(
+			cfg.Collection.GPSFilter,
+			cfg.Collection.GPSSmoothingFactor,
+			cfg.Collection.GPSProcessNoise,
+			cfg.Collection.GPSMaxSpeed,
+		),
+		airtime: newAirtimeTracker(cfg.Collection.Interval),
+		stats:   newFlightStatsTracker(cfg.Collection.Interval, time.Now()),
+		endurance: newEnduranceEstimator(
+			cfg.Collection.BatteryCapacityMah,
+			cfg.Collection.BatteryEstimatorWindow,
+		),
+		home: newHomeTracker(
+			cfg.Collection.HomeLatitude,
+			cfg.Collection.HomeLongitude,
+			cfg.Collection.ReturnCruiseSpeed,
+			cfg.Collection.BatteryCapacityMah,
+			battery.NominalVoltage(),
+		),
+		battery:   battery,
+		anomalies: newAnomalyDetector(cfg.Collection.AnomalyStuckSamples),
+		modem:     newModemReader(cfg.Modem),
+		bandwidth: newBandwidthProbe(cfg.BandwidthProbe),
+		latency:   newLatencyProbe(cfg.LatencyProbe),
+		integrity: newGNSSIntegrityMonitor(
+			cfg.Collection.GNSSDivergenceTolerance,
+			cfg.Collection.GNSSAltitudeTolerance,
+			cfg.Collection.GNSSDivergenceSamples,
+		),
+		altitude: newAltitudeFuser(
+			cfg.Collection.AltitudeFusionTimeConstant,
+			cfg.Collection.AltitudeMaxVDOP,
+		),
+		geofences: newGeofenceChecker(cfg.Collection.Geofences),
+		powerSave: &powerSaveTracker{},
+	}
+	c.healthRules = newHealthRuleEngine(cfg.Collection.EffectiveHealthRules(), c.healthField)
+	c.configured = newTelemetryBackend(cfg)
+	c.backend = c.activeBackend()
+	return c
+}
+
+// newTelemetryBackend creates the configured telemetry backend, nil for the
+// simulated one
+func newTelemetryBackend(cfg *config.Config) telemetryBackend {
+	switch cfg.Collection.Backend {
+	case BackendROS2:
+		return newROS2Backend(cfg.ROS2)
+	case BackendDroneCAN:
+		return newDroneCANBackend(cfg.DroneCAN)
+	case BackendSITL:
+		return newSITLBackend(cfg.SITL, cfg.Agent.NodeName)
+	}
+	return nil
+}
+
+// activeBackend returns the backend telemetry is read from, nil when
+// simulating
+func (c *Collector) activeBackend() telemetryBackend {
+	if c.config.Collection.Simulate {
+		return nil
+	}
+	return c.configured
+}
+
+// Start runs background telemetry subscriptions until ctx is cancelled
+func (c *Collector) Start(ctx context.Context) {
+	c.runCtx = ctx
+	if c.configured != nil {
+		c.startBackend()
+	}
+	if c.bandwidth != nil {
+		go c.bandwidth.Run(ctx)
+	}
+	if c.latency != nil {
+		go c.latency.Run(ctx)
+	}
+}
+
+// Reload applies the runtime-changeable settings of cfg (see
+// config.ApplyReloadable) and returns the changed settings that need a
+// restart. It must not run concurrently with CollectMetrics.
+func (c *Collector) Reload(cfg *config.Config) ([]string, error) {
+	restart, err := c.config.ApplyReloadable(cfg)
+	if err != nil {
+		return nil, err
 	}
+	c.applyPowerSave()
+	c.geofences = newGeofenceChecker(c.config.Collection.Geofences)
+	if rules := c.config.Collection.EffectiveHealthRules(); !reflect.DeepEqual(c.healthRules.definitions(), rules) {
+		c.healthRules = newHealthRuleEngineFrom(rules, c.healthRules, c.healthField)
+	}
+	if backend := c.activeBackend(); backend != c.backend {
+		// Simulated and real positions are unrelated; don't filter or check
+		// one against the other
+		c.backend = backend
+		c.gpsFilter = newGPSFilter(
+			c.config.Collection.GPSFilter,
+			c.config.Collection.GPSSmoothingFactor,
+			c.config.Collection.GPSProcessNoise,
+			c.config.Collection.GPSMaxSpeed,
+		)
+		c.integrity = newGNSSIntegrityMonitor(
+			c.config.Collection.GNSSDivergenceTolerance,
+			c.config.Collection.GNSSAltitudeTolerance,
+			c.config.Collection.GNSSDivergenceSamples,
+		)
+		c.lastGPS, c.lastBattery = nil, nil
+	}
+	return restart, nil
+}
+
+// RestoreState seeds cumulative state (today's airtime, flight stats, home
+// position) from previously published metrics so agent restarts don't
+// reset it
+func (c *Collector) RestoreState(previous *models.UAVMetrics) {
+	c.airtime.Restore(previous.Airtime, time.Now())
+	c.stats.Restore(previous.Stats)
+	c.home.Restore(previous.Home)
 }
 
-// CollectMetrics collects all enabled metrics
+// RestoreHome seeds the home position from the node's local state. It takes
+// precedence over the published home, which may be sealed.
+func (c *Collector) RestoreHome(home *models.HomeData) {
+	c.home.Restore(home)
+}
+
+// CollectMetrics collects all enabled metrics. A section that fails is
+// recorded in CollectionErrors and the rest are still collected, so a dead
+// sensor does not keep the other telemetry from reaching the cluster. The
+// error is only returned when ctx is cancelled.
 func (c *Collector) CollectMetrics(ctx context.Context) (*models.UAVMetrics, error) {
 	metrics := &models.UAVMetrics{
-		NodeName: c.config.Agent.NodeName,
+		NodeName:   c.config.Agent.NodeName,
+		GroundNode: c.config.Agent.GroundNode,
+		Simulated:  c.backend == nil,
+	}
+	c.reinitialize()
+	defer c.watchdog.leave()
+
+	failed := func(section string, err error) {
+		metrics.CollectionErrors = append(metrics.CollectionErrors, models.CollectionError{
+			Section: section,
+			Error:   err.Error(),
+		})
 	}
 
 	// Collect GPS data
+	var rawGPS *models.GPSData
 	if c.config.Collection.EnableGPS {
+		c.watchdog.enter(models.SectionGPS)
 		gps, err := c.collectGPS(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to collect GPS data: %w", err)
+			failed(models.SectionGPS, err)
+			c.gpsWarning = ""
+			if c.lastGPS != nil {
+				metrics.GPS = *c.lastGPS
+			}
+		} else {
+			rawGPS = gps
+			gps, c.gpsWarning = c.gpsFilter.Apply(gps)
+			metrics.GPS = *gps
+			c.lastGPS = gps
 		}
-		metrics.GPS = *gps
+	}
+
+	// Compare the system clock with GNSS time, even without a position fix
+	if c.config.Collection.EnableGPS {
+		metrics.GPS.ClockSkew = clockSkew(c.backend)
 	}
 
 	// Collect battery data
 	if c.config.Collection.EnableBattery {
+		c.watchdog.enter(models.SectionBattery)
 		battery, err := c.collectBattery(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to collect battery data: %w", err)
+			failed(models.SectionBattery, err)
+			if c.lastBattery != nil {
+				metrics.Battery = *c.lastBattery
+			}
+		} else {
+			metrics.Battery = *battery
+			c.lastBattery = battery
 		}
-		metrics.Battery = *battery
 	}
 
 	// Collect flight data
 	if c.config.Collection.EnableFlight {
+		c.watchdog.enter(models.SectionFlight)
 		flight, err := c.collectFlight(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to collect flight data: %w", err)
+			failed(models.SectionFlight, err)
+		} else {
+			metrics.Flight = flight
+
+			if source, ok := c.backend.(escSource); ok {
+				metrics.ESC = source.ESC()
+			}
+
+			c.airtime.Update(flight.IsFlying, time.Now())
+		}
+		metrics.Airtime = c.airtime.Snapshot(c.config.Collection.AirtimeBudgetMinutes)
+	}
+
+	// Save power while the battery is low on the ground or when asked to,
+	// before the probes are read. A vehicle whose flight state failed to
+	// collect is assumed airborne.
+	var battery *models.BatteryData
+	if c.config.Collection.EnableBattery && !metrics.CollectionFailed(models.SectionBattery) {
+		battery = &metrics.Battery
+	}
+	airborne := metrics.CollectionFailed(models.SectionFlight) ||
+		metrics.Flight != nil && (metrics.Flight.Armed || metrics.Flight.IsFlying)
+	metrics.PowerSave = c.powerSave.Update(c.config.PowerSave, battery, airborne, time.Now())
+	c.applyPowerSave()
+
+	// Check the raw fix against dead reckoning and baro altitude. Simulated
+	// telemetry is random and physically inconsistent, so it is not checked.
+	if rawGPS != nil && c.backend != nil && c.config.Collection.EnableGNSSIntegrity {
+		reference := metrics.Flight
+		if c.config.Collection.Backend == BackendDroneCAN {
+			reference = nil // DroneCAN flight data carries no attitude or altitude
 		}
-		metrics.Flight = flight
+		metrics.GPS.Integrity = c.integrity.Check(rawGPS, reference)
 	}
 
+	// Fuse barometric and GNSS altitude
+	c.watchdog.enter(sectionAltitude)
+	metrics.Altitude = c.collectAltitude(metrics)
+
 	// Collect network data
 	if c.config.Collection.EnableNetwork {
+		c.watchdog.enter(models.SectionNetwork)
 		network, err := c.collectNetwork(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to collect network data: %w", err)
+			failed(models.SectionNetwork, err)
+		} else {
+			metrics.Network = network
 		}
-		metrics.Network = network
 	}
 
 	// Collect performance data
 	if c.config.Collection.EnablePerformance {
+		c.watchdog.enter(models.SectionPerformance)
 		performance, err := c.collectPerformance(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to collect performance data: %w", err)
+			failed(models.SectionPerformance, err)
+		} else {
+			metrics.Performance = performance
 		}
-		metrics.Performance = performance
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Distance and return cost relative to home
+	if c.config.Collection.EnableGPS && c.lastGPS != nil {
+		metrics.Home = c.home.Update(&metrics.GPS, &metrics.Battery)
+	}
+
+	// Hardware diagnostics
+	if c.config.Collection.EnableDiagnostics {
+		c.watchdog.enter(sectionDiagnostics)
+		metrics.Diagnostics = c.collectDiagnostics(metrics)
+	}
+	c.watchdog.leave() // the remaining steps read no data source
+
+	// Update derived usage statistics
+	c.stats.Update(metrics, time.Now())
+	metrics.Stats = c.stats.Snapshot()
+
 	// Perform health check
 	if c.config.Collection.EnableHealthCheck {
 		health := c.performHealthCheck(metrics)
@@ -106,6 +360,17 @@ func (c *Collector) CollectMetrics(ctx context.Context) (*models.UAVMetrics, err
 
 // collectGPS collects GPS data (simulated for now)
 func (c *Collector) collectGPS(ctx context.Context) (*models.GPSData, error) {
+	if c.backend != nil {
+		gps, err := c.backend.GPS()
+		if err != nil {
+			return nil, err
+		}
+		if err := gps.ValidateGPS(); err != nil {
+			return nil, err
+		}
+		return gps, nil
+	}
+
 	// TODO: Integrate with real GPS hardware
 	// For now, generate realistic simulated data based on node
 
@@ -132,6 +397,18 @@ func (c *Collector) collectGPS(ctx context.Context) (*models.GPSData, error) {
 		LastUpdate: time.Now(),
 	}
 
+	gps.FixType = models.GNSSFix3D
+	hdop := 0.6 + c.rand.Float64()*0.9 // 0.6-1.5
+	vdop := hdop * 1.5
+	gps.HDOP, gps.VDOP = &hdop, &vdop
+	gps.Constellations = c.simulatedConstellations(gps.Satellites)
+	jamming := c.rand.Intn(15)
+	gps.Interference = &models.GNSSInterference{
+		JammingState:     models.GNSSStateOK,
+		SpoofingState:    models.GNSSStateOK,
+		JammingIndicator: &jamming,
+	}
+
 	// Validate GPS data
 	if err := gps.ValidateGPS(); err != nil {
 		return nil, err
@@ -140,8 +417,42 @@ func (c *Collector) collectGPS(ctx context.Context) (*models.GPSData, error) {
 	return gps, nil
 }
 
+// simulatedConstellations splits the satellites in use across constellations,
+// keeping at least 4 GPS satellites as a multi-GNSS receiver typically would
+func (c *Collector) simulatedConstellations(total int) *models.GNSSConstellations {
+	constellations := &models.GNSSConstellations{GPS: 4}
+	for i := 4; i < total; i++ {
+		switch c.rand.Intn(4) {
+		case 0:
+			constellations.GPS++
+		case 1:
+			constellations.GLONASS++
+		case 2:
+			constellations.Galileo++
+		default:
+			constellations.BeiDou++
+		}
+	}
+	return constellations
+}
+
 // collectBattery collects battery data
 func (c *Collector) collectBattery(ctx context.Context) (*models.BatteryData, error) {
+	if c.backend != nil {
+		battery, err := c.backend.Battery()
+		if err != nil {
+			return nil, err
+		}
+		if err := c.battery.Annotate(battery); err != nil {
+			return nil, err
+		}
+		battery.TimeRemaining, battery.TimeRemainingConfidence = c.endurance.Estimate(battery.RemainingPercent, battery.Current)
+		if err := battery.ValidateBattery(); err != nil {
+			return nil, err
+		}
+		return battery, nil
+	}
+
 	// Try to read from system power supply
 	remainingPercent, err := c.readBatteryFromSystem()
 	if err != nil {
@@ -151,12 +462,20 @@ func (c *Collector) collectBattery(ctx context.Context) (*models.BatteryData, er
 
 	battery := &models.BatteryData{
 		RemainingPercent: remainingPercent,
-		Voltage:          11.1 + (remainingPercent/100)*1.5, // 11.1V-12.6V for 3S LiPo
-		Current:          -5.0 - c.rand.Float64()*5.0,        // -5 to -10A when flying
-		Temperature:      20 + c.rand.Float64()*15,           // 20-35°C
-		TimeRemaining:    int((remainingPercent / 100) * 1800), // Estimate 30 min max flight time
+		Voltage:          c.battery.Voltage(remainingPercent), // resting voltage of the configured pack
+		Current:          -5.0 - c.rand.Float64()*5.0,         // -5 to -10A when flying
+		Temperature:      20 + c.rand.Float64()*15,            // 20-35°C
 		CycleCount:       50 + c.rand.Intn(200),
 	}
+	// Simulated smart battery cells, balanced within 20mV
+	for i := 0; i < c.config.Collection.BatteryCells; i++ {
+		cell := battery.Voltage/float64(c.config.Collection.BatteryCells) + (c.rand.Float64()-0.5)*0.02
+		battery.CellVoltages = append(battery.CellVoltages, math.Round(cell*1000)/1000)
+	}
+	c.battery.Annotate(battery)
+
+	// Estimate endurance from the rolling current-draw history
+	battery.TimeRemaining, battery.TimeRemainingConfidence = c.endurance.Estimate(battery.RemainingPercent, battery.Current)
 
 	// Validate battery data
 	if err := battery.ValidateBattery(); err != nil {
@@ -168,6 +487,10 @@ func (c *Collector) collectBattery(ctx context.Context) (*models.BatteryData, er
 
 // collectFlight collects flight data
 func (c *Collector) collectFlight(ctx context.Context) (*models.FlightData, error) {
+	if c.backend != nil {
+		return c.backend.Flight()
+	}
+
 	modes := []string{
 		models.FlightModeStabilize,
 		models.FlightModeAltitudeHold,
@@ -189,6 +512,31 @@ func (c *Collector) collectFlight(ctx context.Context) (*models.FlightData, erro
 	return flight, nil
 }
 
+// collectAltitude fuses the barometer with the (filtered) GNSS altitude. A
+// failed GPS section keeps the last known fix in metrics, which must not
+// calibrate the barometer, so fusion then runs without GNSS.
+func (c *Collector) collectAltitude(metrics *models.UAVMetrics) *models.AltitudeData {
+	var gps *models.GPSData
+	if c.config.Collection.EnableGPS && !metrics.CollectionFailed(models.SectionGPS) {
+		gps = &metrics.GPS
+	}
+
+	var pressure float64
+	var hasBaro bool
+	if c.backend != nil {
+		if source, ok := c.backend.(baroSource); ok {
+			pressure, hasBaro = source.Pressure()
+		}
+	} else if gps != nil {
+		// Simulated barometer: standard atmosphere shifted by a fixed
+		// weather offset, plus sensor noise
+		pressure = standardPressureAt(gps.Altitude-30) + (c.rand.Float64()-0.5)*0.1
+		hasBaro = true
+	}
+
+	return c.altitude.Update(pressure, hasBaro, gps, time.Now())
+}
+
 // collectNetwork collects network data
 func (c *Collector) collectNetwork(ctx context.Context) (*models.NetworkData, error) {
 	// Try to measure real latency
@@ -208,6 +556,40 @@ func (c *Collector) collectNetwork(ctx context.Context) (*models.NetworkData, er
 		ConnectionType: connectionTypes[c.rand.Intn(len(connectionTypes))],
 	}
 
+	// Real serving cell from the modem replaces the simulated signal
+	if c.modem != nil {
+		c.modemWarning = ""
+		cell, err := c.modem.Read(ctx)
+		if err != nil {
+			c.modemWarning = fmt.Sprintf("Cellular modem unavailable: %v", err)
+			network.SignalStrength = 0
+			network.ConnectionType = models.ConnectionTypeUnknown
+		} else {
+			network.Cellular = cell
+			network.SignalStrength = int(math.Round(cell.RSRP))
+			network.ConnectionType = connectionType(cell.Technology)
+		}
+	}
+
+	// Probe stream latency and packet loss replace the simulated values
+	if c.latency != nil {
+		if latency, loss, ok := c.latency.Result(); ok {
+			network.Latency = latency
+			network.PacketLoss = loss
+		}
+	}
+
+	// Measured bandwidth replaces the simulated value
+	if c.bandwidth != nil {
+		c.probeWarning = ""
+		mbps, err := c.bandwidth.Result()
+		if err != nil {
+			c.probeWarning = fmt.Sprintf("Bandwidth probe failed: %v", err)
+		}
+		network.Bandwidth = mbps
+		network.BandwidthMeasured = mbps > 0
+	}
+
 	return network, nil
 }
 
@@ -248,41 +630,165 @@ func (c *Collector) performHealthCheck(metrics *models.UAVMetrics) *models.Healt
 		LastHealthCheck: time.Now(),
 	}
 
-	// Check battery
-	if metrics.Battery.IsCriticalBattery() {
+	// Check sensor plausibility
+	if c.config.Collection.EnableAnomalyDetection {
+		health.Anomalies = c.anomalies.Check(metrics, health.LastHealthCheck)
+		for _, anomaly := range health.Anomalies {
+			health.Warnings = append(health.Warnings, fmt.Sprintf("Sensor anomaly (%s): %s", anomaly.Sensor, anomaly.Message))
+			if health.Status == models.HealthStatusHealthy {
+				health.Status = models.HealthStatusWarning
+			}
+		}
+	}
+
+	// Sections that failed to collect. GPS and battery are flight critical;
+	// their threshold checks are skipped below since the values are stale.
+	for _, e := range metrics.CollectionErrors {
+		msg := fmt.Sprintf("Failed to collect %s data: %s", e.Section, e.Error)
+		switch e.Section {
+		case models.SectionGPS, models.SectionBattery:
+			health.Status = models.HealthStatusCritical
+			health.Errors = append(health.Errors, msg)
+		default:
+			health.Warnings = append(health.Warnings, msg)
+			if health.Status == models.HealthStatusHealthy {
+				health.Status = models.HealthStatusWarning
+			}
+		}
+	}
+
+	// Check battery voltages; the charge thresholds are health rules
+	if !metrics.CollectionFailed(models.SectionBattery) {
+		if msg := c.battery.CriticalVoltage(&metrics.Battery); msg != "" {
+			health.Status = models.HealthStatusCritical
+			health.Errors = append(health.Errors, msg)
+		}
+		if msg := cellImbalanceWarning(&metrics.Battery, c.config.Collection.CellImbalanceThreshold); msg != "" {
+			if health.Status != models.HealthStatusCritical {
+				health.Status = models.HealthStatusWarning
+			}
+			health.Warnings = append(health.Warnings, msg)
+		}
+	}
+
+	// Check hardware diagnostics
+	if metrics.Diagnostics != nil {
+		for _, sensor := range metrics.Diagnostics.Sensors {
+			var problem string
+			switch {
+			case !sensor.Present:
+				problem = "not detected"
+			case !sensor.Healthy:
+				problem = "unhealthy"
+			case sensor.Calibrated != nil && !*sensor.Calibrated:
+				problem = "not calibrated"
+			default:
+				continue
+			}
+			health.Warnings = append(health.Warnings, fmt.Sprintf("Sensor %s %s", sensor.Name, problem))
+			if health.Status == models.HealthStatusHealthy {
+				health.Status = models.HealthStatusWarning
+			}
+		}
+		for _, link := range metrics.Diagnostics.StaleLinks {
+			health.Warnings = append(health.Warnings, fmt.Sprintf("Stale link: %s", link))
+			if health.Status == models.HealthStatusHealthy {
+				health.Status = models.HealthStatusWarning
+			}
+		}
+	}
+
+	// Check the system clock against GNSS time
+	if warning := clockSkewWarning(&metrics.GPS, c.config.Collection.ClockSkewThreshold); warning != "" {
+		health.Warnings = append(health.Warnings, warning)
+		if health.Status == models.HealthStatusHealthy {
+			health.Status = models.HealthStatusWarning
+		}
+	}
+
+	// Check GNSS interference reported by the receiver
+	if gnss := metrics.GPS.Interference; gnss != nil {
+		if gnss.SpoofingState == models.GNSSStateDetected {
+			health.Status = models.HealthStatusCritical
+			health.Errors = append(health.Errors, "GNSS spoofing detected, position cannot be trusted")
+		} else if gnss.SpoofingState == models.GNSSStateMitigated {
+			health.Warnings = append(health.Warnings, "GNSS spoofing mitigated by receiver")
+			if health.Status == models.HealthStatusHealthy {
+				health.Status = models.HealthStatusWarning
+			}
+		}
+
+		var jamming string
+		switch {
+		case gnss.JammingState == models.GNSSStateDetected:
+			jamming = "GNSS jamming detected"
+		case gnss.JammingState == models.GNSSStateMitigated:
+			jamming = "GNSS jamming mitigated by receiver"
+		case gnss.JammingIndicator != nil && *gnss.JammingIndicator >= c.config.Collection.GNSSJammingThreshold:
+			jamming = fmt.Sprintf("Suspected GNSS jamming: indicator %d", *gnss.JammingIndicator)
+		}
+		if jamming != "" {
+			health.Warnings = append(health.Warnings, jamming)
+			if health.Status == models.HealthStatusHealthy {
+				health.Status = models.HealthStatusWarning
+			}
+		}
+	}
+
+	// Check GNSS integrity against dead reckoning and baro altitude
+	if metrics.GPS.PositionDegraded() {
 		health.Status = models.HealthStatusCritical
-		health.Errors = append(health.Errors, fmt.Sprintf("Critical battery: %.1f%%", metrics.Battery.RemainingPercent))
-	} else if metrics.Battery.IsLowBattery(c.config.Collection.BatteryLowThreshold) {
-		if health.Status != models.HealthStatusCritical {
+		for _, reason := range metrics.GPS.Integrity.Reasons {
+			health.Errors = append(health.Errors, fmt.Sprintf("Security alert: suspected GNSS spoofing or jamming, %s", reason))
+		}
+	}
+
+	if c.modemWarning != "" {
+		health.Warnings = append(health.Warnings, c.modemWarning)
+		if health.Status == models.HealthStatusHealthy {
+			health.Status = models.HealthStatusWarning
+		}
+	}
+	if c.probeWarning != "" {
+		health.Warnings = append(health.Warnings, c.probeWarning)
+		if health.Status == models.HealthStatusHealthy {
 			health.Status = models.HealthStatusWarning
 		}
-		health.Warnings = append(health.Warnings, fmt.Sprintf("Low battery: %.1f%%", metrics.Battery.RemainingPercent))
 	}
 
-	// Check GPS
-	if metrics.GPS.Satellites < c.config.Collection.GPSMinSatellites {
-		health.Warnings = append(health.Warnings, fmt.Sprintf("Low GPS satellites: %d", metrics.GPS.Satellites))
+	if c.gpsWarning != "" {
+		health.Warnings = append(health.Warnings, c.gpsWarning)
 		if health.Status == models.HealthStatusHealthy {
 			health.Status = models.HealthStatusWarning
 		}
 	}
 
-	// Check network
-	if metrics.Network != nil && metrics.Network.Latency > 200 {
-		health.Warnings = append(health.Warnings, fmt.Sprintf("High latency: %.1fms", metrics.Network.Latency))
+	// Check airtime budget
+	if metrics.Airtime != nil && metrics.Airtime.IsOverBudget(metrics.Airtime.BudgetMinutes, health.LastHealthCheck) {
+		health.Warnings = append(health.Warnings, fmt.Sprintf("Daily airtime budget exhausted: %.1f/%.0f min",
+			metrics.Airtime.AirborneMinutes, metrics.Airtime.BudgetMinutes))
 		if health.Status == models.HealthStatusHealthy {
 			health.Status = models.HealthStatusWarning
 		}
 	}
 
-	// Check performance
-	if metrics.Performance != nil && metrics.Performance.CPUUsage > 80 {
-		health.Warnings = append(health.Warnings, fmt.Sprintf("High CPU usage: %.1f%%", metrics.Performance.CPUUsage))
+	// Check the threshold rules: the built-in battery, GPS fix and geofence
+	// rules and the configured ones (network, performance, ...)
+	ruleErrors, ruleWarnings := c.healthRules.Check(metrics, health.LastHealthCheck)
+	if len(ruleErrors) > 0 {
+		health.Status = models.HealthStatusCritical
+		health.Errors = append(health.Errors, ruleErrors...)
+	}
+	if len(ruleWarnings) > 0 {
+		health.Warnings = append(health.Warnings, ruleWarnings...)
 		if health.Status == models.HealthStatusHealthy {
 			health.Status = models.HealthStatusWarning
 		}
 	}
 
+	score := c.scoreHealth(metrics, health)
+	health.Score = &score
+
 	return health
 }
 
@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// collectDiagnostics reports sensor presence and link freshness for the
+// active backend and flags links older than the configured threshold
+func (c *Collector) collectDiagnostics(metrics *models.UAVMetrics) *models.DiagnosticsData {
+	var diag *models.DiagnosticsData
+	if c.backend != nil {
+		diag = c.backend.Diagnostics()
+	} else {
+		diag = c.simulatedDiagnostics(metrics)
+	}
+
+	threshold := c.config.Collection.DiagnosticsStaleThreshold.Seconds()
+	if diag.GPSMessageAge != nil && *diag.GPSMessageAge > threshold {
+		diag.StaleLinks = append(diag.StaleLinks, fmt.Sprintf("GPS last message %.1fs ago", *diag.GPSMessageAge))
+	}
+	if diag.HeartbeatAge != nil && *diag.HeartbeatAge > threshold {
+		diag.StaleLinks = append(diag.StaleLinks, fmt.Sprintf("flight controller heartbeat %.1fs ago", *diag.HeartbeatAge))
+	}
+
+	return diag
+}
+
+// simulatedDiagnostics reports every enabled simulated sensor as present and healthy
+func (c *Collector) simulatedDiagnostics(metrics *models.UAVMetrics) *models.DiagnosticsData {
+	diag := &models.DiagnosticsData{Backend: BackendSimulated}
+	calibrated := true
+
+	if c.config.Collection.EnableGPS {
+		diag.Sensors = append(diag.Sensors, models.SensorStatus{Name: "gps", Present: true, Healthy: true})
+		diag.GPSMessageAge = ageSeconds(metrics.GPS.LastUpdate)
+	}
+	if c.config.Collection.EnableBattery {
+		diag.Sensors = append(diag.Sensors, models.SensorStatus{Name: "battery", Present: true, Healthy: true})
+	}
+	if c.config.Collection.EnableFlight {
+		diag.Sensors = append(diag.Sensors, models.SensorStatus{Name: "imu", Present: true, Healthy: true, Calibrated: &calibrated})
+	}
+
+	return diag
+}
+
+// ageSeconds returns the seconds elapsed since t, or nil if t is unset
+func ageSeconds(t time.Time) *float64 {
+	if t.IsZero() {
+		return nil
+	}
+	age := time.Since(t).Seconds()
+	return &age
+}
@@ -0,0 +1,604 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// Telemetry backends
+const (
+	BackendSimulated = "simulated"
+	BackendROS2      = "ros2"
+	BackendDroneCAN  = "dronecan"
+	BackendSITL      = "sitl"
+)
+
+// DroneCAN (UAVCAN v0) data type IDs
+const (
+	dronecanESCStatus   = 1034 // uavcan.equipment.esc.Status
+	dronecanPressure    = 1028 // uavcan.equipment.air_data.StaticPressure
+	dronecanGNSSFix2    = 1063 // uavcan.equipment.gnss.Fix2
+	dronecanBatteryInfo = 1092 // uavcan.equipment.power.BatteryInfo
+	dronecanNodeStatus  = 341  // uavcan.protocol.NodeStatus
+
+	canEFFFlag = 0x80000000
+	canEFFMask = 0x1FFFFFFF
+
+	kelvinOffset = 273.15
+
+	// BatteryInfo state_of_charge_pct when the battery can't tell
+	dronecanSOCUnknown = 127
+)
+
+// canFrame is a single classic CAN frame
+type canFrame struct {
+	ID   uint32
+	Data []byte
+}
+
+// canSocket reads frames from a CAN interface
+type canSocket interface {
+	ReadFrame() (canFrame, error)
+	Close() error
+}
+
+// escSource is implemented by backends that report ESC telemetry
+type escSource interface {
+	ESC() []models.ESCData
+}
+
+// transferKey identifies an in-progress multi-frame transfer
+type transferKey struct {
+	dataType uint16
+	source   uint8
+}
+
+type transferBuffer struct {
+	transferID uint8
+	toggle     bool
+	payload    []byte
+}
+
+type dronecanFix struct {
+	latitude  float64
+	longitude float64
+	altitude  float64 // MSL, meters
+	velocity  [3]float64
+	sats      int
+	status    int // 0 no fix, 1 time only, 2 2D, 3 3D
+	mode      int // 0 single, 1 DGPS, 2 RTK, 3 PPP
+	subMode   int // RTK: 0 float, 1 fixed
+	accuracy  float64
+
+	// GNSS time converted to UTC, zero when not reported
+	gnssTime time.Time
+}
+
+type dronecanBattery struct {
+	temperature float64 // °C
+	voltage     float64
+	current     float64 // positive while discharging
+	soc         int
+}
+
+// dronecanBackend reads battery, GNSS and ESC broadcasts directly from a
+// DroneCAN bus over SocketCAN
+type dronecanBackend struct {
+	cfg  config.DroneCANConfig
+	open func(iface string) (canSocket, error)
+
+	transfers map[transferKey]*transferBuffer
+
+	mu       sync.RWMutex
+	fix      *dronecanFix
+	battery  *dronecanBattery
+	pressure float64 // static pressure, hPa
+	escs     map[int]models.ESCData
+	updated  map[uint16]time.Time
+	escSeen  map[int]time.Time
+	nodes    map[uint8]dronecanNode
+	lastErr  error
+}
+
+// dronecanNode is the latest NodeStatus heartbeat of a bus node
+type dronecanNode struct {
+	health int // 0 OK, 1 warning, 2 error, 3 critical
+	seen   time.Time
+}
+
+func newDroneCANBackend(cfg config.DroneCANConfig) *dronecanBackend {
+	return &dronecanBackend{
+		cfg:       cfg,
+		open:      openCANSocket,
+		transfers: make(map[transferKey]*transferBuffer),
+		escs:      make(map[int]models.ESCData),
+		updated:   make(map[uint16]time.Time),
+		escSeen:   make(map[int]time.Time),
+		nodes:     make(map[uint8]dronecanNode),
+	}
+}
+
+// Run reads the bus and reopens the interface until ctx is cancelled
+func (b *dronecanBackend) Run(ctx context.Context) {
+	for {
+		err := b.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		b.setErr(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ros2ReconnectDelay):
+		}
+	}
+}
+
+func (b *dronecanBackend) session(ctx context.Context) error {
+	sock, err := b.open(b.cfg.Interface)
+	if err != nil {
+		return fmt.Errorf("failed to open CAN interface %s: %w", b.cfg.Interface, err)
+	}
+	defer sock.Close()
+	b.setErr(nil)
+
+	for ctx.Err() == nil {
+		frame, err := sock.ReadFrame()
+		if err != nil {
+			if isTimeout(err) {
+				continue
+			}
+			return fmt.Errorf("CAN read failed on %s: %w", b.cfg.Interface, err)
+		}
+		b.handleFrame(frame)
+	}
+	return ctx.Err()
+}
+
+// handleFrame reassembles DroneCAN transfers and decodes complete messages
+func (b *dronecanBackend) handleFrame(frame canFrame) {
+	if frame.ID&canEFFFlag == 0 || len(frame.Data) == 0 {
+		return // DroneCAN only uses 29-bit identifiers
+	}
+
+	id := frame.ID & canEFFMask
+	if id&0x80 != 0 {
+		return // service transfer
+	}
+	dataType := uint16(id >> 8)
+	source := uint8(id & 0x7F)
+	if source == 0 {
+		return // anonymous message
+	}
+	switch dataType {
+	case dronecanESCStatus, dronecanGNSSFix2, dronecanBatteryInfo, dronecanPressure:
+	case dronecanNodeStatus:
+		if len(frame.Data) >= 8 {
+			b.recordNodeStatus(source, frame.Data[:7])
+		}
+		return
+	default:
+		return
+	}
+
+	tail := frame.Data[len(frame.Data)-1]
+	start := tail&0x80 != 0
+	end := tail&0x40 != 0
+	toggle := tail&0x20 != 0
+	transferID := tail & 0x1F
+	data := frame.Data[:len(frame.Data)-1]
+
+	key := transferKey{dataType: dataType, source: source}
+
+	if start && end {
+		delete(b.transfers, key)
+		b.decode(dataType, data)
+		return
+	}
+
+	if start {
+		// Multi-frame transfer; the first two bytes carry the transfer CRC.
+		// The CAN controller already checks each frame, so the CRC is skipped.
+		if len(data) < 2 || toggle {
+			return
+		}
+		b.transfers[key] = &transferBuffer{
+			transferID: transferID,
+			toggle:     toggle,
+			payload:    append([]byte{}, data[2:]...),
+		}
+		return
+	}
+
+	buf := b.transfers[key]
+	if buf == nil || buf.transferID != transferID || buf.toggle == toggle {
+		delete(b.transfers, key) // lost a frame, wait for the next transfer
+		return
+	}
+	buf.toggle = toggle
+	buf.payload = append(buf.payload, data...)
+
+	if end {
+		delete(b.transfers, key)
+		b.decode(dataType, buf.payload)
+	}
+}
+
+func (b *dronecanBackend) decode(dataType uint16, payload []byte) {
+	r := bitReader(payload)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch dataType {
+	case dronecanGNSSFix2:
+		if len(payload) < 48 {
+			return
+		}
+		fix := &dronecanFix{
+			longitude: float64(r.signed(136, 37)) / 1e8,
+			latitude:  float64(r.signed(173, 37)) / 1e8,
+			altitude:  float64(r.signed(237, 27)) / 1000,
+			velocity: [3]float64{
+				float64(math.Float32frombits(uint32(r.unsigned(264, 32)))),
+				float64(math.Float32frombits(uint32(r.unsigned(296, 32)))),
+				float64(math.Float32frombits(uint32(r.unsigned(328, 32)))),
+			},
+			sats:    int(r.unsigned(360, 6)),
+			status:  int(r.unsigned(366, 2)),
+			mode:    int(r.unsigned(368, 4)),
+			subMode: int(r.unsigned(372, 6)),
+		}
+		fix.gnssTime = fix2Time(r.unsigned(56, 56), int(r.unsigned(112, 3)), int(r.unsigned(128, 8)))
+
+		// Position covariance: scalar, 6-element diagonal or full 6x6 matrix
+		n := int(r.unsigned(378, 6))
+		if n > 0 && len(payload)*8 >= 384+n*16 {
+			cov := func(i int) float64 { return float16(uint16(r.unsigned(384+i*16, 16))) }
+			switch {
+			case n == 1:
+				fix.accuracy = math.Sqrt(cov(0))
+			case n >= 36:
+				fix.accuracy = math.Sqrt((cov(0) + cov(7)) / 2)
+			case n >= 2:
+				fix.accuracy = math.Sqrt((cov(0) + cov(1)) / 2)
+			}
+		}
+
+		b.fix = fix
+	case dronecanBatteryInfo:
+		if len(payload) < 18 {
+			return
+		}
+		b.battery = &dronecanBattery{
+			temperature: float16(uint16(r.unsigned(0, 16))) - kelvinOffset,
+			voltage:     float16(uint16(r.unsigned(16, 16))),
+			current:     float16(uint16(r.unsigned(32, 16))),
+			soc:         int(r.unsigned(130, 7)),
+		}
+	case dronecanESCStatus:
+		if len(payload) < 14 {
+			return
+		}
+		index := int(r.unsigned(105, 5))
+		b.escs[index] = models.ESCData{
+			Index:              index,
+			ErrorCount:         int64(r.unsigned(0, 32)),
+			Voltage:            float16(uint16(r.unsigned(32, 16))),
+			Current:            float16(uint16(r.unsigned(48, 16))),
+			Temperature:        float16(uint16(r.unsigned(64, 16))) - kelvinOffset,
+			RPM:                int(r.signed(80, 18)),
+			PowerRatingPercent: int(r.unsigned(98, 7)),
+		}
+		b.escSeen[index] = time.Now()
+	case dronecanPressure:
+		if len(payload) < 4 {
+			return
+		}
+		b.pressure = float64(math.Float32frombits(uint32(r.unsigned(0, 32)))) / 100 // Pa to hPa
+	}
+
+	b.updated[dataType] = time.Now()
+}
+
+// recordNodeStatus stores a single-frame NodeStatus heartbeat
+func (b *dronecanBackend) recordNodeStatus(source uint8, payload []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nodes[source] = dronecanNode{
+		health: int(bitReader(payload).unsigned(32, 2)),
+		seen:   time.Now(),
+	}
+}
+
+func (b *dronecanBackend) setErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastErr = err
+}
+
+// fresh reports whether dataType was received within the stale timeout.
+// Caller must hold b.mu.
+func (b *dronecanBackend) fresh(dataType uint16) bool {
+	updated, ok := b.updated[dataType]
+	return ok && time.Since(updated) <= b.cfg.StaleTimeout
+}
+
+// staleError explains why a message has no usable data. Caller must hold b.mu.
+func (b *dronecanBackend) staleError(name string) error {
+	if b.lastErr != nil {
+		return fmt.Errorf("no fresh %s on %s: %w", name, b.cfg.Interface, b.lastErr)
+	}
+	return fmt.Errorf("no fresh %s on %s", name, b.cfg.Interface)
+}
+
+// GPS returns the latest Fix2 converted to GPSData
+func (b *dronecanBackend) GPS() (*models.GPSData, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.fix == nil || !b.fresh(dronecanGNSSFix2) {
+		return nil, b.staleError("gnss.Fix2")
+	}
+	if b.fix.status < 2 {
+		return nil, fmt.Errorf("no GNSS position fix reported on %s", b.cfg.Interface)
+	}
+
+	north, east := b.fix.velocity[0], b.fix.velocity[1]
+	heading := math.Atan2(east, north) * 180 / math.Pi
+	if heading < 0 {
+		heading += 360
+	}
+
+	return &models.GPSData{
+		Latitude:   b.fix.latitude,
+		Longitude:  b.fix.longitude,
+		Altitude:   b.fix.altitude,
+		Heading:    heading,
+		Speed:      math.Hypot(north, east),
+		Satellites: b.fix.sats,
+		Accuracy:   b.fix.accuracy,
+		LastUpdate: b.updated[dronecanGNSSFix2],
+		FixType:    b.fix.fixType(),
+	}, nil
+}
+
+// fixType combines the Fix2 status and mode. Fix2 carries PDOP only, so
+// HDOP/VDOP are not reported.
+func (f *dronecanFix) fixType() string {
+	switch {
+	case f.status < 2: // no fix, or time only
+		return models.GNSSFixNone
+	case f.status == 2:
+		return models.GNSSFix2D
+	}
+	switch f.mode {
+	case 1:
+		return models.GNSSFixDGPS
+	case 2:
+		if f.subMode == 1 {
+			return models.GNSSFixRTKFixed
+		}
+		return models.GNSSFixRTKFloat
+	}
+	return models.GNSSFix3D
+}
+
+// Battery returns the latest BatteryInfo converted to BatteryData
+func (b *dronecanBackend) Battery() (*models.BatteryData, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.battery == nil || !b.fresh(dronecanBatteryInfo) {
+		return nil, b.staleError("power.BatteryInfo")
+	}
+
+	battery := &models.BatteryData{
+		RemainingPercent: float64(b.battery.soc),
+		Voltage:          b.battery.voltage,
+		Current:          -b.battery.current, // discharge is negative in UAVMetrics
+		Temperature:      b.battery.temperature,
+	}
+	if b.battery.soc == dronecanSOCUnknown || b.battery.soc > 100 {
+		// Not reported; estimated from the voltage
+		battery.RemainingPercent = math.NaN()
+	}
+	return battery, nil
+}
+
+// Flight infers armed/flying state from ESC activity; DroneCAN carries no
+// autopilot mode or attitude, so those fields are left empty
+func (b *dronecanBackend) Flight() (*models.FlightData, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.fresh(dronecanESCStatus) {
+		return nil, b.staleError("esc.Status")
+	}
+
+	spinning := false
+	for index, esc := range b.escs {
+		if time.Since(b.escSeen[index]) <= b.cfg.StaleTimeout && esc.RPM != 0 {
+			spinning = true
+			break
+		}
+	}
+
+	flight := &models.FlightData{
+		Armed:    spinning,
+		IsFlying: spinning,
+	}
+	if b.fix != nil && b.fresh(dronecanGNSSFix2) {
+		flight.VerticalSpeed = -b.fix.velocity[2] // NED down to climb rate
+	}
+	return flight, nil
+}
+
+// GNSSTime returns the GNSS time of the latest Fix2
+func (b *dronecanBackend) GNSSTime() (time.Time, time.Time, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.fix == nil || !b.fresh(dronecanGNSSFix2) || b.fix.status < 1 || b.fix.gnssTime.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	return b.fix.gnssTime, b.updated[dronecanGNSSFix2], true
+}
+
+// fix2Time converts a Fix2 GNSS timestamp in microseconds to UTC. Only the
+// UTC and GPS time standards are supported; TAI and unknown ones return the
+// zero time.
+func fix2Time(usec uint64, standard, leapSeconds int) time.Time {
+	if usec == 0 {
+		return time.Time{}
+	}
+	switch standard {
+	case 2: // UTC
+		return time.UnixMicro(int64(usec)).UTC()
+	case 3: // GPS
+		if leapSeconds == 0 {
+			leapSeconds = gpsLeapSeconds
+		}
+		return gpsEpoch.Add(time.Duration(usec)*time.Microsecond - time.Duration(leapSeconds)*time.Second)
+	}
+	return time.Time{}
+}
+
+// Pressure returns the latest StaticPressure broadcast
+func (b *dronecanBackend) Pressure() (float64, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.fresh(dronecanPressure) || b.pressure <= 0 {
+		return 0, false
+	}
+	return b.pressure, true
+}
+
+// ESC returns the latest status of every ESC seen within the stale timeout
+func (b *dronecanBackend) ESC() []models.ESCData {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.escLocked()
+}
+
+// escLocked is ESC for callers already holding b.mu
+func (b *dronecanBackend) escLocked() []models.ESCData {
+	escs := []models.ESCData{}
+	for index, esc := range b.escs {
+		if time.Since(b.escSeen[index]) <= b.cfg.StaleTimeout {
+			escs = append(escs, esc)
+		}
+	}
+	sort.Slice(escs, func(i, j int) bool { return escs[i].Index < escs[j].Index })
+	return escs
+}
+
+// bitReader decodes DroneCAN's bit-packed serialization: fields are laid
+// out MSB-first in the bit stream and multi-byte values are little-endian
+type bitReader []byte
+
+func (r bitReader) unsigned(offset, length int) uint64 {
+	var value uint64
+	for i := 0; i*8 < length; i++ {
+		n := length - i*8
+		if n > 8 {
+			n = 8
+		}
+		var chunk uint64
+		for bit := 0; bit < n; bit++ {
+			pos := offset + i*8 + bit
+			if pos/8 >= len(r) {
+				break
+			}
+			chunk = chunk<<1 | uint64(r[pos/8]>>(7-pos%8)&1)
+		}
+		value |= chunk << (8 * i)
+	}
+	return value
+}
+
+func (r bitReader) signed(offset, length int) int64 {
+	value := r.unsigned(offset, length)
+	if value&(1<<(length-1)) != 0 {
+		value |= ^uint64(0) << length
+	}
+	return int64(value)
+}
+
+// float16 converts an IEEE 754 half-precision value
+func float16(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(h>>10) & 0x1F
+	frac := float64(h & 0x3FF)
+
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(frac, -24)
+	case 0x1F:
+		if frac == 0 {
+			return sign * math.Inf(1)
+		}
+		return math.NaN()
+	}
+	return sign * math.Ldexp(1+frac/1024, exp-15)
+}
+
+// Diagnostics reports GNSS, battery, ESC and bus node presence. The heartbeat
+// age is that of the most recent NodeStatus on the bus.
+func (b *dronecanBackend) Diagnostics() *models.DiagnosticsData {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	diag := &models.DiagnosticsData{Backend: BackendDroneCAN}
+	if updated, ok := b.updated[dronecanGNSSFix2]; ok {
+		diag.GPSMessageAge = ageSeconds(updated)
+	}
+
+	var latest time.Time
+	nodeIDs := make([]int, 0, len(b.nodes))
+	for id, node := range b.nodes {
+		nodeIDs = append(nodeIDs, int(id))
+		if node.seen.After(latest) {
+			latest = node.seen
+		}
+	}
+	diag.HeartbeatAge = ageSeconds(latest)
+
+	gnssPresent := b.fresh(dronecanGNSSFix2)
+	diag.Sensors = append(diag.Sensors,
+		models.SensorStatus{Name: "gnss", Present: gnssPresent, Healthy: gnssPresent && b.fix != nil && b.fix.status >= 2},
+		models.SensorStatus{Name: "battery", Present: b.fresh(dronecanBatteryInfo), Healthy: b.fresh(dronecanBatteryInfo)},
+	)
+	for _, esc := range b.escLocked() {
+		diag.Sensors = append(diag.Sensors, models.SensorStatus{
+			Name:    fmt.Sprintf("esc-%d", esc.Index),
+			Present: true,
+			Healthy: esc.ErrorCount == 0,
+		})
+	}
+
+	sort.Ints(nodeIDs)
+	for _, id := range nodeIDs {
+		node := b.nodes[uint8(id)]
+		present := time.Since(node.seen) <= b.cfg.StaleTimeout
+		diag.Sensors = append(diag.Sensors, models.SensorStatus{
+			Name:    fmt.Sprintf("node-%d", id),
+			Present: present,
+			Healthy: present && node.health == 0,
+		})
+	}
+
+	return diag
+}
@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// rawCANSocket is a SocketCAN CAN_RAW socket
+type rawCANSocket struct {
+	fd int
+}
+
+func openCANSocket(iface string) (canSocket, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := unix.Socket(unix.AF_CAN, unix.SOCK_RAW, unix.CAN_RAW)
+	if err != nil {
+		return nil, fmt.Errorf("socket: %w", err)
+	}
+
+	// Periodic read timeouts let the reader notice cancellation
+	timeout := unix.Timeval{Sec: 1}
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &timeout); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("setsockopt: %w", err)
+	}
+
+	if err := unix.Bind(fd, &unix.SockaddrCAN{Ifindex: ifi.Index}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("bind: %w", err)
+	}
+
+	return &rawCANSocket{fd: fd}, nil
+}
+
+// ReadFrame reads one struct can_frame
+func (s *rawCANSocket) ReadFrame() (canFrame, error) {
+	buf := make([]byte, 16)
+	n, err := unix.Read(s.fd, buf)
+	if err != nil {
+		return canFrame{}, err
+	}
+	if n != len(buf) {
+		return canFrame{}, fmt.Errorf("short CAN frame: %d bytes", n)
+	}
+
+	length := int(buf[4])
+	if length > 8 {
+		length = 8
+	}
+	return canFrame{
+		ID:   binary.LittleEndian.Uint32(buf[0:4]),
+		Data: buf[8 : 8+length],
+	}, nil
+}
+
+func (s *rawCANSocket) Close() error {
+	return unix.Close(s.fd)
+}
+
+func isTimeout(err error) bool {
+	return errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR)
+}
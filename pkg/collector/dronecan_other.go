@@ -0,0 +1,13 @@
+//go:build !linux
+
+package collector
+
+import "fmt"
+
+func openCANSocket(iface string) (canSocket, error) {
+	return nil, fmt.Errorf("SocketCAN is only supported on Linux")
+}
+
+func isTimeout(err error) bool {
+	return false
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"math"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/geo"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// flightStatsTracker maintains cumulative distance, airborne time and energy counters
+type flightStatsTracker struct {
+	since      time.Time
+	distance   float64 // meters
+	flightTime time.Duration
+	energyWh   float64
+	lastSample time.Time
+	lastLat    float64
+	lastLon    float64
+	hasFix     bool
+	maxGap     time.Duration // gaps longer than this (agent stalled) are not counted
+}
+
+func newFlightStatsTracker(interval time.Duration, now time.Time) *flightStatsTracker {
+	return &flightStatsTracker{
+		since:  now,
+		maxGap: 2 * interval,
+	}
+}
+
+// Update folds one collection cycle into the counters
+func (t *flightStatsTracker) Update(metrics *models.UAVMetrics, now time.Time) {
+	// Without flight data (section disabled or failed) the vehicle is not
+	// known to fly, so neither time nor distance accrues
+	flying := metrics.Flight != nil && metrics.Flight.IsFlying
+
+	var dt time.Duration
+	if !t.lastSample.IsZero() {
+		dt = now.Sub(t.lastSample)
+		if dt < 0 || dt > t.maxGap {
+			dt = 0
+		}
+	}
+	t.lastSample = now
+
+	if flying {
+		t.flightTime += dt
+	}
+
+	// Energy: |V * I| integrated over the interval. A failed battery
+	// section leaves the last reading in place, which says nothing about
+	// the draw since.
+	if !metrics.CollectionFailed(models.SectionBattery) {
+		power := math.Abs(metrics.Battery.Voltage * metrics.Battery.Current)
+		t.energyWh += power * dt.Hours()
+	}
+
+	// Distance only counts while flying, to ignore GPS jitter on the ground
+	lat, lon := metrics.GPS.Latitude, metrics.GPS.Longitude
+	if lat == 0 && lon == 0 {
+		return
+	}
+	if t.hasFix && flying && dt > 0 {
+		t.distance += geo.DistanceMeters(t.lastLat, t.lastLon, lat, lon)
+	}
+	t.lastLat, t.lastLon, t.hasFix = lat, lon, true
+}
+
+// Restore seeds the counters from previously published data (e.g. after a
+// restart), so they keep counting from when counting first started
+func (t *flightStatsTracker) Restore(stats *models.FlightStats) {
+	if stats == nil || stats.Since.IsZero() {
+		return
+	}
+	t.since = stats.Since
+	t.distance = stats.TotalDistance
+	t.flightTime = time.Duration(stats.FlightTime) * time.Second
+	t.energyWh = stats.EnergyConsumed
+}
+
+// Snapshot returns the current counters
+func (t *flightStatsTracker) Snapshot() *models.FlightStats {
+	return &models.FlightStats{
+		TotalDistance:  t.distance,
+		FlightTime:     int64(t.flightTime.Seconds()),
+		EnergyConsumed: t.energyWh,
+		Since:          t.since,
+	}
+}
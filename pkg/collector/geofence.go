@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"math"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/geo"
+)
+
+// geofenceChecker measures the UAV position against the configured
+// geofences; the health check's built-in geofence rules compare the margins
+type geofenceChecker struct {
+	fences map[string]config.GeofenceConfig // key: fence name
+}
+
+func newGeofenceChecker(fences []config.GeofenceConfig) *geofenceChecker {
+	if len(fences) == 0 {
+		return nil
+	}
+	g := &geofenceChecker{fences: make(map[string]config.GeofenceConfig, len(fences))}
+	for _, fence := range fences {
+		g.fences[fence.Name] = fence
+	}
+	return g
+}
+
+// Margin returns the distance (m) from the position to the boundary of the
+// named fence, negative when the fence is breached (outside an inclusion
+// fence or inside an exclusion fence)
+func (g *geofenceChecker) Margin(name string, lat, lon float64) (float64, bool) {
+	if g == nil {
+		return 0, false
+	}
+	fence, ok := g.fences[name]
+	if !ok {
+		return 0, false
+	}
+	inside, distance := fenceDistance(fence, lat, lon)
+	if inside == fence.Exclusion {
+		return -distance, true
+	}
+	return distance, true
+}
+
+// fenceDistance reports whether the position is inside the fence and its
+// distance to the fence boundary (m)
+func fenceDistance(fence config.GeofenceConfig, lat, lon float64) (bool, float64) {
+	if fence.Shape == config.GeofenceCircle {
+		d := geo.DistanceMeters(fence.Center.Latitude, fence.Center.Longitude, lat, lon)
+		return d <= fence.Radius, math.Abs(fence.Radius - d)
+	}
+
+	// Project the vertices onto a local plane centered on the position, so
+	// the position is the origin. Fences span at most a few kilometres, where
+	// the equirectangular approximation is well within GPS accuracy.
+	cosLat := math.Cos(lat * math.Pi / 180)
+	points := make([][2]float64, len(fence.Vertices))
+	for i, v := range fence.Vertices {
+		points[i] = [2]float64{
+			(v.Longitude - lon) * math.Pi / 180 * earthRadiusMeters * cosLat,
+			(v.Latitude - lat) * math.Pi / 180 * earthRadiusMeters,
+		}
+	}
+
+	inside := false
+	distance := math.Inf(1)
+	for i := range points {
+		a, b := points[i], points[(i+1)%len(points)]
+
+		// Ray casting along +x from the origin
+		if (a[1] > 0) != (b[1] > 0) && a[0]+(0-a[1])*(b[0]-a[0])/(b[1]-a[1]) > 0 {
+			inside = !inside
+		}
+		distance = math.Min(distance, originToSegment(a, b))
+	}
+	return inside, distance
+}
+
+// originToSegment returns the distance from the origin to segment ab
+func originToSegment(a, b [2]float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = math.Max(0, math.Min(1, -(a[0]*dx+a[1]*dy)/length))
+	}
+	return math.Hypot(a[0]+t*dx, a[1]+t*dy)
+}
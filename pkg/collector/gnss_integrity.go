@@ -0,0 +1,214 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/geo"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+const (
+	// integrityMaxAcceleration bounds how far a vehicle can deviate from
+	// constant-velocity dead reckoning (m/s^2)
+	integrityMaxAcceleration = 3.0
+
+	// integrityMaxDeadReckoning is how long dead reckoning is trusted before
+	// the reference is re-synchronised to GNSS
+	integrityMaxDeadReckoning = 60 * time.Second
+
+	// integrityVelocityTolerance is the allowed difference between the
+	// receiver's velocity and the velocity implied by its position change (m/s)
+	integrityVelocityTolerance = 3.0
+
+	// courseMinSpeed is the ground speed below which the direction of the
+	// velocity vector is noise and the vehicle heading is reported instead (m/s)
+	courseMinSpeed = 0.5
+)
+
+// gnssIntegrityMonitor detects GNSS spoofing and jamming by comparing each
+// fix against a dead-reckoned position (last trusted velocity vector, i.e.
+// ground speed along the course over ground) and the GNSS altitude change against the flight controller's
+// barometric altitude change. While fixes diverge the reference keeps
+// dead-reckoning instead of following the suspect fix. A spoofer dragging the
+// position slowly enough to stay within the dead reckoning allowance usually
+// does not forge a matching receiver velocity, so consecutive fixes are also
+// checked for velocity consistency.
+type gnssIntegrityMonitor struct {
+	tolerance    float64 // horizontal tolerance (m)
+	altTolerance float64 // vertical tolerance (m)
+	confirm      int     // consecutive divergent fixes before degrading
+
+	ref        *integrityReference
+	prevFix    models.GPSData
+	divergent  int
+	deadReckon time.Duration
+	lastUpdate time.Time
+	lastResult *models.GNSSIntegrity
+}
+
+// integrityReference is the last trusted navigation state
+type integrityReference struct {
+	latitude  float64
+	longitude float64
+	altitude  float64 // GNSS altitude (m)
+	baroAlt   float64 // flight controller relative altitude (m)
+	hasBaro   bool
+	speed     float64 // ground speed (m/s)
+	course    float64 // course over ground (degrees)
+}
+
+func newGNSSIntegrityMonitor(tolerance, altTolerance float64, confirm int) *gnssIntegrityMonitor {
+	return &gnssIntegrityMonitor{
+		tolerance:    tolerance,
+		altTolerance: altTolerance,
+		confirm:      confirm,
+	}
+}
+
+// Check compares a raw GNSS fix against the reference. flight may be nil.
+func (m *gnssIntegrityMonitor) Check(gps *models.GPSData, flight *models.FlightData) *models.GNSSIntegrity {
+	if m.ref == nil {
+		m.resync(gps, flight)
+		m.lastResult = &models.GNSSIntegrity{Quality: models.PositionQualityGood}
+		return m.lastResult
+	}
+
+	dt := gps.LastUpdate.Sub(m.lastUpdate)
+	if dt <= 0 {
+		return m.lastResult // no new fix
+	}
+	m.lastUpdate = gps.LastUpdate
+	if dt > integrityMaxDeadReckoning {
+		// Too long since the last fix to dead-reckon meaningfully
+		m.resync(gps, flight)
+		m.divergent = 0
+		m.lastResult = &models.GNSSIntegrity{Quality: models.PositionQualityGood}
+		return m.lastResult
+	}
+
+	// Dead-reckon along the last trusted course over ground. The IMU heading is
+	// not used: a multirotor crabbing into a crosswind points away from its track.
+	seconds := dt.Seconds()
+	predictedLat, predictedLon := destinationPoint(m.ref.latitude, m.ref.longitude, m.ref.course, m.ref.speed*seconds)
+
+	result := &models.GNSSIntegrity{
+		HorizontalDivergence: geo.DistanceMeters(predictedLat, predictedLon, gps.Latitude, gps.Longitude),
+	}
+	allowed := m.tolerance + gps.Accuracy + integrityMaxAcceleration*seconds*seconds/2
+	if result.HorizontalDivergence > allowed {
+		result.Reasons = append(result.Reasons, fmt.Sprintf("GNSS position %.0fm from dead-reckoned position (allowed %.0fm)",
+			result.HorizontalDivergence, allowed))
+	}
+
+	// Receiver velocity (averaged over both fixes) against position change
+	moved := geo.DistanceMeters(m.prevFix.Latitude, m.prevFix.Longitude, gps.Latitude, gps.Longitude)
+	bearing := initialBearing(m.prevFix.Latitude, m.prevFix.Longitude, gps.Latitude, gps.Longitude)
+	impliedE, impliedN := moved/seconds*math.Sin(bearing), moved/seconds*math.Cos(bearing)
+	prevE, prevN := velocityVector(m.prevFix.Speed, m.prevFix.Heading)
+	curE, curN := velocityVector(gps.Speed, gps.Heading)
+	mismatch := math.Hypot(impliedE-(prevE+curE)/2, impliedN-(prevN+curN)/2)
+	if mismatch > integrityVelocityTolerance+2*gps.Accuracy/seconds {
+		result.Reasons = append(result.Reasons, fmt.Sprintf("GNSS velocity disagrees with position change by %.1fm/s", mismatch))
+	}
+	m.prevFix = *gps
+
+	baroAlt, hasBaro := 0.0, flight != nil
+	if hasBaro {
+		baroAlt = flight.Altitude
+	}
+	if hasBaro && m.ref.hasBaro {
+		gnssClimb := gps.Altitude - m.ref.altitude
+		baroClimb := baroAlt - m.ref.baroAlt
+		result.VerticalDivergence = math.Abs(gnssClimb - baroClimb)
+		if result.VerticalDivergence > m.altTolerance+gps.Accuracy {
+			result.Reasons = append(result.Reasons, fmt.Sprintf("GNSS climb %.0fm disagrees with barometric climb %.0fm",
+				gnssClimb, baroClimb))
+		}
+	}
+
+	if len(result.Reasons) == 0 {
+		m.divergent = 0
+		m.resync(gps, flight)
+	} else {
+		// Keep dead-reckoning instead of following the suspect fix
+		m.divergent++
+		m.deadReckon += dt
+		m.ref.latitude, m.ref.longitude = predictedLat, predictedLon
+		if hasBaro && m.ref.hasBaro {
+			m.ref.altitude += baroAlt - m.ref.baroAlt
+			m.ref.baroAlt = baroAlt
+		}
+		if m.deadReckon > integrityMaxDeadReckoning {
+			m.resync(gps, flight)
+		}
+	}
+
+	result.Quality = models.PositionQualityGood
+	if m.divergent >= m.confirm {
+		result.Quality = models.PositionQualityDegraded
+	}
+	m.lastResult = result
+	return result
+}
+
+// resync takes the fix as the new trusted reference
+func (m *gnssIntegrityMonitor) resync(gps *models.GPSData, flight *models.FlightData) {
+	m.ref = &integrityReference{
+		latitude:  gps.Latitude,
+		longitude: gps.Longitude,
+		altitude:  gps.Altitude,
+		speed:     gps.Speed,
+		course:    gps.Heading,
+	}
+	if flight != nil {
+		m.ref.baroAlt = flight.Altitude
+		m.ref.hasBaro = true
+	}
+	m.prevFix = *gps
+	m.lastUpdate = gps.LastUpdate
+	m.deadReckon = 0
+}
+
+// courseOverGround returns the direction of travel (degrees, 0-360) from the
+// north/east velocity, or heading while the vehicle is nearly stationary
+func courseOverGround(north, east, heading float64) float64 {
+	if math.Hypot(north, east) < courseMinSpeed {
+		return heading
+	}
+	course := math.Atan2(east, north) * 180 / math.Pi
+	if course < 0 {
+		course += 360
+	}
+	return course
+}
+
+// velocityVector converts speed and course to east/north components
+func velocityVector(speed, course float64) (float64, float64) {
+	rad := course * math.Pi / 180
+	return speed * math.Sin(rad), speed * math.Cos(rad)
+}
+
+// initialBearing returns the bearing from the first point to the second in radians
+func initialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	deltaLon := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(deltaLon) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(deltaLon)
+	return math.Atan2(y, x)
+}
+
+// destinationPoint returns the point distance meters from lat/lon along bearing
+func destinationPoint(lat, lon, bearing, distance float64) (float64, float64) {
+	lat1 := lat * math.Pi / 180
+	lon1 := lon * math.Pi / 180
+	theta := bearing * math.Pi / 180
+	delta := distance / earthRadiusMeters
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+
+	return lat2 * 180 / math.Pi, lon2 * 180 / math.Pi
+}
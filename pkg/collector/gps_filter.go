@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/k3suav/uav-monitor/pkg/geo"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+const (
+	// maxConsecutiveRejects is the number of rejected fixes after which the
+	// filter assumes the vehicle really moved (e.g. was relocated) and resets
+	maxConsecutiveRejects = 3
+
+	earthRadiusMeters = geo.EarthRadiusMeters
+)
+
+// gpsFilter smooths GPS position/speed and rejects physically impossible jumps
+type gpsFilter struct {
+	mode          string  // none, ema, kalman
+	alpha         float64 // EMA smoothing factor
+	processNoise  float64 // Kalman process noise (m/s)
+	maxSpeed      float64 // outlier threshold (m/s), 0 disables
+	last          *models.GPSData
+	variance      float64 // Kalman position variance (m^2)
+	rejectedCount int
+}
+
+func newGPSFilter(mode string, alpha, processNoise, maxSpeed float64) *gpsFilter {
+	return &gpsFilter{
+		mode:         mode,
+		alpha:        alpha,
+		processNoise: processNoise,
+		maxSpeed:     maxSpeed,
+	}
+}
+
+// Apply filters a raw fix. It returns the filtered fix and, if the raw fix
+// was rejected as an outlier, a description of why.
+func (f *gpsFilter) Apply(raw *models.GPSData) (*models.GPSData, string) {
+	if f.last == nil {
+		f.reset(raw)
+		return raw, ""
+	}
+
+	dt := raw.LastUpdate.Sub(f.last.LastUpdate).Seconds()
+	if dt <= 0 {
+		dt = 1
+	}
+
+	// Outlier rejection based on implied ground speed
+	if f.maxSpeed > 0 {
+		distance := geo.DistanceMeters(f.last.Latitude, f.last.Longitude, raw.Latitude, raw.Longitude)
+		impliedSpeed := distance / dt
+		if impliedSpeed > f.maxSpeed {
+			f.rejectedCount++
+			if f.rejectedCount < maxConsecutiveRejects {
+				held := *f.last
+				return &held, fmt.Sprintf("GPS fix rejected: implied speed %.1fm/s exceeds %.1fm/s", impliedSpeed, f.maxSpeed)
+			}
+			// Too many consecutive rejections, accept the new position
+			f.reset(raw)
+			return raw, ""
+		}
+	}
+	f.rejectedCount = 0
+
+	filtered := *raw
+	switch f.mode {
+	case "ema":
+		filtered.Latitude = f.alpha*raw.Latitude + (1-f.alpha)*f.last.Latitude
+		filtered.Longitude = f.alpha*raw.Longitude + (1-f.alpha)*f.last.Longitude
+		filtered.Altitude = f.alpha*raw.Altitude + (1-f.alpha)*f.last.Altitude
+	case "kalman":
+		accuracy := math.Max(raw.Accuracy, 1)
+		f.variance += dt * f.processNoise * f.processNoise
+		gain := f.variance / (f.variance + accuracy*accuracy)
+		filtered.Latitude = f.last.Latitude + gain*(raw.Latitude-f.last.Latitude)
+		filtered.Longitude = f.last.Longitude + gain*(raw.Longitude-f.last.Longitude)
+		filtered.Altitude = f.last.Altitude + gain*(raw.Altitude-f.last.Altitude)
+		f.variance = (1 - gain) * f.variance
+		filtered.Accuracy = math.Sqrt(f.variance)
+	}
+
+	if f.mode != "none" {
+		filtered.Speed = f.alpha*raw.Speed + (1-f.alpha)*f.last.Speed
+	}
+
+	f.last = &filtered
+	return &filtered, ""
+}
+
+func (f *gpsFilter) reset(fix *models.GPSData) {
+	last := *fix
+	f.last = &last
+	f.variance = math.Max(fix.Accuracy, 1) * math.Max(fix.Accuracy, 1)
+	f.rejectedCount = 0
+}
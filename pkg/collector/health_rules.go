@@ -0,0 +1,218 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// healthRuleEngine evaluates the built-in and configured health rules
+// against each sample, tracking which rules fire and how long their
+// condition has held or cleared
+type healthRuleEngine struct {
+	rules []compiledHealthRule
+}
+
+// healthField reads a rule's field from a sample
+type healthField struct {
+	// Section the field belongs to; the rule keeps its state while the
+	// section fails to collect
+	section string
+	// The field's value, false when absent
+	value func(*models.UAVMetrics) (float64, bool)
+}
+
+// healthFieldResolver returns the healthField of a rule's field, false for
+// unknown fields (rejected by config validation)
+type healthFieldResolver func(field string) (healthField, bool)
+
+type compiledHealthRule struct {
+	config.HealthRule
+
+	field healthField
+	known bool
+
+	// Since when the condition has held (zero when it doesn't)
+	since time.Time
+	// Whether the rule fires
+	active bool
+	// Since when the condition of the firing rule has cleared (zero when it
+	// hasn't)
+	clearedSince time.Time
+
+	// Value of the latest sample, valid when evaluated
+	value     float64
+	evaluated bool
+}
+
+func newHealthRuleEngine(rules []config.HealthRule, resolve healthFieldResolver) *healthRuleEngine {
+	return newHealthRuleEngineFrom(rules, nil, resolve)
+}
+
+// newHealthRuleEngineFrom builds an engine from rules, keeping the state of
+// the rules of previous (nil for none) that didn't change so that reloading
+// doesn't restart their durations or clear them
+func newHealthRuleEngineFrom(rules []config.HealthRule, previous *healthRuleEngine, resolve healthFieldResolver) *healthRuleEngine {
+	engine := &healthRuleEngine{}
+	for _, rule := range rules {
+		compiled := compiledHealthRule{HealthRule: rule}
+		compiled.field, compiled.known = resolve(rule.Field)
+		if previous != nil {
+			for _, old := range previous.rules {
+				if reflect.DeepEqual(old.HealthRule, rule) {
+					compiled.since, compiled.active, compiled.clearedSince = old.since, old.active, old.clearedSince
+					compiled.value = old.value
+					break
+				}
+			}
+		}
+		engine.rules = append(engine.rules, compiled)
+	}
+	return engine
+}
+
+// metricsField resolves the fields of UAVMetrics, which belong to the
+// section named by their first element
+func metricsField(field string) (healthField, bool) {
+	index, err := models.ResolveField(field)
+	if err != nil {
+		return healthField{}, false
+	}
+	section, _, _ := strings.Cut(field, ".")
+	return healthField{section: section, value: index.Value}, true
+}
+
+// Check evaluates the rules against metrics and returns the messages of the
+// rules that fire, by severity. A rule whose section failed to collect or
+// whose value isn't reported (NaN) this cycle keeps its state, so a firing
+// rule keeps firing with its last value instead of clearing before its hold
+// time. A rule whose field is absent without a failure (e.g. the section is
+// disabled) starts over.
+func (e *healthRuleEngine) Check(metrics *models.UAVMetrics, now time.Time) (errors, warnings []string) {
+	for i := range e.rules {
+		rule := &e.rules[i]
+		if !rule.known {
+			continue
+		}
+
+		value, ok := rule.field.value(metrics)
+		missing := metrics.CollectionFailed(rule.field.section) || (ok && math.IsNaN(value))
+		rule.evaluated = ok && !missing
+		switch {
+		case rule.evaluated:
+			rule.value = value
+			if !rule.evaluate(value, now) {
+				continue
+			}
+		case missing:
+			if !rule.active {
+				continue
+			}
+			value = rule.value
+		default:
+			rule.reset()
+			continue
+		}
+
+		message := rule.message(value)
+		if rule.Severity == config.SeverityCritical {
+			errors = append(errors, message)
+		} else {
+			warnings = append(warnings, message)
+		}
+	}
+	return errors, warnings
+}
+
+// evaluate updates the rule's state with value and reports whether it fires
+func (r *compiledHealthRule) evaluate(value float64, now time.Time) bool {
+	if r.active {
+		if r.Holds(value) {
+			r.clearedSince = time.Time{}
+			return true
+		}
+		if r.clearedSince.IsZero() {
+			r.clearedSince = now
+		}
+		if now.Sub(r.clearedSince) < r.Hold {
+			return true
+		}
+		r.reset()
+	}
+
+	if !r.Matches(value) {
+		r.since = time.Time{}
+		return false
+	}
+	if r.since.IsZero() {
+		r.since = now
+	}
+	r.active = now.Sub(r.since) >= r.For
+	return r.active
+}
+
+func (r *compiledHealthRule) reset() {
+	r.since = time.Time{}
+	r.active = false
+	r.clearedSince = time.Time{}
+}
+
+func (r *compiledHealthRule) message(value float64) string {
+	formatted := strconv.FormatFloat(value, 'f', 1, 64)
+	if value == math.Trunc(value) && math.Abs(value) < 1e15 {
+		formatted = strconv.FormatInt(int64(value), 10)
+	}
+	if r.Message == "" {
+		return fmt.Sprintf("%s: %s is %s", r.Name, r.Field, formatted)
+	}
+	return strings.ReplaceAll(r.Message, "{value}", formatted)
+}
+
+// definitions returns the rules the engine was built from
+func (e *healthRuleEngine) definitions() []config.HealthRule {
+	rules := make([]config.HealthRule, len(e.rules))
+	for i, rule := range e.rules {
+		rules[i] = rule.HealthRule
+	}
+	return rules
+}
+
+// healthField resolves the fields computed by the health check and the
+// UAVMetrics fields
+func (c *Collector) healthField(field string) (healthField, bool) {
+	if field == config.FieldGPSHas3DFix {
+		return healthField{section: models.SectionGPS, value: c.gpsHas3DFix}, true
+	}
+	if name, fenceField, ok := config.ParseGeofenceField(field); ok {
+		return healthField{section: models.SectionGPS, value: func(metrics *models.UAVMetrics) (float64, bool) {
+			if metrics.GPS.LastUpdate.IsZero() {
+				return 0, false
+			}
+			margin, ok := c.geofences.Margin(name, metrics.GPS.Latitude, metrics.GPS.Longitude)
+			if fenceField == config.GeofenceFieldDistance && margin < 0 {
+				return 0, false
+			}
+			return margin, ok
+		}}, true
+	}
+	return metricsField(field)
+}
+
+// gpsHas3DFix requires a 3D fix when the receiver reports its fix type,
+// otherwise falls back to the satellite count
+func (c *Collector) gpsHas3DFix(metrics *models.UAVMetrics) (float64, bool) {
+	fixed := metrics.GPS.Has3DFix()
+	if metrics.GPS.FixType == "" {
+		fixed = metrics.GPS.Satellites >= c.config.Collection.GPSMinSatellites
+	}
+	if fixed {
+		return 1, true
+	}
+	return 0, true
+}
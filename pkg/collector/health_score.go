@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"math"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// Health score deductions: every error and warning deducts its weight, up
+// to twice that for threshold findings the further the reading is past the
+// threshold. Readings approaching a threshold deduct up to
+// scoreProximityWeight.
+const (
+	scoreErrorWeight     = 40.0
+	scoreWarningWeight   = 10.0
+	scoreProximityWeight = 5.0
+
+	// Fraction of the threshold within which a reading approaches it
+	scoreProximityRange = 0.2
+
+	// Deductions at which a status band is 63% used up; the score saturates
+	// towards the bottom of the band instead of leaving it
+	scoreDeductionScale = 50.0
+)
+
+// healthScore returns the score of a health status with the given
+// deductions, within the status' band
+func healthScore(status string, deductions float64) float64 {
+	low, high := 80.0, 100.0
+	switch status {
+	case models.HealthStatusWarning:
+		low, high = 40, 80
+	case models.HealthStatusCritical, models.HealthStatusUnknown:
+		low, high = 0, 40
+	}
+	score := high - (high-low)*(1-math.Exp(-deductions/scoreDeductionScale))
+	return math.Round(score*10) / 10
+}
+
+// thresholdDeduction returns the deduction of a reading compared to a
+// threshold: weight and up to weight more relative to how far past the
+// threshold it is when firing, otherwise up to scoreProximityWeight the
+// closer it is to the threshold
+func thresholdDeduction(value, threshold, weight float64, firing bool) float64 {
+	scale := math.Max(math.Abs(threshold), 1)
+	distance := math.Abs(value-threshold) / scale
+	if firing {
+		return weight * (1 + math.Min(distance, 1))
+	}
+	return scoreProximityWeight * math.Max(0, 1-distance/scoreProximityRange)
+}
+
+// ruleDeductions returns the deductions of the rules evaluated in the latest
+// check and the number of errors and warnings they reported
+func (e *healthRuleEngine) ruleDeductions() (deductions float64, errors, warnings int) {
+	for _, rule := range e.rules {
+		if !rule.evaluated || rule.Operator == config.OperatorEqual || rule.Operator == config.OperatorNotEqual {
+			if rule.active {
+				errors, warnings = countFinding(rule.Severity, errors, warnings)
+				deductions += severityWeight(rule.Severity)
+			}
+			continue
+		}
+		value := rule.value
+		if rule.active {
+			errors, warnings = countFinding(rule.Severity, errors, warnings)
+		} else if rule.Matches(value) {
+			value = rule.Threshold // waiting for the rule's duration
+		}
+		deductions += thresholdDeduction(value, rule.Threshold, severityWeight(rule.Severity), rule.active)
+	}
+	return deductions, errors, warnings
+}
+
+func severityWeight(severity string) float64 {
+	if severity == config.SeverityCritical {
+		return scoreErrorWeight
+	}
+	return scoreWarningWeight
+}
+
+func countFinding(severity string, errors, warnings int) (int, int) {
+	if severity == config.SeverityCritical {
+		return errors + 1, warnings
+	}
+	return errors, warnings + 1
+}
+
+// scoreHealth computes the health score of a completed health check. Rule
+// findings (including the built-in battery rules) are weighted by how far
+// past their threshold the reading is, the other findings by their severity.
+func (c *Collector) scoreHealth(metrics *models.UAVMetrics, health *models.HealthData) float64 {
+	deductions, ruleErrors, ruleWarnings := c.healthRules.ruleDeductions()
+	otherErrors := len(health.Errors) - ruleErrors
+	otherWarnings := len(health.Warnings) - ruleWarnings
+
+	deductions += scoreErrorWeight*float64(max(otherErrors, 0)) + scoreWarningWeight*float64(max(otherWarnings, 0))
+	return healthScore(health.Status, deductions)
+}
@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"math"
+
+	"github.com/k3suav/uav-monitor/pkg/geo"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// homeTracker records the home position and computes the return-to-home cost
+type homeTracker struct {
+	home        *models.HomeData
+	cruiseSpeed float64 // m/s
+	capacityWh  float64
+}
+
+// newHomeTracker creates a tracker for a pack of capacityMah at the given
+// nominal voltage, which converts the capacity to energy
+func newHomeTracker(lat, lon, cruiseSpeed, capacityMah, nominalVoltage float64) *homeTracker {
+	t := &homeTracker{
+		cruiseSpeed: cruiseSpeed,
+		capacityWh:  capacityMah / 1000 * nominalVoltage,
+	}
+	if lat != 0 || lon != 0 {
+		t.home = &models.HomeData{
+			Latitude:  lat,
+			Longitude: lon,
+			Source:    models.HomeSourceConfigured,
+		}
+	}
+	return t
+}
+
+// Restore keeps the home point across agent restarts
+func (t *homeTracker) Restore(previous *models.HomeData) {
+	// Sealed home locations are published as 0,0 and cannot be restored
+	if t.home != nil || previous == nil || (previous.Latitude == 0 && previous.Longitude == 0) {
+		return
+	}
+	t.home = &models.HomeData{
+		Latitude:  previous.Latitude,
+		Longitude: previous.Longitude,
+		Source:    previous.Source,
+	}
+}
+
+// Update records the first valid fix as home and returns the home data
+// relative to the current position
+func (t *homeTracker) Update(gps *models.GPSData, battery *models.BatteryData) *models.HomeData {
+	if gps.Latitude == 0 && gps.Longitude == 0 {
+		return nil
+	}
+	if t.home == nil {
+		t.home = &models.HomeData{
+			Latitude:  gps.Latitude,
+			Longitude: gps.Longitude,
+			Source:    models.HomeSourceFirstFix,
+		}
+	}
+
+	home := *t.home
+	home.Distance = geo.DistanceMeters(home.Latitude, home.Longitude, gps.Latitude, gps.Longitude)
+	home.Bearing = bearingDegrees(home.Latitude, home.Longitude, gps.Latitude, gps.Longitude)
+
+	// Return cost: time at cruise speed times the current power draw
+	power := math.Abs(battery.Voltage * battery.Current)
+	if power > 0 {
+		hours := home.Distance / t.cruiseSpeed / 3600
+		home.ReturnEnergy = power * hours
+		home.ReturnBatteryPercent = math.Min(home.ReturnEnergy/t.capacityWh*100, 100)
+	}
+
+	return &home
+}
+
+// bearingDegrees returns the initial bearing from point 1 to point 2 (0-360)
+func bearingDegrees(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLon := (lon2 - lon1) * math.Pi / 180
+
+	y := math.Sin(deltaLon) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLon)
+
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
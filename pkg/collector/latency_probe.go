@@ -0,0 +1,233 @@
+package collector
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+)
+
+// latencyProbeMagic marks probe datagrams so stray traffic is ignored
+const latencyProbeMagic = 0x55415650 // "UAVP"
+
+// latencyProbe sends sequence-numbered UDP echo requests to a ground endpoint
+// (another agent's ServeLatencyProbe) and keeps the outcome of the most recent
+// probes, so latency and packet loss describe a sliding window of the link
+// rather than a single sample.
+type latencyProbe struct {
+	cfg    config.LatencyProbeConfig
+	paused atomic.Bool // no probes while saving power
+
+	mu      sync.Mutex
+	seq     uint32
+	pending map[uint32]time.Time // sent, not yet answered or timed out
+	window  []probeOutcome       // resolved probes, oldest first
+}
+
+// probeOutcome is the result of one resolved probe
+type probeOutcome struct {
+	lost bool
+	rtt  time.Duration
+}
+
+func newLatencyProbe(cfg config.LatencyProbeConfig) *latencyProbe {
+	if cfg.Target == "" {
+		return nil
+	}
+	return &latencyProbe{
+		cfg:     cfg,
+		pending: make(map[uint32]time.Time),
+		window:  make([]probeOutcome, 0, cfg.Window),
+	}
+}
+
+// Run sends probes every interval until ctx is cancelled, reconnecting if
+// the socket fails
+func (p *latencyProbe) Run(ctx context.Context) {
+	for {
+		if err := p.run(ctx); err != nil && ctx.Err() == nil {
+			// The link may be down; missed probes are not counted as lost
+			// because nothing was sent
+			select {
+			case <-ctx.Done():
+			case <-time.After(p.cfg.Interval):
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (p *latencyProbe) run(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", p.cfg.Target)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Close the socket on cancellation to unblock the reader
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- p.receive(conn)
+	}()
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		p.expire(time.Now())
+		if !p.paused.Load() {
+			if err := p.send(conn); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// SetPaused pauses or resumes probing. Result keeps describing the probes
+// sent before the pause.
+func (p *latencyProbe) SetPaused(paused bool) {
+	p.paused.Store(paused)
+}
+
+func (p *latencyProbe) send(conn net.Conn) error {
+	// Registered before sending so a fast reply finds it
+	p.mu.Lock()
+	p.seq++
+	seq := p.seq
+	p.pending[seq] = time.Now()
+	p.mu.Unlock()
+
+	var packet [16]byte
+	binary.BigEndian.PutUint32(packet[0:], latencyProbeMagic)
+	binary.BigEndian.PutUint32(packet[4:], seq)
+	if _, err := conn.Write(packet[:]); err != nil {
+		p.mu.Lock()
+		delete(p.pending, seq)
+		p.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// receive matches echo replies to pending probes by sequence number
+func (p *latencyProbe) receive(conn net.Conn) error {
+	buf := make([]byte, 64)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return err
+		}
+		if n < 8 || binary.BigEndian.Uint32(buf[0:]) != latencyProbeMagic {
+			continue
+		}
+		seq := binary.BigEndian.Uint32(buf[4:])
+
+		p.mu.Lock()
+		// Replies to probes already counted as lost are ignored
+		if sent, ok := p.pending[seq]; ok {
+			delete(p.pending, seq)
+			p.record(probeOutcome{rtt: time.Since(sent)})
+		}
+		p.mu.Unlock()
+	}
+}
+
+// expire counts probes unanswered within the timeout as lost
+func (p *latencyProbe) expire(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for seq, sent := range p.pending {
+		if now.Sub(sent) > p.cfg.Timeout {
+			delete(p.pending, seq)
+			p.record(probeOutcome{lost: true})
+		}
+	}
+}
+
+// record appends an outcome, dropping the oldest once the window is full.
+// Callers hold p.mu.
+func (p *latencyProbe) record(outcome probeOutcome) {
+	if len(p.window) == p.cfg.Window {
+		copy(p.window, p.window[1:])
+		p.window = p.window[:len(p.window)-1]
+	}
+	p.window = append(p.window, outcome)
+}
+
+// Result returns the mean round-trip latency (ms) of answered probes and the
+// packet loss (%) over the window. ok is false until a probe has resolved.
+func (p *latencyProbe) Result() (latency, loss float64, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.window) == 0 {
+		return 0, 0, false
+	}
+	var lost int
+	var total time.Duration
+	for _, o := range p.window {
+		if o.lost {
+			lost++
+			continue
+		}
+		total += o.rtt
+	}
+	if answered := len(p.window) - lost; answered > 0 {
+		latency = float64(total.Microseconds()) / 1000 / float64(answered)
+	}
+	loss = 100 * float64(lost) / float64(len(p.window))
+	return latency, loss, true
+}
+
+// ServeLatencyProbe echoes latency probes from other agents on addr until ctx
+// is cancelled
+func ServeLatencyProbe(ctx context.Context, addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for latency probes on %s: %w", addr, err)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 64)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("latency probe server: %w", err)
+		}
+		if n < 8 || binary.BigEndian.Uint32(buf[0:]) != latencyProbeMagic {
+			continue
+		}
+		conn.WriteTo(buf[:n], from)
+	}
+}
@@ -0,0 +1,399 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// Modem sources
+const (
+	ModemSourceNone         = "none"
+	ModemSourceAT           = "at"
+	ModemSourceModemManager = "modemmanager"
+)
+
+// Cellular radio technologies
+const (
+	CellularLTE     = "LTE"
+	CellularNR5GNSA = "NR5G-NSA"
+	CellularNR5GSA  = "NR5G-SA"
+)
+
+// modemReader reads the serving cell of the cellular modem
+type modemReader interface {
+	Read(ctx context.Context) (*models.CellularData, error)
+}
+
+func newModemReader(cfg config.ModemConfig) modemReader {
+	switch cfg.Source {
+	case ModemSourceAT:
+		return &atModem{cfg: cfg}
+	case ModemSourceModemManager:
+		return &modemManager{cfg: cfg}
+	}
+	return nil
+}
+
+// connectionType maps a cellular technology to a NetworkData connection type
+func connectionType(technology string) string {
+	switch technology {
+	case CellularLTE:
+		return models.ConnectionType4G
+	case CellularNR5GNSA, CellularNR5GSA:
+		return models.ConnectionType5G
+	}
+	return models.ConnectionTypeUnknown
+}
+
+// atModem queries the modem's AT command port directly. Serving cell details
+// come from Quectel's AT+QENG="servingcell"; other modems fall back to the
+// 3GPP AT+CESQ, which reports RSRP/RSRQ but no SINR or band.
+type atModem struct {
+	cfg config.ModemConfig
+}
+
+func (m *atModem) Read(ctx context.Context) (*models.CellularData, error) {
+	port, err := openATPort(m.cfg.Device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open modem port %s: %w", m.cfg.Device, err)
+	}
+	defer port.Close()
+
+	deadline := time.Now().Add(m.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	at := &atSession{port: port, deadline: deadline}
+
+	cops, err := at.command("AT+COPS?")
+	if err != nil {
+		return nil, err
+	}
+	cell := parseCOPS(cops)
+
+	if qeng, err := at.command(`AT+QENG="servingcell"`); err == nil && parseQENG(qeng, cell) {
+		return cell, nil
+	}
+
+	cesq, err := at.command("AT+CESQ")
+	if err != nil {
+		return nil, err
+	}
+	if !parseCESQ(cesq, cell) {
+		return nil, fmt.Errorf("modem reports no serving cell")
+	}
+	return cell, nil
+}
+
+// atSession sends AT commands and collects the response lines
+type atSession struct {
+	port     io.ReadWriter
+	pending  []byte
+	deadline time.Time
+}
+
+// command sends cmd and returns the response lines before the final OK
+func (s *atSession) command(cmd string) ([]string, error) {
+	if _, err := s.port.Write([]byte(cmd + "\r")); err != nil {
+		return nil, fmt.Errorf("%s: %w", cmd, err)
+	}
+
+	lines := []string{}
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cmd, err)
+		}
+		switch {
+		case line == "" || line == cmd: // blank line or command echo
+		case line == "OK":
+			return lines, nil
+		case line == "ERROR" || strings.HasPrefix(line, "+CME ERROR"):
+			return nil, fmt.Errorf("%s: %s", cmd, line)
+		default:
+			lines = append(lines, line)
+		}
+	}
+}
+
+// readLine returns the next response line. The port returns periodically
+// without data so the deadline is honoured.
+func (s *atSession) readLine() (string, error) {
+	buf := make([]byte, 256)
+	for {
+		if i := bytes.IndexByte(s.pending, '\n'); i >= 0 {
+			line := strings.TrimSpace(string(s.pending[:i]))
+			s.pending = s.pending[i+1:]
+			return line, nil
+		}
+		if time.Now().After(s.deadline) {
+			return "", fmt.Errorf("timed out")
+		}
+		n, err := s.port.Read(buf)
+		s.pending = append(s.pending, buf[:n]...)
+		if err != nil && err != io.EOF && !isTimeout(err) {
+			return "", err
+		}
+	}
+}
+
+// atFields splits the parameters of a "+XXX: a,b,c" response line
+func atFields(line, prefix string) ([]string, bool) {
+	if !strings.HasPrefix(line, prefix) {
+		return nil, false
+	}
+	fields := strings.Split(strings.TrimPrefix(line, prefix), ",")
+	for i := range fields {
+		fields[i] = strings.Trim(strings.TrimSpace(fields[i]), `"`)
+	}
+	return fields, true
+}
+
+// parseCOPS reads the operator and access technology from AT+COPS?
+// (+COPS: <mode>,<format>,"<oper>",<AcT>)
+func parseCOPS(lines []string) *models.CellularData {
+	cell := &models.CellularData{}
+	for _, line := range lines {
+		fields, ok := atFields(line, "+COPS:")
+		if !ok || len(fields) < 3 {
+			continue
+		}
+		cell.Operator = fields[2]
+		if len(fields) > 3 {
+			switch fields[3] {
+			case "7":
+				cell.Technology = CellularLTE
+			case "13":
+				cell.Technology = CellularNR5GNSA
+			case "11", "12":
+				cell.Technology = CellularNR5GSA
+			}
+		}
+	}
+	return cell
+}
+
+// parseQENG reads the serving cell from Quectel AT+QENG="servingcell".
+// LTE and NR5G-SA are reported on one line; EN-DC (NR5G-NSA) reports the LTE
+// anchor and the NR cell on separate lines. Values are as reported by the
+// RM5xx/RG5xx series (SINR in dB).
+func parseQENG(lines []string, cell *models.CellularData) bool {
+	found := false
+	lteBand := ""
+	for _, line := range lines {
+		fields, ok := atFields(line, "+QENG:")
+		if !ok || len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "servingcell" {
+			if len(fields) < 3 {
+				continue // EN-DC header line, cells follow
+			}
+			fields = fields[2:]
+		}
+
+		switch fields[0] {
+		case "LTE":
+			if len(fields) < 15 {
+				continue
+			}
+			lteBand = "B" + fields[7]
+			cell.Technology = CellularLTE
+			cell.Band = lteBand
+			setSignal(cell, fields[11], fields[12], fields[14])
+			found = true
+		case "NR5G-SA":
+			if len(fields) < 13 {
+				continue
+			}
+			cell.Technology = CellularNR5GSA
+			cell.Band = "n" + fields[8]
+			setSignal(cell, fields[10], fields[11], fields[12])
+			found = true
+		case "NR5G-NSA":
+			if len(fields) < 9 {
+				continue
+			}
+			cell.Technology = CellularNR5GNSA
+			cell.Band = "n" + fields[8]
+			if lteBand != "" {
+				cell.Band = lteBand + "+" + cell.Band
+			}
+			setSignal(cell, fields[4], fields[6], fields[5])
+			found = true
+		}
+	}
+	return found
+}
+
+// setSignal stores the reported values, skipping the "-" and -32768 that
+// Quectel modems use for unavailable measurements
+func setSignal(cell *models.CellularData, rsrp, rsrq, sinr string) {
+	parse := func(value string) (float64, bool) {
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil && f != -32768
+	}
+	if v, ok := parse(rsrp); ok {
+		cell.RSRP = v
+	}
+	if v, ok := parse(rsrq); ok {
+		cell.RSRQ = v
+	}
+	if v, ok := parse(sinr); ok {
+		cell.SINR = v
+	}
+}
+
+// parseCESQ reads RSRP and RSRQ from the 3GPP extended signal quality
+// (+CESQ: <rxlev>,<ber>,<rscp>,<ecno>,<rsrq>,<rsrp>, 255 = unknown)
+func parseCESQ(lines []string, cell *models.CellularData) bool {
+	for _, line := range lines {
+		fields, ok := atFields(line, "+CESQ:")
+		if !ok || len(fields) < 6 {
+			continue
+		}
+		rsrq, errQ := strconv.Atoi(fields[4])
+		rsrp, errP := strconv.Atoi(fields[5])
+		if errP != nil || rsrp == 255 {
+			return false
+		}
+		cell.RSRP = float64(rsrp - 140)
+		if errQ == nil && rsrq != 255 {
+			cell.RSRQ = float64(rsrq)/2 - 20
+		}
+		if cell.Technology == "" {
+			cell.Technology = CellularLTE
+		}
+		return true
+	}
+	return false
+}
+
+// modemManager reads the modem through ModemManager's mmcli, which drives
+// QMI and MBIM modems as well as AT-only ones. ModemManager does not expose
+// the serving band.
+type modemManager struct {
+	cfg           config.ModemConfig
+	signalEnabled bool
+}
+
+// mmcliString is a ModemManager JSON value, where "--" means unknown
+type mmcliString string
+
+func (s mmcliString) float() (float64, bool) {
+	f, err := strconv.ParseFloat(string(s), 64)
+	return f, err == nil && !math.IsNaN(f)
+}
+
+type mmcliModem struct {
+	Modem struct {
+		ThreeGPP struct {
+			OperatorName string `json:"operator-name"`
+		} `json:"3gpp"`
+		Generic struct {
+			AccessTechnologies []string `json:"access-technologies"`
+		} `json:"generic"`
+	} `json:"modem"`
+}
+
+type mmcliSignal struct {
+	Modem struct {
+		Signal struct {
+			LTE struct {
+				RSRP mmcliString `json:"rsrp"`
+				RSRQ mmcliString `json:"rsrq"`
+				SNR  mmcliString `json:"snr"`
+			} `json:"lte"`
+			NR struct {
+				RSRP mmcliString `json:"rsrp"`
+				RSRQ mmcliString `json:"rsrq"`
+				SNR  mmcliString `json:"snr"`
+			} `json:"5g"`
+		} `json:"signal"`
+	} `json:"modem"`
+}
+
+func (m *modemManager) Read(ctx context.Context) (*models.CellularData, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	// Extended signal reporting is off until a refresh rate is set
+	if !m.signalEnabled {
+		if err := m.mmcli(ctx, nil, "--signal-setup=5"); err == nil {
+			m.signalEnabled = true
+		}
+	}
+
+	var modem mmcliModem
+	if err := m.mmcli(ctx, &modem); err != nil {
+		return nil, err
+	}
+	var signal mmcliSignal
+	if err := m.mmcli(ctx, &signal, "--signal-get"); err != nil {
+		return nil, err
+	}
+
+	cell := &models.CellularData{Operator: modem.Modem.ThreeGPP.OperatorName}
+	hasLTE, hasNR := false, false
+	for _, tech := range modem.Modem.Generic.AccessTechnologies {
+		switch tech {
+		case "lte":
+			hasLTE = true
+		case "5gnr":
+			hasNR = true
+		}
+	}
+
+	s := signal.Modem.Signal
+	rsrp, rsrq, snr := s.LTE.RSRP, s.LTE.RSRQ, s.LTE.SNR
+	switch {
+	case hasNR && hasLTE:
+		cell.Technology = CellularNR5GNSA
+	case hasNR:
+		cell.Technology = CellularNR5GSA
+	case hasLTE:
+		cell.Technology = CellularLTE
+	default:
+		return nil, fmt.Errorf("modem is not on an LTE or 5G cell (access technologies: %v)", modem.Modem.Generic.AccessTechnologies)
+	}
+	if hasNR {
+		if _, ok := s.NR.RSRP.float(); ok {
+			rsrp, rsrq, snr = s.NR.RSRP, s.NR.RSRQ, s.NR.SNR
+		}
+	}
+
+	var ok bool
+	if cell.RSRP, ok = rsrp.float(); !ok {
+		return nil, fmt.Errorf("modem reports no RSRP; is extended signal reporting supported?")
+	}
+	cell.RSRQ, _ = rsrq.float()
+	cell.SINR, _ = snr.float()
+	return cell, nil
+}
+
+// mmcli runs mmcli against the configured modem and decodes its JSON output
+func (m *modemManager) mmcli(ctx context.Context, out interface{}, args ...string) error {
+	args = append([]string{"-m", m.cfg.ModemID, "-J"}, args...)
+	data, err := exec.CommandContext(ctx, "mmcli", args...).Output()
+	if err != nil {
+		return fmt.Errorf("mmcli %s: %w", strings.Join(args, " "), err)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid mmcli output: %w", err)
+	}
+	return nil
+}
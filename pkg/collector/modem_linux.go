@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"io"
+
+	"golang.org/x/sys/unix"
+)
+
+// ttyPort is a modem AT port in raw mode with a 1s read timeout
+type ttyPort struct {
+	fd int
+}
+
+func openATPort(device string) (io.ReadWriteCloser, error) {
+	fd, err := unix.Open(device, unix.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	// Raw mode (cfmakeraw), 115200 8N1; USB modems ignore the baud rate
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB | unix.CBAUD
+	termios.Cflag |= unix.CS8 | unix.CREAD | unix.CLOCAL | unix.B115200
+	termios.Ispeed = unix.B115200
+	termios.Ospeed = unix.B115200
+	// Return after 1s without data so callers can honour their deadline
+	termios.Cc[unix.VMIN] = 0
+	termios.Cc[unix.VTIME] = 10
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, termios); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	// Drop unsolicited result codes queued before we opened the port
+	unix.IoctlSetInt(fd, unix.TCFLSH, unix.TCIOFLUSH)
+
+	return &ttyPort{fd: fd}, nil
+}
+
+func (p *ttyPort) Read(buf []byte) (int, error) {
+	n, err := unix.Read(p.fd, buf)
+	if n < 0 {
+		n = 0
+	}
+	return n, err
+}
+
+func (p *ttyPort) Write(buf []byte) (int, error) {
+	return unix.Write(p.fd, buf)
+}
+
+func (p *ttyPort) Close() error {
+	return unix.Close(p.fd)
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package collector
+
+import (
+	"fmt"
+	"io"
+)
+
+func openATPort(device string) (io.ReadWriteCloser, error) {
+	return nil, fmt.Errorf("modem AT ports are only supported on Linux")
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// powerSaveResumeMargin is how far above the battery threshold the battery
+// must recover before power saving stops, so a reading hovering around the
+// threshold doesn't switch the mode every cycle
+const powerSaveResumeMargin = 5.0
+
+// powerSaveTracker decides whether the agent saves power, manually or
+// because the battery is low
+type powerSaveTracker struct {
+	reason string // empty when not saving power
+	since  time.Time
+}
+
+// Update returns the power saving state after a sample with the given
+// battery reading (nil when it failed, keeping the battery decision), nil
+// when not saving power. A low battery never slows the telemetry of an
+// armed or flying vehicle, when operators need it most.
+func (t *powerSaveTracker) Update(cfg config.PowerSaveConfig, battery *models.BatteryData, airborne bool, now time.Time) *models.PowerSaveData {
+	reason := ""
+	switch {
+	case cfg.Enabled:
+		reason = models.PowerSaveManual
+	case cfg.BatteryThreshold <= 0 || airborne:
+	case battery == nil:
+		if t.reason == models.PowerSaveBattery {
+			reason = models.PowerSaveBattery
+		}
+	case battery.RemainingPercent <= cfg.BatteryThreshold:
+		reason = models.PowerSaveBattery
+	case t.reason == models.PowerSaveBattery && battery.RemainingPercent <= cfg.BatteryThreshold+powerSaveResumeMargin:
+		reason = models.PowerSaveBattery
+	}
+
+	if reason == "" {
+		t.reason = ""
+		return nil
+	}
+	if t.reason == "" {
+		t.since = now
+	}
+	t.reason = reason
+	return &models.PowerSaveData{
+		Reason:          reason,
+		Since:           t.since,
+		IntervalSeconds: cfg.Interval.Seconds(),
+	}
+}
+
+// Active reports whether the agent saves power
+func (t *powerSaveTracker) Active() bool {
+	return t.reason != ""
+}
+
+// Interval returns the collection interval, the power saving interval while
+// saving power if it is longer
+func (c *Collector) Interval() time.Duration {
+	if c.powerSave.Active() && c.config.PowerSave.Interval > c.config.Collection.Interval {
+		return c.config.PowerSave.Interval
+	}
+	return c.config.Collection.Interval
+}
+
+// PowerSaving reports whether the latest sample was collected in power
+// saving mode
+func (c *Collector) PowerSaving() bool {
+	return c.powerSave.Active()
+}
+
+// applyPowerSave pauses or resumes the probes and adapts the trackers
+// integrating over time to the collection interval
+func (c *Collector) applyPowerSave() {
+	active := c.powerSave.Active()
+	if c.bandwidth != nil {
+		c.bandwidth.SetPaused(active)
+	}
+	if c.latency != nil {
+		c.latency.SetPaused(active)
+	}
+	c.airtime.maxGap = 2 * c.Interval()
+	c.stats.maxGap = 2 * c.Interval()
+}
@@ -0,0 +1,513 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// ros2 message types subscribed through rosbridge
+const (
+	rosTypeNavSatFix    = "sensor_msgs/msg/NavSatFix"
+	rosTypeBatteryState = "sensor_msgs/msg/BatteryState"
+	rosTypeImu          = "sensor_msgs/msg/Imu"
+	rosTypeTwistStamped = "geometry_msgs/msg/TwistStamped"
+	rosTypeMavrosState  = "mavros_msgs/msg/State"
+	rosTypeFloat64      = "std_msgs/msg/Float64"
+	rosTypeGPSRaw       = "mavros_msgs/msg/GPSRAW"
+	rosTypePressure     = "sensor_msgs/msg/FluidPressure"
+	rosTypeTimeRef      = "sensor_msgs/msg/TimeReference"
+
+	ros2ReconnectDelay = 5 * time.Second
+)
+
+// rosbridge protocol envelope
+type rosbridgeOp struct {
+	Op    string          `json:"op"`
+	Topic string          `json:"topic"`
+	Type  string          `json:"type,omitempty"`
+	Msg   json.RawMessage `json:"msg,omitempty"`
+}
+
+type rosNavSatFix struct {
+	Status struct {
+		Status int `json:"status"` // -1 no fix, 0 fix, 1 SBAS, 2 GBAS
+	} `json:"status"`
+	Latitude           float64    `json:"latitude"`
+	Longitude          float64    `json:"longitude"`
+	Altitude           float64    `json:"altitude"`
+	PositionCovariance [9]float64 `json:"position_covariance"`
+}
+
+// Unmeasured BatteryState fields are NaN, which rosbridge encodes as null
+type rosBatteryState struct {
+	Voltage     float64    `json:"voltage"`
+	Temperature *float64   `json:"temperature"`
+	Current     float64    `json:"current"`
+	Percentage  *float64   `json:"percentage"` // 0..1
+	CellVoltage []*float64 `json:"cell_voltage"`
+}
+
+type rosImu struct {
+	Orientation struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+		Z float64 `json:"z"`
+		W float64 `json:"w"`
+	} `json:"orientation"`
+}
+
+type rosTwistStamped struct {
+	Twist struct {
+		Linear struct {
+			X float64 `json:"x"`
+			Y float64 `json:"y"`
+			Z float64 `json:"z"`
+		} `json:"linear"`
+	} `json:"twist"`
+}
+
+type rosMavrosState struct {
+	Connected bool   `json:"connected"`
+	Armed     bool   `json:"armed"`
+	Mode      string `json:"mode"`
+}
+
+// MAVROS republishes MAVLink GPS_RAW_INT; eph/epv are DOP scaled by 100
+type rosGPSRaw struct {
+	FixType uint8  `json:"fix_type"`
+	Eph     uint16 `json:"eph"`
+	Epv     uint16 `json:"epv"`
+}
+
+type rosFluidPressure struct {
+	FluidPressure float64 `json:"fluid_pressure"` // Pa
+}
+
+// TimeReference carries the GNSS time (MAVROS publishes SYSTEM_TIME)
+type rosTimeReference struct {
+	TimeRef struct {
+		Sec     int64 `json:"sec"`
+		Nanosec int64 `json:"nanosec"`
+	} `json:"time_ref"`
+}
+
+type rosFloat64 struct {
+	Data float64 `json:"data"`
+}
+
+// ros2Backend subscribes to ROS 2 topics through a rosbridge websocket
+// (rosbridge_suite v2 protocol) and keeps the latest value of each topic
+type ros2Backend struct {
+	cfg config.ROS2Config
+
+	mu      sync.RWMutex
+	navSat  *rosNavSatFix
+	battery *rosBatteryState
+	imu     *rosImu
+	vel     *rosTwistStamped
+	state   *rosMavrosState
+	relAlt  *rosFloat64
+	heading *rosFloat64
+	gpsRaw  *rosGPSRaw
+	press   *rosFluidPressure
+	timeRef *rosTimeReference
+	updated map[string]time.Time
+	lastErr error
+}
+
+func newROS2Backend(cfg config.ROS2Config) *ros2Backend {
+	return &ros2Backend{
+		cfg:     cfg,
+		updated: make(map[string]time.Time),
+	}
+}
+
+// topics returns topic -> message type for every configured subscription
+func (b *ros2Backend) topics() map[string]string {
+	topics := map[string]string{
+		b.cfg.NavSatFixTopic: rosTypeNavSatFix,
+		b.cfg.BatteryTopic:   rosTypeBatteryState,
+		b.cfg.StateTopic:     rosTypeMavrosState,
+		b.cfg.ImuTopic:       rosTypeImu,
+		b.cfg.VelocityTopic:  rosTypeTwistStamped,
+		b.cfg.RelAltTopic:    rosTypeFloat64,
+		b.cfg.HeadingTopic:   rosTypeFloat64,
+		b.cfg.GPSRawTopic:    rosTypeGPSRaw,
+		b.cfg.PressureTopic:  rosTypePressure,
+		b.cfg.TimeRefTopic:   rosTypeTimeRef,
+	}
+	delete(topics, "")
+	return topics
+}
+
+// Run connects to rosbridge and reconnects until ctx is cancelled
+func (b *ros2Backend) Run(ctx context.Context) {
+	for {
+		err := b.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		b.setErr(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ros2ReconnectDelay):
+		}
+	}
+}
+
+func (b *ros2Backend) session(ctx context.Context) error {
+	wsConfig, err := websocket.NewConfig(b.cfg.BridgeURL, "http://localhost/")
+	if err != nil {
+		return fmt.Errorf("invalid rosbridge URL: %w", err)
+	}
+	conn, err := wsConfig.DialContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to rosbridge: %w", err)
+	}
+	defer conn.Close()
+
+	// Close the connection when the agent stops so Receive returns
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for topic, msgType := range b.topics() {
+		sub := rosbridgeOp{Op: "subscribe", Topic: topic, Type: msgType}
+		if err := websocket.JSON.Send(conn, sub); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+	}
+	b.setErr(nil)
+
+	for {
+		var op rosbridgeOp
+		if err := websocket.JSON.Receive(conn, &op); err != nil {
+			return fmt.Errorf("rosbridge connection lost: %w", err)
+		}
+		if op.Op != "publish" {
+			continue
+		}
+		if err := b.handle(op.Topic, op.Msg); err != nil {
+			b.setErr(err)
+		}
+	}
+}
+
+// handle decodes a published message into the latest-value cache
+func (b *ros2Backend) handle(topic string, raw json.RawMessage) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var err error
+	switch topic {
+	case b.cfg.NavSatFixTopic:
+		msg := &rosNavSatFix{}
+		if err = json.Unmarshal(raw, msg); err == nil {
+			b.navSat = msg
+		}
+	case b.cfg.BatteryTopic:
+		msg := &rosBatteryState{}
+		if err = json.Unmarshal(raw, msg); err == nil {
+			b.battery = msg
+		}
+	case b.cfg.StateTopic:
+		msg := &rosMavrosState{}
+		if err = json.Unmarshal(raw, msg); err == nil {
+			b.state = msg
+		}
+	case b.cfg.ImuTopic:
+		msg := &rosImu{}
+		if err = json.Unmarshal(raw, msg); err == nil {
+			b.imu = msg
+		}
+	case b.cfg.VelocityTopic:
+		msg := &rosTwistStamped{}
+		if err = json.Unmarshal(raw, msg); err == nil {
+			b.vel = msg
+		}
+	case b.cfg.RelAltTopic:
+		msg := &rosFloat64{}
+		if err = json.Unmarshal(raw, msg); err == nil {
+			b.relAlt = msg
+		}
+	case b.cfg.HeadingTopic:
+		msg := &rosFloat64{}
+		if err = json.Unmarshal(raw, msg); err == nil {
+			b.heading = msg
+		}
+	case b.cfg.GPSRawTopic:
+		msg := &rosGPSRaw{}
+		if err = json.Unmarshal(raw, msg); err == nil {
+			b.gpsRaw = msg
+		}
+	case b.cfg.PressureTopic:
+		msg := &rosFluidPressure{}
+		if err = json.Unmarshal(raw, msg); err == nil {
+			b.press = msg
+		}
+	case b.cfg.TimeRefTopic:
+		msg := &rosTimeReference{}
+		if err = json.Unmarshal(raw, msg); err == nil {
+			b.timeRef = msg
+		}
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", topic, err)
+	}
+
+	b.updated[topic] = time.Now()
+	return nil
+}
+
+func (b *ros2Backend) setErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastErr = err
+}
+
+// fresh reports whether topic has a message newer than the stale timeout.
+// Caller must hold b.mu.
+func (b *ros2Backend) fresh(topic string) bool {
+	updated, ok := b.updated[topic]
+	return ok && time.Since(updated) <= b.cfg.StaleTimeout
+}
+
+// staleError explains why a topic has no usable data. Caller must hold b.mu.
+func (b *ros2Backend) staleError(topic string) error {
+	if b.lastErr != nil {
+		return fmt.Errorf("no fresh data on %s: %w", topic, b.lastErr)
+	}
+	return fmt.Errorf("no fresh data on %s", topic)
+}
+
+// GPS returns the latest fix converted to GPSData
+func (b *ros2Backend) GPS() (*models.GPSData, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.navSat == nil || !b.fresh(b.cfg.NavSatFixTopic) {
+		return nil, b.staleError(b.cfg.NavSatFixTopic)
+	}
+	if b.navSat.Status.Status < 0 {
+		return nil, fmt.Errorf("no GNSS fix reported on %s", b.cfg.NavSatFixTopic)
+	}
+
+	gps := &models.GPSData{
+		Latitude:   b.navSat.Latitude,
+		Longitude:  b.navSat.Longitude,
+		Altitude:   b.navSat.Altitude,
+		LastUpdate: b.updated[b.cfg.NavSatFixTopic],
+	}
+
+	// Horizontal accuracy from the east/north variances
+	if variance := (b.navSat.PositionCovariance[0] + b.navSat.PositionCovariance[4]) / 2; variance > 0 {
+		gps.Accuracy = math.Sqrt(variance)
+	}
+
+	// The compass heading is the fallback for the course over ground while hovering
+	if b.heading != nil && b.fresh(b.cfg.HeadingTopic) {
+		gps.Heading = b.heading.Data
+	}
+	if b.vel != nil && b.fresh(b.cfg.VelocityTopic) {
+		// velocity_local is ENU: X east, Y north
+		gps.Speed = math.Hypot(b.vel.Twist.Linear.X, b.vel.Twist.Linear.Y)
+		gps.Heading = courseOverGround(b.vel.Twist.Linear.Y, b.vel.Twist.Linear.X, gps.Heading)
+	}
+
+	// NavSatFix only distinguishes fix/no fix; GPSRAW has the fix type and DOP
+	if b.gpsRaw != nil && b.fresh(b.cfg.GPSRawTopic) {
+		gps.FixType = mavlinkFixType(int(b.gpsRaw.FixType))
+		if b.gpsRaw.Eph != math.MaxUint16 {
+			hdop := float64(b.gpsRaw.Eph) / 100
+			gps.HDOP = &hdop
+		}
+		if b.gpsRaw.Epv != math.MaxUint16 {
+			vdop := float64(b.gpsRaw.Epv) / 100
+			gps.VDOP = &vdop
+		}
+	} else if b.navSat.Status.Status > 0 {
+		gps.FixType = models.GNSSFixDGPS // SBAS or ground-based augmentation
+	}
+
+	return gps, nil
+}
+
+// Battery returns the latest BatteryState converted to BatteryData
+func (b *ros2Backend) Battery() (*models.BatteryData, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.battery == nil || !b.fresh(b.cfg.BatteryTopic) {
+		return nil, b.staleError(b.cfg.BatteryTopic)
+	}
+
+	// Without a percentage the charge is estimated from the voltage
+	battery := &models.BatteryData{
+		RemainingPercent: math.NaN(),
+		Voltage:          b.battery.Voltage,
+		Current:          b.battery.Current,
+	}
+	if b.battery.Percentage != nil && !math.IsNaN(*b.battery.Percentage) {
+		battery.RemainingPercent = *b.battery.Percentage * 100
+	}
+	if b.battery.Temperature != nil {
+		battery.Temperature = *b.battery.Temperature
+	}
+	for _, cell := range b.battery.CellVoltage {
+		if cell == nil || math.IsNaN(*cell) {
+			battery.CellVoltages = nil // unknown cells make the spread meaningless
+			break
+		}
+		battery.CellVoltages = append(battery.CellVoltages, *cell)
+	}
+
+	return battery, nil
+}
+
+// Flight returns the latest vehicle state, attitude and vertical speed
+func (b *ros2Backend) Flight() (*models.FlightData, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.state == nil || !b.fresh(b.cfg.StateTopic) {
+		return nil, b.staleError(b.cfg.StateTopic)
+	}
+
+	flight := &models.FlightData{
+		Armed: b.state.Armed,
+		Mode:  mavrosFlightMode(b.state.Mode),
+	}
+
+	if b.relAlt != nil && b.fresh(b.cfg.RelAltTopic) {
+		flight.Altitude = b.relAlt.Data
+	}
+	if b.vel != nil && b.fresh(b.cfg.VelocityTopic) {
+		flight.VerticalSpeed = b.vel.Twist.Linear.Z
+	}
+	if b.imu != nil && b.fresh(b.cfg.ImuTopic) {
+		q := b.imu.Orientation
+		flight.RollAngle = math.Atan2(2*(q.W*q.X+q.Y*q.Z), 1-2*(q.X*q.X+q.Y*q.Y)) * 180 / math.Pi
+		flight.PitchAngle = math.Asin(math.Max(-1, math.Min(1, 2*(q.W*q.Y-q.Z*q.X)))) * 180 / math.Pi
+		// ENU yaw (counter-clockwise from east) to compass heading
+		yaw := math.Atan2(2*(q.W*q.Z+q.X*q.Y), 1-2*(q.Y*q.Y+q.Z*q.Z)) * 180 / math.Pi
+		flight.YawAngle = math.Mod(450-yaw, 360)
+	}
+
+	// Treat the vehicle as airborne when armed and above the ground threshold
+	flight.IsFlying = flight.Armed && flight.Altitude > 0.5
+
+	return flight, nil
+}
+
+// mavrosFlightMode maps the mode string of mavros_msgs/State (ArduPilot
+// mode names or PX4 main.sub modes) to a FlightMode, like flightMode does
+// for the custom mode of a MAVLink heartbeat
+func mavrosFlightMode(mode string) string {
+	switch mode {
+	case "STABILIZE", "STABILIZED", "QSTABILIZE":
+		return models.FlightModeStabilize
+	case "MANUAL", "ACRO":
+		return models.FlightModeManual
+	case "ALT_HOLD", "ALTCTL", "QHOVER":
+		return models.FlightModeAltitudeHold
+	case "POSHOLD", "POSCTL":
+		return models.FlightModePositionHold
+	case "AUTO", "AUTO.MISSION", "AUTO.TAKEOFF", "TAKEOFF":
+		return models.FlightModeAuto
+	case "GUIDED", "OFFBOARD":
+		return models.FlightModeGuided
+	case "LOITER", "AUTO.LOITER", "QLOITER":
+		return models.FlightModeLoiter
+	case "RTL", "AUTO.RTL", "QRTL", "SMART_RTL", "AUTO_RTL":
+		return models.FlightModeRTL
+	case "LAND", "AUTO.LAND", "AUTO.PRECLAND", "QLAND":
+		return models.FlightModeLand
+	}
+	if strings.HasPrefix(mode, "AUTO.") {
+		// Other PX4 auto sub-modes, as for a heartbeat
+		return models.FlightModeAuto
+	}
+	return models.FlightModeUnknown
+}
+
+// GNSSTime returns the GNSS time of the latest TimeReference
+func (b *ros2Backend) GNSSTime() (time.Time, time.Time, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.timeRef == nil || !b.fresh(b.cfg.TimeRefTopic) || b.timeRef.TimeRef.Sec <= 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	gnss := time.Unix(b.timeRef.TimeRef.Sec, b.timeRef.TimeRef.Nanosec).UTC()
+	return gnss, b.updated[b.cfg.TimeRefTopic], true
+}
+
+// Pressure returns the latest static pressure
+func (b *ros2Backend) Pressure() (float64, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.press == nil || !b.fresh(b.cfg.PressureTopic) || b.press.FluidPressure <= 0 {
+		return 0, false
+	}
+	return b.press.FluidPressure / 100, true // Pa to hPa
+}
+
+// Diagnostics reports which topics are publishing and how old the GPS fix and
+// flight controller state are
+func (b *ros2Backend) Diagnostics() *models.DiagnosticsData {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	diag := &models.DiagnosticsData{
+		Backend:       BackendROS2,
+		GPSMessageAge: ageSeconds(b.updated[b.cfg.NavSatFixTopic]),
+		HeartbeatAge:  ageSeconds(b.updated[b.cfg.StateTopic]),
+	}
+
+	topicSensors := []struct {
+		name  string
+		topic string
+	}{
+		{"gps", b.cfg.NavSatFixTopic},
+		{"battery", b.cfg.BatteryTopic},
+		{"imu", b.cfg.ImuTopic},
+		{"flight-controller", b.cfg.StateTopic},
+	}
+	for _, ts := range topicSensors {
+		if ts.topic == "" {
+			continue
+		}
+		present := b.fresh(ts.topic)
+		healthy := present
+		switch ts.name {
+		case "gps":
+			healthy = present && b.navSat != nil && b.navSat.Status.Status >= 0
+		case "flight-controller":
+			healthy = present && b.state != nil && b.state.Connected
+		}
+		diag.Sensors = append(diag.Sensors, models.SensorStatus{Name: ts.name, Present: present, Healthy: healthy})
+	}
+
+	return diag
+}
@@ -0,0 +1,763 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// MAVLink message IDs and CRC extras used by the SITL backend
+const (
+	mavlinkHeartbeat     = 0
+	mavlinkSysStatus     = 1
+	mavlinkSystemTime    = 2
+	mavlinkGPSRawInt     = 24
+	mavlinkScaledPress   = 29
+	mavlinkAttitude      = 30
+	mavlinkGlobalPosInt  = 33
+	mavlinkBatteryStatus = 147
+	mavlinkGNSSIntegrity = 441
+
+	mavlinkV1Magic = 0xFE
+	mavlinkV2Magic = 0xFD
+
+	mavAutopilotArduPilot = 3
+	mavAutopilotPX4       = 12
+	mavModeFlagArmed      = 0x80
+
+	// System ID used for the heartbeats the agent sends as a ground station
+	sitlGCSSystemID = 255
+)
+
+// mavlinkMessages maps message ID to CRC extra and minimum payload length
+var mavlinkMessages = map[uint32]struct {
+	crcExtra byte
+	length   int
+}{
+	mavlinkHeartbeat:     {50, 9},
+	mavlinkSysStatus:     {124, 31},
+	mavlinkSystemTime:    {137, 12},
+	mavlinkGPSRawInt:     {24, 30},
+	mavlinkScaledPress:   {115, 14},
+	mavlinkAttitude:      {39, 28},
+	mavlinkGlobalPosInt:  {104, 28},
+	mavlinkBatteryStatus: {154, 36},
+	mavlinkGNSSIntegrity: {169, 17},
+}
+
+// gnssStates maps GPS_JAMMING_STATE and GPS_SPOOFING_STATE values
+var gnssStates = map[uint8]string{
+	0: models.GNSSStateUnknown,
+	1: models.GNSSStateOK,
+	2: models.GNSSStateMitigated,
+	3: models.GNSSStateDetected,
+}
+
+var trailingNumber = regexp.MustCompile(`(\d+)$`)
+
+// mavSensors maps MAV_SYS_STATUS_SENSOR bits to sensor names
+var mavSensors = []struct {
+	name        string
+	bit         uint32
+	calibration bool // ArduPilot and PX4 report uncalibrated sensors as unhealthy
+}{
+	{"gyro", 0x01, true},
+	{"accelerometer", 0x02, true},
+	{"magnetometer", 0x04, true},
+	{"barometer", 0x08, false},
+	{"gps", 0x20, false},
+	{"rc-receiver", 0x10000, false},
+	{"ahrs", 0x200000, false},
+	{"battery", 0x2000000, false},
+}
+
+// mavlinkState is the latest decoded telemetry of one vehicle
+type mavlinkState struct {
+	autopilot  uint8
+	customMode uint32
+	armed      bool
+
+	latitude    float64
+	longitude   float64
+	altitude    float64 // MSL, meters
+	relativeAlt float64
+	vx, vy, vz  float64 // NED, m/s
+	heading     float64
+	satellites  int
+	fixType     int
+	accuracy    float64
+	hdop, vdop  float64 // -1 unknown
+
+	roll, pitch, yaw float64 // degrees
+
+	pressure float64 // static pressure, hPa
+
+	gnssTime time.Time // SYSTEM_TIME Unix time from GNSS, zero when unknown
+
+	voltage     float64
+	current     float64 // positive while discharging
+	remaining   int     // -1 unknown
+	temperature float64
+	hasTemp     bool
+	cells       []float64 // BATTERY_STATUS cell voltages, V
+
+	// GNSS_INTEGRITY interference monitor
+	jammingState  uint8
+	spoofingState uint8
+	signalQuality uint8 // 0 (worst) - 10 (best), 255 unknown
+
+	// SYS_STATUS onboard sensor bitmasks
+	sensorsPresent uint32
+	sensorsEnabled uint32
+	sensorsHealth  uint32
+
+	updated map[uint32]time.Time
+}
+
+// sitlBackend reads MAVLink telemetry from an ArduPilot or PX4 SITL instance.
+// A single endpoint may be multiplexed across nodes, each selecting its own
+// vehicle by MAVLink system ID.
+type sitlBackend struct {
+	cfg      config.SITLConfig
+	systemID uint8 // 0 accepts the first vehicle seen
+
+	mu      sync.RWMutex
+	state   *mavlinkState
+	lastErr error
+}
+
+func newSITLBackend(cfg config.SITLConfig, nodeName string) *sitlBackend {
+	b := &sitlBackend{cfg: cfg, systemID: uint8(cfg.SystemID)}
+	if cfg.SystemIDFromNode {
+		// uav-node-3 -> system ID 3
+		if m := trailingNumber.FindString(nodeName); m != "" {
+			if id, err := strconv.Atoi(m); err == nil && id > 0 && id < 255 {
+				b.systemID = uint8(id)
+			}
+		}
+	}
+	return b
+}
+
+// Run connects to the SITL endpoint and reconnects until ctx is cancelled
+func (b *sitlBackend) Run(ctx context.Context) {
+	for {
+		err := b.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		b.setErr(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ros2ReconnectDelay):
+		}
+	}
+}
+
+func (b *sitlBackend) session(ctx context.Context) error {
+	conn, err := dialSITL(ctx, b.cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// Announce ourselves as a GCS so the autopilot starts streaming to us
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		var seq uint8
+		for {
+			if _, err := conn.Write(encodeGCSHeartbeat(seq)); err != nil && !isUnconnectedUDP(conn) {
+				return
+			}
+			seq++
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	b.setErr(nil)
+	reader := bufio.NewReader(conn)
+	for {
+		sysID, msgID, payload, err := readMAVLink(reader)
+		if err != nil {
+			if err == errMAVLinkCRC {
+				continue
+			}
+			return fmt.Errorf("SITL connection lost: %w", err)
+		}
+		if sysID == sitlGCSSystemID {
+			continue
+		}
+		b.handle(sysID, msgID, payload)
+	}
+}
+
+// dialSITL opens the endpoint: tcp://host:port connects to the SITL's TCP
+// server, udp://:port listens for the SITL's UDP output, udp://host:port
+// connects to a UDP server (e.g. PX4 SITL or mavlink-router)
+func dialSITL(ctx context.Context, endpoint string) (net.Conn, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SITL endpoint: %w", err)
+	}
+
+	var d net.Dialer
+	switch u.Scheme {
+	case "tcp":
+		return d.DialContext(ctx, "tcp", u.Host)
+	case "udp":
+		if u.Hostname() == "" {
+			addr, err := net.ResolveUDPAddr("udp", u.Host)
+			if err != nil {
+				return nil, err
+			}
+			conn, err := net.ListenUDP("udp", addr)
+			if err != nil {
+				return nil, err
+			}
+			return &udpListenConn{UDPConn: conn}, nil
+		}
+		return d.DialContext(ctx, "udp", u.Host)
+	default:
+		return nil, fmt.Errorf("unsupported SITL endpoint scheme %q (use tcp:// or udp://)", u.Scheme)
+	}
+}
+
+// udpListenConn replies to whichever peer last sent us a datagram
+type udpListenConn struct {
+	*net.UDPConn
+	mu   sync.Mutex
+	peer *net.UDPAddr
+}
+
+func (c *udpListenConn) Read(p []byte) (int, error) {
+	n, addr, err := c.UDPConn.ReadFromUDP(p)
+	if err == nil {
+		c.mu.Lock()
+		c.peer = addr
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+func (c *udpListenConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	peer := c.peer
+	c.mu.Unlock()
+	if peer == nil {
+		return 0, errNoPeer
+	}
+	return c.UDPConn.WriteToUDP(p, peer)
+}
+
+var (
+	errNoPeer     = fmt.Errorf("no SITL peer yet")
+	errMAVLinkCRC = fmt.Errorf("MAVLink CRC mismatch")
+)
+
+func isUnconnectedUDP(conn net.Conn) bool {
+	_, ok := conn.(*udpListenConn)
+	return ok
+}
+
+// readMAVLink reads the next MAVLink v1 or v2 frame we know how to decode
+func readMAVLink(r *bufio.Reader) (uint8, uint32, []byte, error) {
+	for {
+		magic, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+
+		var header []byte
+		switch magic {
+		case mavlinkV1Magic:
+			header = make([]byte, 5) // len, seq, sysid, compid, msgid
+		case mavlinkV2Magic:
+			header = make([]byte, 9) // len, incompat, compat, seq, sysid, compid, msgid[3]
+		default:
+			continue
+		}
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, 0, nil, err
+		}
+
+		length := int(header[0])
+		var sysID uint8
+		var msgID uint32
+		trailer := 2
+		if magic == mavlinkV1Magic {
+			sysID = header[2]
+			msgID = uint32(header[4])
+		} else {
+			sysID = header[4]
+			msgID = uint32(header[6]) | uint32(header[7])<<8 | uint32(header[8])<<16
+			if header[1]&0x01 != 0 {
+				trailer += 13 // signature
+			}
+		}
+
+		body := make([]byte, length+trailer)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return 0, 0, nil, err
+		}
+
+		info, known := mavlinkMessages[msgID]
+		if !known {
+			continue
+		}
+
+		crc := crcAccumulate(0xFFFF, header)
+		crc = crcAccumulate(crc, body[:length])
+		crc = crcAccumulate(crc, []byte{info.crcExtra})
+		if crc != binary.LittleEndian.Uint16(body[length:length+2]) {
+			return 0, 0, nil, errMAVLinkCRC
+		}
+
+		// MAVLink 2 truncates trailing zero bytes
+		payload := body[:length]
+		if len(payload) < info.length {
+			payload = append(payload, make([]byte, info.length-len(payload))...)
+		}
+		return sysID, msgID, payload, nil
+	}
+}
+
+// crcAccumulate implements the MAVLink CRC-16/MCRF4XX
+func crcAccumulate(crc uint16, data []byte) uint16 {
+	for _, b := range data {
+		tmp := b ^ byte(crc&0xFF)
+		tmp ^= tmp << 4
+		crc = (crc >> 8) ^ (uint16(tmp) << 8) ^ (uint16(tmp) << 3) ^ (uint16(tmp) >> 4)
+	}
+	return crc
+}
+
+// encodeGCSHeartbeat builds a MAVLink v1 HEARTBEAT from a ground station
+func encodeGCSHeartbeat(seq uint8) []byte {
+	payload := []byte{
+		0, 0, 0, 0, // custom_mode
+		6, // MAV_TYPE_GCS
+		8, // MAV_AUTOPILOT_INVALID
+		0, // base_mode
+		0, // system_status
+		3, // mavlink_version
+	}
+	header := []byte{byte(len(payload)), seq, sitlGCSSystemID, 190, mavlinkHeartbeat}
+	crc := crcAccumulate(0xFFFF, header)
+	crc = crcAccumulate(crc, payload)
+	crc = crcAccumulate(crc, []byte{mavlinkMessages[mavlinkHeartbeat].crcExtra})
+
+	frame := append([]byte{mavlinkV1Magic}, header...)
+	frame = append(frame, payload...)
+	return binary.LittleEndian.AppendUint16(frame, crc)
+}
+
+// handle decodes a message from the selected vehicle
+func (b *sitlBackend) handle(sysID uint8, msgID uint32, p []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.systemID == 0 {
+		if msgID != mavlinkHeartbeat {
+			return
+		}
+		b.systemID = sysID // lock on to the first vehicle
+	}
+	if sysID != b.systemID {
+		return
+	}
+	if b.state == nil {
+		b.state = &mavlinkState{remaining: -1, updated: make(map[uint32]time.Time)}
+	}
+	s := b.state
+	le := binary.LittleEndian
+
+	switch msgID {
+	case mavlinkHeartbeat:
+		if p[4] == 6 { // another GCS
+			return
+		}
+		s.customMode = le.Uint32(p[0:])
+		s.autopilot = p[5]
+		s.armed = p[6]&mavModeFlagArmed != 0
+	case mavlinkSysStatus:
+		s.sensorsPresent = le.Uint32(p[0:])
+		s.sensorsEnabled = le.Uint32(p[4:])
+		s.sensorsHealth = le.Uint32(p[8:])
+		if v := le.Uint16(p[14:]); v != math.MaxUint16 {
+			s.voltage = float64(v) / 1000
+		}
+		if c := int16(le.Uint16(p[16:])); c != -1 {
+			s.current = float64(c) / 100
+		}
+		s.remaining = int(int8(p[30]))
+	case mavlinkSystemTime:
+		// Autopilots report 0 until their GNSS receiver has the time
+		s.gnssTime = time.Time{}
+		if usec := le.Uint64(p[0:]); usec != 0 {
+			s.gnssTime = time.UnixMicro(int64(usec)).UTC()
+		}
+	case mavlinkGPSRawInt:
+		s.hdop, s.vdop = -1, -1
+		if eph := le.Uint16(p[20:]); eph != math.MaxUint16 {
+			// HDOP times a nominal 2.5m UERE
+			s.hdop = float64(eph) / 100
+			s.accuracy = s.hdop * 2.5
+		}
+		if epv := le.Uint16(p[22:]); epv != math.MaxUint16 {
+			s.vdop = float64(epv) / 100
+		}
+		s.fixType = int(p[28])
+		if sats := p[29]; sats != math.MaxUint8 {
+			s.satellites = int(sats)
+		}
+	case mavlinkScaledPress:
+		s.pressure = float64(math.Float32frombits(le.Uint32(p[4:])))
+	case mavlinkAttitude:
+		s.roll = float64(math.Float32frombits(le.Uint32(p[4:]))) * 180 / math.Pi
+		s.pitch = float64(math.Float32frombits(le.Uint32(p[8:]))) * 180 / math.Pi
+		s.yaw = math.Mod(float64(math.Float32frombits(le.Uint32(p[12:])))*180/math.Pi+360, 360)
+	case mavlinkGlobalPosInt:
+		s.latitude = float64(int32(le.Uint32(p[4:]))) / 1e7
+		s.longitude = float64(int32(le.Uint32(p[8:]))) / 1e7
+		s.altitude = float64(int32(le.Uint32(p[12:]))) / 1000
+		s.relativeAlt = float64(int32(le.Uint32(p[16:]))) / 1000
+		s.vx = float64(int16(le.Uint16(p[20:]))) / 100
+		s.vy = float64(int16(le.Uint16(p[22:]))) / 100
+		s.vz = float64(int16(le.Uint16(p[24:]))) / 100
+		if hdg := le.Uint16(p[26:]); hdg != math.MaxUint16 {
+			s.heading = float64(hdg) / 100
+		}
+	case mavlinkGNSSIntegrity:
+		if p[8] != 0 {
+			return // only the primary receiver
+		}
+		s.jammingState = p[10]
+		s.spoofingState = p[11]
+		s.signalQuality = p[15]
+	case mavlinkBatteryStatus:
+		if t := int16(le.Uint16(p[8:])); t != math.MaxInt16 {
+			s.temperature = float64(t) / 100
+			s.hasTemp = true
+		}
+		s.cells = parseCellVoltages(p)
+	}
+
+	s.updated[msgID] = time.Now()
+}
+
+func (b *sitlBackend) setErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastErr = err
+}
+
+// fresh reports whether msgID was received within the stale timeout.
+// Caller must hold b.mu.
+func (b *sitlBackend) fresh(msgID uint32) bool {
+	if b.state == nil {
+		return false
+	}
+	updated, ok := b.state.updated[msgID]
+	return ok && time.Since(updated) <= b.cfg.StaleTimeout
+}
+
+// staleError explains why a message has no usable data. Caller must hold b.mu.
+func (b *sitlBackend) staleError(name string) error {
+	if b.lastErr != nil {
+		return fmt.Errorf("no fresh %s from SITL %s: %w", name, b.cfg.Endpoint, b.lastErr)
+	}
+	return fmt.Errorf("no fresh %s from SITL %s (system ID %d)", name, b.cfg.Endpoint, b.systemID)
+}
+
+// mavlinkFixType maps a MAVLink GPS_FIX_TYPE to a GPSData fix type
+func mavlinkFixType(fixType int) string {
+	switch fixType {
+	case 2:
+		return models.GNSSFix2D
+	case 3, 7, 8: // 3D, static, PPP
+		return models.GNSSFix3D
+	case 4:
+		return models.GNSSFixDGPS
+	case 5:
+		return models.GNSSFixRTKFloat
+	case 6:
+		return models.GNSSFixRTKFixed
+	}
+	return models.GNSSFixNone
+}
+
+// GPS returns the latest position of the selected vehicle
+func (b *sitlBackend) GPS() (*models.GPSData, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.fresh(mavlinkGlobalPosInt) {
+		return nil, b.staleError("GLOBAL_POSITION_INT")
+	}
+	s := b.state
+	if b.fresh(mavlinkGPSRawInt) && s.fixType < 2 {
+		return nil, fmt.Errorf("no GPS fix reported by SITL (fix type %d)", s.fixType)
+	}
+
+	gps := &models.GPSData{
+		Latitude:   s.latitude,
+		Longitude:  s.longitude,
+		Altitude:   s.altitude,
+		Heading:    courseOverGround(s.vx, s.vy, s.heading), // vx/vy are north/east
+		Speed:      math.Hypot(s.vx, s.vy),
+		Satellites: s.satellites,
+		Accuracy:   s.accuracy,
+		LastUpdate: s.updated[mavlinkGlobalPosInt],
+	}
+	if b.fresh(mavlinkGPSRawInt) {
+		gps.FixType = mavlinkFixType(s.fixType)
+		if s.hdop >= 0 {
+			hdop := s.hdop
+			gps.HDOP = &hdop
+		}
+		if s.vdop >= 0 {
+			vdop := s.vdop
+			gps.VDOP = &vdop
+		}
+	}
+	if b.fresh(mavlinkGNSSIntegrity) {
+		gps.Interference = &models.GNSSInterference{
+			JammingState:  gnssStates[s.jammingState],
+			SpoofingState: gnssStates[s.spoofingState],
+		}
+		if s.signalQuality <= 10 {
+			indicator := int(10-s.signalQuality) * 10
+			gps.Interference.JammingIndicator = &indicator
+		}
+	}
+	return gps, nil
+}
+
+// Battery returns the latest battery state of the selected vehicle
+func (b *sitlBackend) Battery() (*models.BatteryData, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.fresh(mavlinkSysStatus) {
+		return nil, b.staleError("SYS_STATUS")
+	}
+	s := b.state
+	battery := &models.BatteryData{
+		RemainingPercent: float64(s.remaining),
+		Voltage:          s.voltage,
+		Current:          -s.current, // discharge is negative in UAVMetrics
+	}
+	if s.remaining < 0 {
+		// Not reported; estimated from the voltage
+		battery.RemainingPercent = math.NaN()
+	}
+	if s.hasTemp && b.fresh(mavlinkBatteryStatus) {
+		battery.Temperature = s.temperature
+	}
+	if len(s.cells) > 0 && b.fresh(mavlinkBatteryStatus) {
+		battery.CellVoltages = append([]float64(nil), s.cells...)
+	}
+	return battery, nil
+}
+
+// parseCellVoltages returns the cell voltages of a BATTERY_STATUS payload:
+// up to 10 cells in voltages (UINT16_MAX past the last cell) and cells 11-14
+// in the voltages_ext extension (0 when absent). Autopilots without cell
+// monitoring report the pack voltage as the first cell, which is dropped.
+func parseCellVoltages(p []byte) []float64 {
+	le := binary.LittleEndian
+	var cells []float64
+	for i := 0; i < 10; i++ {
+		mv := le.Uint16(p[10+2*i:])
+		if mv == math.MaxUint16 {
+			return checkCellVoltages(cells)
+		}
+		cells = append(cells, float64(mv)/1000)
+	}
+	for offset := 41; offset < 49 && offset+2 <= len(p); offset += 2 {
+		mv := le.Uint16(p[offset:])
+		if mv == 0 || mv == math.MaxUint16 {
+			break
+		}
+		cells = append(cells, float64(mv)/1000)
+	}
+	return checkCellVoltages(cells)
+}
+
+// checkCellVoltages drops readings that can't be single cells
+func checkCellVoltages(cells []float64) []float64 {
+	for _, cell := range cells {
+		if cell > maxCellVoltage {
+			return nil
+		}
+	}
+	return cells
+}
+
+// GNSSTime returns the GNSS time of the latest SYSTEM_TIME
+func (b *sitlBackend) GNSSTime() (time.Time, time.Time, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.fresh(mavlinkSystemTime) || b.state.gnssTime.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	return b.state.gnssTime, b.state.updated[mavlinkSystemTime], true
+}
+
+// Flight returns mode, arming state and attitude of the selected vehicle
+func (b *sitlBackend) Flight() (*models.FlightData, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.fresh(mavlinkHeartbeat) {
+		return nil, b.staleError("HEARTBEAT")
+	}
+	s := b.state
+
+	flight := &models.FlightData{
+		Armed: s.armed,
+		Mode:  flightMode(s.autopilot, s.customMode),
+	}
+	if b.fresh(mavlinkGlobalPosInt) {
+		flight.Altitude = s.relativeAlt
+		flight.VerticalSpeed = -s.vz
+	}
+	if b.fresh(mavlinkAttitude) {
+		flight.RollAngle = s.roll
+		flight.PitchAngle = s.pitch
+		flight.YawAngle = s.yaw
+	}
+	flight.IsFlying = flight.Armed && flight.Altitude > 0.5
+
+	return flight, nil
+}
+
+// Pressure returns the static pressure from SCALED_PRESSURE
+func (b *sitlBackend) Pressure() (float64, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.fresh(mavlinkScaledPress) || b.state.pressure <= 0 {
+		return 0, false
+	}
+	return b.state.pressure, true
+}
+
+// flightMode maps ArduCopter and PX4 custom modes to FlightMode constants
+func flightMode(autopilot uint8, customMode uint32) string {
+	switch autopilot {
+	case mavAutopilotArduPilot:
+		switch customMode {
+		case 0:
+			return models.FlightModeStabilize
+		case 1:
+			return models.FlightModeManual
+		case 2:
+			return models.FlightModeAltitudeHold
+		case 3:
+			return models.FlightModeAuto
+		case 4:
+			return models.FlightModeGuided
+		case 5:
+			return models.FlightModeLoiter
+		case 6:
+			return models.FlightModeRTL
+		case 9:
+			return models.FlightModeLand
+		case 16:
+			return models.FlightModePositionHold
+		}
+	case mavAutopilotPX4:
+		mainMode := (customMode >> 16) & 0xFF
+		subMode := (customMode >> 24) & 0xFF
+		switch mainMode {
+		case 1:
+			return models.FlightModeManual
+		case 2:
+			return models.FlightModeAltitudeHold
+		case 3:
+			return models.FlightModePositionHold
+		case 4:
+			switch subMode {
+			case 3:
+				return models.FlightModeLoiter
+			case 5:
+				return models.FlightModeRTL
+			case 6:
+				return models.FlightModeLand
+			}
+			return models.FlightModeAuto
+		case 6:
+			return models.FlightModeGuided
+		case 7:
+			return models.FlightModeStabilize
+		}
+	}
+	return models.FlightModeUnknown
+}
+
+// Diagnostics reports the SYS_STATUS sensor bitmasks and link ages
+func (b *sitlBackend) Diagnostics() *models.DiagnosticsData {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	diag := &models.DiagnosticsData{Backend: BackendSITL}
+	if b.state == nil {
+		return diag
+	}
+	s := b.state
+
+	gpsUpdated := s.updated[mavlinkGPSRawInt]
+	if gpsUpdated.IsZero() {
+		gpsUpdated = s.updated[mavlinkGlobalPosInt]
+	}
+	diag.GPSMessageAge = ageSeconds(gpsUpdated)
+	diag.HeartbeatAge = ageSeconds(s.updated[mavlinkHeartbeat])
+
+	if !b.fresh(mavlinkSysStatus) {
+		return diag
+	}
+	for _, sensor := range mavSensors {
+		present := s.sensorsPresent&sensor.bit != 0
+		if present && s.sensorsEnabled&sensor.bit == 0 {
+			continue // fitted but disabled by parameters
+		}
+		status := models.SensorStatus{
+			Name:    sensor.name,
+			Present: present,
+			Healthy: present && s.sensorsHealth&sensor.bit != 0,
+		}
+		if sensor.calibration && present {
+			calibrated := status.Healthy
+			status.Calibrated = &calibrated
+		}
+		diag.Sensors = append(diag.Sensors, status)
+	}
+
+	return diag
+}
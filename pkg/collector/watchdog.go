@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// Collection steps besides the sections that read a data source
+const (
+	sectionAltitude    = "altitude"
+	sectionDiagnostics = "diagnostics"
+)
+
+// sourceWatchdog records the collection step in progress, so the agent can
+// tell which data source blocks collection, and the data source to
+// reinitialize before the next collection
+type sourceWatchdog struct {
+	mu          sync.Mutex
+	section     string // empty while no data source is read
+	since       time.Time
+	reinit      map[string]bool
+	stopBackend context.CancelFunc // stops the running backend, nil before Start
+}
+
+func (w *sourceWatchdog) enter(section string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.section, w.since = section, time.Now()
+}
+
+func (w *sourceWatchdog) leave() {
+	w.enter("")
+}
+
+// Busy returns the collection step reading a data source and when it
+// started, an empty section while none is. It is safe to call concurrently
+// with CollectMetrics.
+func (c *Collector) Busy() (string, time.Time) {
+	c.watchdog.mu.Lock()
+	defer c.watchdog.mu.Unlock()
+	return c.watchdog.section, c.watchdog.since
+}
+
+// Interrupt reinitializes the data source of a blocked collection step. A
+// backend is stopped right away, which closes its connection and may unblock
+// the step; the data source is replaced before the next collection. It is
+// safe to call concurrently with CollectMetrics.
+func (c *Collector) Interrupt(section string) {
+	c.watchdog.mu.Lock()
+	defer c.watchdog.mu.Unlock()
+	if c.watchdog.reinit == nil {
+		c.watchdog.reinit = make(map[string]bool)
+	}
+	c.watchdog.reinit[section] = true
+	if backendSection(section) && c.watchdog.stopBackend != nil {
+		c.watchdog.stopBackend()
+	}
+}
+
+// backendSection reports whether a collection step reads the telemetry
+// backend
+func backendSection(section string) bool {
+	switch section {
+	case models.SectionGPS, models.SectionBattery, models.SectionFlight, sectionAltitude, sectionDiagnostics:
+		return true
+	}
+	return false
+}
+
+// startBackend runs the configured backend until it is interrupted or the
+// context passed to Start is cancelled
+func (c *Collector) startBackend() {
+	ctx, cancel := context.WithCancel(c.runCtx)
+	c.watchdog.mu.Lock()
+	c.watchdog.stopBackend = cancel
+	c.watchdog.mu.Unlock()
+	go c.configured.Run(ctx)
+}
+
+// reinitialize replaces the data sources interrupted by the watchdog
+func (c *Collector) reinitialize() {
+	c.watchdog.mu.Lock()
+	reinit := c.watchdog.reinit
+	c.watchdog.reinit = nil
+	c.watchdog.mu.Unlock()
+
+	backend := false
+	for section := range reinit {
+		backend = backend || backendSection(section)
+	}
+	if backend && c.configured != nil {
+		c.configured = newTelemetryBackend(c.config)
+		c.backend = c.activeBackend()
+		if c.runCtx != nil {
+			c.startBackend()
+		}
+	}
+	if reinit[models.SectionNetwork] && c.modem != nil {
+		c.modem = newModemReader(c.config.Modem)
+	}
+}
@@ -2,8 +2,17 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path"
+	"strconv"
+	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // Config holds the configuration for the UAV agent
@@ -19,6 +28,72 @@ type Config struct {
 
 	// UAV metadata
 	UAVMetadata UAVMetadataConfig `json:"uavMetadata"`
+
+	// Remote ID broadcast
+	RemoteID RemoteIDConfig `json:"remoteID"`
+
+	// MQTT telemetry publishing
+	MQTT MQTTConfig `json:"mqtt"`
+
+	// Destinations published samples are fanned out to
+	Sinks SinkConfig `json:"sinks"`
+
+	// Webhook notifications of critical health
+	Alert AlertConfig `json:"alert"`
+
+	// Local on-disk storage
+	Storage StorageConfig `json:"storage"`
+
+	// Envelope encryption of sensitive CRD fields
+	FieldEncryption FieldEncryptionConfig `json:"fieldEncryption"`
+
+	// ROS 2 telemetry backend
+	ROS2 ROS2Config `json:"ros2"`
+
+	// DroneCAN telemetry backend
+	DroneCAN DroneCANConfig `json:"droneCAN"`
+
+	// ArduPilot/PX4 SITL telemetry backend
+	SITL SITLConfig `json:"sitl"`
+
+	// Cellular modem signal metrics
+	Modem ModemConfig `json:"modem"`
+
+	// Active bandwidth measurement
+	BandwidthProbe BandwidthProbeConfig `json:"bandwidthProbe"`
+
+	// Active latency and packet loss measurement
+	LatencyProbe LatencyProbeConfig `json:"latencyProbe"`
+
+	// Low-power collection mode
+	PowerSave PowerSaveConfig `json:"powerSave"`
+
+	// Regional aggregation proxy for large fleets
+	Aggregator AggregatorConfig `json:"aggregator"`
+
+	// Fleet snapshot cache for schedulers and routers in hub clusters
+	FleetCache FleetCacheConfig `json:"fleetCache"`
+
+	// OpenTelemetry export of the agent's own metrics and spans
+	OpenTelemetry OpenTelemetryConfig `json:"openTelemetry"`
+
+	// Retention policies of telemetry data
+	Retention RetentionConfig `json:"retention"`
+
+	// Fleet enrollment of vehicles
+	Enrollment EnrollmentConfig `json:"enrollment"`
+
+	// Decommissioning of retired vehicles
+	Decommission DecommissionConfig `json:"decommission"`
+
+	// Fleet snapshot export for offline analysis
+	Export ExportConfig `json:"export"`
+
+	// CRD conversion webhook between the UAVMetrics versions
+	ConversionWebhook ConversionWebhookConfig `json:"conversionWebhook"`
+
+	// Vehicles whose telemetry this node proxies (empty: the node is the vehicle)
+	Vehicles []VehicleConfig `json:"vehicles,omitempty"`
 }
 
 // AgentConfig contains agent-specific settings
@@ -34,6 +109,68 @@ type AgentConfig struct {
 
 	// Enable structured logging
 	StructuredLogging bool `json:"structuredLogging"`
+
+	// File on the node logs are also written to (empty disables), so they
+	// survive losing the connection to the log shipper
+	LogFile string `json:"logFile,omitempty"`
+
+	// Size in MB at which the log file is rotated (0 for no limit)
+	LogFileMaxSize int `json:"logFileMaxSize"`
+
+	// Age at which the log file is rotated and rotated files are removed
+	// (0 for no limit)
+	LogFileMaxAge time.Duration `json:"logFileMaxAge"`
+
+	// Number of rotated log files kept (0 for no limit)
+	LogFileMaxBackups int `json:"logFileMaxBackups"`
+
+	// Ground node proxying this vehicle's telemetry (empty when the node is the vehicle)
+	GroundNode string `json:"groundNode,omitempty"`
+
+	// How often the config file is checked for changes (0 disables; SIGHUP
+	// always reloads)
+	ConfigReloadInterval time.Duration `json:"configReloadInterval"`
+
+	// Resync period of the watch of the vehicle's UAVAgentConfig, the
+	// desired configuration set by operators; a rejected spec is retried
+	// this often (0 disables the watch)
+	DesiredStateInterval time.Duration `json:"desiredStateInterval"`
+
+	// Address of the /healthz and /readyz endpoints (empty disables)
+	HealthListen string `json:"healthListen"`
+
+	// Address of the /metrics endpoint serving the agent's Kubernetes API
+	// call metrics, always on regardless of the Prometheus sink
+	MetricsListen string `json:"metricsListen"`
+
+	// A collection cycle running longer than this fails /healthz, so the
+	// kubelet restarts a hung agent. Must exceed the time a cycle can spend
+	// retrying the API server (kubernetes.retryTimeout).
+	HealthStallTimeout time.Duration `json:"healthStallTimeout"`
+
+	// Number of collection intervals a data source may block collection
+	// (hung hardware, blocked serial read) before it is reinitialized. When
+	// it is still blocked after as many intervals more, the agent exits with
+	// a distinct code so Kubernetes restarts it (0 disables).
+	WatchdogIntervals int `json:"watchdogIntervals"`
+
+	// Address of the local REST API serving the latest metrics to on-board
+	// applications (empty disables). Metrics are served unsealed, so bind it
+	// to a local address.
+	APIListen string `json:"apiListen"`
+
+	// Number of recent samples per vehicle kept for /api/v1/history on the
+	// local REST API (0 disables)
+	HistorySize int `json:"historySize"`
+
+	// Address of the gRPC telemetry service streaming every collected sample
+	// (empty disables). Samples are unsealed, like the local REST API.
+	GRPCListen string `json:"grpcListen"`
+
+	// Collect, log and serve metrics locally without ever writing to the
+	// Kubernetes API (UAVMetrics, status, Events, Node labels and
+	// conditions, enrollment) or reporting to the aggregator
+	DryRun bool `json:"dryRun"`
 }
 
 // K8sConfig contains Kubernetes client settings
@@ -47,17 +184,131 @@ type K8sConfig struct {
 	// CRD name
 	CRDName string `json:"crdName"`
 
-	// CRD Group
+	// CRD Group and Version. UAVMetrics are accessed through the generated
+	// clientset, which only serves uav.k3s.io/v1alpha1.
 	CRDGroup string `json:"crdGroup"`
 
 	// CRD Version
 	CRDVersion string `json:"crdVersion"`
 
+	// Install or upgrade the UAVMetrics CRD at startup and wait until it's
+	// served, so fresh clusters work without applying its manifest. Only the
+	// cluster components (scheduler, aggregator) install it; the per-node
+	// agent and router ignore it.
+	InstallCRD bool `json:"installCRD"`
+
+	// Name of a node's UAV resources (UAVMetrics, UAVAgentConfig,
+	// UAVEnrollment), {node} being replaced with the node name. Names that
+	// aren't valid DNS-1123 subdomains are sanitized with a hash suffix.
+	NameTemplate string `json:"nameTemplate"`
+
+	// Server-side apply field manager owning the UAVMetrics spec, labels
+	// and power-save annotation written by the agent
+	FieldManager string `json:"fieldManager"`
+
+	// Labels the agent sets on its UAVMetrics besides app and node-name,
+	// e.g. fleet=alpha, for other components to select
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Restrict the UAVMetrics listed and watched in the namespace (e.g. by
+	// the router, the scheduler or the exporter) to a subset, e.g.
+	// fleet=alpha or metadata.name!=uav-test. Field selectors support
+	// metadata.name and metadata.namespace.
+	LabelSelector string `json:"labelSelector,omitempty"`
+	FieldSelector string `json:"fieldSelector,omitempty"`
+
 	// Update retry attempts
 	RetryAttempts int `json:"retryAttempts"`
 
 	// Retry delay
 	RetryDelay time.Duration `json:"retryDelay"`
+
+	// Upper bound of the exponential backoff between retries
+	RetryMaxDelay time.Duration `json:"retryMaxDelay"`
+
+	// How often the UAVMetrics informer (WatchUAVMetrics) redelivers every
+	// cached object to its handlers, 0 to never
+	InformerResync time.Duration `json:"informerResync"`
+
+	// How long transient errors (network, throttling, 5xx) keep being retried
+	// after RetryAttempts are used up
+	RetryTimeout time.Duration `json:"retryTimeout"`
+
+	// Additional API server URLs that slow reads are hedged to and failed
+	// connections fail over to
+	APIEndpoints []string `json:"apiEndpoints,omitempty"`
+
+	// Delay before a request without a response is hedged to the next API server
+	HedgeDelay time.Duration `json:"hedgeDelay"`
+
+	// How often API server endpoints are probed on /readyz to pick a healthy one
+	HealthCheckInterval time.Duration `json:"healthCheckInterval"`
+
+	// How long resolved API server addresses are cached (0 disables the cache)
+	DNSCacheTTL time.Duration `json:"dnsCacheTTL"`
+
+	// Also write an immutable UAVMetricsHistory snapshot of each vehicle at
+	// most this often, for in-cluster trend queries (0 disables snapshots).
+	// The janitor deletes them past the snapshots retention policy.
+	SnapshotInterval time.Duration `json:"snapshotInterval"`
+
+	// Record an Event on the UAVMetrics object when health status changes
+	HealthEvents bool `json:"healthEvents"`
+
+	// Also record health transition Events on the Node
+	HealthNodeEvents bool `json:"healthNodeEvents"`
+
+	// Events a component may record about one object at once, after which
+	// one more is allowed every EventInterval and the rest are dropped.
+	// Similar Events are aggregated into one with a count.
+	EventBurst    int           `json:"eventBurst"`
+	EventInterval time.Duration `json:"eventInterval"`
+
+	// Mirror battery bucket, health, geohash and connection type onto the
+	// Node as uav.k3s.io/* labels
+	NodeLabels bool `json:"nodeLabels"`
+
+	// Geohash length of the position label (5 is about 5km, 6 about 1km)
+	NodeLabelGeohashPrecision int `json:"nodeLabelGeohashPrecision"`
+
+	// Set the UAVBatteryLow and UAVCritical conditions on the Node and taint
+	// it NoSchedule on low battery
+	NodeConditions bool `json:"nodeConditions"`
+
+	// Battery percentage below which the UAVBatteryLow condition is True
+	NodeBatteryLowThreshold float64 `json:"nodeBatteryLowThreshold"`
+
+	// Battery percentage below which the Node is tainted NoSchedule (0 disables the taint)
+	NodeTaintBatteryThreshold float64 `json:"nodeTaintBatteryThreshold"`
+
+	// The taint is removed once battery rises this many points above the threshold
+	NodeTaintHysteresis float64 `json:"nodeTaintHysteresis"`
+
+	// Add the uav.k3s.io/cleanup finalizer to the UAVMetrics, so deleting
+	// it first removes the Node labels, taint and conditions above. The
+	// decommission controller runs the cleanup; without it deletions hang.
+	CleanupFinalizer bool `json:"cleanupFinalizer"`
+
+	// Elect, through a Lease per node, the single agent instance allowed to
+	// write, so two agent pods briefly coexisting during a rollout don't
+	// overwrite each other's updates
+	LeaderElection bool `json:"leaderElection"`
+
+	// Identity of this instance in the Lease (empty for the hostname, which
+	// is the pod name)
+	LeaderElectionIdentity string `json:"leaderElectionIdentity,omitempty"`
+
+	// How long a standby instance waits before taking over a Lease that
+	// isn't renewed, how long the holder keeps retrying to renew it, and the
+	// interval between attempts
+	LeaseDuration      time.Duration `json:"leaseDuration"`
+	LeaseRenewDeadline time.Duration `json:"leaseRenewDeadline"`
+	LeaseRetryPeriod   time.Duration `json:"leaseRetryPeriod"`
+
+	// How often the agent renews each vehicle's heartbeat Lease (0
+	// disables), and how long a renewal keeps the agent alive for IsAlive
+	HeartbeatInterval time.Duration `json:"heartbeatInterval"`
+	HeartbeatTimeout  time.Duration `json:"heartbeatTimeout"`
 }
 
 // CollectionConfig contains data collection settings
@@ -65,6 +316,14 @@ type CollectionConfig struct {
 	// Collection interval
 	Interval time.Duration `json:"interval"`
 
+	// Telemetry backend for GPS/battery/flight data (simulated, ros2, dronecan, sitl)
+	Backend string `json:"backend"`
+
+	// Serve simulated telemetry while the configured backend keeps running,
+	// e.g. for ground tests; unlike the backend it can be changed without a
+	// restart
+	Simulate bool `json:"simulate,omitempty"`
+
 	// GPS collection enabled
 	EnableGPS bool `json:"enableGPS"`
 
@@ -89,8 +348,99 @@ type CollectionConfig struct {
 	// Battery critical threshold
 	BatteryCriticalThreshold float64 `json:"batteryCriticalThreshold"`
 
-	// GPS minimum satellites
+	// Percentage points the battery must recover above the low or critical
+	// threshold before the warning or error clears
+	BatteryClearMargin float64 `json:"batteryClearMargin"`
+
+	// GPS minimum satellites, checked when the receiver does not report its fix type
 	GPSMinSatellites int `json:"gpsMinSatellites"`
+
+	// System clock skew from GNSS time above which health is Warning (0
+	// disables the check)
+	ClockSkewThreshold time.Duration `json:"clockSkewThreshold"`
+
+	// GPS filter: "none", "ema" or "kalman"
+	GPSFilter string `json:"gpsFilter"`
+
+	// EMA smoothing factor (0-1], higher follows new fixes more closely
+	GPSSmoothingFactor float64 `json:"gpsSmoothingFactor"`
+
+	// Kalman process noise in meters per second (expected movement)
+	GPSProcessNoise float64 `json:"gpsProcessNoise"`
+
+	// Fixes implying a speed above this (m/s) are rejected as outliers (0 disables)
+	GPSMaxSpeed float64 `json:"gpsMaxSpeed"`
+
+	// Nominal battery capacity in mAh, used for endurance estimation
+	BatteryCapacityMah float64 `json:"batteryCapacityMah"`
+
+	// Number of current-draw samples in the endurance estimator window
+	BatteryEstimatorWindow int `json:"batteryEstimatorWindow"`
+
+	// Battery chemistry (lipo, li-ion, solid-state), which sets the cell
+	// voltage curve used to estimate the charge from the voltage and the
+	// critical cell voltage
+	BatteryChemistry string `json:"batteryChemistry"`
+
+	// Number of cells in series (e.g. 6 for a 6S pack)
+	BatteryCells int `json:"batteryCells"`
+
+	// Spread between the highest and lowest cell voltage (V) above which
+	// health is Warning, for batteries reporting per-cell voltages (0
+	// disables the check)
+	CellImbalanceThreshold float64 `json:"cellImbalanceThreshold"`
+
+	// Configured home point; when both are zero the first valid GPS fix is used
+	HomeLatitude  float64 `json:"homeLatitude"`
+	HomeLongitude float64 `json:"homeLongitude"`
+
+	// Cruise speed (m/s) assumed when estimating the return-to-home cost
+	ReturnCruiseSpeed float64 `json:"returnCruiseSpeed"`
+
+	// Daily airborne time budget in minutes (0 means unlimited)
+	AirtimeBudgetMinutes float64 `json:"airtimeBudgetMinutes"`
+
+	// Sensor anomaly detection enabled
+	EnableAnomalyDetection bool `json:"enableAnomalyDetection"`
+
+	// Identical consecutive samples before a sensor is reported as stuck
+	AnomalyStuckSamples int `json:"anomalyStuckSamples"`
+
+	// Hardware diagnostics (sensor presence, calibration, link age) enabled
+	EnableDiagnostics bool `json:"enableDiagnostics"`
+
+	// Age after which a GPS or flight controller link is reported as stale
+	DiagnosticsStaleThreshold time.Duration `json:"diagnosticsStaleThreshold"`
+
+	// Receiver jamming indicator (0-100) at which GNSS jamming is suspected
+	GNSSJammingThreshold int `json:"gnssJammingThreshold"`
+
+	// Check GNSS fixes against dead reckoning and barometric altitude
+	EnableGNSSIntegrity bool `json:"enableGNSSIntegrity"`
+
+	// Horizontal divergence from dead reckoning tolerated, on top of fix accuracy (m)
+	GNSSDivergenceTolerance float64 `json:"gnssDivergenceTolerance"`
+
+	// Divergence between GNSS and barometric climb tolerated (m)
+	GNSSAltitudeTolerance float64 `json:"gnssAltitudeTolerance"`
+
+	// Consecutive divergent fixes before position quality is degraded
+	GNSSDivergenceSamples int `json:"gnssDivergenceSamples"`
+
+	// Time constant of the barometric altitude offset calibration against GNSS
+	AltitudeFusionTimeConstant time.Duration `json:"altitudeFusionTimeConstant"`
+
+	// Largest VDOP at which GNSS altitude is used to calibrate the barometer
+	AltitudeMaxVDOP float64 `json:"altitudeMaxVDOP"`
+
+	// Areas checked by the health check
+	Geofences []GeofenceConfig `json:"geofences,omitempty"`
+
+	// Distance from a geofence boundary at which the health check warns (m)
+	GeofenceWarningDistance float64 `json:"geofenceWarningDistance"`
+
+	// Threshold rules evaluated by the health check
+	HealthRules []HealthRule `json:"healthRules"`
 }
 
 // UAVMetadataConfig contains UAV hardware metadata
@@ -105,6 +455,542 @@ type UAVMetadataConfig struct {
 	SerialNumber string `json:"serialNumber"`
 }
 
+// RemoteIDConfig contains regulatory Remote ID publishing settings
+type RemoteIDConfig struct {
+	// Enable Remote ID publishing
+	Enabled bool `json:"enabled"`
+
+	// Message format: astm-f3411 (FAA) or en4709-002 (EU)
+	Format string `json:"format"`
+
+	// Destination: udp://host:port for a local broadcast daemon,
+	// http(s)://... for a network Remote ID service
+	Endpoint string `json:"endpoint"`
+
+	// UAS ID broadcast in the Basic ID message (defaults to the serial number)
+	UASID string `json:"uasID"`
+
+	// Kind of UAS ID: serial_number, caa_registration, utm_assigned_uuid
+	// or specific_session_id
+	IDType string `json:"idType"`
+
+	// Operator registration ID (mandatory for en4709-002)
+	OperatorID string `json:"operatorID"`
+
+	// Operator (ground station) location, if known
+	OperatorLatitude  float64 `json:"operatorLatitude"`
+	OperatorLongitude float64 `json:"operatorLongitude"`
+
+	// EU UA category and class (e.g. "open" / "C2")
+	Category string `json:"category"`
+	Class    string `json:"class"`
+
+	// Timeout for a single publish
+	Timeout time.Duration `json:"timeout"`
+}
+
+// MQTTConfig contains settings for publishing each sample to an MQTT broker
+type MQTTConfig struct {
+	// Enable MQTT publishing
+	Enabled bool `json:"enabled"`
+
+	// Broker URL: tcp://, ssl://, ws:// or wss://host:port
+	Broker string `json:"broker"`
+
+	// Topic, where {node} is replaced by the vehicle's node name
+	Topic string `json:"topic"`
+
+	// Quality of service (0, 1 or 2)
+	QoS int `json:"qos"`
+
+	// Retain the last sample on the broker for new subscribers
+	Retain bool `json:"retain"`
+
+	// Client ID (default uav-agent-<nodeName>)
+	ClientID string `json:"clientID"`
+
+	// Credentials; the password is read from a file, typically a mounted Secret
+	Username     string `json:"username"`
+	PasswordFile string `json:"passwordFile"`
+
+	// CA bundle to verify the broker, and client certificate and key for
+	// mutual TLS (ssl:// and wss:// brokers)
+	CAFile   string `json:"caFile"`
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+
+	// Skip verification of the broker certificate (testing only)
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+
+	// Timeout for a single publish
+	Timeout time.Duration `json:"timeout"`
+}
+
+// SinkConfig contains the destinations each published sample is fanned out
+// to, besides MQTT (see MQTTConfig). Samples are sealed like the CRD before
+// they reach any sink.
+type SinkConfig struct {
+	// Write samples to the UAVMetrics CRD, or report them to the aggregator
+	// when one is configured
+	CRDEnabled bool `json:"crdEnabled"`
+
+	// Skip CRD writes of samples that didn't change significantly since the
+	// last written one: battery within CRDMinBatteryChange points, position
+	// within CRDMinDistance meters, and the same health, flight state and
+	// failing sections. A sample is still written every CRDMaxSkipInterval
+	// so consumers don't consider the vehicle stale.
+	CRDChangeDetection  bool          `json:"crdChangeDetection"`
+	CRDMinBatteryChange float64       `json:"crdMinBatteryChange"`
+	CRDMinDistance      float64       `json:"crdMinDistance"` // meters
+	CRDMaxSkipInterval  time.Duration `json:"crdMaxSkipInterval"`
+
+	// Serve the latest sample of each vehicle in the Prometheus text format
+	PrometheusEnabled bool   `json:"prometheusEnabled"`
+	PrometheusListen  string `json:"prometheusListen"`
+
+	// Append samples as JSON lines to a file on the node, rotated by size
+	FileEnabled    bool   `json:"fileEnabled"`
+	FilePath       string `json:"filePath"`
+	FileMaxSize    int    `json:"fileMaxSize"` // MB, 0 for no limit
+	FileMaxBackups int    `json:"fileMaxBackups"`
+
+	// Attempts per sample of each sink other than the CRD (which retries
+	// per kubernetes.retry*), and the backoff between them
+	RetryAttempts int           `json:"retryAttempts"`
+	RetryDelay    time.Duration `json:"retryDelay"`
+	RetryMaxDelay time.Duration `json:"retryMaxDelay"`
+}
+
+// AlertConfig contains settings for webhook notifications sent when a
+// vehicle's health becomes Critical or specific health errors appear.
+// Alerting is enabled when any webhook URL is set.
+type AlertConfig struct {
+	// Webhooks receiving the alert as a JSON object
+	WebhookURLs []string `json:"webhookURLs,omitempty"`
+
+	// Slack incoming webhooks (or compatible, e.g. Mattermost) receiving a
+	// text message
+	SlackWebhookURLs []string `json:"slackWebhookURLs,omitempty"`
+
+	// Health error patterns (path.Match syntax, e.g. "Battery critically
+	// low*") alerted on even when health is not Critical
+	ErrorPatterns []string `json:"errorPatterns,omitempty"`
+
+	// Minimum time between two notifications of the same alert, so a
+	// flapping condition doesn't flood the receivers
+	Cooldown time.Duration `json:"cooldown"`
+
+	// Timeout for a single webhook request
+	Timeout time.Duration `json:"timeout"`
+}
+
+// Enabled reports whether any webhook is configured
+func (c AlertConfig) Enabled() bool {
+	return len(c.WebhookURLs) > 0 || len(c.SlackWebhookURLs) > 0
+}
+
+// StorageConfig contains settings for data the agent keeps on the node's disk
+type StorageConfig struct {
+	// Encrypt the telemetry kept on the node at rest: the recording
+	// database, the file sink and the home points. The log file is not
+	// encrypted.
+	EncryptAtRest bool `json:"encryptAtRest"`
+
+	// Path of the AES-256 key (raw, hex or base64); typically a mounted Secret
+	EncryptionKeyPath string `json:"encryptionKeyPath"`
+
+	// Generate a node-local key at EncryptionKeyPath if it does not exist
+	GenerateKey bool `json:"generateKey"`
+
+	// Path of the database recording every sample on the node (empty
+	// disables recording)
+	RecordingPath string `json:"recordingPath,omitempty"`
+
+	// Maximum number of samples recorded per vehicle; the oldest are dropped
+	RecordingMaxSamples int `json:"recordingMaxSamples"`
+
+	// Directory keeping each vehicle's home point (<node>.json) across
+	// restarts, since a sealed home can't be restored from the cluster
+	// (empty disables it)
+	HomeStateDir string `json:"homeStateDir,omitempty"`
+}
+
+// EnrollmentConfig contains settings for enrolling vehicles in the fleet.
+// Enabled and Dir are used by the agent, Required by readers of the fleet,
+// and the others by the enrollment controller.
+type EnrollmentConfig struct {
+	// Enroll before publishing telemetry
+	Enabled bool `json:"enabled"`
+
+	// Directory holding each vehicle's identity key (<node>.key), issued
+	// certificate (<node>.crt) and the fleet CA (ca.crt)
+	Dir string `json:"dir"`
+
+	// How often the agent checks whether its enrollment was decided, and
+	// whether its certificate is due for renewal
+	PollInterval time.Duration `json:"pollInterval"`
+
+	// Only list UAVMetrics of vehicles with an approved enrollment
+	Required bool `json:"required"`
+
+	// PEM encoded fleet CA certificate and key signing vehicle certificates;
+	// typically a mounted Secret
+	CACertPath string `json:"caCertPath"`
+	CAKeyPath  string `json:"caKeyPath"`
+
+	// Validity of issued certificates
+	CertValidity time.Duration `json:"certValidity"`
+
+	// Serial number patterns (path.Match syntax) approved without an
+	// operator; empty approves nothing automatically
+	AutoApproveSerials []string `json:"autoApproveSerials,omitempty"`
+
+	// Hardware models allowed by auto-approval (empty allows any)
+	AutoApproveModels []string `json:"autoApproveModels,omitempty"`
+
+	// How often the controller processes enrollments
+	Interval time.Duration `json:"interval"`
+}
+
+// DecommissionConfig contains settings of the decommission controller
+type DecommissionConfig struct {
+	// Directory receiving one archive per decommissioned vehicle; typically
+	// a mounted PersistentVolume
+	ArchiveDir string `json:"archiveDir"`
+
+	// Port of the agent's local API on its pod IP, to archive the vehicle's
+	// history and recording (0 skips them; the agent's API_LISTEN must not
+	// be a loopback address)
+	AgentAPIPort int `json:"agentAPIPort"`
+
+	// Label selector of the agent pods in kubernetes.namespace
+	AgentSelector string `json:"agentSelector"`
+
+	// Timeout of each request to the agent's API
+	AgentAPITimeout time.Duration `json:"agentAPITimeout"`
+
+	// How often the controller advances decommissions
+	Interval time.Duration `json:"interval"`
+
+	// Registry repository the checkpoint images of migrated pods are pushed
+	// to, e.g. registry.local:5000/checkpoints (empty disables migration:
+	// all pods are evicted)
+	CheckpointRegistry string `json:"checkpointRegistry,omitempty"`
+
+	// Image of the jobs building checkpoint images; must provide buildah
+	CheckpointBuilderImage string `json:"checkpointBuilderImage"`
+
+	// kubernetes.io/dockerconfigjson Secret in kubernetes.namespace holding
+	// the credentials to push to the checkpoint registry (empty: none)
+	CheckpointPushSecret string `json:"checkpointPushSecret,omitempty"`
+
+	// Timeout of the kubelet checkpointing one container
+	CheckpointTimeout time.Duration `json:"checkpointTimeout"`
+}
+
+// Fleet snapshot export formats
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatParquet = "parquet"
+)
+
+// ExportConfig contains settings of the fleet snapshot exporter
+type ExportConfig struct {
+	// File the snapshots are written to
+	Output string `json:"output"`
+
+	// csv or parquet; empty picks it from the output file's extension
+	Format string `json:"format,omitempty"`
+
+	// Time between snapshots; snapshots are aligned to multiples of it
+	Interval time.Duration `json:"interval"`
+
+	// How long to export (0 exports until stopped)
+	Duration time.Duration `json:"duration"`
+
+	// Scheduler whose pod placements are exported
+	SchedulerName string `json:"schedulerName"`
+}
+
+// ExportFormat returns the format of the export, from the output file's
+// extension when not set
+func (c ExportConfig) ExportFormat() string {
+	if c.Format != "" {
+		return c.Format
+	}
+	if strings.EqualFold(path.Ext(c.Output), ".parquet") {
+		return ExportFormatParquet
+	}
+	return ExportFormatCSV
+}
+
+// ConversionWebhookConfig contains settings for the conversion webhook
+// the API server calls to convert UAVMetrics between v1alpha1 and v1beta1
+type ConversionWebhookConfig struct {
+	// Address on which the webhook serves HTTPS
+	Listen string `json:"listen"`
+
+	// Serving certificate and key; the certificate must be valid for the
+	// webhook's Service and signed by the CRD's caBundle
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+
+	// PEM encoded CA that signed the serving certificate, written into the
+	// CRD's caBundle; the certificate itself is used when the file doesn't
+	// exist (self-signed)
+	CAFile string `json:"caFile"`
+
+	// namespace/name of the webhook's Service. The webhook points the
+	// UAVMetrics CRD's conversion at it; empty leaves the conversion alone.
+	Service string `json:"service"`
+}
+
+// FieldEncryptionConfig contains settings for sealing sensitive UAVMetrics fields
+type FieldEncryptionConfig struct {
+	// Enable envelope encryption of sensitive fields
+	Enabled bool `json:"enabled"`
+
+	// PEM encoded RSA fleet public key
+	PublicKeyPath string `json:"publicKeyPath"`
+
+	// Fields to seal (metadata.serialNumber, home.location)
+	Fields []string `json:"fields"`
+}
+
+// ROS2Config contains settings for the ROS 2 telemetry backend.
+// Topics are read through a rosbridge websocket server; an empty topic disables it.
+type ROS2Config struct {
+	// rosbridge websocket URL
+	BridgeURL string `json:"bridgeURL"`
+
+	// sensor_msgs/NavSatFix topic
+	NavSatFixTopic string `json:"navSatFixTopic"`
+
+	// sensor_msgs/BatteryState topic
+	BatteryTopic string `json:"batteryTopic"`
+
+	// mavros_msgs/State topic
+	StateTopic string `json:"stateTopic"`
+
+	// sensor_msgs/Imu topic used for attitude
+	ImuTopic string `json:"imuTopic"`
+
+	// geometry_msgs/TwistStamped topic used for ground and vertical speed
+	VelocityTopic string `json:"velocityTopic"`
+
+	// std_msgs/Float64 relative altitude topic
+	RelAltTopic string `json:"relAltTopic"`
+
+	// std_msgs/Float64 compass heading topic
+	HeadingTopic string `json:"headingTopic"`
+
+	// mavros_msgs/GPSRAW topic used for fix type and DOP
+	GPSRawTopic string `json:"gpsRawTopic"`
+
+	// sensor_msgs/FluidPressure static pressure topic used for barometric altitude
+	PressureTopic string `json:"pressureTopic"`
+
+	// sensor_msgs/TimeReference topic with the GNSS time, used to detect
+	// system clock skew
+	TimeRefTopic string `json:"timeRefTopic"`
+
+	// Messages older than this are treated as missing
+	StaleTimeout time.Duration `json:"staleTimeout"`
+}
+
+// DroneCANConfig contains settings for the DroneCAN (UAVCAN v0) telemetry backend
+type DroneCANConfig struct {
+	// SocketCAN interface connected to the vehicle bus
+	Interface string `json:"interface"`
+
+	// Messages older than this are treated as missing
+	StaleTimeout time.Duration `json:"staleTimeout"`
+}
+
+// SITLConfig contains settings for reading MAVLink from an ArduPilot/PX4 SITL instance
+type SITLConfig struct {
+	// tcp://host:port, udp://host:port, or udp://:port to listen for SITL output
+	Endpoint string `json:"endpoint"`
+
+	// MAVLink system ID of this node's vehicle (0 uses the first vehicle seen)
+	SystemID int `json:"systemID"`
+
+	// Derive the system ID from the trailing number of the node name
+	// (uav-node-3 -> 3) when one SITL endpoint serves several nodes
+	SystemIDFromNode bool `json:"systemIDFromNode"`
+
+	// Messages older than this are treated as missing
+	StaleTimeout time.Duration `json:"staleTimeout"`
+}
+
+// ModemConfig contains settings for reading the cellular modem
+type ModemConfig struct {
+	// none (simulated signal), at (direct AT port) or modemmanager (mmcli)
+	Source string `json:"source"`
+
+	// AT command port, e.g. /dev/ttyUSB2 on Quectel modems
+	Device string `json:"device"`
+
+	// ModemManager modem index or path ("any" for the first modem)
+	ModemID string `json:"modemID"`
+
+	// Time allowed for one modem query
+	Timeout time.Duration `json:"timeout"`
+}
+
+// BandwidthProbeConfig contains settings for the periodic bandwidth probe
+type BandwidthProbeConfig struct {
+	// http(s):// URL to download from, or host:port of another agent's probe
+	// server (empty disables the probe and bandwidth stays simulated)
+	Target string `json:"target,omitempty"`
+
+	// Bytes transferred per probe
+	Size int `json:"size"`
+
+	// Time between probes
+	Interval time.Duration `json:"interval"`
+
+	// Time allowed for one probe
+	Timeout time.Duration `json:"timeout"`
+
+	// Address on which to serve probes for other nodes, e.g. :5201 (empty disables)
+	Listen string `json:"listen,omitempty"`
+
+	// File holding the shared token sent with probes to another agent's
+	// probe server and required of the probes this agent serves; typically
+	// a mounted Secret
+	TokenFile string `json:"tokenFile,omitempty"`
+
+	// Probes per minute the probe server answers; the rest are refused so
+	// other nodes can't saturate the radio link
+	ServeRate int `json:"serveRate"`
+}
+
+// LatencyProbeConfig contains settings for the UDP latency and packet loss probe
+type LatencyProbeConfig struct {
+	// host:port of another agent's latency probe server (empty disables the
+	// probe and latency/packet loss stay simulated)
+	Target string `json:"target,omitempty"`
+
+	// Time between probes
+	Interval time.Duration `json:"interval"`
+
+	// Probes unanswered for longer than this are counted as lost
+	Timeout time.Duration `json:"timeout"`
+
+	// Number of recent probes latency and packet loss are computed over
+	Window int `json:"window"`
+
+	// UDP address on which to echo probes for other nodes, e.g. :5202 (empty disables)
+	Listen string `json:"listen,omitempty"`
+}
+
+// PowerSaveConfig contains settings for the low-power collection mode, in
+// which metrics are collected less often and the bandwidth and latency
+// probes are paused
+type PowerSaveConfig struct {
+	// Save power regardless of the battery
+	Enabled bool `json:"enabled"`
+
+	// Remaining battery percentage at or below which power saving starts
+	// while disarmed on the ground (0 disables); it stops once the battery
+	// recovers 5 points above it or the vehicle arms
+	BatteryThreshold float64 `json:"batteryThreshold"`
+
+	// Collection interval while saving power
+	Interval time.Duration `json:"interval"`
+}
+
+// AggregatorConfig contains settings for the regional aggregation proxy.
+// Address is used by agents; the other settings by the aggregator itself.
+type AggregatorConfig struct {
+	// host:port of the aggregator agents send samples to instead of writing
+	// the CRD themselves (empty writes directly to the API server)
+	Address string `json:"address,omitempty"`
+
+	// Timeout of one report to the aggregator
+	Timeout time.Duration `json:"timeout"`
+
+	// CA verifying the aggregator's certificate; vehicles report with their
+	// enrollment certificate (empty: the fleet CA)
+	CAFile string `json:"caFile,omitempty"`
+
+	// Address on which the aggregator serves agents
+	Listen string `json:"listen"`
+
+	// Server certificate of the aggregator (required). Agents must present
+	// their enrollment certificate (verified against enrollment.caCertPath)
+	// and may only report the vehicle it was issued for.
+	TLSCertFile string `json:"tlsCertFile,omitempty"`
+	TLSKeyFile  string `json:"tlsKeyFile,omitempty"`
+
+	// Plaintext address serving only the gRPC health service, for probes
+	HealthListen string `json:"healthListen"`
+
+	// How often the aggregator writes the latest sample of each vehicle;
+	// samples received in between replace each other
+	FlushInterval time.Duration `json:"flushInterval"`
+
+	// Maximum number of writes in flight to the API server
+	Concurrency int `json:"concurrency"`
+
+	// Field manager of the server-side apply writes
+	FieldManager string `json:"fieldManager"`
+}
+
+// FleetCacheConfig contains settings for the fleet snapshot cache. Address
+// is used by schedulers and routers; the other settings by the cache itself.
+type FleetCacheConfig struct {
+	// host:port of the cache to read the fleet from instead of listing
+	// UAVMetrics on the API server (empty lists directly)
+	Address string `json:"address,omitempty"`
+
+	// CA verifying the cache's certificate (empty: the system roots)
+	CAFile string `json:"caFile,omitempty"`
+
+	// Address on which the cache serves subscribers
+	Listen string `json:"listen"`
+
+	// Server certificate of the cache (required); subscribers connect over
+	// TLS only
+	TLSCertFile string `json:"tlsCertFile,omitempty"`
+	TLSKeyFile  string `json:"tlsKeyFile,omitempty"`
+
+	// Plaintext address serving only the gRPC health service, for probes
+	HealthListen string `json:"healthListen"`
+
+	// How long the cache collects UAVMetrics changes from its watch into
+	// one version
+	RefreshInterval time.Duration `json:"refreshInterval"`
+
+	// Number of deltas kept for subscribers resuming after a disconnect;
+	// older subscribers receive a full snapshot instead
+	History int `json:"history"`
+}
+
+// OpenTelemetryConfig contains settings for exporting the agent's collection
+// pipeline metrics and spans over OTLP
+type OpenTelemetryConfig struct {
+	// Collector URL, e.g. http://otel-collector:4318 (empty disables export);
+	// http:// connects without TLS
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// grpc or http/protobuf
+	Protocol string `json:"protocol"`
+
+	// Headers sent with every export, e.g. for authentication
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Service name reported in the resource
+	ServiceName string `json:"serviceName"`
+
+	// Fraction of collection cycles traced (parent-based trace ID ratio)
+	SampleRatio float64 `json:"sampleRatio"`
+
+	// How often metrics are exported
+	MetricInterval time.Duration `json:"metricInterval"`
+}
+
 // DefaultConfig returns a default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -113,6 +999,21 @@ func DefaultConfig() *Config {
 			Version:           "v0.1.0",
 			LogLevel:          getEnvOrDefault("LOG_LEVEL", "info"),
 			StructuredLogging: true,
+			LogFile:           getEnvOrDefault("LOG_FILE", ""),
+			LogFileMaxSize:    getEnvIntOrDefault("LOG_FILE_MAX_SIZE", 10),
+			LogFileMaxAge:     getEnvDurationOrDefault("LOG_FILE_MAX_AGE", 7*24*time.Hour),
+			LogFileMaxBackups: getEnvIntOrDefault("LOG_FILE_MAX_BACKUPS", 5),
+
+			ConfigReloadInterval: getEnvDurationOrDefault("CONFIG_RELOAD_INTERVAL", 10*time.Second),
+			DesiredStateInterval: getEnvDurationOrDefault("DESIRED_STATE_INTERVAL", 30*time.Second),
+			HealthListen:         getEnvOrDefault("HEALTH_LISTEN", ""),
+			MetricsListen:        getEnvOrDefault("METRICS_LISTEN", ":9103"),
+			HealthStallTimeout:   getEnvDurationOrDefault("HEALTH_STALL_TIMEOUT", 5*time.Minute),
+			WatchdogIntervals:    getEnvIntOrDefault("WATCHDOG_INTERVALS", 5),
+			APIListen:            getEnvOrDefault("API_LISTEN", ""),
+			HistorySize:          getEnvIntOrDefault("HISTORY_SIZE", 360),
+			GRPCListen:           getEnvOrDefault("GRPC_LISTEN", ""),
+			DryRun:               getEnvBoolOrDefault("DRY_RUN", false),
 		},
 		Kubernetes: K8sConfig{
 			KubeconfigPath: getEnvOrDefault("KUBECONFIG", ""),
@@ -120,26 +1021,284 @@ func DefaultConfig() *Config {
 			CRDName:        "uavmetrics.uav.k3s.io",
 			CRDGroup:       "uav.k3s.io",
 			CRDVersion:     "v1alpha1",
+			InstallCRD:     getEnvBoolOrDefault("INSTALL_CRD", false),
+			NameTemplate:   getEnvOrDefault("UAV_NAME_TEMPLATE", "uav-{node}"),
+			FieldManager:   getEnvOrDefault("K8S_FIELD_MANAGER", "uav-agent"),
+			Labels:         parseHeaders(getEnvListOrDefault("UAV_LABELS", nil)),
+			LabelSelector:  getEnvOrDefault("UAV_LABEL_SELECTOR", ""),
+			FieldSelector:  getEnvOrDefault("UAV_FIELD_SELECTOR", ""),
 			RetryAttempts:  3,
 			RetryDelay:     2 * time.Second,
+			RetryMaxDelay:  getEnvDurationOrDefault("K8S_RETRY_MAX_DELAY", 30*time.Second),
+			InformerResync: getEnvDurationOrDefault("K8S_INFORMER_RESYNC", 30*time.Second),
+			RetryTimeout:   getEnvDurationOrDefault("K8S_RETRY_TIMEOUT", 2*time.Minute),
+			APIEndpoints:   getEnvListOrDefault("API_SERVER_ENDPOINTS", nil),
+			HedgeDelay:     getEnvDurationOrDefault("API_HEDGE_DELAY", time.Second),
+			DNSCacheTTL:    getEnvDurationOrDefault("DNS_CACHE_TTL", 5*time.Minute),
+
+			HealthCheckInterval: getEnvDurationOrDefault("API_HEALTH_CHECK_INTERVAL", 10*time.Second),
+			SnapshotInterval:    getEnvDurationOrDefault("UAV_SNAPSHOT_INTERVAL", 0),
+			HealthEvents:        getEnvBoolOrDefault("HEALTH_EVENTS", true),
+			HealthNodeEvents:    getEnvBoolOrDefault("HEALTH_NODE_EVENTS", false),
+			EventBurst:          getEnvIntOrDefault("EVENT_BURST", 25),
+			EventInterval:       getEnvDurationOrDefault("EVENT_INTERVAL", 5*time.Minute),
+			NodeLabels:          getEnvBoolOrDefault("NODE_LABELS", false),
+
+			NodeLabelGeohashPrecision: getEnvIntOrDefault("NODE_LABEL_GEOHASH_PRECISION", 5),
+			NodeConditions:            getEnvBoolOrDefault("NODE_CONDITIONS", false),
+			NodeBatteryLowThreshold:   getEnvFloatOrDefault("NODE_BATTERY_LOW_THRESHOLD", 30),
+			NodeTaintBatteryThreshold: getEnvFloatOrDefault("NODE_TAINT_BATTERY_THRESHOLD", 20),
+			NodeTaintHysteresis:       getEnvFloatOrDefault("NODE_TAINT_HYSTERESIS", 5),
+			CleanupFinalizer:          getEnvBoolOrDefault("CLEANUP_FINALIZER", false),
+
+			LeaderElection:         getEnvBoolOrDefault("LEADER_ELECTION", true),
+			LeaderElectionIdentity: getEnvOrDefault("POD_NAME", ""),
+			LeaseDuration:          getEnvDurationOrDefault("LEASE_DURATION", 15*time.Second),
+			LeaseRenewDeadline:     getEnvDurationOrDefault("LEASE_RENEW_DEADLINE", 10*time.Second),
+			LeaseRetryPeriod:       getEnvDurationOrDefault("LEASE_RETRY_PERIOD", 2*time.Second),
+
+			HeartbeatInterval: getEnvDurationOrDefault("HEARTBEAT_INTERVAL", 10*time.Second),
+			HeartbeatTimeout:  getEnvDurationOrDefault("HEARTBEAT_TIMEOUT", 40*time.Second),
 		},
 		Collection: CollectionConfig{
-			Interval:                 getEnvDurationOrDefault("COLLECTION_INTERVAL", 10*time.Second),
-			EnableGPS:                getEnvBoolOrDefault("ENABLE_GPS", true),
-			EnableBattery:            getEnvBoolOrDefault("ENABLE_BATTERY", true),
-			EnableFlight:             getEnvBoolOrDefault("ENABLE_FLIGHT", true),
-			EnableNetwork:            getEnvBoolOrDefault("ENABLE_NETWORK", true),
-			EnablePerformance:        getEnvBoolOrDefault("ENABLE_PERFORMANCE", true),
-			EnableHealthCheck:        getEnvBoolOrDefault("ENABLE_HEALTH_CHECK", true),
-			BatteryLowThreshold:      30.0,
-			BatteryCriticalThreshold: 20.0,
-			GPSMinSatellites:         4,
+			Interval:                   getEnvDurationOrDefault("COLLECTION_INTERVAL", 10*time.Second),
+			Backend:                    getEnvOrDefault("TELEMETRY_BACKEND", "simulated"),
+			Simulate:                   getEnvBoolOrDefault("SIMULATE", false),
+			EnableGPS:                  getEnvBoolOrDefault("ENABLE_GPS", true),
+			EnableBattery:              getEnvBoolOrDefault("ENABLE_BATTERY", true),
+			EnableFlight:               getEnvBoolOrDefault("ENABLE_FLIGHT", true),
+			EnableNetwork:              getEnvBoolOrDefault("ENABLE_NETWORK", true),
+			EnablePerformance:          getEnvBoolOrDefault("ENABLE_PERFORMANCE", true),
+			EnableHealthCheck:          getEnvBoolOrDefault("ENABLE_HEALTH_CHECK", true),
+			BatteryLowThreshold:        30.0,
+			BatteryCriticalThreshold:   20.0,
+			BatteryClearMargin:         getEnvFloatOrDefault("BATTERY_CLEAR_MARGIN", 5),
+			GPSMinSatellites:           4,
+			ClockSkewThreshold:         getEnvDurationOrDefault("CLOCK_SKEW_THRESHOLD", 2*time.Second),
+			GPSFilter:                  getEnvOrDefault("GPS_FILTER", "none"),
+			GPSSmoothingFactor:         getEnvFloatOrDefault("GPS_SMOOTHING_FACTOR", 0.5),
+			GPSProcessNoise:            getEnvFloatOrDefault("GPS_PROCESS_NOISE", 3.0),
+			GPSMaxSpeed:                getEnvFloatOrDefault("GPS_MAX_SPEED", 60.0),
+			BatteryCapacityMah:         getEnvFloatOrDefault("BATTERY_CAPACITY_MAH", 5000),
+			BatteryEstimatorWindow:     getEnvIntOrDefault("BATTERY_ESTIMATOR_WINDOW", 30),
+			BatteryChemistry:           getEnvOrDefault("BATTERY_CHEMISTRY", "lipo"),
+			BatteryCells:               getEnvIntOrDefault("BATTERY_CELLS", 3),
+			CellImbalanceThreshold:     getEnvFloatOrDefault("BATTERY_CELL_IMBALANCE_THRESHOLD", 0.1),
+			HomeLatitude:               getEnvFloatOrDefault("UAV_HOME_LATITUDE", 0),
+			HomeLongitude:              getEnvFloatOrDefault("UAV_HOME_LONGITUDE", 0),
+			ReturnCruiseSpeed:          getEnvFloatOrDefault("RETURN_CRUISE_SPEED", 10.0),
+			AirtimeBudgetMinutes:       getEnvFloatOrDefault("AIRTIME_BUDGET_MINUTES", 0),
+			EnableAnomalyDetection:     getEnvBoolOrDefault("ENABLE_ANOMALY_DETECTION", true),
+			AnomalyStuckSamples:        getEnvIntOrDefault("ANOMALY_STUCK_SAMPLES", 10),
+			EnableDiagnostics:          getEnvBoolOrDefault("ENABLE_DIAGNOSTICS", true),
+			DiagnosticsStaleThreshold:  getEnvDurationOrDefault("DIAGNOSTICS_STALE_THRESHOLD", 2*time.Second),
+			GNSSJammingThreshold:       getEnvIntOrDefault("GNSS_JAMMING_THRESHOLD", 60),
+			EnableGNSSIntegrity:        getEnvBoolOrDefault("ENABLE_GNSS_INTEGRITY", true),
+			GNSSDivergenceTolerance:    getEnvFloatOrDefault("GNSS_DIVERGENCE_TOLERANCE", 50),
+			GNSSAltitudeTolerance:      getEnvFloatOrDefault("GNSS_ALTITUDE_TOLERANCE", 20),
+			GNSSDivergenceSamples:      getEnvIntOrDefault("GNSS_DIVERGENCE_SAMPLES", 2),
+			AltitudeFusionTimeConstant: getEnvDurationOrDefault("ALTITUDE_FUSION_TIME_CONSTANT", time.Minute),
+			AltitudeMaxVDOP:            getEnvFloatOrDefault("ALTITUDE_MAX_VDOP", 2.5),
+			Geofences:                  parseGeofences(getEnvOrDefault("GEOFENCES", "")),
+			GeofenceWarningDistance:    getEnvFloatOrDefault("GEOFENCE_WARNING_DISTANCE", 50),
+			HealthRules:                parseHealthRules(getEnvOrDefault("HEALTH_RULES", "")),
 		},
 		UAVMetadata: UAVMetadataConfig{
 			HardwareModel:   getEnvOrDefault("UAV_HARDWARE_MODEL", "Generic-UAV-v1"),
 			FirmwareVersion: getEnvOrDefault("UAV_FIRMWARE_VERSION", "1.0.0"),
 			SerialNumber:    getEnvOrDefault("UAV_SERIAL_NUMBER", "UAV-000000"),
 		},
+		RemoteID: RemoteIDConfig{
+			Enabled:           getEnvBoolOrDefault("REMOTE_ID_ENABLED", false),
+			Format:            getEnvOrDefault("REMOTE_ID_FORMAT", "astm-f3411"),
+			Endpoint:          getEnvOrDefault("REMOTE_ID_ENDPOINT", "udp://127.0.0.1:4000"),
+			UASID:             getEnvOrDefault("REMOTE_ID_UAS_ID", ""),
+			IDType:            getEnvOrDefault("REMOTE_ID_ID_TYPE", "serial_number"),
+			OperatorID:        getEnvOrDefault("REMOTE_ID_OPERATOR_ID", ""),
+			OperatorLatitude:  getEnvFloatOrDefault("REMOTE_ID_OPERATOR_LAT", 0),
+			OperatorLongitude: getEnvFloatOrDefault("REMOTE_ID_OPERATOR_LON", 0),
+			Category:          getEnvOrDefault("REMOTE_ID_CATEGORY", ""),
+			Class:             getEnvOrDefault("REMOTE_ID_CLASS", ""),
+			Timeout:           getEnvDurationOrDefault("REMOTE_ID_TIMEOUT", 2*time.Second),
+		},
+		Enrollment: EnrollmentConfig{
+			Enabled:            getEnvBoolOrDefault("ENROLLMENT_ENABLED", false),
+			Dir:                getEnvOrDefault("ENROLLMENT_DIR", "/var/lib/uav-agent/enrollment"),
+			PollInterval:       getEnvDurationOrDefault("ENROLLMENT_POLL_INTERVAL", 10*time.Second),
+			Required:           getEnvBoolOrDefault("ENROLLMENT_REQUIRED", false),
+			CACertPath:         getEnvOrDefault("ENROLLMENT_CA_CERT_FILE", "/etc/uav-enrollment/ca.crt"),
+			CAKeyPath:          getEnvOrDefault("ENROLLMENT_CA_KEY_FILE", "/etc/uav-enrollment/ca.key"),
+			CertValidity:       getEnvDurationOrDefault("ENROLLMENT_CERT_VALIDITY", 90*24*time.Hour),
+			AutoApproveSerials: getEnvListOrDefault("ENROLLMENT_AUTO_APPROVE_SERIALS", nil),
+			AutoApproveModels:  getEnvListOrDefault("ENROLLMENT_AUTO_APPROVE_MODELS", nil),
+			Interval:           getEnvDurationOrDefault("ENROLLMENT_INTERVAL", 10*time.Second),
+		},
+		Decommission: DecommissionConfig{
+			ArchiveDir:      getEnvOrDefault("DECOMMISSION_ARCHIVE_DIR", "/var/lib/uav-decommission"),
+			AgentAPIPort:    getEnvIntOrDefault("DECOMMISSION_AGENT_API_PORT", 0),
+			AgentSelector:   getEnvOrDefault("DECOMMISSION_AGENT_SELECTOR", "app=uav-agent"),
+			AgentAPITimeout: getEnvDurationOrDefault("DECOMMISSION_AGENT_API_TIMEOUT", 30*time.Second),
+			Interval:        getEnvDurationOrDefault("DECOMMISSION_INTERVAL", 10*time.Second),
+
+			CheckpointRegistry:     getEnvOrDefault("DECOMMISSION_CHECKPOINT_REGISTRY", ""),
+			CheckpointBuilderImage: getEnvOrDefault("DECOMMISSION_CHECKPOINT_BUILDER_IMAGE", "quay.io/buildah/stable:latest"),
+			CheckpointPushSecret:   getEnvOrDefault("DECOMMISSION_CHECKPOINT_PUSH_SECRET", ""),
+			CheckpointTimeout:      getEnvDurationOrDefault("DECOMMISSION_CHECKPOINT_TIMEOUT", 60*time.Second),
+		},
+		Export: ExportConfig{
+			Output:        getEnvOrDefault("EXPORT_OUTPUT", ""),
+			Format:        getEnvOrDefault("EXPORT_FORMAT", ""),
+			Interval:      getEnvDurationOrDefault("EXPORT_INTERVAL", time.Second),
+			Duration:      getEnvDurationOrDefault("EXPORT_DURATION", 0),
+			SchedulerName: getEnvOrDefault("EXPORT_SCHEDULER_NAME", "uav-scheduler"),
+		},
+		ConversionWebhook: ConversionWebhookConfig{
+			Listen:   getEnvOrDefault("CONVERSION_WEBHOOK_LISTEN", ":9443"),
+			CertFile: getEnvOrDefault("CONVERSION_WEBHOOK_CERT_FILE", "/etc/uav-conversion/tls.crt"),
+			KeyFile:  getEnvOrDefault("CONVERSION_WEBHOOK_KEY_FILE", "/etc/uav-conversion/tls.key"),
+			CAFile:   getEnvOrDefault("CONVERSION_WEBHOOK_CA_FILE", "/etc/uav-conversion/ca.crt"),
+			Service:  getEnvOrDefault("CONVERSION_WEBHOOK_SERVICE", "default/uav-conversion"),
+		},
+		Retention: RetentionConfig{
+			Policies: parseRetentionPolicies(getEnvOrDefault("RETENTION_POLICIES", "")),
+			Interval: getEnvDurationOrDefault("RETENTION_INTERVAL", 10*time.Minute),
+		},
+		MQTT: MQTTConfig{
+			Enabled:            getEnvBoolOrDefault("MQTT_ENABLED", false),
+			Broker:             getEnvOrDefault("MQTT_BROKER", "tcp://127.0.0.1:1883"),
+			Topic:              getEnvOrDefault("MQTT_TOPIC", "uav/{node}/telemetry"),
+			QoS:                getEnvIntOrDefault("MQTT_QOS", 0),
+			Retain:             getEnvBoolOrDefault("MQTT_RETAIN", false),
+			ClientID:           getEnvOrDefault("MQTT_CLIENT_ID", ""),
+			Username:           getEnvOrDefault("MQTT_USERNAME", ""),
+			PasswordFile:       getEnvOrDefault("MQTT_PASSWORD_FILE", ""),
+			CAFile:             getEnvOrDefault("MQTT_CA_FILE", ""),
+			CertFile:           getEnvOrDefault("MQTT_CERT_FILE", ""),
+			KeyFile:            getEnvOrDefault("MQTT_KEY_FILE", ""),
+			InsecureSkipVerify: getEnvBoolOrDefault("MQTT_TLS_INSECURE", false),
+			Timeout:            getEnvDurationOrDefault("MQTT_TIMEOUT", 5*time.Second),
+		},
+		Sinks: SinkConfig{
+			CRDEnabled:        getEnvBoolOrDefault("SINK_CRD_ENABLED", true),
+			PrometheusEnabled: getEnvBoolOrDefault("SINK_PROMETHEUS_ENABLED", false),
+			PrometheusListen:  getEnvOrDefault("SINK_PROMETHEUS_LISTEN", ":9102"),
+			FileEnabled:       getEnvBoolOrDefault("SINK_FILE_ENABLED", false),
+			FilePath:          getEnvOrDefault("SINK_FILE_PATH", "/var/lib/uav-agent/samples.jsonl"),
+			FileMaxSize:       getEnvIntOrDefault("SINK_FILE_MAX_SIZE", 100),
+			FileMaxBackups:    getEnvIntOrDefault("SINK_FILE_MAX_BACKUPS", 5),
+			RetryAttempts:     getEnvIntOrDefault("SINK_RETRY_ATTEMPTS", 2),
+			RetryDelay:        getEnvDurationOrDefault("SINK_RETRY_DELAY", 500*time.Millisecond),
+			RetryMaxDelay:     getEnvDurationOrDefault("SINK_RETRY_MAX_DELAY", 2*time.Second),
+
+			CRDChangeDetection:  getEnvBoolOrDefault("SINK_CRD_CHANGE_DETECTION", false),
+			CRDMinBatteryChange: getEnvFloatOrDefault("SINK_CRD_MIN_BATTERY_CHANGE", 0.5),
+			CRDMinDistance:      getEnvFloatOrDefault("SINK_CRD_MIN_DISTANCE", 5),
+			CRDMaxSkipInterval:  getEnvDurationOrDefault("SINK_CRD_MAX_SKIP_INTERVAL", time.Minute),
+		},
+		PowerSave: PowerSaveConfig{
+			Enabled:          getEnvBoolOrDefault("POWER_SAVE", false),
+			BatteryThreshold: getEnvFloatOrDefault("POWER_SAVE_BATTERY_THRESHOLD", 0),
+			Interval:         getEnvDurationOrDefault("POWER_SAVE_INTERVAL", time.Minute),
+		},
+		Alert: AlertConfig{
+			WebhookURLs:      getEnvListOrDefault("ALERT_WEBHOOK_URLS", nil),
+			SlackWebhookURLs: getEnvListOrDefault("ALERT_SLACK_WEBHOOK_URLS", nil),
+			ErrorPatterns:    getEnvListOrDefault("ALERT_ERROR_PATTERNS", nil),
+			Cooldown:         getEnvDurationOrDefault("ALERT_COOLDOWN", 10*time.Minute),
+			Timeout:          getEnvDurationOrDefault("ALERT_TIMEOUT", 5*time.Second),
+		},
+		Storage: StorageConfig{
+			EncryptAtRest:     getEnvBoolOrDefault("STORAGE_ENCRYPT_AT_REST", false),
+			EncryptionKeyPath: getEnvOrDefault("STORAGE_ENCRYPTION_KEY_FILE", "/var/lib/uav-agent/storage.key"),
+			GenerateKey:       getEnvBoolOrDefault("STORAGE_GENERATE_KEY", true),
+
+			RecordingPath:       getEnvOrDefault("RECORDING_PATH", ""),
+			RecordingMaxSamples: getEnvIntOrDefault("RECORDING_MAX_SAMPLES", 100000),
+
+			HomeStateDir: getEnvOrDefault("HOME_STATE_DIR", "/var/lib/uav-agent/home"),
+		},
+		FieldEncryption: FieldEncryptionConfig{
+			Enabled:       getEnvBoolOrDefault("FIELD_ENCRYPTION_ENABLED", false),
+			PublicKeyPath: getEnvOrDefault("FLEET_PUBLIC_KEY_FILE", "/etc/uav-agent/fleet.pub"),
+			Fields:        getEnvListOrDefault("FIELD_ENCRYPTION_FIELDS", []string{"metadata.serialNumber", "home.location"}),
+		},
+		ROS2: ROS2Config{
+			BridgeURL:      getEnvOrDefault("ROS2_BRIDGE_URL", "ws://127.0.0.1:9090"),
+			NavSatFixTopic: getEnvOrDefault("ROS2_NAVSATFIX_TOPIC", "/mavros/global_position/global"),
+			BatteryTopic:   getEnvOrDefault("ROS2_BATTERY_TOPIC", "/mavros/battery"),
+			StateTopic:     getEnvOrDefault("ROS2_STATE_TOPIC", "/mavros/state"),
+			ImuTopic:       getEnvOrDefault("ROS2_IMU_TOPIC", "/mavros/imu/data"),
+			VelocityTopic:  getEnvOrDefault("ROS2_VELOCITY_TOPIC", "/mavros/local_position/velocity_local"),
+			RelAltTopic:    getEnvOrDefault("ROS2_REL_ALT_TOPIC", "/mavros/global_position/rel_alt"),
+			HeadingTopic:   getEnvOrDefault("ROS2_HEADING_TOPIC", "/mavros/global_position/compass_hdg"),
+			GPSRawTopic:    getEnvOrDefault("ROS2_GPS_RAW_TOPIC", "/mavros/gpsstatus/gps1/raw"),
+			PressureTopic:  getEnvOrDefault("ROS2_PRESSURE_TOPIC", "/mavros/imu/static_pressure"),
+			TimeRefTopic:   getEnvOrDefault("ROS2_TIME_REF_TOPIC", "/mavros/time_reference"),
+			StaleTimeout:   getEnvDurationOrDefault("ROS2_STALE_TIMEOUT", 5*time.Second),
+		},
+		DroneCAN: DroneCANConfig{
+			Interface:    getEnvOrDefault("DRONECAN_INTERFACE", "can0"),
+			StaleTimeout: getEnvDurationOrDefault("DRONECAN_STALE_TIMEOUT", 5*time.Second),
+		},
+		SITL: SITLConfig{
+			Endpoint:         getEnvOrDefault("SITL_ENDPOINT", "udp://:14550"),
+			SystemID:         getEnvIntOrDefault("SITL_SYSTEM_ID", 0),
+			SystemIDFromNode: getEnvBoolOrDefault("SITL_SYSTEM_ID_FROM_NODE", false),
+			StaleTimeout:     getEnvDurationOrDefault("SITL_STALE_TIMEOUT", 5*time.Second),
+		},
+		Modem: ModemConfig{
+			Source:  getEnvOrDefault("MODEM_SOURCE", "none"),
+			Device:  getEnvOrDefault("MODEM_AT_DEVICE", "/dev/ttyUSB2"),
+			ModemID: getEnvOrDefault("MODEM_MANAGER_ID", "any"),
+			Timeout: getEnvDurationOrDefault("MODEM_TIMEOUT", 3*time.Second),
+		},
+		BandwidthProbe: BandwidthProbeConfig{
+			Target:    getEnvOrDefault("BANDWIDTH_PROBE_TARGET", ""),
+			Size:      getEnvIntOrDefault("BANDWIDTH_PROBE_SIZE", 1<<20),
+			Interval:  getEnvDurationOrDefault("BANDWIDTH_PROBE_INTERVAL", 5*time.Minute),
+			Timeout:   getEnvDurationOrDefault("BANDWIDTH_PROBE_TIMEOUT", 10*time.Second),
+			Listen:    getEnvOrDefault("BANDWIDTH_PROBE_LISTEN", ""),
+			TokenFile: getEnvOrDefault("BANDWIDTH_PROBE_TOKEN_FILE", ""),
+			ServeRate: getEnvIntOrDefault("BANDWIDTH_PROBE_SERVE_RATE", 6),
+		},
+		LatencyProbe: LatencyProbeConfig{
+			Target:   getEnvOrDefault("LATENCY_PROBE_TARGET", ""),
+			Interval: getEnvDurationOrDefault("LATENCY_PROBE_INTERVAL", time.Second),
+			Timeout:  getEnvDurationOrDefault("LATENCY_PROBE_TIMEOUT", 2*time.Second),
+			Window:   getEnvIntOrDefault("LATENCY_PROBE_WINDOW", 100),
+			Listen:   getEnvOrDefault("LATENCY_PROBE_LISTEN", ""),
+		},
+		OpenTelemetry: OpenTelemetryConfig{
+			Endpoint:       getEnvOrDefault("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+			Protocol:       getEnvOrDefault("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf"),
+			Headers:        parseHeaders(getEnvListOrDefault("OTEL_EXPORTER_OTLP_HEADERS", nil)),
+			ServiceName:    getEnvOrDefault("OTEL_SERVICE_NAME", "uav-agent"),
+			SampleRatio:    getEnvFloatOrDefault("OTEL_TRACES_SAMPLER_ARG", 1),
+			MetricInterval: getEnvDurationOrDefault("OTEL_METRIC_INTERVAL", 30*time.Second),
+		},
+		FleetCache: FleetCacheConfig{
+			Address:         getEnvOrDefault("FLEET_CACHE_ADDRESS", ""),
+			CAFile:          getEnvOrDefault("FLEET_CACHE_CA_FILE", ""),
+			Listen:          getEnvOrDefault("FLEET_CACHE_LISTEN", ":9096"),
+			TLSCertFile:     getEnvOrDefault("FLEET_CACHE_TLS_CERT_FILE", ""),
+			TLSKeyFile:      getEnvOrDefault("FLEET_CACHE_TLS_KEY_FILE", ""),
+			HealthListen:    getEnvOrDefault("FLEET_CACHE_HEALTH_LISTEN", ":9097"),
+			RefreshInterval: getEnvDurationOrDefault("FLEET_CACHE_REFRESH_INTERVAL", time.Second),
+			History:         getEnvIntOrDefault("FLEET_CACHE_HISTORY", 256),
+		},
+		Aggregator: AggregatorConfig{
+			Address:       getEnvOrDefault("AGGREGATOR_ADDRESS", ""),
+			Timeout:       getEnvDurationOrDefault("AGGREGATOR_TIMEOUT", 5*time.Second),
+			CAFile:        getEnvOrDefault("AGGREGATOR_CA_FILE", ""),
+			Listen:        getEnvOrDefault("AGGREGATOR_LISTEN", ":9095"),
+			TLSCertFile:   getEnvOrDefault("AGGREGATOR_TLS_CERT_FILE", ""),
+			TLSKeyFile:    getEnvOrDefault("AGGREGATOR_TLS_KEY_FILE", ""),
+			HealthListen:  getEnvOrDefault("AGGREGATOR_HEALTH_LISTEN", ":9096"),
+			FlushInterval: getEnvDurationOrDefault("AGGREGATOR_FLUSH_INTERVAL", 2*time.Second),
+			Concurrency:   getEnvIntOrDefault("AGGREGATOR_CONCURRENCY", 16),
+			FieldManager:  getEnvOrDefault("AGGREGATOR_FIELD_MANAGER", "uav-aggregator"),
+		},
+		Vehicles: parseVehicles(getEnvListOrDefault("UAV_VEHICLES", nil)),
 	}
 }
 
@@ -149,6 +1308,47 @@ func (c *Config) Validate() error {
 	if c.Agent.NodeName == "" {
 		return fmt.Errorf("agent.nodeName is required (set NODE_NAME environment variable)")
 	}
+	if c.Agent.LogFile != "" {
+		if c.Agent.LogFileMaxSize < 0 {
+			return fmt.Errorf("agent.logFileMaxSize must be >= 0")
+		}
+		if c.Agent.LogFileMaxAge < 0 {
+			return fmt.Errorf("agent.logFileMaxAge must be >= 0")
+		}
+		if c.Agent.LogFileMaxBackups < 0 {
+			return fmt.Errorf("agent.logFileMaxBackups must be >= 0")
+		}
+	}
+	if c.Agent.ConfigReloadInterval < 0 {
+		return fmt.Errorf("agent.configReloadInterval must be >= 0")
+	}
+	if c.Agent.DesiredStateInterval < 0 {
+		return fmt.Errorf("agent.desiredStateInterval must be >= 0")
+	}
+	if c.Agent.HealthListen != "" && c.Agent.HealthStallTimeout <= c.Kubernetes.RetryTimeout {
+		return fmt.Errorf("agent.healthStallTimeout must be > kubernetes.retryTimeout")
+	}
+	if c.Agent.WatchdogIntervals < 0 {
+		return fmt.Errorf("agent.watchdogIntervals must be >= 0")
+	}
+	if c.Agent.APIListen != "" && c.Agent.APIListen == c.Agent.HealthListen {
+		return fmt.Errorf("agent.apiListen must differ from agent.healthListen")
+	}
+	if c.Agent.HistorySize < 0 {
+		return fmt.Errorf("agent.historySize must be >= 0")
+	}
+	if c.Agent.GRPCListen != "" && (c.Agent.GRPCListen == c.Agent.HealthListen || c.Agent.GRPCListen == c.Agent.APIListen) {
+		return fmt.Errorf("agent.grpcListen must differ from agent.healthListen and agent.apiListen")
+	}
+	if c.Agent.MetricsListen == "" {
+		return fmt.Errorf("agent.metricsListen cannot be empty")
+	}
+	if c.Agent.MetricsListen == c.Agent.HealthListen || c.Agent.MetricsListen == c.Agent.APIListen || c.Agent.MetricsListen == c.Agent.GRPCListen {
+		return fmt.Errorf("agent.metricsListen must differ from agent.healthListen, agent.apiListen and agent.grpcListen")
+	}
+	if c.Sinks.PrometheusEnabled && c.Agent.MetricsListen == c.Sinks.PrometheusListen {
+		return fmt.Errorf("agent.metricsListen must differ from sinks.prometheusListen")
+	}
 
 	// Validate Kubernetes config
 	if c.Kubernetes.Namespace == "" {
@@ -157,9 +1357,98 @@ func (c *Config) Validate() error {
 	if c.Kubernetes.CRDName == "" {
 		return fmt.Errorf("kubernetes.crdName cannot be empty")
 	}
+	if !strings.Contains(c.Kubernetes.NameTemplate, "{node}") {
+		return fmt.Errorf("kubernetes.nameTemplate must contain {node}")
+	}
+	if c.Kubernetes.FieldManager == "" {
+		return fmt.Errorf("kubernetes.fieldManager cannot be empty")
+	}
+	for key, value := range c.Kubernetes.Labels {
+		if key == "app" || key == "node-name" || key == "snapshot-time" {
+			return fmt.Errorf("kubernetes.labels cannot set %s", key)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("kubernetes.labels key %q is invalid: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("kubernetes.labels value %q is invalid: %s", value, strings.Join(errs, "; "))
+		}
+	}
+	if _, err := labels.Parse(c.Kubernetes.LabelSelector); err != nil {
+		return fmt.Errorf("kubernetes.labelSelector is invalid: %w", err)
+	}
+	fieldSelector, err := fields.ParseSelector(c.Kubernetes.FieldSelector)
+	if err != nil {
+		return fmt.Errorf("kubernetes.fieldSelector is invalid: %w", err)
+	}
+	for _, requirement := range fieldSelector.Requirements() {
+		if requirement.Field != "metadata.name" && requirement.Field != "metadata.namespace" {
+			return fmt.Errorf("kubernetes.fieldSelector only supports metadata.name and metadata.namespace, not %s", requirement.Field)
+		}
+	}
 	if c.Kubernetes.RetryAttempts < 0 {
 		return fmt.Errorf("kubernetes.retryAttempts must be >= 0")
 	}
+	if c.Kubernetes.RetryMaxDelay < c.Kubernetes.RetryDelay {
+		return fmt.Errorf("kubernetes.retryMaxDelay must be >= retryDelay")
+	}
+	if c.Kubernetes.InformerResync < 0 {
+		return fmt.Errorf("kubernetes.informerResync must be >= 0")
+	}
+	if c.Kubernetes.SnapshotInterval < 0 {
+		return fmt.Errorf("kubernetes.snapshotInterval must be >= 0")
+	}
+	if c.Kubernetes.EventBurst <= 0 || c.Kubernetes.EventInterval <= 0 {
+		return fmt.Errorf("kubernetes.eventBurst and eventInterval must be > 0")
+	}
+	if c.Kubernetes.RetryTimeout < 0 || c.Kubernetes.DNSCacheTTL < 0 {
+		return fmt.Errorf("kubernetes.retryTimeout and dnsCacheTTL must be >= 0")
+	}
+	if len(c.Kubernetes.APIEndpoints) > 0 && c.Kubernetes.HedgeDelay <= 0 {
+		return fmt.Errorf("kubernetes.hedgeDelay must be > 0")
+	}
+	if len(c.Kubernetes.APIEndpoints) > 0 && c.Kubernetes.HealthCheckInterval <= 0 {
+		return fmt.Errorf("kubernetes.healthCheckInterval must be > 0")
+	}
+	for _, endpoint := range c.Kubernetes.APIEndpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("kubernetes.apiEndpoints: invalid URL %q, expected https://host:port", endpoint)
+		}
+	}
+	if c.Kubernetes.NodeLabels && (c.Kubernetes.NodeLabelGeohashPrecision < 1 || c.Kubernetes.NodeLabelGeohashPrecision > 12) {
+		return fmt.Errorf("kubernetes.nodeLabelGeohashPrecision must be between 1 and 12")
+	}
+	if c.Kubernetes.NodeConditions {
+		if c.Kubernetes.NodeBatteryLowThreshold < 0 || c.Kubernetes.NodeBatteryLowThreshold > 100 {
+			return fmt.Errorf("kubernetes.nodeBatteryLowThreshold must be between 0 and 100")
+		}
+		if c.Kubernetes.NodeTaintBatteryThreshold < 0 || c.Kubernetes.NodeTaintBatteryThreshold > 100 {
+			return fmt.Errorf("kubernetes.nodeTaintBatteryThreshold must be between 0 and 100")
+		}
+		if c.Kubernetes.NodeTaintHysteresis < 0 {
+			return fmt.Errorf("kubernetes.nodeTaintHysteresis must be >= 0")
+		}
+	}
+	if c.Kubernetes.LeaderElection {
+		if c.Kubernetes.LeaseRetryPeriod <= 0 {
+			return fmt.Errorf("kubernetes.leaseRetryPeriod must be > 0")
+		}
+		// The renew deadline must leave room for a jittered retry
+		if float64(c.Kubernetes.LeaseRenewDeadline) <= 1.2*float64(c.Kubernetes.LeaseRetryPeriod) {
+			return fmt.Errorf("kubernetes.leaseRenewDeadline must be > 1.2 * leaseRetryPeriod")
+		}
+		if c.Kubernetes.LeaseDuration <= c.Kubernetes.LeaseRenewDeadline {
+			return fmt.Errorf("kubernetes.leaseDuration must be > leaseRenewDeadline")
+		}
+	}
+	if c.Kubernetes.HeartbeatInterval < 0 {
+		return fmt.Errorf("kubernetes.heartbeatInterval must be >= 0")
+	}
+	// The timeout must cover a missed renewal; Leases hold whole seconds
+	if c.Kubernetes.HeartbeatInterval > 0 && (c.Kubernetes.HeartbeatTimeout <= c.Kubernetes.HeartbeatInterval || c.Kubernetes.HeartbeatTimeout < time.Second) {
+		return fmt.Errorf("kubernetes.heartbeatTimeout must be > heartbeatInterval and >= 1s")
+	}
 
 	// Validate collection config
 	if c.Collection.Interval <= 0 {
@@ -171,32 +1460,543 @@ func (c *Config) Validate() error {
 	if c.Collection.BatteryCriticalThreshold < 0 || c.Collection.BatteryCriticalThreshold > 100 {
 		return fmt.Errorf("collection.batteryCriticalThreshold must be between 0 and 100")
 	}
+	if c.Collection.BatteryClearMargin < 0 || c.Collection.BatteryClearMargin > 100 {
+		return fmt.Errorf("collection.batteryClearMargin must be between 0 and 100")
+	}
+	if c.Collection.ClockSkewThreshold < 0 {
+		return fmt.Errorf("collection.clockSkewThreshold must be >= 0")
+	}
 	if c.Collection.GPSMinSatellites < 0 {
 		return fmt.Errorf("collection.gpsMinSatellites must be >= 0")
 	}
+	switch c.Collection.GPSFilter {
+	case "none", "ema", "kalman":
+	default:
+		return fmt.Errorf("collection.gpsFilter must be one of none, ema, kalman")
+	}
+	if c.Collection.GPSSmoothingFactor <= 0 || c.Collection.GPSSmoothingFactor > 1 {
+		return fmt.Errorf("collection.gpsSmoothingFactor must be in (0, 1]")
+	}
+	if c.Collection.GPSProcessNoise <= 0 {
+		return fmt.Errorf("collection.gpsProcessNoise must be > 0")
+	}
+	if c.Collection.GPSMaxSpeed < 0 {
+		return fmt.Errorf("collection.gpsMaxSpeed must be >= 0")
+	}
+	if c.Collection.BatteryCapacityMah <= 0 {
+		return fmt.Errorf("collection.batteryCapacityMah must be > 0")
+	}
+	if c.Collection.BatteryEstimatorWindow < 1 {
+		return fmt.Errorf("collection.batteryEstimatorWindow must be >= 1")
+	}
+	switch c.Collection.BatteryChemistry {
+	case "lipo", "li-ion", "solid-state":
+	default:
+		return fmt.Errorf("collection.batteryChemistry must be lipo, li-ion or solid-state")
+	}
+	if c.Collection.BatteryCells < 1 || c.Collection.BatteryCells > 14 {
+		return fmt.Errorf("collection.batteryCells must be between 1 and 14")
+	}
+	if c.Collection.CellImbalanceThreshold < 0 {
+		return fmt.Errorf("collection.cellImbalanceThreshold must be >= 0")
+	}
+	if c.Collection.HomeLatitude < -90 || c.Collection.HomeLatitude > 90 {
+		return fmt.Errorf("collection.homeLatitude must be between -90 and 90")
+	}
+	if c.Collection.HomeLongitude < -180 || c.Collection.HomeLongitude > 180 {
+		return fmt.Errorf("collection.homeLongitude must be between -180 and 180")
+	}
+	if c.Collection.ReturnCruiseSpeed <= 0 {
+		return fmt.Errorf("collection.returnCruiseSpeed must be > 0")
+	}
+	if c.Collection.AirtimeBudgetMinutes < 0 {
+		return fmt.Errorf("collection.airtimeBudgetMinutes must be >= 0")
+	}
+	if c.Collection.AnomalyStuckSamples < 2 {
+		return fmt.Errorf("collection.anomalyStuckSamples must be >= 2")
+	}
+	if c.Collection.DiagnosticsStaleThreshold <= 0 {
+		return fmt.Errorf("collection.diagnosticsStaleThreshold must be > 0")
+	}
+	if c.Collection.GNSSJammingThreshold < 0 || c.Collection.GNSSJammingThreshold > 100 {
+		return fmt.Errorf("collection.gnssJammingThreshold must be between 0 and 100")
+	}
+	if c.Collection.GNSSDivergenceTolerance <= 0 || c.Collection.GNSSAltitudeTolerance <= 0 {
+		return fmt.Errorf("collection.gnssDivergenceTolerance and gnssAltitudeTolerance must be > 0")
+	}
+	if c.Collection.GNSSDivergenceSamples < 1 {
+		return fmt.Errorf("collection.gnssDivergenceSamples must be >= 1")
+	}
+	if c.Collection.AltitudeFusionTimeConstant <= 0 {
+		return fmt.Errorf("collection.altitudeFusionTimeConstant must be > 0")
+	}
+	if c.Collection.AltitudeMaxVDOP <= 0 {
+		return fmt.Errorf("collection.altitudeMaxVDOP must be > 0")
+	}
+
+	switch c.Collection.Backend {
+	case "simulated":
+	case "ros2":
+		if c.ROS2.BridgeURL == "" {
+			return fmt.Errorf("ros2.bridgeURL is required for the ros2 backend")
+		}
+		if c.ROS2.StaleTimeout <= 0 {
+			return fmt.Errorf("ros2.staleTimeout must be > 0")
+		}
+	case "dronecan":
+		if c.DroneCAN.Interface == "" {
+			return fmt.Errorf("droneCAN.interface is required for the dronecan backend")
+		}
+		if c.DroneCAN.StaleTimeout <= 0 {
+			return fmt.Errorf("droneCAN.staleTimeout must be > 0")
+		}
+	case "sitl":
+		if c.SITL.Endpoint == "" {
+			return fmt.Errorf("sitl.endpoint is required for the sitl backend")
+		}
+		if c.SITL.SystemID < 0 || c.SITL.SystemID > 254 {
+			return fmt.Errorf("sitl.systemID must be between 0 and 254")
+		}
+		if c.SITL.StaleTimeout <= 0 {
+			return fmt.Errorf("sitl.staleTimeout must be > 0")
+		}
+	default:
+		return fmt.Errorf("collection.backend must be one of: simulated, ros2, dronecan, sitl")
+	}
+
+	if c.Storage.EncryptAtRest && c.Storage.EncryptionKeyPath == "" {
+		return fmt.Errorf("storage.encryptionKeyPath is required when encryptAtRest is enabled")
+	}
+	if c.Storage.RecordingPath != "" && c.Storage.RecordingMaxSamples <= 0 {
+		return fmt.Errorf("storage.recordingMaxSamples must be > 0")
+	}
+
+	switch c.Modem.Source {
+	case "none", "modemmanager":
+	case "at":
+		if c.Modem.Device == "" {
+			return fmt.Errorf("modem.device is required when modem.source is at")
+		}
+	default:
+		return fmt.Errorf("modem.source must be one of: none, at, modemmanager")
+	}
+	if c.Modem.Timeout <= 0 {
+		return fmt.Errorf("modem.timeout must be > 0")
+	}
+
+	if c.BandwidthProbe.Target != "" || c.BandwidthProbe.Listen != "" {
+		if c.BandwidthProbe.Size <= 0 || c.BandwidthProbe.Size > 64<<20 {
+			return fmt.Errorf("bandwidthProbe.size must be between 1 and 67108864 bytes")
+		}
+		if c.BandwidthProbe.Interval <= 0 {
+			return fmt.Errorf("bandwidthProbe.interval must be > 0")
+		}
+		if c.BandwidthProbe.Timeout <= 0 {
+			return fmt.Errorf("bandwidthProbe.timeout must be > 0")
+		}
+	}
+	if t := c.BandwidthProbe.Target; t != "" && !strings.HasPrefix(t, "http://") && !strings.HasPrefix(t, "https://") {
+		if _, _, err := net.SplitHostPort(t); err != nil {
+			return fmt.Errorf("bandwidthProbe.target must be an http(s) URL or host:port: %w", err)
+		}
+		if c.BandwidthProbe.TokenFile == "" {
+			return fmt.Errorf("bandwidthProbe.tokenFile is required to probe another agent's probe server")
+		}
+	}
+	if c.BandwidthProbe.Listen != "" {
+		if c.BandwidthProbe.TokenFile == "" {
+			return fmt.Errorf("bandwidthProbe.tokenFile is required to serve probes")
+		}
+		if c.BandwidthProbe.ServeRate <= 0 {
+			return fmt.Errorf("bandwidthProbe.serveRate must be > 0")
+		}
+	}
+
+	if c.LatencyProbe.Target != "" {
+		if _, _, err := net.SplitHostPort(c.LatencyProbe.Target); err != nil {
+			return fmt.Errorf("latencyProbe.target must be host:port: %w", err)
+		}
+		if c.LatencyProbe.Interval <= 0 {
+			return fmt.Errorf("latencyProbe.interval must be > 0")
+		}
+		if c.LatencyProbe.Timeout <= 0 {
+			return fmt.Errorf("latencyProbe.timeout must be > 0")
+		}
+		if c.LatencyProbe.Window <= 0 {
+			return fmt.Errorf("latencyProbe.window must be > 0")
+		}
+	}
+
+	if c.MQTT.Enabled {
+		u, err := url.Parse(c.MQTT.Broker)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("mqtt.broker: invalid URL %q, expected tcp://host:port", c.MQTT.Broker)
+		}
+		switch u.Scheme {
+		case "tcp", "ssl", "ws", "wss":
+		default:
+			return fmt.Errorf("mqtt.broker scheme must be tcp, ssl, ws or wss")
+		}
+		if c.MQTT.Topic == "" || strings.ContainsAny(c.MQTT.Topic, "+#") {
+			return fmt.Errorf("mqtt.topic must be set and cannot contain wildcards")
+		}
+		if c.MQTT.QoS < 0 || c.MQTT.QoS > 2 {
+			return fmt.Errorf("mqtt.qos must be 0, 1 or 2")
+		}
+		if (c.MQTT.CertFile == "") != (c.MQTT.KeyFile == "") {
+			return fmt.Errorf("mqtt.certFile and mqtt.keyFile must be set together")
+		}
+		if c.MQTT.Timeout <= 0 {
+			return fmt.Errorf("mqtt.timeout must be > 0")
+		}
+	}
+
+	if c.Sinks.CRDChangeDetection {
+		if c.Sinks.CRDMinBatteryChange < 0 || c.Sinks.CRDMinDistance < 0 {
+			return fmt.Errorf("sinks.crdMinBatteryChange and sinks.crdMinDistance must be >= 0")
+		}
+		if c.Sinks.CRDMaxSkipInterval <= 0 {
+			return fmt.Errorf("sinks.crdMaxSkipInterval must be > 0")
+		}
+	}
+	if c.Sinks.PrometheusEnabled && c.Sinks.PrometheusListen == "" {
+		return fmt.Errorf("sinks.prometheusListen is required when the Prometheus sink is enabled")
+	}
+	if c.Sinks.FileEnabled {
+		if c.Sinks.FilePath == "" {
+			return fmt.Errorf("sinks.filePath is required when the file sink is enabled")
+		}
+		if c.Sinks.FileMaxSize < 0 || c.Sinks.FileMaxBackups < 0 {
+			return fmt.Errorf("sinks.fileMaxSize and sinks.fileMaxBackups must be >= 0")
+		}
+	}
+	if c.Sinks.RetryAttempts < 1 {
+		return fmt.Errorf("sinks.retryAttempts must be >= 1")
+	}
+	if c.Sinks.RetryDelay <= 0 || c.Sinks.RetryMaxDelay < c.Sinks.RetryDelay {
+		return fmt.Errorf("sinks.retryDelay must be > 0 and <= sinks.retryMaxDelay")
+	}
+
+	if c.PowerSave.BatteryThreshold < 0 || c.PowerSave.BatteryThreshold > 100 {
+		return fmt.Errorf("powerSave.batteryThreshold must be between 0 and 100")
+	}
+	if c.PowerSave.Interval <= 0 {
+		return fmt.Errorf("powerSave.interval must be > 0")
+	}
+
+	if c.Alert.Enabled() {
+		// The URLs aren't echoed, Slack webhook URLs are secrets
+		for _, webhooks := range [][]string{c.Alert.WebhookURLs, c.Alert.SlackWebhookURLs} {
+			for _, webhook := range webhooks {
+				u, err := url.Parse(webhook)
+				if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+					return fmt.Errorf("alert: invalid webhook URL, expected http(s)://host/path")
+				}
+			}
+		}
+		for _, pattern := range c.Alert.ErrorPatterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("alert.errorPatterns: invalid pattern %q", pattern)
+			}
+		}
+		if c.Alert.Cooldown < 0 {
+			return fmt.Errorf("alert.cooldown must be >= 0")
+		}
+		if c.Alert.Timeout <= 0 {
+			return fmt.Errorf("alert.timeout must be > 0")
+		}
+	}
+
+	if c.OpenTelemetry.Endpoint != "" {
+		u, err := url.Parse(c.OpenTelemetry.Endpoint)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("openTelemetry.endpoint: invalid URL %q, expected http(s)://host:port", c.OpenTelemetry.Endpoint)
+		}
+		if c.OpenTelemetry.Protocol != "grpc" && c.OpenTelemetry.Protocol != "http/protobuf" {
+			return fmt.Errorf("openTelemetry.protocol must be grpc or http/protobuf")
+		}
+		if c.OpenTelemetry.SampleRatio < 0 || c.OpenTelemetry.SampleRatio > 1 {
+			return fmt.Errorf("openTelemetry.sampleRatio must be between 0 and 1")
+		}
+		if c.OpenTelemetry.MetricInterval <= 0 {
+			return fmt.Errorf("openTelemetry.metricInterval must be > 0")
+		}
+	}
+
+	if c.Aggregator.Address != "" {
+		if _, _, err := net.SplitHostPort(c.Aggregator.Address); err != nil {
+			return fmt.Errorf("aggregator.address must be host:port: %w", err)
+		}
+		if c.Aggregator.Timeout <= 0 {
+			return fmt.Errorf("aggregator.timeout must be > 0")
+		}
+		// Vehicles authenticate to the aggregator with their enrollment
+		// certificate
+		if !c.Enrollment.Enabled {
+			return fmt.Errorf("aggregator.address requires enrollment.enabled")
+		}
+	}
+
+	if err := c.validateGeofences(); err != nil {
+		return err
+	}
+	if err := c.validateHealthRules(); err != nil {
+		return err
+	}
+
+	if err := c.validateRetention(); err != nil {
+		return err
+	}
+
+	if c.Enrollment.Enabled {
+		if c.Enrollment.Dir == "" {
+			return fmt.Errorf("enrollment.dir cannot be empty")
+		}
+		if c.Enrollment.PollInterval <= 0 {
+			return fmt.Errorf("enrollment.pollInterval must be > 0")
+		}
+		if c.UAVMetadata.SerialNumber == "" {
+			return fmt.Errorf("uavMetadata.serialNumber is required for enrollment")
+		}
+	}
+
+	if err := c.validateVehicles(); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// ValidateAggregator validates the settings used by the aggregator, which
+// doesn't collect telemetry and so skips the agent checks of Validate
+func (c *Config) ValidateAggregator() error {
+	if c.Kubernetes.Namespace == "" {
+		return fmt.Errorf("kubernetes.namespace cannot be empty")
+	}
+	if c.Aggregator.Listen == "" {
+		return fmt.Errorf("aggregator.listen cannot be empty")
+	}
+	if c.Aggregator.FlushInterval <= 0 {
+		return fmt.Errorf("aggregator.flushInterval must be > 0")
+	}
+	if c.Aggregator.Concurrency <= 0 {
+		return fmt.Errorf("aggregator.concurrency must be > 0")
+	}
+	if c.Aggregator.FieldManager == "" {
+		return fmt.Errorf("aggregator.fieldManager cannot be empty")
+	}
+	if c.Aggregator.TLSCertFile == "" || c.Aggregator.TLSKeyFile == "" {
+		return fmt.Errorf("aggregator.tlsCertFile and aggregator.tlsKeyFile are required")
+	}
+	if c.Enrollment.CACertPath == "" {
+		return fmt.Errorf("enrollment.caCertPath is required to verify agents")
+	}
+	if c.Aggregator.HealthListen == "" {
+		return fmt.Errorf("aggregator.healthListen cannot be empty")
+	}
+	return nil
+}
+
+// ValidateFleetCache validates the settings used by the fleet snapshot cache
+func (c *Config) ValidateFleetCache() error {
+	if c.Kubernetes.Namespace == "" {
+		return fmt.Errorf("kubernetes.namespace cannot be empty")
+	}
+	if c.FleetCache.Listen == "" {
+		return fmt.Errorf("fleetCache.listen cannot be empty")
+	}
+	if c.FleetCache.RefreshInterval <= 0 {
+		return fmt.Errorf("fleetCache.refreshInterval must be > 0")
+	}
+	if c.FleetCache.TLSCertFile == "" || c.FleetCache.TLSKeyFile == "" {
+		return fmt.Errorf("fleetCache.tlsCertFile and fleetCache.tlsKeyFile are required")
+	}
+	if c.FleetCache.HealthListen == "" || c.FleetCache.HealthListen == c.FleetCache.Listen {
+		return fmt.Errorf("fleetCache.healthListen must be set and differ from fleetCache.listen")
+	}
+	if c.FleetCache.History < 0 {
+		return fmt.Errorf("fleetCache.history must be >= 0")
+	}
+	return nil
+}
+
+// ValidateEnrollmentController validates the settings used by the
+// enrollment controller
+func (c *Config) ValidateEnrollmentController() error {
+	if c.Kubernetes.Namespace == "" {
+		return fmt.Errorf("kubernetes.namespace cannot be empty")
+	}
+	if c.Enrollment.CACertPath == "" || c.Enrollment.CAKeyPath == "" {
+		return fmt.Errorf("enrollment.caCertPath and enrollment.caKeyPath are required")
+	}
+	if c.Enrollment.CertValidity <= 0 {
+		return fmt.Errorf("enrollment.certValidity must be > 0")
+	}
+	if c.Enrollment.Interval <= 0 {
+		return fmt.Errorf("enrollment.interval must be > 0")
+	}
+	for _, pattern := range append(append([]string{}, c.Enrollment.AutoApproveSerials...), c.Enrollment.AutoApproveModels...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("enrollment: invalid auto-approval pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// ValidateDecommissionController validates the settings used by the
+// decommission controller
+func (c *Config) ValidateDecommissionController() error {
+	if c.Kubernetes.Namespace == "" {
+		return fmt.Errorf("kubernetes.namespace cannot be empty")
+	}
+	if c.Decommission.ArchiveDir == "" {
+		return fmt.Errorf("decommission.archiveDir cannot be empty")
+	}
+	if c.Decommission.AgentAPIPort < 0 || c.Decommission.AgentAPIPort > 65535 {
+		return fmt.Errorf("decommission.agentAPIPort must be between 0 and 65535")
+	}
+	if _, err := labels.Parse(c.Decommission.AgentSelector); err != nil || c.Decommission.AgentSelector == "" {
+		return fmt.Errorf("decommission.agentSelector must be a non-empty label selector")
+	}
+	if c.Decommission.AgentAPIPort > 0 && c.Decommission.AgentAPITimeout <= 0 {
+		return fmt.Errorf("decommission.agentAPITimeout must be > 0")
+	}
+	if c.Decommission.Interval <= 0 {
+		return fmt.Errorf("decommission.interval must be > 0")
+	}
+	if c.Decommission.CheckpointRegistry != "" {
+		if c.Decommission.CheckpointBuilderImage == "" {
+			return fmt.Errorf("decommission.checkpointBuilderImage cannot be empty when a checkpoint registry is set")
+		}
+		if c.Decommission.CheckpointTimeout < time.Second {
+			return fmt.Errorf("decommission.checkpointTimeout must be at least 1s")
+		}
+	}
+	return nil
+}
+
+// ValidateExporter validates the settings used by the fleet snapshot
+// exporter
+func (c *Config) ValidateExporter() error {
+	if c.Kubernetes.Namespace == "" {
+		return fmt.Errorf("kubernetes.namespace cannot be empty")
+	}
+	if c.Export.Output == "" {
+		return fmt.Errorf("export.output is required (set EXPORT_OUTPUT)")
+	}
+	if format := c.Export.ExportFormat(); format != ExportFormatCSV && format != ExportFormatParquet {
+		return fmt.Errorf("export.format must be %s or %s", ExportFormatCSV, ExportFormatParquet)
+	}
+	if c.Export.Interval <= 0 {
+		return fmt.Errorf("export.interval must be > 0")
+	}
+	if c.Export.Duration < 0 {
+		return fmt.Errorf("export.duration must be >= 0")
+	}
+	return nil
+}
+
+// ValidateConversionWebhook validates the settings used by the UAVMetrics
+// conversion webhook
+func (c *Config) ValidateConversionWebhook() error {
+	if c.ConversionWebhook.Listen == "" {
+		return fmt.Errorf("conversionWebhook.listen cannot be empty")
+	}
+	if c.ConversionWebhook.CertFile == "" || c.ConversionWebhook.KeyFile == "" {
+		return fmt.Errorf("conversionWebhook.certFile and conversionWebhook.keyFile are required")
+	}
+	if c.ConversionWebhook.Service != "" {
+		if namespace, name, ok := strings.Cut(c.ConversionWebhook.Service, "/"); !ok || namespace == "" || name == "" {
+			return fmt.Errorf("conversionWebhook.service must be namespace/name")
+		}
+	}
+	return nil
+}
+
+// ValidateJanitor validates the settings used by the retention janitor
+func (c *Config) ValidateJanitor() error {
+	if c.Kubernetes.Namespace == "" {
+		return fmt.Errorf("kubernetes.namespace cannot be empty")
+	}
+	return c.validateRetention()
+}
+
 // Helper functions
 
+// getenv reads environment variables; Load swaps it out to compute the
+// built-in defaults
+var getenv = os.Getenv
+
 func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getEnvBoolOrDefault(key string, defaultValue bool) bool {
-	value := os.Getenv(key)
+	value := getenv(key)
 	if value == "" {
 		return defaultValue
 	}
 	return value == "true" || value == "1" || value == "yes"
 }
 
+func getEnvListOrDefault(key string, defaultValue []string) []string {
+	value := getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// parseHeaders parses key=value pairs, skipping malformed entries
+func parseHeaders(pairs []string) map[string]string {
+	if len(pairs) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); ok && key != "" {
+			headers[key] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
+
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	value := getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return result
+}
+
+func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
+	value := getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return result
+}
+
 func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
-	value := os.Getenv(key)
+	value := getenv(key)
 	if value == "" {
 		return defaultValue
 	}
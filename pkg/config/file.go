@@ -0,0 +1,223 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// envMu serializes swapping getenv while the built-in defaults are computed
+var envMu sync.Mutex
+
+// Load builds the configuration from, highest precedence first:
+//
+//  1. overrides of the form path=value (e.g. collection.interval=5s), from
+//     --set flags
+//  2. environment variables
+//  3. the YAML or JSON file at path, if set
+//  4. built-in defaults
+//
+// File keys are the JSON field names of Config; durations may be written as
+// strings ("10s") or nanoseconds. Unknown keys are rejected. An environment
+// variable only overrides the file when it changes the built-in default.
+func Load(path string, overrides []string) (*Config, error) {
+	fromEnv := DefaultConfig()
+	if path == "" && len(overrides) == 0 {
+		return fromEnv, nil
+	}
+
+	defaults, err := toMap(builtinDefaults())
+	if err != nil {
+		return nil, err
+	}
+	merged, err := toMap(builtinDefaults())
+	if err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		var file map[string]interface{}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if err := normalizeDurations(file, reflect.TypeOf(Config{}), ""); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+		mergeMaps(merged, file)
+	}
+
+	env, err := toMap(fromEnv)
+	if err != nil {
+		return nil, err
+	}
+	overlayChanged(merged, env, defaults)
+
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid override %q, expected path=value", override)
+		}
+		var parsed interface{}
+		if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+			return nil, fmt.Errorf("invalid override %q: %w", override, err)
+		}
+		parts := strings.Split(key, ".")
+		nested := map[string]interface{}{parts[len(parts)-1]: parsed}
+		for i := len(parts) - 2; i >= 0; i-- {
+			nested = map[string]interface{}{parts[i]: nested}
+		}
+		if err := normalizeDurations(nested, reflect.TypeOf(Config{}), ""); err != nil {
+			return nil, fmt.Errorf("invalid override %q: %w", override, err)
+		}
+		mergeMaps(merged, nested)
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	cfg := &Config{}
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// Marshal renders the configuration as YAML that Load accepts, with
+// durations written as strings
+func (c *Config) Marshal() ([]byte, error) {
+	m, err := toMap(c)
+	if err != nil {
+		return nil, err
+	}
+	err = walkDurations(m, reflect.TypeOf(Config{}), "", func(_ string, value interface{}) (interface{}, error) {
+		if ns, ok := value.(float64); ok {
+			return time.Duration(ns).String(), nil
+		}
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(m)
+}
+
+// builtinDefaults returns the configuration with no environment variables set
+func builtinDefaults() *Config {
+	envMu.Lock()
+	defer envMu.Unlock()
+	getenv = func(string) string { return "" }
+	defer func() { getenv = os.Getenv }()
+	return DefaultConfig()
+}
+
+func toMap(cfg *Config) (map[string]interface{}, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// mergeMaps merges src into dst; nested objects are merged, other values
+// (including lists) replaced
+func mergeMaps(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeMaps(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// overlayChanged copies into dst the values of env that differ from defaults
+func overlayChanged(dst, env, defaults map[string]interface{}) {
+	for key, value := range env {
+		envMap, envIsMap := value.(map[string]interface{})
+		defMap, defIsMap := defaults[key].(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if envIsMap && defIsMap && dstIsMap {
+			overlayChanged(dstMap, envMap, defMap)
+			continue
+		}
+		if !reflect.DeepEqual(value, defaults[key]) {
+			dst[key] = value
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// normalizeDurations converts duration strings in m to nanoseconds
+func normalizeDurations(m map[string]interface{}, t reflect.Type, prefix string) error {
+	return walkDurations(m, t, prefix, func(path string, value interface{}) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid duration %q", path, s)
+		}
+		return int64(d), nil
+	})
+}
+
+// walkDurations calls convert for every duration in m, following the json
+// field names of t, and stores the result
+func walkDurations(m map[string]interface{}, t reflect.Type, prefix string, convert func(path string, value interface{}) (interface{}, error)) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		value, ok := m[name]
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+
+		switch {
+		case field.Type == durationType:
+			converted, err := convert(prefix+name, value)
+			if err != nil {
+				return err
+			}
+			m[name] = converted
+		case field.Type.Kind() == reflect.Struct:
+			if nested, ok := value.(map[string]interface{}); ok {
+				if err := walkDurations(nested, field.Type, prefix+name+".", convert); err != nil {
+					return err
+				}
+			}
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			if items, ok := value.([]interface{}); ok {
+				for _, item := range items {
+					if nested, ok := item.(map[string]interface{}); ok {
+						if err := walkDurations(nested, field.Type.Elem(), prefix+name+"[].", convert); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
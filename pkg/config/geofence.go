@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Geofence shapes
+const (
+	GeofenceCircle  = "circle"
+	GeofencePolygon = "polygon"
+)
+
+// GeofenceConfig is a named area the UAV must stay inside, or outside when
+// Exclusion is set (no-fly zones)
+type GeofenceConfig struct {
+	Name string `json:"name"`
+
+	// circle or polygon
+	Shape string `json:"shape"`
+
+	// Keep out of the area instead of staying inside it
+	Exclusion bool `json:"exclusion,omitempty"`
+
+	// Circle center and radius (m)
+	Center GeoPoint `json:"center,omitempty"`
+	Radius float64  `json:"radius,omitempty"`
+
+	// Polygon vertices in order; the polygon is closed implicitly
+	Vertices []GeoPoint `json:"vertices,omitempty"`
+}
+
+// GeoPoint is a WGS84 position
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// parseGeofences parses GEOFENCES, a semicolon separated list of
+//
+//	name:circle:lat,lon,radius[:exclude]
+//	name:polygon:lat,lon lat,lon lat,lon ...[:exclude]
+//
+// Unparsable coordinates are kept as NaN so that Validate reports them.
+func parseGeofences(value string) []GeofenceConfig {
+	var fences []GeofenceConfig
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		fence := GeofenceConfig{Name: strings.TrimSpace(parts[0])}
+		if len(parts) > 1 {
+			fence.Shape = strings.ToLower(strings.TrimSpace(parts[1]))
+		}
+		if len(parts) > 3 {
+			fence.Exclusion = strings.EqualFold(strings.TrimSpace(parts[3]), "exclude")
+			if !fence.Exclusion && !strings.EqualFold(strings.TrimSpace(parts[3]), "include") {
+				fence.Shape = "" // reported as invalid by Validate
+			}
+		}
+		if len(parts) > 2 {
+			switch fence.Shape {
+			case GeofenceCircle:
+				values := parseFloats(strings.Split(parts[2], ","))
+				if len(values) == 3 {
+					fence.Center = GeoPoint{Latitude: values[0], Longitude: values[1]}
+					fence.Radius = values[2]
+				} else {
+					fence.Radius = math.NaN()
+				}
+			case GeofencePolygon:
+				for _, vertex := range strings.Fields(parts[2]) {
+					values := parseFloats(strings.Split(vertex, ","))
+					if len(values) != 2 {
+						values = []float64{math.NaN(), math.NaN()}
+					}
+					fence.Vertices = append(fence.Vertices, GeoPoint{Latitude: values[0], Longitude: values[1]})
+				}
+			}
+		}
+		fences = append(fences, fence)
+	}
+	return fences
+}
+
+func parseFloats(fields []string) []float64 {
+	values := make([]float64, 0, len(fields))
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			value = math.NaN()
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// validateGeofences checks that geofences have distinct names and valid geometry
+func (c *Config) validateGeofences() error {
+	if c.Collection.GeofenceWarningDistance < 0 {
+		return fmt.Errorf("collection.geofenceWarningDistance must be >= 0")
+	}
+
+	names := make(map[string]bool, len(c.Collection.Geofences))
+	for _, fence := range c.Collection.Geofences {
+		if fence.Name == "" {
+			return fmt.Errorf("collection.geofences: name cannot be empty")
+		}
+		if names[fence.Name] {
+			return fmt.Errorf("collection.geofences: duplicate name %q", fence.Name)
+		}
+		names[fence.Name] = true
+
+		switch fence.Shape {
+		case GeofenceCircle:
+			if !fence.Center.valid() {
+				return fmt.Errorf("collection.geofences: %s has an invalid center", fence.Name)
+			}
+			if !(fence.Radius > 0) {
+				return fmt.Errorf("collection.geofences: %s radius must be > 0", fence.Name)
+			}
+		case GeofencePolygon:
+			if len(fence.Vertices) < 3 {
+				return fmt.Errorf("collection.geofences: %s needs at least 3 vertices", fence.Name)
+			}
+			for _, vertex := range fence.Vertices {
+				if !vertex.valid() {
+					return fmt.Errorf("collection.geofences: %s has an invalid vertex", fence.Name)
+				}
+			}
+		default:
+			return fmt.Errorf("collection.geofences: %s must be name:circle:lat,lon,radius or name:polygon:lat,lon lat,lon ..., optionally followed by :include or :exclude", fence.Name)
+		}
+	}
+	return nil
+}
+
+func (p GeoPoint) valid() bool {
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
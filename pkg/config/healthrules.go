@@ -0,0 +1,386 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// Health rule operators
+const (
+	OperatorGreater        = ">"
+	OperatorGreaterOrEqual = ">="
+	OperatorLess           = "<"
+	OperatorLessOrEqual    = "<="
+	OperatorEqual          = "=="
+	OperatorNotEqual       = "!="
+)
+
+// Health rule severities, the health statuses a rule sets
+const (
+	SeverityWarning  = "Warning"
+	SeverityCritical = "Critical"
+)
+
+// operators lists the operators, two-character ones first for parsing
+var operators = []string{
+	OperatorGreaterOrEqual, OperatorLessOrEqual, OperatorEqual, OperatorNotEqual,
+	OperatorGreater, OperatorLess,
+}
+
+// HealthRule raises a health warning or error while a numeric or boolean
+// UAVMetrics field compares to a threshold, e.g. performance.temperature >
+// 70 for 30s. Booleans compare as 1 (true) and 0 (false).
+//
+// A firing rule keeps firing while the field compares to the clear threshold
+// and until it has stopped doing so for the hold duration, so a value
+// hovering around the threshold doesn't flip the health status every cycle.
+type HealthRule struct {
+	Name string `json:"name"`
+
+	// Dotted path of the field in UAVMetrics, using its JSON names (e.g.
+	// battery.remainingPercent, network.latency, flight.isFlying)
+	Field string `json:"field"`
+
+	// >, >=, <, <=, == or !=
+	Operator string `json:"operator"`
+
+	Threshold float64 `json:"threshold"`
+
+	// Threshold below (for > and >=) or above (for < and <=) which a firing
+	// rule clears; defaults to Threshold
+	Clear *float64 `json:"clear,omitempty"`
+
+	// How long the condition must hold before the rule fires (0 fires on
+	// the first matching sample)
+	For time.Duration `json:"for,omitempty"`
+
+	// How long the condition must have cleared before a firing rule stops
+	// firing (0 stops on the first sample that clears)
+	Hold time.Duration `json:"hold,omitempty"`
+
+	// Warning or Critical
+	Severity string `json:"severity"`
+
+	// Message reported in health warnings or errors; {value} is replaced by
+	// the field's value. Defaults to "<name>: <field> is {value}".
+	Message string `json:"message,omitempty"`
+}
+
+// Matches reports whether value satisfies the rule's condition
+func (r HealthRule) Matches(value float64) bool {
+	return compare(value, r.Operator, r.Threshold)
+}
+
+// Holds reports whether value keeps a firing rule's condition, compared to
+// the clear threshold
+func (r HealthRule) Holds(value float64) bool {
+	if r.Clear == nil {
+		return r.Matches(value)
+	}
+	return compare(value, r.Operator, *r.Clear)
+}
+
+func compare(value float64, op string, threshold float64) bool {
+	switch op {
+	case OperatorGreater:
+		return value > threshold
+	case OperatorGreaterOrEqual:
+		return value >= threshold
+	case OperatorLess:
+		return value < threshold
+	case OperatorLessOrEqual:
+		return value <= threshold
+	case OperatorEqual:
+		return value == threshold
+	case OperatorNotEqual:
+		return value != threshold
+	}
+	return false
+}
+
+// Names of the built-in health rules. Each geofence adds the rules
+// geofence-<name> (breached) and geofence-<name>-near.
+const (
+	HealthRuleCriticalBattery = "critical-battery"
+	HealthRuleLowBattery      = "low-battery"
+	HealthRuleNoGPSFix        = "no-gps-fix"
+)
+
+// Fields computed by the health check in addition to the UAVMetrics fields
+const (
+	// 1 with a 3D fix, or at least collection.gpsMinSatellites when the
+	// receiver doesn't report its fix type; otherwise 0
+	FieldGPSHas3DFix = "gps.has3DFix"
+
+	fieldGeofencePrefix = "geofence."
+)
+
+// Computed fields of each geofence, geofence.<name>.<field>
+const (
+	// Distance (m) from the position to the fence boundary: positive while
+	// the fence is respected, negative once it is breached
+	GeofenceFieldMargin = "margin"
+	// Distance (m) to the fence boundary while the fence is respected,
+	// absent once it is breached
+	GeofenceFieldDistance = "distance"
+)
+
+// GeofenceField returns the computed field of the named geofence
+func GeofenceField(name, field string) string {
+	return fieldGeofencePrefix + name + "." + field
+}
+
+// ParseGeofenceField returns the geofence name and computed field of a
+// GeofenceField
+func ParseGeofenceField(path string) (name, field string, ok bool) {
+	rest, ok := strings.CutPrefix(path, fieldGeofencePrefix)
+	if !ok {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, ".")
+	if i < 0 {
+		return "", "", false
+	}
+	name, field = rest[:i], rest[i+1:]
+	return name, field, field == GeofenceFieldMargin || field == GeofenceFieldDistance
+}
+
+// BuiltinHealthRules returns the built-in checks expressed as health rules,
+// with thresholds from their own settings. They always apply in addition to
+// the configured rules.
+func (c *CollectionConfig) BuiltinHealthRules() []HealthRule {
+	criticalClear := math.Min(c.BatteryCriticalThreshold+c.BatteryClearMargin, 100)
+	lowClear := math.Min(c.BatteryLowThreshold+c.BatteryClearMargin, 100)
+	rules := []HealthRule{
+		{Name: HealthRuleCriticalBattery, Field: "battery.remainingPercent", Operator: OperatorLess,
+			Threshold: c.BatteryCriticalThreshold, Clear: &criticalClear,
+			Severity: SeverityCritical, Message: "Critical battery: {value}%"},
+		{Name: HealthRuleLowBattery, Field: "battery.remainingPercent", Operator: OperatorLess,
+			Threshold: c.BatteryLowThreshold, Clear: &lowClear,
+			Severity: SeverityWarning, Message: "Low battery: {value}%"},
+		{Name: HealthRuleNoGPSFix, Field: FieldGPSHas3DFix, Operator: OperatorEqual, Threshold: 0,
+			Severity: SeverityWarning, Message: "No 3D GPS fix"},
+	}
+	for _, fence := range c.Geofences {
+		breached, near := "Outside geofence "+fence.Name+": margin {value}m", "Near geofence "+fence.Name+" boundary: {value}m"
+		if fence.Exclusion {
+			breached, near = "Inside no-fly geofence "+fence.Name+": margin {value}m", "Approaching no-fly geofence "+fence.Name+": {value}m from boundary"
+		}
+		rules = append(rules,
+			HealthRule{Name: "geofence-" + fence.Name, Field: GeofenceField(fence.Name, GeofenceFieldMargin), Operator: OperatorLess,
+				Threshold: 0, Severity: SeverityCritical, Message: breached},
+			HealthRule{Name: "geofence-" + fence.Name + "-near", Field: GeofenceField(fence.Name, GeofenceFieldDistance), Operator: OperatorLessOrEqual,
+				Threshold: c.GeofenceWarningDistance, Severity: SeverityWarning, Message: near},
+		)
+	}
+	return rules
+}
+
+// EffectiveHealthRules returns the built-in rules followed by the configured
+// ones. A configured rule named like a built-in one replaces it.
+func (c *CollectionConfig) EffectiveHealthRules() []HealthRule {
+	configured := make(map[string]bool, len(c.HealthRules))
+	for _, rule := range c.HealthRules {
+		configured[rule.Name] = true
+	}
+	rules := []HealthRule{}
+	for _, rule := range c.BuiltinHealthRules() {
+		if !configured[rule.Name] {
+			rules = append(rules, rule)
+		}
+	}
+	return append(rules, c.HealthRules...)
+}
+
+// defaultHealthRules are the threshold checks used when no rules are
+// configured
+func defaultHealthRules() []HealthRule {
+	return []HealthRule{
+		{Name: "high-latency", Field: "network.latency", Operator: OperatorGreater, Threshold: 200,
+			Severity: SeverityWarning, Message: "High latency: {value}ms"},
+		{Name: "high-packet-loss", Field: "network.packetLoss", Operator: OperatorGreater, Threshold: 5,
+			Severity: SeverityWarning, Message: "High packet loss: {value}%"},
+		{Name: "high-cpu", Field: "performance.cpuUsage", Operator: OperatorGreater, Threshold: 80,
+			Severity: SeverityWarning, Message: "High CPU usage: {value}%"},
+	}
+}
+
+// parseHealthRules parses HEALTH_RULES, a semicolon separated list of
+//
+//	name:field operator threshold[ clear threshold][ for duration][ hold duration]:severity[:message]
+//
+// e.g. "hot:performance.temperature>70 clear 65 for 30s:Critical". An empty value
+// keeps the default rules, "none" disables them. Unparsable rules are kept
+// with an empty operator so that Validate reports them.
+func parseHealthRules(value string) []HealthRule {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultHealthRules()
+	}
+	if value == "none" {
+		return []HealthRule{}
+	}
+
+	rules := []HealthRule{}
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 4)
+		rule := HealthRule{Name: strings.TrimSpace(parts[0])}
+		if len(parts) > 2 {
+			rule.Severity = strings.TrimSpace(parts[2])
+		}
+		if len(parts) > 3 {
+			rule.Message = strings.TrimSpace(parts[3])
+		}
+		if len(parts) > 1 {
+			parseCondition(&rule, parts[1])
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// parseCondition parses "field operator threshold[ clear threshold][ for
+// duration][ hold duration]" into rule
+func parseCondition(rule *HealthRule, condition string) {
+	condition, options := cutOptions(condition)
+	fields := strings.Fields(options)
+	if len(fields)%2 != 0 {
+		return
+	}
+	for i := 0; i < len(fields); i += 2 {
+		value := fields[i+1]
+		switch fields[i] {
+		case "clear":
+			values := parseFloats([]string{value})
+			if math.IsNaN(values[0]) {
+				return
+			}
+			rule.Clear = &values[0]
+		case "for", "hold":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return
+			}
+			if fields[i] == "for" {
+				rule.For = d
+			} else {
+				rule.Hold = d
+			}
+		default:
+			return
+		}
+	}
+	for _, op := range operators {
+		field, threshold, ok := strings.Cut(condition, op)
+		if !ok {
+			continue
+		}
+		values := parseFloats([]string{threshold})
+		if math.IsNaN(values[0]) {
+			return
+		}
+		rule.Field = strings.TrimSpace(field)
+		rule.Operator = op
+		rule.Threshold = values[0]
+		return
+	}
+}
+
+// cutOptions splits a condition at its first clear, for or hold option
+func cutOptions(condition string) (string, string) {
+	end := len(condition)
+	for _, option := range []string{" clear ", " for ", " hold "} {
+		if i := strings.Index(condition, option); i >= 0 && i < end {
+			end = i
+		}
+	}
+	return condition[:end], condition[end:]
+}
+
+// validateHealthField checks that field is a numeric or boolean UAVMetrics
+// field or a field computed by the health check
+func (c *CollectionConfig) validateHealthField(field string) error {
+	if field == FieldGPSHas3DFix {
+		return nil
+	}
+	if name, _, ok := ParseGeofenceField(field); ok {
+		for _, fence := range c.Geofences {
+			if fence.Name == name {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown geofence %s", name)
+	}
+	_, err := models.ResolveField(field)
+	return err
+}
+
+// validateHealthRules checks that health rules have distinct names, a
+// known field and a valid condition and severity
+func (c *Config) validateHealthRules() error {
+	names := make(map[string]bool, len(c.Collection.HealthRules))
+	for _, rule := range c.Collection.HealthRules {
+		if rule.Name == "" {
+			return fmt.Errorf("collection.healthRules: name cannot be empty")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("collection.healthRules: duplicate name %q", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if rule.Field == "" || !validOperator(rule.Operator) || math.IsNaN(rule.Threshold) {
+			return fmt.Errorf("collection.healthRules: %s must be name:field operator threshold[ clear threshold][ for duration][ hold duration]:severity[:message], with operator one of %s",
+				rule.Name, strings.Join(operators, " "))
+		}
+		if err := c.Collection.validateHealthField(rule.Field); err != nil {
+			return fmt.Errorf("collection.healthRules: %s: %w", rule.Name, err)
+		}
+		if rule.For < 0 || rule.Hold < 0 {
+			return fmt.Errorf("collection.healthRules: %s durations must be >= 0", rule.Name)
+		}
+		if err := validateClear(rule); err != nil {
+			return err
+		}
+		if rule.Severity != SeverityWarning && rule.Severity != SeverityCritical {
+			return fmt.Errorf("collection.healthRules: %s severity must be %s or %s", rule.Name, SeverityWarning, SeverityCritical)
+		}
+	}
+	return nil
+}
+
+// validateClear checks that a rule's clear threshold is on the clearing
+// side of its threshold
+func validateClear(rule HealthRule) error {
+	if rule.Clear == nil {
+		return nil
+	}
+	threshold := *rule.Clear
+	switch {
+	case math.IsNaN(threshold):
+		return fmt.Errorf("collection.healthRules: %s clear threshold must be a number", rule.Name)
+	case rule.Operator == OperatorEqual || rule.Operator == OperatorNotEqual:
+		return fmt.Errorf("collection.healthRules: %s clear threshold requires operator > >= < or <=", rule.Name)
+	case (rule.Operator == OperatorGreater || rule.Operator == OperatorGreaterOrEqual) && threshold > rule.Threshold:
+		return fmt.Errorf("collection.healthRules: %s clear threshold must be <= %g", rule.Name, rule.Threshold)
+	case (rule.Operator == OperatorLess || rule.Operator == OperatorLessOrEqual) && threshold < rule.Threshold:
+		return fmt.Errorf("collection.healthRules: %s clear threshold must be >= %g", rule.Name, rule.Threshold)
+	}
+	return nil
+}
+
+func validOperator(op string) bool {
+	for _, known := range operators {
+		if op == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ApplyReloadable copies from next the settings that take effect without a
+// restart: the log level, collection interval, simulation, enabled
+// collectors, health check thresholds, geofences, health rules and power
+// saving. It returns the paths of other settings that differ (e.g.
+// "collection.backend"), which only apply after a restart.
+func (c *Config) ApplyReloadable(next *Config) ([]string, error) {
+	c.Agent.LogLevel = next.Agent.LogLevel
+
+	col, n := &c.Collection, &next.Collection
+	col.Interval = n.Interval
+	col.Simulate = n.Simulate
+	col.EnableGPS = n.EnableGPS
+	col.EnableBattery = n.EnableBattery
+	col.EnableFlight = n.EnableFlight
+	col.EnableNetwork = n.EnableNetwork
+	col.EnablePerformance = n.EnablePerformance
+	col.EnableHealthCheck = n.EnableHealthCheck
+	col.EnableAnomalyDetection = n.EnableAnomalyDetection
+	col.EnableDiagnostics = n.EnableDiagnostics
+	col.EnableGNSSIntegrity = n.EnableGNSSIntegrity
+	col.BatteryLowThreshold = n.BatteryLowThreshold
+	col.BatteryCriticalThreshold = n.BatteryCriticalThreshold
+	col.BatteryClearMargin = n.BatteryClearMargin
+	col.CellImbalanceThreshold = n.CellImbalanceThreshold
+	col.GPSMinSatellites = n.GPSMinSatellites
+	col.ClockSkewThreshold = n.ClockSkewThreshold
+	col.GNSSJammingThreshold = n.GNSSJammingThreshold
+	col.AirtimeBudgetMinutes = n.AirtimeBudgetMinutes
+	col.DiagnosticsStaleThreshold = n.DiagnosticsStaleThreshold
+	col.Geofences = n.Geofences
+	col.GeofenceWarningDistance = n.GeofenceWarningDistance
+	col.HealthRules = n.HealthRules
+	c.PowerSave = next.PowerSave
+
+	current, err := toMap(c)
+	if err != nil {
+		return nil, err
+	}
+	wanted, err := toMap(next)
+	if err != nil {
+		return nil, err
+	}
+	restart := diffPaths(current, wanted, "")
+	sort.Strings(restart)
+	return restart, nil
+}
+
+// diffPaths returns the paths of the values that differ between a and b
+func diffPaths(a, b map[string]interface{}, prefix string) []string {
+	var paths []string
+	seen := make(map[string]bool, len(a))
+	for key, value := range a {
+		seen[key] = true
+		aMap, aIsMap := value.(map[string]interface{})
+		bMap, bIsMap := b[key].(map[string]interface{})
+		if aIsMap && bIsMap {
+			paths = append(paths, diffPaths(aMap, bMap, prefix+key+".")...)
+		} else if !reflect.DeepEqual(value, b[key]) {
+			paths = append(paths, prefix+key)
+		}
+	}
+	for key := range b {
+		if !seen[key] {
+			paths = append(paths, prefix+key)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Retention data types
+const (
+	// UAVMetrics objects of vehicles that stopped reporting, deleted by the
+	// janitor (cmd/janitor)
+	RetentionMetrics = "metrics"
+
+	// Samples kept in memory by the agent for /api/v1/history
+	RetentionHistory = "history"
+
+	// Samples recorded on the node's disk (storage.recordingPath)
+	RetentionRecording = "recording"
+
+	// UAVMetricsHistory snapshots (kubernetes.snapshotInterval), deleted by
+	// the janitor
+	RetentionSnapshots = "snapshots"
+
+	// Samples written by the file sink (sinks.filePath), whose rotated files
+	// are deleted by the agent
+	RetentionFile = "file"
+)
+
+// RetentionConfig contains the retention policies of telemetry data and the
+// settings of the janitor enforcing them
+type RetentionConfig struct {
+	Policies []RetentionPolicy `json:"policies,omitempty"`
+
+	// How often the janitor looks for expired data
+	Interval time.Duration `json:"interval"`
+}
+
+// RetentionPolicy bounds how long one type of data of a fleet is kept
+type RetentionPolicy struct {
+	// Namespace of the fleet (empty: the default for every fleet)
+	Fleet string `json:"fleet,omitempty"`
+
+	// metrics, history, recording, snapshots or file
+	DataType string `json:"dataType"`
+
+	// Data older than this is deleted (0 keeps it forever)
+	MaxAge time.Duration `json:"maxAge,omitempty"`
+
+	// Data must be kept at least this long, e.g. for regulatory minimum
+	// retention; configurations that could delete it earlier are rejected
+	MinAge time.Duration `json:"minAge,omitempty"`
+}
+
+// PolicyFor returns the policy of dataType for fleet: the policy naming the
+// fleet if any, otherwise the default one. It returns false when neither
+// exists, in which case the data is kept.
+func (r RetentionConfig) PolicyFor(fleet, dataType string) (RetentionPolicy, bool) {
+	var fallback *RetentionPolicy
+	for i, policy := range r.Policies {
+		if policy.DataType != dataType {
+			continue
+		}
+		if policy.Fleet == fleet {
+			return policy, true
+		}
+		if policy.Fleet == "" {
+			fallback = &r.Policies[i]
+		}
+	}
+	if fallback == nil {
+		return RetentionPolicy{}, false
+	}
+	return *fallback, true
+}
+
+// Fleets returns the fleets named by policies
+func (r RetentionConfig) Fleets() []string {
+	var fleets []string
+	seen := make(map[string]bool)
+	for _, policy := range r.Policies {
+		if policy.Fleet != "" && !seen[policy.Fleet] {
+			seen[policy.Fleet] = true
+			fleets = append(fleets, policy.Fleet)
+		}
+	}
+	return fleets
+}
+
+// Expired reports whether data last written at t is past the policy's
+// maximum age and no longer within its minimum age
+func (p RetentionPolicy) Expired(t, now time.Time) bool {
+	age := now.Sub(t)
+	return p.MaxAge > 0 && age > p.MaxAge && age > p.MinAge
+}
+
+// parseRetentionPolicies parses RETENTION_POLICIES, a semicolon separated
+// list of
+//
+//	[fleet/]type:max=<duration>[,min=<duration>]
+//
+// e.g. "metrics:max=720h;production/metrics:min=2160h,max=4320h". Invalid
+// entries are kept with the whole entry as data type so that Validate
+// reports them.
+func parseRetentionPolicies(value string) []RetentionPolicy {
+	var policies []RetentionPolicy
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		target, limits, _ := strings.Cut(entry, ":")
+		policy := RetentionPolicy{DataType: strings.TrimSpace(target)}
+		if fleet, dataType, ok := strings.Cut(target, "/"); ok {
+			policy.Fleet = strings.TrimSpace(fleet)
+			policy.DataType = strings.TrimSpace(dataType)
+		}
+		for _, limit := range strings.Split(limits, ",") {
+			key, raw, _ := strings.Cut(limit, "=")
+			d, err := time.ParseDuration(strings.TrimSpace(raw))
+			switch {
+			case err != nil:
+				policy.Fleet, policy.DataType = "", entry
+			case strings.TrimSpace(key) == "max":
+				policy.MaxAge = d
+			case strings.TrimSpace(key) == "min":
+				policy.MinAge = d
+			default:
+				policy.Fleet, policy.DataType = "", entry
+			}
+		}
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// validateRetention checks that policies are well formed and don't
+// contradict each other or the history kept by the agent
+func (c *Config) validateRetention() error {
+	if c.Retention.Interval <= 0 {
+		return fmt.Errorf("retention.interval must be > 0")
+	}
+
+	seen := make(map[string]bool, len(c.Retention.Policies))
+	for _, policy := range c.Retention.Policies {
+		name := policy.DataType
+		if policy.Fleet != "" {
+			name = policy.Fleet + "/" + policy.DataType
+		}
+		switch policy.DataType {
+		case RetentionMetrics, RetentionHistory, RetentionRecording, RetentionSnapshots, RetentionFile:
+		default:
+			return fmt.Errorf("retention.policies: %q must be [fleet/]metrics, history, recording, snapshots or file followed by :max=<duration>[,min=<duration>]", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("retention.policies: duplicate policy for %s", name)
+		}
+		seen[name] = true
+
+		if policy.MaxAge < 0 || policy.MinAge < 0 {
+			return fmt.Errorf("retention.policies: %s ages must be >= 0", name)
+		}
+		if policy.MaxAge > 0 && policy.MaxAge < policy.MinAge {
+			return fmt.Errorf("retention.policies: %s maxAge must be >= minAge", name)
+		}
+	}
+
+	// The sample limits must be large enough to hold the minimum retention
+	if policy, ok := c.Retention.PolicyFor(c.Kubernetes.Namespace, RetentionHistory); ok && policy.MinAge > 0 {
+		if kept := time.Duration(c.Agent.HistorySize) * c.Collection.Interval; kept < policy.MinAge {
+			return fmt.Errorf("agent.historySize keeps %s of samples, less than the history retention minAge %s", kept, policy.MinAge)
+		}
+	}
+	if policy, ok := c.Retention.PolicyFor(c.Kubernetes.Namespace, RetentionRecording); ok && policy.MinAge > 0 && c.Storage.RecordingPath != "" {
+		if kept := time.Duration(c.Storage.RecordingMaxSamples) * c.Collection.Interval; kept < policy.MinAge {
+			return fmt.Errorf("storage.recordingMaxSamples keeps %s of samples, less than the recording retention minAge %s", kept, policy.MinAge)
+		}
+	}
+	return nil
+}
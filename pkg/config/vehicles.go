@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// VehicleConfig identifies one airframe whose telemetry a ground node proxies
+type VehicleConfig struct {
+	// Vehicle name, used as the node name of its UAVMetrics object
+	Name string `json:"name"`
+
+	// Serial number (default: <UAV_SERIAL_NUMBER>-<name>)
+	SerialNumber string `json:"serialNumber,omitempty"`
+
+	// MAVLink system ID for the SITL backend (0 derives it from the name)
+	SystemID int `json:"systemID,omitempty"`
+
+	// SITL endpoint of the vehicle (default: sitl.endpoint); each vehicle
+	// needs its own
+	SITLEndpoint string `json:"sitlEndpoint,omitempty"`
+}
+
+// parseVehicles parses UAV_VEHICLES entries of the form
+// name[:serial[:systemID[:sitlEndpoint]]]. An invalid system ID is kept as
+// -1 so that Validate reports it.
+func parseVehicles(entries []string) []VehicleConfig {
+	vehicles := make([]VehicleConfig, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 4)
+		v := VehicleConfig{Name: strings.TrimSpace(parts[0])}
+		if len(parts) > 1 {
+			v.SerialNumber = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 && strings.TrimSpace(parts[2]) != "" {
+			id, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+			if err != nil {
+				id = -1
+			}
+			v.SystemID = id
+		}
+		if len(parts) > 3 {
+			v.SITLEndpoint = strings.TrimSpace(parts[3])
+		}
+		vehicles = append(vehicles, v)
+	}
+	return vehicles
+}
+
+// ForVehicle returns a copy of the configuration that collects and publishes
+// as the given proxied vehicle
+func (c *Config) ForVehicle(v VehicleConfig) *Config {
+	vc := *c
+	vc.Vehicles = nil
+	vc.Agent.GroundNode = c.Agent.NodeName
+	vc.Agent.NodeName = v.Name
+
+	vc.UAVMetadata.SerialNumber = v.SerialNumber
+	if vc.UAVMetadata.SerialNumber == "" {
+		vc.UAVMetadata.SerialNumber = c.UAVMetadata.SerialNumber + "-" + v.Name
+	}
+	// Each vehicle broadcasts its own serial number as Remote ID
+	vc.RemoteID.UASID = ""
+
+	// Select the vehicle's messages by system ID on its own endpoint
+	if v.SITLEndpoint != "" {
+		vc.SITL.Endpoint = v.SITLEndpoint
+	}
+	if v.SystemID > 0 {
+		vc.SITL.SystemID = v.SystemID
+		vc.SITL.SystemIDFromNode = false
+	} else {
+		vc.SITL.SystemID = 0
+		vc.SITL.SystemIDFromNode = true
+	}
+
+	// MAVROS multi-vehicle convention: one namespace per vehicle
+	prefix := "/" + v.Name
+	vc.ROS2.NavSatFixTopic = prefix + vc.ROS2.NavSatFixTopic
+	vc.ROS2.BatteryTopic = prefix + vc.ROS2.BatteryTopic
+	vc.ROS2.StateTopic = prefix + vc.ROS2.StateTopic
+	vc.ROS2.ImuTopic = prefix + vc.ROS2.ImuTopic
+	vc.ROS2.VelocityTopic = prefix + vc.ROS2.VelocityTopic
+	vc.ROS2.RelAltTopic = prefix + vc.ROS2.RelAltTopic
+	vc.ROS2.HeadingTopic = prefix + vc.ROS2.HeadingTopic
+	vc.ROS2.GPSRawTopic = prefix + vc.ROS2.GPSRawTopic
+	vc.ROS2.PressureTopic = prefix + vc.ROS2.PressureTopic
+
+	return &vc
+}
+
+// validateVehicles checks that proxied vehicles have distinct, valid identities
+func (c *Config) validateVehicles() error {
+	if len(c.Vehicles) == 0 {
+		return nil
+	}
+	if c.Collection.Backend == "dronecan" {
+		return fmt.Errorf("vehicles: the dronecan backend reads a single CAN bus and cannot proxy several vehicles")
+	}
+	if len(c.Vehicles) > 1 && (c.Kubernetes.NodeLabels || c.Kubernetes.NodeConditions) {
+		return fmt.Errorf("vehicles: node labels and conditions are written to the ground node and support a single proxied vehicle")
+	}
+
+	names := make(map[string]bool, len(c.Vehicles))
+	serials := make(map[string]bool, len(c.Vehicles))
+	systemIDs := make(map[int]bool, len(c.Vehicles))
+	endpoints := make(map[string]string, len(c.Vehicles))
+	for _, v := range c.Vehicles {
+		if v.Name == "" {
+			return fmt.Errorf("vehicles: name cannot be empty")
+		}
+		if errs := validation.IsDNS1123Label(v.Name); len(errs) > 0 {
+			return fmt.Errorf("vehicles: invalid name %q: %s", v.Name, strings.Join(errs, "; "))
+		}
+		if names[v.Name] {
+			return fmt.Errorf("vehicles: duplicate name %q", v.Name)
+		}
+		names[v.Name] = true
+
+		serial := c.ForVehicle(v).UAVMetadata.SerialNumber
+		if serials[serial] {
+			return fmt.Errorf("vehicles: duplicate serial number %q", serial)
+		}
+		serials[serial] = true
+
+		if v.SystemID < 0 || v.SystemID > 254 {
+			return fmt.Errorf("vehicles: %s systemID must be between 0 and 254", v.Name)
+		}
+		if c.Collection.Backend == "sitl" && v.SystemID == 0 && !endsWithDigit(v.Name) {
+			return fmt.Errorf("vehicles: %s needs a systemID (or a name ending in one) for the sitl backend", v.Name)
+		}
+		// Each backend binds or connects its own socket; a second listener
+		// on the same port fails
+		if c.Collection.Backend == "sitl" {
+			endpoint := c.ForVehicle(v).SITL.Endpoint
+			if other, ok := endpoints[endpoint]; ok {
+				return fmt.Errorf("vehicles: %s and %s share the sitl endpoint %s, each vehicle needs its own", other, v.Name, endpoint)
+			}
+			endpoints[endpoint] = v.Name
+		}
+		if v.SystemID > 0 {
+			if systemIDs[v.SystemID] {
+				return fmt.Errorf("vehicles: duplicate systemID %d", v.SystemID)
+			}
+			systemIDs[v.SystemID] = true
+		}
+	}
+	return nil
+}
+
+func endsWithDigit(s string) bool {
+	return s != "" && s[len(s)-1] >= '0' && s[len(s)-1] <= '9'
+}
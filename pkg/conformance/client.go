@@ -0,0 +1,93 @@
+package conformance
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// EncodeFunc 将 UAVMetrics 转换为 UAVMetrics 自定义资源，如 k8s.Client.MetricsToUnstructured
+type EncodeFunc func(*models.UAVMetrics) (*unstructured.Unstructured, error)
+
+// DecodeFunc 从 UAVMetrics 自定义资源还原 UAVMetrics，如 k8s.Client.UnstructuredToMetrics
+type DecodeFunc func(*unstructured.Unstructured) (*models.UAVMetrics, error)
+
+// MetricsRoundTrip 检查 UAVMetrics 与自定义资源之间的转换：
+//   - 编码结果带有 apiVersion、kind 和 spec
+//   - 编码结果可以被 runtime.DeepCopyJSON 复制（只含 JSON 兼容的类型），
+//     且经过一次 JSON 编解码（即写入 API Server 再读回）后仍能解码
+//   - decode(encode(m)) 与 m 相同
+func MetricsRoundTrip(t *testing.T, encode EncodeFunc, decode DecodeFunc, opts Options) {
+	t.Helper()
+
+	forEachCase(t, opts, func(t *testing.T, r *rand.Rand) {
+		metrics := RandomMetrics(r, "uav-0")
+		want := snapshot(t, metrics)
+
+		obj, err := encode(metrics)
+		if err != nil {
+			t.Fatalf("encode failed: %v", err)
+		}
+		if obj.GetAPIVersion() == "" {
+			t.Error("encoded object has no apiVersion")
+		}
+		if obj.GetKind() != "UAVMetrics" {
+			t.Errorf("encoded object has kind %q, want UAVMetrics", obj.GetKind())
+		}
+		if _, ok := obj.Object["spec"].(map[string]interface{}); !ok {
+			t.Fatal("encoded object has no spec")
+		}
+		checkUnchanged(t, "encode", want, metrics)
+
+		decoded, err := decode(obj)
+		if err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		if got := snapshot(t, decoded); got != want {
+			t.Errorf("round trip changed the metrics\n got: %s\nwant: %s", got, want)
+		}
+
+		copied := deepCopy(t, obj)
+		data, err := json.Marshal(copied)
+		if err != nil {
+			t.Fatalf("failed to marshal encoded object: %v", err)
+		}
+		stored := &unstructured.Unstructured{}
+		if err := stored.UnmarshalJSON(data); err != nil {
+			t.Fatalf("failed to unmarshal encoded object: %v", err)
+		}
+		decoded, err = decode(stored)
+		if err != nil {
+			t.Fatalf("decode after JSON round trip failed: %v", err)
+		}
+		if got := snapshot(t, decoded); got != want {
+			t.Errorf("JSON round trip changed the metrics\n got: %s\nwant: %s", got, want)
+		}
+	})
+
+	t.Run("MissingSpec", func(t *testing.T) {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "uav.k3s.io/v1",
+			"kind":       "UAVMetrics",
+		}}
+		if _, err := decode(obj); err == nil {
+			t.Error("decode of an object without spec succeeded")
+		}
+	})
+}
+
+// deepCopy 使用 runtime.DeepCopyJSON 复制对象；对象中含有非 JSON 类型时会 panic
+func deepCopy(t *testing.T, obj *unstructured.Unstructured) (copied map[string]interface{}) {
+	t.Helper()
+	defer func() {
+		if p := recover(); p != nil {
+			t.Fatalf("encoded object contains values that are not JSON compatible: %v", p)
+		}
+	}()
+	return runtime.DeepCopyJSON(obj.Object)
+}
@@ -0,0 +1,50 @@
+// Package conformance 提供可复用的一致性测试套件，供第三方调度算法、路由算法和
+// UAVMetrics 客户端实现在自己的测试中运行：
+//
+//	func TestMyAlgorithm(t *testing.T) {
+//		conformance.SchedulingAlgorithm(t, myalgo.New(), conformance.Options{})
+//	}
+//
+// 套件使用随机生成的 UAVMetrics（含缺失字段、边界值）做基于性质的检查：
+// 分数和权重在 [0,100] 内、相同输入结果确定、不修改输入、结果只引用输入中的节点，
+// 以及 UAVMetrics 经 unstructured 往返后不变。失败信息包含随机种子，
+// 通过 Options.Seed 复现。
+package conformance
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Options 套件参数
+type Options struct {
+	// Seed 随机种子，0 时使用 1
+	Seed int64
+
+	// Iterations 随机用例数，0 时使用 200
+	Iterations int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Seed == 0 {
+		o.Seed = 1
+	}
+	if o.Iterations <= 0 {
+		o.Iterations = 200
+	}
+	return o
+}
+
+// forEachCase 以确定的随机源运行 Iterations 个用例，失败时报告用例的种子
+func forEachCase(t *testing.T, opts Options, fn func(t *testing.T, r *rand.Rand)) {
+	t.Helper()
+	opts = opts.withDefaults()
+	for i := 0; i < opts.Iterations; i++ {
+		seed := opts.Seed + int64(i)
+		fn(t, rand.New(rand.NewSource(seed)))
+		if t.Failed() {
+			t.Logf("failing case: Options{Seed: %d, Iterations: 1}", seed)
+			return
+		}
+	}
+}
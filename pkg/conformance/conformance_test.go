@@ -0,0 +1,143 @@
+package conformance_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/conformance"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	routeralgo "github.com/k3suav/uav-monitor/pkg/router/algorithm"
+	scheduleralgo "github.com/k3suav/uav-monitor/pkg/scheduler/algorithm"
+	"github.com/k3suav/uav-monitor/pkg/terrain"
+)
+
+// 内置算法使用与 cmd/scheduler、cmd/router 相同的默认参数
+
+func schedulingAlgorithms() []scheduleralgo.SchedulingAlgorithm {
+	distance := scheduleralgo.NewDistanceBasedAlgorithm(34.0522, -118.2437)
+	battery := scheduleralgo.NewBatteryAwareAlgorithm(20.0)
+	return []scheduleralgo.SchedulingAlgorithm{
+		distance,
+		battery,
+		scheduleralgo.NewNetworkLatencyAlgorithm(200.0),
+		scheduleralgo.NewAirtimeBudgetAlgorithm(0),
+		scheduleralgo.NewAirtimeBudgetAlgorithm(60),
+		scheduleralgo.NewCompositeAlgorithm(
+			[]scheduleralgo.SchedulingAlgorithm{distance, battery},
+			[]float64{0.6, 0.4},
+		),
+	}
+}
+
+func routingAlgorithms(t *testing.T) []routeralgo.RoutingAlgorithm {
+	t.Helper()
+	// 没有高程瓦片时按海平面地形计算
+	dem, err := terrain.NewDEM(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create DEM: %v", err)
+	}
+	distance := routeralgo.NewDistanceBasedRouter(500.0)
+	battery := routeralgo.NewBatteryAwareRouter(20.0)
+	composite, err := routeralgo.NewCompositeRouter(
+		[]routeralgo.RoutingAlgorithm{distance, battery},
+		[]float64{0.6, 0.4},
+	)
+	if err != nil {
+		t.Fatalf("failed to create composite router: %v", err)
+	}
+	return []routeralgo.RoutingAlgorithm{
+		distance,
+		battery,
+		routeralgo.NewLineOfSightRouter(dem, 50, 10),
+		routeralgo.NewBandwidthRouter(routeralgo.NewRadioModel(5800, 20, 5, 5, 20), 1),
+		composite,
+	}
+}
+
+// newClient 创建不连接 API Server 的客户端，只用于 UAVMetrics 编解码
+func newClient(t *testing.T) *k8s.Client {
+	t.Helper()
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	data := []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: conformance
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: conformance
+  context:
+    cluster: conformance
+current-context: conformance
+`)
+	if err := os.WriteFile(kubeconfig, data, 0o600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Kubernetes.KubeconfigPath = kubeconfig
+	client, err := k8s.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestSchedulingAlgorithms(t *testing.T) {
+	for _, algo := range schedulingAlgorithms() {
+		t.Run(algo.Name(), func(t *testing.T) {
+			conformance.SchedulingAlgorithm(t, algo, conformance.Options{})
+		})
+	}
+}
+
+func TestRoutingAlgorithms(t *testing.T) {
+	for _, algo := range routingAlgorithms(t) {
+		t.Run(algo.Name(), func(t *testing.T) {
+			conformance.RoutingAlgorithm(t, algo, conformance.Options{})
+		})
+	}
+}
+
+func TestMetricsRoundTrip(t *testing.T) {
+	client := newClient(t)
+	conformance.MetricsRoundTrip(t, client.MetricsToUnstructured, client.UnstructuredToMetrics, conformance.Options{})
+}
+
+// Fuzz 目标以种子驱动随机生成器，go test -fuzz 时探索套件默认种子之外的用例
+
+func FuzzSchedulingAlgorithms(f *testing.F) {
+	f.Add(int64(1))
+	f.Add(int64(-1))
+	algorithms := schedulingAlgorithms()
+	f.Fuzz(func(t *testing.T, seed int64) {
+		for _, algo := range algorithms {
+			t.Run(algo.Name(), func(t *testing.T) {
+				conformance.SchedulingAlgorithm(t, algo, conformance.Options{Seed: seed, Iterations: 1})
+			})
+		}
+	})
+}
+
+func FuzzRoutingAlgorithms(f *testing.F) {
+	f.Add(int64(1))
+	f.Add(int64(-1))
+	f.Fuzz(func(t *testing.T, seed int64) {
+		for _, algo := range routingAlgorithms(t) {
+			t.Run(algo.Name(), func(t *testing.T) {
+				conformance.RoutingAlgorithm(t, algo, conformance.Options{Seed: seed, Iterations: 1})
+			})
+		}
+	})
+}
+
+func FuzzMetricsRoundTrip(f *testing.F) {
+	f.Add(int64(1))
+	f.Add(int64(-1))
+	f.Fuzz(func(t *testing.T, seed int64) {
+		client := newClient(t)
+		conformance.MetricsRoundTrip(t, client.MetricsToUnstructured, client.UnstructuredToMetrics, conformance.Options{Seed: seed, Iterations: 1})
+	})
+}
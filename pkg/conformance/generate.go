@@ -0,0 +1,159 @@
+package conformance
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// baseTime 生成时间戳的基准，避免用例依赖当前时间
+var baseTime = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// pick 从候选值中随机选择一个，边界值和常规值以相同概率出现
+func pick(r *rand.Rand, values ...float64) float64 {
+	return values[r.Intn(len(values))]
+}
+
+// maybe 以 1/2 概率返回 true，用于决定可选字段是否存在
+func maybe(r *rand.Rand) bool {
+	return r.Intn(2) == 0
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+// RandomMetrics 生成一份随机但合法的 UAVMetrics：数值在各字段的有效范围内，
+// 包含边界值（0%、100% 电量，极点和日期变更线附近的坐标等），可选部分随机缺失
+func RandomMetrics(r *rand.Rand, nodeName string) *models.UAVMetrics {
+	lastUpdate := baseTime.Add(-time.Duration(r.Intn(600)) * time.Second)
+	m := &models.UAVMetrics{
+		NodeName: nodeName,
+		GPS: models.GPSData{
+			Latitude:   pick(r, -90, 90, 0, -89.999, r.Float64()*180-90, 34.05+r.Float64()*0.1),
+			Longitude:  pick(r, -180, 180, 0, 179.999, r.Float64()*360-180, -118.25+r.Float64()*0.1),
+			Altitude:   pick(r, 0, -50, r.Float64()*500),
+			Heading:    r.Float64() * 360,
+			Speed:      pick(r, 0, r.Float64()*40),
+			Satellites: r.Intn(30),
+			Accuracy:   pick(r, 0, r.Float64()*20),
+			LastUpdate: lastUpdate,
+		},
+		Battery: models.BatteryData{
+			RemainingPercent: pick(r, 0, 100, 5, r.Float64()*100),
+			Voltage:          pick(r, 0, 9.6+r.Float64()*3),
+			Current:          pick(r, 0, -r.Float64()*20, r.Float64()*5),
+			Temperature:      pick(r, -20, 60, r.Float64()*40),
+			TimeRemaining:    r.Intn(3600),
+			CycleCount:       r.Intn(500),
+		},
+	}
+
+	if maybe(r) {
+		m.GPS.FixType = []string{models.GNSSFixNone, models.GNSSFix2D, models.GNSSFix3D, models.GNSSFixRTKFixed}[r.Intn(4)]
+		m.GPS.HDOP = floatPtr(r.Float64() * 5)
+	}
+	if maybe(r) {
+		m.GPS.Integrity = &models.GNSSIntegrity{Quality: models.PositionQualityGood}
+		if maybe(r) {
+			m.GPS.Integrity.Quality = models.PositionQualityDegraded
+			m.GPS.Integrity.HorizontalDivergence = r.Float64() * 500
+			m.GPS.Integrity.Reasons = []string{"position diverges from dead reckoning"}
+		}
+	}
+	if maybe(r) {
+		m.Flight = &models.FlightData{
+			Armed:    maybe(r),
+			Mode:     []string{models.FlightModeManual, models.FlightModeAuto, models.FlightModeRTL}[r.Intn(3)],
+			IsFlying: maybe(r),
+			Altitude: r.Float64() * 120,
+		}
+	}
+	if maybe(r) {
+		m.Network = &models.NetworkData{
+			Latency:        pick(r, 0, 1, 1000, r.Float64()*300),
+			Bandwidth:      pick(r, 0, r.Float64()*100),
+			SignalStrength: -r.Intn(120),
+			PacketLoss:     pick(r, 0, 100, r.Float64()*10),
+			ConnectionType: []string{"wifi", "4G", "5G", "mesh"}[r.Intn(4)],
+		}
+	}
+	if maybe(r) {
+		m.Performance = &models.PerformanceData{
+			CPUUsage:    r.Float64() * 100,
+			MemoryUsage: r.Float64() * 100,
+			DiskUsage:   r.Float64() * 100,
+			Temperature: 30 + r.Float64()*50,
+			Uptime:      r.Int63n(1 << 20),
+		}
+	}
+	if maybe(r) {
+		status := []string{models.HealthStatusHealthy, models.HealthStatusWarning, models.HealthStatusCritical, models.HealthStatusUnknown}[r.Intn(4)]
+		m.Health = &models.HealthData{Status: status, LastHealthCheck: lastUpdate}
+		if status != models.HealthStatusHealthy {
+			m.Health.Warnings = []string{"Low battery"}
+		}
+	}
+	if maybe(r) {
+		m.Metadata = &models.MetadataInfo{HardwareModel: fmt.Sprintf("model-%d", r.Intn(3)), FirmwareVersion: "1.0.0"}
+	}
+	if maybe(r) {
+		m.Airtime = &models.AirtimeData{
+			Date:            baseTime.Format("2006-01-02"),
+			AirborneMinutes: r.Float64() * 240,
+			BudgetMinutes:   pick(r, 0, 120),
+		}
+	}
+	if maybe(r) {
+		m.Home = &models.HomeData{
+			Latitude:             m.GPS.Latitude,
+			Longitude:            m.GPS.Longitude,
+			Source:               models.HomeSourceFirstFix,
+			Distance:             r.Float64() * 5000,
+			ReturnBatteryPercent: pick(r, 0, r.Float64()*50, 120),
+		}
+	}
+	if maybe(r) {
+		m.Altitude = &models.AltitudeData{
+			Fused:      m.GPS.Altitude,
+			Source:     models.AltitudeSourceFused,
+			Barometric: floatPtr(m.GPS.Altitude + r.Float64()*5),
+		}
+	}
+	return m
+}
+
+// RandomFleet 生成 n 份节点名不同的随机 UAVMetrics
+func RandomFleet(r *rand.Rand, n int) []*models.UAVMetrics {
+	fleet := make([]*models.UAVMetrics, 0, n)
+	for i := 0; i < n; i++ {
+		fleet = append(fleet, RandomMetrics(r, fmt.Sprintf("uav-%d", i)))
+	}
+	return fleet
+}
+
+// RandomPod 生成一个待调度的 Pod，资源请求、标签和注解随机
+func RandomPod(r *rand.Rand) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("pod-%d", r.Intn(1000)),
+			Namespace: "default",
+			Labels:    map[string]string{"app": "conformance"},
+		},
+		Spec: v1.PodSpec{
+			SchedulerName: "uav-scheduler",
+			Containers:    []v1.Container{{Name: "app", Image: "busybox"}},
+		},
+	}
+	if maybe(r) {
+		pod.Spec.Containers[0].Resources.Requests = v1.ResourceList{
+			v1.ResourceCPU:    *resource.NewMilliQuantity(int64(r.Intn(2000)), resource.DecimalSI),
+			v1.ResourceMemory: *resource.NewQuantity(int64(r.Intn(512))<<20, resource.BinarySI),
+		}
+	}
+	return pod
+}
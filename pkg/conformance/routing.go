@@ -0,0 +1,122 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/router/algorithm"
+)
+
+// RandomEndpoints 生成 service 的 n 个 endpoint，分布在 fleet 的节点上；
+// 部分 endpoint 所在节点不在 fleet 中（如节点的 UAVMetrics 尚未发布）
+func RandomEndpoints(r *rand.Rand, service string, fleet []*models.UAVMetrics, n int) []algorithm.Endpoint {
+	endpoints := make([]algorithm.Endpoint, 0, n)
+	for i := 0; i < n; i++ {
+		nodeName := "unknown-node"
+		if len(fleet) > 0 && r.Intn(8) != 0 {
+			nodeName = fleet[r.Intn(len(fleet))].NodeName
+		}
+		endpoints = append(endpoints, algorithm.Endpoint{
+			PodName:   fmt.Sprintf("%s-%d", service, i),
+			PodIP:     fmt.Sprintf("10.42.%d.%d", i/250, i%250+1),
+			NodeName:  nodeName,
+			Namespace: "default",
+			Service:   service,
+			Port:      8080,
+		})
+	}
+	return endpoints
+}
+
+// RoutingAlgorithm 检查路由算法是否满足 Router Agent 对算法的约定：
+//   - Name 非空
+//   - 权重在 [0,100] 内，优先级不小于 0
+//   - 只为输入中的 endpoint 分配权重，每个 endpoint 最多一个
+//   - 相同输入的结果相同
+//   - 不修改输入的 endpoint 和 UAVMetrics
+//   - 没有 endpoint 时不 panic
+func RoutingAlgorithm(t *testing.T, algo algorithm.RoutingAlgorithm, opts Options) {
+	t.Helper()
+	ctx := context.Background()
+
+	t.Run("Name", func(t *testing.T) {
+		if algo.Name() == "" {
+			t.Error("Name() is empty")
+		}
+	})
+
+	t.Run("NoEndpoints", func(t *testing.T) {
+		r := rand.New(rand.NewSource(opts.withDefaults().Seed))
+		fleet := RandomFleet(r, 3)
+		weights, err := algo.ComputeWeights(ctx, fleet[0].NodeName, fleet[0], nil, byNode(fleet))
+		if err == nil && len(weights) > 0 {
+			t.Errorf("ComputeWeights with no endpoints returned %d weights", len(weights))
+		}
+	})
+
+	t.Run("ComputeWeights", func(t *testing.T) {
+		forEachCase(t, opts, func(t *testing.T, r *rand.Rand) {
+			fleet := RandomFleet(r, r.Intn(8)+1)
+			source := fleet[r.Intn(len(fleet))]
+			endpoints := RandomEndpoints(r, "svc", fleet, r.Intn(10)+1)
+			targets := byNode(fleet)
+			before := snapshot(t, []interface{}{endpoints, fleet})
+
+			weights, err := algo.ComputeWeights(ctx, source.NodeName, source, endpoints, targets)
+			if err != nil {
+				return
+			}
+			known := make(map[algorithm.Endpoint]bool, len(endpoints))
+			for _, ep := range endpoints {
+				known[ep] = true
+			}
+			seen := make(map[algorithm.Endpoint]bool, len(weights))
+			for _, w := range weights {
+				if w.Weight < 0 || w.Weight > 100 {
+					t.Errorf("endpoint %s weighted %d, want a value in [0,100]", w.Endpoint.PodName, w.Weight)
+				}
+				if w.Priority < 0 {
+					t.Errorf("endpoint %s has priority %d, want >= 0", w.Endpoint.PodName, w.Priority)
+				}
+				if !known[w.Endpoint] {
+					t.Errorf("weight for endpoint %+v which is not in the input", w.Endpoint)
+				}
+				if seen[w.Endpoint] {
+					t.Errorf("endpoint %s weighted more than once", w.Endpoint.PodName)
+				}
+				seen[w.Endpoint] = true
+			}
+
+			again, err := algo.ComputeWeights(ctx, source.NodeName, source, endpoints, targets)
+			if err != nil {
+				t.Errorf("ComputeWeights failed on a repeated call with the same input: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(weightMap(weights), weightMap(again)) {
+				t.Errorf("ComputeWeights is not deterministic: %v then %v", weightMap(weights), weightMap(again))
+			}
+			checkUnchanged(t, "ComputeWeights", before, []interface{}{endpoints, fleet})
+		})
+	})
+}
+
+func byNode(fleet []*models.UAVMetrics) map[string]*models.UAVMetrics {
+	out := make(map[string]*models.UAVMetrics, len(fleet))
+	for _, m := range fleet {
+		out[m.NodeName] = m
+	}
+	return out
+}
+
+// weightMap 按 endpoint 汇总权重和优先级
+func weightMap(weights []algorithm.EndpointWeight) map[string][2]int {
+	out := make(map[string][2]int, len(weights))
+	for _, w := range weights {
+		out[w.Endpoint.PodName] = [2]int{w.Weight, w.Priority}
+	}
+	return out
+}
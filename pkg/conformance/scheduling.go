@@ -0,0 +1,156 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/algorithm"
+)
+
+// SchedulingAlgorithm 检查调度算法是否满足调度器对算法的约定：
+//   - Name 非空
+//   - Filter 返回输入的子集（或 nil 表示不过滤），不重复
+//   - Score 的分数在 [0,100] 内，只针对输入中的节点，每个节点最多一个分数
+//   - 相同输入的 Filter 和 Score 结果相同
+//   - 不修改输入的 UAVMetrics
+//   - 空输入不 panic
+func SchedulingAlgorithm(t *testing.T, algo algorithm.SchedulingAlgorithm, opts Options) {
+	t.Helper()
+	ctx := context.Background()
+
+	t.Run("Name", func(t *testing.T) {
+		if algo.Name() == "" {
+			t.Error("Name() is empty")
+		}
+	})
+
+	t.Run("EmptyInput", func(t *testing.T) {
+		pod := RandomPod(rand.New(rand.NewSource(opts.withDefaults().Seed)))
+		if _, err := algo.Filter(ctx, pod, nil); err != nil {
+			t.Logf("Filter with no nodes returned an error: %v", err)
+		}
+		scores, err := algo.Score(ctx, pod, nil)
+		if err == nil && len(scores) > 0 {
+			t.Errorf("Score with no nodes returned %d scores", len(scores))
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		forEachCase(t, opts, func(t *testing.T, r *rand.Rand) {
+			pod, fleet := RandomPod(r), RandomFleet(r, r.Intn(8)+1)
+			before := snapshot(t, fleet)
+
+			filtered, err := algo.Filter(ctx, pod, fleet)
+			if err != nil {
+				return
+			}
+			checkSubset(t, filtered, fleet)
+
+			again, err := algo.Filter(ctx, pod, fleet)
+			if err != nil {
+				t.Errorf("Filter failed on a repeated call with the same input: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(nodeNames(filtered), nodeNames(again)) {
+				t.Errorf("Filter is not deterministic: %v then %v", nodeNames(filtered), nodeNames(again))
+			}
+			checkUnchanged(t, "Filter", before, fleet)
+		})
+	})
+
+	t.Run("Score", func(t *testing.T) {
+		forEachCase(t, opts, func(t *testing.T, r *rand.Rand) {
+			pod, fleet := RandomPod(r), RandomFleet(r, r.Intn(8)+1)
+			before := snapshot(t, fleet)
+
+			scores, err := algo.Score(ctx, pod, fleet)
+			if err != nil {
+				return
+			}
+			known := make(map[string]bool, len(fleet))
+			for _, m := range fleet {
+				known[m.NodeName] = true
+			}
+			seen := make(map[string]bool, len(scores))
+			for _, s := range scores {
+				if math.IsNaN(s.Score) || s.Score < 0 || s.Score > 100 {
+					t.Errorf("node %s scored %v, want a value in [0,100]", s.NodeName, s.Score)
+				}
+				if !known[s.NodeName] {
+					t.Errorf("score for node %q which is not in the input", s.NodeName)
+				}
+				if seen[s.NodeName] {
+					t.Errorf("node %s scored more than once", s.NodeName)
+				}
+				seen[s.NodeName] = true
+			}
+
+			again, err := algo.Score(ctx, pod, fleet)
+			if err != nil {
+				t.Errorf("Score failed on a repeated call with the same input: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(scoreMap(scores), scoreMap(again)) {
+				t.Errorf("Score is not deterministic: %v then %v", scoreMap(scores), scoreMap(again))
+			}
+			checkUnchanged(t, "Score", before, fleet)
+		})
+	})
+}
+
+// checkSubset 检查 filtered 是 fleet 的子集且不重复
+func checkSubset(t *testing.T, filtered, fleet []*models.UAVMetrics) {
+	t.Helper()
+	known := make(map[*models.UAVMetrics]bool, len(fleet))
+	for _, m := range fleet {
+		known[m] = true
+	}
+	seen := make(map[*models.UAVMetrics]bool, len(filtered))
+	for _, m := range filtered {
+		if !known[m] {
+			t.Errorf("Filter returned metrics that are not in the input (node %q)", m.NodeName)
+		}
+		if seen[m] {
+			t.Errorf("Filter returned node %s more than once", m.NodeName)
+		}
+		seen[m] = true
+	}
+}
+
+func nodeNames(metrics []*models.UAVMetrics) []string {
+	names := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		names = append(names, m.NodeName)
+	}
+	return names
+}
+
+func scoreMap(scores []algorithm.NodeScore) map[string]float64 {
+	out := make(map[string]float64, len(scores))
+	for _, s := range scores {
+		out[s.NodeName] = s.Score
+	}
+	return out
+}
+
+// snapshot 记录输入的 JSON 编码，用于检查算法是否修改了输入
+func snapshot(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to encode input: %v", err)
+	}
+	return string(data)
+}
+
+func checkUnchanged(t *testing.T, method, before string, v interface{}) {
+	t.Helper()
+	if after := snapshot(t, v); after != before {
+		t.Errorf("%s modified its input", method)
+	}
+}
@@ -0,0 +1,68 @@
+package conversion
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// fieldManager owns the conversion of the UAVMetrics CRD
+	fieldManager = "uav-conversion"
+
+	// installInterval is how often the conversion is applied again, so a
+	// reinstalled CRD or a rotated CA is picked up
+	installInterval = time.Minute
+)
+
+// Install keeps the UAVMetrics CRD's conversion pointed at the webhook's
+// Service until ctx is done. Without it the CRD keeps the None strategy and
+// the API server drops the fields v1alpha1 lacks from v1beta1 writes. The
+// CRD itself is installed by the scheduler or the aggregator (or kubectl),
+// so until it exists applying is retried.
+func Install(ctx context.Context, client *k8s.Client, cfg config.ConversionWebhookConfig, log *logrus.Logger) {
+	namespace, name, _ := strings.Cut(cfg.Service, "/")
+	entry := log.WithField("service", cfg.Service)
+
+	ticker := time.NewTicker(installInterval)
+	defer ticker.Stop()
+	installed := false
+	for {
+		caBundle, err := readCABundle(cfg)
+		if err == nil {
+			err = client.EnsureCRDConversion(ctx, namespace, name, caBundle, fieldManager)
+		}
+		switch {
+		case err != nil:
+			entry.WithError(err).Warn("Failed to set the UAVMetrics CRD conversion")
+		case !installed:
+			entry.Info("UAVMetrics CRD converted by this webhook")
+		}
+		installed = err == nil
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// readCABundle returns the CA of the serving certificate, the certificate
+// itself when there is no CA file
+func readCABundle(cfg config.ConversionWebhookConfig) ([]byte, error) {
+	caBundle, err := os.ReadFile(cfg.CAFile)
+	if os.IsNotExist(err) {
+		caBundle, err = os.ReadFile(cfg.CertFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	return caBundle, nil
+}
@@ -0,0 +1,147 @@
+// Package conversion implements the CRD conversion webhook of UAVMetrics:
+// the API server calls it to convert objects between v1alpha1, the storage
+// version, and v1beta1, whose added fields v1alpha1 can only hold in an
+// annotation.
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/k3suav/uav-monitor/pkg/apis/uav/v1alpha1"
+	"github.com/k3suav/uav-monitor/pkg/apis/uav/v1beta1"
+	"github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// maxReviewSize bounds the body of a ConversionReview; the API server sends
+// at most a list page of objects
+const maxReviewSize = 32 << 20
+
+// Review is an apiextensions.k8s.io/v1 ConversionReview
+type Review struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Request  *ReviewRequest  `json:"request,omitempty"`
+	Response *ReviewResponse `json:"response,omitempty"`
+}
+
+// ReviewRequest asks to convert objects to DesiredAPIVersion
+type ReviewRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+// ReviewResponse holds the converted objects, in the order of the request,
+// or the reason the conversion failed
+type ReviewResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// Webhook serves ConversionReviews for UAVMetrics
+type Webhook struct {
+	scheme *runtime.Scheme
+	codecs serializer.CodecFactory
+	log    *logrus.Logger
+}
+
+// NewWebhook creates a webhook converting between the UAVMetrics versions
+func NewWebhook(log *logrus.Logger) (*Webhook, error) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to register v1alpha1: %w", err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to register v1beta1: %w", err)
+	}
+	return &Webhook{scheme: scheme, codecs: serializer.NewCodecFactory(scheme), log: log}, nil
+}
+
+// ServeHTTP answers a ConversionReview. A request that can't be read gets a
+// 400; a failed conversion gets a review with a Failure result, which fails
+// the API request that needed it.
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReviewSize))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("failed to read request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var review Review
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(rw, "request is not a ConversionReview", http.StatusBadRequest)
+		return
+	}
+
+	response := &ReviewResponse{UID: review.Request.UID}
+	converted, err := w.Convert(review.Request.Objects, review.Request.DesiredAPIVersion)
+	if err != nil {
+		w.log.WithError(err).WithField("desiredAPIVersion", review.Request.DesiredAPIVersion).Warn("UAVMetrics conversion failed")
+		response.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+	} else {
+		response.ConvertedObjects = converted
+		response.Result = metav1.Status{Status: metav1.StatusSuccess}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(Review{TypeMeta: review.TypeMeta, Response: response})
+}
+
+// Convert converts objects to desiredAPIVersion. Objects already at that
+// version are returned unchanged.
+func (w *Webhook) Convert(objects []runtime.RawExtension, desiredAPIVersion string) ([]runtime.RawExtension, error) {
+	desired, err := schema.ParseGroupVersion(desiredAPIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid desired API version %q: %w", desiredAPIVersion, err)
+	}
+
+	converted := make([]runtime.RawExtension, 0, len(objects))
+	for _, object := range objects {
+		data, err := w.convert(object.Raw, desired)
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, runtime.RawExtension{Raw: data})
+	}
+	return converted, nil
+}
+
+func (w *Webhook) convert(data []byte, desired schema.GroupVersion) ([]byte, error) {
+	in, gvk, err := w.codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode object: %w", err)
+	}
+	if gvk.GroupVersion() == desired {
+		return data, nil
+	}
+
+	target := desired.WithKind(gvk.Kind)
+	out, err := w.scheme.New(target)
+	if err != nil {
+		return nil, fmt.Errorf("can't convert %s to %s: %w", gvk.Kind, desired, err)
+	}
+	if err := w.scheme.Convert(in, out, nil); err != nil {
+		return nil, fmt.Errorf("failed to convert %s from %s to %s: %w", gvk.Kind, gvk.GroupVersion(), desired, err)
+	}
+	out.GetObjectKind().SetGroupVersionKind(target)
+
+	data, err = json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode converted object: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,170 @@
+package decommission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// archive writes the vehicle's UAVMetrics, UAVEnrollment and, through the
+// agent's local API, its history and recording to a directory of the
+// archive directory. Rerunning it overwrites the same directory.
+func (c *Controller) archive(ctx context.Context, d *models.UAVDecommission) (string, string, error) {
+	// Both name a directory: neither may reach outside the archive directory
+	if errs := validation.IsDNS1123Subdomain(d.Spec.NodeName); len(errs) > 0 {
+		return "", "", fmt.Errorf("invalid nodeName %q: %s", d.Spec.NodeName, strings.Join(errs, "; "))
+	}
+	if d.Status.Archive == "" {
+		d.Status.Archive = fmt.Sprintf("%s-%s", d.Spec.NodeName, time.Now().UTC().Format("20060102T150405Z"))
+	}
+	if errs := validation.IsDNS1123Subdomain(strings.ToLower(d.Status.Archive)); len(errs) > 0 {
+		return "", "", fmt.Errorf("invalid archive %q: %s", d.Status.Archive, strings.Join(errs, "; "))
+	}
+	dir := filepath.Join(c.cfg.ArchiveDir, d.Status.Archive)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	var archived, missing []string
+
+	metrics, err := c.client.GetUAVMetrics(ctx, d.Spec.NodeName)
+	switch {
+	case err == nil:
+		if err := writeJSON(filepath.Join(dir, "uavmetrics.json"), metrics); err != nil {
+			return "", "", err
+		}
+		archived = append(archived, "UAVMetrics")
+	case apierrors.IsNotFound(err):
+		missing = append(missing, "UAVMetrics")
+	default:
+		return "", "", err
+	}
+
+	enrollment, err := c.client.GetEnrollment(ctx, d.Spec.NodeName)
+	switch {
+	case err == nil:
+		if err := writeJSON(filepath.Join(dir, "enrollment.json"), enrollment); err != nil {
+			return "", "", err
+		}
+		archived = append(archived, "UAVEnrollment")
+	case apierrors.IsNotFound(err):
+		missing = append(missing, "UAVEnrollment")
+	default:
+		return "", "", err
+	}
+
+	if c.cfg.AgentAPIPort > 0 {
+		pod, err := c.agentPod(ctx, d.Spec.NodeName)
+		if err != nil {
+			return "", "", err
+		}
+		if pod == nil {
+			missing = append(missing, "history", "recording (agent not running)")
+		} else {
+			for _, data := range []string{"history", "recording"} {
+				found, err := c.fetchAgentData(ctx, pod, d.Spec.NodeName, data, filepath.Join(dir, data+".json"))
+				if err != nil {
+					return "", "", err
+				}
+				if found {
+					archived = append(archived, data)
+				} else {
+					missing = append(missing, data+" (disabled on the agent)")
+				}
+			}
+		}
+	}
+
+	message := fmt.Sprintf("archived %s to %s", strings.Join(archived, ", "), d.Status.Archive)
+	if len(archived) == 0 {
+		message = "nothing to archive"
+	}
+	if len(missing) > 0 {
+		message += fmt.Sprintf("; not found: %s", strings.Join(missing, ", "))
+	}
+	return models.StepDone, message, nil
+}
+
+// agentPod returns the running agent pod on the vehicle's Node, nil if there
+// is none
+func (c *Controller) agentPod(ctx context.Context, nodeName string) (*v1.Pod, error) {
+	pods, err := c.client.NodePods(ctx, c.namespace, c.cfg.AgentSelector, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods {
+		if pods[i].Status.Phase == v1.PodRunning && pods[i].Status.PodIP != "" {
+			return &pods[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// fetchAgentData saves the response of the agent's /api/v1/<data> endpoint
+// for the vehicle to path. It returns false when the agent has the data
+// disabled.
+func (c *Controller) fetchAgentData(ctx context.Context, pod *v1.Pod, nodeName, data, path string) (bool, error) {
+	endpoint := url.URL{
+		Scheme:   "http",
+		Host:     net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(c.cfg.AgentAPIPort)),
+		Path:     "/api/v1/" + data,
+		RawQuery: url.Values{"node": {nodeName}}.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch %s from agent %s: %w", data, pod.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, fmt.Errorf("failed to fetch %s from agent %s: %s: %s", data, pod.Name, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return false, fmt.Errorf("failed to fetch %s from agent %s: %w", data, pod.Name, err)
+	}
+	if err := f.Close(); err != nil {
+		return false, err
+	}
+	return true, os.Rename(f.Name(), path)
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+// Package decommission retires vehicles from the fleet. A UAVDecommission
+// names the vehicle; the controller runs the steps of models.DecommissionSteps
+// in order and records the progress of each in the status, so an
+// interrupted decommission resumes where it stopped. The controller also
+// cleans up after UAVMetrics deleted with the agent's cleanup finalizer.
+package decommission
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/migration"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// stepFunc runs one step and returns StepDone or StepSkipped when it
+// finished, or StepRunning while it waits for the cluster
+type stepFunc func(ctx context.Context, d *models.UAVDecommission) (state, message string, err error)
+
+// Controller advances UAVDecommissions
+type Controller struct {
+	client    *k8s.Client
+	cfg       config.DecommissionConfig
+	namespace string
+	log       *logrus.Logger
+	http      *http.Client
+	events    *k8s.EventRecorder
+
+	// Migrates the vehicle's stateful workloads, nil when no checkpoint
+	// registry is configured
+	checkpointer *migration.Checkpointer
+
+	steps   map[string]stepFunc
+	cleanup []cleanupHook
+}
+
+// NewController creates a controller working through client. Without a
+// checkpointer the Migrate step is skipped and Drain evicts every pod.
+func NewController(client *k8s.Client, checkpointer *migration.Checkpointer, cfg *config.Config, log *logrus.Logger) *Controller {
+	c := &Controller{
+		client:       client,
+		cfg:          cfg.Decommission,
+		namespace:    cfg.Kubernetes.Namespace,
+		log:          log,
+		http:         &http.Client{Timeout: cfg.Decommission.AgentAPITimeout},
+		events:       client.EventRecorder("uav-decommission", ""),
+		checkpointer: checkpointer,
+	}
+	c.steps = map[string]stepFunc{
+		models.StepMigrate:    c.migrate,
+		models.StepDrain:      c.drain,
+		models.StepArchive:    c.archive,
+		models.StepStopAgent:  c.stopAgent,
+		models.StepRevoke:     c.revoke,
+		models.StepCleanNode:  c.cleanNode,
+		models.StepDeleteData: c.deleteResources,
+	}
+	c.cleanup = []cleanupHook{c.clearNode, c.notifyDeregistered}
+	return c
+}
+
+// Run advances decommissions and finalizes deleted UAVMetrics every
+// interval until ctx is cancelled
+func (c *Controller) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.reconcile(ctx)
+		c.finalize(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Controller) reconcile(ctx context.Context) {
+	decommissions, err := c.client.ListDecommissions(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.log.WithError(err).Warn("Failed to list decommissions")
+		}
+		return
+	}
+
+	for _, d := range decommissions {
+		if d.Status.Phase != models.DecommissionCompleted {
+			c.advance(ctx, d)
+		}
+	}
+}
+
+// advance runs the steps of d from the first unfinished one, until a step
+// waits or fails. The status is written after every step.
+func (c *Controller) advance(ctx context.Context, d *models.UAVDecommission) {
+	entry := c.log.WithField("nodeName", d.Spec.NodeName)
+	if d.Status.Phase == "" || d.Status.Phase == models.DecommissionPending {
+		d.Status.Phase = models.DecommissionInProgress
+		d.Status.StartedAt = time.Now().UTC().Truncate(time.Second)
+		d.Status.Steps = make([]models.DecommissionStep, 0, len(models.DecommissionSteps))
+		for _, name := range models.DecommissionSteps {
+			d.Status.Steps = append(d.Status.Steps, models.DecommissionStep{Name: name, State: models.StepPending})
+		}
+		entry.WithField("reason", d.Spec.Reason).Info("Decommission started")
+	}
+
+	for _, name := range models.DecommissionSteps {
+		step := d.Status.Step(name)
+		if step == nil {
+			d.Status.Steps = append(d.Status.Steps, models.DecommissionStep{Name: name, State: models.StepPending})
+			step = &d.Status.Steps[len(d.Status.Steps)-1]
+		}
+		if step.Finished() {
+			continue
+		}
+
+		before := *step
+		state, message, err := c.steps[name](ctx, d)
+		if err != nil {
+			// Retried on the next round
+			state, message = models.StepRunning, err.Error()
+			entry.WithError(err).WithField("step", name).Warn("Decommission step failed")
+		}
+		step.State, step.Message = state, message
+		if step.Finished() {
+			step.CompletedAt = time.Now().UTC().Truncate(time.Second)
+			entry.WithFields(logrus.Fields{"step": name, "state": state, "message": message}).Info("Decommission step finished")
+		}
+		if !step.Finished() {
+			if *step != before {
+				c.updateStatus(ctx, d, entry)
+			}
+			return
+		}
+		if !c.updateStatus(ctx, d, entry) {
+			return
+		}
+	}
+
+	d.Status.Phase = models.DecommissionCompleted
+	d.Status.CompletedAt = time.Now().UTC().Truncate(time.Second)
+	if c.updateStatus(ctx, d, entry) {
+		entry.WithField("archive", d.Status.Archive).Info("UAV decommissioned")
+	}
+}
+
+func (c *Controller) updateStatus(ctx context.Context, d *models.UAVDecommission, entry *logrus.Entry) bool {
+	if err := c.client.UpdateDecommissionStatus(ctx, d); err != nil {
+		entry.WithError(err).Warn("Failed to update decommission status")
+		return false
+	}
+	return true
+}
+
+// migrate cordons the Node, then checkpoints the pods marked with
+// migration.CheckpointAnnotation and restores them on the replacement node.
+// The originals keep running until Drain evicts them.
+func (c *Controller) migrate(ctx context.Context, d *models.UAVDecommission) (string, string, error) {
+	if c.checkpointer == nil {
+		return models.StepSkipped, "no checkpoint registry configured", nil
+	}
+	err := c.client.CordonNode(ctx, d.Spec.NodeName)
+	if apierrors.IsNotFound(err) {
+		return models.StepSkipped, "node not found", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	restored, pending, err := c.checkpointer.MigrateNode(ctx, d.Spec.NodeName, d.Spec.ReplacementNode)
+	if err != nil {
+		return "", "", err
+	}
+	if pending > 0 {
+		return models.StepRunning, fmt.Sprintf("waiting for %d pods to be checkpointed and restored", pending), nil
+	}
+	if restored == 0 {
+		return models.StepSkipped, "no pods to migrate", nil
+	}
+	return models.StepDone, fmt.Sprintf("%d pods restored from checkpoints", restored), nil
+}
+
+// drain cordons the Node and evicts its workloads
+func (c *Controller) drain(ctx context.Context, d *models.UAVDecommission) (string, string, error) {
+	err := c.client.CordonNode(ctx, d.Spec.NodeName)
+	if apierrors.IsNotFound(err) {
+		return models.StepSkipped, "node not found", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	remaining, err := c.client.EvictNodePods(ctx, d.Spec.NodeName)
+	if err != nil {
+		return "", "", err
+	}
+	if remaining > 0 {
+		return models.StepRunning, fmt.Sprintf("waiting for %d pods to be evicted", remaining), nil
+	}
+	return models.StepDone, "node cordoned and drained", nil
+}
+
+// stopAgent labels the Node as decommissioned and waits for the agent
+// DaemonSet to remove its pod, so the agent stops recreating the vehicle's
+// UAVMetrics and Node labels
+func (c *Controller) stopAgent(ctx context.Context, d *models.UAVDecommission) (string, string, error) {
+	err := c.client.MarkNodeDecommissioned(ctx, d.Spec.NodeName)
+	if apierrors.IsNotFound(err) {
+		return models.StepSkipped, "node not found", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	pods, err := c.client.NodePods(ctx, c.namespace, c.cfg.AgentSelector, d.Spec.NodeName)
+	if err != nil {
+		return "", "", err
+	}
+	if len(pods) > 0 {
+		return models.StepRunning, fmt.Sprintf("waiting for agent pod %s to stop (the agent DaemonSet must exclude nodes labelled %s)", pods[0].Name, k8s.LabelDecommissioned), nil
+	}
+	return models.StepDone, "agent stopped", nil
+}
+
+// revoke denies the vehicle's enrollment
+func (c *Controller) revoke(ctx context.Context, d *models.UAVDecommission) (string, string, error) {
+	reason := "decommissioned"
+	if d.Spec.Reason != "" {
+		reason = fmt.Sprintf("decommissioned: %s", d.Spec.Reason)
+	}
+	err := c.client.RevokeEnrollment(ctx, d.Spec.NodeName, reason)
+	if apierrors.IsNotFound(err) {
+		return models.StepSkipped, "vehicle not enrolled", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return models.StepDone, "enrollment denied, certificate no longer renewed", nil
+}
+
+// cleanNode removes the vehicle's labels, taint and conditions from the Node
+func (c *Controller) cleanNode(ctx context.Context, d *models.UAVDecommission) (string, string, error) {
+	err := c.client.ClearNodeUAVState(ctx, d.Spec.NodeName)
+	if apierrors.IsNotFound(err) {
+		return models.StepSkipped, "node not found", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return models.StepDone, "UAV labels, taints and conditions removed", nil
+}
+
+// deleteResources deletes the vehicle's route overrides and UAVMetrics,
+// then the Node when requested. The denied UAVEnrollment is kept as a
+// tombstone, so the vehicle isn't enrolled again if it comes back.
+func (c *Controller) deleteResources(ctx context.Context, d *models.UAVDecommission) (string, string, error) {
+	node := d.Spec.NodeName
+
+	overrides, err := c.client.ListRouteOverrides(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	for _, o := range overrides {
+		if o.NodeName != node {
+			continue
+		}
+		if err := c.client.DeleteRouteOverride(ctx, o.Name); err != nil && !apierrors.IsNotFound(err) {
+			return "", "", err
+		}
+	}
+
+	if err := c.client.DeleteUAVMetrics(ctx, node); err != nil && !apierrors.IsNotFound(err) {
+		return "", "", err
+	}
+
+	if !d.Spec.DeleteNode {
+		return models.StepDone, "UAVMetrics and route overrides deleted", nil
+	}
+	if err := c.client.DeleteNode(ctx, node); err != nil && !apierrors.IsNotFound(err) {
+		return "", "", err
+	}
+	return models.StepDone, "UAVMetrics, route overrides and node deleted", nil
+}
@@ -0,0 +1,74 @@
+package decommission
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// cleanupHook runs when a UAVMetrics holding k8s.CleanupFinalizer is
+// deleted, before the finalizer is removed. Hooks run in order and must be
+// idempotent: after an error all of them run again on the next round.
+type cleanupHook func(ctx context.Context, metrics *models.UAVMetrics) error
+
+// finalize runs the cleanup hooks of every deleted UAVMetrics still holding
+// the cleanup finalizer, then removes the finalizer
+func (c *Controller) finalize(ctx context.Context) {
+	metrics, err := c.client.ListFinalizingUAVMetrics(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.log.WithError(err).Warn("Failed to list deleted UAVMetrics")
+		}
+		return
+	}
+
+	for _, m := range metrics {
+		entry := c.log.WithField("nodeName", m.NodeName)
+		if err := c.runCleanupHooks(ctx, m); err != nil {
+			// Retried on the next round
+			entry.WithError(err).Warn("UAVMetrics cleanup failed")
+			continue
+		}
+		if err := c.client.RemoveCleanupFinalizer(ctx, m.NodeName); err != nil {
+			entry.WithError(err).Warn("Failed to remove UAVMetrics finalizer")
+			continue
+		}
+		entry.Info("UAV deregistered")
+	}
+}
+
+func (c *Controller) runCleanupHooks(ctx context.Context, metrics *models.UAVMetrics) error {
+	for _, hook := range c.cleanup {
+		if err := hook(ctx, metrics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearNode removes the vehicle's labels, taint and conditions from the
+// Node, if it still exists
+func (c *Controller) clearNode(ctx context.Context, metrics *models.UAVMetrics) error {
+	err := c.client.ClearNodeUAVState(ctx, metrics.NodeName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// notifyDeregistered records the Deregistered Event on the UAVMetrics and
+// the Node, for controllers watching either
+func (c *Controller) notifyDeregistered(ctx context.Context, metrics *models.UAVMetrics) error {
+	message := fmt.Sprintf("UAVMetrics %s deleted, UAV state removed from node %s", c.client.ResourceName(metrics.NodeName), metrics.NodeName)
+	err := c.events.UAVMetricsEvent(ctx, metrics.NodeName, v1.EventTypeNormal, k8s.ReasonDeregistered, message)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	c.events.NodeEvent(metrics.NodeName, v1.EventTypeNormal, k8s.ReasonDeregistered, message)
+	return nil
+}
@@ -0,0 +1,308 @@
+// Package enrollment implements the fleet enrollment of vehicles: agents
+// request a certificate for their identity key through a UAVEnrollment, and
+// the controller approves the request (by an operator or a policy) and
+// issues the certificate from the fleet CA.
+package enrollment
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ErrDenied is returned by Enroll when the enrollment was denied
+var ErrDenied = errors.New("enrollment denied")
+
+// Paths returns the identity key, certificate and fleet CA files of a vehicle
+func Paths(cfg *config.Config) (keyPath, certPath, caPath string) {
+	dir := cfg.Enrollment.Dir
+	node := cfg.Agent.NodeName
+	return filepath.Join(dir, node+".key"), filepath.Join(dir, node+".crt"), filepath.Join(dir, "ca.crt")
+}
+
+// Enroll makes sure the vehicle of cfg holds a valid certificate for its
+// identity key, requesting one and waiting for the controller to issue it
+// when needed. The key is created on first use and never leaves the node.
+// A certificate is renewed once less than a third of its validity remains.
+func Enroll(ctx context.Context, client *k8s.Client, cfg *config.Config, log *logrus.Logger) error {
+	keyPath, certPath, caPath := Paths(cfg)
+	entry := log.WithField("nodeName", cfg.Agent.NodeName)
+
+	key, err := loadOrCreateKey(keyPath)
+	if err != nil {
+		return err
+	}
+	if cert, ok := valid(certPath, key); ok {
+		entry.WithField("expiresAt", cert.NotAfter).Info("Vehicle already enrolled")
+		return nil
+	}
+
+	// A certificate issued before a restart may be waiting in the status
+	existing, err := client.GetEnrollment(ctx, cfg.Agent.NodeName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && existing.Issued() && existing.Spec.SerialNumber == cfg.UAVMetadata.SerialNumber {
+		if done, err := store(existing, key, certPath, caPath); done || err != nil {
+			return err
+		}
+	}
+
+	csr, err := certificateRequest(cfg, key)
+	if err != nil {
+		return err
+	}
+	spec := models.UAVEnrollmentSpec{
+		NodeName:      cfg.Agent.NodeName,
+		SerialNumber:  cfg.UAVMetadata.SerialNumber,
+		HardwareModel: cfg.UAVMetadata.HardwareModel,
+		GroundNode:    cfg.Agent.GroundNode,
+		CSR:           csr,
+		RequestedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	if err := client.SubmitEnrollment(ctx, spec); err != nil {
+		return err
+	}
+	entry.WithField("serialNumber", spec.SerialNumber).Info("Enrollment requested, waiting for approval")
+
+	ticker := time.NewTicker(cfg.Enrollment.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		enrollment, err := client.GetEnrollment(ctx, cfg.Agent.NodeName)
+		if err != nil {
+			entry.WithError(err).Warn("Failed to check enrollment")
+			continue
+		}
+		if enrollment.Spec.RequestedAt.Before(spec.RequestedAt) {
+			continue
+		}
+		if enrollment.Status.Phase == models.EnrollmentDenied {
+			return fmt.Errorf("%w: %s", ErrDenied, enrollment.Status.Reason)
+		}
+		if enrollment.Status.Phase != models.EnrollmentApproved || !enrollment.Issued() {
+			continue
+		}
+		done, err := store(enrollment, key, certPath, caPath)
+		if err != nil {
+			return err
+		}
+		if done {
+			entry.WithFields(logrus.Fields{
+				"decidedBy": enrollment.Status.DecidedBy,
+				"expiresAt": enrollment.Status.ExpiresAt,
+			}).Info("Vehicle enrolled")
+			return nil
+		}
+	}
+}
+
+// Renew keeps the certificate of the vehicle of cfg valid until ctx is
+// done. Every PollInterval it checks the stored certificate and, once its
+// renewal is due, enrolls again. A denied renewal stops it: requesting again
+// would only be denied again.
+func Renew(ctx context.Context, client *k8s.Client, cfg *config.Config, log *logrus.Logger) {
+	keyPath, certPath, _ := Paths(cfg)
+	entry := log.WithField("nodeName", cfg.Agent.NodeName)
+
+	ticker := time.NewTicker(cfg.Enrollment.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		key, err := loadOrCreateKey(keyPath)
+		if err != nil {
+			entry.WithError(err).Warn("Failed to load identity key")
+			continue
+		}
+		if _, ok := valid(certPath, key); ok {
+			continue
+		}
+		entry.Info("Certificate renewal due, enrolling again")
+		err = Enroll(ctx, client, cfg, log)
+		switch {
+		case errors.Is(err, ErrDenied):
+			entry.WithError(err).Error("Certificate renewal denied, the certificate won't be renewed")
+			return
+		case err != nil && ctx.Err() == nil:
+			entry.WithError(err).Warn("Failed to renew certificate")
+		}
+	}
+}
+
+// ClientTLSConfig returns the TLS configuration authenticating the vehicle
+// of cfg with its enrolled certificate, for servers accepting fleet
+// certificates such as the aggregator. The key pair is read on every
+// handshake, so a renewed certificate is picked up without a restart.
+// Servers are verified against caFile, or the fleet CA when empty.
+func ClientTLSConfig(cfg *config.Config, caFile string) (*tls.Config, error) {
+	keyPath, certPath, caPath := Paths(cfg)
+	if caFile == "" {
+		caFile = caPath
+	}
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("invalid CA certificate %s", caFile)
+	}
+
+	return &tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load enrollment certificate: %w", err)
+			}
+			return &cert, nil
+		},
+	}, nil
+}
+
+// store writes the issued certificate if it is for key, and reports whether
+// it did
+func store(enrollment *models.UAVEnrollment, key *ecdsa.PrivateKey, certPath, caPath string) (bool, error) {
+	block, _ := pem.Decode([]byte(enrollment.Status.Certificate))
+	if block == nil {
+		return false, nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil || !matches(cert, key) || renewalDue(cert, time.Now()) {
+		return false, nil
+	}
+	if err := writeFile(caPath, []byte(enrollment.Status.CACertificate), 0644); err != nil {
+		return false, err
+	}
+	if err := writeFile(certPath, []byte(enrollment.Status.Certificate), 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func certificateRequest(cfg *config.Config, key crypto.Signer) (string, error) {
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName:   cfg.Agent.NodeName,
+			SerialNumber: cfg.UAVMetadata.SerialNumber,
+		},
+	}, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})), nil
+}
+
+func loadOrCreateKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("invalid identity key %s", path)
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read identity key: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write identity key: %w", err)
+	}
+	return key, nil
+}
+
+func loadCertificate(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid certificate %s", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// valid returns the stored certificate if it is for key and not due for
+// renewal yet
+func valid(certPath string, key *ecdsa.PrivateKey) (*x509.Certificate, bool) {
+	cert, err := loadCertificate(certPath)
+	if err != nil || !matches(cert, key) || renewalDue(cert, time.Now()) {
+		return nil, false
+	}
+	return cert, true
+}
+
+func matches(cert *x509.Certificate, key *ecdsa.PrivateKey) bool {
+	public, err := x509.MarshalPKIXPublicKey(key.Public())
+	return err == nil && bytes.Equal(public, cert.RawSubjectPublicKeyInfo)
+}
+
+// renewalDue reports whether less than a third of the validity remains
+func renewalDue(cert *x509.Certificate, now time.Time) bool {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return cert.NotAfter.Sub(now) < lifetime/3
+}
+
+// writeFile atomically replaces path
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,314 @@
+package enrollment
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Values of the approval annotation
+const (
+	approvalApproved = "approved"
+	approvalDenied   = "denied"
+)
+
+// decidedByController marks denials of the controller itself (a request for
+// another key or object), which neither the annotation nor the policy
+// overrides
+const decidedByController = "controller"
+
+// Controller decides pending enrollments and issues certificates for
+// approved ones. An operator's approval annotation takes precedence over
+// the auto-approval policy; once decided, an enrollment keeps its decision
+// until the annotation changes it.
+type Controller struct {
+	client *k8s.Client
+	cfg    config.EnrollmentConfig
+	log    *logrus.Logger
+
+	ca      *x509.Certificate
+	caKey   crypto.Signer
+	caPEM   string
+	fleetNS string
+}
+
+// NewController loads the fleet CA and creates a controller
+func NewController(client *k8s.Client, cfg *config.Config, log *logrus.Logger) (*Controller, error) {
+	certPEM, err := os.ReadFile(cfg.Enrollment.CACertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("invalid CA certificate %s", cfg.Enrollment.CACertPath)
+	}
+	ca, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificate: %w", err)
+	}
+
+	keyPEM, err := os.ReadFile(cfg.Enrollment.CAKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA key: %w", err)
+	}
+	caKey, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Controller{
+		client:  client,
+		cfg:     cfg.Enrollment,
+		log:     log,
+		ca:      ca,
+		caKey:   caKey,
+		caPEM:   string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})),
+		fleetNS: cfg.Kubernetes.Namespace,
+	}, nil
+}
+
+// Run processes enrollments every interval until ctx is cancelled
+func (c *Controller) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.reconcile(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Controller) reconcile(ctx context.Context) {
+	enrollments, err := c.client.ListEnrollments(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.log.WithError(err).Warn("Failed to list enrollments")
+		}
+		return
+	}
+
+	now := time.Now()
+	for _, e := range enrollments {
+		entry := c.log.WithFields(logrus.Fields{
+			"nodeName":     e.Spec.NodeName,
+			"serialNumber": e.Spec.SerialNumber,
+		})
+
+		status := c.decide(e, publicKeyID(e.Spec.CSR))
+		if e.Name != c.client.ResourceName(e.Spec.NodeName) {
+			// An agent may only request its own vehicle's identity, which is
+			// looked up by name
+			status = models.UAVEnrollmentStatus{
+				Phase:           models.EnrollmentDenied,
+				DecidedBy:       decidedByController,
+				Reason:          fmt.Sprintf("name does not match node %q", e.Spec.NodeName),
+				SerialNumber:    e.Spec.SerialNumber,
+				PublicKeySHA256: e.Status.PublicKeySHA256,
+			}
+		}
+		if status.Phase == models.EnrollmentApproved && (status.Certificate == "" || !e.Issued()) {
+			issued, err := c.issue(e, status, now)
+			if err != nil {
+				// Invalid requests are denied, so the agent stops waiting
+				status = models.UAVEnrollmentStatus{
+					Phase:           models.EnrollmentDenied,
+					DecidedBy:       status.DecidedBy,
+					Reason:          fmt.Sprintf("invalid certificate request: %v", err),
+					SerialNumber:    status.SerialNumber,
+					PublicKeySHA256: e.Status.PublicKeySHA256,
+				}
+			} else {
+				status = issued
+			}
+		}
+		if status == e.Status {
+			continue
+		}
+
+		previous := e.Status.Phase
+		e.Status = status
+		if err := c.client.UpdateEnrollmentStatus(ctx, e); err != nil {
+			// Retried on the next round with the latest request
+			entry.WithError(err).Warn("Failed to update enrollment status")
+			continue
+		}
+		entry = entry.WithFields(logrus.Fields{"phase": status.Phase, "decidedBy": status.DecidedBy})
+		switch {
+		case status.Phase == models.EnrollmentApproved:
+			entry.WithField("expiresAt", status.ExpiresAt).Info("Enrollment certificate issued")
+		case status.Phase == models.EnrollmentPending:
+			entry.Info("Enrollment waiting for operator approval")
+		case status.Phase != previous:
+			entry.WithField("reason", status.Reason).Info("Enrollment decided")
+		}
+	}
+}
+
+// decide returns the status of e after applying the operator's annotation
+// or the auto-approval policy, without issuing a certificate. keyID is the
+// SHA-256 of the requested public key. Once a key was approved, a request
+// for another one is denied whatever the annotation or policy: re-issuing
+// would hand the vehicle's identity to whoever rewrote the request.
+func (c *Controller) decide(e *models.UAVEnrollment, keyID string) models.UAVEnrollmentStatus {
+	status := e.Status
+	serial := e.Spec.SerialNumber
+	bound := status.PublicKeySHA256
+	if bound != "" && keyID != bound {
+		return models.UAVEnrollmentStatus{
+			Phase:           models.EnrollmentDenied,
+			DecidedBy:       decidedByController,
+			Reason:          "public key differs from the approved one; delete the UAVEnrollment to enroll a new key",
+			SerialNumber:    serial,
+			PublicKeySHA256: bound,
+		}
+	}
+
+	// A key mismatch denial no longer holds once the approved key is back
+	decided := (status.Phase == models.EnrollmentApproved || status.Phase == models.EnrollmentDenied) &&
+		(status.SerialNumber == serial || status.DecidedBy == models.DecidedByDecommission) &&
+		status.DecidedBy != decidedByController
+	switch {
+	case e.Approval == approvalDenied:
+		if !decided || status.Phase != models.EnrollmentDenied {
+			return models.UAVEnrollmentStatus{Phase: models.EnrollmentDenied, DecidedBy: "operator", Reason: "denied by operator", SerialNumber: serial, PublicKeySHA256: bound}
+		}
+	case e.Approval == approvalApproved:
+		if !decided || status.Phase != models.EnrollmentApproved {
+			return models.UAVEnrollmentStatus{Phase: models.EnrollmentApproved, DecidedBy: "operator", SerialNumber: serial, PublicKeySHA256: keyID}
+		}
+	case decided:
+	case c.autoApproved(e.Spec):
+		return models.UAVEnrollmentStatus{Phase: models.EnrollmentApproved, DecidedBy: "policy", SerialNumber: serial, PublicKeySHA256: keyID}
+	default:
+		return models.UAVEnrollmentStatus{Phase: models.EnrollmentPending, Reason: "waiting for operator approval", SerialNumber: serial, PublicKeySHA256: bound}
+	}
+	return status
+}
+
+// autoApproved reports whether the policy approves spec: its serial number
+// matches an auto-approved pattern and its hardware model is allowed
+func (c *Controller) autoApproved(spec models.UAVEnrollmentSpec) bool {
+	return matchAny(c.cfg.AutoApproveSerials, spec.SerialNumber) &&
+		(len(c.cfg.AutoApproveModels) == 0 || matchAny(c.cfg.AutoApproveModels, spec.HardwareModel))
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// issue signs the request of e approved by decision and returns the status
+// holding the certificate. The certificate identifies the vehicle by node
+// name (common name and DNS name) and hardware serial number, for TLS
+// client authentication and signing.
+func (c *Controller) issue(e *models.UAVEnrollment, decision models.UAVEnrollmentStatus, now time.Time) (models.UAVEnrollmentStatus, error) {
+	block, _ := pem.Decode([]byte(e.Spec.CSR))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return models.UAVEnrollmentStatus{}, fmt.Errorf("spec.csr is not a PEM certificate request")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return models.UAVEnrollmentStatus{}, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return models.UAVEnrollmentStatus{}, err
+	}
+	if csr.Subject.CommonName != e.Spec.NodeName {
+		return models.UAVEnrollmentStatus{}, fmt.Errorf("common name %q does not match node %q", csr.Subject.CommonName, e.Spec.NodeName)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return models.UAVEnrollmentStatus{}, err
+	}
+	notAfter := now.Add(c.cfg.CertValidity)
+	if notAfter.After(c.ca.NotAfter) {
+		notAfter = c.ca.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   e.Spec.NodeName,
+			SerialNumber: e.Spec.SerialNumber,
+			Organization: []string{c.fleetNS},
+		},
+		DNSNames:    []string{e.Spec.NodeName},
+		NotBefore:   now.Add(-5 * time.Minute), // tolerate clock skew on vehicles
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, c.ca, csr.PublicKey, c.caKey)
+	if err != nil {
+		return models.UAVEnrollmentStatus{}, err
+	}
+
+	return models.UAVEnrollmentStatus{
+		Phase:           models.EnrollmentApproved,
+		DecidedBy:       decision.DecidedBy,
+		SerialNumber:    decision.SerialNumber,
+		PublicKeySHA256: decision.PublicKeySHA256,
+		Certificate:     string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		CACertificate:   c.caPEM,
+		IssuedAt:        now.UTC().Truncate(time.Second),
+		ExpiresAt:       notAfter.UTC().Truncate(time.Second),
+	}, nil
+}
+
+// publicKeyID returns the hex SHA-256 of the public key requested by csr,
+// empty when csr can't be parsed (issue then denies the request)
+func publicKeyID(csr string) string {
+	block, _ := pem.Decode([]byte(csr))
+	if block == nil {
+		return ""
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(request.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+// parsePrivateKey parses a PEM PKCS#8, EC or PKCS#1 private key
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid CA key: no PEM block")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("invalid CA key: unsupported format")
+}
@@ -0,0 +1,271 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// Names of the fields that can be sealed
+const (
+	FieldSerialNumber = "metadata.serialNumber"
+	FieldHomeLocation = "home.location"
+)
+
+// sensitiveFields is the plaintext payload stored inside the envelope
+type sensitiveFields struct {
+	SerialNumber  string   `json:"serialNumber,omitempty"`
+	HomeLatitude  *float64 `json:"homeLatitude,omitempty"`
+	HomeLongitude *float64 `json:"homeLongitude,omitempty"`
+
+	// Distance and bearing to home locate it from the clear GPS position
+	HomeDistance     *float64 `json:"homeDistance,omitempty"`
+	HomeBearing      *float64 `json:"homeBearing,omitempty"`
+	HomeReturnEnergy *float64 `json:"homeReturnEnergy,omitempty"`
+}
+
+// Sealer encrypts sensitive UAVMetrics fields with the fleet public key.
+// A random AES-256 data key encrypts the fields (AES-GCM) and is itself
+// wrapped with RSA-OAEP, so only holders of the fleet private key can read them.
+type Sealer struct {
+	publicKey *rsa.PublicKey
+	keyID     string
+	fields    map[string]bool
+}
+
+// NewSealer creates a sealer from a PEM encoded RSA public key file
+func NewSealer(publicKeyPath string, fields []string) (*Sealer, error) {
+	data, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet public key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("fleet public key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fleet public key: %w", err)
+	}
+	publicKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("fleet public key must be an RSA key")
+	}
+
+	fieldSet := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		switch f {
+		case FieldSerialNumber, FieldHomeLocation:
+			fieldSet[f] = true
+		default:
+			return nil, fmt.Errorf("unsupported sealed field %q", f)
+		}
+	}
+
+	return &Sealer{
+		publicKey: publicKey,
+		keyID:     KeyID(publicKey),
+		fields:    fieldSet,
+	}, nil
+}
+
+// Seal returns a copy of metrics with the configured fields encrypted and
+// blanked. The input is not modified.
+func (s *Sealer) Seal(metrics *models.UAVMetrics) (*models.UAVMetrics, error) {
+	sealed := *metrics
+	payload := sensitiveFields{}
+	names := []string{}
+
+	if s.fields[FieldSerialNumber] && metrics.Metadata != nil && metrics.Metadata.SerialNumber != "" {
+		metadata := *metrics.Metadata
+		payload.SerialNumber = metadata.SerialNumber
+		metadata.SerialNumber = ""
+		sealed.Metadata = &metadata
+		names = append(names, FieldSerialNumber)
+	}
+
+	if s.fields[FieldHomeLocation] && metrics.Home != nil {
+		home := *metrics.Home
+		lat, lon := home.Latitude, home.Longitude
+		distance, bearing, energy := home.Distance, home.Bearing, home.ReturnEnergy
+		payload.HomeLatitude, payload.HomeLongitude = &lat, &lon
+		payload.HomeDistance, payload.HomeBearing, payload.HomeReturnEnergy = &distance, &bearing, &energy
+		home.Latitude, home.Longitude = 0, 0
+		home.Distance, home.Bearing, home.ReturnEnergy = 0, 0, 0
+		sealed.Home = &home
+		names = append(names, FieldHomeLocation)
+	}
+
+	if len(names) == 0 {
+		return &sealed, nil
+	}
+
+	plaintext, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, s.publicKey, dataKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	sealed.Encrypted = &models.EncryptedFields{
+		KeyID:      s.keyID,
+		Algorithm:  "RSA-OAEP-256+A256GCM",
+		Fields:     names,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrappedKey),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, []byte(metrics.NodeName))),
+	}
+
+	return &sealed, nil
+}
+
+// Opener decrypts sealed fields for authorized controllers holding the fleet private key
+type Opener struct {
+	privateKey *rsa.PrivateKey
+	keyID      string
+}
+
+// NewOpener creates an opener from a PEM encoded RSA private key file (PKCS#1 or PKCS#8)
+func NewOpener(privateKeyPath string) (*Opener, error) {
+	data, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet private key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("fleet private key is not PEM encoded")
+	}
+
+	var privateKey *rsa.PrivateKey
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		privateKey = key
+	} else {
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fleet private key: %w", err)
+		}
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("fleet private key must be an RSA key")
+		}
+		privateKey = rsaKey
+	}
+
+	return &Opener{
+		privateKey: privateKey,
+		keyID:      KeyID(&privateKey.PublicKey),
+	}, nil
+}
+
+// Open restores the sealed fields in place and clears the envelope
+func (o *Opener) Open(metrics *models.UAVMetrics) error {
+	env := metrics.Encrypted
+	if env == nil {
+		return nil
+	}
+	if env.KeyID != o.keyID {
+		return fmt.Errorf("fields sealed with key %s, have %s", env.KeyID, o.keyID)
+	}
+
+	wrappedKey, err := base64.StdEncoding.DecodeString(env.WrappedKey)
+	if err != nil {
+		return fmt.Errorf("invalid wrapped key: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(env.Nonce)
+	if err != nil {
+		return fmt.Errorf("invalid nonce: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return fmt.Errorf("invalid ciphertext: %w", err)
+	}
+
+	dataKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, o.privateKey, wrappedKey, nil)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(metrics.NodeName))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt sealed fields: %w", err)
+	}
+
+	var payload sensitiveFields
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return err
+	}
+
+	if payload.SerialNumber != "" {
+		if metrics.Metadata == nil {
+			metrics.Metadata = &models.MetadataInfo{}
+		}
+		metrics.Metadata.SerialNumber = payload.SerialNumber
+	}
+	if payload.HomeLatitude != nil && payload.HomeLongitude != nil {
+		if metrics.Home == nil {
+			metrics.Home = &models.HomeData{}
+		}
+		metrics.Home.Latitude = *payload.HomeLatitude
+		metrics.Home.Longitude = *payload.HomeLongitude
+	}
+	if metrics.Home != nil && payload.HomeDistance != nil && payload.HomeBearing != nil {
+		metrics.Home.Distance = *payload.HomeDistance
+		metrics.Home.Bearing = *payload.HomeBearing
+	}
+	if metrics.Home != nil && payload.HomeReturnEnergy != nil {
+		metrics.Home.ReturnEnergy = *payload.HomeReturnEnergy
+	}
+
+	metrics.Encrypted = nil
+	return nil
+}
+
+// KeyID returns a short fingerprint of the public key
+func KeyID(publicKey *rsa.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,117 @@
+// Package export writes time-aligned snapshots of the whole fleet to files
+// for offline analysis of swarm behaviour and algorithm performance.
+package export
+
+import (
+	"context"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/sirupsen/logrus"
+)
+
+// Exporter takes a snapshot of the fleet at every multiple of the export
+// interval. Each snapshot holds one row per vehicle with its last published
+// state (AgeSeconds tells how old it is), its router's decision statistics
+// and the pods the scheduler placed on it.
+type Exporter struct {
+	client *k8s.Client
+	cfg    config.ExportConfig
+	log    *logrus.Logger
+}
+
+// NewExporter creates an exporter reading the fleet through client
+func NewExporter(client *k8s.Client, cfg *config.Config, log *logrus.Logger) *Exporter {
+	return &Exporter{client: client, cfg: cfg.Export, log: log}
+}
+
+// Run writes snapshots to w until ctx is cancelled or the export duration
+// has elapsed. Snapshots that fail to list the fleet are skipped, leaving
+// a gap in the timestamps; write errors end the export.
+func (e *Exporter) Run(ctx context.Context, w Writer) error {
+	var deadline time.Time
+	if e.cfg.Duration > 0 {
+		deadline = time.Now().Add(e.cfg.Duration)
+	}
+
+	// Snapshots read the informer caches, so each one costs no API request
+	if err := e.client.WatchUAVMetrics(ctx, k8s.UAVMetricsHandler{}); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		e.log.WithError(err).Info("Not watching UAVMetrics, listing them for every snapshot")
+	}
+	if err := e.client.WatchScheduledPods(ctx, e.cfg.SchedulerName); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		e.log.WithError(err).Info("Not watching scheduled pods, listing them for every snapshot")
+	}
+
+	snapshots, rows := 0, 0
+	defer func() {
+		e.log.WithFields(logrus.Fields{"snapshots": snapshots, "rows": rows}).Info("Export finished")
+	}()
+
+	next := time.Now().Truncate(e.cfg.Interval).Add(e.cfg.Interval)
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+		if !deadline.IsZero() && next.After(deadline) {
+			return nil
+		}
+
+		snapshot, err := e.Snapshot(ctx, next)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			e.log.WithError(err).WithField("timestamp", next).Warn("Failed to take fleet snapshot, skipping it")
+		} else {
+			if err := w.Write(snapshot); err != nil {
+				return err
+			}
+			snapshots++
+			rows += len(snapshot)
+		}
+
+		// Skip the ticks missed by a slow snapshot rather than bunching them
+		next = next.Add(e.cfg.Interval)
+		if missed := time.Since(next); missed >= 0 {
+			skipped := int(missed/e.cfg.Interval) + 1
+			next = next.Add(time.Duration(skipped) * e.cfg.Interval)
+			e.log.WithField("skipped", skipped).Warn("Fleet snapshot slower than the export interval")
+		}
+		timer.Reset(time.Until(next))
+	}
+}
+
+// Snapshot returns the state of the fleet at, one row per vehicle
+func (e *Exporter) Snapshot(ctx context.Context, at time.Time) ([]Row, error) {
+	metrics, err := e.client.ListUAVMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := e.client.ListUAVMetricsStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := e.client.ScheduledPods(ctx, e.cfg.SchedulerName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, 0, len(metrics))
+	for _, m := range metrics {
+		rows = append(rows, newRow(at, m, statuses[m.NodeName], pods[m.NodeName]))
+	}
+	sortRows(rows)
+	return rows, nil
+}
@@ -0,0 +1,145 @@
+package export
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// Row is the state of one vehicle in a snapshot. Values the vehicle didn't
+// report, because the section is disabled, failed or sealed, are null
+// (empty in CSV) rather than zero, so they can't pass for readings.
+type Row struct {
+	Timestamp time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	NodeName  string    `parquet:"node_name,dict"`
+	Phase     string    `parquet:"phase,dict"`
+
+	// Time since the vehicle last published metrics (s)
+	AgeSeconds *float64 `parquet:"age_seconds,optional"`
+
+	Latitude   *float64 `parquet:"latitude,optional"`
+	Longitude  *float64 `parquet:"longitude,optional"`
+	Altitude   *float64 `parquet:"altitude,optional"`
+	Heading    *float64 `parquet:"heading,optional"`
+	Speed      *float64 `parquet:"speed,optional"`
+	Satellites *int32   `parquet:"satellites,optional"`
+	FixType    string   `parquet:"fix_type,dict"`
+
+	BatteryPercent *float64 `parquet:"battery_percent,optional"`
+	BatteryVoltage *float64 `parquet:"battery_voltage,optional"`
+	TimeRemaining  *int32   `parquet:"time_remaining_seconds,optional"`
+
+	Health     string `parquet:"health,dict"`
+	FlightMode string `parquet:"flight_mode,dict"`
+	Armed      *bool  `parquet:"armed,optional"`
+	Flying     *bool  `parquet:"flying,optional"`
+
+	// Pods of the exported scheduler placed on the vehicle
+	ScheduledPods int32 `parquet:"scheduled_pods"`
+
+	// Decisions of the vehicle's router over its last reporting window
+	RoutingAlgorithm          string   `parquet:"routing_algorithm,dict"`
+	RoutingDecisionsPerSecond *float64 `parquet:"routing_decisions_per_second,optional"`
+	RoutingFailures           *int64   `parquet:"routing_failures,optional"`
+}
+
+// csvHeader names the CSV columns, in the order of Row's fields
+var csvHeader = []string{
+	"timestamp", "node_name", "phase", "age_seconds",
+	"latitude", "longitude", "altitude", "heading", "speed", "satellites", "fix_type",
+	"battery_percent", "battery_voltage", "time_remaining_seconds",
+	"health", "flight_mode", "armed", "flying",
+	"scheduled_pods",
+	"routing_algorithm", "routing_decisions_per_second", "routing_failures",
+}
+
+func newRow(at time.Time, m *models.UAVMetrics, status *models.UAVMetricsStatus, pods int) Row {
+	row := Row{
+		Timestamp:     at.UTC(),
+		NodeName:      m.NodeName,
+		ScheduledPods: int32(pods),
+	}
+	if lastSeen := m.LastSeen(); !lastSeen.IsZero() {
+		row.AgeSeconds = ptr(at.Sub(lastSeen).Seconds())
+	}
+	// Same tests as the anomaly detector for a section holding readings
+	if !m.GPS.LastUpdate.IsZero() && !m.CollectionFailed(models.SectionGPS) {
+		row.Latitude = ptr(m.GPS.Latitude)
+		row.Longitude = ptr(m.GPS.Longitude)
+		row.Altitude = ptr(m.GPS.Altitude)
+		row.Heading = ptr(m.GPS.Heading)
+		row.Speed = ptr(m.GPS.Speed)
+		row.Satellites = ptr(int32(m.GPS.Satellites))
+		row.FixType = m.GPS.FixType
+	}
+	if (m.Battery.Voltage != 0 || m.Battery.RemainingPercent != 0) && !m.CollectionFailed(models.SectionBattery) {
+		row.BatteryPercent = ptr(m.Battery.RemainingPercent)
+		row.BatteryVoltage = ptr(m.Battery.Voltage)
+		row.TimeRemaining = ptr(int32(m.Battery.TimeRemaining))
+	}
+	if m.Health != nil {
+		row.Health = m.Health.Status
+	}
+	if m.Flight != nil {
+		row.FlightMode = m.Flight.Mode
+		row.Armed = ptr(m.Flight.Armed)
+		row.Flying = ptr(m.Flight.IsFlying)
+	}
+	if status != nil {
+		row.Phase = status.Phase
+		if status.Routing != nil {
+			row.RoutingAlgorithm = status.Routing.Algorithm
+			row.RoutingDecisionsPerSecond = ptr(status.Routing.DecisionsPerSecond)
+			row.RoutingFailures = ptr(status.Routing.Failures)
+		}
+	}
+	return row
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// sortRows orders the rows of a snapshot by node name, so consecutive
+// snapshots list vehicles in the same order
+func sortRows(rows []Row) {
+	sort.Slice(rows, func(i, j int) bool { return rows[i].NodeName < rows[j].NodeName })
+}
+
+// csvRecord formats row as CSV fields, in the order of csvHeader. Null
+// values are empty.
+func csvRecord(row Row) []string {
+	f := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+	i := func(v *int32) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(int(*v))
+	}
+	b := func(v *bool) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatBool(*v)
+	}
+	failures := ""
+	if row.RoutingFailures != nil {
+		failures = strconv.FormatInt(*row.RoutingFailures, 10)
+	}
+	return []string{
+		row.Timestamp.Format(time.RFC3339Nano), row.NodeName, row.Phase, f(row.AgeSeconds),
+		f(row.Latitude), f(row.Longitude), f(row.Altitude), f(row.Heading), f(row.Speed),
+		i(row.Satellites), row.FixType,
+		f(row.BatteryPercent), f(row.BatteryVoltage), i(row.TimeRemaining),
+		row.Health, row.FlightMode, b(row.Armed), b(row.Flying),
+		strconv.Itoa(int(row.ScheduledPods)),
+		row.RoutingAlgorithm, f(row.RoutingDecisionsPerSecond), failures,
+	}
+}
@@ -0,0 +1,89 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+// parquetRowGroupSize is the number of rows buffered before a row group is
+// written, bounding the exporter's memory on long exports
+const parquetRowGroupSize = 50000
+
+// Writer writes snapshots to a file. Close must be called to complete the
+// file (Parquet writes its footer then).
+type Writer interface {
+	Write(rows []Row) error
+	Close() error
+}
+
+// NewWriter returns a writer of format (config.ExportFormatCSV or
+// config.ExportFormatParquet) to w
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case config.ExportFormatCSV:
+		return newCSVWriter(w)
+	case config.ExportFormatParquet:
+		return newParquetWriter(w), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// csvWriter writes one line per row after a header line. Every snapshot is
+// flushed, so the file can be read while the export runs.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (w *csvWriter) Write(rows []Row) error {
+	for _, row := range rows {
+		if err := w.w.Write(csvRecord(row)); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// parquetWriter writes zstd compressed row groups of Row
+type parquetWriter struct {
+	w        *parquet.GenericWriter[Row]
+	buffered int
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{w: parquet.NewGenericWriter[Row](w, parquet.Compression(&zstd.Codec{}))}
+}
+
+func (w *parquetWriter) Write(rows []Row) error {
+	if _, err := w.w.Write(rows); err != nil {
+		return err
+	}
+	w.buffered += len(rows)
+	if w.buffered >= parquetRowGroupSize {
+		w.buffered = 0
+		return w.w.Flush()
+	}
+	return nil
+}
+
+func (w *parquetWriter) Close() error {
+	return w.w.Close()
+}
@@ -0,0 +1,211 @@
+// Package fleetcache serves fleet snapshots to many scheduler and router
+// replicas from memory, over TLS. The cache is the only watcher of
+// UAVMetrics on the API server; subscribers receive a full snapshot once and then the deltas
+// of each change, resuming from their last version after a disconnect.
+package fleetcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Update is one message of a subscription. Samples are UAVMetrics objects
+// encoded as JSON, keyed by node name.
+type Update struct {
+	// Version of the fleet after this update
+	Version uint64 `json:"version"`
+
+	// Reset replaces the subscriber's whole fleet with Upserts
+	Reset bool `json:"reset,omitempty"`
+
+	Upserts map[string]json.RawMessage `json:"upserts,omitempty"`
+	Deletes []string                   `json:"deletes,omitempty"`
+}
+
+// Cache keeps the latest UAVMetrics of the fleet with a version that
+// increases by one on every change. Versions start at the start time in
+// nanoseconds, so they keep increasing across restarts and subscribers of a
+// previous instance get a full snapshot.
+type Cache struct {
+	client *k8s.Client
+	cfg    config.FleetCacheConfig
+	log    *logrus.Logger
+
+	mu      sync.Mutex
+	synced  bool
+	version uint64
+	items   map[string]json.RawMessage
+	history []Update      // deltas with consecutive versions, oldest first
+	changed chan struct{} // closed and replaced on every change
+}
+
+// New creates a cache reading through client
+func New(client *k8s.Client, cfg config.FleetCacheConfig, log *logrus.Logger) *Cache {
+	return &Cache{
+		client:  client,
+		cfg:     cfg,
+		log:     log,
+		version: uint64(time.Now().UnixNano()),
+		items:   make(map[string]json.RawMessage),
+		changed: make(chan struct{}),
+	}
+}
+
+// Run feeds the cache from a UAVMetrics watch until ctx is cancelled. The
+// changes of one RefreshInterval become one version. When the watch can't
+// be started, UAVMetrics are listed every RefreshInterval instead.
+func (c *Cache) Run(ctx context.Context) {
+	notify := make(chan struct{}, 1)
+	changed := func() {
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+	handler := k8s.UAVMetricsHandler{
+		AddFunc:    func(*models.UAVMetrics) { changed() },
+		UpdateFunc: func(_, _ *models.UAVMetrics) { changed() },
+		DeleteFunc: func(*models.UAVMetrics) { changed() },
+	}
+	if err := c.client.WatchUAVMetrics(ctx, handler); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		c.log.WithError(err).Warn("Failed to watch UAVMetrics, polling instead")
+		c.poll(ctx)
+		return
+	}
+
+	// Once synced, ListUAVMetrics reads the watch's cache
+	for {
+		if err := c.refresh(ctx); err != nil && ctx.Err() == nil {
+			c.log.WithError(err).Warn("Failed to refresh fleet snapshot")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-notify:
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.cfg.RefreshInterval):
+		}
+	}
+}
+
+// poll lists UAVMetrics every RefreshInterval until ctx is cancelled
+func (c *Cache) poll(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.refresh(ctx); err != nil && ctx.Err() == nil {
+			// Subscribers keep the last fleet until the API server is back
+			c.log.WithError(err).Warn("Failed to refresh fleet snapshot")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh lists the fleet and records what changed as a new version
+func (c *Cache) refresh(ctx context.Context) error {
+	metrics, err := c.client.ListUAVMetrics(ctx)
+	if err != nil {
+		return err
+	}
+
+	items := make(map[string]json.RawMessage, len(metrics))
+	for _, m := range metrics {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		items[m.NodeName] = data
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delta := Update{Upserts: make(map[string]json.RawMessage)}
+	for name, data := range items {
+		if previous, ok := c.items[name]; !ok || !bytes.Equal(previous, data) {
+			delta.Upserts[name] = data
+		}
+	}
+	for name := range c.items {
+		if _, ok := items[name]; !ok {
+			delta.Deletes = append(delta.Deletes, name)
+		}
+	}
+	if c.synced && len(delta.Upserts) == 0 && len(delta.Deletes) == 0 {
+		return nil
+	}
+
+	c.version++
+	delta.Version = c.version
+	c.items = items
+	c.history = append(c.history, delta)
+	if len(c.history) > c.cfg.History {
+		c.history = append([]Update(nil), c.history[len(c.history)-c.cfg.History:]...)
+	}
+	if !c.synced {
+		c.synced = true
+		c.log.WithFields(logrus.Fields{"vehicles": len(items), "version": c.version}).Info("Fleet snapshot synced")
+	}
+	close(c.changed)
+	c.changed = make(chan struct{})
+
+	c.log.WithFields(logrus.Fields{
+		"version": c.version,
+		"upserts": len(delta.Upserts),
+		"deletes": len(delta.Deletes),
+	}).Debug("Fleet snapshot updated")
+	return nil
+}
+
+// Snapshot returns the whole fleet as a reset update, and false before the
+// first successful refresh
+func (c *Cache) Snapshot() (Update, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.snapshotLocked(), c.synced
+}
+
+func (c *Cache) snapshotLocked() Update {
+	upserts := make(map[string]json.RawMessage, len(c.items))
+	for name, data := range c.items {
+		upserts[name] = data
+	}
+	return Update{Version: c.version, Reset: true, Upserts: upserts}
+}
+
+// updatesSince returns the updates bringing a subscriber at version since
+// up to date: the deltas after since when they are all still in the
+// history, a full snapshot otherwise. The returned channel is closed on the
+// next change. Nothing is returned before the first successful refresh.
+func (c *Cache) updatesSince(since uint64) ([]Update, <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.synced || since == c.version {
+		return nil, c.changed
+	}
+	if since > c.version || len(c.history) == 0 || since < c.history[0].Version-1 {
+		return []Update{c.snapshotLocked()}, c.changed
+	}
+	start := len(c.history) - int(c.version-since)
+	return append([]Update(nil), c.history[start:]...), c.changed
+}
@@ -0,0 +1,148 @@
+package fleetcache
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/resilience"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// ErrNotSynced is returned by ListUAVMetrics before the first snapshot has
+// been received
+var ErrNotSynced = errors.New("fleet cache not synced yet")
+
+// Client keeps a replica of the fleet from a subscription to the cache. It
+// implements k8s.FleetSource, so a k8s.Client can serve ListUAVMetrics from
+// it. While disconnected, the last received fleet is served.
+type Client struct {
+	conn *grpc.ClientConn
+	log  *logrus.Logger
+
+	mu      sync.RWMutex
+	synced  bool
+	version uint64
+	items   map[string]json.RawMessage
+}
+
+// NewClient creates a client for the cache at address, verifying its
+// certificate against the CA in caFile (the system roots when empty). Call
+// Run to subscribe.
+func NewClient(address, caFile string, log *logrus.Logger) (*Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fleet cache CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("invalid fleet cache CA certificate %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	conn, err := grpc.NewClient(address,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, log: log, items: make(map[string]json.RawMessage)}, nil
+}
+
+// Run subscribes until ctx is cancelled, resuming from the last received
+// version after a disconnect
+func (c *Client) Run(ctx context.Context) {
+	defer c.conn.Close()
+	resilience.Reconnect(ctx, resilience.NewBackoff(time.Second, 30*time.Second), time.Minute,
+		c.subscribe,
+		func(err error, delay time.Duration) {
+			c.log.WithError(err).WithField("retryIn", delay).Warn("Fleet cache subscription ended, serving the last snapshot")
+		})
+}
+
+func (c *Client) subscribe(ctx context.Context) error {
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[0], subscribeMethod)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(wrapperspb.UInt64(c.Version())); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	for {
+		msg := &wrapperspb.BytesValue{}
+		if err := stream.RecvMsg(msg); err != nil {
+			return err
+		}
+		var update Update
+		if err := json.Unmarshal(msg.GetValue(), &update); err != nil {
+			return fmt.Errorf("invalid fleet update: %w", err)
+		}
+		c.apply(update)
+	}
+}
+
+func (c *Client) apply(update Update) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if update.Reset {
+		c.items = make(map[string]json.RawMessage, len(update.Upserts))
+	}
+	for name, data := range update.Upserts {
+		c.items[name] = data
+	}
+	for _, name := range update.Deletes {
+		delete(c.items, name)
+	}
+	c.version = update.Version
+	if !c.synced {
+		c.synced = true
+		c.log.WithFields(logrus.Fields{"vehicles": len(c.items), "version": c.version}).Info("Fleet cache synced")
+	}
+}
+
+// Version returns the version of the replica (0 before the first snapshot)
+func (c *Client) Version() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// ListUAVMetrics returns the fleet. Each call decodes new objects, so
+// callers may modify them as with a list from the API server.
+func (c *Client) ListUAVMetrics(ctx context.Context) ([]*models.UAVMetrics, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.synced {
+		return nil, ErrNotSynced
+	}
+
+	metrics := make([]*models.UAVMetrics, 0, len(c.items))
+	for _, data := range c.items {
+		var m models.UAVMetrics
+		if err := json.Unmarshal(data, &m); err != nil {
+			// Skip like undecodable objects from the API server
+			continue
+		}
+		metrics = append(metrics, &m)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,160 @@
+package fleetcache
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	serviceName     = "uav.fleetcache.v1.FleetCache"
+	snapshotMethod  = "/" + serviceName + "/Snapshot"
+	subscribeMethod = "/" + serviceName + "/Subscribe"
+)
+
+// serviceDesc is the hand-written descriptor of uav.fleetcache.v1.FleetCache
+// (api/proto/fleetcache.proto)
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Snapshot",
+			Handler:    handleSnapshot,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       handleSubscribe,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "fleetcache.proto",
+}
+
+func handleSnapshot(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := &emptypb.Empty{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	snapshot := func(ctx context.Context, req interface{}) (interface{}, error) {
+		update, synced := srv.(*Cache).Snapshot()
+		if !synced {
+			return nil, status.Error(codes.Unavailable, "fleet snapshot not synced yet")
+		}
+		return encodeUpdate(update)
+	}
+	if interceptor == nil {
+		return snapshot(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: snapshotMethod}
+	return interceptor(ctx, req, info, snapshot)
+}
+
+func handleSubscribe(srv interface{}, stream grpc.ServerStream) error {
+	c := srv.(*Cache)
+	req := &wrapperspb.UInt64Value{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	since := req.GetValue()
+
+	entry := c.log.WithField("since", since)
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		entry = entry.WithField("peer", p.Addr.String())
+	}
+	entry.Debug("Fleet subscriber connected")
+	defer entry.Debug("Fleet subscriber disconnected")
+
+	for {
+		updates, changed := c.updatesSince(since)
+		for _, update := range updates {
+			msg, err := encodeUpdate(update)
+			if err != nil {
+				return err
+			}
+			if err := stream.SendMsg(msg); err != nil {
+				return err
+			}
+			since = update.Version
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+func encodeUpdate(update Update) (*wrapperspb.BytesValue, error) {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode update: %v", err)
+	}
+	return wrapperspb.Bytes(data), nil
+}
+
+// ServerTLSConfig returns the TLS configuration of a cache serving certFile
+func ServerTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// Serve serves the cache and the standard gRPC health service over TLS
+// (tlsConfig, see ServerTLSConfig) on addr until ctx is cancelled. The
+// health service is also served in plaintext on healthAddr for probes.
+func (c *Cache) Serve(ctx context.Context, addr string, tlsConfig *tls.Config, healthAddr string) error {
+	if tlsConfig == nil {
+		return errors.New("the fleet cache requires TLS")
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	probeLis, err := net.Listen("tcp", healthAddr)
+	if err != nil {
+		lis.Close()
+		return err
+	}
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	server.RegisterService(&serviceDesc, c)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(serviceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	probeServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(probeServer, healthServer)
+	go probeServer.Serve(probeLis)
+
+	go func() {
+		<-ctx.Done()
+		healthServer.Shutdown()
+		// Subscriptions never end on their own, so don't wait for them
+		server.Stop()
+		probeServer.Stop()
+	}()
+
+	return server.Serve(lis)
+}
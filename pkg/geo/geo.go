@@ -0,0 +1,23 @@
+// Package geo holds the geodesic helpers shared by the agent, sinks and
+// terrain model
+package geo
+
+import "math"
+
+// EarthRadiusMeters is the mean earth radius used for great-circle distances
+const EarthRadiusMeters = 6371000.0
+
+// DistanceMeters returns the great-circle distance between two points in
+// meters, using the haversine formula
+func DistanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLat := (lat2 - lat1) * math.Pi / 180
+	deltaLon := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
+			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+
+	return EarthRadiusMeters * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
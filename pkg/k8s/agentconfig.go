@@ -0,0 +1,123 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// agentConfigGVR returns the resource of UAVAgentConfig objects, in the same
+// group and version as UAVMetrics
+func (c *Client) agentConfigGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    c.gvr.Group,
+		Version:  c.gvr.Version,
+		Resource: "uavagentconfigs",
+	}
+}
+
+// GetAgentConfig returns the desired agent configuration of a vehicle. The
+// error satisfies apierrors.IsNotFound when there is none (or the CRD is
+// not installed).
+func (c *Client) GetAgentConfig(ctx context.Context, nodeName string) (*models.UAVAgentConfig, error) {
+	obj, err := c.dynamicClient.Resource(c.agentConfigGVR()).
+		Namespace(c.config.Kubernetes.Namespace).
+		Get(ctx, c.ResourceName(nodeName), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get UAVAgentConfig: %w", err)
+	}
+	return unstructuredToAgentConfig(obj)
+}
+
+// AgentConfigWatch caches the configured namespace's UAVAgentConfigs
+type AgentConfigWatch struct {
+	client   *Client
+	informer cache.SharedIndexInformer
+}
+
+// WatchAgentConfigs starts an informer of the configured namespace's
+// UAVAgentConfigs, resynced every resync, which runs until ctx is done.
+// changed is called on every add, update, delete and resync; like informer
+// handlers it must not block.
+func (c *Client) WatchAgentConfigs(ctx context.Context, resync time.Duration, changed func()) (*AgentConfigWatch, error) {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.dynamicClient,
+		resync, c.config.Kubernetes.Namespace, nil)
+	informer := factory.ForResource(c.agentConfigGVR()).Informer()
+	notify := func(interface{}) { changed() }
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    notify,
+		UpdateFunc: func(_, _ interface{}) { changed() },
+		DeleteFunc: notify,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add UAVAgentConfig handler: %w", err)
+	}
+	factory.Start(ctx.Done())
+	return &AgentConfigWatch{client: c, informer: informer}, nil
+}
+
+// HasSynced reports whether the cache holds the UAVAgentConfigs listed
+// when the watch started
+func (w *AgentConfigWatch) HasSynced() bool {
+	return w.informer.HasSynced()
+}
+
+// Get returns the cached desired agent configuration of a vehicle. The
+// error satisfies apierrors.IsNotFound when there is none.
+func (w *AgentConfigWatch) Get(nodeName string) (*models.UAVAgentConfig, error) {
+	name := w.client.ResourceName(nodeName)
+	obj, exists, err := w.informer.GetStore().GetByKey(w.client.config.Kubernetes.Namespace + "/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached UAVAgentConfig: %w", err)
+	}
+	if !exists {
+		return nil, apierrors.NewNotFound(w.client.agentConfigGVR().GroupResource(), name)
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected UAVAgentConfig cache object %T", obj)
+	}
+	return unstructuredToAgentConfig(u)
+}
+
+// UpdateAgentConfigStatus writes the status of a UAVAgentConfig
+func (c *Client) UpdateAgentConfigStatus(ctx context.Context, agentConfig *models.UAVAgentConfig) error {
+	resource := c.dynamicClient.Resource(c.agentConfigGVR()).Namespace(c.config.Kubernetes.Namespace)
+	obj, err := resource.Get(ctx, agentConfig.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get UAVAgentConfig for status update: %w", err)
+	}
+
+	status, err := toMap(agentConfig.Status)
+	if err != nil {
+		return err
+	}
+	obj.Object["status"] = status
+	if _, err := resource.UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update UAVAgentConfig status: %w", err)
+	}
+	return nil
+}
+
+func unstructuredToAgentConfig(obj *unstructured.Unstructured) (*models.UAVAgentConfig, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	var agentConfig models.UAVAgentConfig
+	if err := json.Unmarshal(data, &agentConfig); err != nil {
+		return nil, err
+	}
+	agentConfig.Name = obj.GetName()
+	agentConfig.Generation = obj.GetGeneration()
+	return &agentConfig, nil
+}
@@ -0,0 +1,202 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	uavv1alpha1 "github.com/k3suav/uav-monitor/pkg/apis/uav/v1alpha1"
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/csaupgrade"
+)
+
+// Phase returns the status phase published for metrics
+func Phase(metrics *models.UAVMetrics) string {
+	if metrics.Health == nil {
+		return "Active"
+	}
+	switch metrics.Health.Status {
+	case "Critical":
+		return "Error"
+	case "Warning", "Healthy":
+		return "Active"
+	default:
+		return "Unknown"
+	}
+}
+
+// ApplyUAVMetrics writes metrics with server-side apply: one request for the
+// spec and labels and one for the status phase, without reading the object
+// first. fieldManager takes ownership of the spec and PowerSaveAnnotation,
+// so the write never fails on a resourceVersion conflict; labels,
+// annotations and status fields owned by others are kept.
+func (c *Client) ApplyUAVMetrics(ctx context.Context, metrics *models.UAVMetrics, fieldManager string) error {
+	if _, err := c.applySpec(ctx, metrics, fieldManager, ""); err != nil {
+		return err
+	}
+
+	status := c.applyObject(metrics.NodeName)
+	status.Object["status"] = map[string]interface{}{
+		"phase":       Phase(metrics),
+		"lastUpdated": time.Now().Format(time.RFC3339),
+	}
+	if _, err := c.apply(ctx, status, fieldManager, "status"); err != nil {
+		return fmt.Errorf("failed to apply status: %w", err)
+	}
+
+	return nil
+}
+
+// applySpec applies the spec of metrics, the agent's labels (app,
+// node-name and kubernetes.labels) and PowerSaveAnnotation as fieldManager. A PowerSaveAnnotation previously
+// applied by fieldManager is removed when metrics has no PowerSave.
+// CleanupFinalizer is applied with kubernetes.cleanupFinalizer, and removed
+// the same way once it's turned off. A non-empty resourceVersion is sent as
+// a precondition. It returns the version of the applied object.
+func (c *Client) applySpec(ctx context.Context, metrics *models.UAVMetrics, fieldManager, resourceVersion string) (objectVersion, error) {
+	obj, err := c.MetricsToUnstructured(metrics)
+	if err != nil {
+		return objectVersion{}, fmt.Errorf("failed to convert metrics to unstructured: %w", err)
+	}
+
+	meta := c.applyObject(metrics.NodeName)
+	obj.SetName(meta.GetName())
+	obj.SetNamespace(meta.GetNamespace())
+	obj.SetResourceVersion(resourceVersion)
+	obj.SetLabels(c.metricsLabels(metrics.NodeName))
+	if metrics.PowerSave != nil {
+		obj.SetAnnotations(map[string]string{PowerSaveAnnotation: metrics.PowerSave.Reason})
+	}
+	if c.config.Kubernetes.CleanupFinalizer {
+		obj.SetFinalizers([]string{CleanupFinalizer})
+	}
+
+	applied, err := c.apply(ctx, obj, fieldManager)
+	if err != nil {
+		return objectVersion{}, fmt.Errorf("failed to apply UAVMetrics: %w", err)
+	}
+	return applied, nil
+}
+
+// metricsLabels returns the labels of a node's UAVMetrics and snapshots:
+// kubernetes.labels, app and node-name
+func (c *Client) metricsLabels(nodeName string) map[string]string {
+	labels := map[string]string{}
+	for k, v := range c.config.Kubernetes.Labels {
+		labels[k] = v
+	}
+	labels["app"] = "uav-agent"
+	labels["node-name"] = nodeName
+	return labels
+}
+
+// applyObject returns an apply configuration naming a node's UAVMetrics,
+// holding no fields yet
+func (c *Client) applyObject(nodeName string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": uavv1alpha1.SchemeGroupVersion.String(),
+		"kind":       "UAVMetrics",
+		"metadata": map[string]interface{}{
+			"name":      c.ResourceName(nodeName),
+			"namespace": c.config.Kubernetes.Namespace,
+		},
+	}}
+}
+
+// apply sends obj as a server-side apply patch, forcing fieldManager's
+// ownership of its fields, and returns the version of the result
+func (c *Client) apply(ctx context.Context, obj *unstructured.Unstructured, fieldManager string, subresources ...string) (objectVersion, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return objectVersion{}, err
+	}
+	force := true
+	options := metav1.PatchOptions{FieldManager: fieldManager, Force: &force}
+	applied, err := c.uavMetrics().Patch(ctx, obj.GetName(), types.ApplyPatchType, data, options, subresources...)
+	if err != nil {
+		return objectVersion{}, err
+	}
+	return versionOf(applied), nil
+}
+
+// objectVersion identifies a state of a UAVMetrics: any write changes its
+// resourceVersion, only spec changes its generation
+type objectVersion struct {
+	resourceVersion string
+	generation      int64
+}
+
+func versionOf(obj *uavv1alpha1.UAVMetrics) objectVersion {
+	return objectVersion{resourceVersion: obj.ResourceVersion, generation: obj.Generation}
+}
+
+// precondition reads a node's UAVMetrics and returns the version telemetry
+// collected at seen can be written over, the zero version when there is no
+// object yet. If its spec changed since generation, our last write, and
+// carries newer telemetry, it returns models.ErrStaleUpdate. Reading the
+// object also upgrades its managed fields.
+func (c *Client) precondition(ctx context.Context, nodeName string, seen time.Time, fieldManager string, generation int64) (objectVersion, error) {
+	existing, err := c.uavMetrics().Get(ctx, c.ResourceName(nodeName), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return objectVersion{}, nil
+	}
+	if err != nil {
+		return objectVersion{}, fmt.Errorf("failed to get UAVMetrics: %w", err)
+	}
+	if existing.Generation != generation && existing.Spec.LastSeen().After(seen) {
+		return objectVersion{}, models.ErrStaleUpdate
+	}
+	return c.upgradeManagedFields(ctx, existing, fieldManager)
+}
+
+// statusWritten records the version of a UAVMetrics after our own status
+// write, so the next apply doesn't conflict with it
+func (c *Client) statusWritten(obj *uavv1alpha1.UAVMetrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if last, ok := c.written[obj.Name]; ok && last.generation == obj.Generation {
+		c.written[obj.Name] = versionOf(obj)
+	}
+}
+
+// upgradeManagedFields hands the fields of a UAVMetrics owned by the agent's
+// former get-then-update writes over to fieldManager, and returns the
+// resulting version. Left with the Update manager, they would outlive their
+// removal from an apply (e.g. the PowerSaveAnnotation once power saving
+// ends).
+func (c *Client) upgradeManagedFields(ctx context.Context, obj *uavv1alpha1.UAVMetrics, fieldManager string) (objectVersion, error) {
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(obj, sets.New(c.updateManager()), fieldManager)
+	if err != nil {
+		return objectVersion{}, fmt.Errorf("failed to upgrade managed fields: %w", err)
+	}
+	if patch == nil {
+		return versionOf(obj), nil
+	}
+	// The patch carries the resourceVersion read: a concurrent write makes
+	// it fail with a conflict, and the next attempt reads again
+	upgraded, err := c.uavMetrics().Patch(ctx, obj.Name, types.JSONPatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return objectVersion{}, fmt.Errorf("failed to upgrade managed fields: %w", err)
+	}
+	return versionOf(upgraded), nil
+}
+
+// updateManager returns the field manager the API server records for our
+// Update requests: the user agent up to its first "/"
+func (c *Client) updateManager() string {
+	userAgent := c.restConfig.UserAgent
+	if userAgent == "" {
+		userAgent = rest.DefaultKubernetesUserAgent()
+	}
+	manager, _, _ := strings.Cut(userAgent, "/")
+	return manager
+}
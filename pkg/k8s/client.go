@@ -3,29 +3,136 @@ package k8s
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	uavv1alpha1 "github.com/k3suav/uav-monitor/pkg/apis/uav/v1alpha1"
 	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/generated/clientset/versioned"
+	uavv1alpha1client "github.com/k3suav/uav-monitor/pkg/generated/clientset/versioned/typed/uav/v1alpha1"
 	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/resilience"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 )
 
+// PowerSaveAnnotation is set on a UAVMetrics to the reason its agent saves
+// power (see models.PowerSaveData), telling consumers that the data is
+// coarse: collected less often, with stale network measurements
+const PowerSaveAnnotation = "uav.k3s.io/power-save"
+
 // Client is a Kubernetes client wrapper for UAV CRD operations
 type Client struct {
-	dynamicClient dynamic.Interface
+	uavClient     versioned.Interface  // UAVMetrics
+	dynamicClient dynamic.Interface    // the other UAV resources
+	metaClient    metadata.Interface   // metadata-only lists (retention sweeps)
+	clientset     kubernetes.Interface // core resources (Events, Node labels)
 	config        *config.Config
 	gvr           schema.GroupVersionResource
+	restConfig    *rest.Config
+
+	// Version of each UAVMetrics as of our last write (see
+	// CreateOrUpdateUAVMetrics), the UAVMetrics informer once
+	// WatchUAVMetrics started it, the UAVEnrollment informer once
+	// EnrolledNodes started it, the pod informers WatchScheduledPods
+	// started, by scheduler name, and the event broadcaster once
+	// EventRecorder started it
+	mu              sync.Mutex
+	written         map[string]objectVersion
+	metricsCache    *uavMetricsCache
+	enrollmentCache cache.SharedIndexInformer
+	podCaches       map[string]cache.SharedIndexInformer
+	events          record.EventBroadcaster
+	eventScheme     *runtime.Scheme
+
+	// Serves ListUAVMetrics instead of the API server when set
+	fleetSource FleetSource
+}
+
+// FleetSource provides the UAVMetrics of the configured namespace from
+// somewhere other than the API server, e.g. a fleet snapshot cache
+type FleetSource interface {
+	ListUAVMetrics(ctx context.Context) ([]*models.UAVMetrics, error)
+}
+
+// SetFleetSource makes ListUAVMetrics read from source. Writes, Get and
+// lists of other namespaces still go to the API server. Call it before the
+// client is shared.
+func (c *Client) SetFleetSource(source FleetSource) {
+	c.fleetSource = source
 }
 
 // NewClient creates a new Kubernetes client
 func NewClient(cfg *config.Config) (*Client, error) {
+	k8sConfig, err := RestConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// UAVMetrics go through the generated clientset, which only serves the
+	// group version its types were generated for
+	if cfg.Kubernetes.CRDGroup != uavv1alpha1.GroupName || cfg.Kubernetes.CRDVersion != uavv1alpha1.SchemeGroupVersion.Version {
+		return nil, fmt.Errorf("unsupported CRD group version %s/%s, the UAVMetrics client serves %s",
+			cfg.Kubernetes.CRDGroup, cfg.Kubernetes.CRDVersion, uavv1alpha1.SchemeGroupVersion)
+	}
+	uavClient, err := versioned.NewForConfig(k8sConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UAV clientset: %w", err)
+	}
+
+	// Create dynamic client
+	dynamicClient, err := dynamic.NewForConfig(k8sConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	metaClient, err := metadata.NewForConfig(k8sConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metadata client: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(k8sConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	// Define GVR (GroupVersionResource)
+	gvr := schema.GroupVersionResource{
+		Group:    cfg.Kubernetes.CRDGroup,
+		Version:  cfg.Kubernetes.CRDVersion,
+		Resource: "uavmetrics",
+	}
+
+	return &Client{
+		uavClient:     uavClient,
+		dynamicClient: dynamicClient,
+		metaClient:    metaClient,
+		clientset:     clientset,
+		config:        cfg,
+		gvr:           gvr,
+		restConfig:    k8sConfig,
+		written:       make(map[string]objectVersion),
+	}, nil
+}
+
+// RestConfig builds the API server connection settings, with the resilience
+// layer (DNS cache, request hedging) installed
+func RestConfig(cfg *config.Config) (*rest.Config, error) {
 	var k8sConfig *rest.Config
 	var err error
 
@@ -47,147 +154,218 @@ func NewClient(cfg *config.Config) (*Client, error) {
 		}
 	}
 
-	// Create dynamic client
-	dynamicClient, err := dynamic.NewForConfig(k8sConfig)
+	err = resilience.Configure(k8sConfig, resilience.Options{
+		Endpoints:           cfg.Kubernetes.APIEndpoints,
+		HedgeDelay:          cfg.Kubernetes.HedgeDelay,
+		HealthCheckInterval: cfg.Kubernetes.HealthCheckInterval,
+		DNSCacheTTL:         cfg.Kubernetes.DNSCacheTTL,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+		return nil, fmt.Errorf("failed to configure API server connection: %w", err)
 	}
+	k8sConfig.Wrap(instrumentTransport)
+	return k8sConfig, nil
+}
 
-	// Define GVR (GroupVersionResource)
-	gvr := schema.GroupVersionResource{
-		Group:    cfg.Kubernetes.CRDGroup,
-		Version:  cfg.Kubernetes.CRDVersion,
-		Resource: "uavmetrics",
-	}
+// RestConfig returns a copy of the connection settings, for building other
+// clients (e.g. a typed clientset) that share the resilience layer
+func (c *Client) RestConfig() *rest.Config {
+	return rest.CopyConfig(c.restConfig)
+}
 
-	return &Client{
-		dynamicClient: dynamicClient,
-		config:        cfg,
-		gvr:           gvr,
-	}, nil
+// UAVClientset returns the generated UAVMetrics clientset, e.g. to build
+// shared informers and listers (see pkg/generated/informers)
+func (c *Client) UAVClientset() versioned.Interface {
+	return c.uavClient
 }
 
-// CreateOrUpdateUAVMetrics creates or updates a UAVMetrics CRD
+// CreateOrUpdateUAVMetrics creates or updates a UAVMetrics CRD with
+// server-side apply, as the configured field manager. Only the spec, the
+// agent's labels and PowerSaveAnnotation are owned by the agent: other
+// labels and annotations, finalizers and status written by controllers are
+// kept. The apply carries the resourceVersion of our previous write as a
+// precondition. When it conflicts, the object is read again: if someone
+// else changed the spec since our last write (another agent instance, an
+// update retried after a partition healed) and it carries newer telemetry,
+// it is left alone and models.ErrStaleUpdate is returned.
 func (c *Client) CreateOrUpdateUAVMetrics(ctx context.Context, metrics *models.UAVMetrics) error {
-	// Convert metrics to unstructured data
-	unstructuredData, err := c.metricsToUnstructured(metrics)
-	if err != nil {
-		return fmt.Errorf("failed to convert metrics to unstructured: %w", err)
-	}
+	fieldManager := c.config.Kubernetes.FieldManager
+	name := c.ResourceName(metrics.NodeName)
 
-	// Set metadata
-	name := fmt.Sprintf("uav-%s", metrics.NodeName)
-	unstructuredData.SetName(name)
-	unstructuredData.SetNamespace(c.config.Kubernetes.Namespace)
-
-	// Add labels
-	labels := map[string]string{
-		"app":       "uav-agent",
-		"node-name": metrics.NodeName,
+	c.mu.Lock()
+	last, ok := c.written[name]
+	c.mu.Unlock()
+	var err error
+	if !ok {
+		// Not written by this process yet, any stored telemetry may be newer
+		if last, err = c.precondition(ctx, metrics.NodeName, metrics.LastSeen(), fieldManager, 0); err != nil {
+			return err
+		}
 	}
-	unstructuredData.SetLabels(labels)
-
-	// Try to get existing resource
-	existing, err := c.dynamicClient.Resource(c.gvr).
-		Namespace(c.config.Kubernetes.Namespace).
-		Get(ctx, name, metav1.GetOptions{})
 
-	if err != nil {
-		// Resource doesn't exist, create it
-		_, err = c.dynamicClient.Resource(c.gvr).
-			Namespace(c.config.Kubernetes.Namespace).
-			Create(ctx, unstructuredData, metav1.CreateOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to create UAVMetrics: %w", err)
+	written, err := c.applySpec(ctx, metrics, fieldManager, last.resourceVersion)
+	if apierrors.IsConflict(err) {
+		if last, err = c.precondition(ctx, metrics.NodeName, metrics.LastSeen(), fieldManager, last.generation); err != nil {
+			return err
 		}
-		return nil
+		written, err = c.applySpec(ctx, metrics, fieldManager, last.resourceVersion)
 	}
 
-	// Resource exists, update it
-	unstructuredData.SetResourceVersion(existing.GetResourceVersion())
-	_, err = c.dynamicClient.Resource(c.gvr).
-		Namespace(c.config.Kubernetes.Namespace).
-		Update(ctx, unstructuredData, metav1.UpdateOptions{})
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if err != nil {
-		return fmt.Errorf("failed to update UAVMetrics: %w", err)
+		delete(c.written, name)
+		return err
 	}
-
+	c.written[name] = written
 	return nil
 }
 
-// CreateOrUpdateWithRetry creates or updates with retry logic
+// CreateOrUpdateWithRetry creates or updates with retry logic, waiting an
+// exponentially growing, jittered delay between attempts. Errors a retry
+// can't fix (forbidden, invalid) are returned at once. Conflicts and
+// transient errors (the API server briefly unreachable over a flaky link)
+// keep being retried until RetryTimeout has passed; other errors are
+// retried RetryAttempts times.
 func (c *Client) CreateOrUpdateWithRetry(ctx context.Context, metrics *models.UAVMetrics) error {
-	var lastErr error
-
-	for attempt := 0; attempt <= c.config.Kubernetes.RetryAttempts; attempt++ {
-		if attempt > 0 {
-			// Wait before retry
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(c.config.Kubernetes.RetryDelay):
-			}
-		}
+	backoff := resilience.NewBackoff(c.config.Kubernetes.RetryDelay, c.config.Kubernetes.RetryMaxDelay)
+	deadline := time.Now().Add(c.config.Kubernetes.RetryTimeout)
 
+	for attempt := 1; ; attempt++ {
 		err := c.CreateOrUpdateUAVMetrics(ctx, metrics)
-		if err == nil {
-			return nil
+		if err == nil || errors.Is(err, models.ErrStaleUpdate) {
+			return err
+		}
+		if resilience.IsPermanent(err) {
+			return err
+		}
+		retryable := apierrors.IsConflict(err) || resilience.IsTransient(err)
+		if attempt > c.config.Kubernetes.RetryAttempts && (!retryable || time.Now().After(deadline)) {
+			return fmt.Errorf("failed after %d attempts: %w", attempt, err)
 		}
-		lastErr = err
-	}
 
-	return fmt.Errorf("failed after %d attempts: %w", c.config.Kubernetes.RetryAttempts+1, lastErr)
+		if err := backoff.Wait(ctx); err != nil {
+			return err
+		}
+		clientMetrics.retry(retryApply)
+	}
 }
 
 // GetUAVMetrics retrieves a UAVMetrics CRD
 func (c *Client) GetUAVMetrics(ctx context.Context, nodeName string) (*models.UAVMetrics, error) {
-	name := fmt.Sprintf("uav-%s", nodeName)
-
-	unstructuredData, err := c.dynamicClient.Resource(c.gvr).
-		Namespace(c.config.Kubernetes.Namespace).
-		Get(ctx, name, metav1.GetOptions{})
+	obj, err := c.uavMetrics().Get(ctx, c.ResourceName(nodeName), metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get UAVMetrics: %w", err)
 	}
 
-	// Convert unstructured to metrics
-	metrics, err := c.unstructuredToMetrics(unstructuredData)
+	return &obj.Spec, nil
+}
+
+// ListUAVMetrics lists all UAVMetrics CRDs, from the fleet source when one
+// is set, or from the informer cache once WatchUAVMetrics has synced it.
+// When enrollment.required is set, vehicles without an approved enrollment
+// are left out (a fleet source is expected to filter itself).
+func (c *Client) ListUAVMetrics(ctx context.Context) ([]*models.UAVMetrics, error) {
+	if c.fleetSource != nil {
+		return c.fleetSource.ListUAVMetrics(ctx)
+	}
+	var metrics []*models.UAVMetrics
+	var err error
+	if metricsCache := c.syncedMetricsCache(); metricsCache != nil {
+		metrics, err = metricsCache.list()
+	} else {
+		metrics, err = c.ListUAVMetricsWith(ctx, c.config.Kubernetes.Namespace, c.listOptions())
+	}
+	if err != nil || !c.config.Enrollment.Required {
+		return metrics, err
+	}
+
+	enrolled, err := c.EnrolledNodes(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert unstructured to metrics: %w", err)
+		return nil, err
+	}
+	members := metrics[:0]
+	for _, m := range metrics {
+		if enrolled[m.NodeName] {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
+
+// ListUAVMetricsIn lists the UAVMetrics in namespace, e.g. a simulated fleet
+// kept apart from the production one
+func (c *Client) ListUAVMetricsIn(ctx context.Context, namespace string) ([]*models.UAVMetrics, error) {
+	return c.ListUAVMetricsWith(ctx, namespace, metav1.ListOptions{})
+}
+
+// ListUAVMetricsWith lists the UAVMetrics in namespace matching the label and
+// field selectors of options, e.g. LabelSelector "fleet=alpha"
+func (c *Client) ListUAVMetricsWith(ctx context.Context, namespace string, options metav1.ListOptions) ([]*models.UAVMetrics, error) {
+	list, err := c.uavClient.UavV1alpha1().UAVMetrics(namespace).List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list UAVMetrics: %w", err)
+	}
+
+	metrics := make([]*models.UAVMetrics, 0, len(list.Items))
+	for i := range list.Items {
+		metrics = append(metrics, &list.Items[i].Spec)
 	}
 
 	return metrics, nil
 }
 
-// ListUAVMetrics lists all UAVMetrics CRDs
-func (c *Client) ListUAVMetrics(ctx context.Context) ([]*models.UAVMetrics, error) {
-	unstructuredList, err := c.dynamicClient.Resource(c.gvr).
-		Namespace(c.config.Kubernetes.Namespace).
-		List(ctx, metav1.ListOptions{})
+// ListUAVMetricsObjects lists the UAVMetrics objects in namespace with their
+// metadata, e.g. to delete them conditionally (see DeleteUAVMetricsIn)
+func (c *Client) ListUAVMetricsObjects(ctx context.Context, namespace string) ([]uavv1alpha1.UAVMetrics, error) {
+	list, err := c.uavClient.UavV1alpha1().UAVMetrics(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list UAVMetrics: %w", err)
 	}
+	return list.Items, nil
+}
 
-	metrics := make([]*models.UAVMetrics, 0, len(unstructuredList.Items))
-	for _, item := range unstructuredList.Items {
-		m, err := c.unstructuredToMetrics(&item)
+// ListUAVMetricsStatus returns the status of every UAVMetrics CRD, by node
+// name. It reads the WatchUAVMetrics informer once it has synced, the API
+// server otherwise, even with a fleet source: fleet sources carry no status.
+func (c *Client) ListUAVMetricsStatus(ctx context.Context) (map[string]*models.UAVMetricsStatus, error) {
+	var items []*uavv1alpha1.UAVMetrics
+	if metricsCache := c.syncedMetricsCache(); metricsCache != nil {
+		var err error
+		if items, err = metricsCache.lister.List(labels.Everything()); err != nil {
+			return nil, fmt.Errorf("failed to list cached UAVMetrics: %w", err)
+		}
+	} else {
+		list, err := c.uavMetrics().List(ctx, c.listOptions())
 		if err != nil {
-			// Log error but continue with other items
-			continue
+			return nil, fmt.Errorf("failed to list UAVMetrics: %w", err)
+		}
+		for i := range list.Items {
+			items = append(items, &list.Items[i])
 		}
-		metrics = append(metrics, m)
 	}
 
-	return metrics, nil
+	statuses := make(map[string]*models.UAVMetricsStatus, len(items))
+	for _, item := range items {
+		if item.Spec.NodeName == "" || item.Status.UAVMetricsStatus == (models.UAVMetricsStatus{}) {
+			continue
+		}
+		statuses[item.Spec.NodeName] = item.Status.UAVMetricsStatus.DeepCopy()
+	}
+	return statuses, nil
 }
 
 // DeleteUAVMetrics deletes a UAVMetrics CRD
 func (c *Client) DeleteUAVMetrics(ctx context.Context, nodeName string) error {
-	name := fmt.Sprintf("uav-%s", nodeName)
+	return c.DeleteUAVMetricsIn(ctx, c.config.Kubernetes.Namespace, nodeName, nil)
+}
 
-	err := c.dynamicClient.Resource(c.gvr).
-		Namespace(c.config.Kubernetes.Namespace).
-		Delete(ctx, name, metav1.DeleteOptions{})
+// DeleteUAVMetricsIn deletes a UAVMetrics CRD in namespace. With
+// preconditions the delete fails with a conflict when the object was
+// replaced or written since it was read.
+func (c *Client) DeleteUAVMetricsIn(ctx context.Context, namespace, nodeName string, preconditions *metav1.Preconditions) error {
+	err := c.uavClient.UavV1alpha1().UAVMetrics(namespace).
+		Delete(ctx, c.ResourceName(nodeName), metav1.DeleteOptions{Preconditions: preconditions})
 	if err != nil {
 		return fmt.Errorf("failed to delete UAVMetrics: %w", err)
 	}
@@ -195,42 +373,118 @@ func (c *Client) DeleteUAVMetrics(ctx context.Context, nodeName string) error {
 	return nil
 }
 
-// UpdateStatus updates the status subresource
+// UpdateStatus sets the phase and the Ready condition in the status
+// subresource and clears the failure of a previous run, keeping other status
+// fields written by controllers. Once the agent is Inactive, the conditions
+// derived from its telemetry become Unknown.
 func (c *Client) UpdateStatus(ctx context.Context, nodeName string, phase string) error {
-	name := fmt.Sprintf("uav-%s", nodeName)
-
-	// Get current resource
-	unstructuredData, err := c.dynamicClient.Resource(c.gvr).
-		Namespace(c.config.Kubernetes.Namespace).
-		Get(ctx, name, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get UAVMetrics for status update: %w", err)
-	}
+	return c.updateStatus(ctx, nodeName, func(obj *uavv1alpha1.UAVMetrics) error {
+		obj.Status.Phase = phase
+		obj.Status.LastUpdated = time.Now()
+		obj.Status.Failure = nil
+		setConditions(obj, readyCondition(phase))
+		if phase == "Inactive" {
+			setConditions(obj, unknownTelemetryConditions("AgentStopped", "Agent has stopped publishing")...)
+		}
+		return nil
+	})
+}
 
-	// Update status
-	status := map[string]interface{}{
-		"phase":       phase,
-		"lastUpdated": time.Now().Format(time.RFC3339),
-	}
+// UpdateMetricsStatus sets the phase and the conditions derived from
+// metrics (Ready, BatteryHealthy, GPSLocked and LinkHealthy) in the status
+// subresource and clears the failure of a previous run
+func (c *Client) UpdateMetricsStatus(ctx context.Context, metrics *models.UAVMetrics) error {
+	phase := Phase(metrics)
+	return c.updateStatus(ctx, metrics.NodeName, func(obj *uavv1alpha1.UAVMetrics) error {
+		obj.Status.Phase = phase
+		obj.Status.LastUpdated = time.Now()
+		obj.Status.Failure = nil
+		setConditions(obj, readyCondition(phase))
+		setConditions(obj, c.telemetryConditions(metrics)...)
+		return nil
+	})
+}
 
-	if err := unstructured.SetNestedMap(unstructuredData.Object, status, "status"); err != nil {
-		return fmt.Errorf("failed to set status: %w", err)
-	}
+// MarkLost sets the phase to Lost, Ready to False and the telemetry
+// conditions to Unknown, and records the last known position in status. If the UAV has published telemetry newer than the beacon since it
+// was declared lost, nothing is written and models.ErrStaleUpdate is returned.
+func (c *Client) MarkLost(ctx context.Context, beacon *models.LostBeacon) error {
+	return c.updateStatus(ctx, beacon.NodeName, func(obj *uavv1alpha1.UAVMetrics) error {
+		if obj.Spec.LastSeen().After(beacon.LastSeen) {
+			return models.ErrStaleUpdate
+		}
+		obj.Status.Phase = "Lost"
+		obj.Status.LastUpdated = beacon.LastSeen
+		obj.Status.LastKnownPosition = beacon
+		setConditions(obj, readyCondition("Lost"))
+		setConditions(obj, unknownTelemetryConditions("NotReporting", "UAV stopped reporting")...)
+		return nil
+	})
+}
 
-	// Update status subresource
-	_, err = c.dynamicClient.Resource(c.gvr).
-		Namespace(c.config.Kubernetes.Namespace).
-		UpdateStatus(ctx, unstructuredData, metav1.UpdateOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to update status: %w", err)
-	}
+// MarkFailed sets the phase to Error, Ready to False and records the error
+// terminating the agent in status
+func (c *Client) MarkFailed(ctx context.Context, nodeName string, failure *models.AgentFailure) error {
+	return c.updateStatus(ctx, nodeName, func(obj *uavv1alpha1.UAVMetrics) error {
+		obj.Status.Phase = "Error"
+		obj.Status.LastUpdated = failure.Time
+		obj.Status.Failure = failure
+		setConditions(obj, condition(uavv1alpha1.ConditionReady, metav1.ConditionFalse, "AgentFailed", failure.Message))
+		return nil
+	})
+}
 
-	return nil
+// UpdateRoutingStats records a router's decision statistics in the status of
+// the node's UAVMetrics, keeping the other status fields
+func (c *Client) UpdateRoutingStats(ctx context.Context, nodeName string, stats *models.RoutingStats) error {
+	return c.updateStatus(ctx, nodeName, func(obj *uavv1alpha1.UAVMetrics) error {
+		obj.Status.Routing = stats
+		return nil
+	})
 }
 
 // Helper functions
 
-func (c *Client) metricsToUnstructured(metrics *models.UAVMetrics) (*unstructured.Unstructured, error) {
+// uavMetrics returns the typed client of the configured namespace's UAVMetrics
+func (c *Client) uavMetrics() uavv1alpha1client.UAVMetricsInterface {
+	return c.uavClient.UavV1alpha1().UAVMetrics(c.config.Kubernetes.Namespace)
+}
+
+// listOptions selects the configured subset of the namespace's UAVMetrics
+func (c *Client) listOptions() metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector: c.config.Kubernetes.LabelSelector,
+		FieldSelector: c.config.Kubernetes.FieldSelector,
+	}
+}
+
+// updateStatus reads a node's UAVMetrics, lets mutate change its status and
+// writes it back through the status subresource. When someone else wrote in
+// between, the write conflicts and is done again from a fresh read, after a
+// short backoff. Errors returned by mutate are returned as is, without
+// writing.
+func (c *Client) updateStatus(ctx context.Context, nodeName string, mutate func(*uavv1alpha1.UAVMetrics) error) error {
+	return retryOnConflict(retry.DefaultBackoff, retryStatus, func() error {
+		obj, err := c.uavMetrics().Get(ctx, c.ResourceName(nodeName), metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get UAVMetrics for status update: %w", err)
+		}
+		if err := mutate(obj); err != nil {
+			return err
+		}
+
+		updated, err := c.uavMetrics().UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update status: %w", err)
+		}
+		c.statusWritten(updated)
+		return nil
+	})
+}
+
+// MetricsToUnstructured converts metrics into a UAVMetrics custom resource with
+// the configured group and version. Object metadata is left for the caller.
+func (c *Client) MetricsToUnstructured(metrics *models.UAVMetrics) (*unstructured.Unstructured, error) {
 	// Convert metrics to JSON
 	data, err := json.Marshal(metrics)
 	if err != nil {
@@ -255,7 +509,8 @@ func (c *Client) metricsToUnstructured(metrics *models.UAVMetrics) (*unstructure
 	return obj, nil
 }
 
-func (c *Client) unstructuredToMetrics(obj *unstructured.Unstructured) (*models.UAVMetrics, error) {
+// UnstructuredToMetrics decodes the spec of a UAVMetrics custom resource.
+func (c *Client) UnstructuredToMetrics(obj *unstructured.Unstructured) (*models.UAVMetrics, error) {
 	// Extract spec
 	spec, found, err := unstructured.NestedMap(obj.Object, "spec")
 	if err != nil || !found {
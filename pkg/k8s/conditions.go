@@ -0,0 +1,105 @@
+package k8s
+
+import (
+	"fmt"
+
+	uavv1alpha1 "github.com/k3suav/uav-monitor/pkg/apis/uav/v1alpha1"
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Packet loss (%) above which the link isn't healthy
+const linkMaxPacketLoss = 10.0
+
+// readyCondition returns the Ready condition of a status phase
+func readyCondition(phase string) metav1.Condition {
+	switch phase {
+	case "Active":
+		return condition(uavv1alpha1.ConditionReady, metav1.ConditionTrue, "Publishing", "Agent is publishing telemetry")
+	case "Error":
+		return condition(uavv1alpha1.ConditionReady, metav1.ConditionFalse, "HealthCritical", "UAV health is critical, see the UAVMetrics events for details")
+	case "Inactive":
+		return condition(uavv1alpha1.ConditionReady, metav1.ConditionFalse, "AgentStopped", "Agent has stopped publishing")
+	case "Lost":
+		return condition(uavv1alpha1.ConditionReady, metav1.ConditionFalse, "Lost", "UAV stopped reporting and is presumed lost")
+	default:
+		return condition(uavv1alpha1.ConditionReady, metav1.ConditionUnknown, "HealthUnknown", "UAV health is unknown")
+	}
+}
+
+// telemetryConditions returns the BatteryHealthy, GPSLocked and LinkHealthy
+// conditions of metrics. Like the Node conditions, messages carry no live
+// values, so transitions are only recorded when the outcome changes.
+func (c *Client) telemetryConditions(metrics *models.UAVMetrics) []metav1.Condition {
+	collection := c.config.Collection
+	var battery, gps, link metav1.Condition
+
+	switch {
+	case !collection.EnableBattery || metrics.CollectionFailed(models.SectionBattery):
+		battery = condition(uavv1alpha1.ConditionBatteryHealthy, metav1.ConditionUnknown, "BatteryNotReported", "Battery was not collected")
+	case metrics.Battery.IsCriticalBattery():
+		battery = condition(uavv1alpha1.ConditionBatteryHealthy, metav1.ConditionFalse, "BatteryCritical", "Battery is critically low")
+	case metrics.Battery.IsLowBattery(collection.BatteryLowThreshold):
+		battery = condition(uavv1alpha1.ConditionBatteryHealthy, metav1.ConditionFalse, "BatteryLow",
+			fmt.Sprintf("Battery below %g%%", collection.BatteryLowThreshold))
+	default:
+		battery = condition(uavv1alpha1.ConditionBatteryHealthy, metav1.ConditionTrue, "BatterySufficient",
+			fmt.Sprintf("Battery at or above %g%%", collection.BatteryLowThreshold))
+	}
+
+	// Same fix requirement as the health check: a 3D fix when the receiver
+	// reports its fix type, otherwise enough satellites
+	switch {
+	case !collection.EnableGPS || metrics.CollectionFailed(models.SectionGPS):
+		gps = condition(uavv1alpha1.ConditionGPSLocked, metav1.ConditionUnknown, "GPSNotReported", "GPS was not collected")
+	case metrics.GPS.LastUpdate.IsZero():
+		gps = condition(uavv1alpha1.ConditionGPSLocked, metav1.ConditionFalse, "NoFix", "GNSS receiver has not reported a fix")
+	case metrics.GPS.PositionDegraded():
+		gps = condition(uavv1alpha1.ConditionGPSLocked, metav1.ConditionFalse, "PositionDegraded", "Fix failed integrity checks against dead reckoning and barometric altitude")
+	case metrics.GPS.FixType != "" && !metrics.GPS.Has3DFix():
+		gps = condition(uavv1alpha1.ConditionGPSLocked, metav1.ConditionFalse, "No3DFix", fmt.Sprintf("GNSS fix type is %s", metrics.GPS.FixType))
+	case metrics.GPS.FixType == "" && metrics.GPS.Satellites < collection.GPSMinSatellites:
+		gps = condition(uavv1alpha1.ConditionGPSLocked, metav1.ConditionFalse, "LowSatellites",
+			fmt.Sprintf("Fewer than %d satellites in use", collection.GPSMinSatellites))
+	default:
+		gps = condition(uavv1alpha1.ConditionGPSLocked, metav1.ConditionTrue, "Locked", "GNSS receiver has a usable fix")
+	}
+
+	switch {
+	case metrics.Diagnostics != nil && len(metrics.Diagnostics.StaleLinks) > 0:
+		link = condition(uavv1alpha1.ConditionLinkHealthy, metav1.ConditionFalse, "LinkStale", "Some of the vehicle's links are stale, see the UAVMetrics diagnostics")
+	case !collection.EnableNetwork || metrics.Network == nil:
+		link = condition(uavv1alpha1.ConditionLinkHealthy, metav1.ConditionUnknown, "NetworkNotReported", "Network was not collected")
+	case metrics.Network.PacketLoss > linkMaxPacketLoss:
+		link = condition(uavv1alpha1.ConditionLinkHealthy, metav1.ConditionFalse, "PacketLoss", fmt.Sprintf("Packet loss above %g%%", linkMaxPacketLoss))
+	default:
+		link = condition(uavv1alpha1.ConditionLinkHealthy, metav1.ConditionTrue, "LinkHealthy", "Links are fresh and packet loss is low")
+	}
+
+	return []metav1.Condition{battery, gps, link}
+}
+
+// unknownTelemetryConditions returns BatteryHealthy, GPSLocked and
+// LinkHealthy as Unknown, for when the agent no longer reports them
+func unknownTelemetryConditions(reason, message string) []metav1.Condition {
+	return []metav1.Condition{
+		condition(uavv1alpha1.ConditionBatteryHealthy, metav1.ConditionUnknown, reason, message),
+		condition(uavv1alpha1.ConditionGPSLocked, metav1.ConditionUnknown, reason, message),
+		condition(uavv1alpha1.ConditionLinkHealthy, metav1.ConditionUnknown, reason, message),
+	}
+}
+
+func condition(conditionType string, status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{Type: conditionType, Status: status, Reason: reason, Message: message}
+}
+
+// setConditions sets conditions on the status of obj, keeping their
+// transition time when their status is unchanged
+func setConditions(obj *uavv1alpha1.UAVMetrics, conditions ...metav1.Condition) {
+	for _, condition := range conditions {
+		condition.ObservedGeneration = obj.Generation
+		meta.SetStatusCondition(&obj.Status.Conditions, condition)
+	}
+}
@@ -0,0 +1,114 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/k3suav/uav-monitor/api/crd"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/yaml"
+)
+
+var crdResource = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// How long EnsureCRD waits for the API server to serve the UAVMetrics CRD
+const crdEstablishTimeout = time.Minute
+
+// EnsureCRD installs or upgrades the UAVMetrics CustomResourceDefinition
+// built into the binary with server-side apply, then waits until the API
+// server serves it (the Established condition). It needs permission to get
+// and patch customresourcedefinitions.
+func (c *Client) EnsureCRD(ctx context.Context) error {
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(crd.UAVMetrics, &obj.Object); err != nil {
+		return fmt.Errorf("failed to decode the UAVMetrics CRD: %w", err)
+	}
+	if obj.GetName() != c.config.Kubernetes.CRDName {
+		return fmt.Errorf("built-in CRD is %s, not kubernetes.crdName %s", obj.GetName(), c.config.Kubernetes.CRDName)
+	}
+
+	resource := c.dynamicClient.Resource(crdResource)
+	options := metav1.ApplyOptions{FieldManager: c.config.Kubernetes.FieldManager, Force: true}
+	if _, err := resource.Apply(ctx, obj.GetName(), obj, options); err != nil {
+		return fmt.Errorf("failed to apply the UAVMetrics CRD: %w", err)
+	}
+
+	err := wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, crdEstablishTimeout, true, func(ctx context.Context) (bool, error) {
+		current, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		conditions, _, _ := unstructured.NestedSlice(current.Object, "status", "conditions")
+		for _, item := range conditions {
+			condition, _ := item.(map[string]interface{})
+			switch condition["type"] {
+			case "Established":
+				if condition["status"] == "True" {
+					return true, nil
+				}
+			case "NamesAccepted":
+				// Another CRD already uses one of the names; waiting won't help
+				if condition["status"] == "False" {
+					return false, fmt.Errorf("names not accepted: %v", condition["message"])
+				}
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("UAVMetrics CRD not established: %w", err)
+	}
+	return nil
+}
+
+// EnsureCRDConversion points the conversion of the UAVMetrics CRD at the
+// webhook served at /convert by the Service namespace/name, trusting
+// caBundle (PEM), with server-side apply as fieldManager. Only
+// spec.conversion is applied, so EnsureCRD keeps owning the rest of the CRD
+// and doesn't reset it. The CRD must exist already. It needs permission to
+// get and patch customresourcedefinitions.
+func (c *Client) EnsureCRDConversion(ctx context.Context, namespace, name string, caBundle []byte, fieldManager string) error {
+	resource := c.dynamicClient.Resource(crdResource)
+	if _, err := resource.Get(ctx, c.config.Kubernetes.CRDName, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("failed to get the UAVMetrics CRD: %w", err)
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]interface{}{
+			"name": c.config.Kubernetes.CRDName,
+		},
+		"spec": map[string]interface{}{
+			"conversion": map[string]interface{}{
+				"strategy": "Webhook",
+				"webhook": map[string]interface{}{
+					"conversionReviewVersions": []interface{}{"v1"},
+					"clientConfig": map[string]interface{}{
+						"service": map[string]interface{}{
+							"namespace": namespace,
+							"name":      name,
+							"path":      "/convert",
+						},
+						"caBundle": base64.StdEncoding.EncodeToString(caBundle),
+					},
+				},
+			},
+		},
+	}}
+	options := metav1.ApplyOptions{FieldManager: fieldManager, Force: true}
+	if _, err := resource.Apply(ctx, obj.GetName(), obj, options); err != nil {
+		return fmt.Errorf("failed to apply the UAVMetrics CRD conversion: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// LabelDecommissioned marks the Node of a decommissioned vehicle. The agent
+// DaemonSet's node affinity excludes such Nodes, which stops the agent.
+const LabelDecommissioned = "uav.k3s.io/decommissioned"
+
+// mirrorPodAnnotation marks static pods, which can't be evicted
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// decommissionGVR returns the resource of UAVDecommission objects, in the
+// same group and version as UAVMetrics
+func (c *Client) decommissionGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    c.gvr.Group,
+		Version:  c.gvr.Version,
+		Resource: "uavdecommissions",
+	}
+}
+
+// ListDecommissions lists all UAVDecommission CRDs
+func (c *Client) ListDecommissions(ctx context.Context) ([]*models.UAVDecommission, error) {
+	unstructuredList, err := c.dynamicClient.Resource(c.decommissionGVR()).
+		Namespace(c.config.Kubernetes.Namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list UAVDecommissions: %w", err)
+	}
+
+	decommissions := make([]*models.UAVDecommission, 0, len(unstructuredList.Items))
+	for _, item := range unstructuredList.Items {
+		d, err := unstructuredToDecommission(&item)
+		if err != nil {
+			continue
+		}
+		decommissions = append(decommissions, d)
+	}
+	return decommissions, nil
+}
+
+// UpdateDecommissionStatus writes the status of a decommission
+func (c *Client) UpdateDecommissionStatus(ctx context.Context, decommission *models.UAVDecommission) error {
+	resource := c.dynamicClient.Resource(c.decommissionGVR()).Namespace(c.config.Kubernetes.Namespace)
+	obj, err := resource.Get(ctx, decommission.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get UAVDecommission for status update: %w", err)
+	}
+
+	status, err := toMap(decommission.Status)
+	if err != nil {
+		return err
+	}
+	obj.Object["status"] = status
+	if _, err := resource.UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update UAVDecommission status: %w", err)
+	}
+	return nil
+}
+
+func unstructuredToDecommission(obj *unstructured.Unstructured) (*models.UAVDecommission, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	var decommission models.UAVDecommission
+	if err := json.Unmarshal(data, &decommission); err != nil {
+		return nil, err
+	}
+	if decommission.Spec.NodeName == "" {
+		return nil, fmt.Errorf("spec.nodeName not found in unstructured object")
+	}
+	decommission.Name = obj.GetName()
+	return &decommission, nil
+}
+
+// CordonNode marks the Node unschedulable. The error satisfies
+// apierrors.IsNotFound when the Node doesn't exist.
+func (c *Client) CordonNode(ctx context.Context, nodeName string) error {
+	patch := []byte(`{"spec":{"unschedulable":true}}`)
+	if _, err := c.clientset.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to cordon node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// EvictNodePods evicts the pods running on the Node, except DaemonSet and
+// static pods, honouring PodDisruptionBudgets. It returns the number of pods
+// still on the Node, including those being evicted or blocked by a budget;
+// call it again until none remain.
+func (c *Client) EvictNodePods(ctx context.Context, nodeName string) (int, error) {
+	pods, err := c.clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pods on node %s: %w", nodeName, err)
+	}
+
+	remaining := 0
+	for _, pod := range pods.Items {
+		if !evictable(&pod) {
+			continue
+		}
+		remaining++
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		eviction := &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		}
+		err := c.clientset.CoreV1().Pods(pod.Namespace).EvictV1(ctx, eviction)
+		switch {
+		case err == nil, apierrors.IsNotFound(err):
+		case apierrors.IsTooManyRequests(err):
+			// Blocked by a PodDisruptionBudget, retried on the next call
+		default:
+			return remaining, fmt.Errorf("failed to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+	}
+	return remaining, nil
+}
+
+// evictable reports whether draining the Node evicts pod
+func evictable(pod *v1.Pod) bool {
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return false
+	}
+	if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+		return false
+	}
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "DaemonSet" {
+			return false
+		}
+	}
+	return true
+}
+
+// NodePods lists the pods in namespace matching selector that run on the
+// Node
+func (c *Client) NodePods(ctx context.Context, namespace, selector, nodeName string) ([]v1.Pod, error) {
+	pods, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods on node %s: %w", nodeName, err)
+	}
+	return pods.Items, nil
+}
+
+// MarkNodeDecommissioned sets the decommissioned label on the Node
+func (c *Client) MarkNodeDecommissioned(ctx context.Context, nodeName string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{LabelDecommissioned: "true"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.clientset.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to label node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// ClearNodeUAVState removes the labels, taint and conditions the agent sets
+// on the Node (see SyncNodeLabels and SyncNodeConditions). The
+// decommissioned label and the cordon are kept.
+func (c *Client) ClearNodeUAVState(ctx context.Context, nodeName string) error {
+	return retryOnConflict(retry.DefaultRetry, retryNode, func() error {
+		node, err := c.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+		}
+
+		conditions := node.Status.Conditions[:0]
+		for _, condition := range node.Status.Conditions {
+			if condition.Type != NodeConditionBatteryLow && condition.Type != NodeConditionCritical {
+				conditions = append(conditions, condition)
+			}
+		}
+		if len(conditions) != len(node.Status.Conditions) {
+			node.Status.Conditions = conditions
+			if node, err = c.clientset.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+		}
+
+		changed := false
+		for _, key := range nodeLabelKeys {
+			if _, ok := node.Labels[key]; ok {
+				delete(node.Labels, key)
+				changed = true
+			}
+		}
+		taints := node.Spec.Taints[:0]
+		for _, taint := range node.Spec.Taints {
+			if taint.Key != TaintBatteryLow {
+				taints = append(taints, taint)
+			}
+		}
+		if len(taints) != len(node.Spec.Taints) {
+			node.Spec.Taints = taints
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+		_, err = c.clientset.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// DeleteNode deletes the Node
+func (c *Client) DeleteNode(ctx context.Context, nodeName string) error {
+	if err := c.clientset.CoreV1().Nodes().Delete(ctx, nodeName, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("failed to delete node %s: %w", nodeName, err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ApprovalAnnotation is set by operators on a UAVEnrollment to approve or
+// deny it manually
+const ApprovalAnnotation = "uav.k3s.io/approval"
+
+// enrollmentGVR returns the resource of UAVEnrollment objects, in the same
+// group and version as UAVMetrics
+func (c *Client) enrollmentGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    c.gvr.Group,
+		Version:  c.gvr.Version,
+		Resource: "uavenrollments",
+	}
+}
+
+// GetEnrollment returns the enrollment of a vehicle. The error satisfies
+// apierrors.IsNotFound when the vehicle never requested enrollment.
+func (c *Client) GetEnrollment(ctx context.Context, nodeName string) (*models.UAVEnrollment, error) {
+	obj, err := c.dynamicClient.Resource(c.enrollmentGVR()).
+		Namespace(c.config.Kubernetes.Namespace).
+		Get(ctx, c.ResourceName(nodeName), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get UAVEnrollment: %w", err)
+	}
+	return unstructuredToEnrollment(obj)
+}
+
+// SubmitEnrollment creates the vehicle's enrollment, or replaces the spec of
+// an existing one (e.g. to request a new certificate). The status is kept.
+func (c *Client) SubmitEnrollment(ctx context.Context, spec models.UAVEnrollmentSpec) error {
+	name := c.ResourceName(spec.NodeName)
+	specMap, err := toMap(spec)
+	if err != nil {
+		return err
+	}
+
+	resource := c.dynamicClient.Resource(c.enrollmentGVR()).Namespace(c.config.Kubernetes.Namespace)
+	existing, err := resource.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": fmt.Sprintf("%s/%s", c.gvr.Group, c.gvr.Version),
+				"kind":       "UAVEnrollment",
+				"spec":       specMap,
+			},
+		}
+		obj.SetName(name)
+		obj.SetNamespace(c.config.Kubernetes.Namespace)
+		if _, err := resource.Create(ctx, obj, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create UAVEnrollment: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get UAVEnrollment: %w", err)
+	}
+
+	existing.Object["spec"] = specMap
+	if _, err := resource.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update UAVEnrollment: %w", err)
+	}
+	return nil
+}
+
+// ListEnrollments lists all UAVEnrollment CRDs
+func (c *Client) ListEnrollments(ctx context.Context) ([]*models.UAVEnrollment, error) {
+	unstructuredList, err := c.dynamicClient.Resource(c.enrollmentGVR()).
+		Namespace(c.config.Kubernetes.Namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list UAVEnrollments: %w", err)
+	}
+
+	enrollments := make([]*models.UAVEnrollment, 0, len(unstructuredList.Items))
+	for _, item := range unstructuredList.Items {
+		e, err := unstructuredToEnrollment(&item)
+		if err != nil {
+			continue
+		}
+		enrollments = append(enrollments, e)
+	}
+	return enrollments, nil
+}
+
+// UpdateEnrollmentStatus writes the status of an enrollment. It fails with a
+// conflict if the spec changed since the enrollment was read.
+func (c *Client) UpdateEnrollmentStatus(ctx context.Context, enrollment *models.UAVEnrollment) error {
+	resource := c.dynamicClient.Resource(c.enrollmentGVR()).Namespace(c.config.Kubernetes.Namespace)
+	obj, err := resource.Get(ctx, enrollment.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get UAVEnrollment for status update: %w", err)
+	}
+	current, err := unstructuredToEnrollment(obj)
+	if err != nil {
+		return err
+	}
+	if !current.Spec.RequestedAt.Equal(enrollment.Spec.RequestedAt) || current.Spec.CSR != enrollment.Spec.CSR {
+		return apierrors.NewConflict(c.enrollmentGVR().GroupResource(), enrollment.Name, fmt.Errorf("enrollment request changed"))
+	}
+
+	status, err := toMap(enrollment.Status)
+	if err != nil {
+		return err
+	}
+	obj.Object["status"] = status
+	if _, err := resource.UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update UAVEnrollment status: %w", err)
+	}
+	return nil
+}
+
+// RevokeEnrollment removes the operator's approval from the vehicle's
+// enrollment and denies it, ending its fleet membership. The issued
+// certificate is dropped from the status but stays valid until it expires.
+// The error satisfies apierrors.IsNotFound when the vehicle never requested
+// enrollment.
+func (c *Client) RevokeEnrollment(ctx context.Context, nodeName, reason string) error {
+	name := c.ResourceName(nodeName)
+	resource := c.dynamicClient.Resource(c.enrollmentGVR()).Namespace(c.config.Kubernetes.Namespace)
+	obj, err := resource.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get UAVEnrollment: %w", err)
+	}
+
+	// Without the annotation, the enrollment controller keeps the denial
+	if annotations := obj.GetAnnotations(); annotations[ApprovalAnnotation] != "" {
+		delete(annotations, ApprovalAnnotation)
+		obj.SetAnnotations(annotations)
+		if obj, err = resource.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update UAVEnrollment: %w", err)
+		}
+	}
+
+	enrollment, err := unstructuredToEnrollment(obj)
+	if err != nil {
+		return err
+	}
+	status, err := toMap(models.UAVEnrollmentStatus{
+		Phase:        models.EnrollmentDenied,
+		DecidedBy:    models.DecidedByDecommission,
+		Reason:       reason,
+		SerialNumber: enrollment.Spec.SerialNumber,
+	})
+	if err != nil {
+		return err
+	}
+	obj.Object["status"] = status
+	if _, err := resource.UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update UAVEnrollment status: %w", err)
+	}
+	return nil
+}
+
+// EnrolledNodes returns the names of the vehicles currently enrolled in the
+// fleet. They're read from an informer of the configured namespace's
+// UAVEnrollments, started by the first call and running for the lifetime
+// of the client; until it has synced they're listed from the API server.
+func (c *Client) EnrolledNodes(ctx context.Context) (map[string]bool, error) {
+	var enrollments []*models.UAVEnrollment
+	if informer := c.startEnrollmentCache(); informer.HasSynced() {
+		for _, obj := range informer.GetStore().List() {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			if e, err := unstructuredToEnrollment(u); err == nil {
+				enrollments = append(enrollments, e)
+			}
+		}
+	} else {
+		var err error
+		if enrollments, err = c.ListEnrollments(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	nodes := make(map[string]bool, len(enrollments))
+	for _, e := range enrollments {
+		if e.Enrolled(now) {
+			nodes[e.Spec.NodeName] = true
+		}
+	}
+	return nodes, nil
+}
+
+// startEnrollmentCache returns the UAVEnrollment informer, starting it on
+// the first call
+func (c *Client) startEnrollmentCache() cache.SharedIndexInformer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.enrollmentCache == nil {
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.dynamicClient,
+			c.config.Kubernetes.InformerResync, c.config.Kubernetes.Namespace, nil)
+		c.enrollmentCache = factory.ForResource(c.enrollmentGVR()).Informer()
+		factory.Start(make(chan struct{}))
+	}
+	return c.enrollmentCache
+}
+
+func unstructuredToEnrollment(obj *unstructured.Unstructured) (*models.UAVEnrollment, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	var enrollment models.UAVEnrollment
+	if err := json.Unmarshal(data, &enrollment); err != nil {
+		return nil, err
+	}
+	if enrollment.Spec.NodeName == "" {
+		return nil, fmt.Errorf("spec.nodeName not found in unstructured object")
+	}
+	enrollment.Name = obj.GetName()
+	enrollment.Approval = obj.GetAnnotations()[ApprovalAnnotation]
+	return &enrollment, nil
+}
+
+// toMap converts v to the map form of unstructured objects
+func toMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
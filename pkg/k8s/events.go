@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Event reasons for health transitions
+const (
+	ReasonHealthRecovered = "HealthRecovered"
+	ReasonHealthWarning   = "HealthWarning"
+	ReasonHealthCritical  = "HealthCritical"
+	ReasonHealthUnknown   = "HealthUnknown"
+)
+
+// maxEventMessage keeps event messages within the API server's limit
+const maxEventMessage = 1024
+
+// RecordHealthTransition records an Event on the UAVMetrics object describing
+// a health change from previous to the current status, with the errors and
+// warnings that triggered it. When kubernetes.healthNodeEvents is set the
+// event is also recorded on the Node, so it shows in kubectl describe node.
+// Events are written asynchronously, see EventRecorder.
+func (c *Client) RecordHealthTransition(ctx context.Context, metrics *models.UAVMetrics, previous string) error {
+	current := models.HealthStatusUnknown
+	if metrics.Health != nil {
+		current = metrics.Health.Status
+	}
+	reason, eventType := healthEventReason(current)
+	message := healthEventMessage(metrics.Health, previous, current)
+
+	recorder := c.EventRecorder("uav-agent", metrics.NodeName)
+	if err := recorder.UAVMetricsEvent(ctx, metrics.NodeName, eventType, reason, message); err != nil {
+		return err
+	}
+	if c.config.Kubernetes.HealthNodeEvents {
+		recorder.NodeEvent(metrics.NodeName, eventType, reason, message)
+	}
+	return nil
+}
+
+// healthEventReason returns the event reason and type for a health status
+func healthEventReason(status string) (string, string) {
+	switch status {
+	case models.HealthStatusHealthy:
+		return ReasonHealthRecovered, v1.EventTypeNormal
+	case models.HealthStatusWarning:
+		return ReasonHealthWarning, v1.EventTypeWarning
+	case models.HealthStatusCritical:
+		return ReasonHealthCritical, v1.EventTypeWarning
+	default:
+		return ReasonHealthUnknown, v1.EventTypeWarning
+	}
+}
+
+// healthEventMessage describes the transition and its triggering conditions
+func healthEventMessage(health *models.HealthData, previous, current string) string {
+	message := fmt.Sprintf("Health changed from %s to %s", previous, current)
+	if health != nil {
+		conditions := append(append([]string{}, health.Errors...), health.Warnings...)
+		if len(conditions) > 0 {
+			message += ": " + strings.Join(conditions, "; ")
+		}
+	}
+	if len(message) > maxEventMessage {
+		message = message[:maxEventMessage-3] + "..."
+	}
+	return message
+}
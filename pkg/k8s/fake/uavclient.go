@@ -0,0 +1,309 @@
+// Package fake provides an in-memory stand-in for the UAV resources of
+// k8s.Client, to run components such as the router without a cluster.
+package fake
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	uavMetricsResource    = schema.GroupResource{Group: "uav.k3s.io", Resource: "uavmetrics"}
+	routeOverrideResource = schema.GroupResource{Group: "uav.k3s.io", Resource: "routeoverrides"}
+)
+
+// UAVClient keeps UAVMetrics and RouteOverrides in memory. Errors match
+// those of the API server (NotFound, AlreadyExists) and
+// models.ErrStaleUpdate, like k8s.Client. It is safe for concurrent use.
+type UAVClient struct {
+	mu        sync.Mutex
+	metrics   map[string]*models.UAVMetrics // key: node name
+	lost      map[string]*models.LostBeacon
+	routing   map[string]*models.RoutingStats
+	overrides map[string]*models.RouteOverride
+	alive     map[string]bool // agent heartbeats, see SetAlive
+
+	handlers    map[int]k8s.UAVMetricsHandler // WatchUAVMetrics handlers
+	nextHandler int
+
+	overrideHandlers map[int]func([]*models.RouteOverride) // WatchRouteOverrides handlers
+}
+
+// NewUAVClient creates a client holding metrics
+func NewUAVClient(metrics ...*models.UAVMetrics) *UAVClient {
+	c := &UAVClient{
+		metrics:   make(map[string]*models.UAVMetrics),
+		lost:      make(map[string]*models.LostBeacon),
+		routing:   make(map[string]*models.RoutingStats),
+		overrides: make(map[string]*models.RouteOverride),
+		alive:     make(map[string]bool),
+		handlers:  make(map[int]k8s.UAVMetricsHandler),
+
+		overrideHandlers: make(map[int]func([]*models.RouteOverride)),
+	}
+	for _, m := range metrics {
+		c.SetUAVMetrics(m)
+	}
+	return c
+}
+
+// SetUAVMetrics creates or replaces the UAVMetrics of metrics.NodeName, as
+// an agent publishing a sample would. A vehicle reporting again is no
+// longer lost.
+func (c *UAVClient) SetUAVMetrics(metrics *models.UAVMetrics) {
+	c.mu.Lock()
+	copied := *metrics
+	old := c.metrics[metrics.NodeName]
+	c.metrics[metrics.NodeName] = &copied
+	delete(c.lost, metrics.NodeName)
+	handlers := c.watchers()
+	c.mu.Unlock()
+
+	for _, h := range handlers {
+		if old == nil && h.AddFunc != nil {
+			h.AddFunc(&copied)
+		} else if old != nil && h.UpdateFunc != nil {
+			h.UpdateFunc(old, &copied)
+		}
+	}
+}
+
+// WatchUAVMetrics calls handler on every change made through SetUAVMetrics
+// and DeleteUAVMetrics, starting with an add for each existing UAVMetrics,
+// until ctx is done
+func (c *UAVClient) WatchUAVMetrics(ctx context.Context, handler k8s.UAVMetricsHandler) error {
+	c.mu.Lock()
+	id := c.nextHandler
+	c.nextHandler++
+	c.handlers[id] = handler
+	existing := make([]*models.UAVMetrics, 0, len(c.metrics))
+	for _, m := range c.metrics {
+		existing = append(existing, m)
+	}
+	c.mu.Unlock()
+
+	if handler.AddFunc != nil {
+		for _, m := range existing {
+			handler.AddFunc(m)
+		}
+	}
+	go func() {
+		<-ctx.Done()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.handlers, id)
+	}()
+	return nil
+}
+
+// watchers returns the WatchUAVMetrics handlers, called with c.mu held
+func (c *UAVClient) watchers() []k8s.UAVMetricsHandler {
+	handlers := make([]k8s.UAVMetricsHandler, 0, len(c.handlers))
+	for _, h := range c.handlers {
+		handlers = append(handlers, h)
+	}
+	return handlers
+}
+
+// DeleteUAVMetrics deletes the UAVMetrics of nodeName
+func (c *UAVClient) DeleteUAVMetrics(ctx context.Context, nodeName string) error {
+	c.mu.Lock()
+	m, ok := c.metrics[nodeName]
+	if !ok {
+		c.mu.Unlock()
+		return apierrors.NewNotFound(uavMetricsResource, nodeName)
+	}
+	delete(c.metrics, nodeName)
+	delete(c.lost, nodeName)
+	delete(c.routing, nodeName)
+	handlers := c.watchers()
+	c.mu.Unlock()
+
+	for _, h := range handlers {
+		if h.DeleteFunc != nil {
+			h.DeleteFunc(m)
+		}
+	}
+	return nil
+}
+
+// GetUAVMetrics returns a copy of the UAVMetrics of nodeName
+func (c *UAVClient) GetUAVMetrics(ctx context.Context, nodeName string) (*models.UAVMetrics, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.metrics[nodeName]
+	if !ok {
+		return nil, apierrors.NewNotFound(uavMetricsResource, nodeName)
+	}
+	copied := *m
+	return &copied, nil
+}
+
+// ListUAVMetrics returns copies of all UAVMetrics, sorted by node name
+func (c *UAVClient) ListUAVMetrics(ctx context.Context) ([]*models.UAVMetrics, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	metrics := make([]*models.UAVMetrics, 0, len(c.metrics))
+	for _, m := range c.metrics {
+		copied := *m
+		metrics = append(metrics, &copied)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].NodeName < metrics[j].NodeName
+	})
+	return metrics, nil
+}
+
+// MarkLost records beacon as the vehicle's last known position. If the
+// vehicle has reported telemetry newer than the beacon, nothing is recorded
+// and models.ErrStaleUpdate is returned.
+func (c *UAVClient) MarkLost(ctx context.Context, beacon *models.LostBeacon) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.metrics[beacon.NodeName]
+	if !ok {
+		return apierrors.NewNotFound(uavMetricsResource, beacon.NodeName)
+	}
+	if m.LastSeen().After(beacon.LastSeen) {
+		return models.ErrStaleUpdate
+	}
+	copied := *beacon
+	c.lost[beacon.NodeName] = &copied
+	return nil
+}
+
+// Lost returns the beacon recorded by MarkLost for nodeName, nil if the
+// vehicle isn't lost
+func (c *UAVClient) Lost(nodeName string) *models.LostBeacon {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lost[nodeName]
+}
+
+// UpdateRoutingStats records a router's decision statistics for nodeName
+func (c *UAVClient) UpdateRoutingStats(ctx context.Context, nodeName string, stats *models.RoutingStats) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.metrics[nodeName]; !ok {
+		return apierrors.NewNotFound(uavMetricsResource, nodeName)
+	}
+	c.routing[nodeName] = stats
+	return nil
+}
+
+// RoutingStats returns the statistics last recorded for nodeName
+func (c *UAVClient) RoutingStats(nodeName string) *models.RoutingStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.routing[nodeName]
+}
+
+// SetAlive sets whether the agent of nodeName renews its heartbeat
+func (c *UAVClient) SetAlive(nodeName string, alive bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.alive[nodeName] = alive
+}
+
+// IsAlive reports whether the agent of nodeName renews its heartbeat, false
+// unless set by SetAlive
+func (c *UAVClient) IsAlive(ctx context.Context, nodeName string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.alive[nodeName], nil
+}
+
+// ListRouteOverrides returns all RouteOverrides, including expired ones,
+// sorted by name
+func (c *UAVClient) ListRouteOverrides(ctx context.Context) ([]*models.RouteOverride, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.routeOverrides(), nil
+}
+
+// routeOverrides copies the RouteOverrides sorted by name, called with c.mu
+// held
+func (c *UAVClient) routeOverrides() []*models.RouteOverride {
+	overrides := make([]*models.RouteOverride, 0, len(c.overrides))
+	for _, o := range c.overrides {
+		copied := *o
+		overrides = append(overrides, &copied)
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].Name < overrides[j].Name
+	})
+	return overrides
+}
+
+// WatchRouteOverrides calls handler with all RouteOverrides, starting with
+// the existing ones, after every CreateRouteOverride and
+// DeleteRouteOverride until ctx is done
+func (c *UAVClient) WatchRouteOverrides(ctx context.Context, handler func([]*models.RouteOverride)) error {
+	c.mu.Lock()
+	id := c.nextHandler
+	c.nextHandler++
+	c.overrideHandlers[id] = handler
+	existing := c.routeOverrides()
+	c.mu.Unlock()
+
+	handler(existing)
+	go func() {
+		<-ctx.Done()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.overrideHandlers, id)
+	}()
+	return nil
+}
+
+// notifyOverrides calls the WatchRouteOverrides handlers, called without
+// c.mu held
+func (c *UAVClient) notifyOverrides() {
+	c.mu.Lock()
+	overrides := c.routeOverrides()
+	handlers := make([]func([]*models.RouteOverride), 0, len(c.overrideHandlers))
+	for _, h := range c.overrideHandlers {
+		handlers = append(handlers, h)
+	}
+	c.mu.Unlock()
+
+	for _, h := range handlers {
+		h(overrides)
+	}
+}
+
+// CreateRouteOverride creates a RouteOverride named after the override
+func (c *UAVClient) CreateRouteOverride(ctx context.Context, override *models.RouteOverride) error {
+	c.mu.Lock()
+	if _, ok := c.overrides[override.Name]; ok {
+		c.mu.Unlock()
+		return apierrors.NewAlreadyExists(routeOverrideResource, override.Name)
+	}
+	copied := *override
+	c.overrides[override.Name] = &copied
+	c.mu.Unlock()
+
+	c.notifyOverrides()
+	return nil
+}
+
+// DeleteRouteOverride deletes a RouteOverride
+func (c *UAVClient) DeleteRouteOverride(ctx context.Context, name string) error {
+	c.mu.Lock()
+	if _, ok := c.overrides[name]; !ok {
+		c.mu.Unlock()
+		return apierrors.NewNotFound(routeOverrideResource, name)
+	}
+	delete(c.overrides, name)
+	c.mu.Unlock()
+
+	c.notifyOverrides()
+	return nil
+}
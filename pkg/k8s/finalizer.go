@@ -0,0 +1,76 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// CleanupFinalizer keeps a deleted UAVMetrics until the Node state the agent
+// set for the vehicle was removed (see kubernetes.cleanupFinalizer)
+const CleanupFinalizer = "uav.k3s.io/cleanup"
+
+// ReasonDeregistered is the reason of the Event recorded once a deleted
+// UAVMetrics was cleaned up
+const ReasonDeregistered = "Deregistered"
+
+// ListFinalizingUAVMetrics lists the UAVMetrics of the configured namespace
+// being deleted and still holding CleanupFinalizer. It reads the API server,
+// not the informer cache.
+func (c *Client) ListFinalizingUAVMetrics(ctx context.Context) ([]*models.UAVMetrics, error) {
+	list, err := c.uavMetrics().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list UAVMetrics: %w", err)
+	}
+
+	var metrics []*models.UAVMetrics
+	for i := range list.Items {
+		obj := &list.Items[i]
+		if obj.DeletionTimestamp != nil && hasFinalizer(obj, CleanupFinalizer) {
+			metrics = append(metrics, obj.Spec.DeepCopy())
+		}
+	}
+	return metrics, nil
+}
+
+// RemoveCleanupFinalizer removes CleanupFinalizer from a node's UAVMetrics,
+// letting a pending deletion complete. A UAVMetrics already gone is not an
+// error.
+func (c *Client) RemoveCleanupFinalizer(ctx context.Context, nodeName string) error {
+	err := retryOnConflict(retry.DefaultRetry, retryFinalizer, func() error {
+		obj, err := c.uavMetrics().Get(ctx, c.ResourceName(nodeName), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		finalizers := obj.Finalizers[:0]
+		for _, f := range obj.Finalizers {
+			if f != CleanupFinalizer {
+				finalizers = append(finalizers, f)
+			}
+		}
+		if len(finalizers) == len(obj.Finalizers) {
+			return nil
+		}
+		obj.Finalizers = finalizers
+		_, err = c.uavMetrics().Update(ctx, obj, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to remove finalizer of UAVMetrics: %w", err)
+	}
+	return nil
+}
+
+func hasFinalizer(obj metav1.Object, finalizer string) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HeartbeatLeaseName returns the name of the Lease the agent of a node
+// renews as its heartbeat
+func (c *Client) HeartbeatLeaseName(nodeName string) string {
+	return c.ResourceName(nodeName + "-heartbeat")
+}
+
+// RenewHeartbeat renews the heartbeat Lease of a node as holder, creating it
+// on the first call. The renewal keeps the agent alive for
+// kubernetes.heartbeatTimeout, which is stored in the Lease so consumers
+// don't need the agent's configuration.
+func (c *Client) RenewHeartbeat(ctx context.Context, nodeName, holder string) error {
+	leases := c.clientset.CoordinationV1().Leases(c.config.Kubernetes.Namespace)
+	now := metav1.NewMicroTime(time.Now())
+	duration := int32(c.config.Kubernetes.HeartbeatTimeout / time.Second)
+
+	lease, err := leases.Get(ctx, c.HeartbeatLeaseName(nodeName), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      c.HeartbeatLeaseName(nodeName),
+				Namespace: c.config.Kubernetes.Namespace,
+				Labels:    c.metricsLabels(nodeName),
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &duration,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		if _, err := leases.Create(ctx, lease, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create heartbeat lease: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get heartbeat lease: %w", err)
+	}
+
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holder {
+		lease.Spec.HolderIdentity = &holder
+		lease.Spec.AcquireTime = &now
+	}
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.RenewTime = &now
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to renew heartbeat lease: %w", err)
+	}
+	return nil
+}
+
+// IsAlive reports whether the agent of a node renewed its heartbeat Lease
+// within the Lease's duration. A vehicle whose telemetry is stale while its
+// agent is alive is out of link or has a stuck data source; one without a
+// heartbeat has lost its agent. A missing Lease, for an agent that never
+// ran or runs without heartbeats, reports false.
+func (c *Client) IsAlive(ctx context.Context, nodeName string) (bool, error) {
+	lease, err := c.clientset.CoordinationV1().Leases(c.config.Kubernetes.Namespace).
+		Get(ctx, c.HeartbeatLeaseName(nodeName), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get heartbeat lease: %w", err)
+	}
+	return heartbeatAlive(lease, time.Now()), nil
+}
+
+// heartbeatAlive reports whether lease was renewed within its duration at now
+func heartbeatAlive(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return false
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return now.Before(expiry)
+}
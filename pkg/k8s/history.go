@@ -0,0 +1,191 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// SnapshotTimeLabel is set on each UAVMetricsHistory snapshot to the time
+// it was taken, in Unix seconds, so retention can age snapshots from their
+// metadata alone
+const SnapshotTimeLabel = "snapshot-time"
+
+// snapshotPageSize bounds the snapshots returned per list request
+const snapshotPageSize = 500
+
+// historyGVR returns the resource of UAVMetricsHistory objects, in the same
+// group and version as UAVMetrics
+func (c *Client) historyGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    c.gvr.Group,
+		Version:  c.gvr.Version,
+		Resource: "uavmetricshistories",
+	}
+}
+
+// SnapshotName returns the name of the snapshot of a node taken at t: the
+// node's resource name followed by t in Unix seconds
+func (c *Client) SnapshotName(nodeName string, t time.Time) string {
+	return ResourceName(c.config.Kubernetes.NameTemplate+"-"+strconv.FormatInt(t.Unix(), 10), nodeName)
+}
+
+// CreateSnapshot writes metrics as an immutable UAVMetricsHistory snapshot
+// taken at t, labelled like the UAVMetrics (app, node-name and
+// kubernetes.labels) so snapshots can be selected the same way, and with
+// SnapshotTimeLabel
+func (c *Client) CreateSnapshot(ctx context.Context, metrics *models.UAVMetrics, t time.Time) error {
+	spec, err := toMap(models.UAVMetricsSnapshot{
+		NodeName:  metrics.NodeName,
+		Timestamp: t.UTC().Truncate(time.Second),
+		Metrics:   *metrics,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to convert snapshot to unstructured: %w", err)
+	}
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": fmt.Sprintf("%s/%s", c.gvr.Group, c.gvr.Version),
+			"kind":       "UAVMetricsHistory",
+			"spec":       spec,
+		},
+	}
+	obj.SetName(c.SnapshotName(metrics.NodeName, t))
+	obj.SetNamespace(c.config.Kubernetes.Namespace)
+	labels := c.metricsLabels(metrics.NodeName)
+	labels[SnapshotTimeLabel] = strconv.FormatInt(t.Unix(), 10)
+	obj.SetLabels(labels)
+
+	_, err = c.dynamicClient.Resource(c.historyGVR()).
+		Namespace(c.config.Kubernetes.Namespace).
+		Create(ctx, obj, metav1.CreateOptions{FieldManager: c.config.Kubernetes.FieldManager})
+	if err != nil {
+		return fmt.Errorf("failed to create UAVMetricsHistory: %w", err)
+	}
+	return nil
+}
+
+// ListSnapshots lists the snapshots of a fleet (namespace), oldest first.
+// A non-empty nodeName restricts them to that node's. The snapshots are
+// listed in pages of snapshotPageSize.
+func (c *Client) ListSnapshots(ctx context.Context, namespace, nodeName string) ([]*models.UAVMetricsSnapshot, error) {
+	options := metav1.ListOptions{Limit: snapshotPageSize}
+	if nodeName != "" {
+		options.LabelSelector = labels.Set{"node-name": nodeName}.String()
+	}
+
+	var snapshots []*models.UAVMetricsSnapshot
+	for {
+		unstructuredList, err := c.dynamicClient.Resource(c.historyGVR()).
+			Namespace(namespace).
+			List(ctx, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list UAVMetricsHistory: %w", err)
+		}
+		for _, item := range unstructuredList.Items {
+			s, err := unstructuredToSnapshot(&item)
+			if err != nil {
+				continue
+			}
+			snapshots = append(snapshots, s)
+		}
+		if options.Continue = unstructuredList.GetContinue(); options.Continue == "" {
+			break
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
+	})
+	return snapshots, nil
+}
+
+// WalkSnapshotMetadata lists the snapshots of a fleet (namespace) without
+// their specs, in pages of snapshotPageSize, and calls fn with each page.
+// The snapshots carry only their name, UID, resource version and the time
+// they were taken: SnapshotTimeLabel, or the creation time of snapshots
+// written before the label was set. Listing stops at the first error fn
+// returns.
+func (c *Client) WalkSnapshotMetadata(ctx context.Context, namespace string, fn func([]*models.UAVMetricsSnapshot) error) error {
+	options := metav1.ListOptions{Limit: snapshotPageSize}
+	for {
+		list, err := c.metaClient.Resource(c.historyGVR()).
+			Namespace(namespace).
+			List(ctx, options)
+		if err != nil {
+			return fmt.Errorf("failed to list UAVMetricsHistory metadata: %w", err)
+		}
+
+		page := make([]*models.UAVMetricsSnapshot, 0, len(list.Items))
+		for i := range list.Items {
+			page = append(page, metadataToSnapshot(&list.Items[i]))
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if options.Continue = list.Continue; options.Continue == "" {
+			return nil
+		}
+	}
+}
+
+// DeleteSnapshotIn deletes a snapshot of the given fleet (namespace) by name,
+// only if it is still the listed object (UID and resource version)
+func (c *Client) DeleteSnapshotIn(ctx context.Context, namespace string, snapshot *models.UAVMetricsSnapshot) error {
+	uid := types.UID(snapshot.UID)
+	preconditions := &metav1.Preconditions{UID: &uid, ResourceVersion: &snapshot.ResourceVersion}
+	err := c.dynamicClient.Resource(c.historyGVR()).
+		Namespace(namespace).
+		Delete(ctx, snapshot.Name, metav1.DeleteOptions{Preconditions: preconditions})
+	if err != nil {
+		return fmt.Errorf("failed to delete UAVMetricsHistory: %w", err)
+	}
+	return nil
+}
+
+func unstructuredToSnapshot(obj *unstructured.Unstructured) (*models.UAVMetricsSnapshot, error) {
+	spec, found, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil || !found {
+		return nil, fmt.Errorf("spec not found in unstructured object")
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot models.UAVMetricsSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	snapshot.Name = obj.GetName()
+	snapshot.UID = string(obj.GetUID())
+	snapshot.ResourceVersion = obj.GetResourceVersion()
+
+	return &snapshot, nil
+}
+
+func metadataToSnapshot(obj *metav1.PartialObjectMetadata) *models.UAVMetricsSnapshot {
+	snapshot := &models.UAVMetricsSnapshot{
+		Name:            obj.Name,
+		NodeName:        obj.Labels["node-name"],
+		Timestamp:       obj.CreationTimestamp.Time,
+		UID:             string(obj.UID),
+		ResourceVersion: obj.ResourceVersion,
+	}
+	if seconds, err := strconv.ParseInt(obj.Labels[SnapshotTimeLabel], 10, 64); err == nil {
+		snapshot.Timestamp = time.Unix(seconds, 0).UTC()
+	}
+	return snapshot
+}
@@ -0,0 +1,225 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+// Operations whose retries are counted in uav_k8s_client_retries_total
+const (
+	retryApply     = "apply"
+	retryStatus    = "status"
+	retryNode      = "node"
+	retryFinalizer = "finalizer"
+)
+
+// requestBuckets are the upper bounds, in seconds, of the request latency
+// histogram
+var requestBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// clientMetrics counts the API calls of every client of the process, so
+// the metrics endpoint of a component covers its typed, dynamic and
+// generated clientsets alike
+var clientMetrics = newAPIMetrics()
+
+type latencyHistogram struct {
+	counts []uint64
+	sum    float64
+	total  uint64
+}
+
+type apiMetrics struct {
+	mu        sync.Mutex
+	requests  map[[2]string]uint64 // verb, code
+	errors    map[string]uint64    // verb
+	latencies map[string]*latencyHistogram
+	retries   map[string]uint64 // operation
+}
+
+func newAPIMetrics() *apiMetrics {
+	return &apiMetrics{
+		requests:  make(map[[2]string]uint64),
+		errors:    make(map[string]uint64),
+		latencies: make(map[string]*latencyHistogram),
+		retries:   make(map[string]uint64),
+	}
+}
+
+// observe records one API call. code is "<error>" when no response was
+// received; those and 429/5xx responses count as errors.
+func (m *apiMetrics) observe(verb, code string, failed bool, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[[2]string{verb, code}]++
+	if failed {
+		m.errors[verb]++
+	}
+
+	h := m.latencies[verb]
+	if h == nil {
+		h = &latencyHistogram{counts: make([]uint64, len(requestBuckets))}
+		m.latencies[verb] = h
+	}
+	seconds := latency.Seconds()
+	for i, bound := range requestBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.total++
+}
+
+func (m *apiMetrics) retry(operation string) {
+	m.mu.Lock()
+	m.retries[operation]++
+	m.mu.Unlock()
+}
+
+// instrumentTransport wraps the client transport to record the verb, result
+// and latency of every request. It is installed outside the resilience
+// layer, so a hedged request counts once. The latency of a watch is the
+// time to its response headers.
+func instrumentTransport(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := rt.RoundTrip(req)
+		verb := requestVerb(req)
+		if err != nil {
+			clientMetrics.observe(verb, "<error>", true, time.Since(start))
+			return resp, err
+		}
+		failed := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		clientMetrics.observe(verb, strconv.Itoa(resp.StatusCode), failed, time.Since(start))
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// requestVerb returns the HTTP method of req, or WATCH for a watch
+func requestVerb(req *http.Request) string {
+	if req.Method == http.MethodGet && req.URL.Query().Get("watch") == "true" {
+		return "WATCH"
+	}
+	return req.Method
+}
+
+// retryOnConflict is retry.RetryOnConflict counting the retries of
+// operation
+func retryOnConflict(backoff wait.Backoff, operation string, fn func() error) error {
+	attempt := 0
+	return retry.RetryOnConflict(backoff, func() error {
+		if attempt > 0 {
+			clientMetrics.retry(operation)
+		}
+		attempt++
+		return fn()
+	})
+}
+
+// WriteClientMetrics writes the API call metrics of the process in the
+// Prometheus text format:
+//
+//	uav_k8s_client_requests_total{verb,code}
+//	uav_k8s_client_request_errors_total{verb}
+//	uav_k8s_client_request_duration_seconds{verb} (histogram)
+//	uav_k8s_client_retries_total{operation}
+func WriteClientMetrics(w io.Writer) {
+	m := clientMetrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprint(w, "# HELP uav_k8s_client_requests_total API server requests by verb and status code.\n# TYPE uav_k8s_client_requests_total counter\n")
+	requests := make([][2]string, 0, len(m.requests))
+	for key := range m.requests {
+		requests = append(requests, key)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i][0] != requests[j][0] {
+			return requests[i][0] < requests[j][0]
+		}
+		return requests[i][1] < requests[j][1]
+	})
+	for _, key := range requests {
+		fmt.Fprintf(w, "uav_k8s_client_requests_total{verb=%q,code=%q} %d\n", key[0], key[1], m.requests[key])
+	}
+
+	fmt.Fprint(w, "# HELP uav_k8s_client_request_errors_total API server requests failed without response or with a 429 or 5xx status.\n# TYPE uav_k8s_client_request_errors_total counter\n")
+	for _, verb := range sortedKeys(m.errors) {
+		fmt.Fprintf(w, "uav_k8s_client_request_errors_total{verb=%q} %d\n", verb, m.errors[verb])
+	}
+
+	fmt.Fprint(w, "# HELP uav_k8s_client_request_duration_seconds API server request latency by verb.\n# TYPE uav_k8s_client_request_duration_seconds histogram\n")
+	for _, verb := range sortedKeys(m.latencies) {
+		h := m.latencies[verb]
+		for i, bound := range requestBuckets {
+			fmt.Fprintf(w, "uav_k8s_client_request_duration_seconds_bucket{verb=%q,le=%q} %d\n", verb, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(w, "uav_k8s_client_request_duration_seconds_bucket{verb=%q,le=\"+Inf\"} %d\n", verb, h.total)
+		fmt.Fprintf(w, "uav_k8s_client_request_duration_seconds_sum{verb=%q} %s\n", verb, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "uav_k8s_client_request_duration_seconds_count{verb=%q} %d\n", verb, h.total)
+	}
+
+	fmt.Fprint(w, "# HELP uav_k8s_client_retries_total Retries of API server operations (apply, status, node, finalizer).\n# TYPE uav_k8s_client_retries_total counter\n")
+	for _, operation := range sortedKeys(m.retries) {
+		fmt.Fprintf(w, "uav_k8s_client_retries_total{operation=%q} %d\n", operation, m.retries[operation])
+	}
+}
+
+// ClientMetricsHandler serves WriteClientMetrics, for components without
+// another metrics endpoint
+func ClientMetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteClientMetrics(w)
+	})
+}
+
+// ServeClientMetrics serves ClientMetricsHandler on /metrics at addr until
+// ctx is cancelled, independently of the component's optional endpoints
+func ServeClientMetrics(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", ClientMetricsHandler())
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,120 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Leader elects, through a Lease per node, the agent instance allowed to
+// write a node's resources. During a DaemonSet rollout the old and the new
+// agent pod briefly run on the same node; only the one holding the Lease
+// publishes, so they don't overwrite each other's updates.
+type Leader struct {
+	client   *Client
+	lock     *resourcelock.LeaseLock
+	log      *logrus.Logger
+	leading  atomic.Bool
+	released chan struct{}
+}
+
+// LeaseName returns the name of the Lease electing the agent of a node
+func (c *Client) LeaseName(nodeName string) string {
+	return c.ResourceName(nodeName + "-agent")
+}
+
+// NewLeader creates a candidate for the Lease of nodeName. identity tells
+// the instances apart; the hostname (the pod name) is used when it's empty.
+func (c *Client) NewLeader(nodeName, identity string, log *logrus.Logger) (*Leader, error) {
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get leader election identity: %w", err)
+		}
+		identity = hostname
+	}
+
+	return &Leader{
+		client: c,
+		lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      c.LeaseName(nodeName),
+				Namespace: c.config.Kubernetes.Namespace,
+			},
+			Client:     c.clientset.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+		},
+		log:      log,
+		released: make(chan struct{}),
+	}, nil
+}
+
+// Identity returns the identity this instance holds the Lease under
+func (l *Leader) Identity() string {
+	return l.lock.Identity()
+}
+
+// IsLeader reports whether this instance currently holds the Lease
+func (l *Leader) IsLeader() bool {
+	return l.leading.Load()
+}
+
+// Run campaigns for the Lease until ctx is done, standing by while another
+// instance holds it and campaigning again when leadership is lost. The
+// Lease is released when ctx is done, so a successor takes over without
+// waiting for it to expire; cancel ctx only once the last write is done.
+func (l *Leader) Run(ctx context.Context) {
+	defer close(l.released)
+
+	cfg := l.client.config.Kubernetes
+	fields := logrus.Fields{"lease": l.lock.LeaseMeta.Name, "identity": l.Identity()}
+	for ctx.Err() == nil {
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            l.lock,
+			LeaseDuration:   cfg.LeaseDuration,
+			RenewDeadline:   cfg.LeaseRenewDeadline,
+			RetryPeriod:     cfg.LeaseRetryPeriod,
+			ReleaseOnCancel: true,
+			Name:            l.lock.LeaseMeta.Name,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(context.Context) {
+					l.leading.Store(true)
+					l.log.WithFields(fields).Info("Acquired agent lease, publishing")
+				},
+				OnStoppedLeading: func() {
+					if !l.leading.Swap(false) {
+						return
+					}
+					if ctx.Err() != nil {
+						l.log.WithFields(fields).Info("Released agent lease")
+					} else {
+						l.log.WithFields(fields).Warn("Lost agent lease, standing by")
+					}
+				},
+				OnNewLeader: func(holder string) {
+					if holder != l.Identity() {
+						l.log.WithFields(fields).WithField("holder", holder).Info("Another agent instance holds the lease, standing by")
+					}
+				},
+			},
+		})
+		if err != nil {
+			// Durations are validated with the configuration
+			l.log.WithError(err).Error("Invalid leader election configuration")
+			return
+		}
+		elector.Run(ctx)
+	}
+}
+
+// Released is closed once Run has returned and the Lease is released
+func (l *Leader) Released() <-chan struct{} {
+	return l.released
+}
@@ -0,0 +1,58 @@
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NodePlaceholder is replaced with the node name in the resource name template
+const NodePlaceholder = "{node}"
+
+// nameHashLength is the number of hex digits of the node name hash appended
+// to names that had to be sanitized or shortened
+const nameHashLength = 8
+
+// ResourceName returns the name of a node's UAV resources (UAVMetrics,
+// UAVAgentConfig, UAVEnrollment) from the configured name template
+func (c *Client) ResourceName(nodeName string) string {
+	return ResourceName(c.config.Kubernetes.NameTemplate, nodeName)
+}
+
+// ResourceName expands template for nodeName into a valid DNS-1123
+// subdomain. A valid expansion is used as is, so existing names don't
+// change. Otherwise it is lowercased, characters other than letters and
+// digits are replaced with '-', it is shortened to fit the length limit
+// and a hash of the node name is appended, so node names sanitized to the
+// same string don't collide.
+func ResourceName(template, nodeName string) string {
+	if template == "" {
+		template = "uav-" + NodePlaceholder
+	}
+	name := strings.ReplaceAll(template, NodePlaceholder, nodeName)
+	if len(validation.IsDNS1123Subdomain(name)) == 0 {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(nodeName))
+	suffix := "-" + hex.EncodeToString(sum[:])[:nameHashLength]
+
+	sanitized := []byte(strings.ToLower(name))
+	for i, ch := range sanitized {
+		if (ch < 'a' || ch > 'z') && (ch < '0' || ch > '9') {
+			sanitized[i] = '-'
+		}
+	}
+	base := string(sanitized)
+	if max := validation.DNS1123SubdomainMaxLength - len(suffix); len(base) > max {
+		base = base[:max]
+	}
+	// The name must start with an alphanumeric character
+	base = strings.Trim(base, "-")
+	if base == "" {
+		return "uav" + suffix
+	}
+	return base + suffix
+}
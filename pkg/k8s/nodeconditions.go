@@ -0,0 +1,139 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// Node conditions set from UAVMetrics
+const (
+	NodeConditionBatteryLow v1.NodeConditionType = "UAVBatteryLow"
+	NodeConditionCritical   v1.NodeConditionType = "UAVCritical"
+)
+
+// TaintBatteryLow keeps new pods off a Node whose battery is below
+// kubernetes.nodeTaintBatteryThreshold. Running pods are not evicted.
+const TaintBatteryLow = "uav.k3s.io/battery-low"
+
+// SyncNodeConditions sets the UAVBatteryLow and UAVCritical conditions on
+// the Node (the ground node of a proxied vehicle) and adds or removes the battery-low NoSchedule taint. The taint is
+// added below the threshold and removed once battery rises above the threshold
+// plus the hysteresis. When battery failed to collect this cycle, the battery
+// condition and taint are left as they are.
+func (c *Client) SyncNodeConditions(ctx context.Context, metrics *models.UAVMetrics) error {
+	cfg := c.config.Kubernetes
+	batteryKnown := c.config.Collection.EnableBattery && !metrics.CollectionFailed(models.SectionBattery)
+	battery := metrics.Battery.RemainingPercent
+
+	return retryOnConflict(retry.DefaultRetry, retryNode, func() error {
+		node, err := c.clientset.CoreV1().Nodes().Get(ctx, metrics.KubeNode(), metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get node %s: %w", metrics.KubeNode(), err)
+		}
+		now := metav1.NewTime(time.Now())
+
+		// Messages carry no live values, so the status is only written on changes
+		conditionsChanged := false
+		if batteryKnown {
+			low := battery < cfg.NodeBatteryLowThreshold
+			reason, message := "BatterySufficient", fmt.Sprintf("Battery at or above %g%%", cfg.NodeBatteryLowThreshold)
+			if low {
+				reason, message = "BatteryLow", fmt.Sprintf("Battery below %g%%", cfg.NodeBatteryLowThreshold)
+			}
+			conditionsChanged = setNodeCondition(node, NodeConditionBatteryLow, low, reason, message, now)
+		}
+
+		critical := metrics.Health != nil && metrics.Health.Status == models.HealthStatusCritical
+		reason, message := "HealthNotCritical", "UAV health is not critical"
+		if critical {
+			reason, message = "HealthCritical", "UAV health is critical, see the UAVMetrics events for details"
+		}
+		if setNodeCondition(node, NodeConditionCritical, critical, reason, message, now) {
+			conditionsChanged = true
+		}
+
+		if conditionsChanged {
+			node, err = c.clientset.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !batteryKnown || cfg.NodeTaintBatteryThreshold <= 0 {
+			return nil
+		}
+		tainted := hasTaint(node, TaintBatteryLow)
+		switch {
+		case !tainted && battery < cfg.NodeTaintBatteryThreshold:
+			node.Spec.Taints = append(node.Spec.Taints, v1.Taint{
+				Key:       TaintBatteryLow,
+				Value:     "true",
+				Effect:    v1.TaintEffectNoSchedule,
+				TimeAdded: &now,
+			})
+		case tainted && battery >= cfg.NodeTaintBatteryThreshold+cfg.NodeTaintHysteresis:
+			taints := node.Spec.Taints[:0]
+			for _, taint := range node.Spec.Taints {
+				if taint.Key != TaintBatteryLow {
+					taints = append(taints, taint)
+				}
+			}
+			node.Spec.Taints = taints
+		default:
+			return nil
+		}
+		_, err = c.clientset.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// setNodeCondition sets the condition on node, keeping the transition time
+// when the status is unchanged. It reports whether anything changed.
+func setNodeCondition(node *v1.Node, conditionType v1.NodeConditionType, value bool, reason, message string, now metav1.Time) bool {
+	status := v1.ConditionFalse
+	if value {
+		status = v1.ConditionTrue
+	}
+	for i := range node.Status.Conditions {
+		condition := &node.Status.Conditions[i]
+		if condition.Type != conditionType {
+			continue
+		}
+		if condition.Status == status && condition.Reason == reason && condition.Message == message {
+			return false
+		}
+		if condition.Status != status {
+			condition.LastTransitionTime = now
+		}
+		condition.Status = status
+		condition.Reason = reason
+		condition.Message = message
+		condition.LastHeartbeatTime = now
+		return true
+	}
+	node.Status.Conditions = append(node.Status.Conditions, v1.NodeCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastHeartbeatTime:  now,
+		LastTransitionTime: now,
+	})
+	return true
+}
+
+func hasTaint(node *v1.Node, key string) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == key {
+			return true
+		}
+	}
+	return false
+}
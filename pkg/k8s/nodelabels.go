@@ -0,0 +1,143 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Node labels mirrored from UAVMetrics. The battery label holds the remaining
+// charge rounded down to a multiple of 10, so it can be matched with the Gt
+// and Lt operators of nodeAffinity.
+const (
+	LabelBattery        = "uav.k3s.io/battery"
+	LabelHealth         = "uav.k3s.io/health"
+	LabelGeohash        = "uav.k3s.io/geohash"
+	LabelConnectionType = "uav.k3s.io/connection-type"
+)
+
+// nodeLabelKeys lists every mirrored label, so labels whose source section is
+// missing are removed from the Node
+var nodeLabelKeys = []string{LabelBattery, LabelHealth, LabelGeohash, LabelConnectionType}
+
+// NodeLabels returns the labels mirrored onto the Node for metrics. Labels
+// whose value is unknown are omitted.
+func NodeLabels(metrics *models.UAVMetrics, geohashPrecision int) map[string]string {
+	labels := map[string]string{
+		LabelBattery: strconv.Itoa(batteryBucket(metrics.Battery.RemainingPercent)),
+	}
+	if metrics.Health != nil && metrics.Health.Status != "" {
+		labels[LabelHealth] = labelValue(metrics.Health.Status)
+	}
+	if metrics.GPS.Satellites > 0 || metrics.GPS.Latitude != 0 || metrics.GPS.Longitude != 0 {
+		labels[LabelGeohash] = Geohash(metrics.GPS.Latitude, metrics.GPS.Longitude, geohashPrecision)
+	}
+	if metrics.Network != nil && metrics.Network.ConnectionType != "" {
+		labels[LabelConnectionType] = labelValue(metrics.Network.ConnectionType)
+	}
+	for key, value := range labels {
+		if value == "" {
+			delete(labels, key)
+		}
+	}
+	return labels
+}
+
+// SyncNodeLabels merge-patches the mirrored labels onto the Node, removing
+// those not present in labels. Other labels on the Node are left untouched.
+func (c *Client) SyncNodeLabels(ctx context.Context, nodeName string, labels map[string]string) error {
+	patchLabels := make(map[string]interface{}, len(nodeLabelKeys))
+	for _, key := range nodeLabelKeys {
+		if value, ok := labels[key]; ok {
+			patchLabels[key] = value
+		} else {
+			patchLabels[key] = nil
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": patchLabels},
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.clientset.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch labels of node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+func batteryBucket(percent float64) int {
+	bucket := int(math.Floor(percent/10)) * 10
+	if bucket < 0 {
+		return 0
+	}
+	if bucket > 100 {
+		return 100
+	}
+	return bucket
+}
+
+// labelValue reduces s to the characters allowed in a label value
+func labelValue(s string) string {
+	value := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		case r == ' ' || r == '/':
+			return '-'
+		}
+		return -1
+	}, s)
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return strings.Trim(value, "-_.")
+}
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash encodes a position as a geohash of the given length
+func Geohash(lat, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	var hash strings.Builder
+	even := true
+	bit, ch := 0, 0
+	for hash.Len() < precision {
+		if even {
+			mid := (lonRange[0] + lonRange[1]) / 2
+			if lon >= mid {
+				ch |= 1 << (4 - bit)
+				lonRange[0] = mid
+			} else {
+				lonRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if lat >= mid {
+				ch |= 1 << (4 - bit)
+				latRange[0] = mid
+			} else {
+				latRange[1] = mid
+			}
+		}
+		even = !even
+		if bit < 4 {
+			bit++
+		} else {
+			hash.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return hash.String()
+}
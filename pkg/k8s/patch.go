@@ -0,0 +1,146 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// JSONPatchOperation is an RFC 6902 JSON patch operation
+type JSONPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MergePatchUAVMetrics applies an RFC 7386 JSON merge patch to a node's
+// UAVMetrics, e.g. {"spec":{"battery":{"remainingPercent":42}}}. Fields
+// absent from patch are left as stored, and null removes a field. seen is
+// when the patched telemetry was collected: like CreateOrUpdateUAVMetrics,
+// the patch is sent with the resourceVersion of our previous write as a
+// precondition, and models.ErrStaleUpdate is returned instead of
+// overwriting newer telemetry someone else wrote.
+func (c *Client) MergePatchUAVMetrics(ctx context.Context, nodeName string, seen time.Time, patch []byte) error {
+	return c.patch(ctx, nodeName, seen, types.MergePatchType, func(resourceVersion string) ([]byte, error) {
+		decoder := json.NewDecoder(bytes.NewReader(patch))
+		decoder.UseNumber()
+		var obj map[string]interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			return nil, fmt.Errorf("failed to decode merge patch: %w", err)
+		}
+		if obj == nil {
+			return nil, fmt.Errorf("merge patch must be a JSON object")
+		}
+		if resourceVersion != "" {
+			meta, _ := obj["metadata"].(map[string]interface{})
+			if meta == nil {
+				meta = map[string]interface{}{}
+			}
+			meta["resourceVersion"] = resourceVersion
+			obj["metadata"] = meta
+		}
+		return json.Marshal(obj)
+	})
+}
+
+// JSONPatchUAVMetrics applies RFC 6902 JSON patch operations to a node's
+// UAVMetrics. The operations apply atomically: if one fails (e.g. a "test"
+// or a "replace" of a missing path), nothing is written. seen and the
+// stale-update guard are as for MergePatchUAVMetrics.
+func (c *Client) JSONPatchUAVMetrics(ctx context.Context, nodeName string, seen time.Time, operations []JSONPatchOperation) error {
+	return c.patch(ctx, nodeName, seen, types.JSONPatchType, func(resourceVersion string) ([]byte, error) {
+		patch := operations
+		if resourceVersion != "" {
+			// Replacing the resourceVersion makes the API server reject the
+			// patch with a conflict once the object changed
+			precondition := JSONPatchOperation{Op: "replace", Path: "/metadata/resourceVersion", Value: resourceVersion}
+			patch = append([]JSONPatchOperation{precondition}, operations...)
+		}
+		data, err := json.Marshal(patch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode JSON patch: %w", err)
+		}
+		return data, nil
+	})
+}
+
+// PatchSection replaces one section of a node's UAVMetrics spec (see the
+// models.Section constants), collected at seen, leaving the others as stored
+func (c *Client) PatchSection(ctx context.Context, nodeName, section string, seen time.Time, value interface{}) error {
+	// "add" replaces an existing member, and unlike "replace" also sets an
+	// optional section the spec doesn't have yet
+	return c.JSONPatchUAVMetrics(ctx, nodeName, seen, []JSONPatchOperation{
+		{Op: "add", Path: "/spec/" + section, Value: value},
+	})
+}
+
+// PatchBattery replaces the battery section of a node's UAVMetrics
+func (c *Client) PatchBattery(ctx context.Context, nodeName string, seen time.Time, battery *models.BatteryData) error {
+	return c.PatchSection(ctx, nodeName, models.SectionBattery, seen, battery)
+}
+
+// PatchGPS replaces the GPS section of a node's UAVMetrics, collected at
+// its LastUpdate
+func (c *Client) PatchGPS(ctx context.Context, nodeName string, gps *models.GPSData) error {
+	return c.PatchSection(ctx, nodeName, models.SectionGPS, gps.LastUpdate, gps)
+}
+
+// patch sends a patch of a node's UAVMetrics as the configured field
+// manager, guarded the way CreateOrUpdateUAVMetrics guards its apply. data
+// returns the patch carrying resourceVersion as a precondition, none when
+// it is empty.
+func (c *Client) patch(ctx context.Context, nodeName string, seen time.Time, patchType types.PatchType, data func(resourceVersion string) ([]byte, error)) error {
+	fieldManager := c.config.Kubernetes.FieldManager
+	name := c.ResourceName(nodeName)
+
+	c.mu.Lock()
+	last, ok := c.written[name]
+	c.mu.Unlock()
+	var err error
+	if !ok {
+		// Not written by this process yet, any stored telemetry may be newer
+		if last, err = c.precondition(ctx, nodeName, seen, fieldManager, 0); err != nil {
+			return err
+		}
+	}
+
+	written, err := c.sendPatch(ctx, name, patchType, data, last.resourceVersion)
+	if apierrors.IsConflict(err) {
+		if last, err = c.precondition(ctx, nodeName, seen, fieldManager, last.generation); err != nil {
+			return err
+		}
+		written, err = c.sendPatch(ctx, name, patchType, data, last.resourceVersion)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		delete(c.written, name)
+		return err
+	}
+	c.written[name] = written
+	return nil
+}
+
+// sendPatch sends the patch data returns for resourceVersion and returns
+// the version of the patched object
+func (c *Client) sendPatch(ctx context.Context, name string, patchType types.PatchType, data func(resourceVersion string) ([]byte, error), resourceVersion string) (objectVersion, error) {
+	patch, err := data(resourceVersion)
+	if err != nil {
+		return objectVersion{}, err
+	}
+	options := metav1.PatchOptions{FieldManager: c.config.Kubernetes.FieldManager}
+	patched, err := c.uavMetrics().Patch(ctx, name, patchType, patch, options)
+	if err != nil {
+		return objectVersion{}, fmt.Errorf("failed to patch UAVMetrics: %w", err)
+	}
+	return versionOf(patched), nil
+}
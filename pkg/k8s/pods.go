@@ -0,0 +1,88 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ScheduledPods returns the number of pods placed by schedulerName on each
+// node that are still pending or running. It reads the WatchScheduledPods
+// informer of schedulerName once it has synced, the API server otherwise.
+func (c *Client) ScheduledPods(ctx context.Context, schedulerName string) (map[string]int, error) {
+	var pods []*v1.Pod
+	if informer := c.syncedPodCache(schedulerName); informer != nil {
+		for _, obj := range informer.GetStore().List() {
+			if pod, ok := obj.(*v1.Pod); ok {
+				pods = append(pods, pod)
+			}
+		}
+	} else {
+		list, err := c.clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, scheduledPodsOptions(schedulerName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods of scheduler %s: %w", schedulerName, err)
+		}
+		for i := range list.Items {
+			pods = append(pods, &list.Items[i])
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		counts[pod.Spec.NodeName]++
+	}
+	return counts, nil
+}
+
+// WatchScheduledPods starts an informer of the pods placed by schedulerName,
+// which ScheduledPods reads instead of the API server, and returns once it
+// has synced. The informer runs until ctx is done; later calls for the same
+// scheduler reuse it.
+func (c *Client) WatchScheduledPods(ctx context.Context, schedulerName string) error {
+	c.mu.Lock()
+	if c.podCaches == nil {
+		c.podCaches = make(map[string]cache.SharedIndexInformer)
+	}
+	informer, ok := c.podCaches[schedulerName]
+	if !ok {
+		factory := informers.NewSharedInformerFactoryWithOptions(c.clientset, c.config.Kubernetes.InformerResync,
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = scheduledPodsOptions(schedulerName).FieldSelector
+			}))
+		informer = factory.Core().V1().Pods().Informer()
+		c.podCaches[schedulerName] = informer
+		factory.Start(ctx.Done())
+	}
+	c.mu.Unlock()
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("pod cache of scheduler %s not synced: %w", schedulerName, ctx.Err())
+	}
+	return nil
+}
+
+// syncedPodCache returns the pod informer of schedulerName if it was started
+// and has synced, nil otherwise
+func (c *Client) syncedPodCache(schedulerName string) cache.SharedIndexInformer {
+	c.mu.Lock()
+	informer := c.podCaches[schedulerName]
+	c.mu.Unlock()
+	if informer == nil || !informer.HasSynced() {
+		return nil
+	}
+	return informer
+}
+
+func scheduledPodsOptions(schedulerName string) metav1.ListOptions {
+	return metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.schedulerName", schedulerName).String(),
+	}
+}
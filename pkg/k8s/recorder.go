@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	uavv1alpha1 "github.com/k3suav/uav-monitor/pkg/apis/uav/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// EventRecorder records Kubernetes Events about UAVMetrics, Nodes and Pods
+// as one component. Events are written asynchronously by a broadcaster
+// shared by all recorders of a client, which aggregates similar Events and
+// rate limits those of each object (kubernetes.eventBurst and
+// eventInterval), so a flapping vehicle can't flood the API server.
+type EventRecorder struct {
+	client   *Client
+	recorder record.EventRecorder
+}
+
+// EventRecorder returns a recorder whose Events have component as source,
+// e.g. uav-agent or the scheduler name, and host as source host: the node
+// the component runs on, empty for cluster components
+func (c *Client) EventRecorder(component, host string) *EventRecorder {
+	broadcaster, scheme := c.eventBroadcaster()
+	return &EventRecorder{
+		client:   c,
+		recorder: broadcaster.NewRecorder(scheme, v1.EventSource{Component: component, Host: host}),
+	}
+}
+
+// ShutdownEvents stops the event broadcaster on exit. Events not written
+// yet may be lost, and recorders drop Events afterwards.
+func (c *Client) ShutdownEvents() {
+	c.mu.Lock()
+	broadcaster := c.events
+	c.mu.Unlock()
+	if broadcaster != nil {
+		broadcaster.Shutdown()
+	}
+}
+
+// eventBroadcaster returns the client's broadcaster, starting it on the
+// first call, and the scheme resolving the kinds of recorded objects
+func (c *Client) eventBroadcaster() (record.EventBroadcaster, *runtime.Scheme) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.events == nil {
+		c.events = record.NewBroadcaster(record.WithCorrelatorOptions(record.CorrelatorOptions{
+			BurstSize: c.config.Kubernetes.EventBurst,
+			QPS:       float32(1 / c.config.Kubernetes.EventInterval.Seconds()),
+		}))
+		c.events.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: c.clientset.CoreV1().Events("")})
+
+		c.eventScheme = runtime.NewScheme()
+		_ = clientgoscheme.AddToScheme(c.eventScheme)
+		_ = uavv1alpha1.AddToScheme(c.eventScheme)
+	}
+	return c.events, c.eventScheme
+}
+
+// UAVMetricsEvent records an Event on a node's UAVMetrics, which is read
+// from the informer cache once WatchUAVMetrics has synced it and from the
+// API server otherwise, so the Event carries its UID and shows in kubectl
+// describe
+func (r *EventRecorder) UAVMetricsEvent(ctx context.Context, nodeName, eventType, reason, message string) error {
+	name := r.client.ResourceName(nodeName)
+	var obj *uavv1alpha1.UAVMetrics
+	var err error
+	if metricsCache := r.client.syncedMetricsCache(); metricsCache != nil {
+		obj, err = metricsCache.lister.Get(name)
+	}
+	if obj == nil {
+		obj, err = r.client.uavMetrics().Get(ctx, name, metav1.GetOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get UAVMetrics for event: %w", err)
+	}
+
+	ref := &v1.ObjectReference{
+		APIVersion:      uavv1alpha1.SchemeGroupVersion.String(),
+		Kind:            "UAVMetrics",
+		Name:            name,
+		Namespace:       obj.Namespace,
+		UID:             obj.UID,
+		ResourceVersion: obj.ResourceVersion,
+	}
+	r.recorder.Event(ref, eventType, reason, truncateEventMessage(message))
+	return nil
+}
+
+// NodeEvent records an Event on a Node, in the default namespace with the
+// node name as UID like the kubelet's, so it shows in kubectl describe node
+func (r *EventRecorder) NodeEvent(nodeName, eventType, reason, message string) {
+	ref := &v1.ObjectReference{
+		Kind: "Node",
+		Name: nodeName,
+		UID:  types.UID(nodeName),
+	}
+	r.recorder.Event(ref, eventType, reason, truncateEventMessage(message))
+}
+
+// PodEvent records an Event on a Pod
+func (r *EventRecorder) PodEvent(pod *v1.Pod, eventType, reason, message string) {
+	r.recorder.Event(pod, eventType, reason, truncateEventMessage(message))
+}
+
+// truncateEventMessage keeps message within the API server's limit
+func truncateEventMessage(message string) string {
+	if len(message) > maxEventMessage {
+		return message[:maxEventMessage-3] + "..."
+	}
+	return message
+}
@@ -0,0 +1,161 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// routeOverrideGVR returns the resource of RouteOverride objects, in the same
+// group and version as UAVMetrics
+func (c *Client) routeOverrideGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    c.gvr.Group,
+		Version:  c.gvr.Version,
+		Resource: "routeoverrides",
+	}
+}
+
+// ListRouteOverrides lists all RouteOverride CRDs, including expired ones
+func (c *Client) ListRouteOverrides(ctx context.Context) ([]*models.RouteOverride, error) {
+	unstructuredList, err := c.dynamicClient.Resource(c.routeOverrideGVR()).
+		Namespace(c.config.Kubernetes.Namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list RouteOverrides: %w", err)
+	}
+
+	overrides := make([]*models.RouteOverride, 0, len(unstructuredList.Items))
+	for _, item := range unstructuredList.Items {
+		o, err := unstructuredToOverride(&item)
+		if err != nil {
+			continue
+		}
+		overrides = append(overrides, o)
+	}
+
+	return overrides, nil
+}
+
+// WatchRouteOverrides calls handler with all RouteOverrides, including
+// expired ones, whenever one changes, starting with the existing ones, and
+// returns once those were delivered. The informer behind it is resynced
+// every kubernetes.informerResync and runs until ctx is done.
+func (c *Client) WatchRouteOverrides(ctx context.Context, handler func([]*models.RouteOverride)) error {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.dynamicClient,
+		c.config.Kubernetes.InformerResync, c.config.Kubernetes.Namespace, nil)
+	informer := factory.ForResource(c.routeOverrideGVR()).Informer()
+
+	// Events before the sync only fill the store; handler sees the full
+	// set once, then after every change, one call at a time
+	var synced atomic.Bool
+	var mu sync.Mutex
+	notify := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		overrides := make([]*models.RouteOverride, 0)
+		for _, obj := range informer.GetStore().List() {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				if o, err := unstructuredToOverride(u); err == nil {
+					overrides = append(overrides, o)
+				}
+			}
+		}
+		handler(overrides)
+	}
+	onEvent := func() {
+		if synced.Load() {
+			notify()
+		}
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { onEvent() },
+		UpdateFunc: func(_, _ interface{}) { onEvent() },
+		DeleteFunc: func(interface{}) { onEvent() },
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add RouteOverride handler: %w", err)
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("RouteOverride cache not synced: %w", ctx.Err())
+	}
+	synced.Store(true)
+	notify()
+	return nil
+}
+
+// CreateRouteOverride creates a RouteOverride CRD named after the override
+func (c *Client) CreateRouteOverride(ctx context.Context, override *models.RouteOverride) error {
+	data, err := json.Marshal(override)
+	if err != nil {
+		return err
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return err
+	}
+	delete(spec, "name")
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": fmt.Sprintf("%s/%s", c.gvr.Group, c.gvr.Version),
+			"kind":       "RouteOverride",
+			"spec":       spec,
+		},
+	}
+	obj.SetName(override.Name)
+	obj.SetNamespace(c.config.Kubernetes.Namespace)
+
+	_, err = c.dynamicClient.Resource(c.routeOverrideGVR()).
+		Namespace(c.config.Kubernetes.Namespace).
+		Create(ctx, obj, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create RouteOverride: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRouteOverride deletes a RouteOverride CRD
+func (c *Client) DeleteRouteOverride(ctx context.Context, name string) error {
+	err := c.dynamicClient.Resource(c.routeOverrideGVR()).
+		Namespace(c.config.Kubernetes.Namespace).
+		Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete RouteOverride: %w", err)
+	}
+
+	return nil
+}
+
+func unstructuredToOverride(obj *unstructured.Unstructured) (*models.RouteOverride, error) {
+	spec, found, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil || !found {
+		return nil, fmt.Errorf("spec not found in unstructured object")
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var override models.RouteOverride
+	if err := json.Unmarshal(data, &override); err != nil {
+		return nil, err
+	}
+	override.Name = obj.GetName()
+
+	return &override, nil
+}
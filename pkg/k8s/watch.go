@@ -0,0 +1,143 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	uavv1alpha1 "github.com/k3suav/uav-monitor/pkg/apis/uav/v1alpha1"
+	"github.com/k3suav/uav-monitor/pkg/generated/informers/externalversions"
+	uavv1alpha1listers "github.com/k3suav/uav-monitor/pkg/generated/listers/uav/v1alpha1"
+	"github.com/k3suav/uav-monitor/pkg/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// UAVMetricsHandler receives the changes of the watched UAVMetrics. Any of
+// its funcs may be nil. Like informer handlers, calls are sequential and
+// shouldn't block; metrics are shared with the cache and must not be
+// modified. Each resync calls UpdateFunc with unchanged metrics.
+type UAVMetricsHandler struct {
+	AddFunc    func(metrics *models.UAVMetrics)
+	UpdateFunc func(oldMetrics, newMetrics *models.UAVMetrics)
+	DeleteFunc func(metrics *models.UAVMetrics)
+}
+
+// uavMetricsCache is the shared informer of the configured namespace's
+// UAVMetrics
+type uavMetricsCache struct {
+	informer cache.SharedIndexInformer
+	lister   uavv1alpha1listers.UAVMetricsNamespaceLister
+}
+
+// WatchUAVMetrics calls handler on every change of the configured
+// namespace's UAVMetrics matching kubernetes.labelSelector and
+// fieldSelector, starting with an add for each existing one, and returns
+// once those adds were delivered. The handler is removed when ctx is done.
+//
+// Calls share one informer, resynced every kubernetes.informerResync and
+// started by the first call: it runs until that call's ctx is done. Once it
+// has synced, ListUAVMetrics reads its cache instead of the API server.
+// Handlers see every vehicle, enrolled or not. UAVMetrics can't be watched
+// when read from a fleet source.
+func (c *Client) WatchUAVMetrics(ctx context.Context, handler UAVMetricsHandler) error {
+	if c.fleetSource != nil {
+		return fmt.Errorf("UAVMetrics are read from a fleet source, which can't be watched")
+	}
+
+	informer := c.startMetricsCache(ctx).informer
+	registration, err := informer.AddEventHandler(handler.eventHandler())
+	if err != nil {
+		return fmt.Errorf("failed to add UAVMetrics handler: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = informer.RemoveEventHandler(registration)
+	}()
+
+	if !cache.WaitForCacheSync(ctx.Done(), registration.HasSynced) {
+		return fmt.Errorf("UAVMetrics cache not synced: %w", ctx.Err())
+	}
+	return nil
+}
+
+// startMetricsCache returns the shared informer, creating and starting it
+// with ctx on the first call
+func (c *Client) startMetricsCache(ctx context.Context) *uavMetricsCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.metricsCache != nil {
+		return c.metricsCache
+	}
+
+	factory := externalversions.NewSharedInformerFactoryWithOptions(c.uavClient, c.config.Kubernetes.InformerResync,
+		externalversions.WithNamespace(c.config.Kubernetes.Namespace),
+		externalversions.WithTweakListOptions(func(options *metav1.ListOptions) {
+			selector := c.listOptions()
+			options.LabelSelector = selector.LabelSelector
+			options.FieldSelector = selector.FieldSelector
+		}))
+	informer := factory.Uav().V1alpha1().UAVMetrics()
+	c.metricsCache = &uavMetricsCache{
+		informer: informer.Informer(),
+		lister:   informer.Lister().UAVMetrics(c.config.Kubernetes.Namespace),
+	}
+	factory.Start(ctx.Done())
+	return c.metricsCache
+}
+
+// syncedMetricsCache returns the shared informer if it was started and has
+// synced, nil otherwise
+func (c *Client) syncedMetricsCache() *uavMetricsCache {
+	c.mu.Lock()
+	metricsCache := c.metricsCache
+	c.mu.Unlock()
+	if metricsCache == nil || !metricsCache.informer.HasSynced() {
+		return nil
+	}
+	return metricsCache
+}
+
+// list returns copies of the cached UAVMetrics specs
+func (m *uavMetricsCache) list() ([]*models.UAVMetrics, error) {
+	objs, err := m.lister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached UAVMetrics: %w", err)
+	}
+
+	metrics := make([]*models.UAVMetrics, 0, len(objs))
+	for _, obj := range objs {
+		metrics = append(metrics, obj.Spec.DeepCopy())
+	}
+	return metrics, nil
+}
+
+func (h UAVMetricsHandler) eventHandler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if m, ok := obj.(*uavv1alpha1.UAVMetrics); ok && h.AddFunc != nil {
+				h.AddFunc(&m.Spec)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldMetrics, ok := oldObj.(*uavv1alpha1.UAVMetrics)
+			if !ok {
+				return
+			}
+			if m, ok := newObj.(*uavv1alpha1.UAVMetrics); ok && h.UpdateFunc != nil {
+				h.UpdateFunc(&oldMetrics.Spec, &m.Spec)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			// The final state of objects deleted while the watch was down
+			// comes in a tombstone
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if m, ok := obj.(*uavv1alpha1.UAVMetrics); ok && h.DeleteFunc != nil {
+				h.DeleteFunc(&m.Spec)
+			}
+		},
+	}
+}
@@ -0,0 +1,187 @@
+// Package logfile writes logs to a file on the node that is rotated by size
+// and age, so the evidence needed to debug a flight survives losing the
+// connection to the log shipper.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp inserted in the names of rotated files
+const backupTimeFormat = "20060102T150405.000"
+
+// Writer is an io.Writer appending to a log file. The file is rotated when
+// a write would grow it beyond maxSize or when it is older than maxAge;
+// rotated files are renamed with their rotation time and only the newest
+// maxBackups, none older than maxAge, are kept (0 for no limit). Rotated
+// files younger than minAge are kept regardless of maxBackups.
+type Writer struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	minAge     time.Duration
+	maxBackups int
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	created time.Time
+}
+
+// Open opens the log file at path, creating it and its directory if needed.
+// Writes are appended to an existing file. Log files are only readable by
+// their owner.
+func Open(path string, maxSize int64, maxAge, minAge time.Duration, maxBackups int) (*Writer, error) {
+	w := &Writer{
+		path:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		minAge:     minAge,
+		maxBackups: maxBackups,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	w.prune()
+	return w, nil
+}
+
+// open opens the log file for appending
+func (w *Writer) open() error {
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	// Files created with wider permissions by earlier versions
+	if err := file.Chmod(0o600); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to restrict log file permissions: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	w.file, w.size = file, info.Size()
+	// The modification time of an existing file stands in for its creation
+	w.created = time.Now()
+	if w.size > 0 {
+		w.created = info.ModTime()
+	}
+	return nil
+}
+
+// Write appends p to the log file, rotating it first when needed
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.size > 0 && w.due(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// due reports whether the log file must be rotated before writing n bytes
+func (w *Writer) due(n int64) bool {
+	if w.maxSize > 0 && w.size+n > w.maxSize {
+		return true
+	}
+	return w.maxAge > 0 && time.Since(w.created) > w.maxAge
+}
+
+// rotate renames the log file with the current time and opens a new one
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	w.file = nil
+	// When the file can't be renamed, keep appending to it rather than
+	// losing logs
+	renamed := os.Rename(w.path, w.backupName(time.Now())) == nil
+	if err := w.open(); err != nil {
+		return err
+	}
+	if renamed {
+		w.prune()
+	}
+	return nil
+}
+
+// backupName returns the name of the log file rotated at t, e.g.
+// agent-20240102T150405.000.log for agent.log
+func (w *Writer) backupName(t time.Time) string {
+	ext := filepath.Ext(w.path)
+	return strings.TrimSuffix(w.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// prune removes the rotated files beyond maxBackups or older than maxAge,
+// except those rotated within minAge.
+// Failures are ignored: a file left behind is removed by the next rotation.
+func (w *Writer) prune() {
+	ext := filepath.Ext(w.path)
+	prefix := strings.TrimSuffix(filepath.Base(w.path), ext) + "-"
+	entries, err := os.ReadDir(filepath.Dir(w.path))
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		name    string
+		rotated time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		rotated, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{name: name, rotated: rotated})
+	}
+	// Newest first
+	sort.Slice(backups, func(i, j int) bool { return backups[i].rotated.After(backups[j].rotated) })
+
+	for i, b := range backups {
+		age := time.Since(b.rotated)
+		if age <= w.minAge {
+			continue
+		}
+		expired := w.maxAge > 0 && age > w.maxAge
+		if expired || (w.maxBackups > 0 && i >= w.maxBackups) {
+			os.Remove(filepath.Join(filepath.Dir(w.path), b.name))
+		}
+	}
+}
+
+// Close closes the log file. Later writes fail.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
@@ -0,0 +1,392 @@
+// Package migration 提供有状态负载在无人机节点之间迁移的辅助功能
+//
+// 退役控制器在排空节点前调用 MigrateNode：通过 kubelet checkpoint API（经由 API Server 的
+// nodes/proxy 子资源）对标记了 CheckpointAnnotation 的 Pod 的容器做 CRIU 检查点，
+// 在源节点上运行 Job 用 buildah 将检查点归档构建为镜像并推送到镜像仓库，
+// 然后以这些镜像在替换节点上创建恢复 Pod（CRI-O 可直接从此类镜像恢复容器）。
+// 调用方需要 nodes/proxy 的 create、pods 的 create 以及 jobs 的 get/create/delete 权限，
+// 源节点需启用 ContainerCheckpoint 特性门控，替换节点的容器运行时需为 CRI-O。
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/sirupsen/logrus"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// CheckpointAnnotation 为 "true" 时，节点退役前对 Pod 做检查点并在替换节点上恢复，
+	// 未标记的 Pod 由排空流程直接驱逐
+	CheckpointAnnotation = "uav.k3s.io/checkpoint"
+
+	// RestoredFromAnnotation 标记恢复 Pod 来源的注解（<命名空间>/<名称>）
+	RestoredFromAnnotation = "uav.k3s.io/restored-from"
+
+	// RestoredFromLabel 恢复 Pod 上记录源 Pod UID 的标签，用于查找已创建的恢复 Pod
+	RestoredFromLabel = "uav.k3s.io/restored-from-uid"
+
+	// checkpointAnnotationName CRI-O 据此识别检查点镜像对应的容器
+	checkpointAnnotationName = "io.kubernetes.cri-o.annotations.checkpoint.name"
+
+	// defaultCheckpointTimeout kubelet 执行检查点的默认超时
+	defaultCheckpointTimeout = 60 * time.Second
+
+	// buildJobTTL 构建 Job 完成后保留的时间
+	buildJobTTL = int32(3600)
+
+	// pushSecretDir 推送凭据 Secret 的挂载目录
+	pushSecretDir = "/etc/checkpoint-push"
+)
+
+// buildScript 将检查点归档构建为镜像并推送
+const buildScript = `set -e
+c=$(buildah from scratch)
+buildah add "$c" "$ARCHIVE" /
+buildah config --annotation=` + checkpointAnnotationName + `="$CONTAINER" "$c"
+buildah commit "$c" "$IMAGE"
+buildah push "$IMAGE"
+`
+
+// Checkpointer 容器检查点与恢复客户端
+type Checkpointer struct {
+	clientset    kubernetes.Interface
+	registry     string // 检查点镜像推送到的仓库前缀
+	builderImage string // 运行 buildah 的镜像
+	pushSecret   string // 推送凭据（kubernetes.io/dockerconfigjson Secret），为空时不使用凭据
+	namespace    string // 构建 Job 所在命名空间
+	timeout      time.Duration
+	log          *logrus.Logger
+}
+
+// NewCheckpointer 创建检查点客户端，构建 Job 创建在 namespace 中
+func NewCheckpointer(clientset kubernetes.Interface, cfg config.DecommissionConfig, namespace string, log *logrus.Logger) *Checkpointer {
+	timeout := cfg.CheckpointTimeout
+	if timeout <= 0 {
+		timeout = defaultCheckpointTimeout
+	}
+	return &Checkpointer{
+		clientset:    clientset,
+		registry:     strings.TrimSuffix(cfg.CheckpointRegistry, "/"),
+		builderImage: cfg.CheckpointBuilderImage,
+		pushSecret:   cfg.CheckpointPushSecret,
+		namespace:    namespace,
+		timeout:      timeout,
+		log:          log,
+	}
+}
+
+// MigrateNode 将节点上标记了 CheckpointAnnotation 的 Pod 迁移到 targetNode
+// （为空时由 Pod 的调度器选择，调用方应先封锁节点）。每次调用推进一步，
+// 返回已在新节点上运行的 Pod 数和仍在迁移的 Pod 数；源 Pod 由调用方在迁移完成后驱逐。
+// 由控制器管理的 Pod 不迁移（控制器会自行重建，恢复 Pod 会与之重复），节点未就绪时无法做检查点，
+// 两者都留给排空流程
+func (c *Checkpointer) MigrateNode(ctx context.Context, nodeName, targetNode string) (restored, pending int, err error) {
+	if targetNode == nodeName {
+		return 0, 0, fmt.Errorf("replacement node %s is the node being migrated", nodeName)
+	}
+	node, err := c.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	if !nodeReady(node) {
+		c.log.WithField("node", nodeName).Warn("Node not ready, its pods can't be checkpointed")
+		return 0, 0, nil
+	}
+
+	pods, err := c.clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list pods on node %s: %w", nodeName, err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Annotations[CheckpointAnnotation] != "true" || pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if owner := metav1.GetControllerOf(pod); owner != nil {
+			c.log.WithFields(logrus.Fields{
+				"pod":   pod.Namespace + "/" + pod.Name,
+				"owner": owner.Kind + "/" + owner.Name,
+			}).Warn("Pod managed by a controller, left to it instead of being migrated")
+			continue
+		}
+
+		done, err := c.migratePod(ctx, pod, targetNode)
+		if err != nil {
+			return restored, pending + 1, fmt.Errorf("failed to migrate pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		if done {
+			restored++
+		} else {
+			pending++
+		}
+	}
+	return restored, pending, nil
+}
+
+// migratePod 推进一个 Pod 的迁移：检查点并构建镜像，全部容器的镜像就绪后创建恢复 Pod，
+// 恢复 Pod 运行后返回 true
+func (c *Checkpointer) migratePod(ctx context.Context, pod *v1.Pod, targetNode string) (bool, error) {
+	restoredPods, err := c.clientset.CoreV1().Pods(pod.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{RestoredFromLabel: string(pod.UID)}).String(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list restore pods: %w", err)
+	}
+	if len(restoredPods.Items) > 0 {
+		restored := &restoredPods.Items[0]
+		switch restored.Status.Phase {
+		case v1.PodRunning, v1.PodSucceeded:
+			return true, nil
+		case v1.PodFailed:
+			return false, fmt.Errorf("restore pod %s failed: %s", restored.Name, restored.Status.Message)
+		}
+		return false, nil
+	}
+
+	images := make(map[string]string, len(pod.Spec.Containers))
+	for i, container := range pod.Spec.Containers {
+		image, err := c.checkpointImage(ctx, pod, i, container.Name)
+		if err != nil {
+			return false, fmt.Errorf("container %s: %w", container.Name, err)
+		}
+		if image != "" {
+			images[container.Name] = image
+		}
+	}
+	if len(images) < len(pod.Spec.Containers) {
+		return false, nil
+	}
+
+	_, err = c.Restore(ctx, pod, targetNode, images)
+	return false, err
+}
+
+// checkpointImage 返回容器检查点镜像，构建尚未完成时返回空字符串。
+// 首次调用时对容器做检查点并创建构建 Job，构建失败时删除 Job，下次调用重新做检查点
+func (c *Checkpointer) checkpointImage(ctx context.Context, pod *v1.Pod, index int, container string) (string, error) {
+	name := buildJobName(pod, index)
+	image := c.imageName(pod, container)
+
+	job, err := c.clientset.BatchV1().Jobs(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		archives, err := c.checkpointContainer(ctx, pod.Spec.NodeName, pod.Namespace, pod.Name, container)
+		if err != nil {
+			return "", fmt.Errorf("checkpoint failed: %w", err)
+		}
+		archive := archives[len(archives)-1]
+		if _, err := c.clientset.BatchV1().Jobs(c.namespace).Create(ctx, c.buildJob(name, pod.Spec.NodeName, archive, container, image), metav1.CreateOptions{}); err != nil {
+			return "", fmt.Errorf("failed to create checkpoint image build job: %w", err)
+		}
+
+		c.log.WithFields(logrus.Fields{
+			"pod":       pod.Namespace + "/" + pod.Name,
+			"node":      pod.Spec.NodeName,
+			"container": container,
+			"archive":   archive,
+			"image":     image,
+		}).Info("Container checkpointed, building image")
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get checkpoint image build job: %w", err)
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return image, nil
+		case batchv1.JobFailed:
+			background := metav1.DeletePropagationBackground
+			if err := c.clientset.BatchV1().Jobs(c.namespace).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &background}); err != nil && !apierrors.IsNotFound(err) {
+				return "", fmt.Errorf("failed to delete failed build job %s: %w", name, err)
+			}
+			return "", fmt.Errorf("building checkpoint image failed (job %s: %s), checkpointing again", name, condition.Message)
+		}
+	}
+	return "", nil
+}
+
+// checkpointContainer 调用 kubelet 的 /checkpoint/{namespace}/{pod}/{container} 接口
+func (c *Checkpointer) checkpointContainer(ctx context.Context, nodeName, namespace, podName, container string) ([]string, error) {
+	raw, err := c.clientset.CoreV1().RESTClient().Post().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("checkpoint", namespace, podName, container).
+		Param("timeout", strconv.Itoa(int(c.timeout.Seconds()))).
+		Do(ctx).
+		Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Items []string `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint response: %w", err)
+	}
+	if len(resp.Items) == 0 {
+		return nil, fmt.Errorf("kubelet returned no checkpoint archives")
+	}
+
+	return resp.Items, nil
+}
+
+// buildJob 生成在源节点上将检查点归档构建为镜像并推送的 Job
+// Pod 直接指定节点（绕过已封锁节点的调度），容忍所有污点；buildah 需要特权容器
+func (c *Checkpointer) buildJob(name, nodeName, archive, container, image string) *batchv1.Job {
+	backoffLimit := int32(0)
+	ttl := buildJobTTL
+	privileged := true
+	archiveDir := path.Dir(archive)
+
+	spec := v1.PodSpec{
+		NodeName:      nodeName,
+		RestartPolicy: v1.RestartPolicyNever,
+		Tolerations:   []v1.Toleration{{Operator: v1.TolerationOpExists}},
+		Containers: []v1.Container{{
+			Name:            "build",
+			Image:           c.builderImage,
+			Command:         []string{"sh", "-c", buildScript},
+			SecurityContext: &v1.SecurityContext{Privileged: &privileged},
+			Env: []v1.EnvVar{
+				{Name: "ARCHIVE", Value: archive},
+				{Name: "CONTAINER", Value: container},
+				{Name: "IMAGE", Value: image},
+			},
+			VolumeMounts: []v1.VolumeMount{{Name: "checkpoints", MountPath: archiveDir, ReadOnly: true}},
+		}},
+		Volumes: []v1.Volume{{
+			Name:         "checkpoints",
+			VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: archiveDir}},
+		}},
+	}
+	if c.pushSecret != "" {
+		build := &spec.Containers[0]
+		build.Env = append(build.Env, v1.EnvVar{Name: "REGISTRY_AUTH_FILE", Value: pushSecretDir + "/auth.json"})
+		build.VolumeMounts = append(build.VolumeMounts, v1.VolumeMount{Name: "push-secret", MountPath: pushSecretDir, ReadOnly: true})
+		spec.Volumes = append(spec.Volumes, v1.Volume{
+			Name: "push-secret",
+			VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{
+				SecretName: c.pushSecret,
+				Items:      []v1.KeyToPath{{Key: v1.DockerConfigJsonKey, Path: "auth.json"}},
+			}},
+		})
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+			Labels:    map[string]string{"app": "uav-checkpoint"},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "uav-checkpoint"}},
+				Spec:       spec,
+			},
+		},
+	}
+}
+
+// buildJobName 构建 Job 名称，由源 Pod UID 和容器序号确定
+func buildJobName(pod *v1.Pod, index int) string {
+	return fmt.Sprintf("checkpoint-%s-%d", pod.UID, index)
+}
+
+// imageName 容器检查点镜像名称，以源 Pod UID 为标签
+func (c *Checkpointer) imageName(pod *v1.Pod, container string) string {
+	return fmt.Sprintf("%s/%s-%s:%s", c.registry, pod.Name, container, pod.UID)
+}
+
+// BuildRestorePod 基于原 Pod 生成恢复 Pod，targetNode 为空时由原 Pod 的调度器选择节点
+// images: 容器名 -> 由检查点归档构建的 OCI 镜像（CRI-O 可直接从此类镜像恢复）
+// 未提供检查点镜像的容器将使用原镜像冷启动。
+// 标签原样复制：只迁移不属于任何控制器的 Pod，原 Pod 带着这些标签也未被任何控制器收养，
+// 因此恢复 Pod 不会被 ReplicaSet 等控制器收养或删除，Service 仍能选中它
+func BuildRestorePod(pod *v1.Pod, targetNode string, images map[string]string) *v1.Pod {
+	restored := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: pod.Name + "-restored-",
+			Namespace:    pod.Namespace,
+			Labels:       make(map[string]string, len(pod.Labels)+1),
+			Annotations:  make(map[string]string, len(pod.Annotations)+1),
+		},
+		Spec: *pod.Spec.DeepCopy(),
+	}
+
+	for k, v := range pod.Labels {
+		restored.Labels[k] = v
+	}
+	restored.Labels[RestoredFromLabel] = string(pod.UID)
+	for k, v := range pod.Annotations {
+		restored.Annotations[k] = v
+	}
+	restored.Annotations[RestoredFromAnnotation] = pod.Namespace + "/" + pod.Name
+
+	// 指定替换节点时直接固定到该节点，由恢复流程而不是调度器决定位置
+	restored.Spec.NodeName = targetNode
+	if targetNode != "" {
+		restored.Spec.SchedulerName = ""
+	}
+
+	for i := range restored.Spec.Containers {
+		if image, ok := images[restored.Spec.Containers[i].Name]; ok && image != "" {
+			restored.Spec.Containers[i].Image = image
+		}
+	}
+
+	return restored
+}
+
+// Restore 创建恢复 Pod，返回新建的 Pod
+// 原 Pod 的删除由调用方（迁移流程）在确认恢复成功后负责
+func (c *Checkpointer) Restore(ctx context.Context, pod *v1.Pod, targetNode string, images map[string]string) (*v1.Pod, error) {
+	restored, err := c.clientset.CoreV1().Pods(pod.Namespace).Create(ctx, BuildRestorePod(pod, targetNode, images), metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create restore pod: %w", err)
+	}
+
+	c.log.WithFields(logrus.Fields{
+		"from":   pod.Namespace + "/" + pod.Name,
+		"to":     restored.Name,
+		"node":   targetNode,
+		"images": len(images),
+	}).Info("Restore pod created")
+
+	return restored, nil
+}
+
+// nodeReady 节点的 Ready condition 是否为 True
+func nodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
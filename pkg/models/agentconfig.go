@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Agent config phases
+const (
+	AgentConfigApplied  = "Applied"
+	AgentConfigRejected = "Rejected"
+)
+
+// UAVAgentConfig is the desired state of one vehicle's agent, set by
+// operators to reconfigure it without restarting the pod. Unset fields keep
+// the agent's own configuration (config file and environment).
+type UAVAgentConfig struct {
+	Name string `json:"-"`
+
+	// Incremented by the API server on every spec change
+	Generation int64 `json:"-"`
+
+	Spec   UAVAgentConfigSpec   `json:"spec"`
+	Status UAVAgentConfigStatus `json:"status,omitempty"`
+}
+
+// UAVAgentConfigSpec is the desired agent configuration
+type UAVAgentConfigSpec struct {
+	NodeName string `json:"nodeName"`
+
+	// Collection interval as a duration string (e.g. "5s")
+	CollectionInterval string `json:"collectionInterval,omitempty"`
+
+	// Collectors to enable or disable
+	Collectors *AgentCollectors `json:"collectors,omitempty"`
+
+	// Serve simulated telemetry instead of the configured backend
+	Simulation *bool `json:"simulation,omitempty"`
+
+	// Save power regardless of the battery
+	PowerSave *bool `json:"powerSave,omitempty"`
+}
+
+// AgentCollectors enables or disables the agent's collectors
+type AgentCollectors struct {
+	GPS         *bool `json:"gps,omitempty"`
+	Battery     *bool `json:"battery,omitempty"`
+	Flight      *bool `json:"flight,omitempty"`
+	Network     *bool `json:"network,omitempty"`
+	Performance *bool `json:"performance,omitempty"`
+	HealthCheck *bool `json:"healthCheck,omitempty"`
+}
+
+// UAVAgentConfigStatus is written by the agent once it has handled a spec
+type UAVAgentConfigStatus struct {
+	// Generation of the spec the agent handled
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	Phase string `json:"phase,omitempty"`
+
+	// Why the spec was rejected
+	Message string `json:"message,omitempty"`
+
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// Decommission phases
+const (
+	DecommissionPending    = "Pending"
+	DecommissionInProgress = "InProgress"
+	DecommissionCompleted  = "Completed"
+)
+
+// Decommission steps, in the order they run
+const (
+	StepMigrate    = "Migrate"
+	StepDrain      = "Drain"
+	StepArchive    = "Archive"
+	StepStopAgent  = "StopAgent"
+	StepRevoke     = "RevokeCredentials"
+	StepCleanNode  = "CleanNode"
+	StepDeleteData = "DeleteResources"
+)
+
+// DecommissionSteps lists the steps of a decommission in order. Each step
+// starts once the previous one is done.
+var DecommissionSteps = []string{StepMigrate, StepDrain, StepArchive, StepStopAgent, StepRevoke, StepCleanNode, StepDeleteData}
+
+// Step states
+const (
+	StepPending = "Pending"
+	StepRunning = "Running"
+	StepDone    = "Done"
+	StepSkipped = "Skipped"
+)
+
+// UAVDecommission retires a vehicle from the fleet. The decommission
+// controller migrates its stateful workloads, drains the others, archives its data, revokes its
+// credentials, removes its labels and taints and deletes its resources,
+// tracking each step in the status.
+type UAVDecommission struct {
+	Name string `json:"-"`
+
+	Spec   UAVDecommissionSpec   `json:"spec"`
+	Status UAVDecommissionStatus `json:"status,omitempty"`
+}
+
+// UAVDecommissionSpec selects the vehicle to retire
+type UAVDecommissionSpec struct {
+	NodeName string `json:"nodeName"`
+	Reason   string `json:"reason,omitempty"`
+
+	// Node the migrated workloads are restored on; empty lets their
+	// scheduler pick one
+	ReplacementNode string `json:"replacementNode,omitempty"`
+
+	// Also delete the Node once everything else is removed
+	DeleteNode bool `json:"deleteNode,omitempty"`
+}
+
+// UAVDecommissionStatus is the progress of a decommission
+type UAVDecommissionStatus struct {
+	Phase string             `json:"phase,omitempty"`
+	Steps []DecommissionStep `json:"steps,omitempty"`
+
+	// Directory of the vehicle's archive, relative to the controller's
+	// archive directory
+	Archive string `json:"archive,omitempty"`
+
+	StartedAt   time.Time `json:"startedAt,omitempty"`
+	CompletedAt time.Time `json:"completedAt,omitempty"`
+}
+
+// DecommissionStep is the state of one step
+type DecommissionStep struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+
+	// What the step did, or why it is still running
+	Message     string    `json:"message,omitempty"`
+	CompletedAt time.Time `json:"completedAt,omitempty"`
+}
+
+// Step returns the state of the named step, nil if it hasn't started
+func (s *UAVDecommissionStatus) Step(name string) *DecommissionStep {
+	for i := range s.Steps {
+		if s.Steps[i].Name == name {
+			return &s.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Finished reports whether the step is done or skipped
+func (s *DecommissionStep) Finished() bool {
+	return s.State == StepDone || s.State == StepSkipped
+}
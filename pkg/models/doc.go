@@ -0,0 +1,5 @@
+// Package models defines the telemetry published by the agents and the
+// specs and statuses of the UAV custom resources.
+//
+// +k8s:deepcopy-gen=package
+package models
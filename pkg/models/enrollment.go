@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Enrollment phases
+const (
+	EnrollmentPending  = "Pending"
+	EnrollmentApproved = "Approved"
+	EnrollmentDenied   = "Denied"
+)
+
+// DecidedByDecommission marks the denial of a decommissioned vehicle. The
+// UAVEnrollment is kept as a tombstone: a new request, even with another
+// serial number, stays denied until an operator approves it again.
+const DecidedByDecommission = "decommission"
+
+// UAVEnrollment is a vehicle's request to join the fleet. The agent fills
+// the spec with the vehicle's hardware identity and a certificate signing
+// request; the enrollment controller approves or denies it and, once
+// approved, issues the certificate in the status. Agents can't write the
+// status, so an approved enrollment is the vehicle's fleet membership.
+type UAVEnrollment struct {
+	Name string `json:"-"`
+
+	Spec   UAVEnrollmentSpec   `json:"spec"`
+	Status UAVEnrollmentStatus `json:"status,omitempty"`
+
+	// uav.k3s.io/approval annotation set by an operator (approved or denied)
+	Approval string `json:"-"`
+}
+
+// UAVEnrollmentSpec is the identity presented by the vehicle
+type UAVEnrollmentSpec struct {
+	NodeName      string `json:"nodeName"`
+	SerialNumber  string `json:"serialNumber"`
+	HardwareModel string `json:"hardwareModel,omitempty"`
+
+	// Node of the agent proxying the vehicle, empty when the agent runs on
+	// the vehicle
+	GroundNode string `json:"groundNode,omitempty"`
+
+	// PEM encoded PKCS#10 request for the vehicle's identity key
+	CSR string `json:"csr"`
+
+	// When the CSR was submitted; a later time requests a new certificate
+	RequestedAt time.Time `json:"requestedAt"`
+}
+
+// UAVEnrollmentStatus is the controller's decision and issued credentials
+type UAVEnrollmentStatus struct {
+	Phase string `json:"phase,omitempty"`
+
+	// Operator, or auto-approval policy, that decided
+	DecidedBy string `json:"decidedBy,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+
+	// Serial number the decision applies to; a vehicle presenting another
+	// one is decided again
+	SerialNumber string `json:"serialNumber,omitempty"`
+
+	// SHA-256 of the public key approved for the vehicle. A vehicle keeps
+	// its identity key across renewals, so a request for another key is
+	// denied until an operator deletes the enrollment.
+	PublicKeySHA256 string `json:"publicKeySHA256,omitempty"`
+
+	// PEM encoded client certificate and the fleet CA that signed it
+	Certificate   string    `json:"certificate,omitempty"`
+	CACertificate string    `json:"caCertificate,omitempty"`
+	IssuedAt      time.Time `json:"issuedAt,omitempty"`
+	ExpiresAt     time.Time `json:"expiresAt,omitempty"`
+}
+
+// Enrolled reports whether the enrollment currently grants fleet membership
+func (e *UAVEnrollment) Enrolled(now time.Time) bool {
+	return e.Status.Phase == EnrollmentApproved && e.Status.Certificate != "" && now.Before(e.Status.ExpiresAt)
+}
+
+// Issued reports whether a certificate was issued for the current request
+func (e *UAVEnrollment) Issued() bool {
+	return e.Status.Certificate != "" && !e.Status.IssuedAt.Before(e.Spec.RequestedAt)
+}
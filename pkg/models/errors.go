@@ -21,4 +21,55 @@ var (
 	ErrK8sClientNotInitialized = errors.New("kubernetes client not initialized")
 	ErrCRDUpdateFailed         = errors.New("failed to update CRD")
 	ErrCRDNotFound             = errors.New("CRD not found")
+	ErrStaleUpdate             = errors.New("stale update: newer telemetry already stored")
 )
+
+// Error categories, which set the agent's exit code and let operators triage
+// failures from logs and the UAVMetrics status
+const (
+	ErrorCategoryConfig     = "config"     // configuration can't be loaded or used
+	ErrorCategoryHardware   = "hardware"   // sensors, buses, modems or storage
+	ErrorCategoryK8sAPI     = "k8s-api"    // Kubernetes API unreachable or refusing requests
+	ErrorCategoryValidation = "validation" // configuration or telemetry out of range
+)
+
+// CategorizedError is an error of a known category
+type CategorizedError struct {
+	Category string
+	Err      error
+}
+
+func (e *CategorizedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CategorizedError) Unwrap() error {
+	return e.Err
+}
+
+// Categorize returns err as an error of category, nil when err is nil
+func Categorize(category string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CategorizedError{Category: category, Err: err}
+}
+
+// ErrorCategory returns the category of err: the outermost category it was
+// wrapped in, else the category of the errors of this package it wraps,
+// empty when unknown
+func ErrorCategory(err error) string {
+	var categorized *CategorizedError
+	if errors.As(err, &categorized) {
+		return categorized.Category
+	}
+	switch {
+	case errors.Is(err, ErrInvalidLatitude), errors.Is(err, ErrInvalidLongitude), errors.Is(err, ErrInvalidBatteryPercent):
+		return ErrorCategoryValidation
+	case errors.Is(err, ErrGPSNotLocked), errors.Is(err, ErrBatteryNotAvailable):
+		return ErrorCategoryHardware
+	case errors.Is(err, ErrK8sClientNotInitialized), errors.Is(err, ErrCRDUpdateFailed), errors.Is(err, ErrCRDNotFound):
+		return ErrorCategoryK8sAPI
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var uavMetricsType = reflect.TypeOf(UAVMetrics{})
+
+// FieldIndex is the path of a UAVMetrics field as struct field indexes
+//
+// +k8s:deepcopy-gen=false
+type FieldIndex [][]int
+
+// ResolveField returns the index of a numeric or boolean UAVMetrics field
+// given as a dotted path of JSON names (e.g. battery.remainingPercent),
+// following pointers
+func ResolveField(path string) (FieldIndex, error) {
+	t := uavMetricsType
+	var index FieldIndex
+	for _, name := range strings.Split(path, ".") {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("unknown field %s", path)
+		}
+		field, ok := jsonField(t, name)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %s", path)
+		}
+		index = append(index, field.Index)
+		t = field.Type
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Bool:
+		return index, nil
+	}
+	return nil, fmt.Errorf("field %s is not a number or boolean", path)
+}
+
+// jsonField returns the field of struct type t with JSON name name
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Value returns the field at index in m as a float64 (booleans as 1 and 0),
+// false when a pointer on the way is nil
+func (index FieldIndex) Value(m *UAVMetrics) (float64, bool) {
+	v := reflect.ValueOf(m).Elem()
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return 0, false
+			}
+			v = v.Elem()
+		}
+		v = v.FieldByIndex(i)
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Bool:
+		if v.Bool() {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
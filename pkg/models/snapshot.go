@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// UAVMetricsSnapshot is an immutable copy of a vehicle's metrics at one
+// point in time. Agents write one every kubernetes.snapshotInterval as a
+// UAVMetricsHistory object, so trends can be queried in the cluster without
+// a separate time series database; the janitor deletes them once past the
+// snapshots retention policy.
+type UAVMetricsSnapshot struct {
+	// Object name, UID and resource version, set when read from the cluster
+	Name            string `json:"-"`
+	UID             string `json:"-"`
+	ResourceVersion string `json:"-"`
+
+	NodeName  string     `json:"nodeName"`
+	Timestamp time.Time  `json:"timestamp"`
+	Metrics   UAVMetrics `json:"metrics"`
+}
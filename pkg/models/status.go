@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// UAVMetricsStatus is the status of a UAVMetrics object: the phase written
+// by the agent (or Lost by the router's lost-UAV detection), the error that
+// last terminated the agent and the routing statistics written by the
+// node's router
+type UAVMetricsStatus struct {
+	// +kubebuilder:validation:Enum=Active;Inactive;Error;Lost;Unknown
+	Phase string `json:"phase,omitempty"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	LastUpdated time.Time     `json:"lastUpdated,omitempty"`
+	Failure     *AgentFailure `json:"failure,omitempty"`
+	Routing     *RoutingStats `json:"routing,omitempty"`
+}
+
+// AgentFailure is the error that terminated the agent, written to the
+// status before it exits and cleared once it publishes again
+type AgentFailure struct {
+	Category string `json:"category"` // see ErrorCategoryConfig and friends
+	Message  string `json:"message"`
+	ExitCode int    `json:"exitCode"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	Time time.Time `json:"time"`
+}
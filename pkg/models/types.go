@@ -1,46 +1,234 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"time"
 )
 
 // UAVMetrics represents the complete metrics data for a UAV
 type UAVMetrics struct {
-	NodeName    string            `json:"nodeName"`
-	GPS         GPSData           `json:"gps"`
-	Battery     BatteryData       `json:"battery"`
-	Flight      *FlightData       `json:"flight,omitempty"`
-	Network     *NetworkData      `json:"network,omitempty"`
-	Performance *PerformanceData  `json:"performance,omitempty"`
-	Health      *HealthData       `json:"health,omitempty"`
-	Metadata    *MetadataInfo     `json:"metadata,omitempty"`
+	NodeName    string           `json:"nodeName"`
+	GPS         GPSData          `json:"gps"`
+	Battery     BatteryData      `json:"battery"`
+	Flight      *FlightData      `json:"flight,omitempty"`
+	Network     *NetworkData     `json:"network,omitempty"`
+	Performance *PerformanceData `json:"performance,omitempty"`
+	Health      *HealthData      `json:"health,omitempty"`
+	Metadata    *MetadataInfo    `json:"metadata,omitempty"`
+	Airtime     *AirtimeData     `json:"airtime,omitempty"`
+	Stats       *FlightStats     `json:"stats,omitempty"`
+	Home        *HomeData        `json:"home,omitempty"`
+	Encrypted   *EncryptedFields `json:"encrypted,omitempty"`
+	ESC         []ESCData        `json:"esc,omitempty"`
+	Diagnostics *DiagnosticsData `json:"diagnostics,omitempty"`
+	Altitude    *AltitudeData    `json:"altitude,omitempty"`
+	PowerSave   *PowerSaveData   `json:"powerSave,omitempty"`
+
+	// Ground node that proxies this vehicle's telemetry; empty when the
+	// vehicle is itself the Kubernetes node
+	GroundNode string `json:"groundNode,omitempty"`
+
+	// Set when the sample comes from the simulated backend rather than
+	// the vehicle's telemetry
+	Simulated bool `json:"simulated,omitempty"`
+
+	// Sections that could not be collected this cycle
+	CollectionErrors []CollectionError `json:"collectionErrors,omitempty"`
+}
+
+// CollectionError records a telemetry section that failed to collect. GPS
+// and battery keep their last known values (GPS LastUpdate shows their age);
+// flight, network and performance are omitted.
+type CollectionError struct {
+	// +kubebuilder:validation:Enum=gps;battery;flight;network;performance
+	Section string `json:"section"`
+	Error   string `json:"error"`
+}
+
+// Telemetry sections
+const (
+	SectionGPS         = "gps"
+	SectionBattery     = "battery"
+	SectionFlight      = "flight"
+	SectionNetwork     = "network"
+	SectionPerformance = "performance"
+)
+
+// CollectionFailed reports whether section failed to collect this cycle
+func (m *UAVMetrics) CollectionFailed(section string) bool {
+	for _, e := range m.CollectionErrors {
+		if e.Section == section {
+			return true
+		}
+	}
+	return false
 }
 
 // GPSData contains GPS location information
 type GPSData struct {
-	Latitude   float64   `json:"latitude"`
-	Longitude  float64   `json:"longitude"`
-	Altitude   float64   `json:"altitude,omitempty"`
-	Heading    float64   `json:"heading,omitempty"`
-	Speed      float64   `json:"speed,omitempty"`
-	Satellites int       `json:"satellites,omitempty"`
-	Accuracy   float64   `json:"accuracy,omitempty"`
+	// +kubebuilder:validation:Minimum=-90
+	// +kubebuilder:validation:Maximum=90
+	Latitude float64 `json:"latitude"`
+	// +kubebuilder:validation:Minimum=-180
+	// +kubebuilder:validation:Maximum=180
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=360
+	Heading float64 `json:"heading,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	Speed float64 `json:"speed,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	Satellites int     `json:"satellites,omitempty"`
+	Accuracy   float64 `json:"accuracy,omitempty"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
 	LastUpdate time.Time `json:"lastUpdate"`
+
+	// System clock minus GNSS time in seconds, when the receiver reports
+	// its time. A large skew breaks certificate validation and lease
+	// renewal.
+	ClockSkew *float64 `json:"clockSkew,omitempty"`
+
+	// Fix type (none, 2D, 3D, DGPS, RTK_FLOAT, RTK_FIXED), empty when not reported
+	// +kubebuilder:validation:Enum=none;"2D";"3D";DGPS;RTK_FLOAT;RTK_FIXED
+	FixType string `json:"fixType,omitempty"`
+
+	// Horizontal and vertical dilution of precision, when the receiver reports them
+	// +kubebuilder:validation:Minimum=0
+	HDOP *float64 `json:"hdop,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	VDOP *float64 `json:"vdop,omitempty"`
+
+	// Satellites used per constellation, when the receiver reports them
+	Constellations *GNSSConstellations `json:"constellations,omitempty"`
+
+	// Jamming and spoofing indicators, when the receiver reports them
+	Interference *GNSSInterference `json:"interference,omitempty"`
+
+	// Consistency of the fix with dead reckoning and barometric altitude
+	Integrity *GNSSIntegrity `json:"integrity,omitempty"`
 }
 
+// GNSS fix types
+const (
+	GNSSFixNone     = "none"
+	GNSSFix2D       = "2D"
+	GNSSFix3D       = "3D"
+	GNSSFixDGPS     = "DGPS"
+	GNSSFixRTKFloat = "RTK_FLOAT"
+	GNSSFixRTKFixed = "RTK_FIXED"
+)
+
+// Has3DFix reports whether the fix resolves altitude (3D or better)
+func (g *GPSData) Has3DFix() bool {
+	switch g.FixType {
+	case GNSSFix3D, GNSSFixDGPS, GNSSFixRTKFloat, GNSSFixRTKFixed:
+		return true
+	}
+	return false
+}
+
+// GNSSIntegrity is the result of checking a fix against independent sensors
+type GNSSIntegrity struct {
+	// +kubebuilder:validation:Enum=good;degraded
+	Quality              string   `json:"quality"`                        // good, degraded
+	HorizontalDivergence float64  `json:"horizontalDivergence,omitempty"` // m from dead-reckoned position
+	VerticalDivergence   float64  `json:"verticalDivergence,omitempty"`   // m between GNSS and baro climb
+	Reasons              []string `json:"reasons,omitempty"`
+}
+
+// Position quality levels
+const (
+	PositionQualityGood     = "good"
+	PositionQualityDegraded = "degraded"
+)
+
+// PositionDegraded reports whether the position failed integrity checks and
+// must not be trusted for distance-based decisions
+func (g *GPSData) PositionDegraded() bool {
+	return g.Integrity != nil && g.Integrity.Quality == PositionQualityDegraded
+}
+
+// GNSSConstellations contains the number of satellites used per constellation
+type GNSSConstellations struct {
+	// +kubebuilder:validation:Minimum=0
+	GPS int `json:"gps"`
+	// +kubebuilder:validation:Minimum=0
+	GLONASS int `json:"glonass"`
+	// +kubebuilder:validation:Minimum=0
+	Galileo int `json:"galileo"`
+	// +kubebuilder:validation:Minimum=0
+	BeiDou int `json:"beidou"`
+}
+
+// GNSSInterference contains the receiver's interference monitor output
+type GNSSInterference struct {
+	// +kubebuilder:validation:Enum=unknown;ok;mitigated;detected
+	JammingState string `json:"jammingState,omitempty"`
+	// +kubebuilder:validation:Enum=unknown;ok;mitigated;detected
+	SpoofingState string `json:"spoofingState,omitempty"`
+
+	// Receiver jamming indicator scaled to 0 (none) - 100 (strong), nil if not reported
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	JammingIndicator *int `json:"jammingIndicator,omitempty"`
+}
+
+// GNSS jamming and spoofing states
+const (
+	GNSSStateUnknown   = "unknown"
+	GNSSStateOK        = "ok"
+	GNSSStateMitigated = "mitigated"
+	GNSSStateDetected  = "detected"
+)
+
 // BatteryData contains battery information
 type BatteryData struct {
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
 	RemainingPercent float64 `json:"remainingPercent"`
-	Voltage          float64 `json:"voltage,omitempty"`
-	Current          float64 `json:"current,omitempty"`
-	Temperature      float64 `json:"temperature,omitempty"`
-	TimeRemaining    int     `json:"timeRemaining,omitempty"`
-	CycleCount       int     `json:"cycleCount,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	Voltage     float64 `json:"voltage,omitempty"`
+	Current     float64 `json:"current,omitempty"`
+	Temperature float64 `json:"temperature,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	TimeRemaining int `json:"timeRemaining,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	CycleCount int `json:"cycleCount,omitempty"`
+
+	// Confidence of the TimeRemaining estimate (0-1)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	TimeRemainingConfidence float64 `json:"timeRemainingConfidence,omitempty"`
+
+	// Configured pack chemistry (lipo, li-ion, solid-state) and cells in series
+	// +kubebuilder:validation:Enum=lipo;li-ion;solid-state
+	Chemistry string `json:"chemistry,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	Cells int `json:"cells,omitempty"`
+
+	// Average cell voltage (Voltage / Cells)
+	// +kubebuilder:validation:Minimum=0
+	CellVoltage float64 `json:"cellVoltage,omitempty"`
+
+	// Voltage of each cell as reported by smart batteries, and the spread
+	// between the highest and lowest of them
+	CellVoltages []float64 `json:"cellVoltages,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	CellImbalance float64 `json:"cellImbalance,omitempty"`
+
+	// RemainingPercent was estimated from the voltage because the source
+	// doesn't report it
+	RemainingEstimated bool `json:"remainingEstimated,omitempty"`
 }
 
 // FlightData contains flight status information
 type FlightData struct {
-	Armed         bool    `json:"armed"`
+	Armed bool `json:"armed"`
+	// +kubebuilder:validation:Enum=MANUAL;STABILIZE;ALTITUDE_HOLD;POSITION_HOLD;AUTO;GUIDED;LOITER;RTL;LAND;UNKNOWN
 	Mode          string  `json:"mode"`
 	IsFlying      bool    `json:"isFlying"`
 	Altitude      float64 `json:"altitude,omitempty"`
@@ -50,32 +238,164 @@ type FlightData struct {
 	YawAngle      float64 `json:"yawAngle,omitempty"`
 }
 
+// AltitudeData is the best altitude estimate from the barometer and GNSS.
+// Barometric altitude follows short-term changes closely but drifts with the
+// weather; GNSS altitude does not drift but is noisy, and unusable without a
+// 3D fix. While GNSS altitude is trustworthy it calibrates the barometer, and
+// the calibrated barometer carries the estimate when it is not.
+type AltitudeData struct {
+	// Fused altitude above mean sea level (m)
+	Fused float64 `json:"fused"`
+
+	// Sensors the fused altitude is derived from
+	// +kubebuilder:validation:Enum=fused;baro;gnss
+	Source string `json:"source"`
+
+	// Static pressure (hPa)
+	// +kubebuilder:validation:Minimum=0
+	Pressure *float64 `json:"pressure,omitempty"`
+
+	// Pressure altitude in the ICAO standard atmosphere (m)
+	Barometric *float64 `json:"barometric,omitempty"`
+
+	// GNSS altitude (m), omitted while it is not usable
+	GNSS *float64 `json:"gnss,omitempty"`
+
+	// Offset from pressure altitude to MSL learned from GNSS (m), omitted
+	// until the barometer has been calibrated
+	BaroOffset *float64 `json:"baroOffset,omitempty"`
+}
+
+// AltitudeMSL returns the fused altitude when available, otherwise the GNSS altitude
+func (m *UAVMetrics) AltitudeMSL() float64 {
+	if m.Altitude != nil {
+		return m.Altitude.Fused
+	}
+	return m.GPS.Altitude
+}
+
+// Altitude sources
+const (
+	AltitudeSourceFused = "fused" // barometer calibrated against GNSS
+	AltitudeSourceBaro  = "baro"  // barometer alone, GNSS altitude unusable
+	AltitudeSourceGNSS  = "gnss"  // GNSS alone, no barometer
+)
+
+// ESCData contains the status of a single electronic speed controller
+type ESCData struct {
+	Index              int     `json:"index"`
+	RPM                int     `json:"rpm"`
+	Voltage            float64 `json:"voltage"`
+	Current            float64 `json:"current"`
+	Temperature        float64 `json:"temperature"` // Celsius
+	PowerRatingPercent int     `json:"powerRatingPercent"`
+	ErrorCount         int64   `json:"errorCount"`
+}
+
+// DiagnosticsData contains hardware presence, calibration and link freshness
+type DiagnosticsData struct {
+	Backend       string         `json:"backend"`
+	Sensors       []SensorStatus `json:"sensors,omitempty"`
+	GPSMessageAge *float64       `json:"gpsMessageAge,omitempty"` // seconds since the last GPS message
+	HeartbeatAge  *float64       `json:"heartbeatAge,omitempty"`  // seconds since the last flight controller heartbeat
+	StaleLinks    []string       `json:"staleLinks,omitempty"`
+}
+
+// SensorStatus describes a single sensor as reported by the vehicle
+type SensorStatus struct {
+	Name       string `json:"name"`
+	Present    bool   `json:"present"`
+	Healthy    bool   `json:"healthy"`
+	Calibrated *bool  `json:"calibrated,omitempty"` // nil when the source does not report it
+}
+
 // NetworkData contains network information
 type NetworkData struct {
-	Latency        float64 `json:"latency,omitempty"`
-	Bandwidth      float64 `json:"bandwidth,omitempty"`
-	SignalStrength int     `json:"signalStrength,omitempty"`
-	PacketLoss     float64 `json:"packetLoss,omitempty"`
-	ConnectionType string  `json:"connectionType,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	Latency float64 `json:"latency,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	Bandwidth float64 `json:"bandwidth,omitempty"`
+	// +kubebuilder:validation:Minimum=-140
+	// +kubebuilder:validation:Maximum=0
+	SignalStrength int `json:"signalStrength,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	PacketLoss float64 `json:"packetLoss,omitempty"`
+	// +kubebuilder:validation:Enum="4G";"5G";WIFI;SATELLITE;UNKNOWN
+	ConnectionType string `json:"connectionType,omitempty"`
+
+	// Bandwidth was measured by the bandwidth probe rather than simulated
+	BandwidthMeasured bool `json:"bandwidthMeasured,omitempty"`
+
+	// Serving cell reported by the cellular modem
+	Cellular *CellularData `json:"cellular,omitempty"`
+}
+
+// CellularData contains the serving cell measurements of the cellular modem
+type CellularData struct {
+	Operator string `json:"operator,omitempty"`
+	// +kubebuilder:validation:Enum=LTE;NR5G-NSA;NR5G-SA
+	Technology string  `json:"technology,omitempty"` // LTE, NR5G-NSA, NR5G-SA
+	Band       string  `json:"band,omitempty"`       // e.g. B3, n78, B3+n78
+	RSRP       float64 `json:"rsrp"`                 // dBm
+	RSRQ       float64 `json:"rsrq,omitempty"`       // dB
+	SINR       float64 `json:"sinr,omitempty"`       // dB
 }
 
 // PerformanceData contains system performance metrics
 type PerformanceData struct {
-	CPUUsage    float64 `json:"cpuUsage,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	CPUUsage float64 `json:"cpuUsage,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
 	MemoryUsage float64 `json:"memoryUsage,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
 	DiskUsage   float64 `json:"diskUsage,omitempty"`
 	Temperature float64 `json:"temperature,omitempty"`
-	Uptime      int64   `json:"uptime,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	Uptime int64 `json:"uptime,omitempty"`
 }
 
 // HealthData contains health status information
 type HealthData struct {
-	Status          string    `json:"status"`
-	Errors          []string  `json:"errors,omitempty"`
-	Warnings        []string  `json:"warnings,omitempty"`
-	LastHealthCheck time.Time `json:"lastHealthCheck"`
+	// +kubebuilder:validation:Enum=Healthy;Warning;Critical;Unknown
+	Status   string   `json:"status"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	LastHealthCheck time.Time       `json:"lastHealthCheck"`
+	Anomalies       []SensorAnomaly `json:"anomalies,omitempty"`
+
+	// Continuous health from 0 to 100, higher is healthier: 80-100 while
+	// Healthy, 40-80 while Warning and 0-40 while Critical, lower the more
+	// and the more severe the findings and the closer or further past their
+	// thresholds the readings are. Nil from agents that don't compute it.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Score *float64 `json:"score,omitempty"`
+}
+
+// SensorAnomaly describes an implausible sensor reading
+type SensorAnomaly struct {
+	Sensor string `json:"sensor"` // gps, battery, imu
+	Field  string `json:"field"`
+	// +kubebuilder:validation:Enum=stuck;rate-of-change;out-of-range
+	Type    string  `json:"type"` // stuck, rate-of-change, out-of-range
+	Value   float64 `json:"value"`
+	Message string  `json:"message"`
 }
 
+// Sensor anomaly types
+const (
+	AnomalyStuck        = "stuck"
+	AnomalyRateOfChange = "rate-of-change"
+	AnomalyOutOfRange   = "out-of-range"
+)
+
 // MetadataInfo contains UAV metadata
 type MetadataInfo struct {
 	AgentVersion    string `json:"agentVersion,omitempty"`
@@ -84,6 +404,274 @@ type MetadataInfo struct {
 	SerialNumber    string `json:"serialNumber,omitempty"`
 }
 
+// Reasons the agent saves power
+const (
+	PowerSaveManual  = "manual"
+	PowerSaveBattery = "battery"
+)
+
+// PowerSaveData is present while the agent saves power: metrics are
+// collected less often and the bandwidth and latency probes are paused, so
+// measured bandwidth, latency and packet loss are stale
+type PowerSaveData struct {
+	// manual or battery
+	// +kubebuilder:validation:Enum=manual;battery
+	Reason string `json:"reason"`
+
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	Since time.Time `json:"since"`
+
+	// Collection interval while saving power
+	// +kubebuilder:validation:Minimum=0
+	IntervalSeconds float64 `json:"intervalSeconds"`
+}
+
+// AirtimeData contains the cumulative airborne time for the current day
+type AirtimeData struct {
+	Date string `json:"date"`
+	// +kubebuilder:validation:Minimum=0
+	AirborneMinutes float64 `json:"airborneMinutes"`
+	// +kubebuilder:validation:Minimum=0
+	BudgetMinutes float64 `json:"budgetMinutes,omitempty"`
+}
+
+// AirtimeDateFormat is the layout of AirtimeData.Date, a UTC calendar day
+const AirtimeDateFormat = "2006-01-02"
+
+// MinutesOn returns the airborne minutes of the UTC day of now: the
+// reported minutes if they are for that day, else 0
+func (a *AirtimeData) MinutesOn(now time.Time) float64 {
+	if a.Date != now.UTC().Format(AirtimeDateFormat) {
+		return 0
+	}
+	return a.AirborneMinutes
+}
+
+// IsOverBudget checks if the airborne time of the UTC day of now has
+// reached the daily budget
+func (a *AirtimeData) IsOverBudget(budget float64, now time.Time) bool {
+	return budget > 0 && a.MinutesOn(now) >= budget
+}
+
+// FlightStats contains cumulative usage counters since Since, kept across
+// agent restarts
+type FlightStats struct {
+	// +kubebuilder:validation:Minimum=0
+	TotalDistance float64 `json:"totalDistance"` // meters traveled while flying
+	// +kubebuilder:validation:Minimum=0
+	FlightTime int64 `json:"flightTime"` // seconds airborne
+	// +kubebuilder:validation:Minimum=0
+	EnergyConsumed float64 `json:"energyConsumed"` // watt-hours drawn from the battery
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	Since time.Time `json:"since"` // when counting started
+}
+
+// HomeData contains the home position and the cost of returning to it
+type HomeData struct {
+	// +kubebuilder:validation:Minimum=-90
+	// +kubebuilder:validation:Maximum=90
+	Latitude float64 `json:"latitude"`
+	// +kubebuilder:validation:Minimum=-180
+	// +kubebuilder:validation:Maximum=180
+	Longitude float64 `json:"longitude"`
+	// +kubebuilder:validation:Enum=first-fix;configured
+	Source string `json:"source"` // first-fix or configured
+	// +kubebuilder:validation:Minimum=0
+	Distance float64 `json:"distance"` // meters from home
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=360
+	Bearing float64 `json:"bearing"` // degrees from home to UAV
+	// +kubebuilder:validation:Minimum=0
+	ReturnEnergy float64 `json:"returnEnergy,omitempty"` // estimated Wh to fly home
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ReturnBatteryPercent float64 `json:"returnBatteryPercent,omitempty"` // ReturnEnergy as battery percentage
+}
+
+// EncryptedFields is an envelope holding sensitive fields sealed with the fleet public key
+type EncryptedFields struct {
+	KeyID      string   `json:"keyID"`
+	Algorithm  string   `json:"algorithm"`
+	Fields     []string `json:"fields"`
+	WrappedKey string   `json:"wrappedKey"`
+	Nonce      string   `json:"nonce"`
+	Ciphertext string   `json:"ciphertext"`
+}
+
+// LostBeacon is the last known state of a UAV that stopped reporting,
+// kept for physical recovery
+type LostBeacon struct {
+	NodeName       string  `json:"nodeName"`
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	Altitude       float64 `json:"altitude"`
+	Heading        float64 `json:"heading"`
+	Speed          float64 `json:"speed"`
+	BatteryPercent float64 `json:"batteryPercent"`
+	TimeRemaining  int     `json:"timeRemaining,omitempty"` // estimated flight time left (s)
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	LastSeen time.Time `json:"lastSeen"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	DetectedAt time.Time `json:"detectedAt"`
+
+	// Estimated area the UAV can have reached since it was last seen
+	SearchArea *SearchArea `json:"searchArea,omitempty"`
+}
+
+// RoutingStats summarizes the routing decisions a node's router made over
+// the last reporting window. Routers publish it in the UAVMetrics status.
+type RoutingStats struct {
+	Algorithm          string             `json:"algorithm"`
+	WindowSeconds      float64            `json:"windowSeconds"`
+	Decisions          int64              `json:"decisions"`
+	Failures           int64              `json:"failures"`
+	DecisionsPerSecond float64            `json:"decisionsPerSecond"`
+	TopServices        []ServiceDecisions `json:"topServices,omitempty"`
+
+	// Average distance to the preferred endpoint's node (km), omitted when
+	// no decision had GPS positions for both ends
+	AverageDistanceKm *float64 `json:"averageDistanceKm,omitempty"`
+
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ServiceDecisions counts the routing decisions made for one service
+type ServiceDecisions struct {
+	Service   string `json:"service"`
+	Decisions int64  `json:"decisions"`
+}
+
+// RouteOverride pins the weight of a service's endpoints on a pod or node,
+// on top of the routing algorithm's output, until it expires. A weight of 0
+// drains the endpoints. Operators create them (directly or through the
+// router's admin API) to steer traffic during incidents.
+type RouteOverride struct {
+	Name      string    `json:"name"`
+	Service   string    `json:"service"`            // namespace/name
+	PodName   string    `json:"podName,omitempty"`  // matches one endpoint
+	NodeName  string    `json:"nodeName,omitempty"` // matches every endpoint on the node
+	Weight    int       `json:"weight"`             // 0-100
+	ExpiresAt time.Time `json:"expiresAt"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+}
+
+// Active reports whether the override is still in effect at now
+func (o *RouteOverride) Active(now time.Time) bool {
+	return now.Before(o.ExpiresAt)
+}
+
+// Validate checks that the override targets an endpoint and has a usable
+// weight and expiry
+func (o *RouteOverride) Validate() error {
+	switch {
+	case o.Service == "":
+		return errors.New("service is required")
+	case o.PodName == "" && o.NodeName == "":
+		return errors.New("podName or nodeName is required")
+	case o.Weight < 0 || o.Weight > 100:
+		return fmt.Errorf("weight %d out of range 0-100", o.Weight)
+	case o.ExpiresAt.IsZero():
+		return errors.New("expiresAt is required")
+	}
+	return nil
+}
+
+// SearchArea is a GeoJSON (RFC 7946) Feature whose polygon bounds where a
+// lost UAV may be found
+type SearchArea struct {
+	Type       string               `json:"type"` // always "Feature"
+	Geometry   GeoJSONPolygon       `json:"geometry"`
+	Properties SearchAreaProperties `json:"properties"`
+}
+
+// GeoJSONPolygon is a GeoJSON Polygon geometry. Positions are
+// [longitude, latitude] and the exterior ring is counterclockwise.
+type GeoJSONPolygon struct {
+	Type        string         `json:"type"` // always "Polygon"
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// SearchAreaProperties describes the inputs of a search area estimate
+type SearchAreaProperties struct {
+	NodeName string `json:"nodeName"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	LastSeen time.Time `json:"lastSeen"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	EstimatedAt    time.Time `json:"estimatedAt"`
+	ElapsedSeconds float64   `json:"elapsedSeconds"` // time the UAV could have kept flying
+	MaxRangeMeters float64   `json:"maxRangeMeters"` // farthest point from the last position
+	WindSpeed      float64   `json:"windSpeed"`      // m/s
+	WindDirection  float64   `json:"windDirection"`  // degrees the wind blows from
+}
+
+// NewLostBeacon builds a beacon from the last metrics published by a UAV
+func NewLostBeacon(m *UAVMetrics, lastSeen, detectedAt time.Time) *LostBeacon {
+	return &LostBeacon{
+		NodeName:       m.NodeName,
+		Latitude:       m.GPS.Latitude,
+		Longitude:      m.GPS.Longitude,
+		Altitude:       m.GPS.Altitude,
+		Heading:        m.GPS.Heading,
+		Speed:          m.GPS.Speed,
+		BatteryPercent: m.Battery.RemainingPercent,
+		TimeRemaining:  m.Battery.TimeRemaining,
+		LastSeen:       lastSeen,
+		DetectedAt:     detectedAt,
+	}
+}
+
+// LastSeen returns when the UAV last published metrics
+func (m *UAVMetrics) LastSeen() time.Time {
+	if m.Health != nil && !m.Health.LastHealthCheck.IsZero() {
+		return m.Health.LastHealthCheck
+	}
+	return m.GPS.LastUpdate
+}
+
+// KubeNode returns the Kubernetes node running the vehicle's agent: the
+// ground node proxying it, or the vehicle itself
+func (m *UAVMetrics) KubeNode() string {
+	if m.GroundNode != "" {
+		return m.GroundNode
+	}
+	return m.NodeName
+}
+
+// Home position sources
+const (
+	HomeSourceFirstFix   = "first-fix"
+	HomeSourceConfigured = "configured"
+)
+
+// UsableBatteryPercent returns the battery left after reserving the energy
+// needed to return home
+func (m *UAVMetrics) UsableBatteryPercent() float64 {
+	if m.Home == nil {
+		return m.Battery.RemainingPercent
+	}
+	usable := m.Battery.RemainingPercent - m.Home.ReturnBatteryPercent
+	if usable < 0 {
+		return 0
+	}
+	return usable
+}
+
 // HealthStatus constants
 const (
 	HealthStatusHealthy  = "Healthy"
@@ -0,0 +1,140 @@
+// Package mqtt publishes UAVMetrics samples to an MQTT broker, for ground
+// control software that consumes telemetry over MQTT rather than from the
+// Kubernetes API.
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// ErrNotConnected is returned while the broker is unreachable. Samples are
+// dropped rather than queued so an outage doesn't grow the agent's memory;
+// the connection is retried in the background.
+var ErrNotConnected = errors.New("not connected to MQTT broker")
+
+// Publisher publishes samples to the configured broker and topic. It is
+// safe for concurrent use, so one publisher serves every vehicle of a
+// ground node.
+type Publisher struct {
+	config config.MQTTConfig
+	client paho.Client
+}
+
+// NewPublisher creates a publisher from the agent configuration and starts
+// connecting to the broker in the background
+func NewPublisher(cfg *config.Config) (*Publisher, error) {
+	mqttConfig := cfg.MQTT
+	if mqttConfig.ClientID == "" {
+		mqttConfig.ClientID = "uav-agent-" + cfg.Agent.NodeName
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(mqttConfig.Broker).
+		SetClientID(mqttConfig.ClientID).
+		SetUsername(mqttConfig.Username).
+		SetConnectTimeout(mqttConfig.Timeout).
+		SetWriteTimeout(mqttConfig.Timeout).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOrderMatters(false)
+
+	if mqttConfig.PasswordFile != "" {
+		password, err := os.ReadFile(mqttConfig.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MQTT password: %w", err)
+		}
+		opts.SetPassword(strings.TrimSpace(string(password)))
+	}
+
+	tlsConfig, err := tlsConfig(mqttConfig)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	client := paho.NewClient(opts)
+	// With connect retry the token only completes once connected, so don't wait
+	client.Connect()
+
+	return &Publisher{config: mqttConfig, client: client}, nil
+}
+
+// tlsConfig builds the TLS settings, or returns nil when none are configured
+func tlsConfig(c config.MQTTConfig) (*tls.Config, error) {
+	if c.CAFile == "" && c.CertFile == "" && !c.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MQTT CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load MQTT client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// Topic returns the topic samples of nodeName are published to
+func (p *Publisher) Topic(nodeName string) string {
+	return strings.ReplaceAll(p.config.Topic, "{node}", nodeName)
+}
+
+// Publish sends metrics as JSON (the same document as the CRD spec). With
+// QoS 1 and 2 it waits for the broker's acknowledgement.
+func (p *Publisher) Publish(ctx context.Context, metrics *models.UAVMetrics) error {
+	if !p.client.IsConnectionOpen() {
+		return ErrNotConnected
+	}
+
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+
+	token := p.client.Publish(p.Topic(metrics.NodeName), byte(p.config.QoS), p.config.Retain, data)
+	timer := time.NewTimer(p.config.Timeout)
+	defer timer.Stop()
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-timer.C:
+		return fmt.Errorf("MQTT publish timed out after %s", p.config.Timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close disconnects from the broker, waiting briefly for in-flight publishes
+func (p *Publisher) Close() {
+	p.client.Disconnect(250)
+}
@@ -0,0 +1,98 @@
+// Package observability exports the agent's own metrics and spans over
+// OTLP, so the performance of the telemetry pipeline can be followed in the
+// same backend as application traces.
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Setup installs global tracer and meter providers exporting to
+// cfg.Endpoint. Instrumentation obtained from the otel package before Setup
+// is called is redirected to them; without Setup it stays a no-op. The
+// returned function flushes and stops the exporters.
+func Setup(ctx context.Context, cfg config.OpenTelemetryConfig, version string, attrs ...attribute.KeyValue) (func(context.Context) error, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint: %w", err)
+	}
+	insecure := endpoint.Scheme == "http"
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(append([]attribute.KeyValue{
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(version),
+	}, attrs...)...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resource: %w", err)
+	}
+
+	var traceExporter sdktrace.SpanExporter
+	var metricExporter sdkmetric.Exporter
+	if cfg.Protocol == "grpc" {
+		traceOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint.Host), otlptracegrpc.WithHeaders(cfg.Headers)}
+		metricOpts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(endpoint.Host), otlpmetricgrpc.WithHeaders(cfg.Headers)}
+		if insecure {
+			traceOpts = append(traceOpts, otlptracegrpc.WithInsecure())
+			metricOpts = append(metricOpts, otlpmetricgrpc.WithInsecure())
+		}
+		traceExporter, err = otlptracegrpc.New(ctx, traceOpts...)
+		if err == nil {
+			metricExporter, err = otlpmetricgrpc.New(ctx, metricOpts...)
+		}
+	} else {
+		// Signal paths are appended to the endpoint's path, as with OTEL_EXPORTER_OTLP_ENDPOINT
+		traceOpts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(endpoint.Host),
+			otlptracehttp.WithURLPath(path.Join("/", endpoint.Path, "v1/traces")),
+			otlptracehttp.WithHeaders(cfg.Headers),
+		}
+		metricOpts := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(endpoint.Host),
+			otlpmetrichttp.WithURLPath(path.Join("/", endpoint.Path, "v1/metrics")),
+			otlpmetrichttp.WithHeaders(cfg.Headers),
+		}
+		if insecure {
+			traceOpts = append(traceOpts, otlptracehttp.WithInsecure())
+			metricOpts = append(metricOpts, otlpmetrichttp.WithInsecure())
+		}
+		traceExporter, err = otlptracehttp.New(ctx, traceOpts...)
+		if err == nil {
+			metricExporter, err = otlpmetrichttp.New(ctx, metricOpts...)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithBatcher(traceExporter),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(cfg.MetricInterval))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}, nil
+}
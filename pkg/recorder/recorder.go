@@ -0,0 +1,221 @@
+// Package recorder persists telemetry samples on the node's disk, so they
+// survive agent restarts and can be analysed after a flight even when the
+// link to the cluster was down.
+package recorder
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/securestore"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrLocked is returned by Open when another process (usually a running
+// agent) holds the database
+var ErrLocked = errors.New("recording database is in use by another process")
+
+// Sample is one recorded sample
+type Sample struct {
+	CollectedAt time.Time          `json:"collectedAt"`
+	Metrics     *models.UAVMetrics `json:"metrics"`
+}
+
+// Recorder stores samples in a bbolt database with one bucket per vehicle,
+// keyed by the bucket's sequence so they stay in recording order when the
+// wall clock steps. Each value is the collection time followed by the
+// sample. Each vehicle keeps at most maxSamples samples, none older than
+// maxAge (0 for no limit).
+type Recorder struct {
+	db         *bolt.DB
+	store      *securestore.Store
+	maxSamples int
+	maxAge     time.Duration
+
+	// Samples' ages are measured from this wall time plus the monotonic
+	// time elapsed since opening, so a wall clock step (e.g. the first GNSS
+	// or NTP sync) doesn't expire the whole recording
+	opened time.Time
+
+	mu     sync.Mutex
+	counts map[string]int // samples per vehicle, loaded on first use
+}
+
+// Open opens or creates the database at path. Values are encrypted with
+// store when it is not nil; samples recorded before encryption was enabled
+// remain readable.
+func Open(path string, maxSamples int, maxAge time.Duration, store *securestore.Store) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording database: %w", err)
+	}
+	return &Recorder{
+		db:         db,
+		store:      store,
+		maxSamples: maxSamples,
+		maxAge:     maxAge,
+		opened:     time.Now(),
+		counts:     make(map[string]int),
+	}, nil
+}
+
+// Close closes the database
+func (r *Recorder) Close() error {
+	return r.db.Close()
+}
+
+// Record stores a sample and drops the vehicle's samples beyond the limits
+func (r *Recorder) Record(at time.Time, metrics *models.UAVMetrics) error {
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	if r.store != nil {
+		if data, err = r.store.Encrypt(data); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(metrics.NodeName))
+		if err != nil {
+			return err
+		}
+		count, ok := r.counts[metrics.NodeName]
+		if !ok {
+			count = bucket.Stats().KeyN
+		}
+
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(sequenceKey(seq), encodeValue(at, data)); err != nil {
+			return err
+		}
+		count++
+
+		// Oldest recorded first; the sample just written is kept
+		now := r.now(at)
+		cursor := bucket.Cursor()
+		for k, v := cursor.First(); k != nil && count > 1; k, v = cursor.First() {
+			expired := r.maxAge > 0 && now.Sub(valueTime(v)) > r.maxAge
+			if !expired && (r.maxSamples <= 0 || count <= r.maxSamples) {
+				break
+			}
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+			count--
+		}
+
+		r.counts[metrics.NodeName] = count
+		return nil
+	})
+}
+
+// now returns the wall time of at corrected for the clock steps since the
+// recorder was opened, at itself when it carries no monotonic reading
+func (r *Recorder) now(at time.Time) time.Time {
+	return r.opened.Round(0).Add(at.Sub(r.opened))
+}
+
+// Vehicles returns the names of the recorded vehicles
+func (r *Recorder) Vehicles() ([]string, error) {
+	var names []string
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, string(name))
+			return nil
+		})
+	})
+	return names, err
+}
+
+// Query returns the samples of node collected in (since, until], in
+// recording order; a zero until means no upper bound. When limit is
+// positive, only the limit samples recorded last are returned.
+func (r *Recorder) Query(node string, since, until time.Time, limit int) ([]Sample, error) {
+	var samples []Sample
+	err := r.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(node))
+		if bucket == nil {
+			return nil
+		}
+
+		// Walk back so that limit keeps the samples recorded last. Collection
+		// times follow the wall clock and aren't ordered, so every sample is
+		// checked.
+		cursor := bucket.Cursor()
+		for k, v := cursor.Last(); k != nil && (limit <= 0 || len(samples) < limit); k, v = cursor.Prev() {
+			at := valueTime(v)
+			if !at.After(since) || (!until.IsZero() && at.After(until)) {
+				continue
+			}
+			metrics, err := r.decode(v[timeSize:])
+			if err != nil {
+				return fmt.Errorf("failed to decode sample at %s: %w", at.Format(time.RFC3339Nano), err)
+			}
+			samples = append(samples, Sample{CollectedAt: at, Metrics: metrics})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(samples)-1; i < j; i, j = i+1, j-1 {
+		samples[i], samples[j] = samples[j], samples[i]
+	}
+	return samples, nil
+}
+
+func (r *Recorder) decode(data []byte) (*models.UAVMetrics, error) {
+	if r.store != nil {
+		plaintext, err := r.store.Decrypt(data)
+		if err == nil {
+			data = plaintext
+		} else if !errors.Is(err, securestore.ErrNotEncrypted) {
+			return nil, err
+		}
+	}
+	var metrics models.UAVMetrics
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+// timeSize is the length of the collection time at the start of a value
+const timeSize = 8
+
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+func encodeValue(at time.Time, data []byte) []byte {
+	value := make([]byte, timeSize, timeSize+len(data))
+	binary.BigEndian.PutUint64(value, uint64(at.UnixNano()))
+	return append(value, data...)
+}
+
+func valueTime(value []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(value[:timeSize])))
+}
@@ -0,0 +1,233 @@
+package remoteid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// Supported jurisdictional message formats
+const (
+	// FormatASTM is ASTM F3411 as required by the FAA (14 CFR Part 89)
+	FormatASTM = "astm-f3411"
+	// FormatEU is ASD-STAN EN 4709-002 as required in the EU
+	FormatEU = "en4709-002"
+)
+
+// UAS ID types of the Basic ID message, shared by both formats
+const (
+	IDTypeSerialNumber      = "serial_number"
+	IDTypeCAARegistration   = "caa_registration"
+	IDTypeUTMAssignedUUID   = "utm_assigned_uuid"
+	IDTypeSpecificSessionID = "specific_session_id"
+)
+
+// Message is the Remote ID payload derived from UAVMetrics
+type Message struct {
+	Format     string `json:"format"`
+	UASID      string `json:"uasID"`
+	IDType     string `json:"idType"`
+	UAType     string `json:"uaType"`
+	OperatorID string `json:"operatorID,omitempty"`
+
+	// Location/Vector message. Without a valid position (GPS failed,
+	// disabled or without a fix) the position fields are 0, the value both
+	// formats reserve for an unknown position, and PositionValid is false.
+	Status        string    `json:"status"`
+	PositionValid bool      `json:"positionValid"`
+	Latitude      float64   `json:"latitude"`
+	Longitude     float64   `json:"longitude"`
+	GeodeticAlt   float64   `json:"geodeticAltitude"`
+	Height        float64   `json:"height"`
+	Direction     float64   `json:"direction"`
+	Speed         float64   `json:"speed"`
+	VerticalSpeed float64   `json:"verticalSpeed"`
+	HorizontalAcc float64   `json:"horizontalAccuracy,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+
+	// System message
+	OperatorLatitude  float64 `json:"operatorLatitude,omitempty"`
+	OperatorLongitude float64 `json:"operatorLongitude,omitempty"`
+	Category          string  `json:"category,omitempty"`
+	Class             string  `json:"class,omitempty"`
+}
+
+// Publisher sends Remote ID messages to a broadcast daemon or network service
+type Publisher struct {
+	config     config.RemoteIDConfig
+	endpoint   *url.URL
+	httpClient *http.Client
+}
+
+// NewPublisher creates a Remote ID publisher from the agent configuration
+func NewPublisher(cfg *config.Config) (*Publisher, error) {
+	ridConfig := cfg.RemoteID
+	if ridConfig.UASID == "" {
+		ridConfig.UASID = cfg.UAVMetadata.SerialNumber
+	}
+	if err := validate(&ridConfig); err != nil {
+		return nil, err
+	}
+
+	endpoint, _ := url.Parse(ridConfig.Endpoint)
+	timeout := ridConfig.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+
+	return &Publisher{
+		config:     ridConfig,
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// validate validates the Remote ID configuration against the selected jurisdiction
+func validate(c *config.RemoteIDConfig) error {
+	switch c.Format {
+	case FormatASTM:
+	case FormatEU:
+		if c.OperatorID == "" {
+			return fmt.Errorf("remoteID.operatorID is required for %s", FormatEU)
+		}
+	default:
+		return fmt.Errorf("remoteID.format must be %s or %s", FormatASTM, FormatEU)
+	}
+
+	if c.UASID == "" {
+		return fmt.Errorf("remoteID.uasID cannot be empty")
+	}
+	switch c.IDType {
+	case IDTypeSerialNumber, IDTypeCAARegistration, IDTypeUTMAssignedUUID, IDTypeSpecificSessionID:
+	default:
+		return fmt.Errorf("remoteID.idType must be one of %s, %s, %s, %s",
+			IDTypeSerialNumber, IDTypeCAARegistration, IDTypeUTMAssignedUUID, IDTypeSpecificSessionID)
+	}
+
+	endpoint, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid remoteID.endpoint: %w", err)
+	}
+	switch endpoint.Scheme {
+	case "udp", "http", "https":
+	default:
+		return fmt.Errorf("remoteID.endpoint scheme must be udp, http or https")
+	}
+
+	return nil
+}
+
+// BuildMessage derives a Remote ID message from collected metrics
+func (p *Publisher) BuildMessage(metrics *models.UAVMetrics) *Message {
+	msg := &Message{
+		Format: p.config.Format,
+		UASID:  p.config.UASID,
+		IDType: p.config.IDType,
+		UAType: "helicopter_or_multirotor",
+		Status: "ground",
+	}
+
+	// A failed GPS section leaves the fallback or last position in place,
+	// which must not be broadcast as the vehicle's location
+	if positionValid(metrics) {
+		msg.PositionValid = true
+		msg.Latitude = metrics.GPS.Latitude
+		msg.Longitude = metrics.GPS.Longitude
+		msg.GeodeticAlt = metrics.GPS.Altitude
+		msg.Direction = metrics.GPS.Heading
+		msg.Speed = metrics.GPS.Speed
+		msg.HorizontalAcc = metrics.GPS.Accuracy
+		msg.Timestamp = metrics.GPS.LastUpdate
+	}
+
+	if metrics.Flight != nil {
+		msg.Height = metrics.Flight.Altitude
+		msg.VerticalSpeed = metrics.Flight.VerticalSpeed
+		if metrics.Flight.IsFlying {
+			msg.Status = "airborne"
+		}
+	}
+
+	if p.config.OperatorLatitude != 0 || p.config.OperatorLongitude != 0 {
+		msg.OperatorLatitude = p.config.OperatorLatitude
+		msg.OperatorLongitude = p.config.OperatorLongitude
+	}
+
+	switch p.config.Format {
+	case FormatEU:
+		// EN 4709-002 requires the operator registration number and UA classification
+		msg.OperatorID = p.config.OperatorID
+		msg.Category = p.config.Category
+		msg.Class = p.config.Class
+	case FormatASTM:
+		// Operator ID is optional under ASTM F3411 / FAA rules
+		msg.OperatorID = p.config.OperatorID
+	}
+
+	return msg
+}
+
+// positionValid reports whether metrics carry a current GPS position
+func positionValid(metrics *models.UAVMetrics) bool {
+	if metrics.CollectionFailed(models.SectionGPS) || metrics.GPS.FixType == models.GNSSFixNone {
+		return false
+	}
+	return metrics.GPS.Latitude != 0 || metrics.GPS.Longitude != 0
+}
+
+// Publish sends the Remote ID message for the given metrics
+func (p *Publisher) Publish(ctx context.Context, metrics *models.UAVMetrics) error {
+	data, err := json.Marshal(p.BuildMessage(metrics))
+	if err != nil {
+		return fmt.Errorf("failed to encode remote ID message: %w", err)
+	}
+
+	switch p.endpoint.Scheme {
+	case "udp":
+		return p.publishUDP(data)
+	default:
+		return p.publishHTTP(ctx, data)
+	}
+}
+
+// publishUDP sends the message as a single datagram to a local broadcast daemon
+func (p *Publisher) publishUDP(data []byte) error {
+	conn, err := net.DialTimeout("udp", p.endpoint.Host, p.httpClient.Timeout)
+	if err != nil {
+		return fmt.Errorf("failed to reach remote ID daemon: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("failed to send remote ID message: %w", err)
+	}
+	return nil
+}
+
+// publishHTTP posts the message to a network Remote ID service
+func (p *Publisher) publishHTTP(ctx context.Context, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post remote ID message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("remote ID service returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// Backoff produces exponentially growing delays with ±20% jitter, so a fleet
+// reconnecting after a link outage does not hit the API server in lockstep
+type Backoff struct {
+	initial time.Duration
+	max     time.Duration
+
+	mu      sync.Mutex
+	current time.Duration
+	rand    *rand.Rand
+}
+
+// NewBackoff creates a backoff starting at initial and capped at max
+func NewBackoff(initial, max time.Duration) *Backoff {
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if max < initial {
+		max = initial
+	}
+	return &Backoff{
+		initial: initial,
+		max:     max,
+		current: initial,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Next returns the next delay
+func (b *Backoff) Next() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delay := b.current
+	b.current *= 2
+	if b.current > b.max {
+		b.current = b.max
+	}
+	jitter := 0.8 + 0.4*b.rand.Float64()
+	return time.Duration(float64(delay) * jitter)
+}
+
+// Reset restarts the backoff from the initial delay
+func (b *Backoff) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current = b.initial
+}
+
+// Wait sleeps for the next delay or until ctx is cancelled
+func (b *Backoff) Wait(ctx context.Context) error {
+	return sleep(ctx, b.Next())
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// IsTransient reports whether err is likely to go away on retry: network
+// errors, timeouts, throttling and API server 5xx responses
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) || apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) || apierrors.IsUnexpectedServerError(err) {
+		return true
+	}
+	if utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err) || utilnet.IsTimeout(err) || utilnet.IsHTTP2ConnectionLost(err) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsPermanent reports whether the API server rejected the request for good:
+// retrying it unchanged gets the same answer (forbidden, invalid or
+// malformed)
+func IsPermanent(err error) bool {
+	return apierrors.IsForbidden(err) || apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) ||
+		apierrors.IsMethodNotSupported(err) || apierrors.IsUnsupportedMediaType(err) ||
+		apierrors.IsRequestEntityTooLargeError(err)
+}
+
+// Reconnect runs a long-lived watch until ctx is cancelled, restarting it
+// with backoff whenever it ends. The backoff resets once a watch has stayed
+// up for stableAfter, so a link that drops now and then reconnects quickly
+// while an API server that keeps refusing is not hammered. onRetry, if set,
+// is called before each wait.
+func Reconnect(ctx context.Context, backoff *Backoff, stableAfter time.Duration, watch func(ctx context.Context) error, onRetry func(err error, delay time.Duration)) error {
+	for {
+		started := time.Now()
+		err := watch(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(started) >= stableAfter {
+			backoff.Reset()
+		}
+		delay := backoff.Next()
+		if onRetry != nil {
+			onRetry(err, delay)
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// dnsAttemptTimeout bounds each connection attempt when a name resolves to
+// several addresses, so one unreachable address does not use up the request
+const dnsAttemptTimeout = 5 * time.Second
+
+// Resolver caches DNS lookups. Entries are refreshed after the TTL; if the
+// refresh fails (DNS is often the first thing to break on a degraded link)
+// the stale addresses keep being used.
+type Resolver struct {
+	ttl    time.Duration
+	lookup func(ctx context.Context, host string) ([]string, error)
+	dialer net.Dialer
+
+	mu      sync.Mutex
+	entries map[string]*dnsEntry
+}
+
+type dnsEntry struct {
+	addrs    []string
+	resolved time.Time
+}
+
+// NewResolver creates a caching resolver
+func NewResolver(ttl time.Duration) *Resolver {
+	return &Resolver{
+		ttl:     ttl,
+		lookup:  net.DefaultResolver.LookupHost,
+		entries: make(map[string]*dnsEntry),
+	}
+}
+
+// LookupHost returns the addresses of host, from the cache when fresh
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	r.mu.Lock()
+	entry := r.entries[host]
+	r.mu.Unlock()
+	if entry != nil && time.Since(entry.resolved) < r.ttl {
+		return entry.addrs, nil
+	}
+
+	addrs, err := r.lookup(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		if entry != nil {
+			return entry.addrs, nil // serve stale
+		}
+		if err == nil {
+			err = &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.entries[host] = &dnsEntry{addrs: addrs, resolved: time.Now()}
+	r.mu.Unlock()
+	return addrs, nil
+}
+
+// DialContext dials address, resolving its host through the cache and trying
+// each resolved address in turn
+func (r *Resolver) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, addr := range addrs {
+		attemptCtx := ctx
+		cancel := func() {}
+		if len(addrs) > 1 {
+			attemptCtx, cancel = context.WithTimeout(ctx, dnsAttemptTimeout)
+		}
+		conn, err := r.dialer.DialContext(attemptCtx, network, net.JoinHostPort(addr, port))
+		cancel()
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
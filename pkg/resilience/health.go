@@ -0,0 +1,122 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// probeTimeout bounds a single /readyz probe
+const probeTimeout = 5 * time.Second
+
+// endpointPool tracks the health of the API server endpoints. Endpoints are
+// probed on /readyz at most once per interval while requests are flowing,
+// and marked down as soon as a connection to them fails. Requests go to
+// healthy endpoints first, in configured order, so the client moves to a
+// backup server while the primary is down and back once it recovers.
+type endpointPool struct {
+	endpoints []*url.URL // primary first
+	interval  time.Duration
+	probe     func(ctx context.Context, endpoint *url.URL) bool
+
+	mu    sync.Mutex
+	state []endpointState
+}
+
+type endpointState struct {
+	healthy   bool
+	probing   bool
+	lastProbe time.Time
+}
+
+func newEndpointPool(endpoints []*url.URL, interval time.Duration, base http.RoundTripper) *endpointPool {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	p := &endpointPool{
+		endpoints: endpoints,
+		interval:  interval,
+		state:     make([]endpointState, len(endpoints)),
+	}
+	for i := range p.state {
+		p.state[i].healthy = true // until proven otherwise
+	}
+	p.probe = func(ctx context.Context, endpoint *url.URL) bool {
+		return probeReady(ctx, base, endpoint)
+	}
+	return p
+}
+
+// order returns endpoint indices to try: healthy endpoints first, then the
+// unhealthy ones as a last resort. Endpoints due for a probe are probed in
+// the background.
+func (p *endpointPool) order() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	healthy := make([]int, 0, len(p.endpoints))
+	var unhealthy []int
+	for i := range p.state {
+		st := &p.state[i]
+		if !st.probing && now.Sub(st.lastProbe) >= p.interval {
+			st.probing = true
+			st.lastProbe = now
+			go p.check(i)
+		}
+		if st.healthy {
+			healthy = append(healthy, i)
+		} else {
+			unhealthy = append(unhealthy, i)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (p *endpointPool) check(i int) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	healthy := p.probe(ctx, p.endpoints[i])
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state[i].healthy = healthy
+	p.state[i].probing = false
+}
+
+// observe records the outcome of a request to endpoint i. Only connection
+// failures mark an endpoint down; readiness is left to the probe.
+func (p *endpointPool) observe(i int, err error) {
+	if err == nil || errors.Is(err, context.Canceled) || !isDialError(err) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state[i].healthy = false
+}
+
+// probeReady reports whether the API server at endpoint is ready. 401 and
+// 403 count as ready: the server is up but does not let anonymous clients
+// read /readyz.
+func probeReady(ctx context.Context, base http.RoundTripper, endpoint *url.URL) bool {
+	u := *endpoint
+	u.Path = "/readyz"
+	u.RawQuery = ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// hedgingTransport sends each request to the first healthy API server.
+// Idempotent requests without a response after the hedge delay are also sent
+// to the next endpoint, and the first good response wins. Other requests
+// (writes and watches) only move to the next endpoint when the connection
+// could not be established, so they are never applied twice.
+type hedgingTransport struct {
+	base  http.RoundTripper
+	pool  *endpointPool
+	delay time.Duration
+}
+
+func newHedgingTransport(base http.RoundTripper, endpoints []*url.URL, delay, healthInterval time.Duration) *hedgingTransport {
+	if delay <= 0 {
+		delay = time.Second
+	}
+	return &hedgingTransport{
+		base:  base,
+		pool:  newEndpointPool(endpoints, healthInterval, base),
+		delay: delay,
+	}
+}
+
+// hedgeable reports whether req can safely be sent more than once
+func hedgeable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	// Watches are long-lived; hedging would keep duplicate streams open
+	return req.URL.Query().Get("watch") != "true" && req.URL.Query().Get("watch") != "1"
+}
+
+// to returns a copy of req addressed to endpoint i
+func (t *hedgingTransport) to(ctx context.Context, req *http.Request, i int) *http.Request {
+	r := req.Clone(ctx)
+	if i > 0 {
+		r.URL.Scheme = t.pool.endpoints[i].Scheme
+		r.URL.Host = t.pool.endpoints[i].Host
+		r.Host = ""
+	}
+	return r
+}
+
+// roundTrip sends req to endpoint i and records the outcome
+func (t *hedgingTransport) roundTrip(req *http.Request, i int) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	t.pool.observe(i, err)
+	return resp, err
+}
+
+func (t *hedgingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	order := t.pool.order()
+	if !hedgeable(req) {
+		return t.failover(req, order)
+	}
+	return t.hedge(req, order)
+}
+
+// failover tries the endpoints in order while connections cannot be made
+func (t *hedgingTransport) failover(req *http.Request, order []int) (*http.Response, error) {
+	var errs []error
+	for i, endpoint := range order {
+		if i > 0 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				break // body already consumed and cannot be replayed
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				break
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		resp, err := t.roundTrip(t.to(req.Context(), req, endpoint), endpoint)
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, err)
+		if !isDialError(err) || req.Context().Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+type hedgeResult struct {
+	index int
+	resp  *http.Response
+	err   error
+}
+
+// hedge races the endpoints, starting the next one after each hedge delay or
+// as soon as an attempt fails
+func (t *hedgingTransport) hedge(req *http.Request, order []int) (*http.Response, error) {
+	results := make(chan hedgeResult, len(order))
+	cancels := make([]context.CancelFunc, 0, len(order))
+	launch := func() {
+		i := len(cancels)
+		ctx, cancel := context.WithCancel(req.Context())
+		cancels = append(cancels, cancel)
+		r := t.to(ctx, req, order[i])
+		go func() {
+			resp, err := t.roundTrip(r, order[i])
+			results <- hedgeResult{index: i, resp: resp, err: err}
+		}()
+	}
+	// cancelOthers stops every attempt except keep (-1 for all) and closes
+	// responses that are still on their way
+	cancelOthers := func(keep, pending int) {
+		for i, cancel := range cancels {
+			if i != keep {
+				cancel()
+			}
+		}
+		go func() {
+			for ; pending > 0; pending-- {
+				if res := <-results; res.resp != nil {
+					res.resp.Body.Close()
+				}
+			}
+		}()
+	}
+
+	launch()
+	pending := 1
+	timer := time.NewTimer(t.delay)
+	defer timer.Stop()
+
+	var lastErr error
+	var fallback *hedgeResult // last 5xx response, returned if nothing better arrives
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if len(cancels) < len(order) {
+				launch()
+				pending++
+				timer.Reset(t.delay)
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil && res.resp.StatusCode < http.StatusInternalServerError {
+				if fallback != nil {
+					fallback.resp.Body.Close()
+				}
+				cancelOthers(res.index, pending)
+				res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.index]}
+				return res.resp, nil
+			}
+			if res.err != nil {
+				lastErr = res.err
+			} else {
+				if fallback != nil {
+					fallback.resp.Body.Close()
+				}
+				fallback = &res
+			}
+			// Don't wait for the hedge delay after a failure
+			if len(cancels) < len(order) {
+				launch()
+				pending++
+				timer.Reset(t.delay)
+			}
+		}
+	}
+
+	if fallback != nil {
+		cancelOthers(fallback.index, 0)
+		fallback.resp.Body = &cancelOnClose{ReadCloser: fallback.resp.Body, cancel: cancels[fallback.index]}
+		return fallback.resp, nil
+	}
+	cancelOthers(-1, 0)
+	return nil, lastErr
+}
+
+// cancelOnClose releases the winning attempt's context with its body
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// isDialError reports whether err happened before the request was sent
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
@@ -0,0 +1,81 @@
+// Package resilience keeps Kubernetes API clients working over flaky control
+// links (4G, radio backhaul). It is shared by the agent, router and scheduler:
+//
+//   - API server addresses are resolved through a DNS cache that keeps serving
+//     the last known addresses while DNS is unreachable
+//   - requests go to the first healthy API server endpoint; endpoints are
+//     probed on /readyz and marked down when connections to them fail
+//   - idempotent requests that are slow to answer are hedged to additional API
+//     server endpoints, and connection failures fail over to them
+//   - transient errors are retried with exponential backoff, and long-lived
+//     watches reconnect with backoff that resets once a watch is stable
+package resilience
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// Options configures the resilience layer
+type Options struct {
+	// Additional API server URLs (e.g. https://10.0.0.2:6443). They must be
+	// covered by the API server certificate (k3s --tls-san).
+	Endpoints []string
+
+	// Delay before a request without a response is hedged to the next endpoint
+	HedgeDelay time.Duration
+
+	// How often each endpoint's /readyz is probed while requests are flowing
+	HealthCheckInterval time.Duration
+
+	// How long resolved addresses are cached; 0 disables the DNS cache
+	DNSCacheTTL time.Duration
+}
+
+// Configure installs the DNS cache and request hedging on a rest.Config
+func Configure(cfg *rest.Config, opts Options) error {
+	if opts.DNSCacheTTL > 0 && cfg.Dial == nil {
+		cfg.Dial = NewResolver(opts.DNSCacheTTL).DialContext
+	}
+
+	if len(opts.Endpoints) == 0 {
+		return nil
+	}
+	primary, err := url.Parse(cfg.Host)
+	if err != nil || primary.Host == "" {
+		return fmt.Errorf("invalid API server host %q", cfg.Host)
+	}
+	endpoints := []*url.URL{primary}
+	for _, endpoint := range opts.Endpoints {
+		u, err := ParseEndpoint(endpoint)
+		if err != nil {
+			return err
+		}
+		if u.Host != primary.Host {
+			endpoints = append(endpoints, u)
+		}
+	}
+	if len(endpoints) == 1 {
+		return nil
+	}
+
+	cfg.WrapTransport = transport.Wrappers(cfg.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+		return newHedgingTransport(rt, endpoints, opts.HedgeDelay, opts.HealthCheckInterval)
+	})
+	return nil
+}
+
+// ParseEndpoint validates an API server URL
+func ParseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid API server endpoint %q, expected https://host:port", endpoint)
+	}
+	return u, nil
+}
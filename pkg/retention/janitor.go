@@ -0,0 +1,150 @@
+// Package retention enforces the retention policies of telemetry data kept
+// in the cluster.
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Janitor deletes the UAVMetrics of vehicles that stopped reporting longer
+// ago than the metrics policy of their fleet allows, and the snapshots older
+// than the snapshots policy; neither is deleted within the policy's minimum
+// age. It sweeps the default namespace and every fleet named by a policy.
+// Deletes are conditional on the listed UID and resource version, so an
+// object written again since the sweep listed it is kept.
+type Janitor struct {
+	client *k8s.Client
+	cfg    *config.Config
+	log    *logrus.Logger
+}
+
+// NewJanitor creates a janitor deleting through client
+func NewJanitor(client *k8s.Client, cfg *config.Config, log *logrus.Logger) *Janitor {
+	return &Janitor{client: client, cfg: cfg, log: log}
+}
+
+// Run sweeps every retention interval until ctx is cancelled
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.cfg.Retention.Interval)
+	defer ticker.Stop()
+
+	for {
+		j.Sweep(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep deletes the data expired at now and returns how many objects were
+// deleted. Failures are logged and retried on the next sweep.
+func (j *Janitor) Sweep(ctx context.Context, now time.Time) int {
+	deleted := 0
+	for _, fleet := range j.fleets() {
+		if policy, ok := j.cfg.Retention.PolicyFor(fleet, config.RetentionMetrics); ok && policy.MaxAge > 0 {
+			deleted += j.sweepMetrics(ctx, fleet, policy, now)
+		}
+		if policy, ok := j.cfg.Retention.PolicyFor(fleet, config.RetentionSnapshots); ok && policy.MaxAge > 0 {
+			deleted += j.sweepSnapshots(ctx, fleet, policy, now)
+		}
+	}
+	return deleted
+}
+
+// sweepMetrics deletes the UAVMetrics of a fleet's vehicles that stopped
+// reporting longer ago than policy allows
+func (j *Janitor) sweepMetrics(ctx context.Context, fleet string, policy config.RetentionPolicy, now time.Time) int {
+	objects, err := j.client.ListUAVMetricsObjects(ctx, fleet)
+	if err != nil {
+		j.log.WithError(err).WithField("fleet", fleet).Warn("Failed to list UAVMetrics for retention")
+		return 0
+	}
+
+	deleted := 0
+	for i := range objects {
+		obj := &objects[i]
+		m := &obj.Spec
+		lastSeen := m.LastSeen()
+		// Without a timestamp the age is unknown, so keep the object
+		if lastSeen.IsZero() || !policy.Expired(lastSeen, now) {
+			continue
+		}
+		entry := j.log.WithFields(logrus.Fields{
+			"fleet":    fleet,
+			"nodeName": m.NodeName,
+			"lastSeen": lastSeen,
+			"maxAge":   policy.MaxAge,
+		})
+		preconditions := &metav1.Preconditions{UID: &obj.UID, ResourceVersion: &obj.ResourceVersion}
+		if err := j.client.DeleteUAVMetricsIn(ctx, fleet, m.NodeName, preconditions); err != nil {
+			if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+				entry.Debug("UAVMetrics changed since listed, kept")
+				continue
+			}
+			entry.WithError(err).Warn("Failed to delete expired UAVMetrics")
+			continue
+		}
+		entry.Info("Deleted expired UAVMetrics")
+		deleted++
+	}
+	return deleted
+}
+
+// sweepSnapshots deletes a fleet's UAVMetricsHistory snapshots taken longer
+// ago than policy allows. Snapshots are listed page by page from their
+// metadata, so a sweep never holds the fleet's snapshot specs.
+func (j *Janitor) sweepSnapshots(ctx context.Context, fleet string, policy config.RetentionPolicy, now time.Time) int {
+	deleted := 0
+	err := j.client.WalkSnapshotMetadata(ctx, fleet, func(snapshots []*models.UAVMetricsSnapshot) error {
+		for _, snapshot := range snapshots {
+			// Without a timestamp the age is unknown, so keep the snapshot
+			if snapshot.Timestamp.IsZero() || !policy.Expired(snapshot.Timestamp, now) {
+				continue
+			}
+			if err := j.client.DeleteSnapshotIn(ctx, fleet, snapshot); err != nil {
+				if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+					continue
+				}
+				j.log.WithError(err).WithFields(logrus.Fields{
+					"fleet":    fleet,
+					"snapshot": snapshot.Name,
+				}).Warn("Failed to delete expired UAVMetrics snapshot")
+				continue
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		j.log.WithError(err).WithField("fleet", fleet).Warn("Failed to list UAVMetrics snapshots for retention")
+	}
+	if deleted > 0 {
+		j.log.WithFields(logrus.Fields{
+			"fleet":   fleet,
+			"deleted": deleted,
+			"maxAge":  policy.MaxAge,
+		}).Info("Deleted expired UAVMetrics snapshots")
+	}
+	return deleted
+}
+
+func (j *Janitor) fleets() []string {
+	fleets := []string{j.cfg.Kubernetes.Namespace}
+	for _, fleet := range j.cfg.Retention.Fleets() {
+		if fleet != j.cfg.Kubernetes.Namespace {
+			fleets = append(fleets, fleet)
+		}
+	}
+	return fleets
+}
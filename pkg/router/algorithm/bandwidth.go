@@ -0,0 +1,126 @@
+package algorithm
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// BandwidthRouter 基于链路带宽的路由算法
+// 两架 UAV 之间的直连带宽由传播模型按斜距估算；任一端有实测带宽
+// （带宽探测）时，链路带宽不超过实测值。带宽越高权重越高
+type BandwidthRouter struct {
+	// Model 无线链路传播模型
+	Model *RadioModel
+
+	// MinBandwidth 最低可接受链路带宽（Mbps），低于此值的节点将被过滤
+	MinBandwidth float64
+}
+
+// NewBandwidthRouter 创建基于链路带宽的路由算法实例
+func NewBandwidthRouter(model *RadioModel, minBandwidth float64) *BandwidthRouter {
+	if minBandwidth < 0 {
+		minBandwidth = 0
+	}
+	return &BandwidthRouter{
+		Model:        model,
+		MinBandwidth: minBandwidth,
+	}
+}
+
+// Name 返回算法名称
+func (r *BandwidthRouter) Name() string {
+	return "bandwidth"
+}
+
+// LinkBandwidth 估算两个节点之间的链路带宽（Mbps），返回值的说明用于日志
+func (r *BandwidthRouter) LinkBandwidth(source, target *models.UAVMetrics) (float64, string) {
+	distance := SlantDistance(&source.GPS, &target.GPS)
+	bandwidth, snr := r.Model.EstimateBandwidth(distance)
+	reason := fmt.Sprintf("modelled %.1fMbps over %.2fkm (SNR %.1fdB)", bandwidth, distance, snr)
+
+	for _, m := range []*models.UAVMetrics{source, target} {
+		if m.Network != nil && m.Network.BandwidthMeasured && m.Network.Bandwidth < bandwidth {
+			bandwidth = m.Network.Bandwidth
+			reason = fmt.Sprintf("measured %.1fMbps at %s, modelled link %.2fkm (SNR %.1fdB)",
+				bandwidth, m.NodeName, distance, snr)
+		}
+	}
+	return bandwidth, reason
+}
+
+// ComputeWeights 计算基于链路带宽的路由权重
+func (r *BandwidthRouter) ComputeWeights(
+	ctx context.Context,
+	sourceNode string,
+	sourceMetrics *models.UAVMetrics,
+	targetEndpoints []Endpoint,
+	targetMetrics map[string]*models.UAVMetrics,
+) ([]EndpointWeight, error) {
+
+	if sourceMetrics == nil {
+		return nil, fmt.Errorf("source metrics is nil for node %s", sourceNode)
+	}
+
+	type candidate struct {
+		ep        Endpoint
+		bandwidth float64
+		reason    string
+	}
+	candidates := make([]candidate, 0, len(targetEndpoints))
+	best := 0.0
+
+	for _, ep := range targetEndpoints {
+		targetM, exists := targetMetrics[ep.NodeName]
+		if !exists {
+			continue
+		}
+
+		// 本节点上的 endpoint 不经过无线链路
+		if ep.NodeName == sourceNode {
+			candidates = append(candidates, candidate{ep: ep, bandwidth: math.Inf(1), reason: "local node"})
+			continue
+		}
+
+		// 位置不可信时无法估算链路，给最低权重但不剔除
+		if sourceMetrics.GPS.PositionDegraded() || targetM.GPS.PositionDegraded() {
+			candidates = append(candidates, candidate{ep: ep, reason: "GNSS position degraded, link bandwidth unknown"})
+			continue
+		}
+
+		bandwidth, reason := r.LinkBandwidth(sourceMetrics, targetM)
+		if bandwidth <= 0 || bandwidth < r.MinBandwidth {
+			continue
+		}
+		if bandwidth > best {
+			best = bandwidth
+		}
+		candidates = append(candidates, candidate{ep: ep, bandwidth: bandwidth, reason: reason})
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no eligible endpoints with link bandwidth >= %.1f Mbps", r.MinBandwidth)
+	}
+
+	// 权重按相对最佳链路的带宽比例分配
+	weights := make([]EndpointWeight, 0, len(candidates))
+	for _, c := range candidates {
+		weight := 1.0
+		switch {
+		case math.IsInf(c.bandwidth, 1):
+			weight = 100
+		case best > 0:
+			weight = 100 * c.bandwidth / best
+		}
+		weights = append(weights, EndpointWeight{
+			Endpoint: c.ep,
+			Weight:   clampWeight(weight),
+			Priority: 0,
+			Reason:   c.reason,
+		})
+	}
+
+	return weights, nil
+}
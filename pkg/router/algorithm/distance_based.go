@@ -53,6 +53,17 @@ func (r *DistanceBasedRouter) ComputeWeights(
 			continue
 		}
 
+		// 任一端位置未通过 GNSS 完整性检查时距离不可信，给最低权重但不剔除
+		if sourceMetrics.GPS.PositionDegraded() || targetM.GPS.PositionDegraded() {
+			weights = append(weights, EndpointWeight{
+				Endpoint: ep,
+				Weight:   1,
+				Priority: 0,
+				Reason:   "GNSS position degraded, distance untrusted",
+			})
+			continue
+		}
+
 		// 计算两点之间的地理距离
 		distance := CalculateDistance(
 			sourceMetrics.GPS.Latitude,
@@ -2,6 +2,7 @@ package algorithm
 
 import (
 	"context"
+	"net"
 
 	"github.com/k3suav/uav-monitor/pkg/models"
 )
@@ -35,6 +36,31 @@ type Endpoint struct {
 	Namespace string // Pod 命名空间
 	Service   string // 所属服务名
 	Port      int32  // 服务端口
+
+	IPFamily    string // PodIP 的地址族（IPFamilyIPv4 或 IPFamilyIPv6）
+	HostNetwork bool   // Pod 使用主机网络，PodIP 即节点地址
+
+	// PodIP 不是 Endpoints 列出的主地址族地址，而是双栈 Pod 的其他地址，
+	// 只在按地址族查询时参与路由，避免双栈 Pod 按地址数加倍权重
+	Secondary bool
+}
+
+// 地址族，取值与 Kubernetes 的 IPFamily 相同
+const (
+	IPFamilyIPv4 = "IPv4"
+	IPFamilyIPv6 = "IPv6"
+)
+
+// IPFamilyOf 返回 IP 地址的地址族，无法解析时返回空字符串
+func IPFamilyOf(ip string) string {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return ""
+	case parsed.To4() != nil:
+		return IPFamilyIPv4
+	}
+	return IPFamilyIPv6
 }
 
 // EndpointWeight 表示 endpoint 的路由权重
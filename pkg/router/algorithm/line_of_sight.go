@@ -0,0 +1,161 @@
+package algorithm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/terrain"
+)
+
+// LineOfSightRouter 基于地形视距的路由算法
+// 定向无线链路需要收发两端通视：被地形遮挡的 endpoint 降到次优先级，
+// 仅在没有通视 endpoint 时使用；通视的 endpoint 按距离和净空分配权重
+type LineOfSightRouter struct {
+	// DEM 离线地形高程
+	DEM *terrain.DEM
+
+	// MaxDistance 最大可接受距离（公里），超过此距离的节点将被过滤
+	MaxDistance float64
+
+	// Clearance 视线与地形之间要求的最小净空（米）
+	Clearance float64
+}
+
+// NewLineOfSightRouter 创建基于视距的路由算法实例
+func NewLineOfSightRouter(dem *terrain.DEM, maxDistance, clearance float64) *LineOfSightRouter {
+	if maxDistance <= 0 {
+		maxDistance = 50.0 // 默认最大 50 公里，超出一般无线链路作用距离
+	}
+	if clearance < 0 {
+		clearance = 0
+	}
+	return &LineOfSightRouter{
+		DEM:         dem,
+		MaxDistance: maxDistance,
+		Clearance:   clearance,
+	}
+}
+
+// Name 返回算法名称
+func (r *LineOfSightRouter) Name() string {
+	return "line-of-sight"
+}
+
+// ComputeWeights 计算基于视距的路由权重
+func (r *LineOfSightRouter) ComputeWeights(
+	ctx context.Context,
+	sourceNode string,
+	sourceMetrics *models.UAVMetrics,
+	targetEndpoints []Endpoint,
+	targetMetrics map[string]*models.UAVMetrics,
+) ([]EndpointWeight, error) {
+
+	if sourceMetrics == nil {
+		return nil, fmt.Errorf("source metrics is nil for node %s", sourceNode)
+	}
+
+	weights := make([]EndpointWeight, 0, len(targetEndpoints))
+
+	for _, ep := range targetEndpoints {
+		targetM, exists := targetMetrics[ep.NodeName]
+		if !exists {
+			continue
+		}
+
+		// 位置不可信时视距分析无意义
+		if sourceMetrics.GPS.PositionDegraded() || targetM.GPS.PositionDegraded() {
+			weights = append(weights, EndpointWeight{
+				Endpoint: ep,
+				Weight:   1,
+				Priority: 1,
+				Reason:   "GNSS position degraded, line of sight unknown",
+			})
+			continue
+		}
+
+		// 同一节点上的 endpoint 不经过无线链路
+		if ep.NodeName == sourceNode {
+			weights = append(weights, EndpointWeight{
+				Endpoint: ep,
+				Weight:   100,
+				Priority: 0,
+				Reason:   "local node",
+			})
+			continue
+		}
+
+		distance := CalculateDistance(
+			sourceMetrics.GPS.Latitude,
+			sourceMetrics.GPS.Longitude,
+			targetM.GPS.Latitude,
+			targetM.GPS.Longitude,
+		)
+		if distance > r.MaxDistance {
+			continue
+		}
+
+		// 距离越近权重越高，衰减尺度为最大距离的 1/3
+		distanceWeight := 100.0 * math.Exp(-distance*3/r.MaxDistance)
+
+		// 高度优先使用气压融合高度，近地面时 GNSS 高度误差较大
+		los, err := r.DEM.LineOfSight(
+			sourceMetrics.GPS.Latitude, sourceMetrics.GPS.Longitude, sourceMetrics.AltitudeMSL(),
+			targetM.GPS.Latitude, targetM.GPS.Longitude, targetM.AltitudeMSL(),
+			r.Clearance,
+		)
+		if err != nil {
+			if !errors.Is(err, terrain.ErrNoData) {
+				return nil, fmt.Errorf("line of sight to %s: %w", ep.NodeName, err)
+			}
+			// 无地形数据：视距未知，按距离权重减半处理
+			weights = append(weights, EndpointWeight{
+				Endpoint: ep,
+				Weight:   clampWeight(distanceWeight / 2),
+				Priority: 0,
+				Reason:   fmt.Sprintf("distance: %.2fkm, no terrain data", distance),
+			})
+			continue
+		}
+
+		if !los.Clear {
+			// 组合算法只看权重，遮挡时同时压低权重
+			weights = append(weights, EndpointWeight{
+				Endpoint: ep,
+				Weight:   clampWeight(distanceWeight / 10),
+				Priority: 1,
+				Reason: fmt.Sprintf("distance: %.2fkm, blocked by terrain at (%.5f, %.5f), clearance %.0fm",
+					distance, los.ObstructionLat, los.ObstructionLon, los.MinClearance),
+			})
+			continue
+		}
+
+		// 净空越大越不易受多径和遮挡影响，最多加成 20%
+		clearanceBonus := 1 + 0.2*math.Min(1, (los.MinClearance-r.Clearance)/100)
+		weights = append(weights, EndpointWeight{
+			Endpoint: ep,
+			Weight:   clampWeight(distanceWeight * clearanceBonus),
+			Priority: 0,
+			Reason:   fmt.Sprintf("distance: %.2fkm, line of sight, clearance %.0fm", distance, los.MinClearance),
+		})
+	}
+
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("no eligible endpoints found within %.2f km", r.MaxDistance)
+	}
+
+	return weights, nil
+}
+
+// clampWeight 将权重限制在 1-100 范围内
+func clampWeight(weight float64) int {
+	if weight > 100 {
+		return 100
+	}
+	if weight < 1 {
+		return 1
+	}
+	return int(weight)
+}
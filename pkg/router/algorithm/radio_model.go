@@ -0,0 +1,81 @@
+package algorithm
+
+import (
+	"math"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// RadioModel 无线链路传播模型
+// 按自由空间路径损耗估算接收功率和信噪比，再由香农容量折算可达带宽，
+// 用于没有实测链路带宽时估计两架 UAV 之间的直连带宽
+type RadioModel struct {
+	FrequencyMHz  float64 // 载波频率（MHz）
+	TxPowerDBm    float64 // 发射功率（dBm）
+	TxGainDBi     float64 // 发射天线增益（dBi）
+	RxGainDBi     float64 // 接收天线增益（dBi）
+	ChannelMHz    float64 // 信道带宽（MHz）
+	NoiseFigureDB float64 // 接收机噪声系数（dB）
+	LossMarginDB  float64 // 额外损耗余量（dB），覆盖线缆、极化失配和衰落
+	MinSNRDB      float64 // 可建立链路的最低信噪比（dB），低于此值视为断链
+	MaxRateMbps   float64 // 电台的最高物理速率（Mbps），0 表示不限
+	Efficiency    float64 // 实际吞吐与香农容量之比
+}
+
+// NewRadioModel 创建传播模型，未设置的参数使用常见 5.8GHz 图传电台的典型值
+func NewRadioModel(frequencyMHz, txPowerDBm, txGainDBi, rxGainDBi, channelMHz float64) *RadioModel {
+	if frequencyMHz <= 0 {
+		frequencyMHz = 5800
+	}
+	if channelMHz <= 0 {
+		channelMHz = 20
+	}
+	return &RadioModel{
+		FrequencyMHz:  frequencyMHz,
+		TxPowerDBm:    txPowerDBm,
+		TxGainDBi:     txGainDBi,
+		RxGainDBi:     rxGainDBi,
+		ChannelMHz:    channelMHz,
+		NoiseFigureDB: 6,
+		LossMarginDB:  6,
+		MinSNRDB:      3,
+		Efficiency:    0.6,
+	}
+}
+
+// PathLoss 自由空间路径损耗（dB），distanceKm 为链路斜距
+func (m *RadioModel) PathLoss(distanceKm float64) float64 {
+	// 过近时按 1 米计算，避免对数发散
+	if distanceKm < 0.001 {
+		distanceKm = 0.001
+	}
+	return 20*math.Log10(distanceKm) + 20*math.Log10(m.FrequencyMHz) + 32.44
+}
+
+// SNR 估算链路信噪比（dB）
+func (m *RadioModel) SNR(distanceKm float64) float64 {
+	rxPower := m.TxPowerDBm + m.TxGainDBi + m.RxGainDBi - m.PathLoss(distanceKm) - m.LossMarginDB
+	// 热噪声：-174 dBm/Hz + 10log10(带宽) + 噪声系数
+	noise := -174 + 10*math.Log10(m.ChannelMHz*1e6) + m.NoiseFigureDB
+	return rxPower - noise
+}
+
+// EstimateBandwidth 估算链路可达带宽（Mbps）及信噪比（dB），断链时带宽为 0
+func (m *RadioModel) EstimateBandwidth(distanceKm float64) (float64, float64) {
+	snr := m.SNR(distanceKm)
+	if snr < m.MinSNRDB {
+		return 0, snr
+	}
+	capacity := m.ChannelMHz * math.Log2(1+math.Pow(10, snr/10)) * m.Efficiency
+	if m.MaxRateMbps > 0 && capacity > m.MaxRateMbps {
+		capacity = m.MaxRateMbps
+	}
+	return capacity, snr
+}
+
+// SlantDistance 计算两个 GPS 位置之间考虑高度差的斜距（公里）
+func SlantDistance(a, b *models.GPSData) float64 {
+	ground := CalculateDistance(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+	height := (a.Altitude - b.Altitude) / 1000
+	return math.Hypot(ground, height)
+}
@@ -0,0 +1,139 @@
+package router
+
+import (
+	"context"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/router/algorithm"
+)
+
+// maxCompareIterations 单次对比每个算法的最大重复计算次数
+const maxCompareIterations = 1000
+
+// AlgorithmComparison 单个路由算法在同一快照上的计算结果
+type AlgorithmComparison struct {
+	Algorithm string `json:"algorithm"`
+	Active    bool   `json:"active"` // 是否为当前使用的算法
+	Error     string `json:"error,omitempty"`
+
+	Weights []algorithm.EndpointWeight `json:"weights,omitempty"`
+
+	// 首选 endpoint（优先级最高、权重最大）及其所在节点距本节点的距离（km，-1 表示未知）
+	PreferredPod        string  `json:"preferredPod,omitempty"`
+	PreferredDistanceKm float64 `json:"preferredDistanceKm"`
+
+	// 未分配流量（权重为 0 或未返回）的 endpoint 数
+	ExcludedEndpoints int `json:"excludedEndpoints"`
+
+	// 计算耗时（微秒），多次计算时为平均、最小和最大值
+	MeanDurationUs float64 `json:"meanDurationUs"`
+	MinDurationUs  float64 `json:"minDurationUs"`
+	MaxDurationUs  float64 `json:"maxDurationUs"`
+}
+
+// RoutingComparison 所有候选算法在同一快照上的对比结果
+type RoutingComparison struct {
+	Service    string                `json:"service"`
+	Node       string                `json:"node"`
+	Endpoints  int                   `json:"endpoints"`
+	Iterations int                   `json:"iterations"`
+	Algorithms []AlgorithmComparison `json:"algorithms"`
+
+	// 计算所用缓存快照的版本
+	SnapshotVersion uint64 `json:"snapshotVersion"`
+}
+
+// SetComparisonAlgorithms 设置 /route/compare 对比的候选算法，需在 Start 前调用
+func (r *RouterAgent) SetComparisonAlgorithms(algorithms []algorithm.RoutingAlgorithm) {
+	r.candidates = algorithms
+}
+
+// CompareRouting 使用当前算法和全部候选算法，在同一份缓存快照上计算服务的路由权重，
+// 每个算法重复计算 iterations 次以统计耗时。family 非空时只对比该地址族的 endpoint，为空时只对比主地址族的。
+// 结果不叠加权重覆盖，也不计入路由决策统计
+func (r *RouterAgent) CompareRouting(ctx context.Context, serviceName, family string, iterations int) (*RoutingComparison, error) {
+	input, version, err := r.routingSnapshot(serviceName, family)
+	if err != nil {
+		return nil, err
+	}
+	sourceMetrics, endpoints, targetMetrics := input.source, input.endpoints, input.targets
+	if iterations < 1 {
+		iterations = 1
+	}
+	if iterations > maxCompareIterations {
+		iterations = maxCompareIterations
+	}
+
+	// 当前算法排在最前，候选算法中的同名算法不再重复计算
+	algorithms := []algorithm.RoutingAlgorithm{r.algorithm}
+	for _, candidate := range r.candidates {
+		if candidate.Name() != r.algorithm.Name() {
+			algorithms = append(algorithms, candidate)
+		}
+	}
+
+	result := &RoutingComparison{
+		Service:    serviceName,
+		Node:       r.nodeName,
+		Endpoints:  len(endpoints),
+		Iterations: iterations,
+
+		SnapshotVersion: version,
+	}
+	for _, algo := range algorithms {
+		comparison := AlgorithmComparison{
+			Algorithm:           algo.Name(),
+			Active:              algo == r.algorithm,
+			PreferredDistanceKm: -1,
+		}
+
+		var total, min, max time.Duration
+		runs := 0
+		for i := 0; i < iterations; i++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			start := time.Now()
+			weights, err := algo.ComputeWeights(ctx, r.nodeName, sourceMetrics, endpoints, targetMetrics)
+			duration := time.Since(start)
+
+			total += duration
+			runs++
+			if i == 0 || duration < min {
+				min = duration
+			}
+			if duration > max {
+				max = duration
+			}
+			if err != nil {
+				comparison.Error = err.Error()
+				comparison.Weights = nil
+				break
+			}
+			comparison.Weights = weights
+		}
+		comparison.MeanDurationUs = microseconds(total) / float64(runs)
+		comparison.MinDurationUs = microseconds(min)
+		comparison.MaxDurationUs = microseconds(max)
+
+		if comparison.Error == "" {
+			if best := preferredEndpoint(comparison.Weights); best != nil {
+				comparison.PreferredPod = best.Endpoint.PodName
+				comparison.PreferredDistanceKm = preferredDistance(sourceMetrics, comparison.Weights, targetMetrics)
+			}
+			routed := 0
+			for _, w := range comparison.Weights {
+				if w.Weight > 0 {
+					routed++
+				}
+			}
+			comparison.ExcludedEndpoints = len(endpoints) - routed
+		}
+		result.Algorithms = append(result.Algorithms, comparison)
+	}
+	return result, nil
+}
+
+func microseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
@@ -0,0 +1,150 @@
+package router
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/router/algorithm"
+)
+
+// topServicesLimit 状态中最多列出的服务数
+const topServicesLimit = 5
+
+// decisionStats 统计一个上报周期内的路由决策
+type decisionStats struct {
+	mu    sync.Mutex
+	since time.Time
+	decisionCounts
+}
+
+// decisionCounts 一段时间内的决策计数
+type decisionCounts struct {
+	decisions     int64
+	failures      int64
+	services      map[string]int64
+	distanceSum   float64 // km
+	distanceCount int64
+}
+
+// decisionPeriod 是 Snapshot 时的周期，上报成功后交给 EndPeriod
+type decisionPeriod struct {
+	end    time.Time
+	counts decisionCounts
+}
+
+func newDecisionStats() *decisionStats {
+	return &decisionStats{
+		since:          time.Now(),
+		decisionCounts: decisionCounts{services: make(map[string]int64)},
+	}
+}
+
+// Record 记录一次路由决策；distanceKm < 0 表示缺少两端 GPS 位置
+func (s *decisionStats) Record(service string, ok bool, distanceKm float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !ok {
+		s.failures++
+		return
+	}
+	s.decisions++
+	s.services[service]++
+	if distanceKm >= 0 {
+		s.distanceSum += distanceKm
+		s.distanceCount++
+	}
+}
+
+// Snapshot 返回当前周期的统计，以及结束该周期时传给 EndPeriod 的 decisionPeriod
+func (s *decisionStats) Snapshot(algorithmName string) (*models.RoutingStats, decisionPeriod) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	window := now.Sub(s.since).Seconds()
+	stats := &models.RoutingStats{
+		Algorithm:     algorithmName,
+		WindowSeconds: window,
+		Decisions:     s.decisions,
+		Failures:      s.failures,
+		UpdatedAt:     now,
+	}
+	if window > 0 {
+		stats.DecisionsPerSecond = float64(s.decisions) / window
+	}
+	if s.distanceCount > 0 {
+		avg := s.distanceSum / float64(s.distanceCount)
+		stats.AverageDistanceKm = &avg
+	}
+	for service, n := range s.services {
+		stats.TopServices = append(stats.TopServices, models.ServiceDecisions{Service: service, Decisions: n})
+	}
+	sort.Slice(stats.TopServices, func(i, j int) bool {
+		a, b := stats.TopServices[i], stats.TopServices[j]
+		if a.Decisions != b.Decisions {
+			return a.Decisions > b.Decisions
+		}
+		return a.Service < b.Service
+	})
+	if len(stats.TopServices) > topServicesLimit {
+		stats.TopServices = stats.TopServices[:topServicesLimit]
+	}
+
+	period := decisionPeriod{end: now, counts: s.decisionCounts}
+	period.counts.services = make(map[string]int64, len(s.services))
+	for service, n := range s.services {
+		period.counts.services[service] = n
+	}
+	return stats, period
+}
+
+// EndPeriod 在统计上报成功后结束 period：扣除其中的决策并开始新的周期，
+// Snapshot 之后记录的决策计入新的周期；上报失败时不调用，统计留到下次上报
+func (s *decisionStats) EndPeriod(period decisionPeriod) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.since = period.end
+	s.decisions -= period.counts.decisions
+	s.failures -= period.counts.failures
+	for service, n := range period.counts.services {
+		if s.services[service] -= n; s.services[service] <= 0 {
+			delete(s.services, service)
+		}
+	}
+	s.distanceSum -= period.counts.distanceSum
+	s.distanceCount -= period.counts.distanceCount
+	if s.distanceCount == 0 {
+		// 避免浮点误差累积
+		s.distanceSum = 0
+	}
+}
+
+// preferredDistance 返回源节点到首选 endpoint（优先级最高、权重最大）所在节点的距离（km），
+// 缺少任一端 GPS 位置时返回 -1
+func preferredDistance(source *models.UAVMetrics, weights []algorithm.EndpointWeight, targets map[string]*models.UAVMetrics) float64 {
+	best := preferredEndpoint(weights)
+	if best == nil {
+		return -1
+	}
+	target := targets[best.Endpoint.NodeName]
+	if target == nil || source.GPS.LastUpdate.IsZero() || target.GPS.LastUpdate.IsZero() {
+		return -1
+	}
+	return algorithm.CalculateDistance(source.GPS.Latitude, source.GPS.Longitude, target.GPS.Latitude, target.GPS.Longitude)
+}
+
+// preferredEndpoint 返回优先级最高、权重最大的 endpoint，weights 为空时返回 nil
+func preferredEndpoint(weights []algorithm.EndpointWeight) *algorithm.EndpointWeight {
+	var best *algorithm.EndpointWeight
+	for i := range weights {
+		w := &weights[i]
+		if best == nil || w.Priority < best.Priority || (w.Priority == best.Priority && w.Weight > best.Weight) {
+			best = w
+		}
+	}
+	return best
+}
@@ -0,0 +1,446 @@
+package router
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PeerState 对端节点的存活状态
+type PeerState string
+
+const (
+	PeerAlive   PeerState = "alive"   // 心跳正常
+	PeerSuspect PeerState = "suspect" // 心跳超过失效时间的一半未更新
+	PeerFailed  PeerState = "failed"  // 心跳超过失效时间未更新
+	PeerLeft    PeerState = "left"    // 主动退出（如滚动升级），不视为故障
+)
+
+const (
+	// gossipFanout 每轮发送的对端数量
+	gossipFanout = 3
+
+	// gossipSeedRounds 每隔多少轮向种子地址发送一次，用于发现新节点和故障恢复的节点
+	gossipSeedRounds = 10
+
+	// gossipReapFactor 故障或退出的成员在 失效时间 × 此系数 后从成员表删除
+	gossipReapFactor = 30
+
+	// gossipMaxPacket 单个 gossip 报文的最大长度
+	gossipMaxPacket = 64 * 1024
+
+	// gossipMACSize 报文头部 HMAC-SHA256 的长度
+	gossipMACSize = sha256.Size
+)
+
+// Peer 对端节点的存活信息
+type Peer struct {
+	Name     string    `json:"name"`
+	Addr     string    `json:"addr"`
+	State    PeerState `json:"state"`
+	LastSeen time.Time `json:"lastSeen"` // 最近一次心跳更新的本地时间
+}
+
+// PeerEvent 对端节点状态变化
+type PeerEvent struct {
+	Name     string    `json:"name"`
+	State    PeerState `json:"state"`
+	Previous PeerState `json:"previous,omitempty"` // 新加入的节点为空
+}
+
+// gossipMember 成员表中的一项
+type gossipMember struct {
+	addr        string
+	incarnation int64  // 进程启动时间，区分重启前后的心跳计数
+	heartbeat   uint64 // 每轮自增的心跳计数
+	updated     time.Time
+	state       PeerState
+}
+
+// gossipDigest 报文中的一条成员心跳
+type gossipDigest struct {
+	Name        string `json:"name"`
+	Addr        string `json:"addr"`
+	Incarnation int64  `json:"incarnation"`
+	Heartbeat   uint64 `json:"heartbeat"`
+}
+
+// gossipMessage gossip 报文，发送时在 JSON 前加上以共享密钥计算的 HMAC-SHA256
+type gossipMessage struct {
+	From    string         `json:"from"`
+	Leave   bool           `json:"leave,omitempty"` // 发送方正在退出
+	Members []gossipDigest `json:"members"`
+}
+
+// Gossip 基于心跳 gossip 的节点存活检测
+// 各节点的 Router Agent 组成对等网络，每轮自增本节点心跳，并把已知成员的心跳
+// 通过 UDP 发给随机几个对端。心跳计数在网络中按对数轮数扩散，某节点心跳
+// 超过失效时间未增长即判定为故障，检测时间为 1-2 秒，远快于 UAVMetrics
+// 上报超时。进程正常退出时广播 leave，避免滚动升级被误判为故障
+// 报文以共享密钥签名，丢弃签名不符的报文，防止伪造 leave 或更高 incarnation 剔除对端
+type Gossip struct {
+	name        string
+	key         []byte // HMAC 共享密钥
+	bindAddr    string
+	advertise   string   // 对端访问本节点的地址
+	seeds       []string // 种子地址（host:port），如 Router 的 headless Service
+	interval    time.Duration
+	failTimeout time.Duration
+	log         *logrus.Logger
+
+	incarnation int64
+	rand        *rand.Rand
+
+	mu        sync.Mutex
+	heartbeat uint64
+	members   map[string]*gossipMember // key: node name，不含本节点
+	events    chan PeerEvent
+}
+
+// NewGossip 创建 gossip 存活检测
+// failTimeout 为判定故障的心跳超时，应为 interval 的数倍，留出扩散时间
+// key 为所有节点共享的 HMAC 密钥，不能为空
+func NewGossip(nodeName string, key []byte, bindAddr, advertise string, seeds []string, interval, failTimeout time.Duration, log *logrus.Logger) *Gossip {
+	if interval <= 0 {
+		interval = 250 * time.Millisecond
+	}
+	if failTimeout <= interval {
+		failTimeout = 8 * interval
+	}
+	now := time.Now()
+	return &Gossip{
+		name:        nodeName,
+		key:         key,
+		bindAddr:    bindAddr,
+		advertise:   advertise,
+		seeds:       seeds,
+		interval:    interval,
+		failTimeout: failTimeout,
+		log:         log,
+		incarnation: now.UnixNano(),
+		rand:        rand.New(rand.NewSource(now.UnixNano())),
+		members:     make(map[string]*gossipMember),
+		events:      make(chan PeerEvent, 64),
+	}
+}
+
+// Events 返回对端状态变化通知
+func (g *Gossip) Events() <-chan PeerEvent {
+	return g.events
+}
+
+// Run 运行 gossip 直到 ctx 取消，退出前向对端广播 leave
+func (g *Gossip) Run(ctx context.Context) error {
+	conn, err := net.ListenPacket("udp", g.bindAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for gossip on %s: %w", g.bindAddr, err)
+	}
+	defer conn.Close()
+
+	go g.receive(conn)
+
+	g.log.WithFields(logrus.Fields{
+		"bind":        g.bindAddr,
+		"advertise":   g.advertise,
+		"seeds":       g.seeds,
+		"failTimeout": g.failTimeout,
+	}).Info("Starting gossip peer liveness")
+
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+	for round := 0; ; round++ {
+		select {
+		case <-ctx.Done():
+			g.leave(conn)
+			return nil
+		case <-ticker.C:
+		}
+
+		g.expire(time.Now())
+
+		g.mu.Lock()
+		g.heartbeat++
+		msg := g.messageLocked(false)
+		targets := g.targetsLocked()
+		g.mu.Unlock()
+
+		// 成员较少时（刚启动或大量节点故障）每轮都联系种子地址
+		if round%gossipSeedRounds == 0 || len(targets) < gossipFanout {
+			targets = append(targets, g.resolveSeeds()...)
+		}
+		g.send(conn, msg, targets)
+	}
+}
+
+// leave 通知所有存活的对端本节点正在退出
+func (g *Gossip) leave(conn net.PacketConn) {
+	g.mu.Lock()
+	msg := g.messageLocked(true)
+	targets := make([]string, 0, len(g.members))
+	for _, m := range g.members {
+		if m.state == PeerAlive || m.state == PeerSuspect {
+			targets = append(targets, m.addr)
+		}
+	}
+	g.mu.Unlock()
+	g.send(conn, msg, targets)
+}
+
+// messageLocked 构造本轮报文，只携带未故障成员的心跳。调用方需持有 g.mu
+func (g *Gossip) messageLocked(leave bool) gossipMessage {
+	msg := gossipMessage{
+		From:  g.name,
+		Leave: leave,
+		Members: []gossipDigest{{
+			Name:        g.name,
+			Addr:        g.advertise,
+			Incarnation: g.incarnation,
+			Heartbeat:   g.heartbeat,
+		}},
+	}
+	if leave {
+		return msg
+	}
+	for name, m := range g.members {
+		if m.state != PeerAlive && m.state != PeerSuspect {
+			continue
+		}
+		msg.Members = append(msg.Members, gossipDigest{
+			Name:        name,
+			Addr:        m.addr,
+			Incarnation: m.incarnation,
+			Heartbeat:   m.heartbeat,
+		})
+	}
+	return msg
+}
+
+// targetsLocked 随机选择本轮的发送对象。调用方需持有 g.mu
+func (g *Gossip) targetsLocked() []string {
+	candidates := make([]string, 0, len(g.members))
+	for _, m := range g.members {
+		if m.state == PeerAlive || m.state == PeerSuspect {
+			candidates = append(candidates, m.addr)
+		}
+	}
+	g.rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > gossipFanout {
+		candidates = candidates[:gossipFanout]
+	}
+	return candidates
+}
+
+// resolveSeeds 解析种子地址，headless Service 解析为全部节点地址
+func (g *Gossip) resolveSeeds() []string {
+	var addrs []string
+	for _, seed := range g.seeds {
+		host, port, err := net.SplitHostPort(seed)
+		if err != nil {
+			continue
+		}
+		hosts, err := net.LookupHost(host)
+		if err != nil {
+			g.log.WithError(err).WithField("seed", seed).Debug("Failed to resolve gossip seed")
+			continue
+		}
+		for _, h := range hosts {
+			addr := net.JoinHostPort(h, port)
+			if addr != g.advertise {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
+
+// sign 计算报文的 HMAC
+func (g *Gossip) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, g.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func (g *Gossip) send(conn net.PacketConn, msg gossipMessage, targets []string) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	data := append(g.sign(payload), payload...)
+	sent := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		if sent[target] {
+			continue
+		}
+		sent[target] = true
+		addr, err := net.ResolveUDPAddr("udp", target)
+		if err != nil {
+			continue
+		}
+		// 发送失败（如链路中断）不处理，由心跳超时体现
+		conn.WriteTo(data, addr)
+	}
+}
+
+// receive 接收对端报文并合并成员心跳，签名不符的报文直接丢弃
+func (g *Gossip) receive(conn net.PacketConn) {
+	buf := make([]byte, gossipMaxPacket)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return // 连接已关闭
+		}
+		if n < gossipMACSize {
+			continue
+		}
+		payload := buf[gossipMACSize:n]
+		if !hmac.Equal(buf[:gossipMACSize], g.sign(payload)) {
+			g.log.WithField("from", from.String()).Debug("Dropping gossip packet with invalid signature")
+			continue
+		}
+		var msg gossipMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			continue
+		}
+		g.merge(msg, time.Now())
+	}
+}
+
+// merge 合并报文中的心跳，心跳（按 incarnation、计数比较）更新的成员视为存活
+func (g *Gossip) merge(msg gossipMessage, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, d := range msg.Members {
+		if d.Name == g.name || d.Name == "" {
+			continue
+		}
+		m, known := g.members[d.Name]
+		if !known {
+			if msg.Leave {
+				continue
+			}
+			g.members[d.Name] = &gossipMember{
+				addr:        d.Addr,
+				incarnation: d.Incarnation,
+				heartbeat:   d.Heartbeat,
+				updated:     now,
+				state:       PeerAlive,
+			}
+			g.notify(PeerEvent{Name: d.Name, State: PeerAlive})
+			continue
+		}
+
+		if msg.Leave && d.Name == msg.From {
+			if d.Incarnation >= m.incarnation {
+				g.transition(d.Name, m, PeerLeft)
+			}
+			continue
+		}
+
+		newer := d.Incarnation > m.incarnation ||
+			(d.Incarnation == m.incarnation && d.Heartbeat > m.heartbeat)
+		if !newer {
+			continue
+		}
+		// 已退出的成员只有重启（incarnation 更新）后才重新加入
+		if m.state == PeerLeft && d.Incarnation == m.incarnation {
+			continue
+		}
+		m.addr = d.Addr
+		m.incarnation = d.Incarnation
+		m.heartbeat = d.Heartbeat
+		m.updated = now
+		g.transition(d.Name, m, PeerAlive)
+	}
+}
+
+// expire 按心跳时间更新成员状态，并清理长时间故障或退出的成员
+func (g *Gossip) expire(now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for name, m := range g.members {
+		age := now.Sub(m.updated)
+		if age > g.failTimeout*gossipReapFactor && (m.state == PeerFailed || m.state == PeerLeft) {
+			delete(g.members, name)
+			continue
+		}
+		if m.state == PeerLeft {
+			continue
+		}
+		switch {
+		case age > g.failTimeout:
+			g.transition(name, m, PeerFailed)
+		case age > g.failTimeout/2:
+			g.transition(name, m, PeerSuspect)
+		}
+	}
+}
+
+// transition 更新成员状态并发出通知。调用方需持有 g.mu
+func (g *Gossip) transition(name string, m *gossipMember, state PeerState) {
+	if m.state == state {
+		return
+	}
+	previous := m.state
+	m.state = state
+	g.notify(PeerEvent{Name: name, State: state, Previous: previous})
+}
+
+// notify 发送状态变化通知，消费方处理不过来时丢弃
+func (g *Gossip) notify(event PeerEvent) {
+	select {
+	case g.events <- event:
+	default:
+		g.log.WithField("peer", event.Name).Warn("Gossip event channel full, dropping peer event")
+	}
+}
+
+// State 返回指定节点的存活状态，未知节点返回 false
+func (g *Gossip) State(nodeName string) (PeerState, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	m, ok := g.members[nodeName]
+	if !ok {
+		return "", false
+	}
+	return m.state, true
+}
+
+// Failed 判断指定节点是否已被 gossip 判定为故障
+func (g *Gossip) Failed(nodeName string) bool {
+	state, ok := g.State(nodeName)
+	return ok && state == PeerFailed
+}
+
+// Peers 返回所有已知对端，按名称排序
+func (g *Gossip) Peers() []Peer {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	peers := make([]Peer, 0, len(g.members))
+	for name, m := range g.members {
+		peers = append(peers, Peer{
+			Name:     name,
+			Addr:     m.addr,
+			State:    m.state,
+			LastSeen: m.updated,
+		})
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Name < peers[j].Name
+	})
+	return peers
+}
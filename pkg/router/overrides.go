@@ -0,0 +1,205 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/router/algorithm"
+	"github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// overrideSet 运维人员设置的 endpoint 权重覆盖（RouteOverride），
+// 叠加在算法输出之上，过期后自动失效
+type overrideSet struct {
+	mu        sync.RWMutex
+	overrides []*models.RouteOverride
+}
+
+// Set 替换全部覆盖
+func (s *overrideSet) Set(overrides []*models.RouteOverride) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides = overrides
+}
+
+// List 返回全部覆盖（含已过期的），按名称排序
+func (s *overrideSet) List() []*models.RouteOverride {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]*models.RouteOverride, len(s.overrides))
+	copy(list, s.overrides)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Apply 将服务的生效覆盖应用到算法输出的权重上。
+// 按 Pod 的覆盖优先于按节点的覆盖；多个覆盖命中同一 endpoint 时取最早过期的。
+// 固定权重 > 0 但被算法剔除的 endpoint 会重新加入（优先级 0）
+func (s *overrideSet) Apply(service string, endpoints []algorithm.Endpoint, weights []algorithm.EndpointWeight, now time.Time) []algorithm.EndpointWeight {
+	s.mu.RLock()
+	var active []*models.RouteOverride
+	for _, o := range s.overrides {
+		if o.Service == service && o.Active(now) {
+			active = append(active, o)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(active) == 0 {
+		return weights
+	}
+
+	present := make(map[string]bool, len(weights))
+	for i := range weights {
+		w := &weights[i]
+		present[w.Endpoint.PodName] = true
+		if o := matchOverride(active, w.Endpoint); o != nil {
+			w.Weight = o.Weight
+			w.Reason = overrideReason(o)
+		}
+	}
+
+	for _, ep := range endpoints {
+		if present[ep.PodName] {
+			continue
+		}
+		if o := matchOverride(active, ep); o != nil && o.Weight > 0 {
+			weights = append(weights, algorithm.EndpointWeight{
+				Endpoint: ep,
+				Weight:   o.Weight,
+				Priority: 0,
+				Reason:   overrideReason(o),
+			})
+		}
+	}
+
+	return weights
+}
+
+// matchOverride 返回命中 endpoint 的覆盖，没有时返回 nil
+func matchOverride(active []*models.RouteOverride, ep algorithm.Endpoint) *models.RouteOverride {
+	var best *models.RouteOverride
+	for _, o := range active {
+		if o.PodName != "" && o.PodName != ep.PodName {
+			continue
+		}
+		if o.PodName == "" && o.NodeName != ep.NodeName {
+			continue
+		}
+		switch {
+		case best == nil:
+			best = o
+		case (o.PodName != "") != (best.PodName != ""):
+			if o.PodName != "" {
+				best = o
+			}
+		case o.ExpiresAt.Before(best.ExpiresAt):
+			best = o
+		}
+	}
+	return best
+}
+
+func overrideReason(o *models.RouteOverride) string {
+	reason := fmt.Sprintf("override %s until %s", o.Name, o.ExpiresAt.Format(time.RFC3339))
+	if o.Reason != "" {
+		reason += ": " + o.Reason
+	}
+	return reason
+}
+
+// overrideGCDelay 过期超过该时间的 RouteOverride 由 Router 删除，
+// 在此之前仍可通过管理接口查看
+const overrideGCDelay = 10 * time.Minute
+
+// watchOverrides 监听并缓存 RouteOverride，无法监听时每 2 秒重新加载；
+// 每分钟删除一次过期的 RouteOverride
+func (r *RouterAgent) watchOverrides(ctx context.Context) {
+	var poll <-chan time.Time
+	if err := r.uavClient.WatchRouteOverrides(ctx, r.overrides.Set); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		r.log.WithError(err).Info("Not watching route overrides, polling them instead")
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	gc := time.NewTicker(time.Minute)
+	defer gc.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll:
+			r.refreshOverrides(ctx)
+		case now := <-gc.C:
+			r.collectOverrides(ctx, now)
+		}
+	}
+}
+
+// refreshOverrides 从 API Server 重新加载 RouteOverride
+func (r *RouterAgent) refreshOverrides(ctx context.Context) {
+	overrides, err := r.uavClient.ListRouteOverrides(ctx)
+	if err != nil {
+		r.log.WithError(err).Debug("Failed to list route overrides")
+		return
+	}
+	r.overrides.Set(overrides)
+}
+
+// collectOverrides 删除过期超过 overrideGCDelay 的 RouteOverride。
+// 每个节点的 Router 都会清理，已被其他节点删除的忽略
+func (r *RouterAgent) collectOverrides(ctx context.Context, now time.Time) {
+	for _, o := range r.overrides.List() {
+		if now.Sub(o.ExpiresAt) < overrideGCDelay {
+			continue
+		}
+		if err := r.uavClient.DeleteRouteOverride(ctx, o.Name); err != nil {
+			if !apierrors.IsNotFound(err) {
+				r.log.WithError(err).WithField("override", o.Name).Warn("Failed to delete expired route override")
+			}
+			continue
+		}
+		r.log.WithFields(logrus.Fields{
+			"override":  o.Name,
+			"expiresAt": o.ExpiresAt,
+		}).Info("Deleted expired route override")
+	}
+}
+
+// RouteOverrides 返回当前缓存的全部权重覆盖
+func (r *RouterAgent) RouteOverrides() []*models.RouteOverride {
+	return r.overrides.List()
+}
+
+// CreateRouteOverride 创建权重覆盖，所有节点的 Router 在下次同步时生效，本节点立即生效
+func (r *RouterAgent) CreateRouteOverride(ctx context.Context, override *models.RouteOverride) error {
+	if err := override.Validate(); err != nil {
+		return err
+	}
+	if err := r.uavClient.CreateRouteOverride(ctx, override); err != nil {
+		return err
+	}
+	r.refreshOverrides(ctx)
+	return nil
+}
+
+// DeleteRouteOverride 删除权重覆盖
+func (r *RouterAgent) DeleteRouteOverride(ctx context.Context, name string) error {
+	if err := r.uavClient.DeleteRouteOverride(ctx, name); err != nil {
+		return err
+	}
+	r.refreshOverrides(ctx)
+	return nil
+}
@@ -0,0 +1,237 @@
+package router
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/router/algorithm"
+)
+
+// GroundStation 地面站（中继链的终点），位置固定
+type GroundStation struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"` // 天线海拔高度（米）
+}
+
+// ParseGroundStations 解析 name:lat:lon[:alt] 格式的地面站列表
+func ParseGroundStations(entries []string) ([]GroundStation, error) {
+	stations := make([]GroundStation, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 3 || len(parts) > 4 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid ground station %q, expected name:lat:lon[:alt]", entry)
+		}
+		values := make([]float64, 3)
+		for i, part := range parts[1:] {
+			v, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ground station %q: %w", entry, err)
+			}
+			values[i] = v
+		}
+		stations = append(stations, GroundStation{
+			Name:      parts[0],
+			Latitude:  values[0],
+			Longitude: values[1],
+			Altitude:  values[2],
+		})
+	}
+	return stations, nil
+}
+
+// RelayHop 中继链中的一跳
+type RelayHop struct {
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	DistanceKm    float64 `json:"distanceKm"`
+	BandwidthMbps float64 `json:"bandwidthMbps"`
+	SNR           float64 `json:"snr"` // dB
+}
+
+// RelayPath 从源 UAV 到地面站的最佳中继链
+type RelayPath struct {
+	Source         string     `json:"source"`
+	Destination    string     `json:"destination"`
+	Hops           []RelayHop `json:"hops"`
+	Cost           float64    `json:"cost"`
+	BottleneckMbps float64    `json:"bottleneckMbps"` // 链路中最小的单跳带宽
+}
+
+// RelayPlanner 多跳中继路径计算
+// 把机群视为空中 mesh：节点为 UAV 和地面站，边为传播模型估算的直连链路
+// （有实测带宽时以实测值为上限），用 Dijkstra 求代价最小的中继链。
+// 单跳代价 = 跳数惩罚 + 100/带宽，既偏好高带宽链路，也避免无谓地增加跳数
+type RelayPlanner struct {
+	model          *algorithm.RadioModel
+	groundStations []GroundStation
+	minBandwidth   float64 // 单跳最低带宽（Mbps），低于此值的链路不可用
+	hopPenalty     float64
+}
+
+// NewRelayPlanner 创建中继路径计算器
+func NewRelayPlanner(model *algorithm.RadioModel, groundStations []GroundStation, minBandwidth, hopPenalty float64) *RelayPlanner {
+	if hopPenalty < 0 {
+		hopPenalty = 0
+	}
+	return &RelayPlanner{
+		model:          model,
+		groundStations: groundStations,
+		minBandwidth:   minBandwidth,
+		hopPenalty:     hopPenalty,
+	}
+}
+
+// relayNode 图中的一个节点
+type relayNode struct {
+	name     string
+	gps      models.GPSData
+	measured float64 // 实测带宽（Mbps），0 表示无实测值
+	ground   bool    // 地面站只能作为终点，不转发
+}
+
+// Plan 计算从 source 到地面站 destination 的最佳中继链
+// destination 为空时选择代价最小的地面站；relays 为可参与转发的 UAV 指标
+func (p *RelayPlanner) Plan(source, destination string, relays map[string]*models.UAVMetrics) (*RelayPath, error) {
+	if len(p.groundStations) == 0 {
+		return nil, fmt.Errorf("no ground stations configured")
+	}
+	if _, ok := relays[source]; !ok {
+		return nil, fmt.Errorf("source UAV %s not found or not eligible for relaying", source)
+	}
+
+	// 构建节点表，按名称排序保证结果稳定
+	nodes := make([]relayNode, 0, len(relays)+len(p.groundStations))
+	names := make([]string, 0, len(relays))
+	for name := range relays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		node := relayNode{name: name, gps: relays[name].GPS}
+		if network := relays[name].Network; network != nil && network.BandwidthMeasured {
+			node.measured = network.Bandwidth
+		}
+		nodes = append(nodes, node)
+	}
+	destinations := make(map[int]bool)
+	for _, gs := range p.groundStations {
+		if destination != "" && gs.Name != destination {
+			continue
+		}
+		destinations[len(nodes)] = true
+		nodes = append(nodes, relayNode{
+			name:   gs.Name,
+			gps:    models.GPSData{Latitude: gs.Latitude, Longitude: gs.Longitude, Altitude: gs.Altitude},
+			ground: true,
+		})
+	}
+	if len(destinations) == 0 {
+		return nil, fmt.Errorf("unknown ground station %s", destination)
+	}
+
+	start := sort.SearchStrings(names, source)
+	dist := make([]float64, len(nodes))
+	prev := make([]int, len(nodes))
+	hops := make([]RelayHop, len(nodes)) // 到达该节点的最后一跳
+	for i := range dist {
+		dist[i] = math.Inf(1)
+		prev[i] = -1
+	}
+	dist[start] = 0
+
+	queue := &relayQueue{{node: start}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(relayItem)
+		u := item.node
+		if item.cost > dist[u] {
+			continue // 过期的队列项
+		}
+		if destinations[u] {
+			return p.buildPath(nodes, prev, hops, start, u, dist[u]), nil
+		}
+		if nodes[u].ground {
+			continue
+		}
+
+		for v := range nodes {
+			if v == u || v == start {
+				continue
+			}
+			distance := algorithm.SlantDistance(&nodes[u].gps, &nodes[v].gps)
+			bandwidth, snr := p.model.EstimateBandwidth(distance)
+			// 有实测带宽时以实测值为上限
+			for _, measured := range []float64{nodes[u].measured, nodes[v].measured} {
+				if measured > 0 && measured < bandwidth {
+					bandwidth = measured
+				}
+			}
+			if bandwidth <= 0 || bandwidth < p.minBandwidth {
+				continue
+			}
+			cost := dist[u] + p.hopPenalty + 100/bandwidth
+			if cost < dist[v] {
+				dist[v] = cost
+				prev[v] = u
+				hops[v] = RelayHop{
+					From:          nodes[u].name,
+					To:            nodes[v].name,
+					DistanceKm:    distance,
+					BandwidthMbps: bandwidth,
+					SNR:           snr,
+				}
+				heap.Push(queue, relayItem{node: v, cost: cost})
+			}
+		}
+	}
+
+	if destination != "" {
+		return nil, fmt.Errorf("no relay path from %s to ground station %s", source, destination)
+	}
+	return nil, fmt.Errorf("no relay path from %s to any ground station", source)
+}
+
+// buildPath 沿 prev 回溯出中继链
+func (p *RelayPlanner) buildPath(nodes []relayNode, prev []int, hops []RelayHop, start, end int, cost float64) *RelayPath {
+	path := &RelayPath{
+		Source:         nodes[start].name,
+		Destination:    nodes[end].name,
+		Cost:           cost,
+		BottleneckMbps: math.Inf(1),
+	}
+	for v := end; v != start; v = prev[v] {
+		path.Hops = append(path.Hops, hops[v])
+		path.BottleneckMbps = math.Min(path.BottleneckMbps, hops[v].BandwidthMbps)
+	}
+	// 回溯得到的是逆序
+	for i, j := 0, len(path.Hops)-1; i < j; i, j = i+1, j-1 {
+		path.Hops[i], path.Hops[j] = path.Hops[j], path.Hops[i]
+	}
+	return path
+}
+
+// relayItem 优先队列项
+type relayItem struct {
+	node int
+	cost float64
+}
+
+// relayQueue 按代价排序的最小堆
+type relayQueue []relayItem
+
+func (q relayQueue) Len() int            { return len(q) }
+func (q relayQueue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q relayQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *relayQueue) Push(x interface{}) { *q = append(*q, x.(relayItem)) }
+func (q *relayQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
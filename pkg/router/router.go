@@ -2,56 +2,125 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/k3suav/uav-monitor/pkg/k8s"
 	"github.com/k3suav/uav-monitor/pkg/models"
 	"github.com/k3suav/uav-monitor/pkg/router/algorithm"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
 
+// metricsDebounce UAVMetrics 事件触发缓存刷新前的等待时间，期间的后续事件合并为一次刷新
+const metricsDebounce = 100 * time.Millisecond
+
 // RouterAgent 智能路由代理
 // 在每个节点上运行，维护本地 UAV metrics 缓存，
 // 并根据可插拔算法为服务请求计算最优路由
 type RouterAgent struct {
 	nodeName      string
-	k8sClientset  *kubernetes.Clientset
-	uavClient     *k8s.Client
+	k8sClientset  kubernetes.Interface
+	uavClient     UAVClient
 	algorithm     algorithm.RoutingAlgorithm
 	log           *logrus.Logger
 
-	// 本地缓存：存储所有节点的 UAV metrics（内存中）
-	metricsCache  map[string]*models.UAVMetrics
-	metricsMutex  sync.RWMutex
+	// 本地缓存：所有节点的 UAV metrics 和所有服务的 endpoints（内存中），
+	// 以带版本的快照原子替换，publishMutex 串行化快照发布
+	cache        atomic.Pointer[cacheSnapshot]
+	publishMutex sync.Mutex
+
+	// 失联 UAV：超过 lostTimeout 未上报即判定为 Lost，保留最后已知位置供回收
+	lostTimeout time.Duration
+	lostBeacons map[string]*models.LostBeacon // key: node name
+	lostMutex   sync.RWMutex
+
+	// 失联 UAV 搜索区域估算（nil 表示不估算）
+	searchArea *SearchAreaEstimator
+
+	// 多跳中继路径计算（nil 表示未配置地面站）
+	relay *RelayPlanner
+
+	// gossip 节点存活检测（nil 表示未启用），故障节点的 endpoint 立即剔除
+	gossip *Gossip
 
-	// Endpoint 缓存：存储所有服务的 endpoints
-	endpointsCache map[string][]algorithm.Endpoint // key: service name
-	endpointsMutex sync.RWMutex
+	// 路由决策统计，按 statsInterval 写入本节点 UAVMetrics 的 status.routing（0 表示不写入）
+	decisions     *decisionStats
+	statsInterval time.Duration
+
+	// 运维人员设置的 endpoint 权重覆盖（RouteOverride CRD）
+	overrides overrideSet
+
+	// 供 /route/compare 对比的候选算法（不含当前算法时也会对比当前算法）
+	candidates []algorithm.RoutingAlgorithm
+
+	// UAV 失联和恢复时在其 UAVMetrics 上记录 Event（nil 表示不记录）
+	events *k8s.EventRecorder
 }
 
+// UAVClient Router 读写 UAV 资源所需的客户端接口
+// 由 *k8s.Client 实现；无集群运行（测试、演示）时使用内存实现 fake.UAVClient（pkg/k8s/fake）
+type UAVClient interface {
+	ListUAVMetrics(ctx context.Context) ([]*models.UAVMetrics, error)
+	WatchUAVMetrics(ctx context.Context, handler k8s.UAVMetricsHandler) error
+	MarkLost(ctx context.Context, beacon *models.LostBeacon) error
+	IsAlive(ctx context.Context, nodeName string) (bool, error)
+	UpdateRoutingStats(ctx context.Context, nodeName string, stats *models.RoutingStats) error
+	ListRouteOverrides(ctx context.Context) ([]*models.RouteOverride, error)
+	WatchRouteOverrides(ctx context.Context, handler func([]*models.RouteOverride)) error
+	CreateRouteOverride(ctx context.Context, override *models.RouteOverride) error
+	DeleteRouteOverride(ctx context.Context, name string) error
+}
+
+var _ UAVClient = (*k8s.Client)(nil)
+
 // NewRouterAgent 创建 Router Agent 实例
+// k8sClientset 可以是 fake.NewClientset()（k8s.io/client-go/kubernetes/fake）
 func NewRouterAgent(
 	nodeName string,
-	k8sClientset *kubernetes.Clientset,
-	uavClient *k8s.Client,
+	k8sClientset kubernetes.Interface,
+	uavClient UAVClient,
 	routingAlgorithm algorithm.RoutingAlgorithm,
+	lostTimeout time.Duration,
+	searchArea *SearchAreaEstimator,
+	relay *RelayPlanner,
+	gossip *Gossip,
+	statsInterval time.Duration,
 	log *logrus.Logger,
 ) *RouterAgent {
-	return &RouterAgent{
-		nodeName:       nodeName,
-		k8sClientset:   k8sClientset,
-		uavClient:      uavClient,
-		algorithm:      routingAlgorithm,
-		log:            log,
-		metricsCache:   make(map[string]*models.UAVMetrics),
-		endpointsCache: make(map[string][]algorithm.Endpoint),
+	r := &RouterAgent{
+		nodeName:      nodeName,
+		k8sClientset:  k8sClientset,
+		uavClient:     uavClient,
+		algorithm:     routingAlgorithm,
+		log:           log,
+		lostTimeout:   lostTimeout,
+		lostBeacons:   make(map[string]*models.LostBeacon),
+		searchArea:    searchArea,
+		relay:         relay,
+		gossip:        gossip,
+		decisions:     newDecisionStats(),
+		statsInterval: statsInterval,
 	}
+	r.cache.Store(&cacheSnapshot{
+		metrics:   make(map[string]*models.UAVMetrics),
+		endpoints: make(map[string][]algorithm.Endpoint),
+	})
+	return r
+}
+
+// SetEventRecorder 设置记录 UAVLost/UAVFound 事件的 recorder，需在 Start 前调用
+func (r *RouterAgent) SetEventRecorder(events *k8s.EventRecorder) {
+	r.events = events
 }
 
 // Start 启动 Router Agent
@@ -67,6 +136,19 @@ func (r *RouterAgent) Start(ctx context.Context) error {
 	// 启动 Endpoint 监听
 	go r.watchEndpoints(ctx)
 
+	// 启动权重覆盖同步
+	go r.watchOverrides(ctx)
+
+	// 处理 gossip 检测到的节点故障
+	if r.gossip != nil {
+		go r.watchPeers(ctx)
+	}
+
+	// 定期写入路由决策统计
+	if r.statsInterval > 0 {
+		go r.pushRoutingStats(ctx)
+	}
+
 	// 等待初始缓存就绪
 	if err := r.waitForCacheReady(ctx); err != nil {
 		return fmt.Errorf("cache initialization failed: %w", err)
@@ -77,34 +159,242 @@ func (r *RouterAgent) Start(ctx context.Context) error {
 }
 
 // watchUAVMetrics 监听并缓存所有节点的 UAV metrics
+// UAVMetrics 变化时由 informer 事件触发刷新（合并 metricsDebounce 内的事件），从 informer 缓存读取，不再定期 List API Server；
+// 无法监听时（如从机队快照缓存读取）退回每 2 秒 List 一次。定时器同时驱动失联检测
 func (r *RouterAgent) watchUAVMetrics(ctx context.Context) {
-	ticker := time.NewTicker(2 * time.Second) // 每 2 秒更新一次缓存
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	err := r.uavClient.WatchUAVMetrics(ctx, k8s.UAVMetricsHandler{
+		AddFunc:    func(*models.UAVMetrics) { notify() },
+		UpdateFunc: func(_, _ *models.UAVMetrics) { notify() },
+		DeleteFunc: func(*models.UAVMetrics) { notify() },
+	})
+	if err != nil {
+		r.log.WithError(err).Info("Not watching UAV metrics, polling them instead")
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-changed:
+			// 合并短时间内的连续事件，避免每个事件都重建缓存
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(metricsDebounce):
+			}
+			r.refreshMetrics(ctx)
 		case <-ticker.C:
-			metrics, err := r.uavClient.ListUAVMetrics(ctx)
-			if err != nil {
-				r.log.WithError(err).Warn("Failed to list UAV metrics")
-				continue
+			r.refreshMetrics(ctx)
+		}
+	}
+}
+
+// refreshMetrics 重建 UAV metrics 缓存并执行失联检测
+func (r *RouterAgent) refreshMetrics(ctx context.Context) {
+	metrics, err := r.uavClient.ListUAVMetrics(ctx)
+	if err != nil {
+		r.log.WithError(err).Warn("Failed to list UAV metrics")
+		return
+	}
+
+	// 更新缓存
+	cache := make(map[string]*models.UAVMetrics, len(metrics))
+	for _, m := range metrics {
+		cache[m.NodeName] = m
+	}
+	version := r.publishMetrics(cache)
+
+	r.log.WithFields(logrus.Fields{
+		"count":   len(metrics),
+		"version": version,
+	}).Debug("UAV metrics cache updated")
+
+	r.detectLost(ctx, metrics)
+}
+
+// watchPeers 处理 gossip 节点状态变化
+// 节点故障时立即执行失联检测，不必等待下一次缓存刷新和 UAVMetrics 上报超时
+func (r *RouterAgent) watchPeers(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-r.gossip.Events():
+			fields := logrus.Fields{
+				"peer":     event.Name,
+				"state":    event.State,
+				"previous": event.Previous,
+			}
+			switch event.State {
+			case PeerFailed:
+				r.log.WithFields(fields).Warn("Peer failure detected by gossip, ejecting its endpoints")
+				cache := r.snapshot().metrics
+				metrics := make([]*models.UAVMetrics, 0, len(cache))
+				for _, m := range cache {
+					metrics = append(metrics, m)
+				}
+				r.detectLost(ctx, metrics)
+			case PeerAlive:
+				if event.Previous == PeerFailed {
+					r.log.WithFields(fields).Info("Peer recovered")
+				} else {
+					r.log.WithFields(fields).Debug("Peer state changed")
+				}
+			default:
+				r.log.WithFields(fields).Debug("Peer state changed")
 			}
+		}
+	}
+}
+
+// peerFailed 判断节点是否已被 gossip 判定为故障（未启用 gossip 时总是 false）
+func (r *RouterAgent) peerFailed(nodeName string) bool {
+	return r.gossip != nil && nodeName != r.nodeName && r.gossip.Failed(nodeName)
+}
+
+// Peers 返回 gossip 已知的对端节点（未启用 gossip 时返回 nil）
+func (r *RouterAgent) Peers() []Peer {
+	if r.gossip == nil {
+		return nil
+	}
+	return r.gossip.Peers()
+}
+
+// detectLost 检测停止上报的 UAV，转为 Lost 时持久化最后已知位置
+// gossip 判定故障的节点即使 UAVMetrics 尚未超时也视为失联
+func (r *RouterAgent) detectLost(ctx context.Context, metrics []*models.UAVMetrics) {
+	if r.lostTimeout <= 0 {
+		return
+	}
 
-			// 更新缓存
-			r.metricsMutex.Lock()
-			r.metricsCache = make(map[string]*models.UAVMetrics)
-			for _, m := range metrics {
-				r.metricsCache[m.NodeName] = m
+	now := time.Now()
+	for _, m := range metrics {
+		lastSeen := m.LastSeen()
+		if lastSeen.IsZero() {
+			continue
+		}
+
+		r.lostMutex.RLock()
+		_, alreadyLost := r.lostBeacons[m.NodeName]
+		r.lostMutex.RUnlock()
+
+		if now.Sub(lastSeen) <= r.lostTimeout && !r.peerFailed(m.NodeName) {
+			if alreadyLost {
+				r.lostMutex.Lock()
+				delete(r.lostBeacons, m.NodeName)
+				r.lostMutex.Unlock()
+				r.log.WithField("uav", m.NodeName).Info("Lost UAV is reporting again")
+				r.recordEvent(ctx, m.NodeName, v1.EventTypeNormal, "UAVFound", "UAV is reporting again")
 			}
-			r.metricsMutex.Unlock()
+			continue
+		}
+		if alreadyLost {
+			continue
+		}
 
-			r.log.WithField("count", len(metrics)).Debug("UAV metrics cache updated")
+		beacon := models.NewLostBeacon(m, lastSeen, now)
+		if r.searchArea != nil {
+			beacon.SearchArea = r.searchArea.Estimate(beacon, now)
 		}
+		r.lostMutex.Lock()
+		r.lostBeacons[m.NodeName] = beacon
+		r.lostMutex.Unlock()
+
+		fields := logrus.Fields{
+			"uav":       beacon.NodeName,
+			"latitude":  beacon.Latitude,
+			"longitude": beacon.Longitude,
+			"altitude":  beacon.Altitude,
+			"heading":   beacon.Heading,
+			"speed":     beacon.Speed,
+			"battery":   beacon.BatteryPercent,
+			"lastSeen":  beacon.LastSeen.Format(time.RFC3339),
+		}
+		if beacon.SearchArea != nil {
+			fields["searchRange"] = beacon.SearchArea.Properties.MaxRangeMeters
+		}
+		// 心跳 Lease 区分失联原因：Agent 仍在续约说明是飞行器链路或数据源中断，否则是 Agent 已停止
+		cause := "agent heartbeat expired"
+		alive, err := r.uavClient.IsAlive(ctx, beacon.NodeName)
+		if err != nil {
+			r.log.WithError(err).WithField("uav", beacon.NodeName).Debug("Failed to check agent heartbeat")
+			cause = "agent heartbeat unknown"
+		} else if alive {
+			cause = "agent alive, telemetry link or data source lost"
+		}
+		fields["cause"] = cause
+		r.log.WithFields(fields).Error("UAV lost, last known position recorded")
+
+		if err := r.uavClient.MarkLost(ctx, beacon); errors.Is(err, models.ErrStaleUpdate) {
+			r.log.WithField("uav", beacon.NodeName).Info("UAV reported newer telemetry, not marking it lost")
+		} else if err != nil {
+			r.log.WithError(err).WithField("uav", beacon.NodeName).Warn("Failed to persist lost UAV beacon")
+		} else {
+			r.recordEvent(ctx, beacon.NodeName, v1.EventTypeWarning, "UAVLost", fmt.Sprintf(
+				"UAV stopped reporting, last seen %s at %.6f,%.6f (%s)", beacon.LastSeen.Format(time.RFC3339), beacon.Latitude, beacon.Longitude, cause))
+		}
+	}
+}
+
+// recordEvent 在 UAV 的 UAVMetrics 上记录 Event，失败只记录日志
+func (r *RouterAgent) recordEvent(ctx context.Context, nodeName, eventType, reason, message string) {
+	if r.events == nil {
+		return
+	}
+	if err := r.events.UAVMetricsEvent(ctx, nodeName, eventType, reason, message); err != nil {
+		r.log.WithError(err).WithField("uav", nodeName).Debug("Failed to record UAV event")
 	}
 }
 
+// LostBeacons 返回所有失联 UAV 的最后已知状态
+func (r *RouterAgent) LostBeacons() []*models.LostBeacon {
+	r.lostMutex.RLock()
+	defer r.lostMutex.RUnlock()
+
+	now := time.Now()
+	beacons := make([]*models.LostBeacon, 0, len(r.lostBeacons))
+	for _, b := range r.lostBeacons {
+		beacons = append(beacons, r.refreshSearchArea(b, now))
+	}
+	sort.Slice(beacons, func(i, j int) bool {
+		return beacons[i].DetectedAt.Before(beacons[j].DetectedAt)
+	})
+	return beacons
+}
+
+// LostBeacon 返回指定 UAV 的失联信标（未失联时返回 nil）
+func (r *RouterAgent) LostBeacon(nodeName string) *models.LostBeacon {
+	r.lostMutex.RLock()
+	defer r.lostMutex.RUnlock()
+
+	beacon, ok := r.lostBeacons[nodeName]
+	if !ok {
+		return nil
+	}
+	return r.refreshSearchArea(beacon, time.Now())
+}
+
+// refreshSearchArea 返回搜索区域按当前时间重新估算后的信标副本
+// 失联时间越长搜索区域越大，直到电池耗尽
+func (r *RouterAgent) refreshSearchArea(beacon *models.LostBeacon, now time.Time) *models.LostBeacon {
+	if r.searchArea == nil {
+		return beacon
+	}
+	refreshed := *beacon
+	refreshed.SearchArea = r.searchArea.Estimate(beacon, now)
+	return &refreshed
+}
+
 // watchEndpoints 监听所有服务的 endpoints 变化
 func (r *RouterAgent) watchEndpoints(ctx context.Context) {
 	// 使用 informer 监听 endpoints 和 pods
@@ -157,10 +447,11 @@ func (r *RouterAgent) rebuildEndpointsCache(ctx context.Context) {
 		return
 	}
 
-	// 构建 Pod -> Node 映射
-	podToNode := make(map[string]string)
-	for _, pod := range pods.Items {
-		podToNode[pod.Namespace+"/"+pod.Name] = pod.Spec.NodeName
+	// 构建 Pod 索引
+	podsByKey := make(map[string]*v1.Pod, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		podsByKey[pod.Namespace+"/"+pod.Name] = pod
 	}
 
 	// 重建缓存
@@ -176,18 +467,31 @@ func (r *RouterAgent) rebuildEndpointsCache(ctx context.Context) {
 					continue
 				}
 
-				podKey := ep.Namespace + "/" + addr.TargetRef.Name
-				nodeName := podToNode[podKey]
-
-				for _, port := range subset.Ports {
-					endpoints = append(endpoints, algorithm.Endpoint{
-						PodName:   addr.TargetRef.Name,
-						PodIP:     addr.IP,
-						NodeName:  nodeName,
-						Namespace: ep.Namespace,
-						Service:   ep.Name,
-						Port:      port.Port,
-					})
+				pod := podsByKey[ep.Namespace+"/"+addr.TargetRef.Name]
+				nodeName := ""
+				if addr.NodeName != nil {
+					nodeName = *addr.NodeName
+				}
+				hostNetwork := false
+				if pod != nil {
+					nodeName = pod.Spec.NodeName
+					hostNetwork = pod.Spec.HostNetwork
+				}
+
+				for i, ip := range endpointIPs(addr.IP, pod) {
+					for _, port := range subset.Ports {
+						endpoints = append(endpoints, algorithm.Endpoint{
+							PodName:     addr.TargetRef.Name,
+							PodIP:       ip,
+							NodeName:    nodeName,
+							Namespace:   ep.Namespace,
+							Service:     ep.Name,
+							Port:        port.Port,
+							IPFamily:    algorithm.IPFamilyOf(ip),
+							HostNetwork: hostNetwork,
+							Secondary:   i > 0,
+						})
+					}
 				}
 			}
 		}
@@ -198,51 +502,122 @@ func (r *RouterAgent) rebuildEndpointsCache(ctx context.Context) {
 	}
 
 	// 更新缓存
-	r.endpointsMutex.Lock()
-	r.endpointsCache = newCache
-	r.endpointsMutex.Unlock()
+	version := r.publishEndpoints(newCache)
 
-	r.log.WithField("services", len(newCache)).Debug("Endpoints cache updated")
+	r.log.WithFields(logrus.Fields{
+		"services": len(newCache),
+		"version":  version,
+	}).Debug("Endpoints cache updated")
 }
 
-// ComputeRouting 计算指定服务的路由权重
-// 这是核心方法，本地查询缓存（无网络延迟）
-func (r *RouterAgent) ComputeRouting(ctx context.Context, serviceName string) ([]algorithm.EndpointWeight, error) {
-	// 从缓存获取源节点指标（本地查询）
-	r.metricsMutex.RLock()
-	sourceMetrics := r.metricsCache[r.nodeName]
-	targetMetrics := make(map[string]*models.UAVMetrics)
-	for k, v := range r.metricsCache {
-		targetMetrics[k] = v
+// endpointIPs 返回 endpoint 地址所属 Pod 的全部地址，addr 排在最前
+// Endpoints 只列出主地址族的地址，双栈 Pod 的另一地址族地址取自 Pod 状态；
+// 主机网络 Pod 的地址即节点地址，Pod 状态只有单栈地址时补充节点的全部地址
+func endpointIPs(addr string, pod *v1.Pod) []string {
+	ips := []string{addr}
+	if pod == nil {
+		return ips
 	}
-	r.metricsMutex.RUnlock()
-
-	if sourceMetrics == nil {
-		return nil, fmt.Errorf("source node %s metrics not found in cache", r.nodeName)
+	candidates := make([]string, 0, 4)
+	for _, podIP := range pod.Status.PodIPs {
+		candidates = append(candidates, podIP.IP)
 	}
+	if pod.Spec.HostNetwork {
+		for _, hostIP := range pod.Status.HostIPs {
+			candidates = append(candidates, hostIP.IP)
+		}
+	}
+	for _, ip := range candidates {
+		if ip != "" && !slices.Contains(ips, ip) && algorithm.IPFamilyOf(ip) != "" {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
 
-	// 从缓存获取目标 endpoints（本地查询）
-	r.endpointsMutex.RLock()
-	endpoints, exists := r.endpointsCache[serviceName]
-	r.endpointsMutex.RUnlock()
-
-	if !exists || len(endpoints) == 0 {
-		return nil, fmt.Errorf("no endpoints found for service %s", serviceName)
+// ComputeRouting 计算指定服务的路由权重
+// 这是核心方法，本地查询缓存（无网络延迟）
+// family 为空时只路由到服务主地址族（Endpoints 列出）的地址，每个 Pod 端口一个 endpoint；
+// 非空时只路由到该地址族（algorithm.IPFamilyIPv4 或 IPFamilyIPv6）的 endpoint，包括双栈 Pod 的其他地址。
+// 同时返回计算所用缓存快照的版本（出错时也返回），供响应中排查
+func (r *RouterAgent) ComputeRouting(ctx context.Context, serviceName, family string) ([]algorithm.EndpointWeight, uint64, error) {
+	input, version, err := r.routingSnapshot(serviceName, family)
+	if err != nil {
+		r.decisions.Record(serviceName, false, 0)
+		return nil, version, err
 	}
 
 	// 调用算法计算权重（本地计算）
-	weights, err := r.algorithm.ComputeWeights(ctx, r.nodeName, sourceMetrics, endpoints, targetMetrics)
+	weights, err := r.algorithm.ComputeWeights(ctx, r.nodeName, input.source, input.endpoints, input.targets)
 	if err != nil {
-		return nil, fmt.Errorf("algorithm %s failed: %w", r.algorithm.Name(), err)
+		r.decisions.Record(serviceName, false, 0)
+		return nil, version, fmt.Errorf("algorithm %s failed: %w", r.algorithm.Name(), err)
 	}
 
+	// 叠加运维人员设置的权重覆盖
+	weights = r.overrides.Apply(serviceName, input.endpoints, weights, time.Now())
+	r.decisions.Record(serviceName, true, preferredDistance(input.source, weights, input.targets))
+
 	r.log.WithFields(logrus.Fields{
 		"service":   serviceName,
 		"algorithm": r.algorithm.Name(),
 		"endpoints": len(weights),
+		"version":   version,
 	}).Debug("Routing computed")
 
-	return weights, nil
+	return weights, version, nil
+}
+
+// ComputeRelayPath 计算从 source UAV 到地面站的最佳中继链
+// source 为空时使用本节点，destination 为空时选择代价最小的地面站。
+// 失联、gossip 判定故障或 GNSS 位置不可信的 UAV 不参与转发
+func (r *RouterAgent) ComputeRelayPath(source, destination string) (*RelayPath, error) {
+	if r.relay == nil {
+		return nil, fmt.Errorf("relay path computation is not configured")
+	}
+	if source == "" {
+		source = r.nodeName
+	}
+
+	cache := r.snapshot().metrics
+	r.lostMutex.RLock()
+	relays := make(map[string]*models.UAVMetrics, len(cache))
+	for name, m := range cache {
+		if _, lost := r.lostBeacons[name]; lost || r.peerFailed(name) || m.GPS.PositionDegraded() {
+			continue
+		}
+		relays[name] = m
+	}
+	r.lostMutex.RUnlock()
+
+	return r.relay.Plan(source, destination, relays)
+}
+
+// pushRoutingStats 每个 statsInterval 将本周期的路由决策统计写入本节点 UAVMetrics 的 status，
+// 写入成功后才开始新的周期，失败时统计并入下个周期
+func (r *RouterAgent) pushRoutingStats(ctx context.Context) {
+	ticker := time.NewTicker(r.statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats, period := r.decisions.Snapshot(r.algorithm.Name())
+			if err := r.uavClient.UpdateRoutingStats(ctx, r.nodeName, stats); err != nil {
+				r.log.WithError(err).Debug("Failed to publish routing stats")
+				continue
+			}
+			r.decisions.EndPeriod(period)
+		}
+	}
+}
+
+// RoutingStats 返回当前周期的路由决策统计
+func (r *RouterAgent) RoutingStats() *models.RoutingStats {
+	stats, _ := r.decisions.Snapshot(r.algorithm.Name())
+	return stats
 }
 
 // waitForCacheReady 等待缓存初始化完成
@@ -258,11 +633,7 @@ func (r *RouterAgent) waitForCacheReady(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			r.metricsMutex.RLock()
-			metricsReady := len(r.metricsCache) > 0
-			r.metricsMutex.RUnlock()
-
-			if metricsReady {
+			if len(r.snapshot().metrics) > 0 {
 				r.log.Info("Cache ready")
 				return nil
 			}
@@ -270,20 +641,21 @@ func (r *RouterAgent) waitForCacheReady(ctx context.Context) error {
 	}
 }
 
+// CachedMetrics 返回缓存中指定节点的 UAV metrics（用于一致性检查），不存在时返回 nil
+func (r *RouterAgent) CachedMetrics(nodeName string) *models.UAVMetrics {
+	return r.snapshot().metrics[nodeName]
+}
+
 // GetCacheStats 获取缓存统计（用于调试）
 func (r *RouterAgent) GetCacheStats() map[string]interface{} {
-	r.metricsMutex.RLock()
-	metricsCount := len(r.metricsCache)
-	r.metricsMutex.RUnlock()
-
-	r.endpointsMutex.RLock()
-	servicesCount := len(r.endpointsCache)
-	r.endpointsMutex.RUnlock()
+	snap := r.snapshot()
 
 	return map[string]interface{}{
-		"metrics_cached":  metricsCount,
-		"services_cached": servicesCount,
-		"node_name":       r.nodeName,
-		"algorithm":       r.algorithm.Name(),
+		"metrics_cached":   len(snap.metrics),
+		"services_cached":  len(snap.endpoints),
+		"snapshot_version": snap.version,
+		"node_name":        r.nodeName,
+		"algorithm":        r.algorithm.Name(),
+		"routing":          r.RoutingStats(),
 	}
 }
@@ -0,0 +1,142 @@
+package router
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+const (
+	// searchEarthRadius 地球半径（米）
+	searchEarthRadius = 6371000.0
+
+	// searchHeadingSpread 航向不确定度，飞得越远横向偏差越大
+	searchHeadingSpread = 15 * math.Pi / 180
+
+	// searchCirclePoints 每个圆近似为多边形时的顶点数
+	searchCirclePoints = 16
+)
+
+// SearchAreaEstimator 失联 UAV 搜索区域估算器
+// 假设 UAV 在失联后沿最后航向、以最后速度继续飞行，同时被风吹离，
+// 直到电池耗尽（无续航估计时按失联时长计算）。搜索区域为失联点附近的
+// 圆与推算终点附近的圆的凸包，终点圆半径随推算距离增大
+type SearchAreaEstimator struct {
+	windSpeed     float64 // 风速（m/s）
+	windDirection float64 // 风的来向（度，气象惯例）
+	baseRadius    float64 // 失联点的最小搜索半径（米），覆盖 GPS 误差和坠落散布
+}
+
+// NewSearchAreaEstimator 创建搜索区域估算器
+func NewSearchAreaEstimator(windSpeed, windDirection, baseRadius float64) *SearchAreaEstimator {
+	if baseRadius <= 0 {
+		baseRadius = 100
+	}
+	return &SearchAreaEstimator{
+		windSpeed:     windSpeed,
+		windDirection: windDirection,
+		baseRadius:    baseRadius,
+	}
+}
+
+// Estimate 估算截至 now 的搜索区域，返回 GeoJSON Feature
+func (e *SearchAreaEstimator) Estimate(beacon *models.LostBeacon, now time.Time) *models.SearchArea {
+	elapsed := now.Sub(beacon.LastSeen).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	// 电池耗尽后不再移动
+	if beacon.TimeRemaining > 0 && elapsed > float64(beacon.TimeRemaining) {
+		elapsed = float64(beacon.TimeRemaining)
+	}
+
+	// 以失联点为原点的局部坐标（x 向东，y 向北，单位米）
+	heading := beacon.Heading * math.Pi / 180
+	downwind := math.Mod(e.windDirection+180, 360) * math.Pi / 180
+	travel := beacon.Speed * elapsed
+	drift := e.windSpeed * elapsed
+	endX := travel*math.Sin(heading) + drift*math.Sin(downwind)
+	endY := travel*math.Cos(heading) + drift*math.Cos(downwind)
+
+	reach := math.Hypot(endX, endY)
+	endRadius := e.baseRadius + reach*math.Tan(searchHeadingSpread)
+
+	points := make([][2]float64, 0, 2*searchCirclePoints)
+	points = appendCircle(points, 0, 0, e.baseRadius)
+	points = appendCircle(points, endX, endY, endRadius)
+	hull := convexHull(points)
+
+	ring := make([][2]float64, 0, len(hull)+1)
+	for _, p := range hull {
+		ring = append(ring, offsetPosition(beacon.Latitude, beacon.Longitude, p[0], p[1]))
+	}
+	ring = append(ring, ring[0]) // GeoJSON 要求首尾闭合
+
+	return &models.SearchArea{
+		Type: "Feature",
+		Geometry: models.GeoJSONPolygon{
+			Type:        "Polygon",
+			Coordinates: [][][2]float64{ring},
+		},
+		Properties: models.SearchAreaProperties{
+			NodeName:       beacon.NodeName,
+			LastSeen:       beacon.LastSeen,
+			EstimatedAt:    now,
+			ElapsedSeconds: elapsed,
+			MaxRangeMeters: math.Max(e.baseRadius, reach+endRadius),
+			WindSpeed:      e.windSpeed,
+			WindDirection:  e.windDirection,
+		},
+	}
+}
+
+// appendCircle 以多边形顶点近似圆
+func appendCircle(points [][2]float64, cx, cy, radius float64) [][2]float64 {
+	for i := 0; i < searchCirclePoints; i++ {
+		angle := 2 * math.Pi * float64(i) / searchCirclePoints
+		points = append(points, [2]float64{cx + radius*math.Cos(angle), cy + radius*math.Sin(angle)})
+	}
+	return points
+}
+
+// convexHull 单调链算法求凸包，结果按逆时针排列（符合 GeoJSON 外环方向）
+func convexHull(points [][2]float64) [][2]float64 {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i][0] != points[j][0] {
+			return points[i][0] < points[j][0]
+		}
+		return points[i][1] < points[j][1]
+	})
+
+	cross := func(o, a, b [2]float64) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+
+	hull := make([][2]float64, 0, 2*len(points))
+	// 下凸壳
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	// 上凸壳
+	lower := len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- {
+		p := points[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// offsetPosition 将局部坐标偏移（米）转换为 [经度, 纬度]
+func offsetPosition(lat, lon, east, north float64) [2]float64 {
+	dLat := north / searchEarthRadius * 180 / math.Pi
+	dLon := east / (searchEarthRadius * math.Cos(lat*math.Pi/180)) * 180 / math.Pi
+	return [2]float64{lon + dLon, lat + dLat}
+}
@@ -2,47 +2,49 @@ package router
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
 	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
+// snapshotVersionHeader 路由响应中计算所用缓存快照版本的响应头（计算失败时也设置）
+const snapshotVersionHeader = "X-Snapshot-Version"
+
 // Server HTTP API 服务器
 // 提供路由查询接口（用于测试）
 type Server struct {
 	router *RouterAgent
 	log    *logrus.Logger
 	port   int
+
+	// 管理接口的 Bearer token，为空时不提供管理接口
+	adminToken string
 }
 
 // NewServer 创建 HTTP 服务器
-func NewServer(router *RouterAgent, port int, log *logrus.Logger) *Server {
+func NewServer(router *RouterAgent, port int, adminToken string, log *logrus.Logger) *Server {
 	return &Server{
-		router: router,
-		port:   port,
-		log:    log,
+		router:     router,
+		port:       port,
+		adminToken: adminToken,
+		log:        log,
 	}
 }
 
 // Start 启动 HTTP 服务器
 func (s *Server) Start(ctx context.Context) error {
-	mux := http.NewServeMux()
-
-	// 路由计算接口
-	mux.HandleFunc("/route", s.handleRoute)
-
-	// 健康检查接口
-	mux.HandleFunc("/health", s.handleHealth)
-
-	// 缓存统计接口
-	mux.HandleFunc("/stats", s.handleStats)
-
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", s.port),
-		Handler: mux,
+		Handler: s.Handler(),
 	}
 
 	go func() {
@@ -56,18 +58,63 @@ func (s *Server) Start(ctx context.Context) error {
 	return server.ListenAndServe()
 }
 
+// Handler 返回 API 的 HTTP handler，不监听端口即可调用（如演示模式）
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	// 路由计算接口
+	mux.HandleFunc("/route", s.handleRoute)
+
+	// 算法对比接口：所有候选算法在当前快照上的权重和耗时
+	mux.HandleFunc("/route/compare", s.handleCompare)
+
+	// 健康检查接口
+	mux.HandleFunc("/health", s.handleHealth)
+
+	// 缓存统计接口
+	mux.HandleFunc("/stats", s.handleStats)
+
+	// 失联 UAV 最后已知位置接口（供回收人员使用）
+	mux.HandleFunc("/lost", s.handleLost)
+
+	// 失联 UAV 搜索区域（GeoJSON，可直接导入 GIS 工具）
+	mux.HandleFunc("/lost/search-area", s.handleSearchArea)
+
+	// 多跳中继路径接口（空中 mesh）
+	mux.HandleFunc("/relay", s.handleRelay)
+
+	// gossip 对端节点存活状态
+	mux.HandleFunc("/peers", s.handlePeers)
+
+	// Kubernetes API 调用指标（Prometheus 文本格式）
+	mux.Handle("/metrics", k8s.ClientMetricsHandler())
+
+	// 管理接口：endpoint 权重覆盖（需要 Bearer token）
+	if s.adminToken != "" {
+		mux.HandleFunc("/admin/overrides", s.requireAdmin(s.handleOverrides))
+	}
+
+	return mux
+}
+
 // handleRoute 处理路由查询请求
-// GET /route?service=namespace/servicename
+// GET /route?service=namespace/servicename&family=ipv6，family 缺省时只返回服务主地址族的 endpoint
 func (s *Server) handleRoute(w http.ResponseWriter, r *http.Request) {
 	serviceName := r.URL.Query().Get("service")
 	if serviceName == "" {
 		http.Error(w, "missing service parameter", http.StatusBadRequest)
 		return
 	}
+	family, err := ParseIPFamily(r.URL.Query().Get("family"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	startTime := time.Now()
 
-	weights, err := s.router.ComputeRouting(r.Context(), serviceName)
+	weights, version, err := s.router.ComputeRouting(r.Context(), serviceName, family)
+	w.Header().Set(snapshotVersionHeader, strconv.FormatUint(version, 10))
 	if err != nil {
 		s.log.WithError(err).WithField("service", serviceName).Warn("Routing computation failed")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -77,12 +124,14 @@ func (s *Server) handleRoute(w http.ResponseWriter, r *http.Request) {
 	duration := time.Since(startTime)
 
 	response := map[string]interface{}{
-		"service":      serviceName,
-		"algorithm":    s.router.algorithm.Name(),
-		"weights":      weights,
-		"duration_ms":  duration.Milliseconds(),
-		"duration_us":  duration.Microseconds(),
-		"endpoints_count": len(weights),
+		"service":          serviceName,
+		"family":           family,
+		"algorithm":        s.router.algorithm.Name(),
+		"weights":          weights,
+		"duration_ms":      duration.Milliseconds(),
+		"duration_us":      duration.Microseconds(),
+		"endpoints_count":  len(weights),
+		"snapshot_version": version,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -95,6 +144,41 @@ func (s *Server) handleRoute(w http.ResponseWriter, r *http.Request) {
 	}).Info("Routing computed successfully")
 }
 
+// handleCompare 使用所有候选算法计算同一服务的路由，对比权重和计算耗时
+// GET /route/compare?service=namespace/servicename&iterations=100&family=ipv4
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	serviceName := r.URL.Query().Get("service")
+	if serviceName == "" {
+		http.Error(w, "missing service parameter", http.StatusBadRequest)
+		return
+	}
+	family, err := ParseIPFamily(r.URL.Query().Get("family"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	iterations := 1
+	if value := r.URL.Query().Get("iterations"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxCompareIterations {
+			http.Error(w, fmt.Sprintf("iterations must be between 1 and %d", maxCompareIterations), http.StatusBadRequest)
+			return
+		}
+		iterations = n
+	}
+
+	comparison, err := s.router.CompareRouting(r.Context(), serviceName, family, iterations)
+	if err != nil {
+		s.log.WithError(err).WithField("service", serviceName).Warn("Routing comparison failed")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(snapshotVersionHeader, strconv.FormatUint(comparison.SnapshotVersion, 10))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison)
+}
+
 // handleHealth 健康检查
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -110,3 +194,194 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
+
+// handleLost 查询失联 UAV 的最后已知位置
+// GET /lost 返回全部失联 UAV；GET /lost?node=xxx 返回指定 UAV
+func (s *Server) handleLost(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if node := r.URL.Query().Get("node"); node != "" {
+		beacon := s.router.LostBeacon(node)
+		if beacon == nil {
+			http.Error(w, fmt.Sprintf("UAV %s is not lost", node), http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(beacon)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"lost": s.router.LostBeacons(),
+	})
+}
+
+// handleSearchArea 以 GeoJSON FeatureCollection 返回失联 UAV 的搜索区域
+// GET /lost/search-area 返回全部；GET /lost/search-area?node=xxx 返回指定 UAV
+func (s *Server) handleSearchArea(w http.ResponseWriter, r *http.Request) {
+	beacons := s.router.LostBeacons()
+	if node := r.URL.Query().Get("node"); node != "" {
+		beacon := s.router.LostBeacon(node)
+		if beacon == nil {
+			http.Error(w, fmt.Sprintf("UAV %s is not lost", node), http.StatusNotFound)
+			return
+		}
+		beacons = []*models.LostBeacon{beacon}
+	}
+
+	features := []*models.SearchArea{}
+	for _, beacon := range beacons {
+		if beacon.SearchArea != nil {
+			features = append(features, beacon.SearchArea)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
+
+// handleRelay 查询从源 UAV 到地面站的最佳中继链
+// GET /relay?source=uav-1&to=gs-1，source 缺省为本节点，to 缺省为代价最小的地面站
+func (s *Server) handleRelay(w http.ResponseWriter, r *http.Request) {
+	source := r.URL.Query().Get("source")
+	destination := r.URL.Query().Get("to")
+
+	path, err := s.router.ComputeRelayPath(source, destination)
+	if err != nil {
+		s.log.WithError(err).WithFields(logrus.Fields{
+			"source": source,
+			"to":     destination,
+		}).Warn("Relay path computation failed")
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(path)
+}
+
+// handlePeers 查询 gossip 检测到的对端节点存活状态
+// GET /peers
+func (s *Server) handlePeers(w http.ResponseWriter, r *http.Request) {
+	if s.router.gossip == nil {
+		http.Error(w, "gossip peer liveness is not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"node":  s.router.nodeName,
+		"peers": s.router.Peers(),
+	})
+}
+
+// requireAdmin 校验请求的 Bearer token，不匹配时返回 401
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="uav-router"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// overrideRequest 创建权重覆盖的请求体，ttl（如 "30m"）与 expiresAt 二选一
+type overrideRequest struct {
+	models.RouteOverride
+	TTL string `json:"ttl,omitempty"`
+}
+
+// handleOverrides 管理 endpoint 权重覆盖
+// GET /admin/overrides 列出全部覆盖
+// POST /admin/overrides 创建覆盖（weight 为 0 表示摘除流量）
+// DELETE /admin/overrides?name=xxx 删除覆盖
+func (s *Server) handleOverrides(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"overrides": s.router.RouteOverrides(),
+		})
+
+	case http.MethodPost:
+		var req overrideRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		override := req.RouteOverride
+		if req.TTL != "" {
+			ttl, err := time.ParseDuration(req.TTL)
+			if err != nil || ttl <= 0 {
+				http.Error(w, fmt.Sprintf("invalid ttl %q", req.TTL), http.StatusBadRequest)
+				return
+			}
+			override.ExpiresAt = time.Now().Add(ttl)
+		}
+		if override.Name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		if override.CreatedBy == "" {
+			override.CreatedBy = "router/" + s.router.nodeName
+		}
+		if err := override.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := s.router.CreateRouteOverride(r.Context(), &override); err != nil {
+			s.log.WithError(err).WithField("override", override.Name).Warn("Failed to create route override")
+			http.Error(w, err.Error(), apiErrorStatus(err))
+			return
+		}
+		s.log.WithFields(logrus.Fields{
+			"override":  override.Name,
+			"service":   override.Service,
+			"pod":       override.PodName,
+			"node":      override.NodeName,
+			"weight":    override.Weight,
+			"expiresAt": override.ExpiresAt,
+		}).Info("Route override created")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(override)
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "missing name parameter", http.StatusBadRequest)
+			return
+		}
+		if err := s.router.DeleteRouteOverride(r.Context(), name); err != nil {
+			s.log.WithError(err).WithField("override", name).Warn("Failed to delete route override")
+			http.Error(w, err.Error(), apiErrorStatus(err))
+			return
+		}
+		s.log.WithField("override", name).Info("Route override deleted")
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// apiErrorStatus 将 API Server 错误映射为 HTTP 状态码
+func apiErrorStatus(err error) int {
+	switch {
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound
+	case apierrors.IsAlreadyExists(err):
+		return http.StatusConflict
+	case apierrors.IsInvalid(err):
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
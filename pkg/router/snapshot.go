@@ -0,0 +1,125 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/router/algorithm"
+)
+
+// cacheSnapshot 路由缓存快照：同一时刻的全部 UAV metrics 和服务 endpoints
+// 快照发布后只读，任一缓存更新时整体替换并递增版本，
+// 路由计算从同一快照读取两份缓存，不会混用不同时刻的状态
+type cacheSnapshot struct {
+	version   uint64
+	metrics   map[string]*models.UAVMetrics   // key: node name
+	endpoints map[string][]algorithm.Endpoint // key: service name
+}
+
+// routingInput 计算一次路由所需的数据，均取自同一快照
+type routingInput struct {
+	source    *models.UAVMetrics
+	endpoints []algorithm.Endpoint
+	targets   map[string]*models.UAVMetrics
+}
+
+// snapshot 返回当前的缓存快照
+func (r *RouterAgent) snapshot() *cacheSnapshot {
+	return r.cache.Load()
+}
+
+// publishMetrics 以新的 UAV metrics 发布下一版本快照
+func (r *RouterAgent) publishMetrics(metrics map[string]*models.UAVMetrics) uint64 {
+	r.publishMutex.Lock()
+	defer r.publishMutex.Unlock()
+
+	current := r.cache.Load()
+	next := &cacheSnapshot{version: current.version + 1, metrics: metrics, endpoints: current.endpoints}
+	r.cache.Store(next)
+	return next.version
+}
+
+// publishEndpoints 以新的服务 endpoints 发布下一版本快照
+func (r *RouterAgent) publishEndpoints(endpoints map[string][]algorithm.Endpoint) uint64 {
+	r.publishMutex.Lock()
+	defer r.publishMutex.Unlock()
+
+	current := r.cache.Load()
+	next := &cacheSnapshot{version: current.version + 1, metrics: current.metrics, endpoints: endpoints}
+	r.cache.Store(next)
+	return next.version
+}
+
+// routingSnapshot 从当前快照获取计算路由所需的源节点指标、服务 endpoints 和目标节点指标
+// family 为空时只保留主地址族的 endpoint，非空时只保留该地址族的 endpoint。出错时也返回快照版本，便于排查
+func (r *RouterAgent) routingSnapshot(serviceName, family string) (*routingInput, uint64, error) {
+	snap := r.snapshot()
+
+	sourceMetrics := snap.metrics[r.nodeName]
+	if sourceMetrics == nil {
+		return nil, snap.version, fmt.Errorf("source node %s metrics not found in cache", r.nodeName)
+	}
+
+	endpoints := snap.endpoints[serviceName]
+	if len(endpoints) == 0 {
+		return nil, snap.version, fmt.Errorf("no endpoints found for service %s", serviceName)
+	}
+	if family != "" {
+		endpoints = filterFamily(endpoints, family)
+		if len(endpoints) == 0 {
+			return nil, snap.version, fmt.Errorf("no %s endpoints found for service %s", family, serviceName)
+		}
+	} else {
+		endpoints = primaryEndpoints(endpoints)
+	}
+
+	targetMetrics := make(map[string]*models.UAVMetrics, len(snap.metrics))
+	for k, v := range snap.metrics {
+		// gossip 判定故障的节点没有 metrics，算法会跳过其 endpoint
+		if r.peerFailed(k) {
+			continue
+		}
+		targetMetrics[k] = v
+	}
+	return &routingInput{
+		source:    sourceMetrics,
+		endpoints: endpoints,
+		targets:   targetMetrics,
+	}, snap.version, nil
+}
+
+// filterFamily 返回地址族为 family 的 endpoint
+func filterFamily(endpoints []algorithm.Endpoint, family string) []algorithm.Endpoint {
+	filtered := make([]algorithm.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if ep.IPFamily == family {
+			filtered = append(filtered, ep)
+		}
+	}
+	return filtered
+}
+
+// primaryEndpoints 返回主地址族的 endpoint
+func primaryEndpoints(endpoints []algorithm.Endpoint) []algorithm.Endpoint {
+	filtered := make([]algorithm.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if !ep.Secondary {
+			filtered = append(filtered, ep)
+		}
+	}
+	return filtered
+}
+
+// ParseIPFamily 解析路由接口的地址族参数（ipv4、ipv6，不区分大小写），空字符串表示不过滤
+func ParseIPFamily(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return "", nil
+	case "ipv4":
+		return algorithm.IPFamilyIPv4, nil
+	case "ipv6":
+		return algorithm.IPFamilyIPv6, nil
+	}
+	return "", fmt.Errorf("invalid address family %q, expected ipv4 or ipv6", value)
+}
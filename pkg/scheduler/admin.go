@@ -0,0 +1,181 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ServeAdmin 启动管理接口（需要 Bearer token）：
+//
+//	GET  /admin/scheduling               查询暂停状态和队列
+//	POST /admin/scheduling/pause?reason= 暂停调度，新 Pod 只入队
+//	POST /admin/scheduling/resume        恢复调度并调度队列中的 Pod
+//	POST /admin/scheduling/drain         立即调度队列中的 Pod，保持暂停
+//	GET  /admin/chargeback[?format=csv]  当前计费周期各命名空间的用量
+//	POST /admin/chargeback/close         返回当前周期报表并开始新的周期
+//	GET  /admin/sandbox[?limit=]         沙箱中生产算法与候选算法的对比
+func (s *Scheduler) ServeAdmin(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/scheduling", s.requireAdmin(s.handleSchedulingStatus))
+	mux.HandleFunc("/admin/scheduling/pause", s.requireAdmin(s.handlePause))
+	mux.HandleFunc("/admin/scheduling/resume", s.requireAdmin(s.handleResume))
+	mux.HandleFunc("/admin/scheduling/drain", s.requireAdmin(s.handleDrain))
+	if s.chargeback != nil {
+		mux.HandleFunc("/admin/chargeback", s.requireAdmin(s.handleChargeback))
+		mux.HandleFunc("/admin/chargeback/close", s.requireAdmin(s.handleChargebackClose))
+	}
+	if s.sandbox != nil {
+		mux.HandleFunc("/admin/sandbox", s.requireAdmin(s.handleSandbox))
+	}
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.config.AdminPort),
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	s.log.WithField("port", s.config.AdminPort).Info("Starting admin API server")
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// requireAdmin 校验 Bearer token 和请求方法
+func (s *Scheduler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="uav-scheduler"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Scheduler) handleSchedulingStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeStatus(w)
+}
+
+func (s *Scheduler) handlePause(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	reason := r.URL.Query().Get("reason")
+	if s.control.Pause(reason) {
+		s.log.WithField("reason", reason).Warn("Scheduling paused")
+	}
+	s.writeStatus(w)
+}
+
+func (s *Scheduler) handleResume(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	if s.control.Resume() {
+		s.log.Info("Scheduling resumed")
+	}
+	s.writeStatus(w)
+}
+
+func (s *Scheduler) handleDrain(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	n := s.control.Drain()
+	s.log.WithField("pods", n).Info("Scheduling queue drain requested")
+	s.writeStatus(w)
+}
+
+func (s *Scheduler) handleChargeback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeReport(w, r, s.chargeback.Report(false))
+}
+
+func (s *Scheduler) handleChargebackClose(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	report := s.chargeback.Report(true)
+	s.log.WithFields(logrus.Fields{
+		"from":      report.From,
+		"to":        report.To,
+		"totalCost": report.TotalCost,
+	}).Info("Chargeback period closed")
+	s.writeReport(w, r, report)
+}
+
+func (s *Scheduler) handleSandbox(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	report, err := s.sandbox.Report(r.Context(), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// writeReport 按 format 参数以 JSON（默认）或 CSV 输出报表
+func (s *Scheduler) writeReport(w http.ResponseWriter, r *http.Request, report *ChargebackReport) {
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chargeback-%s.csv"`, report.To.Format("20060102T150405")))
+		report.WriteCSV(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (s *Scheduler) writeStatus(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.control.Status())
+}
+
+func requirePost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package algorithm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	v1 "k8s.io/api/core/v1"
+)
+
+// LongRunningAnnotation 显式声明 Pod 是否为长时间运行的负载（"true"/"false"）
+// 未设置时，restartPolicy 为 Always 的 Pod 视为长时间运行
+const LongRunningAnnotation = "uav.scheduler/long-running"
+
+// AirtimeBudgetAlgorithm 基于每日飞行时长预算的调度算法
+// 过滤掉当天飞行时长已超出预算的无人机（仅针对长时间运行的负载），
+// 剩余预算越多分数越高。调度器对所有算法都先应用 FilterAirtimeBudget，
+// 本算法只在预算之内按剩余预算打分
+type AirtimeBudgetAlgorithm struct {
+	DefaultBudget float64 // 节点未上报预算时使用的每日预算（分钟），0 表示不限制
+}
+
+// NewAirtimeBudgetAlgorithm 创建基于飞行时长预算的算法
+func NewAirtimeBudgetAlgorithm(defaultBudget float64) *AirtimeBudgetAlgorithm {
+	return &AirtimeBudgetAlgorithm{
+		DefaultBudget: defaultBudget,
+	}
+}
+
+func (a *AirtimeBudgetAlgorithm) Name() string {
+	return "airtime-budget"
+}
+
+func (a *AirtimeBudgetAlgorithm) Filter(ctx context.Context, pod *v1.Pod, metrics []*models.UAVMetrics) ([]*models.UAVMetrics, error) {
+	return FilterAirtimeBudget(pod, metrics, a.DefaultBudget, time.Now()), nil
+}
+
+func (a *AirtimeBudgetAlgorithm) Score(ctx context.Context, pod *v1.Pod, metrics []*models.UAVMetrics) ([]NodeScore, error) {
+	scores := []NodeScore{}
+	now := time.Now()
+
+	for _, m := range metrics {
+		budget := airtimeBudget(m, a.DefaultBudget)
+		if budget <= 0 || m.Airtime == nil {
+			// 无预算限制或无数据，给满分
+			scores = append(scores, NodeScore{
+				NodeName: m.NodeName,
+				Score:    100,
+				Reason:   "airtime: no budget",
+			})
+			continue
+		}
+
+		// 剩余预算比例作为分数（上报的不是今天的数据时视为今天尚未飞行）
+		airborne := m.Airtime.MinutesOn(now)
+		remaining := budget - airborne
+		score := 100.0 * remaining / budget
+		if score < 0 {
+			score = 0
+		}
+
+		scores = append(scores, NodeScore{
+			NodeName: m.NodeName,
+			Score:    score,
+			Reason:   fmt.Sprintf("airtime: %.1f/%.0f min", airborne, budget),
+		})
+	}
+
+	return scores, nil
+}
+
+// FilterAirtimeBudget 过滤掉今天（UTC）飞行时长已达到每日预算的无人机，短任务不受预算限制
+// 所有调度算法共用，defaultBudget 为节点未上报预算时使用的每日预算（分钟），0 表示不限制
+func FilterAirtimeBudget(pod *v1.Pod, metrics []*models.UAVMetrics, defaultBudget float64, now time.Time) []*models.UAVMetrics {
+	if !IsLongRunning(pod) {
+		return metrics
+	}
+
+	filtered := []*models.UAVMetrics{}
+	for _, m := range metrics {
+		if m.Airtime != nil && m.Airtime.IsOverBudget(airtimeBudget(m, defaultBudget), now) {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+	return filtered
+}
+
+// airtimeBudget 返回节点的每日预算（优先使用节点上报值）
+func airtimeBudget(m *models.UAVMetrics, defaultBudget float64) float64 {
+	if m.Airtime != nil && m.Airtime.BudgetMinutes > 0 {
+		return m.Airtime.BudgetMinutes
+	}
+	return defaultBudget
+}
+
+// IsLongRunning 判断 Pod 是否为长时间运行的负载
+func IsLongRunning(pod *v1.Pod) bool {
+	if value, ok := pod.Annotations[LongRunningAnnotation]; ok {
+		return value == "true"
+	}
+	return pod.Spec.RestartPolicy == "" || pod.Spec.RestartPolicy == v1.RestartPolicyAlways
+}
@@ -28,9 +28,9 @@ func (a *BatteryAwareAlgorithm) Name() string {
 func (a *BatteryAwareAlgorithm) Filter(ctx context.Context, pod *v1.Pod, metrics []*models.UAVMetrics) ([]*models.UAVMetrics, error) {
 	filtered := []*models.UAVMetrics{}
 
-	// 过滤掉电量不足的节点
+	// 过滤掉电量不足的节点（扣除返航所需电量）
 	for _, m := range metrics {
-		if m.Battery.RemainingPercent >= a.MinBattery {
+		if m.UsableBatteryPercent() >= a.MinBattery {
 			filtered = append(filtered, m)
 		}
 	}
@@ -42,8 +42,8 @@ func (a *BatteryAwareAlgorithm) Score(ctx context.Context, pod *v1.Pod, metrics
 	scores := []NodeScore{}
 
 	for _, m := range metrics {
-		// 电池电量直接作为分数（0-100）
-		score := m.Battery.RemainingPercent
+		// 扣除返航预留后的可用电量作为分数（0-100）
+		score := m.UsableBatteryPercent()
 
 		// 如果电量低于最低要求，分数为0
 		if score < a.MinBattery {
@@ -53,7 +53,7 @@ func (a *BatteryAwareAlgorithm) Score(ctx context.Context, pod *v1.Pod, metrics
 		scores = append(scores, NodeScore{
 			NodeName: m.NodeName,
 			Score:    score,
-			Reason:   fmt.Sprintf("battery: %.1f%% (min: %.1f%%)", m.Battery.RemainingPercent, a.MinBattery),
+			Reason:   fmt.Sprintf("battery: %.1f%% usable %.1f%% (min: %.1f%%)", m.Battery.RemainingPercent, m.UsableBatteryPercent(), a.MinBattery),
 		})
 	}
 
@@ -49,6 +49,16 @@ func (a *DistanceBasedAlgorithm) Score(ctx context.Context, pod *v1.Pod, metrics
 	}
 
 	for _, m := range metrics {
+		// 位置未通过 GNSS 完整性检查（疑似欺骗/干扰）时距离不可信，给最低分
+		if m.GPS.PositionDegraded() {
+			scores = append(scores, NodeScore{
+				NodeName: m.NodeName,
+				Score:    0,
+				Reason:   "GNSS position degraded, distance untrusted",
+			})
+			continue
+		}
+
 		// 计算节点与目标位置的距离
 		distance := CalculateDistance(
 			m.GPS.Latitude, m.GPS.Longitude,
@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/scheduler/algorithm"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/config"
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// rolloutStats 单个分组（基线/金丝雀）的调度结果统计
+type rolloutStats struct {
+	decisions    int
+	failures     int
+	totalLatency time.Duration
+}
+
+func (s *rolloutStats) failureRate() float64 {
+	if s.decisions == 0 {
+		return 0
+	}
+	return float64(s.failures) / float64(s.decisions)
+}
+
+func (s *rolloutStats) avgLatency() time.Duration {
+	if s.decisions == 0 {
+		return 0
+	}
+	return s.totalLatency / time.Duration(s.decisions)
+}
+
+// CanaryRollout 调度算法灰度发布控制器
+// 新算法先作用于一定比例的调度决策或指定机队，若失败率或调度延迟 SLO
+// 相对基线出现退化，自动回滚到基线算法
+type CanaryRollout struct {
+	baseline algorithm.SchedulingAlgorithm
+	canary   algorithm.SchedulingAlgorithm
+	cfg      config.CanaryConfig
+	fleets   map[string]bool
+	log      *logrus.Logger
+
+	mu         sync.Mutex
+	stats      map[bool]*rolloutStats // key: 是否为金丝雀
+	rolledBack bool
+}
+
+// NewCanaryRollout 创建灰度发布控制器
+func NewCanaryRollout(baseline, canary algorithm.SchedulingAlgorithm, cfg config.CanaryConfig, log *logrus.Logger) *CanaryRollout {
+	fleets := make(map[string]bool, len(cfg.Fleets))
+	for _, f := range cfg.Fleets {
+		fleets[f] = true
+	}
+	return &CanaryRollout{
+		baseline: baseline,
+		canary:   canary,
+		cfg:      cfg,
+		fleets:   fleets,
+		log:      log,
+		stats: map[bool]*rolloutStats{
+			false: {},
+			true:  {},
+		},
+	}
+}
+
+// Select 为 Pod 选择算法，返回算法以及是否走金丝雀分组
+// 同一个 Pod 的多次调度尝试总是落在同一分组
+func (c *CanaryRollout) Select(pod *v1.Pod) (algorithm.SchedulingAlgorithm, bool) {
+	c.mu.Lock()
+	rolledBack := c.rolledBack
+	c.mu.Unlock()
+
+	if rolledBack {
+		return c.baseline, false
+	}
+
+	if len(c.fleets) > 0 {
+		if c.fleets[Fleet(pod)] {
+			return c.canary, true
+		}
+		return c.baseline, false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(pod.Namespace + "/" + pod.Name))
+	if int(h.Sum32()%100) < c.cfg.Percent {
+		return c.canary, true
+	}
+	return c.baseline, false
+}
+
+// Record 记录一次调度结果，并在金丝雀退化时触发回滚
+func (c *CanaryRollout) Record(canary bool, success bool, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats[canary]
+	stats.decisions++
+	stats.totalLatency += latency
+	if !success {
+		stats.failures++
+	}
+
+	if !canary || c.rolledBack {
+		return
+	}
+
+	if reason := c.regression(); reason != "" {
+		c.rolledBack = true
+		c.log.WithFields(logrus.Fields{
+			"audit":             true,
+			"action":            "canary-rollback",
+			"canary":            c.canary.Name(),
+			"baseline":          c.baseline.Name(),
+			"canaryDecisions":   stats.decisions,
+			"canaryFailureRate": stats.failureRate(),
+			"canaryAvgLatency":  stats.avgLatency().Milliseconds(),
+			"reason":            reason,
+		}).Warn("Canary algorithm rolled back")
+	}
+}
+
+// regression 判断金丝雀是否相对基线退化，返回原因（空字符串表示正常）
+func (c *CanaryRollout) regression() string {
+	canary := c.stats[true]
+	baseline := c.stats[false]
+
+	if canary.decisions < c.cfg.MinSamples {
+		return ""
+	}
+
+	if rate := canary.failureRate(); rate > c.cfg.MaxFailureRate && rate > baseline.failureRate() {
+		return fmt.Sprintf("failure rate %.2f exceeds %.2f (baseline %.2f)", rate, c.cfg.MaxFailureRate, baseline.failureRate())
+	}
+
+	if c.cfg.MaxLatency > 0 && canary.avgLatency() > c.cfg.MaxLatency {
+		return fmt.Sprintf("average scheduling latency %dms exceeds SLO %dms",
+			canary.avgLatency().Milliseconds(), c.cfg.MaxLatency.Milliseconds())
+	}
+
+	return ""
+}
+
+// RolledBack 返回金丝雀是否已被回滚
+func (c *CanaryRollout) RolledBack() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rolledBack
+}
@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/config"
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ClassLabel 节点的机型（计费用），未设置时使用 UAVMetrics 中的硬件型号
+const ClassLabel = "uav.k3s.io/class"
+
+// Chargeback 按命名空间统计 UAV 资源用量
+// 每个采样间隔内，本调度器绑定且处于 Running 的 Pod 计入 Pod 分钟数（按机型费率加权），
+// 节点电池放电功率（电压 × 电流）折算的能耗在该节点的 Pod 间平均分摊
+type Chargeback struct {
+	clientset     kubernetes.Interface
+	uavClient     *k8s.Client
+	schedulerName string
+	cfg           config.ChargebackConfig
+	log           *logrus.Logger
+
+	mu         sync.Mutex
+	since      time.Time
+	lastSample time.Time
+	usage      map[string]*NamespaceUsage
+}
+
+// NamespaceUsage 命名空间的累计用量
+type NamespaceUsage struct {
+	Namespace       string             `json:"namespace"`
+	PodMinutes      float64            `json:"podMinutes"`
+	ClassMinutes    map[string]float64 `json:"classMinutes"` // 各机型的 Pod 分钟数
+	WeightedMinutes float64            `json:"weightedMinutes"`
+	EnergyWh        float64            `json:"energyWh"`
+	Cost            float64            `json:"cost"`
+}
+
+// ChargebackReport 计费报表
+type ChargebackReport struct {
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"`
+	ClassRates map[string]float64 `json:"classRates"`
+	EnergyRate float64            `json:"energyRate"`
+	Namespaces []*NamespaceUsage  `json:"namespaces"`
+	TotalCost  float64            `json:"totalCost"`
+}
+
+// NewChargeback 创建用量统计
+func NewChargeback(clientset kubernetes.Interface, uavClient *k8s.Client, schedulerName string, cfg config.ChargebackConfig, log *logrus.Logger) *Chargeback {
+	now := time.Now()
+	return &Chargeback{
+		clientset:     clientset,
+		uavClient:     uavClient,
+		schedulerName: schedulerName,
+		cfg:           cfg,
+		log:           log,
+		since:         now,
+		lastSample:    now,
+		usage:         make(map[string]*NamespaceUsage),
+	}
+}
+
+// Run 按采样间隔累计用量，直到 ctx 取消
+func (c *Chargeback) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := c.sample(ctx, now); err != nil {
+				c.log.WithError(err).Warn("Failed to sample UAV resource usage")
+			}
+		}
+	}
+}
+
+// sample 将上次采样以来的用量计入各命名空间
+func (c *Chargeback) sample(ctx context.Context, now time.Time) error {
+	podList, err := c.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "spec.nodeName!=,status.phase=Running",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	metrics, err := c.uavClient.ListUAVMetrics(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list UAVMetrics: %w", err)
+	}
+	nodeList, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	byNode := make(map[string]*models.UAVMetrics, len(metrics))
+	for _, m := range metrics {
+		byNode[m.KubeNode()] = m
+	}
+	classes := make(map[string]string, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		classes[node.Name] = node.Labels[ClassLabel]
+	}
+
+	pods := []*v1.Pod{}
+	podsPerNode := make(map[string]int)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.SchedulerName != c.schedulerName || pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		pods = append(pods, pod)
+		podsPerNode[pod.Spec.NodeName]++
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elapsed := now.Sub(c.lastSample)
+	c.lastSample = now
+	if elapsed <= 0 {
+		return nil
+	}
+	minutes := elapsed.Minutes()
+
+	for _, pod := range pods {
+		nodeName := pod.Spec.NodeName
+		class := classes[nodeName]
+		if class == "" && byNode[nodeName] != nil && byNode[nodeName].Metadata != nil {
+			class = byNode[nodeName].Metadata.HardwareModel
+		}
+		rate := c.rate(class)
+		if class == "" {
+			class = "unknown"
+		}
+
+		energy := 0.0
+		if m := byNode[nodeName]; m != nil && m.Battery.Current < 0 && m.Battery.Voltage > 0 {
+			watts := m.Battery.Voltage * -m.Battery.Current
+			energy = watts * elapsed.Hours() / float64(podsPerNode[nodeName])
+		}
+
+		u := c.usage[pod.Namespace]
+		if u == nil {
+			u = &NamespaceUsage{Namespace: pod.Namespace, ClassMinutes: make(map[string]float64)}
+			c.usage[pod.Namespace] = u
+		}
+		u.PodMinutes += minutes
+		u.ClassMinutes[class] += minutes
+		u.WeightedMinutes += minutes * rate
+		u.EnergyWh += energy
+		u.Cost += minutes*rate + energy*c.cfg.EnergyRate
+	}
+	return nil
+}
+
+// rate 返回机型的每 Pod 分钟费率
+func (c *Chargeback) rate(class string) float64 {
+	if rate, ok := c.cfg.ClassRates[class]; ok {
+		return rate
+	}
+	return c.cfg.ClassRates[config.DefaultRateClass]
+}
+
+// Report 返回自上次重置以来的用量报表，reset 为 true 时开始新的计费周期
+func (c *Chargeback) Report(reset bool) *ChargebackReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &ChargebackReport{
+		From:       c.since,
+		To:         c.lastSample,
+		ClassRates: c.cfg.ClassRates,
+		EnergyRate: c.cfg.EnergyRate,
+		Namespaces: make([]*NamespaceUsage, 0, len(c.usage)),
+	}
+	for _, u := range c.usage {
+		copied := *u
+		copied.ClassMinutes = maps.Clone(u.ClassMinutes)
+		report.Namespaces = append(report.Namespaces, &copied)
+		report.TotalCost += u.Cost
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace
+	})
+
+	if reset {
+		c.since = c.lastSample
+		c.usage = make(map[string]*NamespaceUsage)
+	}
+	return report
+}
+
+// WriteCSV 以 CSV 导出报表，每个命名空间一行
+func (r *ChargebackReport) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"from", "to", "namespace", "pod_minutes", "weighted_minutes", "energy_wh", "cost"})
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', 3, 64) }
+	for _, u := range r.Namespaces {
+		out.Write([]string{
+			r.From.Format(time.RFC3339),
+			r.To.Format(time.RFC3339),
+			u.Namespace,
+			formatFloat(u.PodMinutes),
+			formatFloat(u.WeightedMinutes),
+			formatFloat(u.EnergyWh),
+			formatFloat(u.Cost),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
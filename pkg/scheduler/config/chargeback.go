@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRateClass 未单独配置费率的机型使用的费率键
+const DefaultRateClass = "*"
+
+// ChargebackConfig UAV 资源用量计费配置
+// 费用 = Pod 分钟数 × 机型费率 + 分摊的电池能耗（Wh）× 能耗费率，单位由使用方约定
+type ChargebackConfig struct {
+	Enabled    bool
+	Interval   time.Duration      // 采样间隔
+	ClassRates map[string]float64 // 每 Pod 分钟的费率，key 为机型，DefaultRateClass 为默认
+	EnergyRate float64            // 每 Wh 能耗的费率
+}
+
+// parseRates 解析逗号分隔的 name=rate 列表，格式错误的项解析为 -1，由 Validate 报错
+func parseRates(value string) map[string]float64 {
+	rates := make(map[string]float64)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rate, _ := strings.Cut(entry, "=")
+		f, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil {
+			f = -1
+		}
+		rates[strings.TrimSpace(name)] = f
+	}
+	return rates
+}
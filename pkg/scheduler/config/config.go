@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -26,11 +27,68 @@ type SchedulerConfig struct {
 	RetryAttempts int           // 失败重试次数
 	RetryDelay    time.Duration // 重试延迟
 
+	// Pod watch 断开后重连的最大退避时间
+	WatchMaxBackoff time.Duration
+
+	// 镜像预拉取（针对慢链路无人机）
+	PrePullEnabled    bool    // 是否启用镜像预拉取
+	PrePullTopN       int     // 在得分前 N 的候选节点上预拉取
+	PrePullMinLatency float64 // 触发预拉取的最低网络延迟（毫秒）
+
+	// 任务优先级抢占（资源不足时驱逐低优先级机队的 Pod）
+	// 先比较机队优先级（未配置的机队为 0），同一机队内再比较任务优先级
+	PreemptionEnabled bool
+	FleetPriorities   map[string]int
+
+	// 算法灰度发布
+	Canary CanaryConfig
+
+	// 机队配额（key 为机队，DefaultQuotaFleet 为默认），超出时 Pod 保持 Pending
+	// 并按 QuotaRetryInterval 重试
+	Quotas             map[string]FleetQuota
+	QuotaRetryInterval time.Duration
+
+	// 地理时间窗口（Pod 注解指定目标区域和时间窗口）：目标区域内没有无人机时按 WindowRetryInterval 重试，
+	// 并按航向和速度预测 WindowPredictionHorizon 内进入区域的无人机（0 表示不预测）
+	WindowRetryInterval     time.Duration
+	WindowPredictionHorizon time.Duration
+
+	// 按命名空间统计 UAV 资源用量，供内部计费
+	Chargeback ChargebackConfig
+
+	// 调度模拟沙箱：将待调度 Pod 镜像到沙箱命名空间，对模拟机队分别运行生产算法和候选算法
+	Sandbox SandboxConfig
+
+	// Kubernetes API 调用指标（/metrics，始终启用）
+	MetricsPort int
+
+	// 管理接口（暂停/恢复调度、清空队列），AdminPort 为 0 时不启用
+	AdminPort   int
+	AdminToken  string // Bearer token
+	StartPaused bool   // 以暂停状态启动（维护期间重启时使用）
+
 	// 日志配置
 	LogLevel          string
 	StructuredLogging bool
 }
 
+// CanaryConfig 算法灰度发布配置
+type CanaryConfig struct {
+	Algorithm      string        // 灰度算法名称（为空表示不启用）
+	Percent        int           // 走灰度算法的调度决策比例（0-100）
+	Fleets         []string      // 指定机队走灰度算法（设置后忽略 Percent）
+	MaxFailureRate float64       // 失败率超过此值且高于基线时回滚
+	MaxLatency     time.Duration // 平均调度延迟 SLO（0 表示不检查）
+	MinSamples     int           // 评估前金丝雀至少需要的调度次数
+}
+
+// SandboxConfig 调度模拟沙箱配置
+type SandboxConfig struct {
+	Namespace string        // 沙箱命名空间（为空表示不启用），模拟机队的 UAVMetrics 和影子 Pod 都在此命名空间
+	Algorithm string        // 与生产算法对比的候选算法名称
+	Retention time.Duration // 影子 Pod 的保留时间
+}
+
 // AlgorithmParams 算法参数
 type AlgorithmParams struct {
 	// Distance-based 算法参数
@@ -43,6 +101,9 @@ type AlgorithmParams struct {
 	// Network-latency 算法参数
 	MaxLatency float64
 
+	// Airtime-budget 算法参数（每日飞行时长预算，分钟）
+	AirtimeBudget float64
+
 	// Composite 算法参数
 	CompositeAlgorithms []string  // 子算法名称列表
 	CompositeWeights    []float64 // 对应权重
@@ -58,6 +119,39 @@ func DefaultConfig() *SchedulerConfig {
 		WorkerThreads:   getEnvIntOrDefault("WORKER_THREADS", 1),
 		RetryAttempts:   3,
 		RetryDelay:      2 * time.Second,
+		WatchMaxBackoff: getEnvDurationOrDefault("WATCH_MAX_BACKOFF", 30*time.Second),
+		PrePullEnabled:    getEnvBoolOrDefault("PREPULL_ENABLED", false),
+		PrePullTopN:       getEnvIntOrDefault("PREPULL_TOP_N", 3),
+		PrePullMinLatency: getEnvFloatOrDefault("PREPULL_MIN_LATENCY", 100.0),
+		PreemptionEnabled: getEnvBoolOrDefault("PREEMPTION_ENABLED", false),
+		FleetPriorities:   parseFleetPriorities(os.Getenv("FLEET_PRIORITIES")),
+		Canary: CanaryConfig{
+			Algorithm:      getEnvOrDefault("CANARY_ALGORITHM", ""),
+			Percent:        getEnvIntOrDefault("CANARY_PERCENT", 10),
+			Fleets:         getEnvListOrDefault("CANARY_FLEETS"),
+			MaxFailureRate: getEnvFloatOrDefault("CANARY_MAX_FAILURE_RATE", 0.2),
+			MaxLatency:     time.Duration(getEnvIntOrDefault("CANARY_MAX_LATENCY_MS", 0)) * time.Millisecond,
+			MinSamples:     getEnvIntOrDefault("CANARY_MIN_SAMPLES", 20),
+		},
+		Quotas:             parseFleetQuotas(os.Getenv("FLEET_QUOTAS")),
+		QuotaRetryInterval: getEnvDurationOrDefault("QUOTA_RETRY_INTERVAL", 30*time.Second),
+		WindowRetryInterval:     getEnvDurationOrDefault("GEO_WINDOW_RETRY_INTERVAL", 15*time.Second),
+		WindowPredictionHorizon: getEnvDurationOrDefault("GEO_WINDOW_PREDICTION_HORIZON", 10*time.Minute),
+		Chargeback: ChargebackConfig{
+			Enabled:    getEnvBoolOrDefault("CHARGEBACK_ENABLED", false),
+			Interval:   getEnvDurationOrDefault("CHARGEBACK_INTERVAL", time.Minute),
+			ClassRates: parseRates(getEnvOrDefault("CHARGEBACK_CLASS_RATES", "*=1")),
+			EnergyRate: getEnvFloatOrDefault("CHARGEBACK_ENERGY_RATE", 0),
+		},
+		Sandbox: SandboxConfig{
+			Namespace: getEnvOrDefault("SANDBOX_NAMESPACE", ""),
+			Algorithm: getEnvOrDefault("SANDBOX_ALGORITHM", ""),
+			Retention: getEnvDurationOrDefault("SANDBOX_RETENTION", 7*24*time.Hour),
+		},
+		MetricsPort:     getEnvIntOrDefault("METRICS_PORT", 9103),
+		AdminPort:       getEnvIntOrDefault("ADMIN_PORT", 0),
+		AdminToken:      getEnvOrDefault("ADMIN_TOKEN", ""),
+		StartPaused:     getEnvBoolOrDefault("START_PAUSED", false),
 		LogLevel:        getEnvOrDefault("LOG_LEVEL", "info"),
 		StructuredLogging: getEnvBoolOrDefault("STRUCTURED_LOGGING", false),
 		AlgorithmParams: AlgorithmParams{
@@ -65,6 +159,7 @@ func DefaultConfig() *SchedulerConfig {
 			TargetLongitude: getEnvFloatOrDefault("TARGET_LONGITUDE", -118.2437),
 			MinBattery:      getEnvFloatOrDefault("MIN_BATTERY", 30.0),
 			MaxLatency:      getEnvFloatOrDefault("MAX_LATENCY", 200.0),
+			AirtimeBudget:   getEnvFloatOrDefault("AIRTIME_BUDGET", 0),
 		},
 	}
 }
@@ -80,6 +175,75 @@ func (c *SchedulerConfig) Validate() error {
 	if c.WorkerThreads < 1 {
 		return fmt.Errorf("workerThreads must be >= 1")
 	}
+	if c.PrePullTopN < 1 {
+		return fmt.Errorf("prePullTopN must be >= 1")
+	}
+	if c.WatchMaxBackoff < time.Second {
+		return fmt.Errorf("watchMaxBackoff must be >= 1s")
+	}
+	for fleet, priority := range c.FleetPriorities {
+		if priority < 0 {
+			return fmt.Errorf("invalid priority for fleet %q, expected fleet:priority with priority >= 0", fleet)
+		}
+	}
+	for fleet, quota := range c.Quotas {
+		if quota.Pods < 0 || quota.CPU < 0 {
+			return fmt.Errorf("invalid quota for fleet %q, expected fleet:pods=N,cpu=Q", fleet)
+		}
+	}
+	if len(c.Quotas) > 0 && c.QuotaRetryInterval <= 0 {
+		return fmt.Errorf("quotaRetryInterval must be > 0")
+	}
+	if c.WindowRetryInterval <= 0 {
+		return fmt.Errorf("windowRetryInterval must be > 0")
+	}
+	if c.WindowPredictionHorizon < 0 {
+		return fmt.Errorf("windowPredictionHorizon must be >= 0")
+	}
+	if c.Chargeback.Enabled {
+		if c.AdminPort == 0 {
+			return fmt.Errorf("chargeback reports are served by the admin API, adminPort must be set")
+		}
+		if c.Chargeback.Interval <= 0 {
+			return fmt.Errorf("chargeback interval must be > 0")
+		}
+		for class, rate := range c.Chargeback.ClassRates {
+			if rate < 0 {
+				return fmt.Errorf("invalid chargeback rate for class %q, expected class=rate", class)
+			}
+		}
+		if c.Chargeback.EnergyRate < 0 {
+			return fmt.Errorf("chargeback energy rate must be >= 0")
+		}
+	}
+	if c.Sandbox.Namespace != "" {
+		if c.Sandbox.Namespace == c.Namespace {
+			return fmt.Errorf("sandbox namespace must differ from the scheduler namespace")
+		}
+		if c.Sandbox.Algorithm == "" {
+			return fmt.Errorf("sandbox algorithm is required when the sandbox namespace is set")
+		}
+		if c.Sandbox.Retention <= 0 {
+			return fmt.Errorf("sandbox retention must be > 0")
+		}
+	}
+	if c.MetricsPort < 1 || c.MetricsPort > 65535 {
+		return fmt.Errorf("metricsPort must be between 1 and 65535")
+	}
+	if c.AdminPort != 0 && c.AdminPort == c.MetricsPort {
+		return fmt.Errorf("adminPort must differ from metricsPort")
+	}
+	if c.AdminPort != 0 && c.AdminToken == "" {
+		return fmt.Errorf("adminToken is required when adminPort is set")
+	}
+	if c.Canary.Algorithm != "" {
+		if c.Canary.Algorithm == c.AlgorithmName {
+			return fmt.Errorf("canary algorithm must differ from algorithmName")
+		}
+		if c.Canary.Percent < 0 || c.Canary.Percent > 100 {
+			return fmt.Errorf("canary percent must be between 0 and 100")
+		}
+	}
 	return nil
 }
 
@@ -100,6 +264,16 @@ func getEnvBoolOrDefault(key string, defaultValue bool) bool {
 	return value == "true" || value == "1" || value == "yes"
 }
 
+func getEnvListOrDefault(key string) []string {
+	result := []string{}
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func getEnvIntOrDefault(key string, defaultValue int) int {
 	value := os.Getenv(key)
 	if value == "" {
@@ -125,3 +299,15 @@ func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
 	}
 	return result
 }
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+	return result
+}
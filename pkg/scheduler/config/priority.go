@@ -0,0 +1,33 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseFleetPriorities 解析 FLEET_PRIORITIES，格式为逗号分隔的 fleet:priority，例如
+//
+//	emergency-response:100,survey:10
+//
+// 未列出的机队优先级为 0；格式错误的项解析为 -1，由 Validate 报错
+func parseFleetPriorities(value string) map[string]int {
+	priorities := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fleet, priority, ok := strings.Cut(entry, ":")
+		fleet = strings.TrimSpace(fleet)
+		if !ok || fleet == "" {
+			priorities[entry] = -1
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(priority))
+		if err != nil || n < 0 {
+			n = -1
+		}
+		priorities[fleet] = n
+	}
+	return priorities
+}
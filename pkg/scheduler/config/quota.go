@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// DefaultQuotaFleet 未单独配置配额的机队使用的配额键
+const DefaultQuotaFleet = "*"
+
+// FleetQuota 机队在 UAV 节点上可运行的 Pod 上限，0 表示不限制
+type FleetQuota struct {
+	Pods int   // Pod 数
+	CPU  int64 // CPU 请求总量（millicores）
+}
+
+// parseFleetQuotas 解析 FLEET_QUOTAS，格式为分号分隔的 fleet:limit,limit，
+// limit 为 pods=N 或 cpu=数量（K8s 资源格式，如 4 或 2500m），例如
+//
+//	team-a:pods=10,cpu=4;team-b:cpu=2500m;*:pods=20
+//
+// 格式错误的项解析为 -1，由 Validate 报错
+func parseFleetQuotas(value string) map[string]FleetQuota {
+	quotas := make(map[string]FleetQuota)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fleet, limits, ok := strings.Cut(entry, ":")
+		fleet = strings.TrimSpace(fleet)
+		quota := FleetQuota{}
+		if !ok || fleet == "" {
+			quotas[entry] = FleetQuota{Pods: -1}
+			continue
+		}
+		for _, limit := range strings.Split(limits, ",") {
+			key, amount, _ := strings.Cut(strings.TrimSpace(limit), "=")
+			switch key {
+			case "pods":
+				n, err := strconv.Atoi(amount)
+				if err != nil {
+					n = -1
+				}
+				quota.Pods = n
+			case "cpu":
+				q, err := resource.ParseQuantity(amount)
+				if err != nil {
+					quota.CPU = -1
+				} else {
+					quota.CPU = q.MilliValue()
+				}
+			default:
+				quota.Pods = -1
+			}
+		}
+		quotas[fleet] = quota
+	}
+	return quotas
+}
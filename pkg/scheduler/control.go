@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SchedulingControl 调度暂停/恢复控制
+// 暂停期间新的待调度 Pod 进入队列而不绑定，恢复或清空队列时统一调度。
+// 用于机队维护或已知遥测数据不可信的时段
+type SchedulingControl struct {
+	mu       sync.Mutex
+	paused   bool
+	pausedAt time.Time
+	reason   string
+	queue    map[string]*v1.Pod // key: namespace/name
+	order    []string           // 入队顺序
+
+	// 通知调度循环处理队列（恢复或清空队列）
+	drain chan struct{}
+}
+
+// ControlStatus 调度控制状态
+type ControlStatus struct {
+	Paused   bool       `json:"paused"`
+	PausedAt *time.Time `json:"pausedAt,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+	Queued   []string   `json:"queued"` // 按入队顺序的 namespace/name
+}
+
+// NewSchedulingControl 创建调度控制，paused 为 true 时以暂停状态启动
+func NewSchedulingControl(paused bool) *SchedulingControl {
+	c := &SchedulingControl{
+		queue: make(map[string]*v1.Pod),
+		drain: make(chan struct{}, 1),
+	}
+	if paused {
+		c.Pause("paused at startup")
+	}
+	return c
+}
+
+// Pause 暂停调度，返回 false 表示已处于暂停状态
+func (c *SchedulingControl) Pause(reason string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		return false
+	}
+	c.paused = true
+	c.pausedAt = time.Now()
+	c.reason = reason
+	return true
+}
+
+// Resume 恢复调度并调度队列中的 Pod，返回 false 表示未处于暂停状态
+func (c *SchedulingControl) Resume() bool {
+	c.mu.Lock()
+	wasPaused := c.paused
+	c.paused = false
+	c.pausedAt = time.Time{}
+	c.reason = ""
+	c.mu.Unlock()
+
+	c.Drain()
+	return wasPaused
+}
+
+// Drain 立即调度队列中的 Pod（不改变暂停状态），返回队列长度
+func (c *SchedulingControl) Drain() int {
+	c.mu.Lock()
+	n := len(c.order)
+	c.mu.Unlock()
+
+	select {
+	case c.drain <- struct{}{}:
+	default: // 已有未处理的通知
+	}
+	return n
+}
+
+// Status 返回当前状态
+func (c *SchedulingControl) Status() ControlStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := ControlStatus{
+		Paused: c.paused,
+		Reason: c.reason,
+		Queued: append([]string{}, c.order...),
+	}
+	if c.paused {
+		pausedAt := c.pausedAt
+		status.PausedAt = &pausedAt
+	}
+	return status
+}
+
+// hold 暂停期间将 Pod 入队并返回 true；未暂停时返回 false，由调用方直接调度
+func (c *SchedulingControl) hold(pod *v1.Pod) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		return false
+	}
+	key := pod.Namespace + "/" + pod.Name
+	if _, queued := c.queue[key]; !queued {
+		c.order = append(c.order, key)
+	}
+	c.queue[key] = pod
+	return true
+}
+
+// remove 将已删除或已被调度的 Pod 移出队列
+func (c *SchedulingControl) remove(pod *v1.Pod) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := pod.Namespace + "/" + pod.Name
+	if _, queued := c.queue[key]; !queued {
+		return
+	}
+	delete(c.queue, key)
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// take 取出队列中的全部 Pod
+func (c *SchedulingControl) take() []*v1.Pod {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pods := make([]*v1.Pod, 0, len(c.order))
+	for _, key := range c.order {
+		pods = append(pods, c.queue[key])
+	}
+	c.queue = make(map[string]*v1.Pod)
+	c.order = nil
+	return pods
+}
+
+// drainQueue 按入队顺序调度队列中的 Pod，跳过已删除或已分配节点的 Pod
+func (s *Scheduler) drainQueue(ctx context.Context) {
+	pods := s.control.take()
+	if len(pods) == 0 {
+		return
+	}
+	s.log.WithField("pods", len(pods)).Info("Draining scheduling queue")
+
+	for _, queued := range pods {
+		if ctx.Err() != nil {
+			return
+		}
+		pod, err := s.k8sClientset.CoreV1().Pods(queued.Namespace).Get(ctx, queued.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			s.log.WithError(err).WithField("pod", queued.Name).Error("Failed to get queued pod")
+			continue
+		}
+		if pod.Spec.NodeName != "" {
+			continue
+		}
+
+		s.log.WithFields(logrus.Fields{
+			"pod":       pod.Name,
+			"namespace": pod.Namespace,
+		}).Info("Scheduling queued pod...")
+		if err := s.schedulePod(ctx, pod); err != nil {
+			s.log.WithError(err).WithField("pod", pod.Name).Error("Failed to schedule pod")
+		}
+	}
+}
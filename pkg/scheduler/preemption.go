@@ -0,0 +1,523 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/algorithm"
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// MissionPriorityAnnotation Pod 的任务优先级（emergency/high/routine/low 或整数）
+	MissionPriorityAnnotation = "uav.scheduler/mission-priority"
+
+	// FleetLabel Pod 所属机队，未设置时使用命名空间
+	FleetLabel = "uav.k3s.io/fleet"
+
+	// 任务优先级
+	PriorityEmergency = 1000
+	PriorityHigh      = 500
+	PriorityRoutine   = 100
+	PriorityLow       = 0
+
+	// victimWaitTimeout 等待被抢占 Pod 退出的最长时间，超时后放弃本次抢占
+	victimWaitTimeout = 60 * time.Second
+
+	// systemPriorityClassPrefix 系统 PriorityClass 的名称前缀（system-cluster-critical、system-node-critical）
+	systemPriorityClassPrefix = "system-"
+
+	// systemCriticalPriority 系统关键 Pod 的最低 spec.priority
+	systemCriticalPriority = 2000000000
+)
+
+// MissionPriority 解析 Pod 的任务优先级，默认 routine
+func MissionPriority(pod *v1.Pod) int {
+	value, ok := pod.Annotations[MissionPriorityAnnotation]
+	if !ok {
+		return PriorityRoutine
+	}
+
+	switch value {
+	case "emergency":
+		return PriorityEmergency
+	case "high":
+		return PriorityHigh
+	case "routine":
+		return PriorityRoutine
+	case "low":
+		return PriorityLow
+	}
+
+	if p, err := strconv.Atoi(value); err == nil {
+		return p
+	}
+	return PriorityRoutine
+}
+
+// Fleet 返回 Pod 所属机队
+func Fleet(pod *v1.Pod) string {
+	if fleet := pod.Labels[FleetLabel]; fleet != "" {
+		return fleet
+	}
+	return pod.Namespace
+}
+
+// Preemptor 任务优先级抢占
+// 当高优先级任务（如应急响应）在候选节点上资源不足时，
+// 驱逐该节点上低优先级任务（如例行测绘）的 Pod。
+// 先比较机队优先级，同一机队内再比较任务优先级。
+// 抢占者等待 victim 退出期间，提名节点上为其预留资源（记录在 status.nominatedNodeName），
+// 其他不能抢占它的 Pod 不会占用这部分资源
+type Preemptor struct {
+	clientset       kubernetes.Interface
+	events          *k8s.EventRecorder
+	schedulerName   string
+	fleetPriorities map[string]int
+	log             *logrus.Logger
+
+	mu          sync.Mutex
+	nominations map[string]*nomination // 已驱逐 victim、等待其退出的 Pod，key: namespace/name
+}
+
+// nomination 抢占者在提名节点上等待的 victim
+type nomination struct {
+	pod      *v1.Pod
+	node     string
+	victims  []*v1.Pod
+	deadline time.Time
+}
+
+// PreemptionPendingError 已驱逐低优先级 Pod，等待其退出后再绑定
+type PreemptionPendingError struct {
+	Node      string
+	Remaining int
+}
+
+func (e *PreemptionPendingError) Error() string {
+	return fmt.Sprintf("waiting for %d preempted pods to terminate on node %s", e.Remaining, e.Node)
+}
+
+// NewPreemptor 创建抢占器，只有由 schedulerName 调度的 Pod 可以被抢占，
+// fleetPriorities 中未列出的机队优先级为 0
+func NewPreemptor(clientset kubernetes.Interface, events *k8s.EventRecorder, schedulerName string, fleetPriorities map[string]int, log *logrus.Logger) *Preemptor {
+	return &Preemptor{
+		clientset:       clientset,
+		events:          events,
+		schedulerName:   schedulerName,
+		fleetPriorities: fleetPriorities,
+		log:             log,
+		nominations:     make(map[string]*nomination),
+	}
+}
+
+// FleetPriority 返回 Pod 所属机队的优先级
+func (p *Preemptor) FleetPriority(pod *v1.Pod) int {
+	return p.fleetPriorities[Fleet(pod)]
+}
+
+// outranks 判断 pod 是否可以抢占 victim：机队优先级高，或同机队内任务优先级高
+func (p *Preemptor) outranks(pod, victim *v1.Pod) bool {
+	if pf, vf := p.FleetPriority(pod), p.FleetPriority(victim); pf != vf {
+		return pf > vf
+	}
+	return MissionPriority(pod) > MissionPriority(victim)
+}
+
+// preemptible 判断 Pod 是否可以作为 victim：只抢占本调度器调度的工作负载，
+// 不抢占 DaemonSet Pod、静态（mirror）Pod、kube-system 中的 Pod 和系统关键 Pod
+func (p *Preemptor) preemptible(pod *v1.Pod) bool {
+	if pod.Spec.SchedulerName != p.schedulerName || pod.Namespace == metav1.NamespaceSystem {
+		return false
+	}
+	if _, ok := pod.Annotations[v1.MirrorPodAnnotationKey]; ok {
+		return false
+	}
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "DaemonSet" {
+			return false
+		}
+	}
+	if strings.HasPrefix(pod.Spec.PriorityClassName, systemPriorityClassPrefix) {
+		return false
+	}
+	return pod.Spec.Priority == nil || *pod.Spec.Priority < systemCriticalPriority
+}
+
+// SelectNode 按分数顺序选择资源足够的节点，必要时抢占低优先级 Pod
+// scores 必须已按分数降序排列。驱逐 victim 后不阻塞等待，返回 *PreemptionPendingError，
+// 由 retryPreemptions 在 victim 退出后重新调度
+func (p *Preemptor) SelectNode(ctx context.Context, pod *v1.Pod, scores []algorithm.NodeScore) (string, error) {
+	// 已在等待 victim 退出
+	if n := p.nominated(pod); n != nil {
+		remaining, err := p.remainingVictims(ctx, n)
+		if err != nil {
+			return "", err
+		}
+		if remaining > 0 {
+			if time.Now().After(n.deadline) {
+				p.forget(pod)
+				p.setNominatedNode(ctx, pod, "")
+				return "", fmt.Errorf("timed out waiting for %d preempted pods to terminate on node %s", remaining, n.node)
+			}
+			return "", &PreemptionPendingError{Node: n.node, Remaining: remaining}
+		}
+		p.forget(pod)
+		if fits, err := p.fits(ctx, pod, n.node); err == nil && fits {
+			return n.node, nil
+		}
+		// 释放的资源已被占用，重新选择
+		p.setNominatedNode(ctx, pod, "")
+	}
+
+	// 先找无需抢占即可容纳的节点
+	for _, s := range scores {
+		fits, err := p.fits(ctx, pod, s.NodeName)
+		if err != nil {
+			p.log.WithError(err).WithField("node", s.NodeName).Debug("Resource fit check failed")
+			continue
+		}
+		if fits {
+			return s.NodeName, nil
+		}
+	}
+
+	// 所有节点资源不足，尝试在得分最高的可抢占节点上驱逐低优先级 Pod
+	for _, s := range scores {
+		victims, err := p.selectVictims(ctx, pod, s.NodeName)
+		if err != nil || len(victims) == 0 {
+			continue
+		}
+
+		// 先试运行全部驱逐，任一被 PodDisruptionBudget 拒绝时不驱逐该节点上的任何 Pod
+		if err := p.dryRunEvictions(ctx, victims); err != nil {
+			p.log.WithError(err).WithField("node", s.NodeName).Debug("Preemption rejected by eviction dry run")
+			continue
+		}
+
+		for _, victim := range victims {
+			if err := p.evict(ctx, victim, false); err != nil && !apierrors.IsNotFound(err) {
+				return "", fmt.Errorf("failed to evict %s/%s: %w", victim.Namespace, victim.Name, err)
+			}
+			p.recordPreemption(pod, victim, s.NodeName)
+		}
+
+		p.nominate(pod, s.NodeName, victims)
+		recordUnschedulable(ctx, p.clientset, p.events, pod, v1.PodReasonUnschedulable,
+			fmt.Sprintf("preempted lower-priority pods on node %s, waiting for them to terminate", s.NodeName), p.log)
+		p.setNominatedNode(ctx, pod, s.NodeName)
+		return "", &PreemptionPendingError{Node: s.NodeName, Remaining: len(victims)}
+	}
+
+	return "", fmt.Errorf("no node has enough resources and no lower-priority pods can be preempted")
+}
+
+// remainingVictims 统计仍未退出的 victim（同名 Pod 的 UID 不同视为已退出后重建）
+func (p *Preemptor) remainingVictims(ctx context.Context, n *nomination) (int, error) {
+	remaining := 0
+	for _, victim := range n.victims {
+		current, err := p.clientset.CoreV1().Pods(victim.Namespace).Get(ctx, victim.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get preempted pod %s/%s: %w", victim.Namespace, victim.Name, err)
+		}
+		if current.UID == victim.UID {
+			remaining++
+		}
+	}
+	return remaining, nil
+}
+
+// nominate 记录抢占者等待的 victim
+func (p *Preemptor) nominate(pod *v1.Pod, nodeName string, victims []*v1.Pod) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nominations[pod.Namespace+"/"+pod.Name] = &nomination{
+		pod:      pod,
+		node:     nodeName,
+		victims:  victims,
+		deadline: time.Now().Add(victimWaitTimeout),
+	}
+}
+
+// setNominatedNode 在 Pod 的 status.nominatedNodeName 中记录提名节点，nodeName 为空时清除
+func (p *Preemptor) setNominatedNode(ctx context.Context, pod *v1.Pod, nodeName string) {
+	var value interface{}
+	if nodeName != "" {
+		value = nodeName
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"nominatedNodeName": value},
+	})
+	if err != nil {
+		return
+	}
+	if _, err := p.clientset.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status"); err != nil {
+		p.log.WithError(err).WithField("pod", pod.Namespace+"/"+pod.Name).Debug("Failed to set nominated node")
+	}
+}
+
+// reserved 返回提名到节点、pod 不能抢占的其他 Pod 的资源请求之和
+func (p *Preemptor) reserved(pod *v1.Pod, nodeName string) (int64, int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var cpu, mem int64
+	for _, n := range p.nominations {
+		if n.node != nodeName || n.pod.UID == pod.UID || p.outranks(pod, n.pod) {
+			continue
+		}
+		c, m := podRequests(n.pod)
+		cpu += c
+		mem += m
+	}
+	return cpu, mem
+}
+
+// nominated 返回 Pod 当前的抢占提名
+func (p *Preemptor) nominated(pod *v1.Pod) *nomination {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nominations[pod.Namespace+"/"+pod.Name]
+}
+
+// forget 移除 Pod 的抢占提名
+func (p *Preemptor) forget(pod *v1.Pod) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.nominations, pod.Namespace+"/"+pod.Name)
+}
+
+// pending 返回全部等待 victim 退出的 Pod
+func (p *Preemptor) pending() []*v1.Pod {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pods := make([]*v1.Pod, 0, len(p.nominations))
+	for _, n := range p.nominations {
+		pods = append(pods, n.pod)
+	}
+	return pods
+}
+
+// retryPreemptions 重新调度等待 victim 退出的 Pod
+func (s *Scheduler) retryPreemptions(ctx context.Context) {
+	for _, pending := range s.preemptor.pending() {
+		if ctx.Err() != nil {
+			return
+		}
+		pod, err := s.k8sClientset.CoreV1().Pods(pending.Namespace).Get(ctx, pending.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			s.preemptor.forget(pending)
+			continue
+		}
+		if err != nil {
+			s.log.WithError(err).WithField("pod", pending.Name).Debug("Failed to get pod waiting for preemption")
+			continue
+		}
+		if pod.Spec.NodeName != "" {
+			s.preemptor.forget(pod)
+			continue
+		}
+		if s.control.hold(pod) {
+			continue
+		}
+		if err := s.schedulePod(ctx, pod); err != nil {
+			s.log.WithError(err).WithField("pod", pod.Name).Debug("Pod still waiting for preemption")
+		}
+	}
+}
+
+// selectVictims 选出为容纳 pod 需要驱逐的最少低优先级 Pod（机队优先级、任务优先级从低到高）
+func (p *Preemptor) selectVictims(ctx context.Context, pod *v1.Pod, nodeName string) ([]*v1.Pod, error) {
+	usage, err := p.usage(ctx, pod, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []*v1.Pod{}
+	for i := range usage.running {
+		if p.outranks(pod, &usage.running[i]) {
+			candidates = append(candidates, &usage.running[i])
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return p.outranks(candidates[j], candidates[i])
+	})
+
+	allocatable := usage.allocatable
+	cpu, mem := usage.cpu, usage.mem
+	needCPU, needMem := podRequests(pod)
+
+	victims := []*v1.Pod{}
+	for _, c := range candidates {
+		if cpu+needCPU <= allocatable.cpu && mem+needMem <= allocatable.mem {
+			break
+		}
+		victimCPU, victimMem := podRequests(c)
+		cpu -= victimCPU
+		mem -= victimMem
+		victims = append(victims, c)
+	}
+
+	if cpu+needCPU > allocatable.cpu || mem+needMem > allocatable.mem {
+		return nil, nil // 即使全部驱逐也无法容纳
+	}
+	return victims, nil
+}
+
+// fits 检查 pod 的资源请求是否能被节点容纳
+func (p *Preemptor) fits(ctx context.Context, pod *v1.Pod, nodeName string) (bool, error) {
+	usage, err := p.usage(ctx, pod, nodeName)
+	if err != nil {
+		return false, err
+	}
+	needCPU, needMem := podRequests(pod)
+	return usage.cpu+needCPU <= usage.allocatable.cpu && usage.mem+needMem <= usage.allocatable.mem, nil
+}
+
+// nodeUsage 从 pod 的角度看节点的资源占用
+type nodeUsage struct {
+	allocatable nodeResources
+	running     []v1.Pod // 运行中、可以被抢占的 Pod（抢占候选）
+	cpu         int64    // 运行中和正在退出的 Pod，以及提名到该节点、pod 不能抢占的 Pod 的请求之和
+	mem         int64
+}
+
+// usage 统计节点的资源占用。正在退出的 Pod（如已驱逐的 victim）在退出前仍占用资源，
+// 提名到该节点的抢占者的资源也已预留
+func (p *Preemptor) usage(ctx context.Context, pod *v1.Pod, nodeName string) (nodeUsage, error) {
+	allocatable, err := p.allocatable(ctx, nodeName)
+	if err != nil {
+		return nodeUsage{}, err
+	}
+
+	podList, err := p.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + nodeName,
+	})
+	if err != nil {
+		return nodeUsage{}, fmt.Errorf("failed to list pods on %s: %w", nodeName, err)
+	}
+
+	usage := nodeUsage{allocatable: allocatable, running: []v1.Pod{}}
+	for _, item := range podList.Items {
+		if item.Status.Phase == v1.PodSucceeded || item.Status.Phase == v1.PodFailed {
+			continue
+		}
+		cpu, mem := podRequests(&item)
+		usage.cpu += cpu
+		usage.mem += mem
+		if item.DeletionTimestamp == nil && p.preemptible(&item) {
+			usage.running = append(usage.running, item)
+		}
+	}
+
+	cpu, mem := p.reserved(pod, nodeName)
+	usage.cpu += cpu
+	usage.mem += mem
+	return usage, nil
+}
+
+type nodeResources struct {
+	cpu int64 // millicores
+	mem int64 // bytes
+}
+
+func (p *Preemptor) allocatable(ctx context.Context, nodeName string) (nodeResources, error) {
+	node, err := p.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nodeResources{}, fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	return nodeResources{
+		cpu: quantityMilli(node.Status.Allocatable, v1.ResourceCPU),
+		mem: quantityValue(node.Status.Allocatable, v1.ResourceMemory),
+	}, nil
+}
+
+// evict 通过 Eviction API 驱逐 Pod（遵守 PodDisruptionBudget），dryRun 时只校验不驱逐
+func (p *Preemptor) evict(ctx context.Context, victim *v1.Pod, dryRun bool) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      victim.Name,
+			Namespace: victim.Namespace,
+		},
+		DeleteOptions: &metav1.DeleteOptions{
+			Preconditions: metav1.NewUIDPreconditions(string(victim.UID)),
+		},
+	}
+	if dryRun {
+		eviction.DeleteOptions.DryRun = []string{metav1.DryRunAll}
+	}
+	return p.clientset.CoreV1().Pods(victim.Namespace).EvictV1(ctx, eviction)
+}
+
+// dryRunEvictions 试运行全部驱逐，任一被拒绝时返回错误
+func (p *Preemptor) dryRunEvictions(ctx context.Context, victims []*v1.Pod) error {
+	for _, victim := range victims {
+		if err := p.evict(ctx, victim, true); err != nil {
+			return fmt.Errorf("eviction of %s/%s rejected: %w", victim.Namespace, victim.Name, err)
+		}
+	}
+	return nil
+}
+
+// recordPreemption 将抢占决策写入审计记录（结构化日志 + Kubernetes Event）
+func (p *Preemptor) recordPreemption(preemptor, victim *v1.Pod, nodeName string) {
+	message := fmt.Sprintf("Preempted by %s/%s (fleet %s, fleet priority %d, priority %d) on node %s",
+		preemptor.Namespace, preemptor.Name, Fleet(preemptor), p.FleetPriority(preemptor), MissionPriority(preemptor), nodeName)
+
+	p.log.WithFields(logrus.Fields{
+		"audit":                  true,
+		"action":                 "preempt",
+		"preemptor":              preemptor.Namespace + "/" + preemptor.Name,
+		"preemptorFleet":         Fleet(preemptor),
+		"preemptorFleetPriority": p.FleetPriority(preemptor),
+		"preemptorPriority":      MissionPriority(preemptor),
+		"victim":                 victim.Namespace + "/" + victim.Name,
+		"victimFleet":            Fleet(victim),
+		"victimFleetPriority":    p.FleetPriority(victim),
+		"victimPriority":         MissionPriority(victim),
+		"node":                   nodeName,
+	}).Warn("Pod preempted")
+
+	p.events.PodEvent(victim, v1.EventTypeWarning, "Preempted", message)
+}
+
+// 资源计算辅助函数
+
+func podRequests(pod *v1.Pod) (int64, int64) {
+	var cpu, mem int64
+	for _, c := range pod.Spec.Containers {
+		cpu += quantityMilli(c.Resources.Requests, v1.ResourceCPU)
+		mem += quantityValue(c.Resources.Requests, v1.ResourceMemory)
+	}
+	return cpu, mem
+}
+
+func quantityMilli(list v1.ResourceList, name v1.ResourceName) int64 {
+	if q, ok := list[name]; ok {
+		return q.MilliValue()
+	}
+	return 0
+}
+
+func quantityValue(list v1.ResourceList, name v1.ResourceName) int64 {
+	if q, ok := list[name]; ok {
+		return q.Value()
+	}
+	return 0
+}
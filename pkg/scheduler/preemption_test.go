@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testSchedulerName = "uav-scheduler"
+
+func testPod(namespace, name, priority string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			UID:         types.UID(namespace + "-" + name),
+			Annotations: map[string]string{MissionPriorityAnnotation: priority},
+		},
+		Spec: v1.PodSpec{
+			NodeName:      "uav-node-1",
+			SchedulerName: testSchedulerName,
+			Containers: []v1.Container{{
+				Name: "app",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+				},
+			}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func TestSelectVictimsSkipsSystemPods(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "uav-node-1"},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("2"),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+		},
+	}
+
+	daemon := testPod("uav", "uav-agent-x7k2p", "low")
+	daemon.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "uav-agent"}}
+	system := testPod(metav1.NamespaceSystem, "coredns-5d78c9869d-q2xkz", "low")
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	preemptor := NewPreemptor(fake.NewClientset(node, daemon, system), nil, testSchedulerName, nil, log)
+
+	emergency := testPod("uav", "search-and-rescue", "emergency")
+	emergency.Spec.NodeName = ""
+
+	// The node is full, and evicting either low-priority pod would make room
+	victims, err := preemptor.selectVictims(context.Background(), emergency, node.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, victim := range victims {
+		t.Errorf("selected %s/%s as victim", victim.Namespace, victim.Name)
+	}
+}
+
+func TestPreemptible(t *testing.T) {
+	systemPriority := int32(systemCriticalPriority)
+	preemptor := NewPreemptor(fake.NewClientset(), nil, testSchedulerName, nil, logrus.New())
+
+	tests := []struct {
+		name   string
+		mutate func(*v1.Pod)
+		want   bool
+	}{
+		{"workload", func(*v1.Pod) {}, true},
+		{"other scheduler", func(pod *v1.Pod) { pod.Spec.SchedulerName = "default-scheduler" }, false},
+		{"kube-system", func(pod *v1.Pod) { pod.Namespace = metav1.NamespaceSystem }, false},
+		{"mirror pod", func(pod *v1.Pod) { pod.Annotations[v1.MirrorPodAnnotationKey] = "hash" }, false},
+		{"daemonset", func(pod *v1.Pod) {
+			pod.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "uav-agent"}}
+		}, false},
+		{"system priority class", func(pod *v1.Pod) { pod.Spec.PriorityClassName = "system-node-critical" }, false},
+		{"system priority", func(pod *v1.Pod) { pod.Spec.Priority = &systemPriority }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := testPod("uav", "survey", "low")
+			tt.mutate(pod)
+			if got := preemptor.preemptible(pod); got != tt.want {
+				t.Errorf("preemptible = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
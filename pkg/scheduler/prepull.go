@@ -0,0 +1,249 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/algorithm"
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// prePullLabel 预拉取 Pod 的标签，用于清理
+	prePullLabel = "uav.scheduler/image-prepull"
+
+	// prePullTTL 预拉取 Pod 的最长存活时间
+	prePullTTL = 30 * time.Minute
+)
+
+// prePullRequests 预拉取 Pod 的资源请求：容器只执行一条命令，但仍需计入节点容量
+var prePullRequests = v1.ResourceList{
+	v1.ResourceCPU:    resource.MustParse("10m"),
+	v1.ResourceMemory: resource.MustParse("16Mi"),
+}
+
+// ImagePrePuller 镜像预拉取控制器
+// 对于调度到远距离（高延迟链路）无人机的 Pod，在绑定前于选中节点上开始拉取镜像，
+// 抢占时在等待 victim 退出期间拉取，降低 Pod 的启动延迟；
+// 同时在得分靠前的候选节点上拉取，降低后续启动（重调度、扩容）时的延迟。
+// 预拉取 Pod 创建在工作负载的命名空间中，使用其 imagePullSecrets 和容忍度
+type ImagePrePuller struct {
+	clientset  kubernetes.Interface
+	topN       int
+	minLatency float64 // 触发预拉取的最低网络延迟（毫秒）
+	log        *logrus.Logger
+
+	// 已下发的预拉取任务（key: node/image），避免重复创建
+	inflight map[string]time.Time
+	mu       sync.Mutex
+}
+
+// NewImagePrePuller 创建镜像预拉取控制器
+func NewImagePrePuller(clientset kubernetes.Interface, topN int, minLatency float64, log *logrus.Logger) *ImagePrePuller {
+	if topN <= 0 {
+		topN = 3
+	}
+	return &ImagePrePuller{
+		clientset:  clientset,
+		topN:       topN,
+		minLatency: minLatency,
+		log:        log,
+		inflight:   make(map[string]time.Time),
+	}
+}
+
+// PrePull 在 chosenNode 和前 N 个候选节点上预拉取 Pod 的镜像
+// scores 必须已按分数降序排列；chosenNode 为即将绑定或抢占提名的节点，不在前 N 名时也会预拉取
+func (p *ImagePrePuller) PrePull(ctx context.Context, pod *v1.Pod, scores []algorithm.NodeScore, metrics []*models.UAVMetrics, chosenNode string) {
+	images := podImages(pod)
+	if len(images) == 0 {
+		return
+	}
+
+	metricsByNode := make(map[string]*models.UAVMetrics, len(metrics))
+	for _, m := range metrics {
+		metricsByNode[m.KubeNode()] = m
+	}
+
+	candidates := []string{chosenNode}
+	for i, s := range scores {
+		if i >= p.topN {
+			break
+		}
+		if s.NodeName != chosenNode {
+			candidates = append(candidates, s.NodeName)
+		}
+	}
+
+	for _, nodeName := range candidates {
+		// 只对慢链路（远距离）节点预拉取
+		m := metricsByNode[nodeName]
+		if m == nil || m.Network == nil || m.Network.Latency < p.minLatency {
+			continue
+		}
+
+		present, err := p.nodeImages(ctx, nodeName)
+		if err != nil {
+			p.log.WithError(err).WithField("node", nodeName).Debug("Failed to read node images")
+		}
+
+		for _, image := range images {
+			if present[image] || !p.markInflight(nodeName, image) {
+				continue
+			}
+			if err := p.createPrePullPod(ctx, pod, nodeName, image); err != nil {
+				p.clearInflight(nodeName, image)
+				p.log.WithError(err).WithFields(logrus.Fields{
+					"node":  nodeName,
+					"image": image,
+				}).Warn("Failed to create image pre-pull pod")
+				continue
+			}
+			p.log.WithFields(logrus.Fields{
+				"pod":     pod.Name,
+				"node":    nodeName,
+				"image":   image,
+				"latency": m.Network.Latency,
+			}).Info("Image pre-pull started")
+		}
+	}
+}
+
+// Run 周期性清理已完成或超时的预拉取 Pod
+func (p *ImagePrePuller) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.cleanup(ctx)
+		}
+	}
+}
+
+// cleanup 删除已结束或超过 TTL 的预拉取 Pod
+func (p *ImagePrePuller) cleanup(ctx context.Context) {
+	pods, err := p.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		LabelSelector: prePullLabel + "=true",
+	})
+	if err != nil {
+		p.log.WithError(err).Warn("Failed to list pre-pull pods")
+		return
+	}
+
+	for _, pod := range pods.Items {
+		finished := pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+		expired := time.Since(pod.CreationTimestamp.Time) > prePullTTL
+		if !finished && !expired {
+			continue
+		}
+
+		err := p.clientset.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil {
+			p.log.WithError(err).WithField("pod", pod.Name).Debug("Failed to delete pre-pull pod")
+			continue
+		}
+		p.clearInflight(pod.Spec.NodeName, pod.Annotations[prePullLabel+"-image"])
+	}
+}
+
+// createPrePullPod 在指定节点上创建一次性 Pod 以触发 workload 镜像的拉取
+// 容器命令即使因镜像中没有 shell 而失败，镜像也已经被拉取到节点上。
+// 只容忍 workload 容忍的污点，不会出现在 workload 本身无法运行的节点上
+func (p *ImagePrePuller) createPrePullPod(ctx context.Context, workload *v1.Pod, nodeName, image string) error {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "uav-prepull-",
+			Namespace:    workload.Namespace,
+			Labels: map[string]string{
+				prePullLabel: "true",
+			},
+			Annotations: map[string]string{
+				prePullLabel + "-image": image,
+			},
+		},
+		Spec: v1.PodSpec{
+			NodeName:         nodeName, // 直接指定节点，绕过调度
+			RestartPolicy:    v1.RestartPolicyNever,
+			ImagePullSecrets: append([]v1.LocalObjectReference(nil), workload.Spec.ImagePullSecrets...),
+			Containers: []v1.Container{{
+				Name:            "prepull",
+				Image:           image,
+				ImagePullPolicy: v1.PullIfNotPresent,
+				Command:         []string{"/bin/sh", "-c", "exit 0"},
+				Resources: v1.ResourceRequirements{
+					Requests: prePullRequests.DeepCopy(),
+				},
+			}},
+			Tolerations: append([]v1.Toleration(nil), workload.Spec.Tolerations...),
+		},
+	}
+
+	_, err := p.clientset.CoreV1().Pods(workload.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	return err
+}
+
+// nodeImages 返回节点上已存在的镜像集合
+func (p *ImagePrePuller) nodeImages(ctx context.Context, nodeName string) (map[string]bool, error) {
+	node, err := p.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+
+	present := make(map[string]bool)
+	for _, img := range node.Status.Images {
+		for _, name := range img.Names {
+			present[name] = true
+			// 节点上的镜像名通常带 registry 前缀，例如 docker.io/library/nginx:alpine
+			present[strings.TrimPrefix(name, "docker.io/library/")] = true
+			present[strings.TrimPrefix(name, "docker.io/")] = true
+		}
+	}
+	return present, nil
+}
+
+func (p *ImagePrePuller) markInflight(nodeName, image string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := nodeName + "/" + image
+	if started, ok := p.inflight[key]; ok && time.Since(started) < prePullTTL {
+		return false
+	}
+	p.inflight[key] = time.Now()
+	return true
+}
+
+func (p *ImagePrePuller) clearInflight(nodeName, image string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.inflight, nodeName+"/"+image)
+}
+
+// podImages 返回 Pod 使用的所有镜像（去重）
+func podImages(pod *v1.Pod) []string {
+	seen := make(map[string]bool)
+	images := []string{}
+	containers := append([]v1.Container{}, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+	for _, c := range containers {
+		if c.Image == "" || seen[c.Image] {
+			continue
+		}
+		seen[c.Image] = true
+		images = append(images, c.Image)
+	}
+	return images
+}
@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/config"
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// FleetQuotas 机队配额
+// 限制每个机队（见 Fleet）在 UAV 节点（由本调度器绑定的 Pod 所在节点）上
+// 运行的 Pod 数和 CPU 请求总量，避免共享机队被单个团队占满。
+// 超出配额的 Pod 保持 Pending，记录 FailedScheduling 事件，配额释放后重试
+type FleetQuotas struct {
+	clientset     kubernetes.Interface
+	events        *k8s.EventRecorder
+	schedulerName string
+	quotas        map[string]config.FleetQuota
+	log           *logrus.Logger
+
+	mu      sync.Mutex
+	blocked map[string]*v1.Pod // 因配额等待的 Pod，key: namespace/name
+}
+
+// QuotaExceededError 机队配额不足
+type QuotaExceededError struct {
+	Fleet    string
+	Resource string // pods 或 cpu
+	Used     int64
+	Request  int64
+	Limit    int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	if e.Resource == "cpu" {
+		return fmt.Sprintf("fleet %s exceeds its cpu quota: %s used + %s requested > %s",
+			e.Fleet, milliString(e.Used), milliString(e.Request), milliString(e.Limit))
+	}
+	return fmt.Sprintf("fleet %s exceeds its pod quota: %d running, limit %d", e.Fleet, e.Used, e.Limit)
+}
+
+// NewFleetQuotas 创建机队配额检查
+func NewFleetQuotas(clientset kubernetes.Interface, events *k8s.EventRecorder, schedulerName string, quotas map[string]config.FleetQuota, log *logrus.Logger) *FleetQuotas {
+	return &FleetQuotas{
+		clientset:     clientset,
+		events:        events,
+		schedulerName: schedulerName,
+		quotas:        quotas,
+		log:           log,
+		blocked:       make(map[string]*v1.Pod),
+	}
+}
+
+// quotaFor 返回机队的配额，未单独配置时使用默认配额
+func (q *FleetQuotas) quotaFor(fleet string) (config.FleetQuota, bool) {
+	if quota, ok := q.quotas[fleet]; ok {
+		return quota, true
+	}
+	quota, ok := q.quotas[config.DefaultQuotaFleet]
+	return quota, ok
+}
+
+// Check 检查绑定 pod 后机队是否仍在配额内，超出时返回 *QuotaExceededError
+func (q *FleetQuotas) Check(ctx context.Context, pod *v1.Pod) error {
+	fleet := Fleet(pod)
+	quota, ok := q.quotaFor(fleet)
+	if !ok || (quota.Pods == 0 && quota.CPU == 0) {
+		return nil
+	}
+
+	pods, cpu, err := q.usage(ctx, fleet)
+	if err != nil {
+		return err
+	}
+
+	if quota.Pods > 0 && pods+1 > quota.Pods {
+		return &QuotaExceededError{Fleet: fleet, Resource: "pods", Used: int64(pods), Request: 1, Limit: int64(quota.Pods)}
+	}
+	needCPU, _ := podRequests(pod)
+	if quota.CPU > 0 && cpu+needCPU > quota.CPU {
+		return &QuotaExceededError{Fleet: fleet, Resource: "cpu", Used: cpu, Request: needCPU, Limit: quota.CPU}
+	}
+	return nil
+}
+
+// usage 统计机队已绑定到 UAV 节点且未结束的 Pod 数和 CPU 请求总量
+func (q *FleetQuotas) usage(ctx context.Context, fleet string) (int, int64, error) {
+	podList, err := q.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "spec.nodeName!=",
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list pods for quota: %w", err)
+	}
+
+	pods := 0
+	var cpu int64
+	for i := range podList.Items {
+		item := &podList.Items[i]
+		if item.Spec.NodeName == "" || item.Spec.SchedulerName != q.schedulerName || Fleet(item) != fleet {
+			continue
+		}
+		if item.Status.Phase == v1.PodSucceeded || item.Status.Phase == v1.PodFailed || item.DeletionTimestamp != nil {
+			continue
+		}
+		pods++
+		c, _ := podRequests(item)
+		cpu += c
+	}
+	return pods, cpu, nil
+}
+
+// block 记录因配额等待的 Pod，并以 FailedScheduling 事件和 PodScheduled 条件说明原因
+func (q *FleetQuotas) block(ctx context.Context, pod *v1.Pod, reason error) {
+	q.wait(pod)
+
+	message := reason.Error()
+	q.log.WithFields(logrus.Fields{
+		"pod":       pod.Name,
+		"namespace": pod.Namespace,
+		"fleet":     Fleet(pod),
+	}).Warn("Fleet quota exceeded, pod left pending")
+
+	recordUnschedulable(ctx, q.clientset, q.events, pod, v1.PodReasonUnschedulable, message, q.log)
+}
+
+// wait 将 Pod 加入配额等待列表
+func (q *FleetQuotas) wait(pod *v1.Pod) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.blocked[pod.Namespace+"/"+pod.Name] = pod
+}
+
+// unblock 将 Pod 移出配额等待列表
+func (q *FleetQuotas) unblock(pod *v1.Pod) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.blocked, pod.Namespace+"/"+pod.Name)
+}
+
+// takeBlocked 取出全部因配额等待的 Pod
+func (q *FleetQuotas) takeBlocked() []*v1.Pod {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pods := make([]*v1.Pod, 0, len(q.blocked))
+	for _, pod := range q.blocked {
+		pods = append(pods, pod)
+	}
+	q.blocked = make(map[string]*v1.Pod)
+	return pods
+}
+
+// retryBlocked 重新调度因配额等待的 Pod（仍超出配额的会再次进入等待列表）
+func (s *Scheduler) retryBlocked(ctx context.Context) {
+	for _, blocked := range s.quotas.takeBlocked() {
+		if ctx.Err() != nil {
+			return
+		}
+		pod, err := s.k8sClientset.CoreV1().Pods(blocked.Namespace).Get(ctx, blocked.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			s.log.WithError(err).WithField("pod", blocked.Name).Debug("Failed to get pod waiting for quota")
+			s.quotas.wait(blocked)
+			continue
+		}
+		if pod.Spec.NodeName != "" || s.control.hold(pod) {
+			continue
+		}
+		if err := s.schedulePod(ctx, pod); err != nil {
+			s.log.WithError(err).WithField("pod", pod.Name).Debug("Pod still waiting for quota")
+		}
+	}
+}
+
+func milliString(milli int64) string {
+	return resource.NewMilliQuantity(milli, resource.DecimalSI).String()
+}
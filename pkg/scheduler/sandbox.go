@@ -0,0 +1,401 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/algorithm"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/config"
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// sandboxSchedulerName 影子 Pod 的 schedulerName，没有调度器处理，影子 Pod 始终保持 Pending
+	sandboxSchedulerName = "uav-sandbox"
+
+	// sandboxSourceLabel 影子 Pod 的标签，值为源 Pod 的 UID
+	sandboxSourceLabel = "uav.scheduler/sandbox-source"
+
+	// sandboxDecisionAnnotation 影子 Pod 上记录的 SandboxDecision（JSON）
+	sandboxDecisionAnnotation = "uav.scheduler/sandbox-decision"
+)
+
+// SandboxResult 单个算法在模拟机队上的调度结果
+type SandboxResult struct {
+	Algorithm  string  `json:"algorithm"`
+	Node       string  `json:"node,omitempty"`
+	Score      float64 `json:"score,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	Candidates int     `json:"candidates"` // 通过过滤的节点数
+	DurationUs float64 `json:"durationUs"`
+}
+
+// SandboxDecision 一个源 Pod 在沙箱中的生产算法和候选算法调度结果
+type SandboxDecision struct {
+	Pod        string        `json:"pod"` // 源 Pod（namespace/name）
+	ShadowPod  string        `json:"shadowPod"`
+	Time       time.Time     `json:"time"`
+	FleetSize  int           `json:"fleetSize"` // 模拟机队节点数
+	Production SandboxResult `json:"production"`
+	Candidate  SandboxResult `json:"candidate"`
+}
+
+// Agreed 两个算法是否选择了同一节点
+func (d *SandboxDecision) Agreed() bool {
+	return d.Production.Error == "" && d.Candidate.Error == "" && d.Production.Node == d.Candidate.Node
+}
+
+// SandboxReport 沙箱中保留的全部决策的对比汇总
+type SandboxReport struct {
+	Namespace  string `json:"namespace"`
+	Production string `json:"production"`
+	Candidate  string `json:"candidate"`
+
+	Pods             int     `json:"pods"`
+	Agreed           int     `json:"agreed"` // 两个算法选择同一节点的 Pod 数
+	AgreementRate    float64 `json:"agreementRate"`
+	ProductionFailed int     `json:"productionFailed"`
+	CandidateFailed  int     `json:"candidateFailed"`
+
+	// 成功调度的平均分数和平均耗时
+	ProductionMeanScore      float64 `json:"productionMeanScore"`
+	CandidateMeanScore       float64 `json:"candidateMeanScore"`
+	ProductionMeanDurationUs float64 `json:"productionMeanDurationUs"`
+	CandidateMeanDurationUs  float64 `json:"candidateMeanDurationUs"`
+
+	// 最近的决策，按时间倒序
+	Decisions []SandboxDecision `json:"decisions"`
+}
+
+// Sandbox 调度模拟沙箱
+// 将待调度的真实 Pod 镜像为沙箱命名空间中的影子 Pod，对沙箱中的模拟机队
+// 分别运行生产算法和候选算法，结果记录在影子 Pod 的注解上。影子 Pod 不会被调度，
+// 调度器重启后对比结果仍然保留，可在相同工作负载上比较两个算法的行为
+type Sandbox struct {
+	clientset  kubernetes.Interface
+	uavClient  *k8s.Client
+	production algorithm.SchedulingAlgorithm
+	candidate  algorithm.SchedulingAlgorithm
+	cfg        config.SandboxConfig
+	log        *logrus.Logger
+
+	// 已镜像的源 Pod（key: UID），避免 Modified 事件重复镜像
+	mirrored map[types.UID]time.Time
+	mu       sync.Mutex
+}
+
+// NewSandbox 创建调度模拟沙箱
+func NewSandbox(clientset kubernetes.Interface, uavClient *k8s.Client, production, candidate algorithm.SchedulingAlgorithm, cfg config.SandboxConfig, log *logrus.Logger) *Sandbox {
+	return &Sandbox{
+		clientset:  clientset,
+		uavClient:  uavClient,
+		production: production,
+		candidate:  candidate,
+		cfg:        cfg,
+		log:        log,
+		mirrored:   make(map[types.UID]time.Time),
+	}
+}
+
+// Mirror 将 Pod 镜像到沙箱，并记录两个算法在模拟机队上的调度结果
+// 每个源 Pod 成功镜像一次，失败不影响生产调度
+func (s *Sandbox) Mirror(ctx context.Context, pod *v1.Pod) {
+	s.mu.Lock()
+	if _, ok := s.mirrored[pod.UID]; ok {
+		s.mu.Unlock()
+		return
+	}
+	s.mirrored[pod.UID] = time.Now()
+	s.mu.Unlock()
+
+	if err := s.mirror(ctx, pod); err != nil {
+		// 下一次 Modified 事件时重试
+		s.mu.Lock()
+		delete(s.mirrored, pod.UID)
+		s.mu.Unlock()
+		s.log.WithError(err).WithFields(logrus.Fields{
+			"pod":       pod.Name,
+			"namespace": pod.Namespace,
+		}).Warn("Failed to mirror pod to sandbox")
+	}
+}
+
+func (s *Sandbox) mirror(ctx context.Context, pod *v1.Pod) error {
+	metrics, err := s.uavClient.ListUAVMetricsIn(ctx, s.cfg.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list sandbox fleet: %w", err)
+	}
+
+	decision := SandboxDecision{
+		Pod:        pod.Namespace + "/" + pod.Name,
+		ShadowPod:  shadowPodName(pod),
+		Time:       time.Now(),
+		FleetSize:  len(metrics),
+		Production: sandboxSchedule(ctx, s.production, pod, metrics),
+		Candidate:  sandboxSchedule(ctx, s.candidate, pod, metrics),
+	}
+
+	shadow, err := s.shadowPod(pod, &decision)
+	if err != nil {
+		return err
+	}
+	if _, err := s.clientset.CoreV1().Pods(s.cfg.Namespace).Create(ctx, shadow, metav1.CreateOptions{}); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			// 调度器重启前已镜像
+			return nil
+		}
+		return fmt.Errorf("failed to create shadow pod: %w", err)
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"pod":            decision.Pod,
+		"productionNode": decision.Production.Node,
+		"candidateNode":  decision.Candidate.Node,
+		"agreed":         decision.Agreed(),
+	}).Info("Pod mirrored to sandbox")
+	return nil
+}
+
+// sandboxSchedule 在模拟机队上运行算法的过滤和打分，返回得分最高的节点（不绑定）
+func sandboxSchedule(ctx context.Context, algo algorithm.SchedulingAlgorithm, pod *v1.Pod, metrics []*models.UAVMetrics) (result SandboxResult) {
+	result = SandboxResult{Algorithm: algo.Name()}
+	start := time.Now()
+	defer func() {
+		result.DurationUs = float64(time.Since(start)) / float64(time.Microsecond)
+	}()
+
+	if len(metrics) == 0 {
+		result.Error = "no UAV nodes in the sandbox fleet"
+		return result
+	}
+	filtered, err := algo.Filter(ctx, pod, metrics)
+	if err != nil {
+		result.Error = fmt.Sprintf("filter error: %v", err)
+		return result
+	}
+	result.Candidates = len(filtered)
+	if len(filtered) == 0 {
+		result.Error = "no nodes passed filter"
+		return result
+	}
+	scores, err := algo.Score(ctx, pod, filtered)
+	if err != nil {
+		result.Error = fmt.Sprintf("score error: %v", err)
+		return result
+	}
+	scores = nodeScores(scores, filtered)
+	if len(scores) == 0 {
+		result.Error = "no scores returned"
+		return result
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	result.Node = scores[0].NodeName
+	result.Score = scores[0].Score
+	result.Reason = scores[0].Reason
+	return result
+}
+
+// shadowPodName 影子 Pod 名称：源 Pod 的 namespace.name
+func shadowPodName(pod *v1.Pod) string {
+	name := pod.Namespace + "." + pod.Name
+	if len(name) > 253 {
+		name = strings.TrimRight(name[:253], ".-")
+	}
+	return name
+}
+
+// shadowPod 复制源 Pod 的标签、注解和 spec，交给不存在的调度器，使其保持 Pending。
+// 去掉卷、ServiceAccount 和 overhead 等依赖源命名空间对象或由准入控制填充的字段
+func (s *Sandbox) shadowPod(pod *v1.Pod, decision *SandboxDecision) (*v1.Pod, error) {
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string, len(pod.Labels)+1)
+	for k, v := range pod.Labels {
+		labels[k] = v
+	}
+	labels[sandboxSourceLabel] = string(pod.UID)
+
+	annotations := make(map[string]string, len(pod.Annotations)+1)
+	for k, v := range pod.Annotations {
+		annotations[k] = v
+	}
+	annotations[sandboxDecisionAnnotation] = string(data)
+
+	spec := *pod.Spec.DeepCopy()
+	spec.SchedulerName = sandboxSchedulerName
+	spec.NodeName = ""
+	spec.ServiceAccountName = ""
+	spec.DeprecatedServiceAccount = ""
+	automount := false
+	spec.AutomountServiceAccountToken = &automount
+	spec.Volumes = nil
+	spec.Overhead = nil
+	spec.EphemeralContainers = nil
+	for i := range spec.InitContainers {
+		spec.InitContainers[i].VolumeMounts = nil
+		spec.InitContainers[i].VolumeDevices = nil
+	}
+	for i := range spec.Containers {
+		spec.Containers[i].VolumeMounts = nil
+		spec.Containers[i].VolumeDevices = nil
+	}
+
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        decision.ShadowPod,
+			Namespace:   s.cfg.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: spec,
+	}, nil
+}
+
+// Report 汇总沙箱中保留的全部决策，Decisions 只包含最近 limit 条
+func (s *Sandbox) Report(ctx context.Context, limit int) (*SandboxReport, error) {
+	decisions, err := s.decisions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SandboxReport{
+		Namespace:  s.cfg.Namespace,
+		Production: s.production.Name(),
+		Candidate:  s.candidate.Name(),
+		Pods:       len(decisions),
+	}
+	var productionOK, candidateOK int
+	for _, d := range decisions {
+		if d.Agreed() {
+			report.Agreed++
+		}
+		if d.Production.Error != "" {
+			report.ProductionFailed++
+		} else {
+			productionOK++
+			report.ProductionMeanScore += d.Production.Score
+			report.ProductionMeanDurationUs += d.Production.DurationUs
+		}
+		if d.Candidate.Error != "" {
+			report.CandidateFailed++
+		} else {
+			candidateOK++
+			report.CandidateMeanScore += d.Candidate.Score
+			report.CandidateMeanDurationUs += d.Candidate.DurationUs
+		}
+	}
+	if report.Pods > 0 {
+		report.AgreementRate = float64(report.Agreed) / float64(report.Pods)
+	}
+	if productionOK > 0 {
+		report.ProductionMeanScore /= float64(productionOK)
+		report.ProductionMeanDurationUs /= float64(productionOK)
+	}
+	if candidateOK > 0 {
+		report.CandidateMeanScore /= float64(candidateOK)
+		report.CandidateMeanDurationUs /= float64(candidateOK)
+	}
+
+	sort.Slice(decisions, func(i, j int) bool {
+		return decisions[i].Time.After(decisions[j].Time)
+	})
+	if limit >= 0 && len(decisions) > limit {
+		decisions = decisions[:limit]
+	}
+	report.Decisions = decisions
+	return report, nil
+}
+
+// decisions 从影子 Pod 的注解读取决策
+func (s *Sandbox) decisions(ctx context.Context) ([]SandboxDecision, error) {
+	pods, err := s.clientset.CoreV1().Pods(s.cfg.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: sandboxSourceLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow pods: %w", err)
+	}
+
+	decisions := make([]SandboxDecision, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		var d SandboxDecision
+		if err := json.Unmarshal([]byte(pod.Annotations[sandboxDecisionAnnotation]), &d); err != nil {
+			s.log.WithError(err).WithField("pod", pod.Name).Debug("Skipping shadow pod without a valid decision")
+			continue
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, nil
+}
+
+// Run 周期性删除超过保留时间的影子 Pod
+func (s *Sandbox) Run(ctx context.Context) {
+	interval := s.cfg.Retention / 10
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.cleanup(ctx)
+		}
+	}
+}
+
+// cleanup 删除超过保留时间的影子 Pod，并清理已镜像记录
+func (s *Sandbox) cleanup(ctx context.Context) {
+	pods, err := s.clientset.CoreV1().Pods(s.cfg.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: sandboxSourceLabel,
+	})
+	if err != nil {
+		s.log.WithError(err).Warn("Failed to list shadow pods")
+		return
+	}
+
+	deleted := 0
+	for _, pod := range pods.Items {
+		if time.Since(pod.CreationTimestamp.Time) <= s.cfg.Retention {
+			continue
+		}
+		err := s.clientset.CoreV1().Pods(s.cfg.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			s.log.WithError(err).WithField("pod", pod.Name).Debug("Failed to delete shadow pod")
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		s.log.WithField("pods", deleted).Info("Expired shadow pods deleted")
+	}
+
+	s.mu.Lock()
+	for uid, at := range s.mirrored {
+		if time.Since(at) > s.cfg.Retention {
+			delete(s.mirrored, uid)
+		}
+	}
+	s.mu.Unlock()
+}
@@ -2,21 +2,24 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
 
 	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/resilience"
 	"github.com/k3suav/uav-monitor/pkg/scheduler/algorithm"
 	"github.com/k3suav/uav-monitor/pkg/scheduler/config"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/registry"
 	"github.com/sirupsen/logrus"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 // Scheduler UAV 自定义调度器
@@ -26,31 +29,21 @@ type Scheduler struct {
 	uavClient     *k8s.Client
 	algorithm     algorithm.SchedulingAlgorithm
 	log           *logrus.Logger
+	prePuller     *ImagePrePuller // 镜像预拉取（可选）
+	preemptor     *Preemptor      // 任务优先级抢占（可选）
+	canary        *CanaryRollout  // 算法灰度发布（可选）
+	control       *SchedulingControl // 暂停/恢复调度
+	quotas        *FleetQuotas       // 机队配额（可选）
+	chargeback    *Chargeback        // 资源用量计费（可选）
+	sandbox       *Sandbox           // 调度模拟沙箱（可选）
+	events        *k8s.EventRecorder // 以调度器名称记录 Pod 事件
+	windows       *GeoWindows        // 地理时间窗口定时队列
 }
 
 // NewScheduler 创建新的调度器
 func NewScheduler(cfg *config.SchedulerConfig, algo algorithm.SchedulingAlgorithm, uavClient *k8s.Client) (*Scheduler, error) {
-	// 创建 K8s clientset
-	var k8sConfig *rest.Config
-	var err error
-
-	if cfg.KubeconfigPath == "" {
-		k8sConfig, err = rest.InClusterConfig()
-		if err != nil {
-			kubeconfigPath := clientcmd.RecommendedHomeFile
-			k8sConfig, err = clientcmd.BuildConfigFromFlags("", kubeconfigPath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to build kubernetes config: %w", err)
-			}
-		}
-	} else {
-		k8sConfig, err = clientcmd.BuildConfigFromFlags("", cfg.KubeconfigPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to build kubernetes config: %w", err)
-		}
-	}
-
-	clientset, err := kubernetes.NewForConfig(k8sConfig)
+	// 创建 K8s clientset，与 UAV Client 共用连接配置（含 DNS 缓存和请求对冲）
+	clientset, err := kubernetes.NewForConfig(uavClient.RestConfig())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
 	}
@@ -81,13 +74,48 @@ func NewScheduler(cfg *config.SchedulerConfig, algo algorithm.SchedulingAlgorith
 		})
 	}
 
-	return &Scheduler{
+	// Pod 事件共用 UAV Client 的事件广播器（合并相同事件并限速）
+	events := uavClient.EventRecorder(cfg.SchedulerName, "")
+
+	s := &Scheduler{
 		config:       cfg,
 		k8sClientset: clientset,
 		uavClient:    uavClient,
 		algorithm:    algo,
 		log:          log,
-	}, nil
+		control:      NewSchedulingControl(cfg.StartPaused),
+		events:       events,
+		windows:      NewGeoWindows(clientset, events, cfg.WindowRetryInterval, cfg.WindowPredictionHorizon, log),
+	}
+
+	if cfg.PrePullEnabled {
+		s.prePuller = NewImagePrePuller(clientset, cfg.PrePullTopN, cfg.PrePullMinLatency, log)
+	}
+	if cfg.PreemptionEnabled {
+		s.preemptor = NewPreemptor(clientset, events, cfg.SchedulerName, cfg.FleetPriorities, log)
+	}
+	if cfg.Chargeback.Enabled {
+		s.chargeback = NewChargeback(clientset, uavClient, cfg.SchedulerName, cfg.Chargeback, log)
+	}
+	if len(cfg.Quotas) > 0 {
+		s.quotas = NewFleetQuotas(clientset, events, cfg.SchedulerName, cfg.Quotas, log)
+	}
+	if cfg.Canary.Algorithm != "" {
+		canaryAlgo, err := registry.Get(cfg.Canary.Algorithm)
+		if err != nil {
+			return nil, fmt.Errorf("canary algorithm: %w", err)
+		}
+		s.canary = NewCanaryRollout(algo, canaryAlgo, cfg.Canary, log)
+	}
+	if cfg.Sandbox.Namespace != "" {
+		candidate, err := registry.Get(cfg.Sandbox.Algorithm)
+		if err != nil {
+			return nil, fmt.Errorf("sandbox algorithm: %w", err)
+		}
+		s.sandbox = NewSandbox(clientset, uavClient, algo, candidate, cfg.Sandbox, log)
+	}
+
+	return s, nil
 }
 
 // Run 启动调度器
@@ -97,19 +125,49 @@ func (s *Scheduler) Run(ctx context.Context) error {
 		"algorithm":     s.algorithm.Name(),
 	}).Info("Starting UAV Scheduler")
 
-	// 启动 Pod watcher
-	for {
-		select {
-		case <-ctx.Done():
-			s.log.Info("Scheduler stopped")
-			return ctx.Err()
-		default:
-			if err := s.watchAndSchedule(ctx); err != nil {
-				s.log.WithError(err).Error("Watch and schedule error")
-				time.Sleep(5 * time.Second) // 错误后等待重试
-			}
+	if s.prePuller != nil {
+		go s.prePuller.Run(ctx)
+	}
+
+	if s.chargeback != nil {
+		go s.chargeback.Run(ctx)
+	}
+
+	if s.sandbox != nil {
+		go s.sandbox.Run(ctx)
+	}
+
+	// Kubernetes API 调用指标始终提供，不依赖管理接口
+	go func() {
+		addr := fmt.Sprintf(":%d", s.config.MetricsPort)
+		s.log.WithField("port", s.config.MetricsPort).Info("Starting client metrics server")
+		if err := k8s.ServeClientMetrics(ctx, addr); err != nil {
+			s.log.WithError(err).Error("Client metrics server stopped")
 		}
+	}()
+
+	if s.config.AdminPort != 0 {
+		go func() {
+			if err := s.ServeAdmin(ctx); err != nil {
+				s.log.WithError(err).Error("Admin API server stopped")
+			}
+		}()
+	}
+	if s.config.StartPaused {
+		s.log.Warn("Scheduling paused at startup, pods will be queued until resumed")
 	}
+
+	// 启动 Pod watcher，断开后按指数退避重连；watch 稳定运行一段时间后退避重置
+	err := resilience.Reconnect(ctx,
+		resilience.NewBackoff(time.Second, s.config.WatchMaxBackoff),
+		s.config.WatchMaxBackoff,
+		s.watchAndSchedule,
+		func(err error, delay time.Duration) {
+			s.log.WithError(err).WithField("retryIn", delay).Error("Watch and schedule error")
+		},
+	)
+	s.log.Info("Scheduler stopped")
+	return err
 }
 
 // watchAndSchedule 监听并调度 Pod
@@ -126,15 +184,57 @@ func (s *Scheduler) watchAndSchedule(ctx context.Context) error {
 
 	s.log.Info("Watching for unscheduled pods...")
 
+	// 定期重试因机队配额等待的 Pod
+	var quotaRetry <-chan time.Time
+	if s.quotas != nil {
+		ticker := time.NewTicker(s.config.QuotaRetryInterval)
+		defer ticker.Stop()
+		quotaRetry = ticker.C
+	}
+
+	// 定期重试等待地理时间窗口的 Pod（到期的才重新调度）
+	windowTicker := time.NewTicker(time.Second)
+	defer windowTicker.Stop()
+
+	// 定期重试等待被抢占 Pod 退出的 Pod
+	var preemptionRetry <-chan time.Time
+	if s.preemptor != nil {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		preemptionRetry = ticker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-quotaRetry:
+			s.retryBlocked(ctx)
+		case <-windowTicker.C:
+			s.retryWindows(ctx)
+		case <-preemptionRetry:
+			s.retryPreemptions(ctx)
+		case <-s.control.drain:
+			s.drainQueue(ctx)
 		case event, ok := <-watcher.ResultChan():
 			if !ok {
 				return fmt.Errorf("watch channel closed")
 			}
 
+			if event.Type == watch.Deleted {
+				if pod, ok := event.Object.(*v1.Pod); ok {
+					s.control.remove(pod)
+					s.windows.remove(pod)
+					if s.quotas != nil {
+						s.quotas.unblock(pod)
+					}
+					if s.preemptor != nil {
+						s.preemptor.forget(pod)
+					}
+				}
+				continue
+			}
+
 			if event.Type == watch.Added || event.Type == watch.Modified {
 				pod, ok := event.Object.(*v1.Pod)
 				if !ok {
@@ -148,6 +248,27 @@ func (s *Scheduler) watchAndSchedule(ctx context.Context) error {
 
 				// 检查是否已经分配节点
 				if pod.Spec.NodeName != "" {
+					s.control.remove(pod)
+					s.windows.remove(pod)
+					continue
+				}
+
+				// 等待地理时间窗口的 Pod 到期前由定时队列重试
+				if s.windows.Waiting(pod, time.Now()) {
+					continue
+				}
+
+				// 镜像到沙箱（暂停期间同样镜像，异步执行不影响生产调度）
+				if s.sandbox != nil {
+					go s.sandbox.Mirror(ctx, pod)
+				}
+
+				// 暂停期间只入队不绑定
+				if s.control.hold(pod) {
+					s.log.WithFields(logrus.Fields{
+						"pod":       pod.Name,
+						"namespace": pod.Namespace,
+					}).Info("Scheduling paused, pod queued")
 					continue
 				}
 
@@ -165,10 +286,55 @@ func (s *Scheduler) watchAndSchedule(ctx context.Context) error {
 	}
 }
 
+// Control 返回暂停/恢复调度控制
+func (s *Scheduler) Control() *SchedulingControl {
+	return s.control
+}
+
 // schedulePod 调度单个 Pod
-func (s *Scheduler) schedulePod(ctx context.Context, pod *v1.Pod) error {
+func (s *Scheduler) schedulePod(ctx context.Context, pod *v1.Pod) (err error) {
 	startTime := time.Now()
 
+	// 地理时间窗口：窗口开启前、结束后不调度
+	window, err := ParseGeoWindow(pod)
+	if err != nil {
+		s.windows.block(ctx, pod, &WindowWaitError{Reason: ReasonWindowMissed, Message: err.Error()}, startTime)
+		return err
+	}
+	if window != nil {
+		if err := window.CheckTime(startTime); err != nil {
+			var wait *WindowWaitError
+			if errors.As(err, &wait) {
+				s.windows.block(ctx, pod, wait, window.Start)
+			}
+			return err
+		}
+	}
+
+	// 机队配额检查（在选择算法之前，配额不足不计入灰度统计）
+	if s.quotas != nil {
+		if err := s.quotas.Check(ctx, pod); err != nil {
+			var exceeded *QuotaExceededError
+			if errors.As(err, &exceeded) {
+				s.quotas.block(ctx, pod, exceeded)
+			}
+			return err
+		}
+		s.quotas.unblock(pod)
+	}
+
+	// 选择算法（灰度发布时部分决策使用金丝雀算法）
+	algo := s.algorithm
+	if s.canary != nil {
+		var isCanary bool
+		algo, isCanary = s.canary.Select(pod)
+		defer func() {
+			// 等待被抢占 Pod 退出不算调度失败
+			var pending *PreemptionPendingError
+			s.canary.Record(isCanary, err == nil || errors.As(err, &pending), time.Since(startTime))
+		}()
+	}
+
 	// 1. 获取所有节点的 UAVMetrics
 	metrics, err := s.uavClient.ListUAVMetrics(ctx)
 	if err != nil {
@@ -181,25 +347,41 @@ func (s *Scheduler) schedulePod(ctx context.Context, pod *v1.Pod) error {
 
 	s.log.WithField("nodeCount", len(metrics)).Debug("Fetched UAVMetrics")
 
-	// 2. 过滤节点
-	filteredMetrics := metrics
-	if s.algorithm.Filter != nil {
-		filteredMetrics, err = s.algorithm.Filter(ctx, pod, metrics)
+	// 指定目标区域时只在区域内的节点中选择，没有时进入定时队列等待
+	if window != nil {
+		var due time.Time
+		metrics, due, err = s.windows.Admit(window, metrics, startTime)
 		if err != nil {
-			return fmt.Errorf("filter error: %w", err)
-		}
-		if len(filteredMetrics) == 0 {
-			return fmt.Errorf("no nodes passed filter")
+			var wait *WindowWaitError
+			if errors.As(err, &wait) {
+				s.windows.block(ctx, pod, wait, due)
+			}
+			return err
 		}
-		s.log.WithField("filteredCount", len(filteredMetrics)).Debug("Nodes filtered")
+		s.windows.remove(pod)
 	}
 
+	// 2. 过滤节点：飞行时长预算对所有算法生效，再由算法过滤
+	metrics = algorithm.FilterAirtimeBudget(pod, metrics, s.config.AlgorithmParams.AirtimeBudget, startTime)
+	if len(metrics) == 0 {
+		return fmt.Errorf("all UAV nodes exhausted their daily airtime budget")
+	}
+	filteredMetrics, err := algo.Filter(ctx, pod, metrics)
+	if err != nil {
+		return fmt.Errorf("filter error: %w", err)
+	}
+	if len(filteredMetrics) == 0 {
+		return fmt.Errorf("no nodes passed filter")
+	}
+	s.log.WithField("filteredCount", len(filteredMetrics)).Debug("Nodes filtered")
+
 	// 3. 计算分数
-	scores, err := s.algorithm.Score(ctx, pod, filteredMetrics)
+	scores, err := algo.Score(ctx, pod, filteredMetrics)
 	if err != nil {
 		return fmt.Errorf("score error: %w", err)
 	}
 
+	scores = nodeScores(scores, filteredMetrics)
 	if len(scores) == 0 {
 		return fmt.Errorf("no scores returned")
 	}
@@ -211,6 +393,28 @@ func (s *Scheduler) schedulePod(ctx context.Context, pod *v1.Pod) error {
 
 	bestNode := scores[0].NodeName
 	bestScore := scores[0].Score
+	bestReason := scores[0].Reason
+
+	// 启用抢占时，按资源容量选择节点，必要时驱逐低优先级任务
+	if s.preemptor != nil {
+		bestNode, err = s.preemptor.SelectNode(ctx, pod, scores)
+		if err != nil {
+			var pending *PreemptionPendingError
+			if errors.As(err, &pending) {
+				// 等待 victim 退出期间在提名节点和候选节点上拉取镜像
+				s.prePull(ctx, pod, scores, filteredMetrics, pending.Node)
+				return err
+			}
+			return fmt.Errorf("preemption error: %w", err)
+		}
+		for _, sc := range scores {
+			if sc.NodeName == bestNode {
+				bestScore = sc.Score
+				bestReason = sc.Reason
+				break
+			}
+		}
+	}
 
 	// 记录前3名节点的分数（用于调试）
 	topScores := scores
@@ -220,11 +424,14 @@ func (s *Scheduler) schedulePod(ctx context.Context, pod *v1.Pod) error {
 
 	s.log.WithFields(logrus.Fields{
 		"pod":       pod.Name,
-		"algorithm": s.algorithm.Name(),
+		"algorithm": algo.Name(),
 		"topScores": topScores,
 	}).Debug("Scoring completed")
 
-	// 5. 绑定 Pod 到节点
+	// 5. 绑定前在选中节点和慢链路候选节点上预拉取镜像（异步，不影响调度结果）
+	s.prePull(ctx, pod, scores, filteredMetrics, bestNode)
+
+	// 6. 绑定 Pod 到节点
 	if err := s.bindPodToNode(ctx, pod, bestNode); err != nil {
 		return fmt.Errorf("bind error: %w", err)
 	}
@@ -235,14 +442,49 @@ func (s *Scheduler) schedulePod(ctx context.Context, pod *v1.Pod) error {
 		"pod":       pod.Name,
 		"namespace": pod.Namespace,
 		"node":      bestNode,
+		"algorithm": algo.Name(),
 		"score":     fmt.Sprintf("%.2f", bestScore),
-		"reason":    scores[0].Reason,
+		"reason":    bestReason,
 		"duration":  duration.Milliseconds(),
 	}).Info("Pod scheduled successfully")
 
 	return nil
 }
 
+// prePull 启用镜像预拉取时，异步在 chosenNode 和得分靠前的慢链路候选节点上拉取 Pod 的镜像
+func (s *Scheduler) prePull(ctx context.Context, pod *v1.Pod, scores []algorithm.NodeScore, metrics []*models.UAVMetrics, chosenNode string) {
+	if s.prePuller != nil {
+		go s.prePuller.PrePull(ctx, pod, scores, metrics, chosenNode)
+	}
+}
+
+// nodeScores 将飞行器的得分换算为其所在 Kubernetes 节点的得分：
+// 地面节点代理的飞行器没有自己的 Node，Pod 只能绑定到地面节点。
+// 同一地面节点代理多架飞行器时取最高分
+func nodeScores(scores []algorithm.NodeScore, metrics []*models.UAVMetrics) []algorithm.NodeScore {
+	kubeNodes := make(map[string]string, len(metrics))
+	for _, m := range metrics {
+		kubeNodes[m.NodeName] = m.KubeNode()
+	}
+
+	best := make(map[string]int, len(scores))
+	result := make([]algorithm.NodeScore, 0, len(scores))
+	for _, sc := range scores {
+		if node, ok := kubeNodes[sc.NodeName]; ok {
+			sc.NodeName = node
+		}
+		if i, ok := best[sc.NodeName]; ok {
+			if sc.Score > result[i].Score {
+				result[i] = sc
+			}
+			continue
+		}
+		best[sc.NodeName] = len(result)
+		result = append(result, sc)
+	}
+	return result
+}
+
 // bindPodToNode 绑定 Pod 到节点
 func (s *Scheduler) bindPodToNode(ctx context.Context, pod *v1.Pod, nodeName string) error {
 	binding := &v1.Binding{
@@ -260,6 +502,43 @@ func (s *Scheduler) bindPodToNode(ctx context.Context, pod *v1.Pod, nodeName str
 	if err != nil {
 		return fmt.Errorf("failed to bind pod %s to node %s: %w", pod.Name, nodeName, err)
 	}
+	s.events.PodEvent(pod, v1.EventTypeNormal, "Scheduled",
+		fmt.Sprintf("Successfully assigned %s/%s to %s", pod.Namespace, pod.Name, nodeName))
 
 	return nil
 }
+
+// recordUnschedulable 以 FailedScheduling 事件和 PodScheduled 条件说明 Pod 未调度的原因
+func recordUnschedulable(ctx context.Context, clientset kubernetes.Interface, events *k8s.EventRecorder, pod *v1.Pod, reason, message string, log *logrus.Logger) {
+	// 条件未变化时不更新，避免触发 Modified 事件后反复调度
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodScheduled && cond.Status == v1.ConditionFalse && cond.Reason == reason && cond.Message == message {
+			return
+		}
+	}
+
+	events.PodEvent(pod, v1.EventTypeWarning, "FailedScheduling", message)
+
+	now := metav1.Now()
+	updated := pod.DeepCopy()
+	condition := v1.PodCondition{
+		Type:               v1.PodScheduled,
+		Status:             v1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+	}
+	replaced := false
+	for i := range updated.Status.Conditions {
+		if updated.Status.Conditions[i].Type == v1.PodScheduled {
+			updated.Status.Conditions[i] = condition
+			replaced = true
+		}
+	}
+	if !replaced {
+		updated.Status.Conditions = append(updated.Status.Conditions, condition)
+	}
+	if _, err := clientset.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil && !apierrors.IsConflict(err) {
+		log.WithError(err).Debug("Failed to set PodScheduled condition")
+	}
+}
@@ -0,0 +1,302 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/scheduler/algorithm"
+	"github.com/sirupsen/logrus"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// TargetRadiusAnnotation 目标区域半径（公里），与 uav.scheduler/target-lat/target-lon 一起指定目标区域
+	TargetRadiusAnnotation = "uav.scheduler/target-radius"
+
+	// WindowStartAnnotation / WindowEndAnnotation 时间窗口（RFC 3339），可只指定其一
+	WindowStartAnnotation = "uav.scheduler/window-start"
+	WindowEndAnnotation   = "uav.scheduler/window-end"
+
+	// PodScheduled 条件的原因
+	ReasonWindowPending = "GeoWindowPending" // 等待窗口开启或无人机进入目标区域
+	ReasonWindowMissed  = "GeoWindowMissed"  // 窗口已结束或注解无效，不再重试
+
+	// kmPerDegree 纬度每度对应的距离（公里）
+	kmPerDegree = 111.195
+)
+
+// GeoWindow Pod 的地理时间窗口：在 Start 与 End 之间，且有无人机位于目标区域内时才绑定
+type GeoWindow struct {
+	Start time.Time // 零值表示立即开启
+	End   time.Time // 零值表示不结束
+
+	HasArea bool               // 是否指定了目标区域
+	Center  algorithm.Location // 目标区域中心
+	Radius  float64            // 目标区域半径（公里）
+}
+
+// ParseGeoWindow 解析 Pod 的地理时间窗口注解，未指定时返回 nil
+func ParseGeoWindow(pod *v1.Pod) (*GeoWindow, error) {
+	annotations := pod.Annotations
+	window := &GeoWindow{}
+	var err error
+	if value, ok := annotations[WindowStartAnnotation]; ok {
+		if window.Start, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, fmt.Errorf("invalid %s %q, expected an RFC 3339 time", WindowStartAnnotation, value)
+		}
+	}
+	if value, ok := annotations[WindowEndAnnotation]; ok {
+		if window.End, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, fmt.Errorf("invalid %s %q, expected an RFC 3339 time", WindowEndAnnotation, value)
+		}
+	}
+	if !window.Start.IsZero() && !window.End.IsZero() && !window.End.After(window.Start) {
+		return nil, fmt.Errorf("%s must be after %s", WindowEndAnnotation, WindowStartAnnotation)
+	}
+
+	if value, ok := annotations[TargetRadiusAnnotation]; ok {
+		window.Radius, err = strconv.ParseFloat(value, 64)
+		if err != nil || window.Radius <= 0 {
+			return nil, fmt.Errorf("invalid %s %q, expected a radius in km > 0", TargetRadiusAnnotation, value)
+		}
+		lat, errLat := strconv.ParseFloat(annotations["uav.scheduler/target-lat"], 64)
+		lon, errLon := strconv.ParseFloat(annotations["uav.scheduler/target-lon"], 64)
+		if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("%s requires valid uav.scheduler/target-lat and uav.scheduler/target-lon", TargetRadiusAnnotation)
+		}
+		window.HasArea = true
+		window.Center = algorithm.Location{Latitude: lat, Longitude: lon}
+	}
+
+	if window.Start.IsZero() && window.End.IsZero() && !window.HasArea {
+		return nil, nil
+	}
+	return window, nil
+}
+
+// WindowWaitError Pod 等待地理时间窗口
+type WindowWaitError struct {
+	Reason  string // ReasonWindowPending 或 ReasonWindowMissed
+	Message string
+}
+
+func (e *WindowWaitError) Error() string {
+	return e.Message
+}
+
+// GeoWindows 地理时间窗口定时队列
+// 窗口未开启、或目标区域内暂时没有无人机的 Pod 保持 Pending，以 PodScheduled 条件说明等待原因，
+// 到期（窗口开启、预测无人机进入区域或下一次重试）后重新调度；窗口结束后不再重试
+type GeoWindows struct {
+	clientset kubernetes.Interface
+	events    *k8s.EventRecorder
+	retry     time.Duration // 目标区域内没有无人机时的重试间隔
+	horizon   time.Duration // 按航向和速度预测无人机位置的最长时间（0 表示不预测）
+	log       *logrus.Logger
+
+	mu      sync.Mutex
+	waiting map[string]windowEntry // key: namespace/name
+}
+
+type windowEntry struct {
+	pod *v1.Pod
+	due time.Time
+}
+
+// NewGeoWindows 创建地理时间窗口队列
+func NewGeoWindows(clientset kubernetes.Interface, events *k8s.EventRecorder, retry, horizon time.Duration, log *logrus.Logger) *GeoWindows {
+	return &GeoWindows{
+		clientset: clientset,
+		events:    events,
+		retry:     retry,
+		horizon:   horizon,
+		log:       log,
+		waiting:   make(map[string]windowEntry),
+	}
+}
+
+// CheckTime 检查时间窗口是否已开启，未开启或已结束时返回 *WindowWaitError
+func (window *GeoWindow) CheckTime(now time.Time) error {
+	switch {
+	case !window.End.IsZero() && !now.Before(window.End):
+		return &WindowWaitError{
+			Reason:  ReasonWindowMissed,
+			Message: fmt.Sprintf("geo-temporal window ended at %s", window.End.Format(time.RFC3339)),
+		}
+	case now.Before(window.Start):
+		return &WindowWaitError{
+			Reason:  ReasonWindowPending,
+			Message: fmt.Sprintf("waiting for the geo-temporal window opening at %s", window.Start.Format(time.RFC3339)),
+		}
+	}
+	return nil
+}
+
+// Admit 返回位于目标区域内的节点；没有时返回 *WindowWaitError，
+// 说明预测最早进入区域的无人机，并返回重试时间
+func (w *GeoWindows) Admit(window *GeoWindow, metrics []*models.UAVMetrics, now time.Time) ([]*models.UAVMetrics, time.Time, error) {
+	if !window.HasArea {
+		return metrics, time.Time{}, nil
+	}
+
+	inArea := []*models.UAVMetrics{}
+	var nextNode string
+	var next time.Time
+	for _, m := range metrics {
+		// 位置未通过 GNSS 完整性检查时不可信
+		if m.GPS.PositionDegraded() {
+			continue
+		}
+		if math.Hypot(window.offset(&m.GPS)) <= window.Radius {
+			inArea = append(inArea, m)
+			continue
+		}
+		if arrival, ok := w.predictArrival(window, &m.GPS, now); ok && (next.IsZero() || arrival.Before(next)) {
+			nextNode, next = m.NodeName, arrival
+		}
+	}
+	if len(inArea) > 0 {
+		return inArea, time.Time{}, nil
+	}
+
+	area := fmt.Sprintf("%.2fkm of (%.4f,%.4f)", window.Radius, window.Center.Latitude, window.Center.Longitude)
+	if next.IsZero() {
+		return nil, now.Add(w.retry), &WindowWaitError{
+			Reason:  ReasonWindowPending,
+			Message: fmt.Sprintf("no UAV within %s", area),
+		}
+	}
+	return nil, next, &WindowWaitError{
+		Reason:  ReasonWindowPending,
+		Message: fmt.Sprintf("waiting for %s, predicted within %s at %s", nextNode, area, next.Format(time.RFC3339)),
+	}
+}
+
+// predictArrival 按最近一次定位的航向和速度直线外推，返回无人机在窗口内最早进入目标区域的时间。
+// 以定位时间为起点外推，同一份遥测得到相同的预测，避免条件反复更新
+func (w *GeoWindows) predictArrival(window *GeoWindow, gps *models.GPSData, now time.Time) (time.Time, bool) {
+	if w.horizon <= 0 || gps.Speed <= 0 || gps.LastUpdate.IsZero() {
+		return time.Time{}, false
+	}
+
+	// 以区域中心为原点的局部平面坐标（公里）和速度（公里/秒）
+	x, y := window.offset(gps)
+	heading := gps.Heading * math.Pi / 180
+	vx := gps.Speed / 1000 * math.Sin(heading)
+	vy := gps.Speed / 1000 * math.Cos(heading)
+
+	// 求解 |p + v·t| = r 的较小根，即进入区域的时刻
+	a := vx*vx + vy*vy
+	b := 2 * (x*vx + y*vy)
+	c := x*x + y*y - window.Radius*window.Radius
+	discriminant := b*b - 4*a*c
+	if discriminant < 0 {
+		return time.Time{}, false // 航线不经过目标区域
+	}
+	t := (-b - math.Sqrt(discriminant)) / (2 * a)
+	if t < 0 {
+		return time.Time{}, false // 正在远离目标区域
+	}
+
+	arrival := gps.LastUpdate.Add(time.Duration(t * float64(time.Second)))
+	if arrival.After(now.Add(w.horizon)) || (!window.End.IsZero() && !arrival.Before(window.End)) {
+		return time.Time{}, false
+	}
+	if arrival.Before(now) {
+		arrival = now // 按预测已进入区域，遥测尚未更新
+	}
+	return arrival, true
+}
+
+// offset 返回定位相对目标区域中心的东向、北向距离（公里），目标区域不大，按平面近似
+func (window *GeoWindow) offset(gps *models.GPSData) (float64, float64) {
+	cosLat := math.Cos(window.Center.Latitude * math.Pi / 180)
+	return (gps.Longitude - window.Center.Longitude) * kmPerDegree * cosLat,
+		(gps.Latitude - window.Center.Latitude) * kmPerDegree
+}
+
+// wait 将 Pod 加入定时队列，到期后重试
+func (w *GeoWindows) wait(pod *v1.Pod, due time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.waiting[pod.Namespace+"/"+pod.Name] = windowEntry{pod: pod, due: due}
+}
+
+// remove 将 Pod 移出定时队列
+func (w *GeoWindows) remove(pod *v1.Pod) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.waiting, pod.Namespace+"/"+pod.Name)
+}
+
+// Waiting 返回 Pod 是否在定时队列中且尚未到期（到期前忽略其 watch 事件，避免条件更新触发反复调度）
+func (w *GeoWindows) Waiting(pod *v1.Pod, now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	entry, ok := w.waiting[pod.Namespace+"/"+pod.Name]
+	return ok && now.Before(entry.due)
+}
+
+// takeDue 取出已到期的 Pod
+func (w *GeoWindows) takeDue(now time.Time) []*v1.Pod {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	pods := []*v1.Pod{}
+	for key, entry := range w.waiting {
+		if !now.Before(entry.due) {
+			pods = append(pods, entry.pod)
+			delete(w.waiting, key)
+		}
+	}
+	return pods
+}
+
+// block 记录等待原因；等待中的 Pod 进入定时队列，窗口已结束的 Pod 不再重试
+func (w *GeoWindows) block(ctx context.Context, pod *v1.Pod, wait *WindowWaitError, due time.Time) {
+	if wait.Reason == ReasonWindowPending {
+		w.wait(pod, due)
+	} else {
+		w.remove(pod)
+	}
+	w.log.WithFields(logrus.Fields{
+		"pod":       pod.Name,
+		"namespace": pod.Namespace,
+		"reason":    wait.Reason,
+		"retryAt":   due.Format(time.RFC3339),
+	}).Info("Pod waiting for geo-temporal window")
+	recordUnschedulable(ctx, w.clientset, w.events, pod, wait.Reason, wait.Message, w.log)
+}
+
+// retryWindows 重新调度定时队列中到期的 Pod（仍需等待的会再次入队）
+func (s *Scheduler) retryWindows(ctx context.Context) {
+	for _, due := range s.windows.takeDue(time.Now()) {
+		if ctx.Err() != nil {
+			return
+		}
+		pod, err := s.k8sClientset.CoreV1().Pods(due.Namespace).Get(ctx, due.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			s.log.WithError(err).WithField("pod", due.Name).Debug("Failed to get pod waiting for geo-temporal window")
+			s.windows.wait(due, time.Now().Add(s.windows.retry))
+			continue
+		}
+		if pod.Spec.NodeName != "" || s.control.hold(pod) {
+			continue
+		}
+		if err := s.schedulePod(ctx, pod); err != nil {
+			s.log.WithError(err).WithField("pod", pod.Name).Debug("Pod still waiting for geo-temporal window")
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package securestore encrypts the telemetry the agent keeps on the node's
+// disk (recordings, file sink samples and home points) with AES-256-GCM, so
+// a recovered vehicle doesn't give its flight data away.
+package securestore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeySize is the AES-256 key length in bytes
+const KeySize = 32
+
+// magic prefixes every encrypted file so plaintext files are detected
+var magic = []byte("UAVENC1")
+
+var (
+	// ErrNotEncrypted is returned when reading a file without the encryption header
+	ErrNotEncrypted = errors.New("file is not encrypted")
+	// ErrInvalidKey is returned for keys that are not 32 bytes after decoding
+	ErrInvalidKey = errors.New("invalid encryption key: must be 32 bytes (raw, hex or base64)")
+)
+
+// Store encrypts and decrypts local files with AES-GCM
+type Store struct {
+	aead cipher.AEAD
+}
+
+// New creates a store from a 32-byte key
+func New(key []byte) (*Store, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{aead: aead}, nil
+}
+
+// LoadKey reads a key from path. The file may contain the raw 32 bytes or a
+// hex/base64 encoding of them (as produced by a Kubernetes Secret mount).
+// If the file does not exist and generate is true, a new node-local key is
+// created with 0600 permissions.
+func LoadKey(path string, generate bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && generate {
+		return generateKey(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption key: %w", err)
+	}
+	return decodeKey(data)
+}
+
+// Encrypt seals plaintext; the output is magic | nonce | ciphertext
+func (s *Store) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(magic)+len(nonce)+len(plaintext)+s.aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, nonce...)
+	return s.aead.Seal(out, nonce, plaintext, magic), nil
+}
+
+// Decrypt opens data produced by Encrypt
+func (s *Store) Decrypt(data []byte) ([]byte, error) {
+	if len(data) < len(magic) || string(data[:len(magic)]) != string(magic) {
+		return nil, ErrNotEncrypted
+	}
+	data = data[len(magic):]
+
+	nonceSize := s.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("encrypted data too short")
+	}
+	plaintext, err := s.aead.Open(nil, data[:nonceSize], data[nonceSize:], magic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// WriteFile encrypts data and atomically writes it to path
+func (s *Store) WriteFile(path string, data []byte, perm os.FileMode) error {
+	sealed, err := s.Encrypt(data)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(sealed); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadFile reads and decrypts a file written by WriteFile
+func (s *Store) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.Decrypt(data)
+}
+
+func decodeKey(data []byte) ([]byte, error) {
+	if len(data) == KeySize {
+		return data, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+func generateKey(path string) ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write encryption key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,76 @@
+package sink
+
+import (
+	"crypto/tls"
+
+	"github.com/k3suav/uav-monitor/pkg/aggregator"
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/enrollment"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/securestore"
+	"github.com/sirupsen/logrus"
+)
+
+// Build creates a pipeline with the sinks enabled in cfg. The CRD sink is
+// left out of dry runs. store encrypts the samples of the file sink, nil
+// unless storage.encryptAtRest is set. On error, the sinks already created
+// are closed.
+func Build(cfg *config.Config, client *k8s.Client, store *securestore.Store, log *logrus.Logger) (*Pipeline, error) {
+	p := NewPipeline(log)
+	retry := Options{
+		Attempts:   cfg.Sinks.RetryAttempts,
+		RetryDelay: cfg.Sinks.RetryDelay,
+		MaxDelay:   cfg.Sinks.RetryMaxDelay,
+	}
+	fail := func(err error) (*Pipeline, error) {
+		p.Close()
+		return nil, err
+	}
+
+	// UAVMetrics CRD, written through the regional aggregator when one is
+	// configured, with each vehicle's enrollment certificate
+	if cfg.Sinks.CRDEnabled && !cfg.Agent.DryRun {
+		var aggregatorClient *aggregator.Client
+		if cfg.Aggregator.Address != "" {
+			credentials := func(nodeName string) (*tls.Config, error) {
+				vehicleCfg := *cfg
+				vehicleCfg.Agent.NodeName = nodeName
+				return enrollment.ClientTLSConfig(&vehicleCfg, cfg.Aggregator.CAFile)
+			}
+			var err error
+			aggregatorClient, err = aggregator.NewClient(cfg.Aggregator.Address, cfg.Aggregator.Timeout, credentials)
+			if err != nil {
+				return fail(err)
+			}
+		}
+		// The Kubernetes client retries on its own
+		p.Add(NewCRDSink(client, aggregatorClient, cfg, log), Options{Critical: true})
+	}
+
+	if cfg.MQTT.Enabled {
+		mqttSink, err := NewMQTTSink(cfg)
+		if err != nil {
+			return fail(err)
+		}
+		p.Add(mqttSink, retry)
+	}
+
+	if cfg.Sinks.PrometheusEnabled {
+		prometheusSink, err := NewPrometheusSink(cfg.Sinks.PrometheusListen)
+		if err != nil {
+			return fail(err)
+		}
+		p.Add(prometheusSink, retry)
+	}
+
+	if cfg.Sinks.FileEnabled {
+		policy, _ := cfg.Retention.PolicyFor(cfg.Kubernetes.Namespace, config.RetentionFile)
+		fileSink, err := NewFileSink(cfg.Sinks.FilePath, int64(cfg.Sinks.FileMaxSize)<<20, cfg.Sinks.FileMaxBackups, policy, store)
+		if err != nil {
+			return fail(err)
+		}
+		p.Add(fileSink, retry)
+	}
+
+	return p, nil
+}
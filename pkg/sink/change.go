@@ -0,0 +1,56 @@
+package sink
+
+import (
+	"math"
+	"slices"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/geo"
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// significantChange reports whether next differs enough from prev, the
+// sample last written, to be written as well. Fields sealed by field
+// encryption aren't compared.
+func significantChange(prev, next *models.UAVMetrics, cfg config.SinkConfig) bool {
+	if math.Abs(next.Battery.RemainingPercent-prev.Battery.RemainingPercent) >= cfg.CRDMinBatteryChange {
+		return true
+	}
+
+	// Position, including altitude; gaining or losing the fix always counts
+	if prev.GPS.LastUpdate.IsZero() != next.GPS.LastUpdate.IsZero() {
+		return true
+	}
+	horizontal := geo.DistanceMeters(prev.GPS.Latitude, prev.GPS.Longitude, next.GPS.Latitude, next.GPS.Longitude)
+	if math.Hypot(horizontal, next.GPS.Altitude-prev.GPS.Altitude) >= cfg.CRDMinDistance {
+		return true
+	}
+
+	// Health, which also determines the status phase
+	prevHealth, nextHealth := prev.Health, next.Health
+	if (prevHealth == nil) != (nextHealth == nil) {
+		return true
+	}
+	if prevHealth != nil && (prevHealth.Status != nextHealth.Status ||
+		!slices.Equal(prevHealth.Errors, nextHealth.Errors) ||
+		!slices.Equal(prevHealth.Warnings, nextHealth.Warnings)) {
+		return true
+	}
+
+	// Flight state
+	if (prev.Flight == nil) != (next.Flight == nil) {
+		return true
+	}
+	if prev.Flight != nil && (prev.Flight.Armed != next.Flight.Armed ||
+		prev.Flight.IsFlying != next.Flight.IsFlying ||
+		prev.Flight.Mode != next.Flight.Mode) {
+		return true
+	}
+
+	// Sections that started or stopped failing, and power saving
+	sameSection := func(a, b models.CollectionError) bool { return a.Section == b.Section }
+	if !slices.EqualFunc(prev.CollectionErrors, next.CollectionErrors, sameSection) {
+		return true
+	}
+	return (prev.PowerSave == nil) != (next.PowerSave == nil)
+}
@@ -0,0 +1,181 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/aggregator"
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/k8s"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// CRDSink writes samples to the vehicles' UAVMetrics, directly or through
+// the regional aggregator, and keeps what is derived from them in the API
+// server up to date: health transition Events, Node labels and conditions,
+// the status phase and the UAVMetricsHistory snapshots.
+type CRDSink struct {
+	client     *k8s.Client
+	aggregator *aggregator.Client // nil to write directly
+	config     config.K8sConfig
+	changes    config.SinkConfig // change detection thresholds
+	log        *logrus.Logger
+
+	mu    sync.Mutex
+	nodes map[string]*crdNodeState
+}
+
+// crdNodeState is what the sink last published for a vehicle. Each vehicle
+// is published by a single collection loop, so it is only locked to look up.
+type crdNodeState struct {
+	// Last published health status, to record Events on transitions
+	health string
+
+	// Labels last mirrored onto the Node, to patch only on change
+	nodeLabels map[string]string
+
+	// Sample last written to the UAVMetrics and when, for change detection
+	written     *models.UAVMetrics
+	writtenTime time.Time
+
+	// When the last UAVMetricsHistory snapshot was written
+	snapshotTime time.Time
+}
+
+// NewCRDSink creates a sink writing to the UAVMetrics CRD, or reporting to
+// aggregatorClient when it isn't nil
+func NewCRDSink(client *k8s.Client, aggregatorClient *aggregator.Client, cfg *config.Config, log *logrus.Logger) *CRDSink {
+	return &CRDSink{
+		client:     client,
+		aggregator: aggregatorClient,
+		config:     cfg.Kubernetes,
+		changes:    cfg.Sinks,
+		log:        log,
+		nodes:      make(map[string]*crdNodeState),
+	}
+}
+
+// Name implements Sink
+func (s *CRDSink) Name() string {
+	if s.aggregator != nil {
+		return "aggregator"
+	}
+	return "crd"
+}
+
+// Publish implements Sink. Updates overtaken by newer telemetry already
+// stored, and with change detection those without significant changes, are
+// skipped without an error.
+func (s *CRDSink) Publish(ctx context.Context, metrics *models.UAVMetrics) error {
+	state := s.state(ctx, metrics.NodeName)
+
+	// Node labels and conditions are still synced below; they're only
+	// patched when they change
+	skip := s.changes.CRDChangeDetection && state.written != nil &&
+		time.Since(state.writtenTime) < s.changes.CRDMaxSkipInterval &&
+		!significantChange(state.written, metrics, s.changes)
+	if skip {
+		s.log.WithField("nodeName", metrics.NodeName).Debug("Skipped CRD update, no significant change")
+	} else if s.aggregator != nil {
+		if err := s.aggregator.Report(ctx, metrics); err != nil {
+			return fmt.Errorf("failed to report to aggregator: %w", err)
+		}
+	} else if err := s.client.CreateOrUpdateWithRetry(ctx, metrics); err != nil {
+		if errors.Is(err, models.ErrStaleUpdate) {
+			// Newer telemetry was stored while this update was in flight
+			s.log.WithField("nodeName", metrics.NodeName).Warn("Skipped stale update, newer telemetry already stored")
+			return nil
+		}
+		return models.Categorize(models.ErrorCategoryK8sAPI, fmt.Errorf("failed to update CRD: %w", err))
+	}
+	if !skip {
+		state.written, state.writtenTime = metrics, time.Now()
+	}
+
+	// Record health transitions as Events
+	health := models.HealthStatusUnknown
+	if metrics.Health != nil {
+		health = metrics.Health.Status
+	}
+	if state.health != "" && health != state.health && s.config.HealthEvents {
+		if err := s.client.RecordHealthTransition(ctx, metrics, state.health); err != nil {
+			s.log.WithError(err).WithField("nodeName", metrics.NodeName).Warn("Failed to record health transition event")
+		}
+	}
+	state.health = health
+
+	// Mirror key fields onto the Node for nodeAffinity and existing tooling
+	if s.config.NodeLabels {
+		labels := k8s.NodeLabels(metrics, s.config.NodeLabelGeohashPrecision)
+		if !maps.Equal(labels, state.nodeLabels) {
+			if err := s.client.SyncNodeLabels(ctx, metrics.KubeNode(), labels); err != nil {
+				s.log.WithError(err).WithField("nodeName", metrics.NodeName).Warn("Failed to update node labels")
+			} else {
+				state.nodeLabels = labels
+			}
+		}
+	}
+
+	// Node conditions and low-battery taint for the default scheduler
+	if s.config.NodeConditions {
+		if err := s.client.SyncNodeConditions(ctx, metrics); err != nil {
+			s.log.WithError(err).WithField("nodeName", metrics.NodeName).Warn("Failed to update node conditions")
+		}
+	}
+
+	// Update status and conditions (the aggregator writes the phase along
+	// with the spec)
+	if s.aggregator == nil && !skip {
+		if err := s.client.UpdateMetricsStatus(ctx, metrics); err != nil {
+			s.log.WithError(err).WithField("nodeName", metrics.NodeName).Warn("Failed to update status")
+			// Don't return error for status update failures
+		}
+	}
+
+	// Snapshots are taken on schedule, whether or not the sample changed
+	// significantly, so trends have regular points. They aren't written
+	// through the aggregator.
+	if s.aggregator == nil && s.config.SnapshotInterval > 0 {
+		if now := time.Now(); now.Sub(state.snapshotTime) >= s.config.SnapshotInterval {
+			if err := s.client.CreateSnapshot(ctx, metrics, now); err != nil {
+				s.log.WithError(err).WithField("nodeName", metrics.NodeName).Warn("Failed to write UAVMetrics snapshot")
+			} else {
+				state.snapshotTime = now
+			}
+		}
+	}
+	return nil
+}
+
+// state returns the state of a vehicle. The health last published before a
+// restart is read from its UAVMetrics, so the first transition is recorded.
+func (s *CRDSink) state(ctx context.Context, nodeName string) *crdNodeState {
+	s.mu.Lock()
+	state, ok := s.nodes[nodeName]
+	s.mu.Unlock()
+	if ok {
+		return state
+	}
+
+	state = &crdNodeState{}
+	if previous, err := s.client.GetUAVMetrics(ctx, nodeName); err == nil && previous.Health != nil {
+		state.health = previous.Health.Status
+	}
+	s.mu.Lock()
+	s.nodes[nodeName] = state
+	s.mu.Unlock()
+	return state
+}
+
+// Close implements Sink
+func (s *CRDSink) Close() error {
+	if s.aggregator != nil {
+		return s.aggregator.Close()
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/logfile"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/securestore"
+)
+
+// FileSink appends samples as JSON lines to a file on the node, rotated by
+// size, e.g. for ground software tailing it or for copying off after a
+// flight. With a store, each line holds a sample sealed by the store,
+// base64-encoded; ReadFileSink turns the file back into JSON lines.
+type FileSink struct {
+	writer *logfile.Writer
+	store  *securestore.Store
+}
+
+// NewFileSink opens the file at path. It is rotated when it would grow
+// beyond maxSize bytes, keeping maxBackups rotated files (0 for no limit).
+// Rotated files past the retention policy's maximum age are deleted, and
+// files within its minimum age are kept even beyond maxBackups. Samples are
+// encrypted with store unless it is nil.
+func NewFileSink(path string, maxSize int64, maxBackups int, retention config.RetentionPolicy, store *securestore.Store) (*FileSink, error) {
+	writer, err := logfile.Open(path, maxSize, retention.MaxAge, retention.MinAge, maxBackups)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{writer: writer, store: store}, nil
+}
+
+// Name implements Sink
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// Publish implements Sink. Each sample is written with a single write, so
+// lines of concurrently published vehicles don't interleave.
+func (s *FileSink) Publish(ctx context.Context, metrics *models.UAVMetrics) error {
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	if s.store != nil {
+		sealed, err := s.store.Encrypt(data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt sample: %w", err)
+		}
+		data = []byte(base64.StdEncoding.EncodeToString(sealed))
+	}
+	_, err = s.writer.Write(append(data, '\n'))
+	return err
+}
+
+// Close implements Sink
+func (s *FileSink) Close() error {
+	return s.writer.Close()
+}
+
+// ReadFileSink copies the samples of a file written by FileSink from r to w
+// as JSON lines, decrypting them with store. Lines written before
+// encryption was enabled are copied as they are.
+func ReadFileSink(r io.Reader, store *securestore.Store, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		if len(data) > 0 && data[0] != '{' {
+			if store == nil {
+				return fmt.Errorf("line %d: sample is encrypted and no key is configured", line)
+			}
+			sealed, err := base64.StdEncoding.DecodeString(string(data))
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			if data, err = store.Decrypt(sealed); err != nil {
+				if errors.Is(err, securestore.ErrNotEncrypted) {
+					return fmt.Errorf("line %d: neither JSON nor an encrypted sample", line)
+				}
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
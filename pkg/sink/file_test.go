@@ -0,0 +1,93 @@
+package sink_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/securestore"
+	"github.com/k3suav/uav-monitor/pkg/sink"
+)
+
+func TestFileSinkEncryptsAtRest(t *testing.T) {
+	store, err := securestore.New(bytes.Repeat([]byte{7}, securestore.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "samples.jsonl")
+	fileSink, err := sink.NewFileSink(path, 0, 0, config.RetentionPolicy{}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	published := []*models.UAVMetrics{
+		{NodeName: "uav-node-1", GPS: models.GPSData{Latitude: 47.3977, Longitude: 8.5456}},
+		{NodeName: "uav-node-2", GPS: models.GPSData{Latitude: -33.8688, Longitude: 151.2093}},
+	}
+	for _, metrics := range published {
+		if err := fileSink.Publish(context.Background(), metrics); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fileSink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("file mode = %v, want 0600", mode)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, plaintext := range []string{"uav-node-1", "47.3977", "nodeName", "{"} {
+		if bytes.Contains(data, []byte(plaintext)) {
+			t.Errorf("file contains plaintext %q", plaintext)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != len(published) {
+		t.Fatalf("file has %d lines, want %d", len(lines), len(published))
+	}
+	for i, line := range lines {
+		sealed, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			t.Fatalf("line %d is not base64: %v", i+1, err)
+		}
+		if _, err := store.Decrypt(sealed); err != nil {
+			t.Errorf("line %d doesn't decrypt: %v", i+1, err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := sink.ReadFileSink(bytes.NewReader(data), store, &out); err != nil {
+		t.Fatal(err)
+	}
+	decoder := json.NewDecoder(&out)
+	for _, want := range published {
+		var got models.UAVMetrics
+		if err := decoder.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.NodeName != want.NodeName || got.GPS.Latitude != want.GPS.Latitude || got.GPS.Longitude != want.GPS.Longitude {
+			t.Errorf("read back %s at %v,%v, want %s at %v,%v", got.NodeName, got.GPS.Latitude, got.GPS.Longitude,
+				want.NodeName, want.GPS.Latitude, want.GPS.Longitude)
+		}
+	}
+
+	if err := sink.ReadFileSink(bytes.NewReader(data), nil, &out); err == nil {
+		t.Error("reading encrypted samples without a key succeeded")
+	}
+}
@@ -0,0 +1,40 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/k3suav/uav-monitor/pkg/config"
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/mqtt"
+)
+
+// MQTTSink publishes samples to an MQTT broker
+type MQTTSink struct {
+	publisher *mqtt.Publisher
+}
+
+// NewMQTTSink creates a sink publishing to the configured broker, which it
+// starts connecting to in the background
+func NewMQTTSink(cfg *config.Config) (*MQTTSink, error) {
+	publisher, err := mqtt.NewPublisher(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &MQTTSink{publisher: publisher}, nil
+}
+
+// Name implements Sink
+func (s *MQTTSink) Name() string {
+	return "mqtt"
+}
+
+// Publish implements Sink
+func (s *MQTTSink) Publish(ctx context.Context, metrics *models.UAVMetrics) error {
+	return s.publisher.Publish(ctx, metrics)
+}
+
+// Close implements Sink
+func (s *MQTTSink) Close() error {
+	s.publisher.Close()
+	return nil
+}
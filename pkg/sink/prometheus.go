@@ -0,0 +1,198 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+)
+
+// PrometheusSink serves the latest sample of each vehicle on /metrics in the
+// Prometheus text exposition format
+type PrometheusSink struct {
+	server *http.Server
+
+	mu      sync.Mutex
+	latest  map[string]*models.UAVMetrics
+	updated map[string]time.Time
+}
+
+// prometheusGauge is a gauge exported for every vehicle
+type prometheusGauge struct {
+	name  string
+	help  string
+	value func(m *models.UAVMetrics) (float64, bool) // false when unknown
+}
+
+var prometheusGauges = []prometheusGauge{
+	{"uav_battery_remaining_percent", "Remaining battery capacity.", func(m *models.UAVMetrics) (float64, bool) {
+		return m.Battery.RemainingPercent, true
+	}},
+	{"uav_battery_voltage_volts", "Battery pack voltage.", func(m *models.UAVMetrics) (float64, bool) {
+		return m.Battery.Voltage, m.Battery.Voltage != 0
+	}},
+	{"uav_battery_current_amperes", "Battery current draw.", func(m *models.UAVMetrics) (float64, bool) {
+		return m.Battery.Current, m.Battery.Voltage != 0
+	}},
+	{"uav_battery_cell_imbalance_volts", "Spread between the highest and lowest cell voltages.", func(m *models.UAVMetrics) (float64, bool) {
+		return m.Battery.CellImbalance, len(m.Battery.CellVoltages) > 0
+	}},
+	{"uav_gps_latitude_degrees", "GPS latitude.", func(m *models.UAVMetrics) (float64, bool) {
+		return m.GPS.Latitude, !m.GPS.LastUpdate.IsZero()
+	}},
+	{"uav_gps_longitude_degrees", "GPS longitude.", func(m *models.UAVMetrics) (float64, bool) {
+		return m.GPS.Longitude, !m.GPS.LastUpdate.IsZero()
+	}},
+	{"uav_gps_altitude_meters", "GPS altitude.", func(m *models.UAVMetrics) (float64, bool) {
+		return m.GPS.Altitude, !m.GPS.LastUpdate.IsZero()
+	}},
+	{"uav_gps_satellites", "Satellites used in the GPS fix.", func(m *models.UAVMetrics) (float64, bool) {
+		return float64(m.GPS.Satellites), !m.GPS.LastUpdate.IsZero()
+	}},
+	{"uav_flight_armed", "Whether the vehicle is armed (1) or not (0).", func(m *models.UAVMetrics) (float64, bool) {
+		return boolValue(m.Flight != nil && m.Flight.Armed), m.Flight != nil
+	}},
+	{"uav_flight_flying", "Whether the vehicle is flying (1) or not (0).", func(m *models.UAVMetrics) (float64, bool) {
+		return boolValue(m.Flight != nil && m.Flight.IsFlying), m.Flight != nil
+	}},
+	{"uav_network_latency_milliseconds", "Network latency to the cluster.", func(m *models.UAVMetrics) (float64, bool) {
+		if m.Network == nil {
+			return 0, false
+		}
+		return m.Network.Latency, true
+	}},
+	{"uav_network_packet_loss_percent", "Network packet loss to the cluster.", func(m *models.UAVMetrics) (float64, bool) {
+		if m.Network == nil {
+			return 0, false
+		}
+		return m.Network.PacketLoss, true
+	}},
+	{"uav_health_score", "Health score (0-100).", func(m *models.UAVMetrics) (float64, bool) {
+		if m.Health == nil || m.Health.Score == nil {
+			return 0, false
+		}
+		return *m.Health.Score, true
+	}},
+}
+
+// NewPrometheusSink starts serving /metrics on listen
+func NewPrometheusSink(listen string) (*PrometheusSink, error) {
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", listen, err)
+	}
+
+	s := &PrometheusSink{
+		latest:  make(map[string]*models.UAVMetrics),
+		updated: make(map[string]time.Time),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go s.server.Serve(ln)
+	return s, nil
+}
+
+// Name implements Sink
+func (s *PrometheusSink) Name() string {
+	return "prometheus"
+}
+
+// Publish implements Sink
+func (s *PrometheusSink) Publish(ctx context.Context, metrics *models.UAVMetrics) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest[metrics.NodeName] = metrics
+	s.updated[metrics.NodeName] = time.Now()
+	return nil
+}
+
+// handleMetrics writes every gauge of every vehicle, sorted by node name
+func (s *PrometheusSink) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	nodes := make([]string, 0, len(s.latest))
+	for node := range s.latest {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	latest := make([]*models.UAVMetrics, len(nodes))
+	updated := make([]time.Time, len(nodes))
+	for i, node := range nodes {
+		latest[i], updated[i] = s.latest[node], s.updated[node]
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, gauge := range prometheusGauges {
+		writeHeader(w, gauge.name, gauge.help)
+		for i, m := range latest {
+			if value, ok := gauge.value(m); ok {
+				writeSample(w, gauge.name, nodes[i], nil, value)
+			}
+		}
+	}
+
+	writeHeader(w, "uav_health_status", "Health status of the vehicle (1 for the current status).")
+	for i, m := range latest {
+		status := models.HealthStatusUnknown
+		if m.Health != nil {
+			status = m.Health.Status
+		}
+		writeSample(w, "uav_health_status", nodes[i], []string{"status", status}, 1)
+	}
+
+	writeHeader(w, "uav_sample_timestamp_seconds", "Time the latest sample was published.")
+	for i := range latest {
+		writeSample(w, "uav_sample_timestamp_seconds", nodes[i], nil, float64(updated[i].UnixMilli())/1000)
+	}
+}
+
+func writeHeader(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// writeSample writes one sample labelled with the node name and the extra
+// label name/value pairs
+func writeSample(w io.Writer, name, node string, labels []string, value float64) {
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteString(`{node="`)
+	b.WriteString(escapeLabel(node))
+	b.WriteByte('"')
+	for i := 0; i+1 < len(labels); i += 2 {
+		b.WriteString(`,` + labels[i] + `="`)
+		b.WriteString(escapeLabel(labels[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteString("} ")
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte('\n')
+	io.WriteString(w, b.String())
+}
+
+// escapeLabel escapes a label value for the text exposition format
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Close implements Sink
+func (s *PrometheusSink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,150 @@
+// Package sink fans the samples published by the agent out to their
+// destinations (the UAVMetrics CRD, MQTT, Prometheus, a file on the node).
+// Each sink is retried with its own backoff, so a slow or unreachable
+// destination doesn't hold back the others.
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/k3suav/uav-monitor/pkg/models"
+	"github.com/k3suav/uav-monitor/pkg/resilience"
+	"github.com/sirupsen/logrus"
+)
+
+// Sink is a destination of published samples. Publish is called
+// concurrently for different vehicles.
+type Sink interface {
+	// Name identifies the sink in logs and traces
+	Name() string
+
+	// Publish delivers one sample
+	Publish(ctx context.Context, metrics *models.UAVMetrics) error
+
+	// Close releases the sink's resources
+	Close() error
+}
+
+// Options control how the pipeline publishes to a sink
+type Options struct {
+	// A failure of a critical sink fails the collection cycle; failures of
+	// other sinks are only logged
+	Critical bool
+
+	// Attempts per sample (at least one) and the backoff between them
+	Attempts   int
+	RetryDelay time.Duration
+	MaxDelay   time.Duration
+}
+
+// TraceFunc starts a span for one step of a collection cycle; the returned
+// function ends it
+type TraceFunc func(ctx context.Context, stage string) (context.Context, func(error))
+
+// Pipeline publishes every sample to all its sinks concurrently
+type Pipeline struct {
+	sinks []*stage
+	log   *logrus.Logger
+
+	// Traces each sink's publish (nil disables)
+	Trace TraceFunc
+}
+
+// stage is a sink with its options
+type stage struct {
+	sink    Sink
+	options Options
+}
+
+// NewPipeline creates an empty pipeline
+func NewPipeline(log *logrus.Logger) *Pipeline {
+	return &Pipeline{log: log}
+}
+
+// Add adds a sink to the pipeline. Call it before the pipeline is shared.
+func (p *Pipeline) Add(sink Sink, options Options) {
+	if options.Attempts < 1 {
+		options.Attempts = 1
+	}
+	p.sinks = append(p.sinks, &stage{sink: sink, options: options})
+}
+
+// Names returns the names of the pipeline's sinks
+func (p *Pipeline) Names() []string {
+	names := make([]string, 0, len(p.sinks))
+	for _, s := range p.sinks {
+		names = append(names, s.sink.Name())
+	}
+	return names
+}
+
+// Publish publishes metrics to every sink and waits for all of them. It
+// returns the errors of critical sinks; other failures are logged.
+func (p *Pipeline) Publish(ctx context.Context, metrics *models.UAVMetrics) error {
+	errs := make([]error, len(p.sinks))
+	var wg sync.WaitGroup
+	for i, s := range p.sinks {
+		wg.Add(1)
+		go func(i int, s *stage) {
+			defer wg.Done()
+			errs[i] = p.publish(ctx, s, metrics)
+		}(i, s)
+	}
+	wg.Wait()
+
+	var critical []error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		s := p.sinks[i]
+		if s.options.Critical {
+			critical = append(critical, err)
+			continue
+		}
+		p.log.WithError(err).WithFields(logrus.Fields{
+			"nodeName": metrics.NodeName,
+			"sink":     s.sink.Name(),
+		}).Warn("Failed to publish to sink")
+	}
+	return errors.Join(critical...)
+}
+
+// publish publishes metrics to one sink, retrying with backoff
+func (p *Pipeline) publish(ctx context.Context, s *stage, metrics *models.UAVMetrics) error {
+	end := func(error) {}
+	if p.Trace != nil {
+		ctx, end = p.Trace(ctx, s.sink.Name()+" sink")
+	}
+
+	var err error
+	backoff := resilience.NewBackoff(s.options.RetryDelay, s.options.MaxDelay)
+	for attempt := 1; ; attempt++ {
+		if err = s.sink.Publish(ctx, metrics); err == nil || attempt >= s.options.Attempts {
+			break
+		}
+		if backoff.Wait(ctx) != nil {
+			break
+		}
+	}
+	end(err)
+	if err != nil {
+		return fmt.Errorf("%s sink: %w", s.sink.Name(), err)
+	}
+	return nil
+}
+
+// Close closes every sink
+func (p *Pipeline) Close() error {
+	var errs []error
+	for _, s := range p.sinks {
+		if err := s.sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s sink: %w", s.sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,243 @@
+package terrain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/k3suav/uav-monitor/pkg/geo"
+)
+
+const (
+	// earthRadius 地球半径（米）
+	earthRadius = geo.EarthRadiusMeters
+
+	// refractionK 无线电传播的等效地球半径系数（标准大气 4/3）
+	refractionK = 4.0 / 3.0
+
+	// voidValue SRTM 数据空洞标记
+	voidValue = -32768
+
+	// maxSamples 单条视距路径最多采样点数
+	maxSamples = 4000
+)
+
+// ErrNoData 坐标所在瓦片缺失或该处全为数据空洞
+var ErrNoData = errors.New("no terrain data")
+
+// DEM 离线数字高程模型
+// 从目录加载 SRTM .hgt 瓦片（每个 1°×1°，文件名如 N30E120.hgt，
+// 1201×1201 为 3 弧秒、3601×3601 为 1 弧秒），按需加载并缓存。
+// 高程为海拔（米，EGM96 大地水准面），与 GPS 海拔高度一致
+type DEM struct {
+	dir string
+
+	mu    sync.Mutex
+	tiles map[string]*tile // 缺失的瓦片缓存为 nil，避免反复读盘
+}
+
+// tile 一个 1°×1° 瓦片，第 0 行为北边界
+type tile struct {
+	size int
+	data []int16
+}
+
+// NewDEM 创建从 dir 加载瓦片的高程模型
+func NewDEM(dir string) (*DEM, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("terrain directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("terrain directory %s is not a directory", dir)
+	}
+	return &DEM{
+		dir:   dir,
+		tiles: make(map[string]*tile),
+	}, nil
+}
+
+// Elevation 返回坐标处的地形海拔（米），在相邻栅格点间双线性插值
+func (d *DEM) Elevation(lat, lon float64) (float64, error) {
+	latFloor := math.Floor(lat)
+	lonFloor := math.Floor(lon)
+	t, err := d.tile(int(latFloor), int(lonFloor))
+	if err != nil {
+		return 0, err
+	}
+
+	last := float64(t.size - 1)
+	row := (latFloor + 1 - lat) * last
+	col := (lon - lonFloor) * last
+	r0, c0 := int(math.Floor(row)), int(math.Floor(col))
+	r1, c1 := minInt(r0+1, t.size-1), minInt(c0+1, t.size-1)
+	fr, fc := row-float64(r0), col-float64(c0)
+
+	// 空洞点不参与插值，按剩余点的权重归一化
+	var sum, weightSum float64
+	for _, p := range []struct {
+		r, c int
+		w    float64
+	}{
+		{r0, c0, (1 - fr) * (1 - fc)},
+		{r0, c1, (1 - fr) * fc},
+		{r1, c0, fr * (1 - fc)},
+		{r1, c1, fr * fc},
+	} {
+		v := t.data[p.r*t.size+p.c]
+		if v == voidValue {
+			continue
+		}
+		sum += float64(v) * p.w
+		weightSum += p.w
+	}
+	if weightSum == 0 {
+		return 0, ErrNoData
+	}
+	return sum / weightSum, nil
+}
+
+// HeightAboveGround 返回海拔 altitude（米）在坐标处的离地高度
+func (d *DEM) HeightAboveGround(lat, lon, altitude float64) (float64, error) {
+	ground, err := d.Elevation(lat, lon)
+	if err != nil {
+		return 0, err
+	}
+	return altitude - ground, nil
+}
+
+// LOSResult 视距分析结果
+type LOSResult struct {
+	// Clear 整条路径的净空均不小于要求值
+	Clear bool
+
+	// Distance 两点间距离（米）
+	Distance float64
+
+	// MinClearance 路径上视线与地形的最小净空（米），负值表示被遮挡
+	MinClearance float64
+
+	// ObstructionLat/ObstructionLon 最小净空所在位置
+	ObstructionLat float64
+	ObstructionLon float64
+}
+
+// LineOfSight 分析两点（海拔高度，米）之间的视距
+// 沿连线按栅格间距采样，视线高度扣除地球曲率（考虑大气折射），
+// 净空不小于 clearance（米）时视为通视
+func (d *DEM) LineOfSight(lat1, lon1, alt1, lat2, lon2, alt2, clearance float64) (*LOSResult, error) {
+	distance := geo.DistanceMeters(lat1, lon1, lat2, lon2)
+	result := &LOSResult{
+		Distance:       distance,
+		MinClearance:   math.Inf(1),
+		ObstructionLat: lat1,
+		ObstructionLon: lon1,
+	}
+
+	t, err := d.tile(int(math.Floor(lat1)), int(math.Floor(lon1)))
+	if err != nil {
+		return nil, err
+	}
+	spacing := 3600.0 / float64(t.size-1) * 30.87 // 栅格间距（米）
+	samples := int(math.Ceil(distance / spacing))
+	if samples > maxSamples {
+		samples = maxSamples
+	}
+	if samples < 2 {
+		samples = 2
+	}
+
+	effectiveRadius := refractionK * earthRadius
+	for i := 1; i < samples; i++ {
+		f := float64(i) / float64(samples)
+		lat := lat1 + (lat2-lat1)*f
+		lon := lon1 + (lon2-lon1)*f
+
+		ground, err := d.Elevation(lat, lon)
+		if err != nil {
+			return nil, err
+		}
+		d1, d2 := distance*f, distance*(1-f)
+		beam := alt1 + (alt2-alt1)*f - d1*d2/(2*effectiveRadius)
+
+		if c := beam - ground; c < result.MinClearance {
+			result.MinClearance = c
+			result.ObstructionLat, result.ObstructionLon = lat, lon
+		}
+	}
+	result.Clear = result.MinClearance >= clearance
+	return result, nil
+}
+
+// tile 加载（或从缓存取得）左下角为 lat/lon 的瓦片
+func (d *DEM) tile(lat, lon int) (*tile, error) {
+	name := tileName(lat, lon)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if t, ok := d.tiles[name]; ok {
+		if t == nil {
+			return nil, fmt.Errorf("%w: tile %s not found", ErrNoData, name)
+		}
+		return t, nil
+	}
+
+	t, err := loadTile(filepath.Join(d.dir, name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			d.tiles[name] = nil
+			return nil, fmt.Errorf("%w: tile %s not found", ErrNoData, name)
+		}
+		return nil, err
+	}
+	d.tiles[name] = t
+	return t, nil
+}
+
+// loadTile 读取 .hgt 文件（大端 int16，行优先）
+func loadTile(path string) (*tile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var size int
+	switch len(raw) {
+	case 1201 * 1201 * 2:
+		size = 1201
+	case 3601 * 3601 * 2:
+		size = 3601
+	default:
+		return nil, fmt.Errorf("invalid terrain tile %s: unexpected size %d bytes", path, len(raw))
+	}
+
+	data := make([]int16, size*size)
+	for i := range data {
+		data[i] = int16(binary.BigEndian.Uint16(raw[2*i:]))
+	}
+	return &tile{size: size, data: data}, nil
+}
+
+// tileName 返回 SRTM 瓦片文件名，如 N30E120.hgt、S01W078.hgt
+func tileName(lat, lon int) string {
+	ns, ew := 'N', 'E'
+	if lat < 0 {
+		ns, lat = 'S', -lat
+	}
+	if lon < 0 {
+		ew, lon = 'W', -lon
+	}
+	return fmt.Sprintf("%c%02d%c%03d.hgt", ns, lat, ew, lon)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}